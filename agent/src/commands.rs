@@ -1,6 +1,11 @@
 use serde::{Deserialize, Serialize};
+use std::collections::HashMap;
+use std::future::Future;
+use std::process::Stdio;
+use std::sync::{Arc, Mutex};
 use std::time::Duration;
 use tokio::process::Command as TokioCommand;
+use tokio::sync::oneshot;
 
 use crate::config::Config;
 
@@ -21,6 +26,8 @@ pub struct IncomingCommand {
     pub instances: Option<u32>,
     /// load balancing stratejisi
     pub strategy: Option<String>,
+    /// exec katalog girdisinin backend'de doğrulanmış parametreleri
+    pub args: Option<serde_json::Map<String, serde_json::Value>>,
 }
 
 #[derive(Debug, Serialize)]
@@ -66,6 +73,62 @@ impl IncomingCommand {
         })
         .unwrap_or_default()
     }
+
+    /// Backend'in iptal mesajıyla durdurulan komutun sonucu.
+    pub fn cancelled_json(&self) -> String {
+        serde_json::to_string(&CommandResult {
+            msg_type: "result".to_string(),
+            command_id: self.command_id.clone(),
+            status: "cancelled".to_string(),
+            error: Some("komut iptal edildi".to_string()),
+            output: None,
+        })
+        .unwrap_or_default()
+    }
+}
+
+/// Çalışmakta olan komutlar — backend'in cancel mesajı command_id ile eşleşen komutu durdurur.
+#[derive(Clone, Default)]
+pub struct RunningCommands {
+    inner: Arc<Mutex<HashMap<String, oneshot::Sender<()>>>>,
+}
+
+impl RunningCommands {
+    /// Komutu iptal edilebilir olarak kaydeder; dönen alıcı `cancellable`'a verilir.
+    pub fn register(&self, command_id: &str) -> oneshot::Receiver<()> {
+        let (tx, rx) = oneshot::channel();
+        self.lock().insert(command_id.to_string(), tx);
+        rx
+    }
+
+    /// Tamamlanan komutu kayıttan düşer.
+    pub fn finish(&self, command_id: &str) {
+        self.lock().remove(command_id);
+    }
+
+    /// Komut çalışıyorsa iptal eder; çalışan komut yoksa false döner.
+    pub fn cancel(&self, command_id: &str) -> bool {
+        match self.lock().remove(command_id) {
+            Some(tx) => tx.send(()).is_ok(),
+            None => false,
+        }
+    }
+
+    fn lock(&self) -> std::sync::MutexGuard<'_, HashMap<String, oneshot::Sender<()>>> {
+        self.inner.lock().unwrap_or_else(|e| e.into_inner())
+    }
+}
+
+/// fut'u iptal sinyali gelene kadar çalıştırır; iptal edilirse None döner. Bırakılan komutun
+/// süreç grubu run_shell tarafından sonlandırılır.
+pub async fn cancellable<T>(
+    fut: impl Future<Output = T>,
+    cancelled: oneshot::Receiver<()>,
+) -> Option<T> {
+    tokio::select! {
+        out = fut => Some(out),
+        Ok(()) = cancelled => None,
+    }
 }
 
 /// İzin verilen komut listesi (allowlist). Bu liste dışında hiçbir komut çalıştırılmaz.
@@ -153,36 +216,36 @@ pub async fn execute(cmd: &IncomingCommand, config: &Config) -> Result<Option<St
             let timeout = cmd.timeout_sec.unwrap_or(30);
 
             // Güvenlik: keyfi shell komutu YASAK.
-            // Yalnızca izin verilen token → shell komutu eşlemeleri çalıştırılır.
-            // Bu liste backend'deki allowedExecCommands ile senkronize tutulmalıdır.
-            let shell_cmd = match token.as_str() {
-                "status"       => "systemctl status || service --status-all 2>&1 | head -30",
-                "logs"         => "journalctl -n 100 --no-pager 2>/dev/null || tail -n 100 /var/log/syslog 2>/dev/null || echo 'log kaynağı bulunamadı'",
-                "health-check" => "curl -sf http://localhost:8080/health 2>/dev/null || echo 'health endpoint erişilemiyor'",
-                "version"      => "uname -r && cat /etc/os-release 2>/dev/null | head -5",
-                "uptime"       => "uptime",
-                "df-h"         => "df -h",
-                "free-m"       => "free -m",
-                "top-snapshot" => "ps aux --sort=-%cpu | head -15",
-                _ => {
+            // Yalnızca yerleşik veya yerel katalogda (NANONET_EXEC_CATALOG) tanımlı adlar
+            // çalıştırılır; backend yalnızca adı ve doğrulanmış parametreleri gönderir.
+            let shell_cmd = match builtin_exec(&token)
+                .map(str::to_string)
+                .or_else(|| custom_exec_catalog(config).remove(&token))
+            {
+                Some(c) => c,
+                None => {
                     tracing::warn!(
                         "[{}] İzin verilmeyen exec token reddedildi: '{}'",
-                        cmd.command_id, token
+                        cmd.command_id,
+                        token
                     );
                     return Err(format!(
-                        "izin verilmeyen komut: '{}' — geçerliler: status, logs, health-check, version, uptime, df-h, free-m, top-snapshot",
-                        token
+                        "izin verilmeyen komut: '{}' — geçerliler: {}",
+                        token,
+                        exec_command_names(config).join(", ")
                     ));
                 }
             };
+            let envs = exec_env(cmd.args.as_ref())?;
 
             tracing::info!(
-                "[{}] exec çalıştırılıyor (token: '{}', timeout: {}s)",
+                "[{}] exec çalıştırılıyor (token: '{}', timeout: {}s, args: {})",
                 cmd.command_id,
                 token,
-                timeout
+                timeout,
+                envs.len()
             );
-            run_shell(shell_cmd, timeout).await
+            run_shell_with_env(&shell_cmd, timeout, &envs).await
         }
 
         "scale" => {
@@ -229,16 +292,129 @@ pub async fn execute(cmd: &IncomingCommand, config: &Config) -> Result<Option<St
     }
 }
 
+/// Yerleşik exec diagnostikleri — backend'deki builtinExecCommands ile senkronize tutulmalıdır.
+const BUILTIN_EXEC: &[(&str, &str)] = &[
+    ("status", "systemctl status || service --status-all 2>&1 | head -30"),
+    ("logs", "journalctl -n 100 --no-pager 2>/dev/null || tail -n 100 /var/log/syslog 2>/dev/null || echo 'log kaynağı bulunamadı'"),
+    ("health-check", "curl -sf http://localhost:8080/health 2>/dev/null || echo 'health endpoint erişilemiyor'"),
+    ("version", "uname -r && cat /etc/os-release 2>/dev/null | head -5"),
+    ("uptime", "uptime"),
+    ("df-h", "df -h"),
+    ("free-m", "free -m"),
+    ("top-snapshot", "ps aux --sort=-%cpu | head -15"),
+];
+
+fn builtin_exec(name: &str) -> Option<&'static str> {
+    BUILTIN_EXEC
+        .iter()
+        .find(|(n, _)| *n == name)
+        .map(|(_, cmd)| *cmd)
+}
+
+fn valid_exec_name(name: &str) -> bool {
+    let mut chars = name.chars();
+    matches!(chars.next(), Some(c) if c.is_ascii_lowercase())
+        && (2..=50).contains(&name.len())
+        && chars.all(|c| c.is_ascii_lowercase() || c.is_ascii_digit() || c == '-')
+}
+
+/// Yerel exec kataloğu: NANONET_EXEC_CATALOG dosyasındaki ad → shell komutu eşlemesi.
+/// Dosya her çağrıda okunur; agent yeniden başlatılmadan girdi eklenebilir. Yerleşik adlar
+/// ve geçersiz adlar yok sayılır.
+pub fn custom_exec_catalog(config: &Config) -> HashMap<String, String> {
+    let Some(path) = config.exec_catalog.as_deref() else {
+        return HashMap::new();
+    };
+    let parsed = std::fs::read_to_string(path)
+        .map_err(|e| e.to_string())
+        .and_then(|raw| {
+            serde_json::from_str::<HashMap<String, String>>(&raw).map_err(|e| e.to_string())
+        });
+    match parsed {
+        Ok(entries) => entries
+            .into_iter()
+            .filter(|(name, _)| valid_exec_name(name) && builtin_exec(name).is_none())
+            .collect(),
+        Err(e) => {
+            tracing::warn!(path = %path, error = %e, "Exec kataloğu okunamadı");
+            HashMap::new()
+        }
+    }
+}
+
+/// Agent'ın çalıştırabildiği tüm exec adları — hello mesajında bildirilir.
+pub fn exec_command_names(config: &Config) -> Vec<String> {
+    let mut names: Vec<String> = BUILTIN_EXEC.iter().map(|(n, _)| n.to_string()).collect();
+    let mut custom: Vec<String> = custom_exec_catalog(config).into_keys().collect();
+    custom.sort();
+    names.extend(custom);
+    names
+}
+
+/// exec parametrelerini NANONET_ARG_<AD> ortam değişkenlerine çevirir. Değerler komut
+/// metnine hiçbir zaman eklenmez; katalogdaki komut bunları "$NANONET_ARG_AD" olarak okur.
+fn exec_env(
+    args: Option<&serde_json::Map<String, serde_json::Value>>,
+) -> Result<Vec<(String, String)>, String> {
+    let mut envs = Vec::new();
+    for (name, value) in args.into_iter().flatten() {
+        let valid_name = matches!(name.chars().next(), Some(c) if c.is_ascii_lowercase())
+            && name
+                .chars()
+                .all(|c| c.is_ascii_lowercase() || c.is_ascii_digit() || c == '_');
+        if !valid_name {
+            return Err(format!("geçersiz parametre adı: {}", name));
+        }
+        let value = match value {
+            serde_json::Value::String(s) => s.clone(),
+            serde_json::Value::Number(n) => n.to_string(),
+            serde_json::Value::Bool(b) => b.to_string(),
+            _ => return Err(format!("desteklenmeyen parametre değeri: {}", name)),
+        };
+        if value.chars().any(char::is_control) {
+            return Err(format!("parametre kontrol karakteri içeremez: {}", name));
+        }
+        envs.push((format!("NANONET_ARG_{}", name.to_ascii_uppercase()), value));
+    }
+    Ok(envs)
+}
+
 /// Belirtilen shell komutunu çalıştırır, stdout döner.
 async fn run_shell(cmd: &str, timeout_sec: u64) -> Result<Option<String>, String> {
-    let result = tokio::time::timeout(
-        Duration::from_secs(timeout_sec),
-        TokioCommand::new("sh").arg("-c").arg(cmd).output(),
-    )
-    .await;
+    run_shell_with_env(cmd, timeout_sec, &[]).await
+}
+
+async fn run_shell_with_env(
+    cmd: &str,
+    timeout_sec: u64,
+    envs: &[(String, String)],
+) -> Result<Option<String>, String> {
+    // Komut kendi süreç grubunda çalışır; zaman aşımı veya iptalde sh'nin başlattığı tüm
+    // süreçler birlikte sonlandırılır.
+    let mut command = TokioCommand::new("sh");
+    command
+        .arg("-c")
+        .arg(cmd)
+        .envs(envs.iter().map(|(k, v)| (k.as_str(), v.as_str())))
+        .stdin(Stdio::null())
+        .stdout(Stdio::piped())
+        .stderr(Stdio::piped())
+        .kill_on_drop(true);
+    #[cfg(unix)]
+    command.process_group(0);
+    let child = command
+        .spawn()
+        .map_err(|e| format!("Komut başlatılamadı: {}", e))?;
+    let mut group = ProcessGroup(child.id());
+
+    let result =
+        tokio::time::timeout(Duration::from_secs(timeout_sec), child.wait_with_output()).await;
 
     match result {
         Ok(Ok(out)) => {
+            // Tamamlanan komutun arka plana bıraktığı süreçlere (örn. start ile başlatılan
+            // servis) dokunulmaz.
+            group.release();
             let stdout = String::from_utf8_lossy(&out.stdout).trim().to_string();
             let stderr = String::from_utf8_lossy(&out.stderr).trim().to_string();
 
@@ -260,7 +436,64 @@ async fn run_shell(cmd: &str, timeout_sec: u64) -> Result<Option<String>, String
                 Err(msg)
             }
         }
-        Ok(Err(e)) => Err(format!("Komut başlatılamadı: {}", e)),
+        Ok(Err(e)) => Err(format!("Komut çalıştırılamadı: {}", e)),
         Err(_) => Err(format!("Zaman aşımı ({}s)", timeout_sec)),
     }
 }
+
+/// Çalışan komutun süreç grubu; serbest bırakılmadan düşürülürse gruptaki süreçler öldürülür.
+struct ProcessGroup(Option<u32>);
+
+impl ProcessGroup {
+    fn release(&mut self) {
+        self.0 = None;
+    }
+}
+
+#[cfg(unix)]
+impl Drop for ProcessGroup {
+    fn drop(&mut self) {
+        if let Some(pgid) = self.0.filter(|&id| id > 0) {
+            // Grup zaten boşsa ESRCH döner; sonuç önemsizdir.
+            unsafe {
+                libc::killpg(pgid as libc::pid_t, libc::SIGKILL);
+            }
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[tokio::test]
+    async fn cancel_kills_running_command() {
+        let marker = std::env::temp_dir().join(format!("nanonet-cancel-{}", uuid::Uuid::new_v4()));
+        let running = RunningCommands::default();
+        let cancelled = running.register("c1");
+        let cmd = format!("sleep 1; touch {}", marker.display());
+        let task = tokio::spawn(async move { cancellable(run_shell(&cmd, 10), cancelled).await });
+
+        tokio::time::sleep(Duration::from_millis(200)).await;
+        assert!(running.cancel("c1"));
+        assert!(task.await.unwrap().is_none());
+        assert!(!running.cancel("c1"), "iptal edilen komut kayıttan düşer");
+
+        tokio::time::sleep(Duration::from_millis(1500)).await;
+        assert!(
+            !marker.exists(),
+            "iptal edilen komutun süreçleri sonlandırılmalı"
+        );
+    }
+
+    #[tokio::test]
+    async fn finished_command_is_not_cancelled() {
+        let running = RunningCommands::default();
+        let cancelled = running.register("c2");
+        let out = cancellable(run_shell("echo ok", 5), cancelled).await;
+        running.finish("c2");
+
+        assert_eq!(out, Some(Ok(Some("ok".to_string()))));
+        assert!(!running.cancel("c2"));
+    }
+}
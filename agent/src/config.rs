@@ -70,6 +70,36 @@ pub struct Config {
     #[arg(long, default_value = "0", env = "NANONET_AGENT_PORT")]
     pub agent_port: u16,
 
+    /// Agent kimliği — yeniden bağlantılarda aynı kalmalı (varsayılan: hostname)
+    #[arg(long, env = "NANONET_AGENT_ID")]
+    pub agent_id: Option<String>,
+
+    /// Komut imzalarını doğrulamak için backend açık anahtar(lar)ı — base64, virgülle ayrılmış.
+    /// Verilmezse backend HTTPS ise /api/v1/agent/signing-keys'ten alınır. Anahtar bilindiği
+    /// anda imzasız command/cancel/config mesajları reddedilir.
+    #[arg(long, env = "NANONET_COMMAND_PUBKEY")]
+    pub command_pubkey: Option<String>,
+
+    /// Anahtar bilinmese de (düz HTTP backend, anahtar alınamadı) imzasız komutları reddet
+    #[arg(long, env = "NANONET_REQUIRE_SIGNED_COMMANDS")]
+    pub require_signed_commands: bool,
+
+    /// Yerel exec kataloğu — {"ad": "shell komutu"} biçiminde JSON dosyası. Backend'deki servis
+    /// kataloğunda aynı adla tanımlanan girdiler bu komutları çalıştırır; parametreler
+    /// NANONET_ARG_<AD> ortam değişkenleri olarak verilir.
+    #[arg(long, env = "NANONET_EXEC_CATALOG")]
+    pub exec_catalog: Option<String>,
+
+    /// Takip edilip backend'e gönderilecek log dosyaları — mutlak yollar, virgülle ayrılmış.
+    /// Backend'den yapılandırma gelirse onunkiler geçerli olur (NANONET_LOG_ALLOW sınırları içinde).
+    #[arg(long, env = "NANONET_LOG_PATHS")]
+    pub log_paths: Option<String>,
+
+    /// Backend'in log_paths ile takip ettirebileceği dosya veya dizinler — mutlak yollar, virgülle
+    /// ayrılmış. Verilmezse yalnızca NANONET_LOG_PATHS'teki dosyalar seçilebilir.
+    #[arg(long, env = "NANONET_LOG_ALLOW")]
+    pub log_allow: Option<String>,
+
     /// Bağlantı koptuğunda biriktirilebilecek max metrik sayısı
     #[arg(long, default_value = "120", env = "NANONET_BUFFER_SIZE")]
     pub buffer_size: usize,
@@ -82,7 +112,31 @@ impl Config {
 
     pub fn ws_url(&self) -> String {
         // Token URL'de taşınmaz — auth_header() kullan.
-        format!("{}/ws/agent?service_id={}", self.backend, self.service_id)
+        format!(
+            "{}/ws/agent?service_id={}&agent_id={}",
+            self.backend,
+            self.service_id,
+            self.effective_agent_id()
+        )
+    }
+
+    /// Yapılandırılmış agent kimliği; yoksa hostname. URL'de güvenli olmayan karakterler '-' olur.
+    pub fn effective_agent_id(&self) -> String {
+        let raw = self
+            .agent_id
+            .clone()
+            .or_else(sysinfo::System::host_name)
+            .unwrap_or_else(|| "agent".to_string());
+        raw.chars()
+            .take(100)
+            .map(|c| {
+                if c.is_ascii_alphanumeric() || c == '-' || c == '_' || c == '.' {
+                    c
+                } else {
+                    '-'
+                }
+            })
+            .collect()
     }
 
     /// WebSocket handshake Authorization header değeri.
@@ -94,7 +148,6 @@ impl Config {
     }
 
     /// Backend HTTP base URL (ws:// → http://, wss:// → https://)
-    #[allow(dead_code)]
     pub fn http_base(&self) -> String {
         self.backend
             .replace("ws://", "http://")
@@ -0,0 +1,39 @@
+use serde_json::json;
+use std::net::UdpSocket;
+use sysinfo::System;
+
+use crate::commands;
+use crate::config::Config;
+
+/// Agent'ın konuştuğu protokol sürümü — backend welcome ile anlaşılan sürümü döndürür.
+pub const PROTOCOL_VERSION: u32 = 2;
+
+/// Backend'in desteklediği komut aksiyonları — hello mesajında yetenek olarak bildirilir.
+const CAPABILITIES: &[&str] = &["restart", "stop", "start", "exec", "scale", "ping"];
+
+/// Bağlantı kurulduktan hemen sonra gönderilen tanıtım mesajı.
+pub fn hello_json(config: &Config) -> String {
+    json!({
+        "type": "hello",
+        "protocol_version": PROTOCOL_VERSION,
+        "agent_id": config.effective_agent_id(),
+        "version": env!("CARGO_PKG_VERSION"),
+        "hostname": System::host_name().unwrap_or_default(),
+        "os": System::long_os_version().unwrap_or_default(),
+        "ips": local_ips(),
+        "capabilities": CAPABILITIES,
+        "exec_commands": commands::exec_command_names(config),
+    })
+    .to_string()
+}
+
+/// Varsayılan rotadaki yerel IP. UDP connect paket göndermez, yalnızca rota seçer.
+fn local_ips() -> Vec<String> {
+    UdpSocket::bind("0.0.0.0:0")
+        .and_then(|socket| {
+            socket.connect("8.8.8.8:80")?;
+            socket.local_addr()
+        })
+        .map(|addr| vec![addr.ip().to_string()])
+        .unwrap_or_default()
+}
@@ -0,0 +1,212 @@
+use std::collections::HashMap;
+use std::fs::File;
+use std::io::{Read, Seek, SeekFrom};
+use std::sync::atomic::Ordering;
+use std::time::Duration;
+
+use chrono::{SecondsFormat, Utc};
+use serde_json::json;
+use tokio::sync::watch;
+
+use crate::remote_config::RuntimeConfig;
+use crate::ws::{self, OutgoingTx};
+
+/// Dosyalar bu aralıkla yeni satırlar için taranır.
+const POLL_INTERVAL: Duration = Duration::from_secs(1);
+/// Tek turda dosya başına okunacak en fazla bayt; kalan bir sonraki tura bırakılır.
+const MAX_READ_PER_TICK: u64 = 256 * 1024;
+/// Backend'in kabul ettiği en uzun satır (ws.MaxLogLineBytes).
+const MAX_LINE_BYTES: usize = 8192;
+/// log mesajı başına en fazla satır ve yaklaşık boyut (backend okuma limiti 1 MiB).
+const MAX_BATCH_LINES: usize = 200;
+const MAX_BATCH_BYTES: usize = 256 * 1024;
+
+/// Takip edilen tek dosyanın okuma konumu.
+struct FileState {
+    offset: u64,
+    file_id: Option<u64>,
+    partial: Vec<u8>,
+}
+
+/// log_paths'teki dosyaları takip eder ve yeni satırları log mesajı olarak gönderir.
+/// Dosyalar ilk görüldüklerinde sonlarından okunmaya başlanır; geçmiş satırlar gönderilmez.
+/// Bağlantı yokken veya backend log mesajını desteklemiyorsa okuma yapılmaz, konum korunur.
+pub async fn run(
+    ws_tx: OutgoingTx,
+    runtime_rx: watch::Receiver<RuntimeConfig>,
+    mut shutdown_rx: watch::Receiver<bool>,
+) {
+    let mut files: HashMap<String, FileState> = HashMap::new();
+    let mut interval = tokio::time::interval(POLL_INTERVAL);
+
+    loop {
+        tokio::select! {
+            _ = interval.tick() => {},
+            _ = shutdown_rx.changed() => {
+                tracing::info!("Log takibi kapatılıyor (shutdown sinyali)");
+                return;
+            }
+        }
+
+        let paths = runtime_rx.borrow().log_paths.clone();
+        files.retain(|path, _| paths.contains(path));
+        if paths.is_empty() {
+            continue;
+        }
+
+        for path in &paths {
+            if !files.contains_key(path) {
+                // Henüz var olmayan dosya için konum 0'dan başlar; oluşturulduğunda baştan okunur.
+                let (offset, file_id) = match std::fs::metadata(path) {
+                    Ok(meta) => (meta.len(), file_id(&meta)),
+                    Err(_) => (0, None),
+                };
+                tracing::info!(path = %path, "Log dosyası takibe alındı");
+                files.insert(
+                    path.clone(),
+                    FileState {
+                        offset,
+                        file_id,
+                        partial: Vec::new(),
+                    },
+                );
+            }
+        }
+
+        if !ws::WS_CONNECTED.load(Ordering::Relaxed) || !ws::LOGS_SUPPORTED.load(Ordering::Relaxed)
+        {
+            continue;
+        }
+
+        let mut lines = Vec::new();
+        for path in &paths {
+            if let Some(state) = files.get_mut(path) {
+                match read_new_lines(path, state) {
+                    Ok(mut new) => lines.append(&mut new),
+                    Err(e) => tracing::debug!(path = %path, error = %e, "Log dosyası okunamadı"),
+                }
+            }
+        }
+
+        for batch in batches(lines) {
+            let msg = json!({ "type": "log", "lines": batch }).to_string();
+            if ws_tx.send(msg).await.is_err() {
+                return;
+            }
+        }
+    }
+}
+
+/// Dosyada son konumdan sonra eklenen tam satırları döndürür. Dosya kısaldıysa (truncate) veya
+/// yerine yenisi geldiyse (rotation) baştan okunur.
+fn read_new_lines(path: &str, state: &mut FileState) -> std::io::Result<Vec<serde_json::Value>> {
+    let mut file = File::open(path)?;
+    let meta = file.metadata()?;
+    let id = file_id(&meta);
+    if meta.len() < state.offset || (id.is_some() && state.file_id.is_some() && id != state.file_id)
+    {
+        tracing::info!(path = %path, "Log dosyası döndürülmüş, baştan okunuyor");
+        state.offset = 0;
+        state.partial.clear();
+    }
+    state.file_id = id;
+    if meta.len() == state.offset {
+        return Ok(Vec::new());
+    }
+
+    file.seek(SeekFrom::Start(state.offset))?;
+    let mut buf = Vec::new();
+    let read = file.take(MAX_READ_PER_TICK).read_to_end(&mut buf)?;
+    state.offset += read as u64;
+
+    let ts = Utc::now().to_rfc3339_opts(SecondsFormat::Millis, true);
+    let mut lines = Vec::new();
+    state.partial.extend_from_slice(&buf);
+    while let Some(pos) = state.partial.iter().position(|b| *b == b'\n') {
+        let raw: Vec<u8> = state.partial.drain(..=pos).collect();
+        push_line(&mut lines, path, &ts, &raw);
+    }
+    // Satır sonu gelmeyen çok uzun satır sonsuza kadar biriktirilmez.
+    if state.partial.len() > MAX_LINE_BYTES {
+        let raw = std::mem::take(&mut state.partial);
+        push_line(&mut lines, path, &ts, &raw);
+    }
+    Ok(lines)
+}
+
+fn push_line(lines: &mut Vec<serde_json::Value>, path: &str, ts: &str, raw: &[u8]) {
+    let text = String::from_utf8_lossy(raw);
+    let text = text.trim_end_matches(['\r', '\n']);
+    if text.trim().is_empty() {
+        return;
+    }
+    let message = truncate(text, MAX_LINE_BYTES);
+    lines.push(json!({
+        "ts": ts,
+        "stream": "file",
+        "level": detect_level(message),
+        "message": message,
+        "path": path,
+    }));
+}
+
+fn batches(lines: Vec<serde_json::Value>) -> Vec<Vec<serde_json::Value>> {
+    let mut out = Vec::new();
+    let mut current = Vec::new();
+    let mut size = 0;
+    for line in lines {
+        let len = line["message"].as_str().map(str::len).unwrap_or(0);
+        if !current.is_empty() && (current.len() >= MAX_BATCH_LINES || size + len > MAX_BATCH_BYTES)
+        {
+            out.push(std::mem::take(&mut current));
+            size = 0;
+        }
+        size += len;
+        current.push(line);
+    }
+    if !current.is_empty() {
+        out.push(current);
+    }
+    out
+}
+
+/// Satırın başındaki seviye işaretinden (ERROR, [warn], level=info, "level":"debug" …) seviyeyi
+/// tahmin eder. Bulunamazsa "unknown" döner; backend eş anlamlıları normalize eder.
+fn detect_level(message: &str) -> &'static str {
+    let head: String = message.chars().take(120).collect::<String>().to_lowercase();
+    for token in head.split(|c: char| !c.is_ascii_alphabetic()) {
+        let level = match token {
+            "trace" | "trc" => "trace",
+            "debug" | "dbg" => "debug",
+            "info" | "inf" | "notice" => "info",
+            "warn" | "warning" | "wrn" => "warn",
+            "error" | "err" => "error",
+            "fatal" | "panic" | "critical" | "crit" | "emerg" | "alert" => "fatal",
+            _ => continue,
+        };
+        return level;
+    }
+    "unknown"
+}
+
+fn truncate(s: &str, max: usize) -> &str {
+    if s.len() <= max {
+        return s;
+    }
+    let mut end = max;
+    while !s.is_char_boundary(end) {
+        end -= 1;
+    }
+    &s[..end]
+}
+
+#[cfg(unix)]
+fn file_id(meta: &std::fs::Metadata) -> Option<u64> {
+    use std::os::unix::fs::MetadataExt;
+    Some(meta.ino())
+}
+
+#[cfg(not(unix))]
+fn file_id(_meta: &std::fs::Metadata) -> Option<u64> {
+    None
+}
@@ -4,7 +4,11 @@ mod commands;
 mod config;
 mod error;
 mod health;
+mod hello;
+mod log_tail;
 mod metrics;
+mod remote_config;
+mod signing;
 mod ws;
 
 use chrono::Utc;
@@ -20,6 +24,7 @@ use sysinfo::{Disks, Networks, System};
 use tokio::sync::watch;
 
 use buffer::MetricBuffer;
+use remote_config::RuntimeConfig;
 
 #[tokio::main]
 async fn main() -> error::Result<()> {
@@ -46,11 +51,8 @@ async fn main() -> error::Result<()> {
     tracing::info!("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━");
     tracing::info!("  Backend:       {}", config.backend);
     tracing::info!("  Service ID:    {}", config.service_id);
-    tracing::info!(
-        "  WS URL:        {}/ws/agent?service_id={}",
-        config.backend,
-        config.service_id
-    );
+    tracing::info!("  Agent ID:      {}", config.effective_agent_id());
+    tracing::info!("  WS URL:        {}", config.ws_url());
     tracing::info!(
         "  Auth:          {}",
         if config.effective_token().is_some() {
@@ -59,6 +61,17 @@ async fn main() -> error::Result<()> {
             "(yok — NANONET_AGENT_TOKEN veya NANONET_TOKEN gerekli)"
         }
     );
+    tracing::info!(
+        "  Komut imzası:  {}",
+        match (
+            config.require_signed_commands,
+            config.command_pubkey.is_some()
+        ) {
+            (true, _) => "zorunlu",
+            (false, true) => "imzalıysa doğrulanır",
+            (false, false) => "doğrulanmıyor",
+        }
+    );
     tracing::info!("  Health URL:    {}", config.health_url());
     tracing::info!("  Poll interval: {}s", config.poll_interval);
     tracing::info!("  Error window:  {} checks", config.error_rate_window);
@@ -72,6 +85,14 @@ async fn main() -> error::Result<()> {
         Some(p) => tracing::info!("  Process watch: {}", p),
         None => tracing::info!("  Process watch: (yok)"),
     }
+    match &config.log_paths {
+        Some(paths) => tracing::info!("  Log dosyaları: {}", paths),
+        None => tracing::info!("  Log dosyaları: (yok)"),
+    }
+    match &config.log_allow {
+        Some(allow) => tracing::info!("  Log izinleri:  {}", allow),
+        None => tracing::info!("  Log izinleri:  (yalnızca yerel log dosyaları)"),
+    }
     match &config.restart_cmd {
         Some(cmd) => tracing::info!("  Restart cmd:   {}", cmd),
         None => tracing::warn!("  Restart cmd:   (yapılandırılmamış)"),
@@ -94,6 +115,8 @@ async fn main() -> error::Result<()> {
     let (ws_tx, ws_rx) = ws::channel();
     let restart_count = Arc::new(AtomicU64::new(0));
     let metric_buffer = MetricBuffer::new(config.buffer_size);
+    // Backend'den gelen config mesajları toplama ayarlarını çalışma anında günceller.
+    let (runtime_tx, mut runtime_rx) = watch::channel(RuntimeConfig::from_local(&config));
 
     // Graceful shutdown kanalı
     let (shutdown_tx, _) = watch::channel(false);
@@ -111,6 +134,7 @@ async fn main() -> error::Result<()> {
             ws_restart_count,
             ws_buffer,
             shutdown_rx_ws,
+            runtime_tx,
         )
         .await;
     });
@@ -122,6 +146,14 @@ async fn main() -> error::Result<()> {
         agent_health::serve(health_port, start_time, health_buffer).await;
     });
 
+    // ─── Log Takibi Task ───
+    let logs_tx = ws_tx.clone();
+    let logs_runtime_rx = runtime_rx.clone();
+    let shutdown_rx_logs = shutdown_tx.subscribe();
+    let _logs_task = tokio::spawn(async move {
+        log_tail::run(logs_tx, logs_runtime_rx, shutdown_rx_logs).await;
+    });
+
     // ─── Metrik Toplama Task ───
     let metrics_config = config.clone();
     let metrics_restart_count = Arc::clone(&restart_count);
@@ -149,7 +181,8 @@ async fn main() -> error::Result<()> {
         sys.refresh_cpu_usage();
         tokio::time::sleep(Duration::from_secs(1)).await;
 
-        let mut interval = tokio::time::interval(Duration::from_secs(metrics_config.poll_interval));
+        let mut interval =
+            tokio::time::interval(Duration::from_secs(runtime_rx.borrow().poll_interval));
         let agent_start = std::time::Instant::now();
 
         // Hata oranı için kayan pencere
@@ -158,33 +191,56 @@ async fn main() -> error::Result<()> {
         loop {
             tokio::select! {
                 _ = interval.tick() => {},
+                Ok(()) = runtime_rx.changed() => {
+                    let runtime = runtime_rx.borrow_and_update().clone();
+                    tracing::info!(
+                        version = runtime.version,
+                        poll_interval = runtime.poll_interval,
+                        collectors = ?runtime.collectors,
+                        "Metrik toplama ayarları güncellendi"
+                    );
+                    interval = tokio::time::interval(Duration::from_secs(runtime.poll_interval));
+                    continue;
+                }
                 _ = shutdown_rx_metrics.changed() => {
                     tracing::info!("Metrics task kapatılıyor (shutdown sinyali)");
                     break;
                 }
             }
 
+            let runtime = runtime_rx.borrow().clone();
+
             // Sistem metrikleri
             let elapsed = last_tick.elapsed().as_secs_f64();
             last_tick = std::time::Instant::now();
-            let (mut snapshot, new_disk_snap) = metrics::collect_system(
-                &mut sys,
-                &mut disks,
-                &mut networks,
-                &prev_disk_snap,
-                elapsed,
-            );
-            prev_disk_snap = new_disk_snap;
+            let mut snapshot = if runtime.collects("system") {
+                let (snapshot, new_disk_snap) = metrics::collect_system(
+                    &mut sys,
+                    &mut disks,
+                    &mut networks,
+                    &prev_disk_snap,
+                    elapsed,
+                );
+                prev_disk_snap = new_disk_snap;
+                snapshot
+            } else {
+                metrics::MetricSnapshot::default()
+            };
 
             // Per-process metrikleri
-            sys.refresh_processes();
-            let process_metrics = process_target
-                .as_deref()
-                .and_then(|t| metrics::collect_process(&sys, t));
+            let process_metrics = match process_target.as_deref() {
+                Some(t) if runtime.collects("process") => {
+                    sys.refresh_processes();
+                    metrics::collect_process(&sys, t)
+                }
+                _ => None,
+            };
 
             // App metrics endpoint
             if let Some(ref url) = app_metrics_url {
-                metrics::fetch_app_metrics(&http_client, &mut snapshot, url).await;
+                if runtime.collects("app_metrics") {
+                    metrics::fetch_app_metrics(&http_client, &mut snapshot, url).await;
+                }
             }
 
             // Delta ağ metrikleri (MB/s)
@@ -269,6 +325,11 @@ async fn main() -> error::Result<()> {
                 );
             }
 
+            // Kapalı toplayıcının alanları sıfır değil, hiç gönderilmez.
+            if !runtime.collects("system") {
+                message["system"] = json!({});
+            }
+
             let msg_str = message.to_string();
 
             // WS bağlıysa doğrudan gönder, değilse buffer'a ekle
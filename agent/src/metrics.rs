@@ -3,7 +3,7 @@ use serde::{Deserialize, Serialize};
 use std::time::Duration;
 use sysinfo::{Disks, Networks, System};
 
-#[derive(Debug, Clone, Serialize, Deserialize)]
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
 pub struct MetricSnapshot {
     pub cpu_percent: f32,
     pub memory_used_mb: f32,
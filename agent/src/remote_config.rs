@@ -0,0 +1,192 @@
+use std::path::{Component, Path};
+
+use serde::Deserialize;
+use serde_json::Value;
+
+use crate::config::Config;
+
+/// Backend'in açıp kapatabildiği toplayıcılar. Servis sağlık kontrolü her zaman açıktır.
+pub const COLLECTORS: &[&str] = &["system", "process", "app_metrics"];
+
+const MIN_POLL_INTERVAL: u64 = 5;
+const MAX_POLL_INTERVAL: u64 = 300;
+
+/// Çalışma anında değiştirilebilen toplama ayarları. Backend config mesajı gelene kadar
+/// yerel (CLI/env) değerler kullanılır; sürüm 0 yerel yapılandırmayı ifade eder.
+#[derive(Debug, Clone, PartialEq)]
+pub struct RuntimeConfig {
+    pub version: u64,
+    pub poll_interval: u64,
+    pub collectors: Vec<String>,
+    pub log_paths: Vec<String>,
+    /// Backend'in seçebileceği log dosyaları/dizinleri; yerel yapılandırmadan gelir ve uzak
+    /// config ile değiştirilemez.
+    pub log_allow: Vec<String>,
+}
+
+#[derive(Deserialize)]
+struct ConfigMessage {
+    config_version: u64,
+    poll_interval_sec: u64,
+    collectors: Vec<String>,
+    log_paths: Vec<String>,
+}
+
+impl RuntimeConfig {
+    pub fn from_local(config: &Config) -> Self {
+        let log_paths = split_paths(config.log_paths.as_deref());
+        let log_allow = match config.log_allow.as_deref() {
+            Some(allow) => split_paths(Some(allow)),
+            None => log_paths.clone(),
+        };
+        Self {
+            version: 0,
+            poll_interval: config.poll_interval.max(1),
+            collectors: COLLECTORS.iter().map(|c| c.to_string()).collect(),
+            log_paths,
+            log_allow,
+        }
+    }
+
+    pub fn collects(&self, name: &str) -> bool {
+        self.collectors.iter().any(|c| c == name)
+    }
+
+    /// Doğrulanmış config mesajından yeni ayarları üretir. Mesaj mevcut sürümden yeni
+    /// değilse Ok(None) döner (yeniden bağlantıda aynı sürüm tekrar gönderilir).
+    pub fn apply(&self, value: Value) -> Result<Option<RuntimeConfig>, String> {
+        let msg: ConfigMessage =
+            serde_json::from_value(value).map_err(|e| format!("config çözülemedi: {}", e))?;
+        if msg.config_version <= self.version {
+            return Ok(None);
+        }
+        if !(MIN_POLL_INTERVAL..=MAX_POLL_INTERVAL).contains(&msg.poll_interval_sec) {
+            return Err(format!(
+                "poll_interval_sec {}-{} aralığında olmalı",
+                MIN_POLL_INTERVAL, MAX_POLL_INTERVAL
+            ));
+        }
+        if let Some(unknown) = msg
+            .collectors
+            .iter()
+            .find(|c| !COLLECTORS.contains(&c.as_str()))
+        {
+            return Err(format!("bilinmeyen toplayıcı: {}", unknown));
+        }
+        if let Some(bad) = msg
+            .log_paths
+            .iter()
+            .find(|p| !Path::new(p.as_str()).is_absolute())
+        {
+            return Err(format!("log yolu mutlak olmalı: {}", bad));
+        }
+        if let Some(bad) = msg.log_paths.iter().find(|p| !self.log_allowed(p)) {
+            return Err(format!("log yolu agent izin listesinde değil: {}", bad));
+        }
+        Ok(Some(RuntimeConfig {
+            version: msg.config_version,
+            poll_interval: msg.poll_interval_sec,
+            collectors: msg.collectors,
+            log_paths: msg.log_paths,
+            log_allow: self.log_allow.clone(),
+        }))
+    }
+
+    /// Yolun yerel izin listesindeki bir dosya veya dizin altında olduğunu bildirir. Karşılaştırma
+    /// bileşen bazındadır ("/var/log" "/var/logs/x"i kapsamaz); ".." içeren yollar reddedilir.
+    fn log_allowed(&self, path: &str) -> bool {
+        let path = Path::new(path);
+        if path.components().any(|c| matches!(c, Component::ParentDir)) {
+            return false;
+        }
+        self.log_allow
+            .iter()
+            .any(|allowed| path.starts_with(Path::new(allowed)))
+    }
+}
+
+fn split_paths(list: Option<&str>) -> Vec<String> {
+    list.unwrap_or("")
+        .split(',')
+        .map(str::trim)
+        .filter(|p| !p.is_empty())
+        .map(str::to_string)
+        .collect()
+}
+
+/// config mesajına agent yanıtı.
+pub fn ack_json(version: u64, result: Result<(), &str>) -> String {
+    let mut ack = serde_json::json!({
+        "type": "config_ack",
+        "config_version": version,
+        "status": if result.is_ok() { "applied" } else { "rejected" },
+    });
+    if let Err(e) = result {
+        ack["error"] = Value::String(e.to_string());
+    }
+    ack.to_string()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use clap::Parser;
+    use serde_json::json;
+
+    fn runtime(args: &[&str]) -> RuntimeConfig {
+        let base = [
+            "nanonet-agent",
+            "--backend",
+            "ws://localhost:8080",
+            "--service-id",
+            "svc-1",
+        ];
+        RuntimeConfig::from_local(&Config::parse_from(base.iter().chain(args)))
+    }
+
+    fn message(log_paths: &[&str]) -> Value {
+        json!({
+            "config_version": 2,
+            "poll_interval_sec": 10,
+            "collectors": ["system"],
+            "log_paths": log_paths,
+        })
+    }
+
+    #[test]
+    fn remote_log_paths_limited_to_local_allowlist() {
+        let current = runtime(&[
+            "--log-paths",
+            "/var/log/app.log",
+            "--log-allow",
+            "/var/log/app",
+        ]);
+        assert_eq!(current.log_allow, vec!["/var/log/app"]);
+
+        let next = current
+            .apply(message(&["/var/log/app/error.log"]))
+            .unwrap()
+            .unwrap();
+        assert_eq!(next.log_paths, vec!["/var/log/app/error.log"]);
+        assert_eq!(next.log_allow, current.log_allow);
+
+        for bad in [
+            "/etc/shadow",
+            "/var/log/application.log",
+            "/var/log/app/../../../etc/shadow",
+        ] {
+            assert!(current.apply(message(&[bad])).is_err(), "{}", bad);
+        }
+    }
+
+    #[test]
+    fn allowlist_defaults_to_local_log_paths() {
+        let current = runtime(&["--log-paths", "/var/log/app.log"]);
+        assert!(current.apply(message(&["/var/log/app.log"])).is_ok());
+        assert!(current.apply(message(&["/var/log/other.log"])).is_err());
+
+        let none = runtime(&[]);
+        assert!(none.apply(message(&[])).unwrap().is_some());
+        assert!(none.apply(message(&["/var/log/app.log"])).is_err());
+    }
+}
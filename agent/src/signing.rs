@@ -0,0 +1,252 @@
+use base64::{engine::general_purpose::STANDARD, Engine as _};
+use ed25519_dalek::{Signature, Verifier as _, VerifyingKey};
+use serde_json::Value;
+use std::collections::HashMap;
+use std::time::Duration;
+
+use crate::config::Config;
+
+/// Backend ile agent saatleri arasında tolere edilen fark (saniye).
+const CLOCK_SKEW_SECS: i64 = 60;
+
+/// Backend'in imzaladığı komut zarflarını doğrular ve tekrar gönderilen komutları reddeder.
+pub struct CommandVerifier {
+    keys: Vec<VerifyingKey>,
+    /// Anahtar NANONET_COMMAND_PUBKEY ile sabitlendi; backend'den alınmaz.
+    pinned: bool,
+    required: bool,
+    service_id: String,
+    /// "type:command_id" → expires_at; süresi dolanlar temizlenir.
+    seen: HashMap<String, i64>,
+}
+
+impl CommandVerifier {
+    pub fn new(config: &Config) -> Self {
+        let keys = config
+            .command_pubkey
+            .as_deref()
+            .map(parse_keys)
+            .unwrap_or_default();
+        if config.command_pubkey.is_some() && keys.is_empty() {
+            tracing::warn!("NANONET_COMMAND_PUBKEY geçerli bir Ed25519 açık anahtarı içermiyor");
+        }
+        Self {
+            pinned: !keys.is_empty(),
+            keys,
+            required: config.require_signed_commands,
+            service_id: config.service_id.clone(),
+            seen: HashMap::new(),
+        }
+    }
+
+    /// Sabitlenmiş anahtar yoksa açık anahtarları backend'den alır. Anahtar yalnızca HTTPS
+    /// üzerinden alınır; düz HTTP'de araya giren biri kendi anahtarını verebileceğinden
+    /// NANONET_COMMAND_PUBKEY ile sabitlenmelidir. Backend geçici anahtar kullanıyorsa yeniden
+    /// başladığında değişir; bu yüzden her bağlantıda yenilenir, alınamazsa eskisi korunur.
+    pub async fn ensure_keys(&mut self, config: &Config) {
+        if self.pinned {
+            return;
+        }
+        let base = config.http_base();
+        if !base.starts_with("https://") {
+            if self.required {
+                tracing::warn!(
+                    "İmza anahtarı düz HTTP üzerinden alınmaz — NANONET_COMMAND_PUBKEY tanımlanmalı, komutlar reddedilecek"
+                );
+            }
+            return;
+        }
+        let url = format!("{}/api/v1/agent/signing-keys", base);
+        match fetch_keys(&url).await {
+            Ok(keys) if !keys.is_empty() => {
+                tracing::info!(
+                    count = keys.len(),
+                    "Komut imza anahtarları backend'den alındı"
+                );
+                self.keys = keys;
+            }
+            Ok(_) => tracing::warn!("Backend imza anahtarı döndürmedi"),
+            Err(e) => tracing::warn!(error = %e, "İmza anahtarları alınamadı"),
+        }
+    }
+
+    /// Mesajı doğrular ve çalıştırılacak içeriği döndürür. İmzalı mesajlarda yalnızca
+    /// imzalanmış payload kullanılır; üst seviye alanlara güvenilmez. Açık anahtar bilindiği
+    /// anda (sabitlenmiş veya backend'den alınmış) imzasız mesajlar zorunluluktan bağımsız
+    /// reddedilir; aksi halde imzayı silmek doğrulamayı atlatırdı.
+    pub fn verify(&mut self, value: Value) -> Result<Value, String> {
+        let Some(envelope) = value.get("envelope") else {
+            if self.required || !self.keys.is_empty() {
+                return Err("komut imzalı değil".to_string());
+            }
+            return Ok(value);
+        };
+        if self.keys.is_empty() {
+            if self.required {
+                return Err("imza doğrulanacak açık anahtar yok".to_string());
+            }
+            return Ok(value);
+        }
+
+        let field = |name: &str| envelope.get(name).and_then(|v| v.as_str()).unwrap_or("");
+        if field("alg") != "ed25519" {
+            return Err(format!("desteklenmeyen imza algoritması: {}", field("alg")));
+        }
+        let payload = STANDARD
+            .decode(field("payload"))
+            .map_err(|_| "payload çözülemedi".to_string())?;
+        let signature = STANDARD
+            .decode(field("signature"))
+            .ok()
+            .and_then(|b| Signature::from_slice(&b).ok())
+            .ok_or_else(|| "imza çözülemedi".to_string())?;
+        if !self
+            .keys
+            .iter()
+            .any(|k| k.verify(&payload, &signature).is_ok())
+        {
+            return Err("imza doğrulanamadı".to_string());
+        }
+
+        let signed: Value =
+            serde_json::from_slice(&payload).map_err(|_| "payload JSON değil".to_string())?;
+        let service_id = signed.get("service_id").and_then(|v| v.as_str());
+        if service_id != Some(self.service_id.as_str()) {
+            return Err("komut başka bir servis için imzalanmış".to_string());
+        }
+
+        let now = chrono::Utc::now().timestamp();
+        let issued_at = signed
+            .get("issued_at")
+            .and_then(|v| v.as_i64())
+            .unwrap_or(0);
+        let expires_at = signed
+            .get("expires_at")
+            .and_then(|v| v.as_i64())
+            .unwrap_or(0);
+        if expires_at <= now {
+            return Err("komutun süresi dolmuş".to_string());
+        }
+        if issued_at > now + CLOCK_SKEW_SECS {
+            return Err("komut gelecekte imzalanmış".to_string());
+        }
+
+        // config mesajlarında command_id yoktur; eski sürümler RuntimeConfig::apply'da elenir.
+        if let Some(command_id) = signed.get("command_id").and_then(|v| v.as_str()) {
+            let key = format!(
+                "{}:{}",
+                signed.get("type").and_then(|v| v.as_str()).unwrap_or(""),
+                command_id
+            );
+            self.seen.retain(|_, exp| *exp > now);
+            if self.seen.contains_key(&key) {
+                return Err("komut daha önce alındı (replay)".to_string());
+            }
+            self.seen.insert(key, expires_at);
+        }
+
+        Ok(signed)
+    }
+}
+
+fn parse_keys(raw: &str) -> Vec<VerifyingKey> {
+    raw.split(',')
+        .filter_map(|k| STANDARD.decode(k.trim()).ok())
+        .filter_map(|b| <[u8; 32]>::try_from(b.as_slice()).ok())
+        .filter_map(|b| VerifyingKey::from_bytes(&b).ok())
+        .collect()
+}
+
+async fn fetch_keys(url: &str) -> Result<Vec<VerifyingKey>, reqwest::Error> {
+    let body: Value = reqwest::Client::new()
+        .get(url)
+        .timeout(Duration::from_secs(10))
+        .send()
+        .await?
+        .error_for_status()?
+        .json()
+        .await?;
+    let keys = body["data"]["keys"]
+        .as_array()
+        .map(|keys| {
+            keys.iter()
+                .filter(|k| k["alg"] == "ed25519")
+                .filter_map(|k| k["public_key"].as_str())
+                .collect::<Vec<_>>()
+                .join(",")
+        })
+        .unwrap_or_default();
+    Ok(parse_keys(&keys))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use clap::Parser;
+    use ed25519_dalek::{Signer, SigningKey};
+    use serde_json::json;
+
+    fn config(args: &[&str]) -> Config {
+        let base = [
+            "nanonet-agent",
+            "--backend",
+            "ws://localhost:8080",
+            "--service-id",
+            "svc-1",
+        ];
+        Config::parse_from(base.iter().chain(args))
+    }
+
+    fn signed(key: &SigningKey, command_id: &str) -> Value {
+        let now = chrono::Utc::now().timestamp();
+        let payload = json!({
+            "type": "command",
+            "command_id": command_id,
+            "action": "ping",
+            "service_id": "svc-1",
+            "issued_at": now,
+            "expires_at": now + 60,
+        })
+        .to_string();
+        json!({
+            "type": "command",
+            "envelope": {
+                "alg": "ed25519",
+                "key_id": "k1",
+                "payload": STANDARD.encode(&payload),
+                "signature": STANDARD.encode(key.sign(payload.as_bytes()).to_bytes()),
+            },
+        })
+    }
+
+    #[test]
+    fn unsigned_rejected_once_key_known() {
+        let key = SigningKey::from_bytes(&[7u8; 32]);
+        let pubkey = STANDARD.encode(key.verifying_key().to_bytes());
+        let mut verifier = CommandVerifier::new(&config(&["--command-pubkey", &pubkey]));
+        let unsigned = json!({"type": "command", "command_id": "c1", "action": "stop"});
+
+        assert!(verifier.verify(unsigned.clone()).is_err());
+        assert!(verifier.verify(signed(&key, "c1")).is_ok());
+        assert!(
+            verifier.verify(signed(&key, "c1")).is_err(),
+            "tekrar gönderilen komut reddedilir"
+        );
+
+        // Anahtar bilinmiyorsa ve imza zorunlu değilse eski backend'lerle uyum korunur.
+        let mut legacy = CommandVerifier::new(&config(&[]));
+        assert!(legacy.verify(unsigned).is_ok());
+    }
+
+    #[tokio::test]
+    async fn keys_not_fetched_over_plain_http() {
+        let cfg = config(&["--require-signed-commands"]);
+        let mut verifier = CommandVerifier::new(&cfg);
+        verifier.ensure_keys(&cfg).await;
+
+        assert!(verifier.keys.is_empty());
+        assert!(verifier
+            .verify(json!({"type": "command", "command_id": "c1"}))
+            .is_err());
+    }
+}
@@ -9,9 +9,12 @@ use tokio_tungstenite::{
 };
 
 use crate::buffer::MetricBuffer;
-use crate::commands;
+use crate::commands::{self, RunningCommands};
 use crate::config::Config;
 use crate::error::AgentError;
+use crate::hello;
+use crate::remote_config::{self, RuntimeConfig};
+use crate::signing::CommandVerifier;
 
 /// Max backoff delay in seconds
 const MAX_BACKOFF_SECS: u64 = 32;
@@ -25,6 +28,8 @@ pub type OutgoingRx = mpsc::Receiver<String>;
 
 /// Paylaşılan WS bağlantı durumu
 pub static WS_CONNECTED: AtomicBool = AtomicBool::new(false);
+/// Backend welcome mesajında log mesajını kabul ettiğini bildirdi mi (bağlantı başına).
+pub static LOGS_SUPPORTED: AtomicBool = AtomicBool::new(false);
 /// Toplam gönderilen metrik sayısı
 pub static METRICS_SENT: AtomicU64 = AtomicU64::new(0);
 /// Toplam başarılı komut sayısı
@@ -41,8 +46,14 @@ pub async fn run(
     restart_count: Arc<AtomicU64>,
     buffer: MetricBuffer,
     mut shutdown_rx: watch::Receiver<bool>,
+    runtime: watch::Sender<RuntimeConfig>,
 ) {
     let ws_url = config.ws_url();
+    // Görülen komut kimlikleri yeniden bağlantılar arasında korunur (replay koruması).
+    let mut verifier = CommandVerifier::new(config);
+    // Komutlar arka planda çalışır; sonuçları bağlantı yenilense de bu kanaldan gönderilir.
+    let running = RunningCommands::default();
+    let (results_tx, mut results_rx) = channel();
     let mut delay_secs: u64 = 1;
     let mut attempt: u32 = 0;
 
@@ -55,6 +66,7 @@ pub async fn run(
 
         attempt += 1;
         tracing::info!(attempt, "WS bağlantı denemesi");
+        verifier.ensure_keys(config).await;
 
         match connect_and_run(
             &ws_url,
@@ -63,6 +75,11 @@ pub async fn run(
             Arc::clone(&restart_count),
             &buffer,
             &mut shutdown_rx,
+            &mut verifier,
+            &runtime,
+            &running,
+            &results_tx,
+            &mut results_rx,
         )
         .await
         {
@@ -121,6 +138,11 @@ async fn connect_and_run(
     restart_count: Arc<AtomicU64>,
     buffer: &MetricBuffer,
     shutdown_rx: &mut watch::Receiver<bool>,
+    verifier: &mut CommandVerifier,
+    runtime: &watch::Sender<RuntimeConfig>,
+    running: &RunningCommands,
+    results_tx: &OutgoingTx,
+    results_rx: &mut OutgoingRx,
 ) -> Result<ShutdownReason, AgentError> {
     let mut request = ws_url
         .into_client_request()
@@ -144,10 +166,20 @@ async fn connect_and_run(
     .map_err(|e| AgentError::WebSocketConnection(e.to_string()))?;
 
     tracing::info!("WebSocket bağlantısı kuruldu ✓");
+    LOGS_SUPPORTED.store(false, Ordering::Relaxed);
     WS_CONNECTED.store(true, Ordering::Relaxed);
 
     let (mut sink, mut stream) = ws_stream.split();
 
+    // Kendini tanıt — backend agent kaydını (sürüm, host, yetenekler) bundan oluşturur
+    if let Err(e) = sink.send(Message::Text(hello::hello_json(config))).await {
+        WS_CONNECTED.store(false, Ordering::Relaxed);
+        return Err(AgentError::WebSocketConnection(format!(
+            "Hello gönderilemedi: {}",
+            e
+        )));
+    }
+
     // Buffer'daki birikmiş metrikleri gönder
     let buffered = buffer.drain().await;
     if !buffered.is_empty() {
@@ -203,11 +235,19 @@ async fn connect_and_run(
                 }
             }
 
+            // Arka planda tamamlanan komutların sonuçları
+            Some(result) = results_rx.recv() => {
+                if let Err(e) = sink.send(Message::Text(result)).await {
+                    WS_CONNECTED.store(false, Ordering::Relaxed);
+                    return Err(AgentError::WebSocketConnection(format!("Komut sonucu gönderilemedi: {}", e)));
+                }
+            }
+
             // Incoming messages
             msg = stream.next() => {
                 match msg {
                     Some(Ok(Message::Text(text))) => {
-                        handle_incoming(&text, &mut sink, config, Arc::clone(&restart_count)).await;
+                        handle_incoming(&text, &mut sink, config, Arc::clone(&restart_count), verifier, runtime, running, results_tx).await;
                     }
                     Some(Ok(Message::Pong(_))) => {
                         tracing::debug!("Pong alındı ✓");
@@ -239,6 +279,10 @@ async fn handle_incoming<S>(
     sink: &mut S,
     config: &Config,
     restart_count: Arc<AtomicU64>,
+    verifier: &mut CommandVerifier,
+    runtime: &watch::Sender<RuntimeConfig>,
+    running: &RunningCommands,
+    results_tx: &OutgoingTx,
 ) where
     S: SinkExt<Message> + Unpin,
     S::Error: std::fmt::Display,
@@ -253,11 +297,79 @@ async fn handle_incoming<S>(
 
     let msg_type = value.get("type").and_then(|v| v.as_str()).unwrap_or("");
 
-    if msg_type != "command" {
-        tracing::debug!(msg_type, "Bilinmeyen mesaj tipi");
-        return;
+    match msg_type {
+        "command" => {}
+        "welcome" => {
+            let version = value
+                .get("protocol_version")
+                .and_then(|v| v.as_u64())
+                .unwrap_or(0);
+            if version != u64::from(hello::PROTOCOL_VERSION) {
+                tracing::warn!(
+                    version,
+                    ours = hello::PROTOCOL_VERSION,
+                    "Backend farklı bir protokol sürümü seçti"
+                );
+            }
+            let logs = value
+                .get("agent_messages")
+                .and_then(|v| v.as_array())
+                .is_some_and(|msgs| msgs.iter().any(|m| m.as_str() == Some("log")));
+            LOGS_SUPPORTED.store(logs, Ordering::Relaxed);
+            tracing::info!(
+                version,
+                logs,
+                capabilities = %value.get("capabilities").cloned().unwrap_or_default(),
+                "Backend el sıkışması tamamlandı"
+            );
+            return;
+        }
+        "config" => {
+            handle_config(value, sink, verifier, runtime).await;
+            return;
+        }
+        "cancel" => {
+            handle_cancel(value, verifier, running);
+            return;
+        }
+        "error" => {
+            tracing::warn!(
+                code = value.get("code").and_then(|v| v.as_str()).unwrap_or(""),
+                ref_type = value.get("ref_type").and_then(|v| v.as_str()).unwrap_or(""),
+                message = value.get("message").and_then(|v| v.as_str()).unwrap_or(""),
+                "Backend mesajı reddetti"
+            );
+            return;
+        }
+        _ => {
+            tracing::debug!(msg_type, "Bilinmeyen mesaj tipi");
+            return;
+        }
     }
 
+    // İmzalı komutlarda yalnızca doğrulanmış payload çalıştırılır.
+    let command_id = value
+        .get("command_id")
+        .and_then(|v| v.as_str())
+        .unwrap_or("")
+        .to_string();
+    let value = match verifier.verify(value) {
+        Ok(v) => v,
+        Err(e) => {
+            tracing::warn!(command_id = %command_id, error = %e, "Komut reddedildi");
+            let result = serde_json::json!({
+                "type": "result",
+                "command_id": command_id,
+                "status": "failed",
+                "error": format!("komut doğrulanamadı: {}", e),
+            });
+            if let Err(e) = sink.send(Message::Text(result.to_string())).await {
+                tracing::error!(error = %e, "Komut sonucu gönderilemedi");
+            }
+            return;
+        }
+    };
+
     let cmd: commands::IncomingCommand = match serde_json::from_value(value) {
         Ok(c) => c,
         Err(e) => {
@@ -279,30 +391,190 @@ async fn handle_incoming<S>(
         return;
     }
 
-    let is_restart = cmd.action == "restart";
-
-    // Komutu çalıştır
-    let (success, error, output) = match commands::execute(&cmd, config).await {
-        Ok(out) => {
-            if is_restart {
-                restart_count.fetch_add(1, Ordering::Relaxed);
-                tracing::info!(
-                    total = restart_count.load(Ordering::Relaxed),
-                    "Restart tamamlandı"
-                );
+    // Komut arka planda çalışır; WS döngüsü bu sırada cancel dahil diğer mesajları işler.
+    let cancelled = running.register(&cmd.command_id);
+    let running = running.clone();
+    let results_tx = results_tx.clone();
+    let config = config.clone();
+    tokio::spawn(async move {
+        let outcome = commands::cancellable(commands::execute(&cmd, &config), cancelled).await;
+        running.finish(&cmd.command_id);
+
+        let result = match outcome {
+            Some(Ok(output)) => {
+                if cmd.action == "restart" {
+                    restart_count.fetch_add(1, Ordering::Relaxed);
+                    tracing::info!(
+                        total = restart_count.load(Ordering::Relaxed),
+                        "Restart tamamlandı"
+                    );
+                }
+                COMMANDS_HANDLED.fetch_add(1, Ordering::Relaxed);
+                cmd.result_json(true, None, output)
             }
-            COMMANDS_HANDLED.fetch_add(1, Ordering::Relaxed);
-            (true, None, out)
+            Some(Err(e)) => {
+                tracing::error!(action = %cmd.action, error = %e, "Komut başarısız");
+                cmd.result_json(false, Some(e), None)
+            }
+            None => {
+                tracing::info!(action = %cmd.action, command_id = %cmd.command_id, "Komut iptal edildi");
+                cmd.cancelled_json()
+            }
+        };
+
+        // Sonucu gönder
+        if results_tx.send(result).await.is_err() {
+            tracing::error!(command_id = %cmd.command_id, "Komut sonucu gönderilemedi");
+        }
+    });
+}
+
+/// Backend'in iptal mesajını doğrular ve eşleşen çalışan komutu durdurur. Sonuç (cancelled)
+/// komutun kendi görevinden gönderilir.
+fn handle_cancel(
+    value: serde_json::Value,
+    verifier: &mut CommandVerifier,
+    running: &RunningCommands,
+) {
+    // İmzalı iptallerde command_id yalnızca doğrulanmış payload'dan okunur.
+    let value = match verifier.verify(value) {
+        Ok(v) => v,
+        Err(e) => {
+            tracing::warn!(error = %e, "İptal mesajı reddedildi");
+            return;
+        }
+    };
+    let command_id = value
+        .get("command_id")
+        .and_then(|v| v.as_str())
+        .unwrap_or("");
+    if running.cancel(command_id) {
+        tracing::info!(command_id, "Komut iptal ediliyor");
+    } else {
+        tracing::debug!(command_id, "İptal edilecek çalışan komut yok");
+    }
+}
+
+/// Backend'in gönderdiği merkezi yapılandırmayı doğrular, uygular ve config_ack ile yanıtlar.
+async fn handle_config<S>(
+    value: serde_json::Value,
+    sink: &mut S,
+    verifier: &mut CommandVerifier,
+    runtime: &watch::Sender<RuntimeConfig>,
+) where
+    S: SinkExt<Message> + Unpin,
+    S::Error: std::fmt::Display,
+{
+    let offered = value
+        .get("config_version")
+        .and_then(|v| v.as_u64())
+        .unwrap_or(0);
+    let current = runtime.borrow().clone();
+
+    let applied = verifier
+        .verify(value)
+        .and_then(|signed| current.apply(signed));
+    let ack = match applied {
+        Ok(Some(next)) => {
+            tracing::info!(
+                version = next.version,
+                poll_interval = next.poll_interval,
+                collectors = ?next.collectors,
+                log_paths = ?next.log_paths,
+                "Backend yapılandırması uygulandı"
+            );
+            let version = next.version;
+            runtime.send_replace(next);
+            remote_config::ack_json(version, Ok(()))
+        }
+        Ok(None) => {
+            tracing::debug!(
+                offered,
+                current = current.version,
+                "Yapılandırma zaten güncel"
+            );
+            remote_config::ack_json(current.version, Ok(()))
         }
         Err(e) => {
-            tracing::error!(action = %cmd.action, error = %e, "Komut başarısız");
-            (false, Some(e), None)
+            tracing::warn!(version = offered, error = %e, "Backend yapılandırması reddedildi");
+            remote_config::ack_json(offered, Err(e.as_str()))
         }
     };
 
-    // Sonucu gönder
-    let result = cmd.result_json(success, error, output);
-    if let Err(e) = sink.send(Message::Text(result)).await {
-        tracing::error!(error = %e, "Komut sonucu gönderilemedi");
+    if let Err(e) = sink.send(Message::Text(ack)).await {
+        tracing::error!(error = %e, "config_ack gönderilemedi");
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use base64::{engine::general_purpose::STANDARD, Engine as _};
+    use clap::Parser;
+    use ed25519_dalek::{Signer, SigningKey};
+    use serde_json::json;
+
+    /// Backend'in encodeForAgent'ı gibi cancel mesajını zarfa koyar (schemas/backend/cancel.json).
+    fn signed_cancel(key: &SigningKey, command_id: &str) -> serde_json::Value {
+        let now = chrono::Utc::now().timestamp();
+        let payload = json!({
+            "type": "cancel",
+            "command_id": command_id,
+            "service_id": "svc-1",
+            "issued_at": now,
+            "expires_at": now + 60,
+        })
+        .to_string();
+        json!({
+            "type": "cancel",
+            "command_id": command_id,
+            "envelope": {
+                "alg": "ed25519",
+                "key_id": "k1",
+                "payload": STANDARD.encode(&payload),
+                "signature": STANDARD.encode(key.sign(payload.as_bytes()).to_bytes()),
+            },
+        })
+    }
+
+    #[tokio::test]
+    async fn signed_cancel_stops_running_command() {
+        let key = SigningKey::from_bytes(&[7u8; 32]);
+        let pubkey = STANDARD.encode(key.verifying_key().to_bytes());
+        let config = Config::parse_from([
+            "nanonet-agent",
+            "--backend",
+            "ws://localhost:8080",
+            "--service-id",
+            "svc-1",
+            "--command-pubkey",
+            &pubkey,
+            "--require-signed-commands",
+        ]);
+        let mut verifier = CommandVerifier::new(&config);
+        let running = RunningCommands::default();
+        let cancelled = running.register("c1");
+        let task = tokio::spawn(commands::cancellable(
+            tokio::time::sleep(Duration::from_secs(30)),
+            cancelled,
+        ));
+
+        // İmzasız ve başka anahtarla imzalanmış iptaller komutu durdurmaz.
+        handle_cancel(
+            json!({"type": "cancel", "command_id": "c1"}),
+            &mut verifier,
+            &running,
+        );
+        let forged = SigningKey::from_bytes(&[9u8; 32]);
+        handle_cancel(signed_cancel(&forged, "c1"), &mut verifier, &running);
+        tokio::time::sleep(Duration::from_millis(50)).await;
+        assert!(!task.is_finished());
+
+        handle_cancel(signed_cancel(&key, "c1"), &mut verifier, &running);
+        let out = tokio::time::timeout(Duration::from_secs(5), task)
+            .await
+            .expect("iptal edilen komut hemen dönmeli")
+            .unwrap();
+        assert!(out.is_none());
     }
 }
@@ -9,19 +9,28 @@ import (
 	"syscall"
 	"time"
 
+	"nanonet-backend/internal/agents"
 	"nanonet-backend/internal/ai"
 	"nanonet-backend/internal/alerts"
+	"nanonet-backend/internal/approvals"
 	"nanonet-backend/internal/auth"
 	"nanonet-backend/internal/commands"
+	"nanonet-backend/internal/fleet"
 	"nanonet-backend/internal/k8s"
+	"nanonet-backend/internal/logs"
 	"nanonet-backend/internal/maintenance"
 	"nanonet-backend/internal/metrics"
+	"nanonet-backend/internal/orgs"
+	"nanonet-backend/internal/recovery"
+	"nanonet-backend/internal/runbooks"
+	"nanonet-backend/internal/schedules"
 	"nanonet-backend/internal/services"
 	"nanonet-backend/internal/settings"
 	"nanonet-backend/internal/ws"
 	"nanonet-backend/pkg/audit"
 	"nanonet-backend/pkg/config"
 	"nanonet-backend/pkg/database"
+	"nanonet-backend/pkg/lockout"
 	"nanonet-backend/pkg/mailer"
 	"nanonet-backend/pkg/ratelimit"
 	"nanonet-backend/pkg/redisstore"
@@ -50,8 +59,31 @@ func main() {
 		}
 	}()
 
+	// ── Komut imzalama ────────────────────────────────────────────
+	signatureTTL := time.Duration(cfg.CommandSignatureTTL) * time.Second
+	var signer *ws.CommandSigner
+	if cfg.CommandSigningKey != "" {
+		signer, err = ws.NewCommandSigner(cfg.CommandSigningKey, signatureTTL)
+		if err != nil {
+			log.Fatalf("COMMAND_SIGNING_KEY geçersiz: %v", err)
+		}
+	} else if cfg.RedisURL != "" {
+		// Redis ile çok örnekli çalışmada her örnek kendi anahtarını üretirse
+		// röle edilen ve kuyruktaki komutların imzası agent'ta reddedilir.
+		log.Fatal("REDIS_URL ayarlıyken COMMAND_SIGNING_KEY zorunludur — tüm backend örnekleri aynı imza anahtarını kullanmalıdır")
+	} else {
+		signer, err = ws.GenerateCommandSigner(signatureTTL)
+		if err != nil {
+			log.Fatalf("Komut imza anahtarı üretilemedi: %v", err)
+		}
+		log.Println("[WARN] COMMAND_SIGNING_KEY ayarlanmamış — geçici imza anahtarı üretildi; " +
+			"yeniden başlatmada değişir ve birden fazla backend örneğinde tüm örnekler aynı anahtarı kullanmalıdır")
+	}
+	log.Printf("Komut imzalama aktif (key_id: %s)", signer.PublicKey().KeyID)
+
 	// ── Redis (optional) ───────────────────────────────────────────
 	var bl tokenblacklist.Blacklist
+	var lockStore lockout.Store
 	var hub *ws.Hub
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -62,17 +94,35 @@ func main() {
 		if err != nil {
 			log.Printf("[WARN] Redis bağlantısı başarısız (%v) — bellek içi mod kullanılıyor", err)
 			bl = tokenblacklist.NewInMemory()
+			lockStore = lockout.NewInMemory()
 			hub = ws.NewHub(cfg.WSMaxConnections)
 		} else {
 			log.Printf("Redis bağlandı: %s", cfg.RedisURL)
 			bl = tokenblacklist.NewRedis(rdb)
+			lockStore = lockout.NewRedis(rdb)
 			hub = ws.NewHubWithRedis(cfg.WSMaxConnections, rdb)
-			go hub.StartRedis(ctx)
 		}
 	} else {
 		bl = tokenblacklist.NewInMemory()
+		lockStore = lockout.NewInMemory()
 		hub = ws.NewHub(cfg.WSMaxConnections)
 	}
+	hub.SetCommandSigner(signer)
+	go hub.StartRedis(ctx)
+
+	// ── Agent registry ────────────────────────────────────────────
+	agentRegistry := agents.NewRegistry(db, hub)
+	agentConfigs := agents.NewConfigStore(db, hub)
+	agentConfigs.Attach()
+	agentRegistry.SetConfigStore(agentConfigs)
+	agentRegistry.Attach()
+	go agentRegistry.Start(ctx)
+	agentCreds := agents.NewCredentialStore(db, hub)
+
+	// ── Agent log shipping ────────────────────────────────────────
+	logSvc := logs.NewService(db, hub)
+	logSvc.Attach()
+	go logSvc.Run(ctx)
 
 	go hub.Run()
 
@@ -103,6 +153,52 @@ func main() {
 		}
 	}()
 
+	// ── Auto-recovery ─────────────────────────────────────────────
+	recoveryCtl := recovery.NewController(db, hub, recovery.Config{
+		AlertTypes:         cfg.AutoRecoveryAlertTypes,
+		MaxAttemptsPerHour: cfg.AutoRecoveryMaxPerHour,
+		BaseBackoff:        time.Duration(cfg.AutoRecoveryBackoffSec) * time.Second,
+		MaxBackoff:         time.Duration(cfg.AutoRecoveryMaxBackoffSec) * time.Second,
+		BreakerThreshold:   cfg.AutoRecoveryBreakerThreshold,
+		BreakerCooldown:    time.Duration(cfg.AutoRecoveryBreakerCooldown) * time.Second,
+	})
+	recoveryCtl.SetMaintenanceChecker(maintRepo)
+
+	// ── Runbooks ──────────────────────────────────────────────────
+	runbookSvc := runbooks.NewService(db, hub)
+	recoveryCtl.SetRunbookRunner(runbookSvc)
+	recoveryCtl.SetAlertRunbookRunner(runbookSvc)
+	runbookSvc.SetOnExecutionFinished(func(e runbooks.Execution) {
+		if e.TriggeredBy != runbooks.TriggerAutoRecovery {
+			return
+		}
+		status := "failed"
+		if e.Status == runbooks.StatusSuccess {
+			status = "success"
+		}
+		recoveryCtl.HandleCommandResult(e.ID.String(), status)
+	})
+	go runbookSvc.Start(ctx)
+
+	// ── Scheduled commands ────────────────────────────────────────
+	scheduleSvc := schedules.NewService(db, hub)
+	scheduleSvc.SetMaintenanceChecker(maintRepo)
+	go scheduleSvc.Start(ctx)
+
+	// ── Fleet operations ──────────────────────────────────────────
+	fleetSvc := fleet.NewService(db, hub)
+	go fleetSvc.Start(ctx)
+
+	// ── Two-person approvals ──────────────────────────────────────
+	approvalSvc := approvals.NewService(db, hub)
+	go approvalSvc.Start(ctx)
+
+	alertSvc.SetOnAlertCreated(func(a alerts.Alert) {
+		// Alert runbook'u başlarsa aynı alert için auto-recovery restart'ı atlanır.
+		recoveryCtl.HandleAlert(a.ServiceID, a.Type)
+	})
+	go recoveryCtl.Start(ctx)
+
 	// ── Mailer ────────────────────────────────────────────────────
 	m := mailer.New(mailer.Config{
 		Host:     cfg.SMTPHost,
@@ -115,22 +211,84 @@ func main() {
 		log.Println("Warning: SMTP yapılandırılmamış, şifre sıfırlama emaili gönderilmeyecek")
 	} else {
 		alertSvc.SetNotifier(m)
+		approvalSvc.SetNotifier(m)
 		log.Println("Alert email bildirimleri aktif")
 	}
 
 	// ── Handlers ──────────────────────────────────────────────────
 	authHandler := auth.NewHandler(db, cfg.JWTSecret, m, cfg.FrontendURL, bl)
+	authHandler.SetLockout(lockStore)
+	authHandler.SetEmailVerification(cfg.EmailVerificationRequired)
+	if cfg.OIDCIssuer != "" {
+		authHandler.SetOIDC(newOIDCProvider(cfg))
+		log.Printf("OIDC tek oturum açma aktif (issuer: %s)", cfg.OIDCIssuer)
+	}
 	authMiddleware := auth.NewMiddleware(cfg.JWTSecret, bl)
+	authAccounts := auth.NewService(db, cfg.JWTSecret)
+	authMiddleware.SetAPIKeys(authAccounts)
+	if cfg.EmailVerificationRequired {
+		authMiddleware.SetEmailVerifier(authAccounts)
+	}
 	serviceHandler := services.NewHandler(db, hub)
+	serviceHandler.SetApprovalGate(approvalSvc)
+	serviceHandler.SetConfigNotifier(agentConfigs)
 	metricsHandler := metrics.NewHandler(db)
 	alertHandler := alerts.NewHandler(alertSvc)
 	maintHandler := maintenance.NewHandler(maintRepo)
 	wsHandler := ws.NewHandler(hub, cfg.JWTSecret, cfg.FrontendURL)
-	aiHandler := ai.NewHandler(db, cfg.ClaudeAPIKey)
+	wsHandler.SetAgentAuthenticator(agentCreds)
+	wsHandler.SetTokenRevocation(authMiddleware)
+	wsHandler.SetAllowUserAgentTokens(cfg.AgentAllowUserTokens)
+	aiHandler, err := ai.NewHandler(db, ai.ProviderConfig{
+		Provider:       cfg.LLMProvider,
+		BaseURL:        cfg.LLMBaseURL,
+		APIKey:         cfg.LLMAPIKey,
+		Model:          cfg.LLMModel,
+		DeepModel:      cfg.LLMDeepModel,
+		MaxTokens:      cfg.LLMMaxTokens,
+		Timeout:        time.Duration(cfg.LLMTimeoutSec) * time.Second,
+		ConnectTimeout: time.Duration(cfg.LLMConnectTimeoutSec) * time.Second,
+	})
+	if err != nil {
+		log.Fatalf("AI sağlayıcısı yapılandırılamadı: %v", err)
+	}
 	cmdHandler := commands.NewHandler(db)
 	cmdService := commands.NewService(db)
 	settingsHandler := settings.NewHandler(db)
 	auditHandler := audit.NewHandler(db)
+	if cfg.AuditOrgID != "" {
+		id, err := uuid.Parse(cfg.AuditOrgID)
+		if err != nil {
+			log.Fatalf("AUDIT_ORG_ID geçersiz: %v", err)
+		}
+		auditHandler.SetAuditor(orgs.NewOrgAuditor(db, id))
+	}
+	if cfg.AuditSinkAddr != "" {
+		sink, err := audit.NewSink(audit.SinkConfig{
+			Network: cfg.AuditSinkNetwork,
+			Addr:    cfg.AuditSinkAddr,
+			Format:  audit.SinkFormat(cfg.AuditSinkFormat),
+		})
+		if err != nil {
+			log.Fatalf("audit sink yapılandırılamadı: %v", err)
+		}
+		go sink.Start(ctx)
+		audit.SetSink(sink)
+		log.Printf("Audit kayıtları SIEM'e iletiliyor (%s/%s, %s)", cfg.AuditSinkNetwork, cfg.AuditSinkAddr, cfg.AuditSinkFormat)
+	}
+	// track — değişiklik yapan uçların audit kaydı (bkz. audit.Logger.Track).
+	track := audit.New(db).Track
+	recoveryHandler := recovery.NewHandler(recoveryCtl)
+	runbookHandler := runbooks.NewHandler(runbookSvc)
+	scheduleHandler := schedules.NewHandler(scheduleSvc)
+	fleetHandler := fleet.NewHandler(fleetSvc)
+	approvalHandler := approvals.NewHandler(approvalSvc)
+	agentHandler := agents.NewHandler(agentRegistry, agentCreds, agentConfigs)
+	logHandler := logs.NewHandler(logSvc)
+	orgService := orgs.NewService(db)
+	orgService.SetMailer(m, cfg.FrontendURL)
+	orgHandler := orgs.NewHandler(orgService)
+	authz := orgs.NewAuthorizer(db)
 
 	// ── Kubernetes (optional) ─────────────────────────────────────
 	var k8sClient *k8s.Client
@@ -145,7 +303,23 @@ func main() {
 	} else {
 		log.Println("K8S_NAMESPACE tanımlanmadı — Kubernetes entegrasyonu devre dışı")
 	}
+	// Cluster tek bir organizasyona aittir; K8S_ORG_ID verilmezse k8s uçları 403 döner.
+	var k8sOrgID uuid.UUID
+	if cfg.K8sOrgID != "" {
+		id, err := uuid.Parse(cfg.K8sOrgID)
+		if err != nil {
+			log.Fatalf("K8S_ORG_ID geçersiz: %v", err)
+		}
+		k8sOrgID = id
+	} else if k8sClient != nil {
+		log.Println("[WARN] K8S_ORG_ID tanımlanmadı — Kubernetes uçları kapalı")
+	}
 	k8sHandler := k8s.NewHandler(k8sClient)
+	k8sHandler.SetApprovalGate(approvalSvc)
+	approvalSvc.SetClusterOrg(k8sOrgID)
+	if k8sClient != nil {
+		approvalSvc.SetClusterExecutor(k8sClient)
+	}
 
 	// ── Router ────────────────────────────────────────────────────
 	router := gin.New()
@@ -161,7 +335,12 @@ func main() {
 	strictLimiter := ratelimit.StrictMiddleware(10, time.Minute)
 
 	hub.SetOnCommandResult(func(commandID, status string, msg ws.AgentMessage) {
-		_ = cmdService.UpdateStatus(context.Background(), commandID, status, nil)
+		output := msg.Output
+		if output == nil {
+			output = msg.Error
+		}
+		_ = cmdService.RecordResult(context.Background(), commandID, status, output)
+		recoveryCtl.HandleCommandResult(commandID, status)
 	})
 
 	// Askıda kalan komutları periyodik olarak timeout'a al
@@ -188,87 +367,196 @@ func main() {
 		{
 			authGroup.POST("/register", authHandler.Register)
 			authGroup.POST("/login", authHandler.Login)
+			authGroup.POST("/login/2fa", authHandler.LoginTwoFactor)
 			authGroup.POST("/refresh", authHandler.Refresh)
 			authGroup.POST("/forgot-password", authHandler.ForgotPassword)
 			authGroup.POST("/reset-password", authHandler.ResetPassword)
+			authGroup.POST("/2fa/reset-request", authHandler.RequestTwoFactorReset)
+			authGroup.POST("/2fa/reset", authHandler.ConfirmTwoFactorReset)
+			authGroup.POST("/verify-email", authHandler.VerifyEmail)
+			authGroup.POST("/invitations/preview", authHandler.PreviewInvitation)
+			authGroup.GET("/oidc", authHandler.OIDCStatus)
+			authGroup.GET("/oidc/login", authHandler.OIDCLogin)
+			authGroup.GET("/oidc/callback", authHandler.OIDCCallback)
 			authGroup.POST("/logout", authMiddleware.Required(), authHandler.Logout)
 			authGroup.POST("/agent-token", authMiddleware.Required(), authHandler.AgentToken)
 			authGroup.GET("/me", authMiddleware.Required(), authHandler.Me)
 			authGroup.PUT("/password", authMiddleware.Required(), authHandler.ChangePassword)
+			authGroup.GET("/sessions", authMiddleware.Required(), authHandler.ListSessions)
+			authGroup.DELETE("/sessions", authMiddleware.Required(), authHandler.RevokeAllSessions)
+			authGroup.DELETE("/sessions/:sessionId", authMiddleware.Required(), authHandler.RevokeSession)
+			authGroup.GET("/api-keys", authMiddleware.Required(), authHandler.ListAPIKeys)
+			authGroup.POST("/api-keys", authMiddleware.Required(), authHandler.CreateAPIKey)
+			authGroup.DELETE("/api-keys/:keyId", authMiddleware.Required(), authHandler.RevokeAPIKey)
+			authGroup.GET("/2fa", authMiddleware.Required(), authHandler.TwoFactorStatus)
+			authGroup.POST("/2fa/setup", authMiddleware.Required(), authHandler.SetupTwoFactor)
+			authGroup.POST("/2fa/enable", authMiddleware.Required(), authHandler.EnableTwoFactor)
+			authGroup.POST("/2fa/disable", authMiddleware.Required(), authHandler.DisableTwoFactor)
+			authGroup.POST("/2fa/recovery-codes", authMiddleware.Required(), authHandler.RegenerateRecoveryCodes)
+			authGroup.POST("/users/:userId/2fa/reset", authMiddleware.Required(), authHandler.AdminResetTwoFactor)
+			authGroup.POST("/users/:userId/unlock", authMiddleware.Required(), authHandler.UnlockAccount)
+			authGroup.POST("/verify-email/resend", authMiddleware.Required(), authHandler.ResendVerification)
+			authGroup.POST("/invitations/accept", authMiddleware.Required(), authHandler.AcceptInvitation)
 		}
 
 		svcGroup := v1.Group("/services", authMiddleware.Required())
+		// Rol kontrolleri: okuma viewer, komut ve bakım işlemleri operator, yapılandırma admin.
+		viewer := authz.RequireServiceRole(orgs.RoleViewer)
+		operator := authz.RequireServiceRole(orgs.RoleOperator)
+		admin := authz.RequireServiceRole(orgs.RoleAdmin)
 		{
 			svcGroup.GET("", serviceHandler.List)
-			svcGroup.POST("", serviceHandler.Create)
-			svcGroup.GET("/:id", serviceHandler.Get)
-			svcGroup.PUT("/:id", serviceHandler.Update)
-			svcGroup.DELETE("/:id", serviceHandler.Delete)
-			svcGroup.GET("/:id/metrics", metricsHandler.GetHistory)
-			svcGroup.GET("/:id/metrics/aggregated", metricsHandler.GetAggregated)
-			svcGroup.GET("/:id/metrics/uptime", metricsHandler.GetUptime)
-			svcGroup.GET("/:id/metrics/rollup", metricsHandler.GetRollup)
-			svcGroup.GET("/:id/alerts", alertHandler.List)
-			svcGroup.GET("/:id/alert-rules", alertHandler.GetAlertRules)
-			svcGroup.PUT("/:id/alert-rules", alertHandler.UpsertAlertRules)
-			svcGroup.GET("/:id/maintenance", maintHandler.List)
-			svcGroup.POST("/:id/maintenance", maintHandler.Create)
-			svcGroup.DELETE("/:id/maintenance/:windowId", maintHandler.Delete)
-			svcGroup.GET("/:id/insights", aiHandler.GetInsights)
-			svcGroup.POST("/:id/restart", strictLimiter, serviceHandler.Restart)
-			svcGroup.POST("/:id/stop", strictLimiter, serviceHandler.Stop)
-			svcGroup.POST("/:id/start", strictLimiter, serviceHandler.Start)
-			svcGroup.POST("/:id/exec", strictLimiter, serviceHandler.Exec)
-			svcGroup.POST("/:id/scale", strictLimiter, serviceHandler.Scale)
-			svcGroup.POST("/:id/ping", serviceHandler.Ping)
-			svcGroup.POST("/:id/analyze", aiHandler.Analyze)
-			svcGroup.GET("/:id/commands", cmdHandler.GetHistory)
+			svcGroup.POST("", track(audit.ActionServiceCreate, "service"), serviceHandler.Create)
+			svcGroup.GET("/:id", viewer, serviceHandler.Get)
+			svcGroup.PUT("/:id", track(audit.ActionServiceUpdate, "service"), admin, serviceHandler.Update)
+			svcGroup.DELETE("/:id", track(audit.ActionServiceDelete, "service"), admin, serviceHandler.Delete)
+			svcGroup.GET("/:id/metrics", viewer, metricsHandler.GetHistory)
+			svcGroup.GET("/:id/metrics/aggregated", viewer, metricsHandler.GetAggregated)
+			svcGroup.GET("/:id/metrics/uptime", viewer, metricsHandler.GetUptime)
+			svcGroup.GET("/:id/metrics/rollup", viewer, metricsHandler.GetRollup)
+			svcGroup.GET("/:id/alerts", viewer, alertHandler.List)
+			svcGroup.GET("/:id/alert-rules", viewer, alertHandler.GetAlertRules)
+			svcGroup.PUT("/:id/alert-rules", track(audit.ActionAlertRulesUpdate, "service"), admin, alertHandler.UpsertAlertRules)
+			svcGroup.GET("/:id/maintenance", viewer, maintHandler.List)
+			svcGroup.POST("/:id/maintenance", track(audit.ActionMaintenanceCreate, "service"), operator, maintHandler.Create)
+			svcGroup.DELETE("/:id/maintenance/:windowId", track(audit.ActionMaintenanceDelete, "service"), operator, maintHandler.Delete)
+			svcGroup.GET("/:id/insights", viewer, aiHandler.GetInsights)
+			svcGroup.GET("/:id/agents", viewer, agentHandler.List)
+			svcGroup.GET("/:id/agents/:agentId/connections", viewer, agentHandler.Connections)
+			svcGroup.GET("/:id/agents/:agentId/link-metrics", viewer, agentHandler.LinkMetrics)
+			svcGroup.GET("/:id/agent-credentials", admin, agentHandler.ListCredentials)
+			svcGroup.POST("/:id/agent-credentials", admin, agentHandler.CreateCredential)
+			svcGroup.POST("/:id/agent-credentials/:credentialId/rotate", admin, agentHandler.RotateCredential)
+			svcGroup.DELETE("/:id/agent-credentials/:credentialId", admin, agentHandler.RevokeCredential)
+			svcGroup.GET("/:id/agent-config", viewer, agentHandler.GetConfig)
+			svcGroup.PUT("/:id/agent-config", admin, agentHandler.UpdateConfig)
+			svcGroup.GET("/:id/logs", viewer, logHandler.Search)
+			svcGroup.GET("/:id/exec-commands", viewer, serviceHandler.ListExecCommands)
+			svcGroup.POST("/:id/exec-commands", admin, serviceHandler.CreateExecCommand)
+			svcGroup.PUT("/:id/exec-commands/:name", admin, serviceHandler.UpdateExecCommand)
+			svcGroup.DELETE("/:id/exec-commands/:name", admin, serviceHandler.DeleteExecCommand)
+			svcGroup.GET("/:id/approval-policies", viewer, approvalHandler.ListServicePolicies)
+			svcGroup.PUT("/:id/approval-policies", track(audit.ActionApprovalPolicySet, "service"), admin, approvalHandler.SetServicePolicy)
+			svcGroup.DELETE("/:id/approval-policies/:policyId", track(audit.ActionApprovalPolicyDelete, "service"), admin, approvalHandler.DeleteServicePolicy)
+			svcGroup.POST("/:id/restart", track(audit.ActionServiceRestart, "service"), operator, strictLimiter, serviceHandler.Restart)
+			svcGroup.POST("/:id/stop", track(audit.ActionServiceStop, "service"), operator, strictLimiter, serviceHandler.Stop)
+			svcGroup.POST("/:id/start", track(audit.ActionServiceStart, "service"), operator, strictLimiter, serviceHandler.Start)
+			svcGroup.POST("/:id/exec", track(audit.ActionCommandExec, "service"), operator, strictLimiter, serviceHandler.Exec)
+			svcGroup.POST("/:id/scale", track(audit.ActionServiceScale, "service"), operator, strictLimiter, serviceHandler.Scale)
+			svcGroup.POST("/:id/ping", operator, serviceHandler.Ping)
+			svcGroup.POST("/:id/analyze", track(audit.ActionAIAnalyze, "service"), operator, aiHandler.Analyze)
+			svcGroup.GET("/:id/commands", viewer, cmdHandler.GetHistory)
+			svcGroup.POST("/:id/commands/:commandId/cancel", track(audit.ActionCommandCancel, "service"), operator, serviceHandler.CancelCommand)
+			svcGroup.GET("/:id/recovery", viewer, recoveryHandler.GetStatus)
+			svcGroup.GET("/:id/runbooks", viewer, runbookHandler.List)
+			svcGroup.POST("/:id/runbooks", track(audit.ActionRunbookCreate, "service"), admin, runbookHandler.Create)
+			svcGroup.GET("/:id/runbooks/:runbookId", viewer, runbookHandler.Get)
+			svcGroup.PUT("/:id/runbooks/:runbookId", track(audit.ActionRunbookUpdate, "service"), admin, runbookHandler.Update)
+			svcGroup.DELETE("/:id/runbooks/:runbookId", track(audit.ActionRunbookDelete, "service"), admin, runbookHandler.Delete)
+			svcGroup.POST("/:id/runbooks/:runbookId/run", operator, strictLimiter, runbookHandler.Run)
+			svcGroup.GET("/:id/runbooks/:runbookId/executions", viewer, runbookHandler.ListExecutions)
+			svcGroup.GET("/:id/runbook-executions/:executionId", viewer, runbookHandler.GetExecution)
+			svcGroup.GET("/:id/schedules", viewer, scheduleHandler.List)
+			svcGroup.POST("/:id/schedules", track(audit.ActionScheduleCreate, "service"), admin, scheduleHandler.Create)
+			svcGroup.PUT("/:id/schedules/:scheduleId", track(audit.ActionScheduleUpdate, "service"), admin, scheduleHandler.Update)
+			svcGroup.DELETE("/:id/schedules/:scheduleId", track(audit.ActionScheduleDelete, "service"), admin, scheduleHandler.Delete)
+			svcGroup.GET("/:id/schedules/:scheduleId/runs", viewer, scheduleHandler.Runs)
+		}
+
+		orgGroup := v1.Group("/orgs", authMiddleware.Required())
+		{
+			orgGroup.GET("", orgHandler.List)
+			orgGroup.POST("", orgHandler.Create)
+			orgGroup.GET("/:orgId", orgHandler.Get)
+			orgGroup.PUT("/:orgId", orgHandler.Update)
+			orgGroup.DELETE("/:orgId", orgHandler.Delete)
+			orgGroup.GET("/:orgId/members", orgHandler.ListMembers)
+			orgGroup.POST("/:orgId/members", orgHandler.AddMember)
+			orgGroup.PUT("/:orgId/members/:userId", orgHandler.UpdateMember)
+			orgGroup.DELETE("/:orgId/members/:userId", orgHandler.RemoveMember)
+			orgGroup.GET("/:orgId/invitations", orgHandler.ListInvitations)
+			orgGroup.POST("/:orgId/invitations", orgHandler.CreateInvitation)
+			orgGroup.DELETE("/:orgId/invitations/:invitationId", orgHandler.RevokeInvitation)
+			orgGroup.GET("/:orgId/teams", orgHandler.ListTeams)
+			orgGroup.POST("/:orgId/teams", orgHandler.CreateTeam)
+			orgGroup.PUT("/:orgId/teams/:teamId", orgHandler.UpdateTeam)
+			orgGroup.DELETE("/:orgId/teams/:teamId", orgHandler.DeleteTeam)
+			orgGroup.POST("/:orgId/teams/:teamId/members", orgHandler.AddTeamMember)
+			orgGroup.DELETE("/:orgId/teams/:teamId/members/:userId", orgHandler.RemoveTeamMember)
+			orgGroup.POST("/:orgId/teams/:teamId/services", orgHandler.AddTeamService)
+			orgGroup.DELETE("/:orgId/teams/:teamId/services/:serviceId", orgHandler.RemoveTeamService)
 		}
 
 		alertsGroup := v1.Group("/alerts", authMiddleware.Required())
 		{
 			alertsGroup.GET("", alertHandler.GetActive)
-			alertsGroup.POST("/:alertId/resolve", alertHandler.Resolve)
+			alertsGroup.POST("/:alertId/resolve", track(audit.ActionAlertResolve, "alert"), alertHandler.Resolve)
+		}
+
+		approvalGroup := v1.Group("/approvals", authMiddleware.Required())
+		{
+			approvalGroup.GET("", approvalHandler.List)
+			approvalGroup.GET("/:approvalId", approvalHandler.Get)
+			approvalGroup.POST("/:approvalId/approve", strictLimiter, approvalHandler.Approve)
+			approvalGroup.POST("/:approvalId/reject", approvalHandler.Reject)
+		}
+
+		fleetGroup := v1.Group("/fleet", authMiddleware.Required())
+		{
+			fleetGroup.GET("/operations", fleetHandler.List)
+			fleetGroup.POST("/operations", strictLimiter, fleetHandler.Create)
+			fleetGroup.GET("/operations/:opId", fleetHandler.Get)
 		}
 
 		settingsGroup := v1.Group("/settings", authMiddleware.Required())
 		{
 			settingsGroup.GET("", settingsHandler.Get)
-			settingsGroup.PUT("", settingsHandler.Update)
+			settingsGroup.PUT("", track(audit.ActionSettingsUpdate, "settings"), settingsHandler.Update)
 		}
 
 		auditGroup := v1.Group("/audit", authMiddleware.Required())
 		{
 			auditGroup.GET("", auditHandler.GetLogs)
+			auditGroup.GET("/verify", strictLimiter, auditHandler.Verify)
+			auditGroup.GET("/export", track(audit.ActionAuditExport, "audit"), strictLimiter, auditHandler.Export)
 		}
 
+		// Agent'ların komut imzasını doğrulamak için açık anahtarlar (kimlik doğrulama yok)
+		v1.GET("/agent/signing-keys", wsHandler.SigningKeys)
+
 		// Tek istekle tüm servislerin uptime özetini döndürür (N+1 önleme)
 		v1.GET("/services/uptime/summary", authMiddleware.Required(), metricsHandler.GetBulkUptime)
 
 		k8sGroup := v1.Group("/k8s", authMiddleware.Required())
+		k8sViewer := authz.RequireOrgRole(k8sOrgID, orgs.RoleViewer)
+		k8sOperator := authz.RequireOrgRole(k8sOrgID, orgs.RoleOperator)
+		k8sAdmin := authz.RequireOrgRole(k8sOrgID, orgs.RoleAdmin)
 		{
-			k8sGroup.GET("/status", k8sHandler.GetStatus)
-			k8sGroup.GET("/namespaces", k8sHandler.ListNamespaces)
-			k8sGroup.GET("/nodes", k8sHandler.GetNodes)
-			k8sGroup.GET("/pods", k8sHandler.GetPods)
-			k8sGroup.GET("/pods/all", k8sHandler.GetAllPods)
-			k8sGroup.GET("/pods/:name/logs", k8sHandler.GetPodLogs)
-			k8sGroup.DELETE("/pods/:name", strictLimiter, k8sHandler.DeletePod)
-			k8sGroup.GET("/deployments", k8sHandler.ListDeployments)
-			k8sGroup.GET("/deployments/:name", k8sHandler.GetDeployment)
-			k8sGroup.POST("/deployments/:name/scale", strictLimiter, k8sHandler.ScaleDeployment)
-			k8sGroup.POST("/deployments/:name/restart", strictLimiter, k8sHandler.RolloutRestart)
-			k8sGroup.GET("/hpa", k8sHandler.ListHPAs)
-			k8sGroup.GET("/hpa/:name", k8sHandler.GetHPA)
-			k8sGroup.POST("/hpa", strictLimiter, k8sHandler.CreateOrUpdateHPA)
-			k8sGroup.DELETE("/hpa/:name", strictLimiter, k8sHandler.DeleteHPA)
-			k8sGroup.GET("/services", k8sHandler.ListServices)
-			k8sGroup.GET("/endpoints/:name", k8sHandler.GetServiceEndpoints)
-			k8sGroup.GET("/events", k8sHandler.GetEvents)
-			k8sGroup.GET("/top/pods", k8sHandler.GetTopPods)
-			k8sGroup.GET("/top/nodes", k8sHandler.GetTopNodes)
-			k8sGroup.POST("/deploy", strictLimiter, k8sHandler.DeployService)
-			k8sGroup.DELETE("/deploy/:name", strictLimiter, k8sHandler.UndeployService)
+			k8sGroup.GET("/status", k8sViewer, k8sHandler.GetStatus)
+			k8sGroup.GET("/namespaces", k8sViewer, k8sHandler.ListNamespaces)
+			k8sGroup.GET("/nodes", k8sViewer, k8sHandler.GetNodes)
+			k8sGroup.GET("/pods", k8sViewer, k8sHandler.GetPods)
+			k8sGroup.GET("/pods/all", k8sViewer, k8sHandler.GetAllPods)
+			k8sGroup.GET("/pods/:name/logs", k8sViewer, k8sHandler.GetPodLogs)
+			k8sGroup.DELETE("/pods/:name", track(audit.ActionK8sPodDelete, "k8s"), k8sAdmin, strictLimiter, k8sHandler.DeletePod)
+			k8sGroup.GET("/deployments", k8sViewer, k8sHandler.ListDeployments)
+			k8sGroup.GET("/deployments/:name", k8sViewer, k8sHandler.GetDeployment)
+			k8sGroup.POST("/deployments/:name/scale", track(audit.ActionK8sScale, "k8s"), k8sOperator, strictLimiter, k8sHandler.ScaleDeployment)
+			k8sGroup.POST("/deployments/:name/restart", track(audit.ActionK8sRestart, "k8s"), k8sOperator, strictLimiter, k8sHandler.RolloutRestart)
+			k8sGroup.GET("/hpa", k8sViewer, k8sHandler.ListHPAs)
+			k8sGroup.GET("/hpa/:name", k8sViewer, k8sHandler.GetHPA)
+			k8sGroup.POST("/hpa", track(audit.ActionK8sHPAUpdate, "k8s"), k8sAdmin, strictLimiter, k8sHandler.CreateOrUpdateHPA)
+			k8sGroup.DELETE("/hpa/:name", track(audit.ActionK8sHPADelete, "k8s"), k8sAdmin, strictLimiter, k8sHandler.DeleteHPA)
+			k8sGroup.GET("/services", k8sViewer, k8sHandler.ListServices)
+			k8sGroup.GET("/endpoints/:name", k8sViewer, k8sHandler.GetServiceEndpoints)
+			k8sGroup.GET("/events", k8sViewer, k8sHandler.GetEvents)
+			k8sGroup.GET("/top/pods", k8sViewer, k8sHandler.GetTopPods)
+			k8sGroup.GET("/top/nodes", k8sViewer, k8sHandler.GetTopNodes)
+			k8sGroup.POST("/deploy", track(audit.ActionK8sDeploy, "k8s"), k8sAdmin, strictLimiter, k8sHandler.DeployService)
+			k8sGroup.DELETE("/deploy/:name", track(audit.ActionK8sUndeploy, "k8s"), k8sAdmin, strictLimiter, k8sHandler.UndeployService)
+			k8sGroup.GET("/approval-policies", k8sViewer, approvalHandler.ListClusterPolicies)
+			k8sGroup.PUT("/approval-policies", track(audit.ActionApprovalPolicySet, "k8s"), k8sAdmin, approvalHandler.SetClusterPolicy)
+			k8sGroup.DELETE("/approval-policies/:policyId", track(audit.ActionApprovalPolicyDelete, "k8s"), k8sAdmin, approvalHandler.DeleteClusterPolicy)
 		}
 	}
 
@@ -278,6 +566,7 @@ func main() {
 	{
 		wsGroup.GET("/dashboard", wsHandler.Dashboard)
 		wsGroup.GET("/services/:id", wsHandler.ServiceStream)
+		wsGroup.GET("/services/:id/logs", wsHandler.ServiceLogs)
 		wsGroup.GET("/agent", wsHandler.AgentConnect)
 	}
 
@@ -383,3 +672,34 @@ func corsMiddleware(frontendURL string, extraOrigins []string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// newOIDCProvider — OIDC ayarlarını doğrular; hatalı yapılandırmada başlatmayı durdurur.
+func newOIDCProvider(cfg *config.Config) *auth.OIDCProvider {
+	if cfg.OIDCClientID == "" || cfg.OIDCRedirectURL == "" {
+		log.Fatal("OIDC_ISSUER tanımlıyken OIDC_CLIENT_ID ve OIDC_REDIRECT_URL zorunlu")
+	}
+	groupRoles, err := auth.ParseGroupRoles(cfg.OIDCGroupRoles)
+	if err != nil {
+		log.Fatalf("OIDC_GROUP_ROLES: %v", err)
+	}
+	var orgID uuid.UUID
+	if cfg.OIDCOrgID != "" {
+		if orgID, err = uuid.Parse(cfg.OIDCOrgID); err != nil {
+			log.Fatalf("OIDC_ORG_ID geçersiz: %v", err)
+		}
+	}
+	if len(groupRoles) > 0 && orgID == uuid.Nil {
+		log.Println("[WARN] OIDC_GROUP_ROLES tanımlı ama OIDC_ORG_ID yok — grup rolleri uygulanmayacak")
+	}
+	return auth.NewOIDCProvider(auth.OIDCConfig{
+		Issuer:         cfg.OIDCIssuer,
+		ClientID:       cfg.OIDCClientID,
+		ClientSecret:   cfg.OIDCClientSecret,
+		RedirectURL:    cfg.OIDCRedirectURL,
+		Scopes:         cfg.OIDCScopes,
+		AllowedDomains: cfg.OIDCAllowedDomains,
+		GroupsClaim:    cfg.OIDCGroupsClaim,
+		GroupRoles:     groupRoles,
+		OrgID:          orgID,
+	})
+}
@@ -0,0 +1,186 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"nanonet-backend/internal/ws"
+	"nanonet-backend/pkg/audit"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	maxLogPaths     = 20
+	maxLogPathLen   = 500
+	maxConfigErrLen = 500
+)
+
+var ErrInvalidConfig = errors.New("geçersiz agent yapılandırması")
+
+// windowsAbsPath — "C:\logs\app.log" veya "C:/logs/app.log" biçimindeki yollar.
+var windowsAbsPath = regexp.MustCompile(`^[A-Za-z]:[\\/]`)
+
+// ConfigStore — servislerin merkezi agent yapılandırmasını saklar, sürümler ve agent'lara iletir.
+type ConfigStore struct {
+	repo        *Repository
+	hub         *ws.Hub
+	auditLogger *audit.Logger
+}
+
+func NewConfigStore(db *gorm.DB, hub *ws.Hub) *ConfigStore {
+	return &ConfigStore{
+		repo:        NewRepository(db),
+		hub:         hub,
+		auditLogger: audit.New(db),
+	}
+}
+
+// Attach — agent'ların config_ack yanıtlarını agent kayıtlarına yazar.
+func (s *ConfigStore) Attach() {
+	s.hub.SetOnConfigAck(s.handleAck)
+}
+
+func (s *ConfigStore) Get(ctx context.Context, serviceID uuid.UUID) (*Config, error) {
+	return s.repo.GetConfig(ctx, serviceID)
+}
+
+// Update — yapılandırmayı doğrular, sürümünü artırır ve bağlı agent'lara gönderir.
+func (s *ConfigStore) Update(ctx context.Context, serviceID, userID uuid.UUID, req UpdateConfigRequest) (*Config, error) {
+	req, err := normalizeConfig(req)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := s.repo.UpdateConfig(ctx, serviceID, userID, req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	s.hub.PushAgentConfig(serviceID.String(), "", cfg.Message())
+
+	s.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &userID,
+		Action:       audit.ActionAgentConfigUpdate,
+		ResourceType: "service",
+		ResourceID:   &serviceID,
+		Status:       audit.StatusSuccess,
+		Details: map[string]interface{}{
+			"version":           cfg.Version,
+			"poll_interval_sec": cfg.PollIntervalSec,
+			"collectors":        cfg.Collectors,
+			"log_paths":         cfg.LogPaths,
+		},
+	})
+	return cfg, nil
+}
+
+// ConfigChanged — servis kaydındaki bir alan (örn. poll_interval_sec) değiştiğinde sürümü
+// artırır ve yeni yapılandırmayı agent'lara gönderir.
+func (s *ConfigStore) ConfigChanged(ctx context.Context, serviceID uuid.UUID) error {
+	cfg, err := s.repo.BumpConfigVersion(ctx, serviceID, time.Now())
+	if err != nil {
+		return err
+	}
+	s.hub.PushAgentConfig(serviceID.String(), "", cfg.Message())
+	return nil
+}
+
+// PushTo — yeni tanıtım yapan agent'a güncel yapılandırmayı gönderir.
+func (s *ConfigStore) PushTo(ctx context.Context, serviceID uuid.UUID, agentID string) {
+	cfg, err := s.repo.GetConfig(ctx, serviceID)
+	if err != nil {
+		log.Printf("[agents] yapılandırma okunamadı service=%s: %v", serviceID, err)
+		return
+	}
+	s.hub.PushAgentConfig(serviceID.String(), agentID, cfg.Message())
+}
+
+func (s *ConfigStore) handleAck(sess ws.AgentSession, msg ws.AgentMessage) {
+	serviceID, err := uuid.Parse(sess.ServiceID)
+	if err != nil {
+		return
+	}
+	var errMsg *string
+	if msg.Error != nil && *msg.Error != "" {
+		e := truncate(*msg.Error, maxConfigErrLen)
+		errMsg = &e
+	}
+	if msg.Status == "rejected" && errMsg != nil {
+		log.Printf("[agents] agent yapılandırmayı reddetti service=%s agent=%s version=%d: %s",
+			sess.ServiceID, sess.AgentID, msg.ConfigVersion, *errMsg)
+	}
+	if err := s.repo.RecordConfigAck(context.Background(), serviceID, sess.AgentID, msg.ConfigVersion, msg.Status, errMsg, time.Now()); err != nil {
+		log.Printf("[agents] config_ack kaydedilemedi service=%s agent=%s: %v", sess.ServiceID, sess.AgentID, err)
+	}
+}
+
+// normalizeConfig — toplayıcıları ve log yollarını doğrular, tekrarları atar.
+func normalizeConfig(req UpdateConfigRequest) (UpdateConfigRequest, error) {
+	if req.Collectors != nil {
+		collectors := make([]string, 0, len(req.Collectors))
+		for _, c := range req.Collectors {
+			c = strings.TrimSpace(c)
+			if !containsString(ws.ConfigCollectors, c) {
+				return req, fmt.Errorf("%w: bilinmeyen toplayıcı %q (geçerli: %s)", ErrInvalidConfig, c, strings.Join(ws.ConfigCollectors, ", "))
+			}
+			if !containsString(collectors, c) {
+				collectors = append(collectors, c)
+			}
+		}
+		req.Collectors = collectors
+	}
+
+	if req.LogPaths != nil {
+		if len(req.LogPaths) > maxLogPaths {
+			return req, fmt.Errorf("%w: en fazla %d log yolu tanımlanabilir", ErrInvalidConfig, maxLogPaths)
+		}
+		paths := make([]string, 0, len(req.LogPaths))
+		for _, p := range req.LogPaths {
+			p = strings.TrimSpace(p)
+			if err := validateLogPath(p); err != nil {
+				return req, err
+			}
+			if !containsString(paths, p) {
+				paths = append(paths, p)
+			}
+		}
+		req.LogPaths = paths
+	}
+	return req, nil
+}
+
+// validateLogPath — yolun biçimini denetler. Hangi dosyaların okunabileceğine agent kendi yerel
+// izin listesiyle (NANONET_LOG_ALLOW) karar verir; liste dışındaki yolları içeren config reddedilir.
+func validateLogPath(p string) error {
+	switch {
+	case p == "":
+		return fmt.Errorf("%w: log yolu boş olamaz", ErrInvalidConfig)
+	case len(p) > maxLogPathLen:
+		return fmt.Errorf("%w: log yolu en fazla %d karakter olabilir", ErrInvalidConfig, maxLogPathLen)
+	case strings.ContainsRune(p, 0):
+		return fmt.Errorf("%w: log yolu geçersiz karakter içeriyor", ErrInvalidConfig)
+	case !strings.HasPrefix(p, "/") && !windowsAbsPath.MatchString(p):
+		return fmt.Errorf("%w: log yolu mutlak olmalı: %s", ErrInvalidConfig, p)
+	}
+	for _, seg := range strings.FieldsFunc(p, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if seg == ".." {
+			return fmt.Errorf("%w: log yolu '..' içeremez: %s", ErrInvalidConfig, p)
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package agents
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeConfig(t *testing.T) {
+	req, err := normalizeConfig(UpdateConfigRequest{
+		Collectors: []string{" system", "process", "system"},
+		LogPaths:   []string{"/var/log/app.log", " /var/log/app.log ", `C:\logs\app.log`},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"system", "process"}, req.Collectors)
+	assert.Equal(t, []string{"/var/log/app.log", `C:\logs\app.log`}, req.LogPaths)
+
+	req, err = normalizeConfig(UpdateConfigRequest{Collectors: []string{}})
+	require.NoError(t, err)
+	assert.NotNil(t, req.Collectors, "boş liste tüm toplayıcıları kapatır")
+	assert.Nil(t, req.LogPaths, "verilmeyen alan değiştirilmez")
+
+	for _, bad := range []UpdateConfigRequest{
+		{Collectors: []string{"gpu"}},
+		{LogPaths: []string{"logs/app.log"}},
+		{LogPaths: []string{"/var/log/../../etc/shadow"}},
+		{LogPaths: []string{""}},
+		{LogPaths: make([]string, maxLogPaths+1)},
+	} {
+		_, err := normalizeConfig(bad)
+		assert.ErrorIs(t, err, ErrInvalidConfig, "%+v", bad)
+	}
+}
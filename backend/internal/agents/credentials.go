@@ -0,0 +1,225 @@
+package agents
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"nanonet-backend/internal/orgs"
+	"nanonet-backend/internal/ws"
+	"nanonet-backend/pkg/audit"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxCredentialTTL — kimlik bilgisi için verilebilecek en uzun geçerlilik süresi.
+	maxCredentialTTL = 5 * 365 * 24 * time.Hour
+	// maxRotationGrace — rotasyonda eski kimlik bilgisinin geçerli kalabileceği en uzun süre.
+	maxRotationGrace = 24 * time.Hour
+	// tokenPrefixLen — listede gösterilen token başlangıcının uzunluğu.
+	tokenPrefixLen = 12
+)
+
+var (
+	ErrCredentialNotFound = errors.New("kimlik bilgisi bulunamadı")
+	ErrCredentialInactive = errors.New("kimlik bilgisi iptal edilmiş veya süresi dolmuş")
+)
+
+// IssuedCredential — yeni oluşturulan kimlik bilgisi ve yalnızca bu yanıtta gösterilen token.
+type IssuedCredential struct {
+	Credential
+	Token string `json:"token"`
+}
+
+// CredentialStore — servise bağlı agent kimlik bilgilerini üretir, doğrular ve iptal eder.
+type CredentialStore struct {
+	repo        *Repository
+	hub         *ws.Hub
+	auditLogger *audit.Logger
+}
+
+func NewCredentialStore(db *gorm.DB, hub *ws.Hub) *CredentialStore {
+	return &CredentialStore{
+		repo:        NewRepository(db),
+		hub:         hub,
+		auditLogger: audit.New(db),
+	}
+}
+
+// Issue — servis için yeni kimlik bilgisi üretir. ttl 0 ise süresizdir.
+func (s *CredentialStore) Issue(ctx context.Context, serviceID, userID uuid.UUID, name string, ttl time.Duration) (*IssuedCredential, error) {
+	issued, err := newCredential(serviceID, userID, name, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateCredential(ctx, &issued.Credential); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, audit.ActionAgentCredentialCreate, userID, issued.ID, map[string]any{
+		"service_id": serviceID,
+		"name":       issued.Name,
+	})
+	return issued, nil
+}
+
+// Rotate — kimlik bilgisinin yerine yenisini üretir. grace 0 ise eski kimlik bilgisi hemen
+// iptal edilir ve onunla açılmış bağlantılar kapatılır; değilse grace sonunda süresi dolar.
+func (s *CredentialStore) Rotate(ctx context.Context, serviceID, credentialID, userID uuid.UUID, ttl, grace time.Duration) (*IssuedCredential, error) {
+	old, err := s.get(ctx, serviceID, credentialID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !old.Active(now) || old.RotatedTo != nil {
+		return nil, ErrCredentialInactive
+	}
+
+	issued, err := newCredential(serviceID, userID, old.Name, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	var retireAt *time.Time
+	if grace > 0 {
+		t := now.Add(grace)
+		if old.ExpiresAt != nil && old.ExpiresAt.Before(t) {
+			t = *old.ExpiresAt
+		}
+		retireAt = &t
+	}
+	if err := s.repo.RotateCredential(ctx, old.ID, &issued.Credential, userID, now, retireAt); err != nil {
+		return nil, err
+	}
+	if retireAt == nil {
+		s.hub.DisconnectCredential(old.ID.String())
+	} else {
+		// Bağlı agent'lar bağlantı anındaki süreyi taşır; grace sonunda kapatılmaları için
+		// güncellenir. Diğer backend örnekleri bunu Registry turunda veritabanından okur.
+		s.hub.SetCredentialExpiry(old.ID.String(), retireAt)
+	}
+
+	s.record(ctx, audit.ActionAgentCredentialRotate, userID, old.ID, map[string]any{
+		"service_id":     serviceID,
+		"new_credential": issued.ID,
+		"grace_sec":      int(grace.Seconds()),
+	})
+	return issued, nil
+}
+
+// Revoke — kimlik bilgisini iptal eder ve onunla açılmış tüm agent bağlantılarını kapatır.
+func (s *CredentialStore) Revoke(ctx context.Context, serviceID, credentialID, userID uuid.UUID) error {
+	cred, err := s.get(ctx, serviceID, credentialID)
+	if err != nil {
+		return err
+	}
+	revoked, err := s.repo.RevokeCredential(ctx, cred.ID, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	// Zaten iptal edilmiş olsa da bağlantı kapatma isteği tekrarlanır (idempotent).
+	s.hub.DisconnectCredential(cred.ID.String())
+
+	if revoked {
+		s.record(ctx, audit.ActionAgentCredentialRevoke, userID, cred.ID, map[string]any{
+			"service_id": serviceID,
+		})
+	}
+	return nil
+}
+
+func (s *CredentialStore) List(ctx context.Context, serviceID uuid.UUID) ([]Credential, error) {
+	return s.repo.ListCredentials(ctx, serviceID)
+}
+
+// AuthenticateAgent — ws.AgentAuthenticator; token'ı özetiyle arar ve geçerliliğini doğrular.
+func (s *CredentialStore) AuthenticateAgent(ctx context.Context, token string) (*ws.AgentCredential, error) {
+	cred, err := s.repo.FindCredentialByHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCredentialNotFound
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if !cred.Active(now) {
+		return nil, ErrCredentialInactive
+	}
+	if err := s.repo.TouchCredential(ctx, cred.ID, now); err != nil {
+		log.Printf("[agents] kimlik bilgisi last_used_at güncellenemedi (%s): %v", cred.ID, err)
+	}
+	return &ws.AgentCredential{
+		ID:        cred.ID.String(),
+		ServiceID: cred.ServiceID.String(),
+		ExpiresAt: cred.ExpiresAt,
+	}, nil
+}
+
+// HasServiceRole — ws.AgentAuthenticator; kullanıcının servis üzerindeki rolünü doğrular.
+func (s *CredentialStore) HasServiceRole(ctx context.Context, userID, serviceID, need string) (bool, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return false, nil
+	}
+	sid, err := uuid.Parse(serviceID)
+	if err != nil {
+		return false, nil
+	}
+	return orgs.HasServiceRole(ctx, s.repo.db, sid, uid, need)
+}
+
+func (s *CredentialStore) get(ctx context.Context, serviceID, credentialID uuid.UUID) (*Credential, error) {
+	cred, err := s.repo.GetCredential(ctx, serviceID, credentialID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCredentialNotFound
+		}
+		return nil, err
+	}
+	return cred, nil
+}
+
+func (s *CredentialStore) record(ctx context.Context, action audit.Action, userID, credentialID uuid.UUID, details map[string]any) {
+	s.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &userID,
+		Action:       action,
+		ResourceType: "agent_credential",
+		ResourceID:   &credentialID,
+		Status:       audit.StatusSuccess,
+		Details:      details,
+	})
+}
+
+func newCredential(serviceID, userID uuid.UUID, name string, ttl time.Duration) (*IssuedCredential, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	token := ws.AgentCredentialPrefix + hex.EncodeToString(b)
+
+	cred := Credential{
+		ID:          uuid.New(),
+		ServiceID:   serviceID,
+		Name:        name,
+		TokenPrefix: token[:tokenPrefixLen],
+		TokenHash:   hashToken(token),
+		CreatedBy:   &userID,
+		CreatedAt:   time.Now(),
+	}
+	if ttl > 0 {
+		t := cred.CreatedAt.Add(ttl)
+		cred.ExpiresAt = &t
+	}
+	return &IssuedCredential{Credential: cred, Token: token}, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
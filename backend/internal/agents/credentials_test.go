@@ -0,0 +1,32 @@
+package agents
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialDeadlines(t *testing.T) {
+	now := time.Now()
+	retire := now.Add(time.Hour)
+	revoked := now.Add(-time.Minute)
+
+	graced := Credential{ID: uuid.New(), ExpiresAt: &retire}
+	revokedCred := Credential{ID: uuid.New(), ExpiresAt: &retire, RevokedAt: &revoked}
+	open := Credential{ID: uuid.New()}
+	deleted := uuid.New()
+
+	got := credentialDeadlines(
+		[]uuid.UUID{graced.ID, revokedCred.ID, open.ID, deleted},
+		[]Credential{graced, revokedCred, open},
+		now,
+	)
+	require.Len(t, got, 4)
+	assert.Equal(t, retire, *got[graced.ID.String()], "rotasyon grace sonu bağlantıya taşınır")
+	assert.Equal(t, revoked, *got[revokedCred.ID.String()], "iptal anı süre dolumundan önce gelir")
+	assert.Nil(t, got[open.ID.String()])
+	assert.Equal(t, now, *got[deleted.String()], "silinmiş kimlik bilgisinin bağlantısı kapatılır")
+}
@@ -0,0 +1,300 @@
+package agents
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"nanonet-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	registry *Registry
+	creds    *CredentialStore
+	configs  *ConfigStore
+}
+
+func NewHandler(registry *Registry, creds *CredentialStore, configs *ConfigStore) *Handler {
+	return &Handler{registry: registry, creds: creds, configs: configs}
+}
+
+// List — GET /services/:id/agents
+// Servise bağlanmış tüm agent'ları (bağlantısı kopmuş olanlar dahil) listeler.
+func (h *Handler) List(c *gin.Context) {
+	serviceID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+
+	agents, err := h.registry.List(c.Request.Context(), serviceID)
+	if err != nil {
+		response.InternalError(c, "agent'lar alınamadı")
+		return
+	}
+
+	connected := 0
+	for _, a := range agents {
+		if a.Connected {
+			connected++
+		}
+	}
+	response.Success(c, gin.H{
+		"agents":    agents,
+		"total":     len(agents),
+		"connected": connected,
+	})
+}
+
+// Connections — GET /services/:id/agents/:agentId/connections
+// Agent'ın bağlantı/kopma geçmişini en yeniden eskiye döndürür.
+func (h *Handler) Connections(c *gin.Context) {
+	serviceID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	conns, total, err := h.registry.ListConnections(c.Request.Context(), serviceID, c.Param("agentId"), limit, offset)
+	if err != nil {
+		response.InternalError(c, "bağlantı geçmişi alınamadı")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"connections": conns,
+		"total":       total,
+		"page":        page,
+	})
+}
+
+// LinkMetrics — GET /services/:id/agents/:agentId/link-metrics
+// Agent bağlantısının RTT, yeniden bağlanma ve atlanan mesaj örneklerini döndürür.
+func (h *Handler) LinkMetrics(c *gin.Context) {
+	serviceID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+
+	durationStr := c.DefaultQuery("duration", "1h")
+	duration, err := time.ParseDuration(durationStr)
+	if err != nil || duration < time.Minute || duration > 7*24*time.Hour {
+		response.BadRequest(c, "duration 1m ile 168h arasında olmalı (örn: 30m, 1h, 24h)")
+		return
+	}
+
+	samples, err := h.registry.ListLinkMetrics(c.Request.Context(), serviceID, c.Param("agentId"), duration)
+	if err != nil {
+		response.InternalError(c, "bağlantı kalitesi örnekleri alınamadı")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"agent_id": c.Param("agentId"),
+		"duration": durationStr,
+		"samples":  samples,
+	})
+}
+
+// ListCredentials — GET /services/:id/agent-credentials
+func (h *Handler) ListCredentials(c *gin.Context) {
+	serviceID, _, ok := h.authorizeUser(c)
+	if !ok {
+		return
+	}
+
+	creds, err := h.creds.List(c.Request.Context(), serviceID)
+	if err != nil {
+		response.InternalError(c, "kimlik bilgileri alınamadı")
+		return
+	}
+	response.Success(c, gin.H{"credentials": creds})
+}
+
+// CreateCredential — POST /services/:id/agent-credentials
+// Token yalnızca bu yanıtta döner; sonradan görüntülenemez.
+func (h *Handler) CreateCredential(c *gin.Context) {
+	serviceID, userID, ok := h.authorizeUser(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name          string `json:"name" binding:"required,max=100"`
+		ExpiresInDays int    `json:"expires_in_days" binding:"min=0"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+	ttl, ok := credentialTTL(c, req.ExpiresInDays)
+	if !ok {
+		return
+	}
+
+	issued, err := h.creds.Issue(c.Request.Context(), serviceID, userID, req.Name, ttl)
+	if err != nil {
+		response.InternalError(c, "kimlik bilgisi oluşturulamadı")
+		return
+	}
+	response.Created(c, issued)
+}
+
+// RotateCredential — POST /services/:id/agent-credentials/:credentialId/rotate
+// grace_sec 0 ise eski kimlik bilgisi hemen iptal edilir ve bağlantıları kapatılır.
+func (h *Handler) RotateCredential(c *gin.Context) {
+	serviceID, userID, ok := h.authorizeUser(c)
+	if !ok {
+		return
+	}
+	credentialID, err := uuid.Parse(c.Param("credentialId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz kimlik bilgisi ID")
+		return
+	}
+
+	var req struct {
+		ExpiresInDays int `json:"expires_in_days"`
+		GraceSec      int `json:"grace_sec"`
+	}
+	_ = c.ShouldBindJSON(&req)
+	ttl, ok := credentialTTL(c, req.ExpiresInDays)
+	if !ok {
+		return
+	}
+	grace := time.Duration(req.GraceSec) * time.Second
+	if grace < 0 || grace > maxRotationGrace {
+		response.BadRequest(c, "grace_sec 0-86400 aralığında olmalı")
+		return
+	}
+
+	issued, err := h.creds.Rotate(c.Request.Context(), serviceID, credentialID, userID, ttl, grace)
+	if err != nil {
+		writeCredentialError(c, err, "kimlik bilgisi yenilenemedi")
+		return
+	}
+	response.Created(c, issued)
+}
+
+// RevokeCredential — DELETE /services/:id/agent-credentials/:credentialId
+// Kimlik bilgisiyle açılmış canlı agent bağlantıları hemen kapatılır.
+func (h *Handler) RevokeCredential(c *gin.Context) {
+	serviceID, userID, ok := h.authorizeUser(c)
+	if !ok {
+		return
+	}
+	credentialID, err := uuid.Parse(c.Param("credentialId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz kimlik bilgisi ID")
+		return
+	}
+
+	if err := h.creds.Revoke(c.Request.Context(), serviceID, credentialID, userID); err != nil {
+		writeCredentialError(c, err, "kimlik bilgisi iptal edilemedi")
+		return
+	}
+	response.Success(c, gin.H{"message": "kimlik bilgisi iptal edildi"})
+}
+
+// GetConfig — GET /services/:id/agent-config
+func (h *Handler) GetConfig(c *gin.Context) {
+	serviceID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+
+	cfg, err := h.configs.Get(c.Request.Context(), serviceID)
+	if err != nil {
+		response.InternalError(c, "agent yapılandırması alınamadı")
+		return
+	}
+	response.Success(c, cfg)
+}
+
+// UpdateConfig — PUT /services/:id/agent-config
+// Sürüm artırılır ve yapılandırma bağlı agent'lara hemen gönderilir; agent'ların uyguladığı
+// sürüm GET /services/:id/agents yanıtındaki config_version/config_status alanlarında görünür.
+func (h *Handler) UpdateConfig(c *gin.Context) {
+	serviceID, userID, ok := h.authorizeUser(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	cfg, err := h.configs.Update(c.Request.Context(), serviceID, userID, req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidConfig) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.InternalError(c, "agent yapılandırması güncellenemedi")
+		return
+	}
+	response.Success(c, cfg)
+}
+
+func credentialTTL(c *gin.Context, days int) (time.Duration, bool) {
+	ttl := time.Duration(days) * 24 * time.Hour
+	if days < 0 || ttl > maxCredentialTTL {
+		response.BadRequest(c, "expires_in_days 0-1825 aralığında olmalı")
+		return 0, false
+	}
+	return ttl, true
+}
+
+func writeCredentialError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrCredentialNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrCredentialInactive):
+		response.Error(c, 409, err.Error())
+	default:
+		response.InternalError(c, fallback)
+	}
+}
+
+func (h *Handler) authorize(c *gin.Context) (uuid.UUID, bool) {
+	serviceID, _, ok := h.authorizeUser(c)
+	return serviceID, ok
+}
+
+func (h *Handler) authorizeUser(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz servis ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	owner, err := h.registry.IsServiceOwner(c.Request.Context(), serviceID, userID)
+	if err != nil {
+		response.InternalError(c, "servis doğrulanamadı")
+		return uuid.Nil, uuid.Nil, false
+	}
+	if !owner {
+		response.NotFound(c, "servis bulunamadı")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return serviceID, userID, true
+}
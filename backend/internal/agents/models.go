@@ -0,0 +1,135 @@
+package agents
+
+import (
+	"time"
+
+	"nanonet-backend/internal/ws"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Agent — bir servise bağlanmış (veya bağlanmış olan) agent'ın kalıcı kaydı.
+type Agent struct {
+	ID                 uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ServiceID          uuid.UUID      `gorm:"type:uuid;not null" json:"service_id"`
+	AgentID            string         `gorm:"type:varchar(100);not null" json:"agent_id"`
+	Version            *string        `gorm:"type:varchar(50)" json:"version,omitempty"`
+	Hostname           *string        `gorm:"type:varchar(255)" json:"hostname,omitempty"`
+	OS                 *string        `gorm:"column:os;type:varchar(100)" json:"os,omitempty"`
+	IPs                pq.StringArray `gorm:"column:ips;type:text[];not null;default:'{}'" json:"ips"`
+	Capabilities       pq.StringArray `gorm:"type:text[];not null;default:'{}'" json:"capabilities"`
+	RemoteIP           *string        `gorm:"type:varchar(45)" json:"remote_ip,omitempty"`
+	FirstSeenAt        time.Time      `gorm:"not null;default:now()" json:"first_seen_at"`
+	LastSeenAt         time.Time      `gorm:"not null;default:now()" json:"last_seen_at"`
+	LastConnectedAt    *time.Time     `json:"last_connected_at,omitempty"`
+	LastDisconnectedAt *time.Time     `json:"last_disconnected_at,omitempty"`
+	ConfigVersion      *int           `json:"config_version,omitempty"`
+	ConfigStatus       *string        `gorm:"type:varchar(20)" json:"config_status,omitempty"`
+	ConfigError        *string        `json:"config_error,omitempty"`
+	ConfigAckedAt      *time.Time     `json:"config_acked_at,omitempty"`
+
+	// Connected ve Live kalıcı değildir; hub'daki canlı bağlantılardan doldurulur.
+	Connected bool              `gorm:"-" json:"connected"`
+	Live      *ws.AgentInstance `gorm:"-" json:"live,omitempty"`
+}
+
+func (Agent) TableName() string { return "agents" }
+
+// Connection — tek bir agent WebSocket oturumu.
+type Connection struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	ServiceID      uuid.UUID  `gorm:"type:uuid;not null" json:"service_id"`
+	AgentID        string     `gorm:"type:varchar(100);not null" json:"agent_id"`
+	RemoteIP       *string    `gorm:"type:varchar(45)" json:"remote_ip,omitempty"`
+	CredentialID   *uuid.UUID `gorm:"type:uuid" json:"credential_id,omitempty"`
+	ConnectedAt    time.Time  `gorm:"not null" json:"connected_at"`
+	DisconnectedAt *time.Time `json:"disconnected_at,omitempty"`
+}
+
+func (Connection) TableName() string { return "agent_connections" }
+
+// LinkMetric — agent WebSocket bağlantısının dakikalık kalite örneği.
+type LinkMetric struct {
+	Time       time.Time `gorm:"not null" json:"time"`
+	ServiceID  uuid.UUID `gorm:"type:uuid;not null" json:"service_id"`
+	AgentID    string    `gorm:"type:varchar(100);not null" json:"agent_id"`
+	RTTMs      *float64  `gorm:"column:rtt_ms" json:"rtt_ms,omitempty"`
+	RTTAvgMs   *float64  `gorm:"column:rtt_avg_ms" json:"rtt_avg_ms,omitempty"`
+	Reconnects int       `gorm:"not null;default:0" json:"reconnects"`
+	SendDrops  int64     `gorm:"not null;default:0" json:"send_drops"`
+}
+
+func (LinkMetric) TableName() string { return "agent_link_metrics" }
+
+// Hello — agent'ın hello mesajından alınan, uzunlukları sınırlandırılmış bilgiler.
+type Hello struct {
+	Version      string
+	Hostname     string
+	OS           string
+	IPs          []string
+	Capabilities []string
+}
+
+// Credential — servise bağlı agent kimlik bilgisi. Token yalnızca oluşturulurken bir kez
+// gösterilir; veritabanında SHA-256 özeti tutulur.
+type Credential struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ServiceID   uuid.UUID  `gorm:"type:uuid;not null" json:"service_id"`
+	Name        string     `gorm:"type:varchar(100);not null" json:"name"`
+	TokenPrefix string     `gorm:"type:varchar(16);not null" json:"token_prefix"`
+	TokenHash   string     `gorm:"type:varchar(64);not null;unique" json:"-"`
+	CreatedBy   *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy   *uuid.UUID `gorm:"type:uuid" json:"revoked_by,omitempty"`
+	RotatedTo   *uuid.UUID `gorm:"type:uuid" json:"rotated_to,omitempty"`
+	CreatedAt   time.Time  `gorm:"not null;default:now()" json:"created_at"`
+}
+
+func (Credential) TableName() string { return "agent_credentials" }
+
+// Active — kimlik bilgisinin verilen anda bağlantı için kullanılabilir olduğunu bildirir.
+func (c *Credential) Active(now time.Time) bool {
+	return c.RevokedAt == nil && (c.ExpiresAt == nil || c.ExpiresAt.After(now))
+}
+
+// Deadline — kimlik bilgisinin geçerliliğini yitirdiği an (iptal veya süre dolumu); süresiz ve
+// iptal edilmemişse nil döner.
+func (c *Credential) Deadline() *time.Time {
+	if c.RevokedAt != nil && (c.ExpiresAt == nil || c.RevokedAt.Before(*c.ExpiresAt)) {
+		return c.RevokedAt
+	}
+	return c.ExpiresAt
+}
+
+// Config — servisin merkezi agent yapılandırması. PollIntervalSec services tablosundan okunur.
+type Config struct {
+	ServiceID       uuid.UUID      `gorm:"type:uuid;primary_key" json:"service_id"`
+	Version         int            `gorm:"not null;default:1" json:"version"`
+	PollIntervalSec int            `gorm:"->" json:"poll_interval_sec"`
+	Collectors      pq.StringArray `gorm:"type:text[];not null" json:"collectors"`
+	LogPaths        pq.StringArray `gorm:"type:text[];not null" json:"log_paths"`
+	UpdatedBy       *uuid.UUID     `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt       time.Time      `gorm:"not null;default:now()" json:"updated_at"`
+}
+
+func (Config) TableName() string { return "agent_configs" }
+
+// Message — yapılandırmanın agent'a gönderilen config mesajı hali.
+func (c *Config) Message() ws.AgentConfig {
+	return ws.AgentConfig{
+		ConfigVersion:   c.Version,
+		PollIntervalSec: c.PollIntervalSec,
+		Collectors:      append([]string{}, c.Collectors...),
+		LogPaths:        append([]string{}, c.LogPaths...),
+	}
+}
+
+// UpdateConfigRequest — nil alanlar değiştirilmez; boş liste tüm değerleri kaldırır.
+type UpdateConfigRequest struct {
+	PollIntervalSec *int     `json:"poll_interval_sec" binding:"omitempty,min=5,max=300"`
+	Collectors      []string `json:"collectors"`
+	LogPaths        []string `json:"log_paths"`
+}
@@ -0,0 +1,410 @@
+package agents
+
+import (
+	"context"
+	"time"
+
+	"nanonet-backend/internal/orgs"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// RecordConnect — agent kaydını oluşturur/günceller ve yeni bağlantı oturumunu açar.
+func (r *Repository) RecordConnect(ctx context.Context, conn *Connection) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			INSERT INTO agents (service_id, agent_id, remote_ip, first_seen_at, last_seen_at, last_connected_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT (service_id, agent_id) DO UPDATE
+			SET remote_ip = EXCLUDED.remote_ip,
+			    last_seen_at = EXCLUDED.last_seen_at,
+			    last_connected_at = EXCLUDED.last_connected_at
+		`, conn.ServiceID, conn.AgentID, conn.RemoteIP, conn.ConnectedAt, conn.ConnectedAt, conn.ConnectedAt).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(conn).Error
+	})
+}
+
+// RecordHello — hello bilgilerini agent kaydına yazar ve kaydın ID'sini döndürür.
+func (r *Repository) RecordHello(ctx context.Context, serviceID uuid.UUID, agentID string, hello Hello, at time.Time) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var id uuid.UUID
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO agents (service_id, agent_id, version, hostname, os, ips, capabilities, first_seen_at, last_seen_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (service_id, agent_id) DO UPDATE
+		SET version = EXCLUDED.version,
+		    hostname = EXCLUDED.hostname,
+		    os = EXCLUDED.os,
+		    ips = EXCLUDED.ips,
+		    capabilities = EXCLUDED.capabilities,
+		    last_seen_at = EXCLUDED.last_seen_at
+		RETURNING id
+	`, serviceID, agentID, hello.Version, hello.Hostname, hello.OS,
+		pq.StringArray(hello.IPs), pq.StringArray(hello.Capabilities), at, at).Scan(&id).Error
+	return id, err
+}
+
+// RecordDisconnect — bağlantı oturumunu kapatır ve agent'ın son görülme zamanını günceller.
+func (r *Repository) RecordDisconnect(ctx context.Context, sessionID, serviceID uuid.UUID, agentID string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&Connection{}).
+			Where("id = ? AND disconnected_at IS NULL", sessionID).
+			Update("disconnected_at", at).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&Agent{}).
+			Where("service_id = ? AND agent_id = ?", serviceID, agentID).
+			Updates(map[string]interface{}{
+				"last_seen_at":         at,
+				"last_disconnected_at": at,
+			}).Error
+	})
+}
+
+// TouchSessions — açık oturumlardaki agent'ların son görülme zamanını günceller.
+func (r *Repository) TouchSessions(ctx context.Context, sessionIDs []uuid.UUID, at time.Time) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Exec(`
+		UPDATE agents a SET last_seen_at = ?
+		FROM agent_connections c
+		WHERE c.id IN ? AND c.service_id = a.service_id AND c.agent_id = a.agent_id
+	`, at, sessionIDs).Error
+}
+
+// CloseStaleSessions — agent'ı threshold'dan beri görülmeyen açık oturumları kapatır
+// (örn: oturumun bağlı olduğu backend örneği çöktü).
+func (r *Repository) CloseStaleSessions(ctx context.Context, threshold time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Exec(`
+		UPDATE agent_connections c SET disconnected_at = a.last_seen_at
+		FROM agents a
+		WHERE c.disconnected_at IS NULL
+		  AND c.service_id = a.service_id AND c.agent_id = a.agent_id
+		  AND a.last_seen_at < ?
+	`, threshold).Error
+}
+
+// SetServiceAgent — servisin en son tanıtım yapan agent kaydını services.agent_id'ye yazar.
+func (r *Repository) SetServiceAgent(ctx context.Context, serviceID, agentRecordID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).
+		Table("services").
+		Where("id = ?", serviceID).
+		Update("agent_id", agentRecordID).Error
+}
+
+func (r *Repository) List(ctx context.Context, serviceID uuid.UUID) ([]Agent, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var agents []Agent
+	err := r.db.WithContext(ctx).
+		Where("service_id = ?", serviceID).
+		Order("last_seen_at DESC").
+		Find(&agents).Error
+	return agents, err
+}
+
+func (r *Repository) ListConnections(ctx context.Context, serviceID uuid.UUID, agentID string, limit, offset int) ([]Connection, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := r.db.WithContext(ctx).Model(&Connection{}).
+		Where("service_id = ? AND agent_id = ?", serviceID, agentID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var conns []Connection
+	err := query.Order("connected_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&conns).Error
+	return conns, total, err
+}
+
+// InsertLinkMetrics — bağlantı kalitesi örneklerini toplu olarak yazar.
+func (r *Repository) InsertLinkMetrics(ctx context.Context, samples []LinkMetric) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return r.db.WithContext(ctx).Create(&samples).Error
+}
+
+// ListLinkMetrics — agent'ın verilen andan sonraki bağlantı kalitesi örneklerini eskiden yeniye döndürür.
+func (r *Repository) ListLinkMetrics(ctx context.Context, serviceID uuid.UUID, agentID string, since time.Time) ([]LinkMetric, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var samples []LinkMetric
+	err := r.db.WithContext(ctx).
+		Where("service_id = ? AND agent_id = ? AND time >= ?", serviceID, agentID, since).
+		Order("time ASC").
+		Find(&samples).Error
+	return samples, err
+}
+
+// IsServiceOwner — kullanıcının servise en az viewer rolüyle erişebildiğini bildirir
+// (organizasyon üyeliği veya takım ataması).
+func (r *Repository) IsServiceOwner(ctx context.Context, serviceID, userID uuid.UUID) (bool, error) {
+	return orgs.HasServiceRole(ctx, r.db, serviceID, userID, orgs.RoleViewer)
+}
+
+func (r *Repository) CreateCredential(ctx context.Context, cred *Credential) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return r.db.WithContext(ctx).Create(cred).Error
+}
+
+func (r *Repository) ListCredentials(ctx context.Context, serviceID uuid.UUID) ([]Credential, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var creds []Credential
+	err := r.db.WithContext(ctx).
+		Where("service_id = ?", serviceID).
+		Order("created_at DESC").
+		Find(&creds).Error
+	return creds, err
+}
+
+func (r *Repository) GetCredential(ctx context.Context, serviceID, id uuid.UUID) (*Credential, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var cred Credential
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND service_id = ?", id, serviceID).
+		First(&cred).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+func (r *Repository) FindCredentialByHash(ctx context.Context, hash string) (*Credential, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var cred Credential
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&cred).Error; err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+func (r *Repository) FindCredentialsByIDs(ctx context.Context, ids []uuid.UUID) ([]Credential, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var creds []Credential
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&creds).Error
+	return creds, err
+}
+
+func (r *Repository) TouchCredential(ctx context.Context, id uuid.UUID, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Model(&Credential{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
+
+// RevokeCredential — henüz iptal edilmemiş kimlik bilgisini iptal eder; iptal edildiyse true döner.
+func (r *Repository) RevokeCredential(ctx context.Context, id, revokedBy uuid.UUID, at time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	res := r.db.WithContext(ctx).Model(&Credential{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at": at,
+			"revoked_by": revokedBy,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// RotateCredential — yeni kimlik bilgisini oluşturur ve eskisini ona bağlar. retireAt nil ise
+// eski kimlik bilgisi hemen iptal edilir, değilse o ana kadar geçerli kalır.
+func (r *Repository) RotateCredential(ctx context.Context, oldID uuid.UUID, next *Credential, revokedBy uuid.UUID, now time.Time, retireAt *time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		updates := map[string]interface{}{"rotated_to": next.ID}
+		if retireAt == nil {
+			updates["revoked_at"] = now
+			updates["revoked_by"] = revokedBy
+		} else {
+			updates["expires_at"] = *retireAt
+		}
+		res := tx.Model(&Credential{}).
+			Where("id = ? AND revoked_at IS NULL AND rotated_to IS NULL", oldID).
+			Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrCredentialInactive
+		}
+		return nil
+	})
+}
+
+// GetConfig — servisin agent yapılandırmasını döndürür; kayıt yoksa varsayılanlarla oluşturur.
+func (r *Repository) GetConfig(ctx context.Context, serviceID uuid.UUID) (*Config, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	db := r.db.WithContext(ctx)
+	if err := db.Exec(`
+		INSERT INTO agent_configs (service_id) VALUES (?)
+		ON CONFLICT (service_id) DO NOTHING
+	`, serviceID).Error; err != nil {
+		return nil, err
+	}
+	return findConfig(db, serviceID)
+}
+
+// UpdateConfig — yapılandırmayı (ve verilmişse servisin toplama aralığını) günceller,
+// sürümü bir artırır ve güncel kaydı döndürür. nil alanlara dokunulmaz.
+func (r *Repository) UpdateConfig(ctx context.Context, serviceID, userID uuid.UUID, req UpdateConfigRequest, at time.Time) (*Config, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var cfg *Config
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if req.PollIntervalSec != nil {
+			err := tx.Table("services").
+				Where("id = ?", serviceID).
+				Updates(map[string]interface{}{
+					"poll_interval_sec": *req.PollIntervalSec,
+					"updated_at":        at,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		updates := map[string]interface{}{
+			"version":    gorm.Expr("agent_configs.version + 1"),
+			"updated_by": userID,
+			"updated_at": at,
+		}
+		if req.Collectors != nil {
+			updates["collectors"] = pq.StringArray(req.Collectors)
+		}
+		if req.LogPaths != nil {
+			updates["log_paths"] = pq.StringArray(req.LogPaths)
+		}
+		if err := tx.Exec(`
+			INSERT INTO agent_configs (service_id) VALUES (?)
+			ON CONFLICT (service_id) DO NOTHING
+		`, serviceID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Config{}).Where("service_id = ?", serviceID).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		var err error
+		cfg, err = findConfig(tx, serviceID)
+		return err
+	})
+	return cfg, err
+}
+
+// BumpConfigVersion — yapılandırmanın bir parçası başka yerden (örn. servis güncellemesi)
+// değiştiğinde sürümü artırır ve güncel kaydı döndürür.
+func (r *Repository) BumpConfigVersion(ctx context.Context, serviceID uuid.UUID, at time.Time) (*Config, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	db := r.db.WithContext(ctx)
+	if err := db.Exec(`
+		INSERT INTO agent_configs (service_id, updated_at) VALUES (?, ?)
+		ON CONFLICT (service_id) DO UPDATE
+		SET version = agent_configs.version + 1,
+		    updated_at = EXCLUDED.updated_at
+	`, serviceID, at).Error; err != nil {
+		return nil, err
+	}
+	return findConfig(db, serviceID)
+}
+
+// RecordConfigAck — agent'ın yapılandırma yanıtını agent kaydına yazar.
+func (r *Repository) RecordConfigAck(ctx context.Context, serviceID uuid.UUID, agentID string, version int, status string, errMsg *string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	updates := map[string]interface{}{
+		"config_status":   status,
+		"config_error":    errMsg,
+		"config_acked_at": at,
+	}
+	if status == "applied" {
+		updates["config_version"] = version
+	}
+	return r.db.WithContext(ctx).Model(&Agent{}).
+		Where("service_id = ? AND agent_id = ?", serviceID, agentID).
+		Updates(updates).Error
+}
+
+func findConfig(db *gorm.DB, serviceID uuid.UUID) (*Config, error) {
+	var cfg Config
+	err := db.Raw(`
+		SELECT c.service_id, c.version, s.poll_interval_sec, c.collectors, c.log_paths, c.updated_by, c.updated_at
+		FROM agent_configs c
+		JOIN services s ON s.id = c.service_id
+		WHERE c.service_id = ?
+	`, serviceID).Scan(&cfg).Error
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ServiceID == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &cfg, nil
+}
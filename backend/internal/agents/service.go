@@ -0,0 +1,283 @@
+package agents
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"nanonet-backend/internal/ws"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// touchInterval — bağlı agent'ların last_seen_at değeri bu aralıkla güncellenir.
+	touchInterval = time.Minute
+	// staleSessionAfter — bu süre boyunca görülmeyen agent'ın açık oturumu kapatılır.
+	staleSessionAfter = 5 * time.Minute
+
+	maxIPs          = 16
+	maxCapabilities = 50
+)
+
+// Registry — hub'daki agent bağlantı olaylarını agents/agent_connections tablolarına yazar.
+type Registry struct {
+	repo    *Repository
+	hub     *ws.Hub
+	configs *ConfigStore
+}
+
+func NewRegistry(db *gorm.DB, hub *ws.Hub) *Registry {
+	return &Registry{
+		repo: NewRepository(db),
+		hub:  hub,
+	}
+}
+
+// SetConfigStore — hello gönderen agent'lara güncel yapılandırmanın iletilmesini sağlar.
+func (r *Registry) SetConfigStore(c *ConfigStore) {
+	r.configs = c
+}
+
+// Attach — registry'yi hub'ın agent bağlantı olaylarına bağlar.
+func (r *Registry) Attach() {
+	r.hub.SetOnAgentConnect(r.handleConnect)
+	r.hub.SetOnAgentHello(r.handleHello)
+	r.hub.SetOnAgentDisconnect(r.handleDisconnect)
+}
+
+// Start — bu örneğe bağlı agent'ların last_seen_at değerini ve bağlantı kalitesi örneklerini
+// yazar, yarım kalan oturumları ve kimlik bilgisinin süresi dolmuş bağlantıları kapatır.
+func (r *Registry) Start(ctx context.Context) {
+	ticker := time.NewTicker(touchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			sessions := r.hub.LocalAgentSessions()
+			ids := make([]uuid.UUID, 0, len(sessions))
+			for _, s := range sessions {
+				if id, err := uuid.Parse(s.SessionID); err == nil {
+					ids = append(ids, id)
+				}
+			}
+			if err := r.repo.TouchSessions(ctx, ids, now); err != nil {
+				log.Printf("[agents] last_seen güncellenemedi: %v", err)
+			}
+			if err := r.repo.InsertLinkMetrics(ctx, linkSamples(r.hub.LocalAgentInstances(), now)); err != nil {
+				log.Printf("[agents] bağlantı kalitesi örnekleri yazılamadı: %v", err)
+			}
+			if err := r.repo.CloseStaleSessions(ctx, now.Add(-staleSessionAfter)); err != nil {
+				log.Printf("[agents] yarım kalan oturumlar kapatılamadı: %v", err)
+			}
+			r.refreshCredentialExpiry(ctx, sessions, now)
+			r.hub.CloseExpiredCredentialSessions(now)
+		}
+	}
+}
+
+// refreshCredentialExpiry — bağlı agent'ların kimlik bilgilerini veritabanından yeniden okur ve
+// bağlantıdaki geçerlilik sonunu günceller. Böylece başka bir backend örneğinde yapılan rotasyon
+// veya iptal de en geç bir tur sonra bu örnekteki bağlantıları kapatır.
+func (r *Registry) refreshCredentialExpiry(ctx context.Context, sessions []ws.AgentSession, now time.Time) {
+	seen := map[uuid.UUID]bool{}
+	var ids []uuid.UUID
+	for _, s := range sessions {
+		if id, err := uuid.Parse(s.CredentialID); err == nil && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	creds, err := r.repo.FindCredentialsByIDs(ctx, ids)
+	if err != nil {
+		log.Printf("[agents] kimlik bilgisi geçerlilikleri okunamadı: %v", err)
+		return
+	}
+	for id, deadline := range credentialDeadlines(ids, creds, now) {
+		r.hub.SetCredentialExpiry(id, deadline)
+	}
+}
+
+// credentialDeadlines — bağlantılardaki kimlik bilgilerinin güncel geçerlilik sonlarını döndürür.
+// Veritabanında bulunamayan (silinmiş) kimlik bilgisinin süresi now olarak kabul edilir.
+func credentialDeadlines(ids []uuid.UUID, creds []Credential, now time.Time) map[string]*time.Time {
+	out := make(map[string]*time.Time, len(ids))
+	for _, id := range ids {
+		expired := now
+		out[id.String()] = &expired
+	}
+	for i := range creds {
+		out[creds[i].ID.String()] = creds[i].Deadline()
+	}
+	return out
+}
+
+func (r *Registry) handleConnect(s ws.AgentSession) {
+	serviceID, sessionID, ok := parseSession(s)
+	if !ok {
+		return
+	}
+	conn := &Connection{
+		ID:          sessionID,
+		ServiceID:   serviceID,
+		AgentID:     s.AgentID,
+		ConnectedAt: s.ConnectedAt,
+	}
+	if s.RemoteIP != "" {
+		conn.RemoteIP = &s.RemoteIP
+	}
+	if credID, err := uuid.Parse(s.CredentialID); err == nil {
+		conn.CredentialID = &credID
+	}
+	if err := r.repo.RecordConnect(context.Background(), conn); err != nil {
+		log.Printf("[agents] bağlantı kaydedilemedi service=%s agent=%s: %v", s.ServiceID, s.AgentID, err)
+	}
+}
+
+func (r *Registry) handleHello(s ws.AgentSession, msg ws.AgentMessage) {
+	serviceID, _, ok := parseSession(s)
+	if !ok {
+		return
+	}
+	hello := Hello{
+		Version:      truncate(msg.Version, 50),
+		Hostname:     truncate(msg.Hostname, 255),
+		OS:           truncate(msg.OS, 100),
+		IPs:          limitList(msg.IPs, maxIPs, 45),
+		Capabilities: limitList(msg.Capabilities, maxCapabilities, 50),
+	}
+
+	ctx := context.Background()
+	if r.configs != nil {
+		r.configs.PushTo(ctx, serviceID, s.AgentID)
+	}
+	id, err := r.repo.RecordHello(ctx, serviceID, s.AgentID, hello, time.Now())
+	if err != nil {
+		log.Printf("[agents] hello kaydedilemedi service=%s agent=%s: %v", s.ServiceID, s.AgentID, err)
+		return
+	}
+	if err := r.repo.SetServiceAgent(ctx, serviceID, id); err != nil {
+		log.Printf("[agents] servis agent_id güncellenemedi service=%s: %v", s.ServiceID, err)
+	}
+}
+
+func (r *Registry) handleDisconnect(s ws.AgentSession, at time.Time) {
+	serviceID, sessionID, ok := parseSession(s)
+	if !ok {
+		return
+	}
+	if err := r.repo.RecordDisconnect(context.Background(), sessionID, serviceID, s.AgentID, at); err != nil {
+		log.Printf("[agents] bağlantı kapanışı kaydedilemedi service=%s agent=%s: %v", s.ServiceID, s.AgentID, err)
+	}
+}
+
+// List — servisin kayıtlı agent'larını canlı bağlantı durumuyla birlikte döndürür.
+// Henüz kaydı yazılmamış canlı bağlantılar da listeye eklenir.
+func (r *Registry) List(ctx context.Context, serviceID uuid.UUID) ([]Agent, error) {
+	agents, err := r.repo.List(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	live := make(map[string]ws.AgentInstance)
+	for _, inst := range r.hub.ListAgentInstances(serviceID.String()) {
+		live[inst.AgentID] = inst
+	}
+
+	for i := range agents {
+		if inst, ok := live[agents[i].AgentID]; ok {
+			inst := inst
+			agents[i].Connected = true
+			agents[i].Live = &inst
+			delete(live, agents[i].AgentID)
+		}
+	}
+	for _, inst := range live {
+		inst := inst
+		connectedAt := inst.ConnectedAt
+		agents = append(agents, Agent{
+			ServiceID:       serviceID,
+			AgentID:         inst.AgentID,
+			FirstSeenAt:     inst.ConnectedAt,
+			LastSeenAt:      inst.LastSeenAt,
+			LastConnectedAt: &connectedAt,
+			Connected:       true,
+			Live:            &inst,
+		})
+	}
+	return agents, nil
+}
+
+func (r *Registry) ListConnections(ctx context.Context, serviceID uuid.UUID, agentID string, limit, offset int) ([]Connection, int64, error) {
+	return r.repo.ListConnections(ctx, serviceID, agentID, limit, offset)
+}
+
+// ListLinkMetrics — agent'ın son duration süresindeki bağlantı kalitesi örnekleri.
+func (r *Registry) ListLinkMetrics(ctx context.Context, serviceID uuid.UUID, agentID string, duration time.Duration) ([]LinkMetric, error) {
+	return r.repo.ListLinkMetrics(ctx, serviceID, agentID, time.Now().Add(-duration))
+}
+
+func (r *Registry) IsServiceOwner(ctx context.Context, serviceID, userID uuid.UUID) (bool, error) {
+	return r.repo.IsServiceOwner(ctx, serviceID, userID)
+}
+
+// linkSamples — canlı instance'lardan bağlantı kalitesi örnekleri oluşturur.
+func linkSamples(instances []ws.AgentInstance, at time.Time) []LinkMetric {
+	samples := make([]LinkMetric, 0, len(instances))
+	for _, inst := range instances {
+		serviceID, err := uuid.Parse(inst.ServiceID)
+		if err != nil {
+			continue
+		}
+		samples = append(samples, LinkMetric{
+			Time:       at,
+			ServiceID:  serviceID,
+			AgentID:    inst.AgentID,
+			RTTMs:      inst.RTTMs,
+			RTTAvgMs:   inst.RTTAvgMs,
+			Reconnects: inst.Reconnects,
+			SendDrops:  inst.SendDrops,
+		})
+	}
+	return samples
+}
+
+func parseSession(s ws.AgentSession) (uuid.UUID, uuid.UUID, bool) {
+	serviceID, err := uuid.Parse(s.ServiceID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false
+	}
+	sessionID, err := uuid.Parse(s.SessionID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false
+	}
+	return serviceID, sessionID, true
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
+
+func limitList(items []string, maxItems, maxLen int) []string {
+	if len(items) > maxItems {
+		items = items[:maxItems]
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if item != "" {
+			out = append(out, truncate(item, maxLen))
+		}
+	}
+	return out
+}
@@ -0,0 +1,42 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// anthropicProvider — Anthropic Messages API.
+type anthropicProvider struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+func (p *anthropicProvider) Name() string { return ProviderAnthropic }
+
+func (p *anthropicProvider) Complete(ctx context.Context, req CompletionRequest) (string, error) {
+	body := ClaudeRequest{
+		Model:     req.Model,
+		MaxTokens: req.MaxTokens,
+		Messages: []Message{
+			{Role: "user", Content: req.Prompt},
+		},
+	}
+	headers := map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": "2023-06-01",
+	}
+
+	var resp ClaudeResponse
+	if err := postJSON(ctx, p.client, p.Name(), p.baseURL+"/v1/messages", headers, body, &resp); err != nil {
+		return "", err
+	}
+	if resp.StopReason == "max_tokens" {
+		return "", ErrTruncated
+	}
+	if len(resp.Content) == 0 {
+		return "", fmt.Errorf("claude boş yanıt döndü")
+	}
+	return resp.Content[0].Text, nil
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"strconv"
 
+	"nanonet-backend/pkg/audit"
 	"nanonet-backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -16,10 +17,12 @@ type Handler struct {
 	service *Service
 }
 
-func NewHandler(db *gorm.DB, apiKey string) *Handler {
-	return &Handler{
-		service: NewService(db, apiKey),
+func NewHandler(db *gorm.DB, cfg ProviderConfig) (*Handler, error) {
+	svc, err := NewService(db, cfg)
+	if err != nil {
+		return nil, err
 	}
+	return &Handler{service: svc}, nil
 }
 
 func (h *Handler) Analyze(c *gin.Context) {
@@ -42,6 +45,7 @@ func (h *Handler) Analyze(c *gin.Context) {
 	if req.WindowMinutes <= 0 {
 		req.WindowMinutes = 30
 	}
+	audit.Annotate(c, "request", req)
 
 	result, err := h.service.Analyze(c.Request.Context(), userID, serviceID, req.WindowMinutes, req.DeepAnalysis)
 	if err != nil {
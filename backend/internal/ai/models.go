@@ -86,3 +86,17 @@ type ClaudeResponse struct {
 	} `json:"content"`
 	StopReason string `json:"stop_reason"`
 }
+
+// ChatCompletionRequest — OpenAI uyumlu /chat/completions isteği.
+type ChatCompletionRequest struct {
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"`
+	Messages  []Message `json:"messages"`
+}
+
+type ChatCompletionResponse struct {
+	Choices []struct {
+		Message      Message `json:"message"`
+		FinishReason string  `json:"finish_reason"`
+	} `json:"choices"`
+}
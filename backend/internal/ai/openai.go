@@ -0,0 +1,43 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// openAIProvider — OpenAI uyumlu Chat Completions API. baseURL /v1 dahil verilir
+// (ör. http://localhost:11434/v1); API anahtarı yerel sunucularda boş bırakılabilir.
+type openAIProvider struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+func (p *openAIProvider) Name() string { return ProviderOpenAI }
+
+func (p *openAIProvider) Complete(ctx context.Context, req CompletionRequest) (string, error) {
+	body := ChatCompletionRequest{
+		Model:     req.Model,
+		MaxTokens: req.MaxTokens,
+		Messages: []Message{
+			{Role: "user", Content: req.Prompt},
+		},
+	}
+	headers := map[string]string{}
+	if p.apiKey != "" {
+		headers["Authorization"] = "Bearer " + p.apiKey
+	}
+
+	var resp ChatCompletionResponse
+	if err := postJSON(ctx, p.client, p.Name(), p.baseURL+"/chat/completions", headers, body, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("LLM boş yanıt döndü")
+	}
+	if resp.Choices[0].FinishReason == "length" {
+		return "", ErrTruncated
+	}
+	return resp.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,152 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderAnthropic = "anthropic"
+	// ProviderOpenAI — OpenAI uyumlu /chat/completions uç noktası (OpenAI, vLLM, Ollama, llama.cpp server).
+	ProviderOpenAI = "openai"
+)
+
+const (
+	defaultAnthropicBaseURL = "https://api.anthropic.com"
+	defaultOpenAIBaseURL    = "https://api.openai.com/v1"
+	defaultLLMTimeout       = 60 * time.Second
+	defaultConnectTimeout   = 10 * time.Second
+)
+
+// ErrTruncated — yanıt token limitinde kesildi.
+var ErrTruncated = errors.New("AI yanıtı token limitinde kesildi; LLM_MAX_TOKENS değerini artırın")
+
+// Provider — tek mesajlık sohbet tamamlama yapan LLM uç noktası.
+type Provider interface {
+	Name() string
+	Complete(ctx context.Context, req CompletionRequest) (string, error)
+}
+
+// CompletionRequest — sağlayıcıya gönderilen istem.
+type CompletionRequest struct {
+	Model     string
+	Prompt    string
+	MaxTokens int
+}
+
+// ProviderConfig — sağlayıcı seçimi ve bağlantı ayarları. Boş alanlar sağlayıcının
+// varsayılanlarıyla doldurulur.
+type ProviderConfig struct {
+	Provider string // anthropic veya openai
+	BaseURL  string
+	APIKey   string
+	// Model — varsayılan analiz modeli; DeepModel derin analizde kullanılır.
+	Model     string
+	DeepModel string
+	MaxTokens int
+	// Timeout — isteğin toplam süresi; ConnectTimeout yalnızca bağlantı kurulumu.
+	Timeout        time.Duration
+	ConnectTimeout time.Duration
+}
+
+// withDefaults — Anthropic için Claude modelleri varsayılandır; OpenAI uyumlu uçlarda model
+// sunucuya göre değiştiğinden zorunludur.
+func (c ProviderConfig) withDefaults() (ProviderConfig, error) {
+	if c.Provider == "" {
+		c.Provider = ProviderAnthropic
+	}
+	switch c.Provider {
+	case ProviderAnthropic:
+		if c.BaseURL == "" {
+			c.BaseURL = defaultAnthropicBaseURL
+		}
+		if c.Model == "" {
+			c.Model = ModelHaiku
+		}
+		if c.DeepModel == "" {
+			c.DeepModel = ModelSonnet
+		}
+	case ProviderOpenAI:
+		if c.BaseURL == "" {
+			c.BaseURL = defaultOpenAIBaseURL
+		}
+		if c.Model == "" {
+			return c, fmt.Errorf("%s sağlayıcısı için LLM_MODEL zorunlu", c.Provider)
+		}
+	default:
+		return c, fmt.Errorf("geçersiz LLM sağlayıcısı %q (anthropic veya openai)", c.Provider)
+	}
+	c.BaseURL = strings.TrimRight(c.BaseURL, "/")
+	if c.DeepModel == "" {
+		c.DeepModel = c.Model
+	}
+	if c.MaxTokens <= 0 {
+		c.MaxTokens = MaxTokensDefault
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultLLMTimeout
+	}
+	if c.ConnectTimeout <= 0 {
+		c.ConnectTimeout = defaultConnectTimeout
+	}
+	return c, nil
+}
+
+// NewProvider — yapılandırmadaki sağlayıcıyı oluşturur.
+func NewProvider(cfg ProviderConfig) (Provider, error) {
+	cfg, err := cfg.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			DialContext:         (&net.Dialer{Timeout: cfg.ConnectTimeout}).DialContext,
+			TLSHandshakeTimeout: cfg.ConnectTimeout,
+		},
+	}
+	if cfg.Provider == ProviderOpenAI {
+		return &openAIProvider{baseURL: cfg.BaseURL, apiKey: cfg.APIKey, client: client}, nil
+	}
+	return &anthropicProvider{baseURL: cfg.BaseURL, apiKey: cfg.APIKey, client: client}, nil
+}
+
+// postJSON — isteği JSON olarak gönderir ve 200 yanıtını out'a çözer. Hata gövdesi yalnızca
+// loglanır; istemciye sağlayıcı ayrıntısı dönmez.
+func postJSON(ctx context.Context, client *http.Client, provider, url string, headers map[string]string, in, out interface{}) error {
+	jsonData, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		log.Printf("[LLM %s] HTTP %d hatası (body: %.200s)", provider, resp.StatusCode, string(body))
+		return fmt.Errorf("%s API hatası (HTTP %d)", provider, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
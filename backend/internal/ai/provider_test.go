@@ -0,0 +1,116 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeAnalysis = "```json\n{\"summary\":\"ok\",\"root_cause\":\"yok\",\"recommendations\":[{\"action\":\"izle\",\"priority\":\"low\"}],\"confidence\":0.9}\n```"
+
+func TestAnthropicProvider_Complete(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("x-api-key"))
+		assert.Equal(t, "2023-06-01", r.Header.Get("anthropic-version"))
+
+		var req ClaudeRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, ModelHaiku, req.Model)
+		assert.Equal(t, MaxTokensDefault, req.MaxTokens)
+		require.Len(t, req.Messages, 1)
+		assert.Equal(t, "istem", req.Messages[0].Content)
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"content":     []map[string]string{{"type": "text", "text": fakeAnalysis}},
+			"stop_reason": "end_turn",
+		})
+	}))
+	defer srv.Close()
+
+	svc := &Service{}
+	cfg, err := ProviderConfig{BaseURL: srv.URL, APIKey: "secret"}.withDefaults()
+	require.NoError(t, err)
+	svc.llm, err = NewProvider(cfg)
+	require.NoError(t, err)
+	svc.maxTokens = cfg.MaxTokens
+
+	result, err := svc.complete(context.Background(), "istem", cfg.Model)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result.Summary)
+	assert.Equal(t, 0.9, result.Confidence)
+	require.Len(t, result.Recommendations, 1)
+}
+
+func TestOpenAIProvider_Complete(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Empty(t, r.Header.Get("Authorization"), "yerel sunucuda anahtar gönderilmemeli")
+
+		var req ChatCompletionRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "llama3.1:8b", req.Model)
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{
+				"message":       map[string]string{"role": "assistant", "content": fakeAnalysis},
+				"finish_reason": "stop",
+			}},
+		})
+	}))
+	defer srv.Close()
+
+	p, err := NewProvider(ProviderConfig{Provider: ProviderOpenAI, BaseURL: srv.URL + "/v1/", Model: "llama3.1:8b"})
+	require.NoError(t, err)
+	text, err := p.Complete(context.Background(), CompletionRequest{Model: "llama3.1:8b", Prompt: "istem", MaxTokens: 100})
+	require.NoError(t, err)
+
+	result, err := parseAnalysis(text)
+	require.NoError(t, err)
+	assert.Equal(t, "yok", result.RootCause)
+}
+
+func TestProvider_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/chat/completions" {
+			json.NewEncoder(w).Encode(map[string]any{
+				"choices": []map[string]any{{"message": map[string]string{"content": "{"}, "finish_reason": "length"}},
+			})
+			return
+		}
+		http.Error(w, `{"error":"invalid key"}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	anthropic, err := NewProvider(ProviderConfig{BaseURL: srv.URL})
+	require.NoError(t, err)
+	_, err = anthropic.Complete(context.Background(), CompletionRequest{Model: ModelHaiku, Prompt: "x"})
+	assert.ErrorContains(t, err, "HTTP 401")
+
+	openai, err := NewProvider(ProviderConfig{Provider: ProviderOpenAI, BaseURL: srv.URL + "/v1", Model: "m"})
+	require.NoError(t, err)
+	_, err = openai.Complete(context.Background(), CompletionRequest{Model: "m", Prompt: "x"})
+	assert.ErrorIs(t, err, ErrTruncated)
+}
+
+func TestProviderConfig_Defaults(t *testing.T) {
+	cfg, err := ProviderConfig{}.withDefaults()
+	require.NoError(t, err)
+	assert.Equal(t, ModelHaiku, cfg.Model)
+	assert.Equal(t, ModelSonnet, cfg.DeepModel)
+
+	_, err = ProviderConfig{Provider: ProviderOpenAI}.withDefaults()
+	assert.Error(t, err, "OpenAI uyumlu uçta model zorunlu")
+
+	cfg, err = ProviderConfig{Provider: ProviderOpenAI, Model: "qwen2.5"}.withDefaults()
+	require.NoError(t, err)
+	assert.Equal(t, "qwen2.5", cfg.DeepModel)
+
+	_, err = ProviderConfig{Provider: "gemini"}.withDefaults()
+	assert.Error(t, err)
+}
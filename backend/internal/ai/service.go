@@ -1,19 +1,17 @@
 package ai
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"strings"
 	"sync"
 	"time"
 
 	"nanonet-backend/internal/metrics"
+	"nanonet-backend/internal/orgs"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -24,8 +22,10 @@ var ErrRateLimitExceeded = errors.New("AI analiz limiti aşıldı, lütfen 1 dak
 
 type Service struct {
 	db          *gorm.DB
-	apiKey      string
-	client      *http.Client
+	llm         Provider
+	model       string
+	deepModel   string
+	maxTokens   int
 	repo        *Repository
 	metricsRepo *metrics.Repository
 	rateLimiter *RateLimiter
@@ -102,15 +102,26 @@ func (rl *RateLimiter) Allow(userID string) bool {
 	return true
 }
 
-func NewService(db *gorm.DB, apiKey string) *Service {
+// NewService — cfg'deki LLM sağlayıcısıyla analiz servisi oluşturur.
+func NewService(db *gorm.DB, cfg ProviderConfig) (*Service, error) {
+	cfg, err := cfg.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	llm, err := NewProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
 	return &Service{
 		db:          db,
-		apiKey:      apiKey,
-		client:      &http.Client{Timeout: 60 * time.Second},
+		llm:         llm,
+		model:       cfg.Model,
+		deepModel:   cfg.DeepModel,
+		maxTokens:   cfg.MaxTokens,
 		repo:        NewRepository(db),
 		metricsRepo: metrics.NewRepository(db),
 		rateLimiter: NewRateLimiter(10, time.Minute),
-	}
+	}, nil
 }
 
 func (s *Service) Analyze(ctx context.Context, userID, serviceID uuid.UUID, windowMinutes int, deepAnalysis bool) (*AnalysisResult, error) {
@@ -136,7 +147,7 @@ func (s *Service) Analyze(ctx context.Context, userID, serviceID uuid.UUID, wind
 	}
 	var svc svcInfo
 	if err := s.db.WithContext(ctx).Table("services").
-		Where("id = ? AND user_id = ?", serviceID, userID).
+		Where("id = ? AND id IN (?)", serviceID, orgs.AccessibleServices(s.db, userID, orgs.RoleViewer)).
 		First(&svc).Error; err != nil {
 		return nil, fmt.Errorf("servis bulunamadı")
 	}
@@ -199,7 +210,7 @@ func (s *Service) Analyze(ctx context.Context, userID, serviceID uuid.UUID, wind
 	// Diğer servislerin durumu (cross-servis korelasyon)
 	var otherServices []svcInfo
 	s.db.WithContext(ctx).Table("services").
-		Where("user_id = ? AND id != ?", userID, serviceID).
+		Where("id IN (?) AND id != ?", orgs.AccessibleServices(s.db, userID, orgs.RoleViewer), serviceID).
 		Find(&otherServices)
 
 	var otherSvcInfo strings.Builder
@@ -227,12 +238,12 @@ func (s *Service) Analyze(ctx context.Context, userID, serviceID uuid.UUID, wind
 		windowMinutes,
 	)
 
-	model := ModelHaiku
+	model := s.model
 	if deepAnalysis {
-		model = ModelSonnet
+		model = s.deepModel
 	}
 
-	result, err := s.callClaude(prompt, model)
+	result, err := s.complete(ctx, prompt, model)
 	if err != nil {
 		return nil, fmt.Errorf("AI analizi başarısız: %w", err)
 	}
@@ -243,56 +254,18 @@ func (s *Service) Analyze(ctx context.Context, userID, serviceID uuid.UUID, wind
 	return result, nil
 }
 
-func (s *Service) callClaude(prompt, model string) (*AnalysisResult, error) {
-	reqBody := ClaudeRequest{
-		Model:     model,
-		MaxTokens: MaxTokensDefault,
-		Messages: []Message{
-			{Role: "user", Content: prompt},
-		},
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := http.NewRequest("POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", s.apiKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
-
-	resp, err := s.client.Do(req)
+// complete — istemi sağlayıcıya gönderir ve yanıttaki JSON analizi çözer.
+func (s *Service) complete(ctx context.Context, prompt, model string) (*AnalysisResult, error) {
+	text, err := s.llm.Complete(ctx, CompletionRequest{Model: model, Prompt: prompt, MaxTokens: s.maxTokens})
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		log.Printf("[Claude API] HTTP %d hatası (body: %.200s)", resp.StatusCode, string(body))
-		return nil, fmt.Errorf("claude API hatası (HTTP %d)", resp.StatusCode)
-	}
-
-	var claudeResp ClaudeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&claudeResp); err != nil {
-		return nil, err
-	}
-
-	if len(claudeResp.Content) == 0 {
-		return nil, fmt.Errorf("claude boş yanıt döndü")
-	}
-
-	if claudeResp.StopReason == "max_tokens" {
-		return nil, fmt.Errorf("AI yanıtı token limitinde kesildi; MaxTokens değerini artırın")
-	}
+	return parseAnalysis(text)
+}
 
-	rawText := claudeResp.Content[0].Text
-	// Markdown code fence varsa temizle (```json ... ``` veya ``` ... ```)
+// parseAnalysis — model yanıtındaki JSON nesnesini ayıklar; yerel modeller JSON'u markdown kod
+// bloğu veya açıklama metniyle sarabilir.
+func parseAnalysis(rawText string) (*AnalysisResult, error) {
 	rawText = strings.TrimSpace(rawText)
 	if idx := strings.Index(rawText, "{"); idx > 0 {
 		rawText = rawText[idx:]
@@ -305,7 +278,6 @@ func (s *Service) callClaude(prompt, model string) (*AnalysisResult, error) {
 	if err := json.Unmarshal([]byte(rawText), &result); err != nil {
 		return nil, fmt.Errorf("AI yanıtı parse edilemedi (raw: %.200s): %w", rawText, err)
 	}
-
 	return &result, nil
 }
 
@@ -358,6 +330,10 @@ func (s *Service) saveInsight(serviceID uuid.UUID, model string, result *Analysi
 		rootCause = rootCause[:2000]
 	}
 
+	// ai_insights.model varchar(50); yerel model adları daha uzun olabilir.
+	if len(model) > 50 {
+		model = model[:50]
+	}
 	insight := &AIInsight{
 		AlertID:         alertID,
 		Model:           model,
@@ -378,12 +354,9 @@ func (s *Service) GetInsights(ctx context.Context, serviceID uuid.UUID, limit, o
 	return s.repo.GetByServiceID(ctx, serviceID, limit, offset)
 }
 
-// IsServiceOwner servisin belirtilen kullanıcıya ait olup olmadığını kontrol eder.
+// IsServiceOwner — kullanıcının servise en az viewer rolüyle erişebildiğini bildirir
+// (organizasyon üyeliği veya takım ataması).
 func (s *Service) IsServiceOwner(ctx context.Context, serviceID, userID uuid.UUID) bool {
-	var count int64
-	s.db.WithContext(ctx).
-		Table("services").
-		Where("id = ? AND user_id = ?", serviceID, userID).
-		Count(&count)
-	return count > 0
+	ok, err := orgs.HasServiceRole(ctx, s.db, serviceID, userID, orgs.RoleViewer)
+	return err == nil && ok
 }
@@ -3,6 +3,7 @@ package alerts
 import (
 	"strconv"
 
+	"nanonet-backend/pkg/audit"
 	"nanonet-backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -172,6 +173,9 @@ func (h *Handler) UpsertAlertRules(c *gin.Context) {
 		return
 	}
 
+	if before, err := h.service.GetAlertRule(c.Request.Context(), serviceID); err == nil && before != nil {
+		audit.Before(c, before)
+	}
 	rule := &ServiceAlertRule{
 		ServiceID:          serviceID,
 		CPUThreshold:       req.CPUThreshold,
@@ -184,6 +188,7 @@ func (h *Handler) UpsertAlertRules(c *gin.Context) {
 		response.InternalError(c, "alert kuralları güncellenemedi")
 		return
 	}
+	audit.After(c, rule)
 
 	response.Success(c, rule)
 }
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"time"
 
+	"nanonet-backend/internal/orgs"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -75,8 +77,7 @@ func (r *Repository) GetActiveAlerts(ctx context.Context, userID uuid.UUID) ([]A
 
 	var alerts []Alert
 	err := r.db.WithContext(ctx).
-		Joins("JOIN services ON services.id = alerts.service_id").
-		Where("services.user_id = ? AND alerts.resolved_at IS NULL", userID).
+		Where("alerts.service_id IN (?) AND alerts.resolved_at IS NULL", orgs.AccessibleServices(r.db, userID, orgs.RoleViewer)).
 		Order("alerts.triggered_at DESC").
 		Find(&alerts).Error
 	return alerts, err
@@ -133,16 +134,11 @@ func (r *Repository) ResolveByTypes(ctx context.Context, serviceID uuid.UUID, al
 		Update("resolved_at", now).Error
 }
 
+// IsServiceOwner — kullanıcının servise en az viewer rolüyle erişebildiğini bildirir
+// (organizasyon üyeliği veya takım ataması).
 func (r *Repository) IsServiceOwner(ctx context.Context, serviceID, userID uuid.UUID) bool {
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-
-	var count int64
-	r.db.WithContext(ctx).
-		Table("services").
-		Where("id = ? AND user_id = ?", serviceID, userID).
-		Count(&count)
-	return count > 0
+	ok, err := orgs.HasServiceRole(ctx, r.db, serviceID, userID, orgs.RoleViewer)
+	return err == nil && ok
 }
 
 func (r *Repository) ResolveByUser(ctx context.Context, alertID, userID uuid.UUID) error {
@@ -151,10 +147,10 @@ func (r *Repository) ResolveByUser(ctx context.Context, alertID, userID uuid.UUI
 
 	now := time.Now()
 	result := r.db.WithContext(ctx).
-		Exec(`UPDATE alerts SET resolved_at = ?
-			WHERE id = ? AND resolved_at IS NULL
-			AND service_id IN (SELECT id FROM services WHERE user_id = ?)`,
-			now, alertID, userID)
+		Model(&Alert{}).
+		Where("id = ? AND resolved_at IS NULL AND service_id IN (?)",
+			alertID, orgs.AccessibleServices(r.db, userID, orgs.RoleOperator)).
+		Update("resolved_at", now)
 
 	if result.Error != nil {
 		return result.Error
@@ -18,12 +18,16 @@ type alertNotifier interface {
 	SendAlert(toEmail, serviceName, alertType, message, severity string) error
 }
 
+// OnAlertCreatedFunc — yeni bir alert kaydedildiğinde çağrılır.
+type OnAlertCreatedFunc func(alert Alert)
+
 type Service struct {
-	repo     *Repository
-	rules    AlertRule
-	maint    maintenanceChecker
-	notifier alertNotifier
-	db       *gorm.DB
+	repo           *Repository
+	rules          AlertRule
+	maint          maintenanceChecker
+	notifier       alertNotifier
+	onAlertCreated OnAlertCreatedFunc
+	db             *gorm.DB
 }
 
 func NewService(db *gorm.DB) *Service {
@@ -44,6 +48,11 @@ func (s *Service) SetNotifier(n alertNotifier) {
 	s.notifier = n
 }
 
+// SetOnAlertCreated wires in a callback that runs (async) for every newly created alert.
+func (s *Service) SetOnAlertCreated(fn OnAlertCreatedFunc) {
+	s.onAlertCreated = fn
+}
+
 func (s *Service) CheckMetricAndCreateAlert(ctx context.Context, serviceID uuid.UUID, metric *metrics.Metric) error {
 	// Skip all alert creation during active maintenance windows.
 	if s.maint != nil {
@@ -163,6 +172,9 @@ func (s *Service) CheckMetricAndCreateAlert(ctx context.Context, serviceID uuid.
 			if s.notifier != nil && s.notifier.Enabled() && alert.Severity != "info" {
 				go s.sendAlertEmail(serviceID, alert)
 			}
+			if s.onAlertCreated != nil {
+				go s.onAlertCreated(alert)
+			}
 		}
 	}
 
@@ -0,0 +1,275 @@
+package approvals
+
+import (
+	"errors"
+	"strconv"
+
+	"nanonet-backend/pkg/audit"
+	"nanonet-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// List — GET /approvals?status=pending
+// Kullanıcının açtığı veya onaylayıcısı olduğu istekleri listeler.
+func (h *Handler) List(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	reqs, total, err := h.service.List(c.Request.Context(), userID, c.Query("status"), limit, offset)
+	if err != nil {
+		response.InternalError(c, "onay istekleri alınamadı")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"approvals": reqs,
+		"total":     total,
+		"page":      page,
+	})
+}
+
+// Get — GET /approvals/:approvalId
+func (h *Handler) Get(c *gin.Context) {
+	userID, id, ok := parseIDs(c)
+	if !ok {
+		return
+	}
+
+	req, err := h.service.Get(c.Request.Context(), id, userID)
+	if err != nil {
+		writeError(c, err, "onay isteği alınamadı")
+		return
+	}
+	response.Success(c, req)
+}
+
+// Approve — POST /approvals/:approvalId/approve
+// Onaylanan aksiyon hemen çalıştırılır; sonuç istek kaydında döner.
+func (h *Handler) Approve(c *gin.Context) {
+	h.decide(c, true)
+}
+
+// Reject — POST /approvals/:approvalId/reject
+func (h *Handler) Reject(c *gin.Context) {
+	h.decide(c, false)
+}
+
+func (h *Handler) decide(c *gin.Context, approve bool) {
+	userID, id, ok := parseIDs(c)
+	if !ok {
+		return
+	}
+
+	var body DecisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			response.ValidationError(c, err)
+			return
+		}
+	}
+
+	var (
+		req *Request
+		err error
+	)
+	if approve {
+		req, err = h.service.Approve(c.Request.Context(), id, userID, body.Note)
+	} else {
+		req, err = h.service.Reject(c.Request.Context(), id, userID, body.Note)
+	}
+	if err != nil {
+		writeError(c, err, "karar kaydedilemedi")
+		return
+	}
+	response.Success(c, req)
+}
+
+// ListServicePolicies — GET /services/:id/approval-policies
+func (h *Handler) ListServicePolicies(c *gin.Context) {
+	serviceID, ok := h.authorizeService(c)
+	if !ok {
+		return
+	}
+	h.listPolicies(c, &serviceID)
+}
+
+// SetServicePolicy — PUT /services/:id/approval-policies
+func (h *Handler) SetServicePolicy(c *gin.Context) {
+	serviceID, ok := h.authorizeService(c)
+	if !ok {
+		return
+	}
+	h.setPolicy(c, &serviceID)
+}
+
+// DeleteServicePolicy — DELETE /services/:id/approval-policies/:policyId
+func (h *Handler) DeleteServicePolicy(c *gin.Context) {
+	serviceID, ok := h.authorizeService(c)
+	if !ok {
+		return
+	}
+	h.deletePolicy(c, &serviceID)
+}
+
+// ListClusterPolicies — GET /k8s/approval-policies
+func (h *Handler) ListClusterPolicies(c *gin.Context) {
+	h.listPolicies(c, nil)
+}
+
+// SetClusterPolicy — PUT /k8s/approval-policies
+// Cluster politikaları K8S_ORG_ID adminlerinin ortak ayarıdır; herhangi bir admin değiştirebilir.
+func (h *Handler) SetClusterPolicy(c *gin.Context) {
+	h.setPolicy(c, nil)
+}
+
+// DeleteClusterPolicy — DELETE /k8s/approval-policies/:policyId
+func (h *Handler) DeleteClusterPolicy(c *gin.Context) {
+	h.deletePolicy(c, nil)
+}
+
+func (h *Handler) listPolicies(c *gin.Context, serviceID *uuid.UUID) {
+	policies, err := h.service.ListPolicies(c.Request.Context(), serviceID)
+	if err != nil {
+		response.InternalError(c, "onay politikaları alınamadı")
+		return
+	}
+	response.Success(c, gin.H{"policies": policies})
+}
+
+func (h *Handler) setPolicy(c *gin.Context, serviceID *uuid.UUID) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+
+	var req PolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	if before := h.findPolicy(c, serviceID, func(p Policy) bool { return p.Action == req.Action }); before != nil {
+		audit.Before(c, before)
+	}
+	policy, err := h.service.SetPolicy(c.Request.Context(), userID, serviceID, req)
+	if err != nil {
+		writeError(c, err, "onay politikası kaydedilemedi")
+		return
+	}
+	audit.After(c, policy)
+	response.Success(c, policy)
+}
+
+func (h *Handler) deletePolicy(c *gin.Context, serviceID *uuid.UUID) {
+	policyID, err := uuid.Parse(c.Param("policyId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz politika ID")
+		return
+	}
+
+	if before := h.findPolicy(c, serviceID, func(p Policy) bool { return p.ID == policyID }); before != nil {
+		audit.Before(c, before)
+	}
+	if err := h.service.DeletePolicy(c.Request.Context(), policyID, serviceID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(c, "onay politikası bulunamadı")
+			return
+		}
+		response.InternalError(c, "onay politikası silinemedi")
+		return
+	}
+	response.Success(c, gin.H{"message": "onay politikası silindi"})
+}
+
+// findPolicy — audit kaydının önceki hali için politikayı bulur; bulunamazsa nil döner.
+func (h *Handler) findPolicy(c *gin.Context, serviceID *uuid.UUID, match func(Policy) bool) *Policy {
+	policies, err := h.service.ListPolicies(c.Request.Context(), serviceID)
+	if err != nil {
+		return nil
+	}
+	for i := range policies {
+		if match(policies[i]) {
+			return &policies[i]
+		}
+	}
+	return nil
+}
+
+func (h *Handler) authorizeService(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return uuid.Nil, false
+	}
+
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz servis ID")
+		return uuid.Nil, false
+	}
+
+	owner, err := h.service.IsServiceOwner(c.Request.Context(), serviceID, userID)
+	if err != nil {
+		response.InternalError(c, "servis doğrulanamadı")
+		return uuid.Nil, false
+	}
+	if !owner {
+		response.NotFound(c, "servis bulunamadı")
+		return uuid.Nil, false
+	}
+	return serviceID, true
+}
+
+func parseIDs(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("approvalId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz onay isteği ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, id, true
+}
+
+func writeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrForbidden):
+		response.Error(c, 403, err.Error())
+	case errors.Is(err, ErrNotPending):
+		response.Error(c, 409, err.Error())
+	case errors.Is(err, ErrInvalidPolicy):
+		response.BadRequest(c, err.Error())
+	default:
+		response.InternalError(c, fallback)
+	}
+}
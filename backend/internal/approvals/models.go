@@ -0,0 +1,109 @@
+package approvals
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Onay gerektirebilen aksiyonlar. k8s_* aksiyonları servise değil cluster'a bağlıdır.
+const (
+	ActionStop         = "stop"
+	ActionScale        = "scale"
+	ActionK8sDeletePod = "k8s_delete_pod"
+	ActionK8sUndeploy  = "k8s_undeploy"
+)
+
+var serviceActions = map[string]bool{
+	ActionStop:  true,
+	ActionScale: true,
+}
+
+var clusterActions = map[string]bool{
+	ActionK8sDeletePod: true,
+	ActionK8sUndeploy:  true,
+}
+
+// İstek durumları.
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+	StatusExpired  = "expired"
+	// StatusExecuted — onaylandı ve aksiyon çalıştırıldı.
+	StatusExecuted = "executed"
+	// StatusFailed — onaylandı fakat aksiyon çalıştırılamadı.
+	StatusFailed = "failed"
+)
+
+const (
+	defaultTTLSec = 3600
+	minTTLSec     = 60
+	maxTTLSec     = 86400
+)
+
+// Policy — bir servis (veya cluster) aksiyonu için onay gereksinimi.
+type Policy struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
+	ServiceID   *uuid.UUID     `gorm:"type:uuid" json:"service_id,omitempty"`
+	Action      string         `gorm:"type:varchar(30);not null" json:"action"`
+	ApproverIDs pq.StringArray `gorm:"type:uuid[];not null" json:"approver_ids"`
+	TTLSec      int            `gorm:"not null;default:3600" json:"ttl_sec"`
+	CreatedAt   time.Time      `gorm:"not null;default:now()" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"not null;default:now()" json:"updated_at"`
+}
+
+func (Policy) TableName() string { return "approval_policies" }
+
+// Request — onay bekleyen (veya karara bağlanmış) aksiyon isteği.
+type Request struct {
+	ID           uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	PolicyID     *uuid.UUID      `gorm:"type:uuid" json:"policy_id,omitempty"`
+	ServiceID    *uuid.UUID      `gorm:"type:uuid" json:"service_id,omitempty"`
+	Action       string          `gorm:"type:varchar(30);not null" json:"action"`
+	Target       *string         `gorm:"type:varchar(255)" json:"target,omitempty"`
+	Params       json.RawMessage `gorm:"type:jsonb" json:"params,omitempty"`
+	RequestedBy  uuid.UUID       `gorm:"type:uuid;not null" json:"requested_by"`
+	ApproverIDs  pq.StringArray  `gorm:"type:uuid[];not null" json:"approver_ids"`
+	Status       string          `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	DecidedBy    *uuid.UUID      `gorm:"type:uuid" json:"decided_by,omitempty"`
+	DecisionNote *string         `gorm:"type:text" json:"decision_note,omitempty"`
+	CommandID    *string         `gorm:"type:varchar(36)" json:"command_id,omitempty"`
+	Error        *string         `gorm:"type:text" json:"error,omitempty"`
+	ExpiresAt    time.Time       `gorm:"not null" json:"expires_at"`
+	DecidedAt    *time.Time      `json:"decided_at,omitempty"`
+	CreatedAt    time.Time       `gorm:"not null;default:now()" json:"created_at"`
+}
+
+func (Request) TableName() string { return "approval_requests" }
+
+// canApprove — kullanıcı isteği onaylayabilir mi? İsteği açan kişi kendi isteğini onaylayamaz.
+func (r *Request) canApprove(userID uuid.UUID) bool {
+	if userID == r.RequestedBy {
+		return false
+	}
+	for _, id := range r.ApproverIDs {
+		if id == userID.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// canView — istek sahibi ve onaylayıcılar isteği görebilir.
+func (r *Request) canView(userID uuid.UUID) bool {
+	return userID == r.RequestedBy || r.canApprove(userID)
+}
+
+type PolicyRequest struct {
+	Action    string   `json:"action" binding:"required"`
+	Approvers []string `json:"approvers" binding:"required,min=1,max=10,dive,email"`
+	TTLSec    int      `json:"ttl_sec" binding:"omitempty,min=60,max=86400"`
+}
+
+type DecisionRequest struct {
+	Note string `json:"note" binding:"max=500"`
+}
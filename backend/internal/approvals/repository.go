@@ -0,0 +1,256 @@
+package approvals
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"nanonet-backend/internal/orgs"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// UpsertPolicy — servis (veya cluster) + aksiyon için politikayı oluşturur ya da günceller.
+func (r *Repository) UpsertPolicy(ctx context.Context, p *Policy) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var existing Policy
+	query := r.db.WithContext(ctx).Where("action = ?", p.Action)
+	if p.ServiceID != nil {
+		query = query.Where("service_id = ?", *p.ServiceID)
+	} else {
+		query = query.Where("service_id IS NULL")
+	}
+	err := query.First(&existing).Error
+	if err == gorm.ErrRecordNotFound {
+		return r.db.WithContext(ctx).Create(p).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	p.ID = existing.ID
+	p.CreatedAt = existing.CreatedAt
+	p.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).
+		Model(&Policy{}).
+		Where("id = ?", existing.ID).
+		Updates(map[string]interface{}{
+			"user_id":      p.UserID,
+			"approver_ids": p.ApproverIDs,
+			"ttl_sec":      p.TTLSec,
+			"updated_at":   p.UpdatedAt,
+		}).Error
+}
+
+// FindPolicy — aksiyon için geçerli politikayı döndürür; serviceID nil ise cluster politikası aranır.
+func (r *Repository) FindPolicy(ctx context.Context, serviceID *uuid.UUID, action string) (*Policy, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var p Policy
+	query := r.db.WithContext(ctx).Where("action = ?", action)
+	if serviceID != nil {
+		query = query.Where("service_id = ?", *serviceID)
+	} else {
+		query = query.Where("service_id IS NULL")
+	}
+	if err := query.First(&p).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *Repository) ListPolicies(ctx context.Context, serviceID *uuid.UUID) ([]Policy, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := r.db.WithContext(ctx)
+	if serviceID != nil {
+		query = query.Where("service_id = ?", *serviceID)
+	} else {
+		query = query.Where("service_id IS NULL")
+	}
+	var policies []Policy
+	err := query.Order("action ASC").Find(&policies).Error
+	return policies, err
+}
+
+// DeletePolicy — servisin (serviceID nil ise cluster'ın) politikasını siler.
+func (r *Repository) DeletePolicy(ctx context.Context, id uuid.UUID, serviceID *uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := r.db.WithContext(ctx).Where("id = ?", id)
+	if serviceID != nil {
+		query = query.Where("service_id = ?", *serviceID)
+	} else {
+		query = query.Where("service_id IS NULL")
+	}
+	result := query.Delete(&Policy{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *Repository) CreateRequest(ctx context.Context, req *Request) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Create(req).Error
+}
+
+func (r *Repository) GetRequest(ctx context.Context, id uuid.UUID) (*Request, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var req Request
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&req).Error; err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// ListRequests — kullanıcının açtığı veya onaylayıcısı olduğu istekleri döndürür.
+func (r *Repository) ListRequests(ctx context.Context, userID uuid.UUID, status string, limit, offset int) ([]Request, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := r.db.WithContext(ctx).Model(&Request{}).
+		Where("requested_by = ? OR ? = ANY(approver_ids)", userID, userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reqs []Request
+	err := query.Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&reqs).Error
+	return reqs, total, err
+}
+
+// Decide — bekleyen ve süresi dolmamış isteği atomik olarak karara bağlar.
+// İstek başka bir örnek/kullanıcı tarafından karara bağlandıysa false döner.
+func (r *Repository) Decide(ctx context.Context, id, userID uuid.UUID, status string, note *string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	result := r.db.WithContext(ctx).
+		Model(&Request{}).
+		Where("id = ? AND status = ? AND expires_at > ?", id, StatusPending, now).
+		Updates(map[string]interface{}{
+			"status":        status,
+			"decided_by":    userID,
+			"decision_note": note,
+			"decided_at":    now,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *Repository) UpdateRequest(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).
+		Model(&Request{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+// ExpirePending — süresi dolan bekleyen istekleri "expired" işaretler ve döndürür.
+func (r *Repository) ExpirePending(ctx context.Context, now time.Time) ([]Request, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var expired []Request
+	err := r.db.WithContext(ctx).
+		Model(&expired).
+		Clauses(clause.Returning{}).
+		Where("status = ? AND expires_at <= ?", StatusPending, now).
+		Update("status", StatusExpired).Error
+	return expired, err
+}
+
+// ResolveUserIDs — küçük harfli email adreslerini kullanıcı ID'lerine çevirir.
+func (r *Repository) ResolveUserIDs(ctx context.Context, emails []string) (map[string]uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var rows []struct {
+		ID    uuid.UUID
+		Email string
+	}
+	err := r.db.WithContext(ctx).
+		Table("users").
+		Select("id, email").
+		Where("LOWER(email) IN ?", emails).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]uuid.UUID, len(rows))
+	for _, row := range rows {
+		ids[strings.ToLower(row.Email)] = row.ID
+	}
+	return ids, nil
+}
+
+// UserEmails — bildirimler için kullanıcı ID'lerinin email adreslerini döndürür.
+func (r *Repository) UserEmails(ctx context.Context, ids []string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var emails []string
+	err := r.db.WithContext(ctx).
+		Table("users").
+		Where("id IN ?", ids).
+		Pluck("email", &emails).Error
+	return emails, err
+}
+
+func (r *Repository) ServiceName(ctx context.Context, serviceID uuid.UUID) string {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var name string
+	r.db.WithContext(ctx).Raw("SELECT name FROM services WHERE id = ?", serviceID).Scan(&name)
+	return name
+}
+
+// IsServiceOwner — kullanıcının servise en az viewer rolüyle erişebildiğini bildirir
+// (organizasyon üyeliği veya takım ataması).
+func (r *Repository) IsServiceOwner(ctx context.Context, serviceID, userID uuid.UUID) (bool, error) {
+	return orgs.HasServiceRole(ctx, r.db, serviceID, userID, orgs.RoleViewer)
+}
+
+// HasApproverRole — kullanıcının aksiyonu onaylayacak rolü olduğunu bildirir: servis
+// politikalarında servis üzerinde operator, cluster politikalarında clusterOrgID'de admin
+// (k8s yazma uçlarıyla aynı rol). clusterOrgID boşsa cluster onayı verilemez.
+func (r *Repository) HasApproverRole(ctx context.Context, serviceID *uuid.UUID, clusterOrgID, userID uuid.UUID) (bool, error) {
+	if serviceID != nil {
+		return orgs.HasServiceRole(ctx, r.db, *serviceID, userID, orgs.RoleOperator)
+	}
+	return orgs.HasOrgRole(ctx, r.db, clusterOrgID, userID, orgs.RoleAdmin)
+}
@@ -0,0 +1,481 @@
+package approvals
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"nanonet-backend/internal/services"
+	"nanonet-backend/internal/ws"
+	"nanonet-backend/pkg/audit"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound      = errors.New("onay isteği bulunamadı")
+	ErrForbidden     = errors.New("bu isteği onaylama yetkiniz yok")
+	ErrNotPending    = errors.New("istek artık onay beklemiyor")
+	ErrInvalidPolicy = errors.New("geçersiz onay politikası")
+
+	errUnknownApprover = fmt.Errorf("%w: onaylayıcılardan biri bulunamadı veya bu aksiyonu onaylama yetkisine sahip değil", ErrInvalidPolicy)
+)
+
+const expirySweepInterval = 30 * time.Second
+
+// clusterExecutor — k8s.Client tarafından karşılanır; import döngüsünü önler.
+type clusterExecutor interface {
+	DeletePod(ctx context.Context, name string) error
+	UndeployService(ctx context.Context, name string) error
+}
+
+// approvalNotifier — pkg/mailer.Mailer tarafından karşılanır.
+type approvalNotifier interface {
+	Enabled() bool
+	SendApprovalRequest(toEmail, summary, requestedBy string, expiresAt time.Time) error
+}
+
+type Service struct {
+	repo        *Repository
+	dispatcher  *services.Dispatcher
+	hub         *ws.Hub
+	cluster     clusterExecutor
+	notifier    approvalNotifier
+	auditLogger *audit.Logger
+
+	clusterOrgID uuid.UUID
+}
+
+func NewService(db *gorm.DB, hub *ws.Hub) *Service {
+	return &Service{
+		repo:        NewRepository(db),
+		dispatcher:  services.NewDispatcher(db, hub),
+		hub:         hub,
+		auditLogger: audit.New(db),
+	}
+}
+
+// SetClusterExecutor wires in the Kubernetes client used for approved k8s_* actions.
+func (s *Service) SetClusterExecutor(c clusterExecutor) {
+	s.cluster = c
+}
+
+// SetNotifier wires in an email notifier after construction.
+func (s *Service) SetNotifier(n approvalNotifier) {
+	s.notifier = n
+}
+
+// SetClusterOrg sets the organization whose admins may approve cluster (k8s_*) actions.
+func (s *Service) SetClusterOrg(orgID uuid.UUID) {
+	s.clusterOrgID = orgID
+}
+
+// Start — süresi dolan bekleyen istekleri periyodik olarak "expired" işaretler.
+func (s *Service) Start(ctx context.Context) {
+	ticker := time.NewTicker(expirySweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.expire(ctx)
+		}
+	}
+}
+
+func (s *Service) expire(ctx context.Context) {
+	expired, err := s.repo.ExpirePending(ctx, time.Now())
+	if err != nil {
+		log.Printf("[approvals] süresi dolan istekler işaretlenemedi: %v", err)
+		return
+	}
+	for i := range expired {
+		req := &expired[i]
+		s.auditLogger.Record(ctx, audit.Entry{
+			Action:       audit.ActionApprovalExpired,
+			ResourceType: "approval_request",
+			ResourceID:   &req.ID,
+			Status:       audit.StatusFailure,
+			Details: map[string]any{
+				"action":       req.Action,
+				"requested_by": req.RequestedBy,
+				"initiated_by": "system",
+			},
+		})
+		s.notifyDecision(req)
+	}
+}
+
+// Submit — aksiyon için onay politikası varsa bekleyen bir istek oluşturur ve ID'sini döndürür.
+// Politika yoksa nil döner; çağıran aksiyonu hemen çalıştırmalıdır.
+// serviceID nil ise aksiyon cluster kapsamındadır (k8s_*), target pod/deployment adını taşır.
+func (s *Service) Submit(ctx context.Context, serviceID *uuid.UUID, requestedBy uuid.UUID, action, target string, params map[string]interface{}) (*uuid.UUID, error) {
+	policy, err := s.repo.FindPolicy(ctx, serviceID, action)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &Request{
+		PolicyID:    &policy.ID,
+		ServiceID:   serviceID,
+		Action:      action,
+		Params:      paramsJSON,
+		RequestedBy: requestedBy,
+		ApproverIDs: policy.ApproverIDs,
+		Status:      StatusPending,
+		ExpiresAt:   time.Now().Add(time.Duration(policy.TTLSec) * time.Second),
+	}
+	if target != "" {
+		req.Target = &target
+	}
+	if err := s.repo.CreateRequest(ctx, req); err != nil {
+		return nil, err
+	}
+
+	s.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &requestedBy,
+		Action:       audit.ActionApprovalRequested,
+		ResourceType: "approval_request",
+		ResourceID:   &req.ID,
+		Status:       audit.StatusSuccess,
+		Details: map[string]any{
+			"action":     action,
+			"service_id": serviceID,
+			"target":     target,
+			"expires_at": req.ExpiresAt,
+		},
+	})
+
+	s.notifyApprovers(req)
+	return &req.ID, nil
+}
+
+// Get — kullanıcının görebileceği bir onay isteğini döndürür.
+func (s *Service) Get(ctx context.Context, id, userID uuid.UUID) (*Request, error) {
+	req, err := s.repo.GetRequest(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !req.canView(userID) {
+		return nil, ErrNotFound
+	}
+	return req, nil
+}
+
+func (s *Service) List(ctx context.Context, userID uuid.UUID, status string, limit, offset int) ([]Request, int64, error) {
+	return s.repo.ListRequests(ctx, userID, status, limit, offset)
+}
+
+// Approve — isteği onaylar ve aksiyonu isteği açan kullanıcı adına çalıştırır.
+func (s *Service) Approve(ctx context.Context, id, userID uuid.UUID, note string) (*Request, error) {
+	req, err := s.decide(ctx, id, userID, StatusApproved, note)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if commandID, execErr := s.execute(ctx, req); execErr != nil {
+		msg := execErr.Error()
+		req.Status, req.Error = StatusFailed, &msg
+		updates["status"], updates["error"] = StatusFailed, msg
+	} else {
+		req.Status = StatusExecuted
+		updates["status"] = StatusExecuted
+		if commandID != "" {
+			req.CommandID = &commandID
+			updates["command_id"] = commandID
+		}
+	}
+	if err := s.repo.UpdateRequest(ctx, req.ID, updates); err != nil {
+		log.Printf("[approvals] istek güncellenemedi id=%s: %v", req.ID, err)
+	}
+
+	s.notifyDecision(req)
+	return req, nil
+}
+
+// Reject — isteği reddeder; aksiyon çalıştırılmaz.
+func (s *Service) Reject(ctx context.Context, id, userID uuid.UUID, note string) (*Request, error) {
+	req, err := s.decide(ctx, id, userID, StatusRejected, note)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyDecision(req)
+	return req, nil
+}
+
+func (s *Service) decide(ctx context.Context, id, userID uuid.UUID, status, note string) (*Request, error) {
+	req, err := s.Get(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !req.canApprove(userID) {
+		return nil, ErrForbidden
+	}
+	// Politika tanımlandıktan sonra erişimi kaldırılan onaylayıcı karar veremez.
+	allowed, err := s.repo.HasApproverRole(ctx, req.ServiceID, s.clusterOrgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+
+	var notePtr *string
+	if note != "" {
+		notePtr = &note
+	}
+	ok, err := s.repo.Decide(ctx, id, userID, status, notePtr)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotPending
+	}
+
+	now := time.Now()
+	req.Status = status
+	req.DecidedBy = &userID
+	req.DecisionNote = notePtr
+	req.DecidedAt = &now
+
+	action := audit.ActionApprovalApproved
+	if status == StatusRejected {
+		action = audit.ActionApprovalRejected
+	}
+	s.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &userID,
+		Action:       action,
+		ResourceType: "approval_request",
+		ResourceID:   &req.ID,
+		Status:       audit.StatusSuccess,
+		Details: map[string]any{
+			"action":       req.Action,
+			"service_id":   req.ServiceID,
+			"target":       req.Target,
+			"requested_by": req.RequestedBy,
+			"note":         note,
+		},
+	})
+	return req, nil
+}
+
+// execute — onaylanan aksiyonu çalıştırır; servis komutları için command_id döner.
+func (s *Service) execute(ctx context.Context, req *Request) (string, error) {
+	var params map[string]interface{}
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return "", err
+		}
+	}
+
+	if req.ServiceID != nil {
+		agentID, _ := params["agent_id"].(string)
+		result, err := s.dispatcher.Dispatch(ctx, services.DispatchRequest{
+			ServiceID: *req.ServiceID,
+			UserID:    req.RequestedBy,
+			Action:    req.Action,
+			Params:    params,
+			AgentID:   agentID,
+			Approved:  true,
+		})
+		if err != nil {
+			return "", err
+		}
+		return result.CommandID, nil
+	}
+
+	if s.cluster == nil {
+		return "", errors.New("Kubernetes entegrasyonu yapılandırılmamış")
+	}
+	target := ""
+	if req.Target != nil {
+		target = *req.Target
+	}
+	switch req.Action {
+	case ActionK8sDeletePod:
+		return "", s.cluster.DeletePod(ctx, target)
+	case ActionK8sUndeploy:
+		return "", s.cluster.UndeployService(ctx, target)
+	}
+	return "", fmt.Errorf("desteklenmeyen aksiyon: %s", req.Action)
+}
+
+// ListPolicies — servisin (serviceID nil ise cluster'ın) onay politikalarını döndürür.
+func (s *Service) ListPolicies(ctx context.Context, serviceID *uuid.UUID) ([]Policy, error) {
+	return s.repo.ListPolicies(ctx, serviceID)
+}
+
+// SetPolicy — aksiyon için onay politikasını oluşturur veya günceller.
+// Onaylayıcılar arasında politikayı tanımlayan dışında en az bir kullanıcı bulunmalı ve her
+// onaylayıcının aksiyonu onaylayacak rolü olmalıdır (bkz. Repository.HasApproverRole).
+func (s *Service) SetPolicy(ctx context.Context, userID uuid.UUID, serviceID *uuid.UUID, in PolicyRequest) (*Policy, error) {
+	if serviceID != nil && !serviceActions[in.Action] {
+		return nil, fmt.Errorf("%w: servis politikaları yalnızca stop ve scale için tanımlanabilir", ErrInvalidPolicy)
+	}
+	if serviceID == nil && !clusterActions[in.Action] {
+		return nil, fmt.Errorf("%w: cluster politikaları yalnızca k8s_delete_pod ve k8s_undeploy için tanımlanabilir", ErrInvalidPolicy)
+	}
+
+	emails := make([]string, 0, len(in.Approvers))
+	for _, e := range in.Approvers {
+		emails = append(emails, strings.ToLower(strings.TrimSpace(e)))
+	}
+	ids, err := s.repo.ResolveUserIDs(ctx, emails)
+	if err != nil {
+		return nil, err
+	}
+	approvers, err := approverList(emails, ids, userID, func(id uuid.UUID) (bool, error) {
+		return s.repo.HasApproverRole(ctx, serviceID, s.clusterOrgID, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := in.TTLSec
+	if ttl == 0 {
+		ttl = defaultTTLSec
+	}
+
+	policy := &Policy{
+		UserID:      userID,
+		ServiceID:   serviceID,
+		Action:      in.Action,
+		ApproverIDs: approvers,
+		TTLSec:      ttl,
+	}
+	if err := s.repo.UpsertPolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// approverList — email'leri tekilleştirilmiş onaylayıcı ID'lerine çevirir. Bulunamayan ve yetkisi
+// olmayan kullanıcılar aynı hatayı alır; böylece uç kayıtlı email'leri sızdırmaz.
+func approverList(emails []string, ids map[string]uuid.UUID, userID uuid.UUID, allowed func(uuid.UUID) (bool, error)) (pq.StringArray, error) {
+	seen := make(map[uuid.UUID]bool, len(emails))
+	approvers := make(pq.StringArray, 0, len(emails))
+	hasOther := false
+	for _, e := range emails {
+		id, ok := ids[e]
+		if !ok {
+			return nil, errUnknownApprover
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ok, err := allowed(id)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errUnknownApprover
+		}
+		if id != userID {
+			hasOther = true
+		}
+		approvers = append(approvers, id.String())
+	}
+	if !hasOther {
+		return nil, fmt.Errorf("%w: en az bir onaylayıcı sizden farklı bir kullanıcı olmalı", ErrInvalidPolicy)
+	}
+	return approvers, nil
+}
+
+func (s *Service) DeletePolicy(ctx context.Context, id uuid.UUID, serviceID *uuid.UUID) error {
+	err := s.repo.DeletePolicy(ctx, id, serviceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *Service) IsServiceOwner(ctx context.Context, serviceID, userID uuid.UUID) (bool, error) {
+	return s.repo.IsServiceOwner(ctx, serviceID, userID)
+}
+
+// summary — bildirimlerde kullanılan kısa açıklama (örn: "stop — api-gateway").
+func (s *Service) summary(ctx context.Context, req *Request) string {
+	subject := ""
+	if req.ServiceID != nil {
+		subject = s.repo.ServiceName(ctx, *req.ServiceID)
+	} else if req.Target != nil {
+		subject = *req.Target
+	}
+	if subject == "" {
+		return req.Action
+	}
+	return req.Action + " — " + subject
+}
+
+// notifyApprovers — onaylayıcılara WebSocket ve (yapılandırılmışsa) email ile haber verir.
+func (s *Service) notifyApprovers(req *Request) {
+	for _, id := range req.ApproverIDs {
+		if id == req.RequestedBy.String() {
+			continue
+		}
+		s.hub.BroadcastUserEvent(id, "approval_requested", req)
+	}
+	if req.ServiceID != nil {
+		s.hub.BroadcastEvent(req.ServiceID.String(), "approval_requested", req)
+	}
+
+	if s.notifier == nil || !s.notifier.Enabled() {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		approvers := make([]string, 0, len(req.ApproverIDs))
+		for _, id := range req.ApproverIDs {
+			if id != req.RequestedBy.String() {
+				approvers = append(approvers, id)
+			}
+		}
+		emails, err := s.repo.UserEmails(ctx, approvers)
+		if err != nil {
+			log.Printf("[approvals] onaylayıcı email'leri alınamadı id=%s: %v", req.ID, err)
+			return
+		}
+		requester, _ := s.repo.UserEmails(ctx, []string{req.RequestedBy.String()})
+		requestedBy := req.RequestedBy.String()
+		if len(requester) > 0 {
+			requestedBy = requester[0]
+		}
+		summary := s.summary(ctx, req)
+		for _, email := range emails {
+			if err := s.notifier.SendApprovalRequest(email, summary, requestedBy, req.ExpiresAt); err != nil {
+				log.Printf("[approvals] email gönderilemedi id=%s: %v", req.ID, err)
+			}
+		}
+	}()
+}
+
+// notifyDecision — karar (veya süre dolumu) isteği açan kullanıcıya ve servis paneline iletilir.
+func (s *Service) notifyDecision(req *Request) {
+	s.hub.BroadcastUserEvent(req.RequestedBy.String(), "approval_decided", req)
+	if req.ServiceID != nil {
+		s.hub.BroadcastEvent(req.ServiceID.String(), "approval_decided", req)
+	}
+}
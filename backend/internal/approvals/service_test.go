@@ -0,0 +1,69 @@
+package approvals
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequest_CanApproveAndView(t *testing.T) {
+	requester, approver, other := uuid.New(), uuid.New(), uuid.New()
+	req := &Request{
+		RequestedBy: requester,
+		ApproverIDs: pq.StringArray{requester.String(), approver.String()},
+	}
+
+	assert.False(t, req.canApprove(requester), "isteği açan listede olsa da kendi isteğini onaylayamaz")
+	assert.True(t, req.canApprove(approver))
+	assert.False(t, req.canApprove(other), "listede olmayan onaylayamaz")
+
+	assert.True(t, req.canView(requester))
+	assert.True(t, req.canView(approver))
+	assert.False(t, req.canView(other))
+}
+
+func TestApproverList(t *testing.T) {
+	caller, alice, bob := uuid.New(), uuid.New(), uuid.New()
+	ids := map[string]uuid.UUID{
+		"caller@example.com": caller,
+		"alice@example.com":  alice,
+		"bob@example.com":    bob,
+	}
+	allowAll := func(uuid.UUID) (bool, error) { return true, nil }
+
+	_, err := approverList([]string{"caller@example.com"}, ids, caller, allowAll)
+	assert.ErrorIs(t, err, ErrInvalidPolicy, "yalnızca çağıranı içeren liste reddedilmeli")
+
+	got, err := approverList([]string{"alice@example.com", "caller@example.com", "alice@example.com"}, ids, caller, allowAll)
+	require.NoError(t, err)
+	assert.Equal(t, pq.StringArray{alice.String(), caller.String()}, got, "tekrarlar atlanır")
+
+	_, unknownErr := approverList([]string{"alice@example.com", "nobody@example.com"}, ids, caller, allowAll)
+	assert.ErrorIs(t, unknownErr, ErrInvalidPolicy)
+	assert.NotContains(t, unknownErr.Error(), "nobody@example.com", "email hata mesajına sızmamalı")
+
+	onlyAlice := func(id uuid.UUID) (bool, error) { return id != bob, nil }
+	_, forbiddenErr := approverList([]string{"alice@example.com", "bob@example.com"}, ids, caller, onlyAlice)
+	assert.Equal(t, unknownErr, forbiddenErr, "yetkisiz ve bilinmeyen onaylayıcı aynı hatayı almalı")
+
+	dbErr := errors.New("bağlantı koptu")
+	_, err = approverList([]string{"alice@example.com"}, ids, caller, func(uuid.UUID) (bool, error) { return false, dbErr })
+	assert.ErrorIs(t, err, dbErr)
+}
+
+func TestSetPolicy_ActionScope(t *testing.T) {
+	s := &Service{}
+	serviceID := uuid.New()
+	approvers := []string{"alice@example.com"}
+
+	_, err := s.SetPolicy(context.Background(), uuid.New(), &serviceID, PolicyRequest{Action: ActionK8sDeletePod, Approvers: approvers})
+	assert.ErrorIs(t, err, ErrInvalidPolicy, "servis politikası cluster aksiyonu alamaz")
+
+	_, err = s.SetPolicy(context.Background(), uuid.New(), nil, PolicyRequest{Action: ActionStop, Approvers: approvers})
+	assert.ErrorIs(t, err, ErrInvalidPolicy, "cluster politikası servis aksiyonu alamaz")
+}
@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"nanonet-backend/pkg/audit"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// APIKeyPrefix — kişisel API anahtarlarının başlangıcı; middleware JWT'lerden bununla ayırır.
+const APIKeyPrefix = "nn_"
+
+// API anahtarı kapsamları. Anahtar yalnızca kapsamlarının izin verdiği uçlara erişebilir.
+const (
+	ScopeMetricsWrite    = "metrics:write"
+	ScopeServicesRead    = "services:read"
+	ScopeCommandsExecute = "commands:execute"
+)
+
+// APIKeyScopes — verilebilecek kapsamlar.
+var APIKeyScopes = []string{ScopeMetricsWrite, ScopeServicesRead, ScopeCommandsExecute}
+
+const (
+	// maxAPIKeysPerUser — kullanıcı başına aktif anahtar sınırı.
+	maxAPIKeysPerUser = 25
+	// apiKeyPrefixLen — listede gösterilen anahtar başlangıcının uzunluğu.
+	apiKeyPrefixLen = 11
+	// lastUsedResolution — last_used_at en fazla bu aralıkla güncellenir.
+	lastUsedResolution = time.Minute
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("API anahtarı bulunamadı")
+	ErrAPIKeyInvalid  = errors.New("geçersiz veya süresi dolmuş API anahtarı")
+	ErrAPIKeyLimit    = errors.New("en fazla 25 aktif API anahtarı oluşturulabilir")
+)
+
+// APIKey — kullanıcıya ait, kapsamlı API anahtarı. Anahtarın kendisi yalnızca SHA-256 özeti
+// olarak saklanır.
+type APIKey struct {
+	ID         uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
+	Name       string         `gorm:"type:varchar(100);not null" json:"name"`
+	Prefix     string         `gorm:"type:varchar(16);not null" json:"prefix"`
+	KeyHash    string         `gorm:"type:varchar(64);not null;unique" json:"-"`
+	Scopes     pq.StringArray `gorm:"type:text[];not null" json:"scopes"`
+	ExpiresAt  *time.Time     `json:"expires_at"`
+	LastUsedAt *time.Time     `json:"last_used_at"`
+	RevokedAt  *time.Time     `json:"revoked_at"`
+	CreatedAt  time.Time      `gorm:"not null;default:now()" json:"created_at"`
+}
+
+func (APIKey) TableName() string { return "api_keys" }
+
+// Active — anahtar iptal edilmemiş ve süresi dolmamışsa true.
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// HasScope — anahtarın kapsamı içerip içermediğini bildirir.
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,min=1,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,oneof=metrics:write services:read commands:execute"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=3650"`
+}
+
+// IssuedAPIKey — yeni oluşturulan anahtar ve yalnızca bu yanıtta gösterilen değeri.
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyAuthenticator — middleware'in nn_ anahtarlarını doğrulamak için kullandığı arayüz.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*APIKey, error)
+}
+
+// commandActions — commands:execute kapsamındaki servis uçları.
+var commandActions = []string{"restart", "stop", "start", "exec", "scale", "ping"}
+
+// ScopeForRoute — bir API anahtarının route'a erişmesi için gereken kapsam. "" dönen route'lar
+// (hesap, organizasyon, ayar ve yapılandırma uçları) API anahtarıyla kullanılamaz.
+func ScopeForRoute(method, path string) string {
+	const services = "/api/v1/services"
+	switch {
+	case method == "POST" && path == "/api/v1/metrics":
+		return ScopeMetricsWrite
+	case method == "GET" && (path == services || strings.HasPrefix(path, services+"/")):
+		return ScopeServicesRead
+	case method == "POST" && strings.HasPrefix(path, services+"/:id/"):
+		rest := strings.TrimPrefix(path, services+"/:id/")
+		if slices.Contains(commandActions, rest) ||
+			rest == "commands/:commandId/cancel" ||
+			rest == "runbooks/:runbookId/run" {
+			return ScopeCommandsExecute
+		}
+	}
+	return ""
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIKey — kullanıcı için yeni anahtar üretir; anahtar değeri yalnızca bir kez döner.
+func (s *Service) CreateAPIKey(ctx context.Context, userID uuid.UUID, req CreateAPIKeyRequest) (*IssuedAPIKey, error) {
+	var active int64
+	if err := s.db.WithContext(ctx).Model(&APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())", userID).
+		Count(&active).Error; err != nil {
+		return nil, err
+	}
+	if active >= maxAPIKeysPerUser {
+		return nil, ErrAPIKeyLimit
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	key := APIKeyPrefix + hex.EncodeToString(b)
+
+	scopes := slices.Clone(req.Scopes)
+	slices.Sort(scopes)
+	issued := &IssuedAPIKey{
+		APIKey: APIKey{
+			UserID:  userID,
+			Name:    strings.TrimSpace(req.Name),
+			Prefix:  key[:apiKeyPrefixLen],
+			KeyHash: hashAPIKey(key),
+			Scopes:  slices.Compact(scopes),
+		},
+		Key: key,
+	}
+	if req.ExpiresInDays > 0 {
+		exp := time.Now().Add(time.Duration(req.ExpiresInDays) * 24 * time.Hour)
+		issued.ExpiresAt = &exp
+	}
+	if err := s.db.WithContext(ctx).Create(&issued.APIKey).Error; err != nil {
+		return nil, err
+	}
+
+	s.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &userID,
+		Action:       audit.ActionAPIKeyCreate,
+		ResourceType: "api_key",
+		ResourceID:   &issued.ID,
+		Status:       audit.StatusSuccess,
+		Details:      map[string]any{"name": issued.Name, "scopes": []string(issued.Scopes)},
+	})
+	return issued, nil
+}
+
+// ListAPIKeys — kullanıcının anahtarları (iptal edilenler dahil), en yeniden eskiye.
+func (s *Service) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]APIKey, error) {
+	var keys []APIKey
+	err := s.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// RevokeAPIKey — anahtarı iptal eder; iptal edilmiş anahtar bir sonraki istekte reddedilir.
+func (s *Service) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error {
+	res := s.db.WithContext(ctx).Model(&APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	s.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &userID,
+		Action:       audit.ActionAPIKeyRevoke,
+		ResourceType: "api_key",
+		ResourceID:   &keyID,
+		Status:       audit.StatusSuccess,
+	})
+	return nil
+}
+
+// AuthenticateAPIKey — APIKeyAuthenticator; anahtarı doğrular ve last_used_at'i günceller.
+func (s *Service) AuthenticateAPIKey(ctx context.Context, key string) (*APIKey, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, ErrAPIKeyInvalid
+	}
+	var k APIKey
+	if err := s.db.WithContext(ctx).Where("key_hash = ?", hashAPIKey(key)).First(&k).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyInvalid
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if !k.Active(now) {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	// Her istekte yazmamak için last_used_at dakikada en fazla bir kez güncellenir.
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= lastUsedResolution {
+		if err := s.db.WithContext(ctx).Model(&APIKey{}).
+			Where("id = ?", k.ID).
+			Update("last_used_at", now).Error; err != nil {
+			log.Printf("[auth] API anahtarı last_used_at güncellenemedi (%s): %v", k.ID, err)
+		}
+		k.LastUsedAt = &now
+	}
+	return &k, nil
+}
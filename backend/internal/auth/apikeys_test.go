@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubAPIKeys struct {
+	key *APIKey
+}
+
+func (s *stubAPIKeys) AuthenticateAPIKey(_ context.Context, key string) (*APIKey, error) {
+	if s.key == nil || key != "nn_valid" {
+		return nil, ErrAPIKeyInvalid
+	}
+	return s.key, nil
+}
+
+func TestScopeForRoute(t *testing.T) {
+	assert.Equal(t, ScopeMetricsWrite, ScopeForRoute("POST", "/api/v1/metrics"))
+	assert.Equal(t, ScopeServicesRead, ScopeForRoute("GET", "/api/v1/services"))
+	assert.Equal(t, ScopeServicesRead, ScopeForRoute("GET", "/api/v1/services/:id/metrics"))
+	assert.Equal(t, ScopeCommandsExecute, ScopeForRoute("POST", "/api/v1/services/:id/restart"))
+	assert.Equal(t, ScopeCommandsExecute, ScopeForRoute("POST", "/api/v1/services/:id/runbooks/:runbookId/run"))
+	assert.Empty(t, ScopeForRoute("POST", "/api/v1/services"), "servis oluşturma kapsam dışı")
+	assert.Empty(t, ScopeForRoute("DELETE", "/api/v1/services/:id"))
+	assert.Empty(t, ScopeForRoute("POST", "/api/v1/auth/api-keys"), "anahtar anahtar üretemez")
+}
+
+func TestMiddleware_APIKey(t *testing.T) {
+	userID := uuid.New()
+	m := NewMiddleware("test-secret-key-minimum-32-chars-x!", &stubBlacklist{})
+	m.SetAPIKeys(&stubAPIKeys{key: &APIKey{ID: uuid.New(), UserID: userID, Scopes: []string{ScopeServicesRead}}})
+
+	r := gin.New()
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"user_id": c.GetString("user_id")}) }
+	r.GET("/api/v1/services/:id", m.Required(), ok)
+	r.POST("/api/v1/services/:id/restart", m.Required(), ok)
+	r.GET("/api/v1/settings", m.Required(), ok)
+
+	do := func(method, path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/api/v1/services/abc", "nn_valid")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, userID.String(), decodeBody(t, w)["user_id"])
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/api/v1/services/abc/restart", "nn_valid").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/settings", "nn_valid").Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/api/v1/services/abc", "nn_revoked").Code)
+}
+
+func TestMiddleware_APIKeyDisabled(t *testing.T) {
+	m := NewMiddleware("test-secret-key-minimum-32-chars-x!", &stubBlacklist{})
+	r := gin.New()
+	r.GET("/api/v1/services", m.Required(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/services", nil)
+	req.Header.Set("Authorization", "Bearer nn_valid")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -2,10 +2,18 @@ package auth
 
 import (
 	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"nanonet-backend/internal/orgs"
 	"nanonet-backend/pkg/audit"
+	"nanonet-backend/pkg/lockout"
 	"nanonet-backend/pkg/mailer"
 	"nanonet-backend/pkg/response"
 	"nanonet-backend/pkg/tokenblacklist"
@@ -22,11 +30,14 @@ type Handler struct {
 	mailer      *mailer.Mailer
 	frontendURL string
 	db          *gorm.DB
+	oidc        *OIDCProvider
 }
 
 func NewHandler(db *gorm.DB, jwtSecret string, m *mailer.Mailer, frontendURL string, bl tokenblacklist.Blacklist) *Handler {
+	service := NewService(db, jwtSecret)
+	service.SetBlacklist(bl)
 	return &Handler{
-		service:     NewService(db, jwtSecret),
+		service:     service,
 		blacklist:   bl,
 		mailer:      m,
 		frontendURL: frontendURL,
@@ -34,6 +45,22 @@ func NewHandler(db *gorm.DB, jwtSecret string, m *mailer.Mailer, frontendURL str
 	}
 }
 
+// SetLockout — hesap başına başarısız giriş kilidini etkinleştirir; store çoklu örnekte Redis olmalıdır.
+func (h *Handler) SetLockout(store lockout.Store) {
+	h.service.SetLockout(store, h.mailer, h.frontendURL)
+}
+
+// SetEmailVerification — required true ise yeni hesaplar e-posta doğrulanana kadar kısıtlıdır;
+// middleware'e de SetEmailVerifier ile bir doğrulayıcı verilmelidir.
+func (h *Handler) SetEmailVerification(required bool) {
+	h.service.SetEmailVerification(required)
+}
+
+// SetOIDC — OpenID Connect ile tek oturum açmayı etkinleştirir.
+func (h *Handler) SetOIDC(p *OIDCProvider) {
+	h.oidc = p
+}
+
 func (h *Handler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -41,8 +68,13 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
-	user, err := h.service.Register(req.Email, req.Password)
+	ctx := c.Request.Context()
+	user, inv, err := h.service.Register(ctx, req.Email, req.Password, req.InvitationToken)
 	if err != nil {
+		if errors.Is(err, orgs.ErrInvitationInvalid) || errors.Is(err, orgs.ErrInvitationEmail) {
+			response.BadRequest(c, err.Error())
+			return
+		}
 		errMsg := err.Error()
 		if strings.Contains(errMsg, "duplicate") || strings.Contains(errMsg, "unique") || strings.Contains(errMsg, "already exists") {
 			response.Error(c, 409, "bu email adresi zaten kullanılıyor")
@@ -52,16 +84,34 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
-	tokens, err := h.service.GenerateTokens(user.ID)
+	// Doğrulanmamış hesap giriş yapabilir ancak yalnızca hesap uçlarına erişebilir.
+	if user.EmailVerifiedAt == nil {
+		if err := h.service.SendVerificationEmail(ctx, user, h.mailer, h.frontendURL); err != nil {
+			log.Printf("[auth] doğrulama e-postası oluşturulamadı (%s): %v", user.ID, err)
+		}
+	}
+
+	tokens, err := h.service.StartSession(ctx, user.ID, sessionMeta(c))
 	if err != nil {
 		response.InternalError(c, "token oluşturulamadı")
 		return
 	}
 
-	response.Created(c, gin.H{
-		"user":   user,
-		"tokens": tokens,
-	})
+	details := map[string]any{"method": "password"}
+	if inv != nil {
+		details["invitation_id"] = inv.ID
+	}
+	h.record(c, audit.ActionRegister, user.ID, details)
+
+	body := gin.H{
+		"user":                        user,
+		"tokens":                      tokens,
+		"email_verification_required": user.EmailVerifiedAt == nil,
+	}
+	if inv != nil {
+		body["invitation"] = inv
+	}
+	response.Created(c, body)
 }
 
 func (h *Handler) Login(c *gin.Context) {
@@ -73,15 +123,34 @@ func (h *Handler) Login(c *gin.Context) {
 
 	user, err := h.service.Login(req.Email, req.Password)
 	if err != nil {
+		if lockedResponse(c, err) {
+			return
+		}
 		response.Unauthorized(c, err.Error())
 		return
 	}
 
-	tokens, err := h.service.GenerateTokens(user.ID)
+	// İki adımlı doğrulama etkinse token yerine challenge döner; giriş POST /auth/login/2fa ile tamamlanır.
+	if user.TOTPEnabledAt != nil {
+		challenge, err := h.service.NewTwoFactorChallenge(user.ID)
+		if err != nil {
+			response.InternalError(c, "token oluşturulamadı")
+			return
+		}
+		response.Success(c, gin.H{
+			"two_factor_required": true,
+			"challenge_token":     challenge,
+			"expires_in":          int64(twoFactorChallengeTTL.Seconds()),
+		})
+		return
+	}
+
+	tokens, err := h.service.StartSession(c.Request.Context(), user.ID, sessionMeta(c))
 	if err != nil {
 		response.InternalError(c, "token oluşturulamadı")
 		return
 	}
+	h.record(c, audit.ActionLogin, user.ID, map[string]any{"method": "password"})
 
 	response.Success(c, gin.H{
 		"user":   user,
@@ -89,43 +158,79 @@ func (h *Handler) Login(c *gin.Context) {
 	})
 }
 
-func (h *Handler) Refresh(c *gin.Context) {
-	var req struct {
-		RefreshToken string `json:"refresh_token" binding:"required"`
-	}
-
+// LoginTwoFactor — POST /auth/login/2fa
+// Challenge token'ı ve TOTP ya da kurtarma koduyla girişi tamamlar. Kullanılan challenge kara
+// listeye alınır; aynı challenge ile ikinci oturum açılamaz.
+func (h *Handler) LoginTwoFactor(c *gin.Context) {
+	var req TwoFactorLoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.ValidationError(c, err)
 		return
 	}
 
-	// Reject already-blacklisted tokens before any DB work.
-	if h.blacklist.IsBlacklisted(c.Request.Context(), req.RefreshToken) {
-		response.Unauthorized(c, "geçersiz refresh token")
+	ctx := c.Request.Context()
+	if h.blacklist.IsBlacklisted(ctx, req.ChallengeToken) {
+		response.Unauthorized(c, ErrInvalidChallenge.Error())
 		return
 	}
-
-	userID, expiry, err := h.service.ValidateRefreshToken(req.RefreshToken)
+	user, expiry, err := h.service.CompleteTwoFactorLogin(ctx, req.ChallengeToken, req.Code)
 	if err != nil {
-		response.Unauthorized(c, "geçersiz refresh token")
+		if lockedResponse(c, err) {
+			return
+		}
+		if errors.Is(err, ErrInvalidChallenge) || errors.Is(err, ErrInvalidTwoFactorCode) {
+			response.Unauthorized(c, err.Error())
+			return
+		}
+		response.InternalError(c, "giriş tamamlanamadı")
 		return
 	}
+	if ttl := time.Until(expiry); ttl > 0 {
+		_ = h.blacklist.Add(ctx, req.ChallengeToken, ttl)
+	}
 
-	tokens, err := h.service.GenerateTokens(userID)
+	tokens, err := h.service.StartSession(c.Request.Context(), user.ID, sessionMeta(c))
 	if err != nil {
 		response.InternalError(c, "token oluşturulamadı")
 		return
 	}
+	h.record(c, audit.ActionLogin, user.ID, map[string]any{"method": "password", "two_factor": true})
 
-	// Blacklist the consumed refresh token so it cannot be reused.
-	if ttl := time.Until(expiry); ttl > 0 {
-		_ = h.blacklist.Add(c.Request.Context(), req.RefreshToken, ttl)
+	response.Success(c, gin.H{
+		"user":   user,
+		"tokens": tokens,
+	})
+}
+
+func (h *Handler) Refresh(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	tokens, err := h.service.RefreshSession(c.Request.Context(), req.RefreshToken, sessionMeta(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrRefreshTokenReused), errors.Is(err, ErrRefreshRaced):
+			response.Unauthorized(c, err.Error())
+		case errors.Is(err, ErrInvalidRefreshToken):
+			response.Unauthorized(c, "geçersiz refresh token")
+		default:
+			response.InternalError(c, "token oluşturulamadı")
+		}
+		return
 	}
 
 	response.Success(c, tokens)
 }
 
 // AgentToken generates a long-lived token suitable for agent processes.
+// Kullanıcıya bağlı bu token yalnızca kullanıcının kendi servisleri için kabul edilir;
+// yeni kurulumlar servise bağlı POST /services/:id/agent-credentials kullanmalıdır.
 func (h *Handler) AgentToken(c *gin.Context) {
 	userIDStr := c.GetString("user_id")
 	if userIDStr == "" {
@@ -144,10 +249,12 @@ func (h *Handler) AgentToken(c *gin.Context) {
 		response.InternalError(c, "agent token oluşturulamadı")
 		return
 	}
+	h.record(c, audit.ActionAgentTokenIssue, userID, nil)
 
 	response.Success(c, gin.H{
 		"agent_token": token,
 		"expires_in":  int64((3650 * 24 * time.Hour).Seconds()),
+		"deprecated":  "servise bağlı, iptal edilebilir kimlik bilgisi için POST /services/:id/agent-credentials kullanın",
 	})
 }
 
@@ -155,7 +262,19 @@ func (h *Handler) Logout(c *gin.Context) {
 	tokenString := c.GetString("token")
 	if tokenString != "" {
 		// Access tokens live for 24h; blacklist for the full window.
-		_ = h.blacklist.Add(c.Request.Context(), tokenString, 24*time.Hour)
+		_ = h.blacklist.Add(c.Request.Context(), tokenString, accessTokenTTL)
+	}
+	// Oturumu da kapat; aynı oturumun refresh token'ı artık yenilenemez.
+	userID, errUser := uuid.Parse(c.GetString("user_id"))
+	sessionID, errSession := uuid.Parse(c.GetString("session_id"))
+	if errUser == nil && errSession == nil {
+		if err := h.service.RevokeSession(c.Request.Context(), userID, sessionID, RevokeLogout); err != nil &&
+			!errors.Is(err, ErrSessionNotFound) {
+			log.Printf("[auth] oturum kapatılamadı (%s): %v", sessionID, err)
+		}
+	}
+	if errUser == nil {
+		h.record(c, audit.ActionLogout, userID, nil)
 	}
 	response.Success(c, gin.H{"message": "çıkış başarılı"})
 }
@@ -272,8 +391,628 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 
 	tokenString := c.GetString("token")
 	if tokenString != "" {
-		_ = h.blacklist.Add(c.Request.Context(), tokenString, 24*time.Hour)
+		_ = h.blacklist.Add(c.Request.Context(), tokenString, accessTokenTTL)
+	}
+	// Şifre değişince tüm cihazlardaki oturumlar kapanır.
+	if _, err := h.service.RevokeAllSessions(c.Request.Context(), userID, uuid.Nil, RevokePasswordChanged); err != nil {
+		log.Printf("[auth] oturumlar kapatılamadı (%s): %v", userID, err)
 	}
 
 	response.Success(c, gin.H{"message": "şifre güncellendi, lütfen tekrar giriş yapın"})
 }
+
+// VerifyEmail — POST /auth/verify-email
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required,max=128"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	user, err := h.service.VerifyEmail(c.Request.Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, ErrInvalidVerification) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.InternalError(c, "e-posta doğrulanamadı")
+		return
+	}
+
+	response.Success(c, gin.H{"message": "E-posta adresiniz doğrulandı", "user": user})
+}
+
+// ResendVerification — POST /auth/verify-email/resend
+func (h *Handler) ResendVerification(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+
+	if err := h.service.ResendVerification(c.Request.Context(), userID, h.mailer, h.frontendURL); err != nil {
+		switch {
+		case errors.Is(err, ErrEmailAlreadyVerified):
+			response.BadRequest(c, err.Error())
+		case errors.Is(err, ErrVerificationCooldown):
+			c.Header("Retry-After", strconv.Itoa(int(resendCooldown.Seconds())))
+			response.Error(c, http.StatusTooManyRequests, err.Error())
+		default:
+			response.InternalError(c, "doğrulama e-postası gönderilemedi")
+		}
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Doğrulama bağlantısı gönderildi"})
+}
+
+// PreviewInvitation — POST /auth/invitations/preview
+// Davet bağlantısının organizasyonunu ve rolünü gösterir; account_exists ile arayüz kayıt veya
+// giriş formunu seçer.
+func (h *Handler) PreviewInvitation(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required,max=128"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	inv, err := orgs.LookupInvitation(c.Request.Context(), h.db, req.Token)
+	if err != nil {
+		if errors.Is(err, orgs.ErrInvitationInvalid) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, "davet alınamadı")
+		return
+	}
+	var accounts int64
+	if err := h.db.WithContext(c.Request.Context()).Model(&User{}).
+		Where("LOWER(email) = ?", inv.Email).Count(&accounts).Error; err != nil {
+		response.InternalError(c, "davet alınamadı")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"org_id":         inv.OrgID,
+		"org_name":       inv.OrgName,
+		"email":          inv.Email,
+		"role":           inv.Role,
+		"expires_at":     inv.ExpiresAt,
+		"account_exists": accounts > 0,
+	})
+}
+
+// AcceptInvitation — POST /auth/invitations/accept
+// Hesabı olan kullanıcı daveti giriş yaptıktan sonra kabul eder; adres davetle aynı olmalıdır.
+func (h *Handler) AcceptInvitation(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+	var req struct {
+		Token string `json:"token" binding:"required,max=128"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	inv, err := h.service.AcceptInvitation(c.Request.Context(), userID, req.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, orgs.ErrInvitationInvalid):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, orgs.ErrInvitationEmail):
+			response.Forbidden(c, err.Error())
+		default:
+			response.InternalError(c, "davet kabul edilemedi")
+		}
+		return
+	}
+
+	response.Success(c, inv)
+}
+
+// ListSessions — GET /auth/sessions
+func (h *Handler) ListSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+	sessions, err := h.service.ListSessions(c.Request.Context(), userID)
+	if err != nil {
+		response.InternalError(c, "oturumlar alınamadı")
+		return
+	}
+	current := c.GetString("session_id")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.String() == current
+	}
+	response.Success(c, gin.H{"sessions": sessions})
+}
+
+// RevokeSession — DELETE /auth/sessions/:sessionId
+// Oturumun refresh token'ı ve access token'ları hemen geçersiz olur.
+func (h *Handler) RevokeSession(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz oturum ID")
+		return
+	}
+	if err := h.service.RevokeSession(c.Request.Context(), userID, sessionID, RevokeUser); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, "oturum kapatılamadı")
+		return
+	}
+	response.Success(c, gin.H{"revoked": true})
+}
+
+// RevokeAllSessions — DELETE /auth/sessions?keep_current=true
+// "Her yerden çıkış": kullanıcının tüm oturumlarını kapatır; keep_current=true ise istek yapılan
+// oturum açık kalır.
+func (h *Handler) RevokeAllSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+	var except uuid.UUID
+	if c.Query("keep_current") == "true" {
+		except, _ = uuid.Parse(c.GetString("session_id"))
+	}
+	count, err := h.service.RevokeAllSessions(c.Request.Context(), userID, except, RevokeLogoutAll)
+	if err != nil {
+		response.InternalError(c, "oturumlar kapatılamadı")
+		return
+	}
+	if except == uuid.Nil {
+		// Oturumlardan önce üretilmiş access token'lar oturum anahtarıyla yakalanamaz.
+		if tokenString := c.GetString("token"); tokenString != "" {
+			_ = h.blacklist.Add(c.Request.Context(), tokenString, accessTokenTTL)
+		}
+	}
+	response.Success(c, gin.H{"revoked": count})
+}
+
+// ListAPIKeys — GET /auth/api-keys
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+	keys, err := h.service.ListAPIKeys(c.Request.Context(), userID)
+	if err != nil {
+		response.InternalError(c, "API anahtarları alınamadı")
+		return
+	}
+	response.Success(c, gin.H{"api_keys": keys, "scopes": APIKeyScopes})
+}
+
+// CreateAPIKey — POST /auth/api-keys
+// Anahtar değeri yalnızca bu yanıtta döner; sonradan görüntülenemez.
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+	issued, err := h.service.CreateAPIKey(c.Request.Context(), userID, req)
+	if err != nil {
+		if errors.Is(err, ErrAPIKeyLimit) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.InternalError(c, "API anahtarı oluşturulamadı")
+		return
+	}
+	response.Created(c, issued)
+}
+
+// RevokeAPIKey — DELETE /auth/api-keys/:keyId
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+	keyID, err := uuid.Parse(c.Param("keyId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz anahtar ID")
+		return
+	}
+	if err := h.service.RevokeAPIKey(c.Request.Context(), userID, keyID); err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, "API anahtarı iptal edilemedi")
+		return
+	}
+	response.Success(c, gin.H{"revoked": true})
+}
+
+// TwoFactorStatus — GET /auth/2fa
+func (h *Handler) TwoFactorStatus(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+	status, err := h.service.TwoFactorStatus(c.Request.Context(), userID)
+	if err != nil {
+		response.InternalError(c, "iki adımlı doğrulama durumu alınamadı")
+		return
+	}
+	response.Success(c, status)
+}
+
+// SetupTwoFactor — POST /auth/2fa/setup
+// Yeni TOTP anahtarını ve QR kod için otpauth:// adresini döndürür. Etkinleştirme
+// POST /auth/2fa/enable ile yapılır.
+func (h *Handler) SetupTwoFactor(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+	setup, err := h.service.SetupTOTP(c.Request.Context(), userID)
+	if err != nil {
+		h.twoFactorError(c, err, "iki adımlı doğrulama kurulamadı")
+		return
+	}
+	response.Success(c, setup)
+}
+
+// EnableTwoFactor — POST /auth/2fa/enable
+// Kurtarma kodları yalnızca bu yanıtta döner.
+func (h *Handler) EnableTwoFactor(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+	codes, err := h.service.EnableTOTP(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		h.twoFactorError(c, err, "iki adımlı doğrulama etkinleştirilemedi")
+		return
+	}
+	response.Success(c, gin.H{"enabled": true, "recovery_codes": codes})
+}
+
+// DisableTwoFactor — POST /auth/2fa/disable
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+	var req DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+	if err := h.service.DisableTOTP(c.Request.Context(), userID, req.Password, req.Code); err != nil {
+		h.twoFactorError(c, err, "iki adımlı doğrulama kapatılamadı")
+		return
+	}
+	response.Success(c, gin.H{"enabled": false})
+}
+
+// RegenerateRecoveryCodes — POST /auth/2fa/recovery-codes
+// Eski kurtarma kodları geçersiz olur; yeni kodlar yalnızca bu yanıtta döner.
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		h.twoFactorError(c, err, "kurtarma kodları oluşturulamadı")
+		return
+	}
+	response.Success(c, gin.H{"recovery_codes": codes})
+}
+
+// RequestTwoFactorReset — POST /auth/2fa/reset-request
+func (h *Handler) RequestTwoFactorReset(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	if err := h.service.RequestTwoFactorReset(req.Email, h.mailer, h.frontendURL); err != nil {
+		response.InternalError(c, "işlem gerçekleştirilemedi")
+		return
+	}
+
+	// Enumeration saldırılarını önlemek için her durumda aynı yanıt
+	response.Success(c, gin.H{"message": "Eğer bu hesapta iki adımlı doğrulama etkinse sıfırlama bağlantısı gönderildi"})
+}
+
+// ConfirmTwoFactorReset — POST /auth/2fa/reset
+func (h *Handler) ConfirmTwoFactorReset(c *gin.Context) {
+	var req struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	if err := h.service.ConfirmTwoFactorReset(c.Request.Context(), req.Token, req.Password); err != nil {
+		if errors.Is(err, ErrInvalidPassword) {
+			response.Unauthorized(c, err.Error())
+			return
+		}
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"message": "İki adımlı doğrulama kapatıldı, lütfen giriş yapıp yeniden kurun"})
+}
+
+// AdminResetTwoFactor — POST /auth/users/:userId/2fa/reset
+// Kişisel olmayan ortak bir organizasyonda admin/owner olan kullanıcı, kendisinden düşük roldeki
+// üyenin iki adımlı doğrulamasını kapatabilir.
+func (h *Handler) AdminResetTwoFactor(c *gin.Context) {
+	actorID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+	targetID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz kullanıcı ID")
+		return
+	}
+	if err := h.service.AdminResetTwoFactor(c.Request.Context(), actorID, targetID, h.mailer); err != nil {
+		h.twoFactorError(c, err, "iki adımlı doğrulama sıfırlanamadı")
+		return
+	}
+	response.Success(c, gin.H{"reset": true})
+}
+
+// UnlockAccount — POST /auth/users/:userId/unlock
+// Başarısız girişler nedeniyle kilitlenen hesabın kilidini ve deneme sayacını kaldırır. Yetki
+// kuralı 2FA sıfırlamayla aynıdır.
+func (h *Handler) UnlockAccount(c *gin.Context) {
+	actorID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+	targetID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz kullanıcı ID")
+		return
+	}
+	if err := h.service.UnlockAccount(c.Request.Context(), actorID, targetID); err != nil {
+		switch {
+		case errors.Is(err, ErrUnlockDenied):
+			response.Forbidden(c, err.Error())
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			response.NotFound(c, "kullanıcı bulunamadı")
+		default:
+			response.InternalError(c, "hesap kilidi kaldırılamadı")
+		}
+		return
+	}
+	response.Success(c, gin.H{"unlocked": true})
+}
+
+// lockedResponse — err bir *LockedError ise 429 ve Retry-After yazar.
+func lockedResponse(c *gin.Context, err error) bool {
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+	response.Error(c, http.StatusTooManyRequests, locked.Error())
+	return true
+}
+
+// twoFactorError — 2FA servis hatalarını HTTP yanıtına çevirir.
+func (h *Handler) twoFactorError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrInvalidTwoFactorCode), errors.Is(err, ErrInvalidPassword):
+		response.Unauthorized(c, err.Error())
+	case errors.Is(err, ErrTwoFactorResetDenied):
+		response.Forbidden(c, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.NotFound(c, "kullanıcı bulunamadı")
+	case errors.Is(err, ErrTwoFactorNotEnabled), errors.Is(err, ErrTwoFactorAlreadyEnabled),
+		errors.Is(err, ErrTwoFactorSetupMissing):
+		response.Error(c, http.StatusConflict, err.Error())
+	default:
+		response.InternalError(c, fallback)
+	}
+}
+
+// oidcFlowCookie — giriş akışının durumunu taşıyan çerez; yalnızca callback'e gönderilir.
+const (
+	oidcFlowCookie     = "nn_oidc_flow"
+	oidcFlowCookiePath = "/api/v1/auth/oidc"
+)
+
+// OIDCStatus — GET /auth/oidc
+// Frontend'in "SSO ile giriş" düğmesini gösterip göstermeyeceğini bildirir.
+func (h *Handler) OIDCStatus(c *gin.Context) {
+	if h.oidc == nil {
+		response.Success(c, gin.H{"enabled": false})
+		return
+	}
+	response.Success(c, gin.H{"enabled": true, "login_url": oidcFlowCookiePath + "/login"})
+}
+
+// OIDCLogin — GET /auth/oidc/login?redirect=/path
+// PKCE verifier, state ve nonce üretir, bunları imzalı bir çerezde saklar ve kullanıcıyı kimlik
+// sağlayıcısına yönlendirir.
+func (h *Handler) OIDCLogin(c *gin.Context) {
+	if h.oidc == nil {
+		response.NotFound(c, ErrOIDCDisabled.Error())
+		return
+	}
+
+	flow, cookie, err := h.service.newOIDCFlow(safeRedirect(c.Query("redirect")))
+	if err != nil {
+		response.InternalError(c, "oturum açma başlatılamadı")
+		return
+	}
+	target, err := h.oidc.AuthCodeURL(c.Request.Context(), flow.State, flow.Nonce, flow.Verifier)
+	if err != nil {
+		log.Printf("[auth] OIDC yetkilendirme adresi oluşturulamadı: %v", err)
+		response.Error(c, http.StatusBadGateway, "kimlik sağlayıcısına ulaşılamadı")
+		return
+	}
+
+	h.setFlowCookie(c, cookie, int(oidcFlowTTL.Seconds()))
+	c.Redirect(http.StatusFound, target)
+}
+
+// OIDCCallback — GET /auth/oidc/callback
+// Kodu id_token'a çevirir, kimliği doğrular ve yeni bir oturumun token'larıyla kullanıcıyı
+// frontend'e yönlendirir. Token'lar sunucu loglarına düşmemesi için URL fragment'ında taşınır.
+func (h *Handler) OIDCCallback(c *gin.Context) {
+	if h.oidc == nil {
+		response.NotFound(c, ErrOIDCDisabled.Error())
+		return
+	}
+
+	cookie, _ := c.Cookie(oidcFlowCookie)
+	h.setFlowCookie(c, "", -1)
+
+	if idpErr := c.Query("error"); idpErr != "" {
+		h.oidcFail(c, fmt.Errorf("kimlik sağlayıcısı girişi reddetti: %s", idpErr))
+		return
+	}
+	flow, err := h.service.parseOIDCFlow(cookie, c.Query("state"))
+	if err != nil {
+		h.oidcFail(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	idToken, err := h.oidc.Exchange(ctx, c.Query("code"), flow.Verifier)
+	if err != nil {
+		h.oidcFail(c, err)
+		return
+	}
+	ident, err := h.oidc.VerifyIDToken(ctx, idToken, flow.Nonce)
+	if err != nil {
+		h.oidcFail(c, err)
+		return
+	}
+	user, err := h.service.LoginOIDC(ctx, h.oidc, ident)
+	if err != nil {
+		h.oidcFail(c, err)
+		return
+	}
+
+	// İki adımlı doğrulaması olan kullanıcı, parola girişindeki gibi ikinci adımı tamamlar.
+	if user.TOTPEnabledAt != nil {
+		challenge, err := h.service.NewTwoFactorChallenge(user.ID)
+		if err != nil {
+			h.oidcFail(c, err)
+			return
+		}
+		fragment := url.Values{
+			"challenge_token": {challenge},
+			"redirect":        {flow.Redirect},
+		}
+		c.Redirect(http.StatusFound, h.frontendURL+"/auth/sso#"+fragment.Encode())
+		return
+	}
+
+	tokens, err := h.service.StartSession(ctx, user.ID, sessionMeta(c))
+	if err != nil {
+		h.oidcFail(c, err)
+		return
+	}
+	fragment := url.Values{
+		"access_token":  {tokens.AccessToken},
+		"refresh_token": {tokens.RefreshToken},
+		"expires_in":    {strconv.FormatInt(tokens.ExpiresIn, 10)},
+		"redirect":      {flow.Redirect},
+	}
+	c.Redirect(http.StatusFound, h.frontendURL+"/auth/sso#"+fragment.Encode())
+}
+
+// oidcFail — hatayı loglar ve kullanıcıyı giriş sayfasına kullanıcıya gösterilebilir mesajla döndürür.
+func (h *Handler) oidcFail(c *gin.Context, err error) {
+	log.Printf("[auth] OIDC girişi başarısız: %v", err)
+	msg := "tek oturum açma başarısız oldu"
+	for _, known := range []error{ErrOIDCInvalidFlow, ErrOIDCEmail, ErrOIDCDomain, ErrOIDCAccountExists} {
+		if errors.Is(err, known) {
+			msg = known.Error()
+		}
+	}
+	c.Redirect(http.StatusFound, h.frontendURL+"/login?"+url.Values{"sso_error": {msg}}.Encode())
+}
+
+func (h *Handler) setFlowCookie(c *gin.Context, value string, maxAge int) {
+	secure := c.Request.TLS != nil || strings.HasPrefix(h.frontendURL, "https://")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcFlowCookie, value, maxAge, oidcFlowCookiePath, "", secure, true)
+}
+
+// record — hesabın kendi işlemini isteğin IP ve user agent bilgisiyle audit log'a yazar.
+func (h *Handler) record(c *gin.Context, action audit.Action, userID uuid.UUID, details map[string]any) {
+	h.service.auditLogger.Record(c.Request.Context(), audit.Entry{
+		UserID:       &userID,
+		Action:       action,
+		ResourceType: "user",
+		ResourceID:   &userID,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		Status:       audit.StatusSuccess,
+		Details:      details,
+	})
+}
+
+// sessionMeta — oturum kaydı için isteğin IP ve User-Agent bilgisi.
+func sessionMeta(c *gin.Context) SessionMeta {
+	return SessionMeta{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+// safeRedirect — yalnızca uygulama içi göreli yolları kabul eder (açık yönlendirmeyi önler).
+func safeRedirect(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.ContainsAny(path, "\\\r\n") {
+		return "/"
+	}
+	return path
+}
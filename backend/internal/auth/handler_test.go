@@ -220,6 +220,25 @@ func TestMiddleware_AgentTokenRejected(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
 
+func TestMiddleware_RefreshTokenRejected(t *testing.T) {
+	secret := "test-secret-key-minimum-32-chars-x!"
+	svc := &Service{jwtSecret: secret}
+
+	refresh, err := svc.generateSessionToken(uuid.New(), uuid.New(), "jti-1", time.Hour, "refresh")
+	require.NoError(t, err)
+
+	m := NewMiddleware(secret, &stubBlacklist{})
+	r := gin.New()
+	r.GET("/protected", m.Required(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+refresh)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "refresh token API erişimi vermemeli")
+}
+
 func TestMiddleware_BlacklistedToken(t *testing.T) {
 	secret := "test-secret-key-minimum-32-chars-x!"
 	svc := &Service{jwtSecret: secret}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"nanonet-backend/internal/orgs"
+	"nanonet-backend/pkg/audit"
+	"nanonet-backend/pkg/lockout"
+	"nanonet-backend/pkg/mailer"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// failureWindow — başarısız denemelerin sayıldığı süre; ilk hatadan itibaren başlar.
+	failureWindow = time.Hour
+	// delayAfter — bu sayıdan sonraki her hata, bir sonraki denemeyi katlanarak artan süre bekletir.
+	delayAfter = 5
+	maxDelay   = 30 * time.Second
+	// lockAfter — bu sayıda hatadan sonra hesap lockDuration süresince kilitlenir; pencere içindeki
+	// her yeni hata kilidi yeniler.
+	lockAfter    = 10
+	lockDuration = 15 * time.Minute
+)
+
+// ErrUnlockDenied — kilidi kaldıran kullanıcının hedef üzerinde yönetici yetkisi yok.
+var ErrUnlockDenied = errors.New("bu kullanıcının hesap kilidini kaldırma yetkiniz yok")
+
+// LockedError — hesap geçici olarak kilitli veya bir sonraki deneme için beklenmesi gerekiyor.
+type LockedError struct {
+	RetryAfter time.Duration
+	// Locked — true ise hesap kilitli; false ise yalnızca kısa bir bekleme uygulanıyor.
+	Locked bool
+}
+
+func (e *LockedError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("çok fazla başarısız giriş denemesi, hesap geçici olarak kilitlendi; %d dakika sonra tekrar deneyin",
+			int(math.Ceil(e.RetryAfter.Minutes())))
+	}
+	return fmt.Sprintf("çok fazla başarısız giriş denemesi, %d saniye sonra tekrar deneyin",
+		int(math.Ceil(e.RetryAfter.Seconds())))
+}
+
+// failureDelay — n. başarısız denemeden sonra uygulanacak bekleme veya kilit süresi.
+func failureDelay(n int) (time.Duration, bool) {
+	switch {
+	case n >= lockAfter:
+		return lockDuration, true
+	case n > delayAfter:
+		d := time.Second << (n - delayAfter - 1)
+		return min(d, maxDelay), false
+	}
+	return 0, false
+}
+
+// loginGuard — hesap başına başarısız giriş sayacı, artan gecikme ve geçici kilit. Anahtar
+// e-posta adresidir; kayıtlı olmayan adresler de aynı şekilde sayılır, böylece yanıtlar hesabın
+// varlığını ele vermez.
+type loginGuard struct {
+	store       lockout.Store
+	mailer      *mailer.Mailer
+	frontendURL string
+}
+
+func lockoutKey(email string) string {
+	return "login:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// SetLockout — hesap kilitleme durumunun tutulacağı store'u ayarlar (çoklu örnekte Redis).
+// Kilitlenen hesabın sahibine m üzerinden bildirim gönderilir.
+func (s *Service) SetLockout(store lockout.Store, m *mailer.Mailer, frontendURL string) {
+	s.guard = &loginGuard{store: store, mailer: m, frontendURL: frontendURL}
+}
+
+// checkLockout — hesap kilitliyse veya bekleme süresi dolmadıysa *LockedError döner. Store
+// hatalarında giriş engellenmez.
+func (s *Service) checkLockout(ctx context.Context, email string) error {
+	if s.guard == nil {
+		return nil
+	}
+	left, err := s.guard.store.LockedFor(ctx, lockoutKey(email))
+	if err != nil {
+		log.Printf("[auth] kilit durumu okunamadı: %v", err)
+		return nil
+	}
+	if left > 0 {
+		return &LockedError{RetryAfter: left, Locked: left > maxDelay}
+	}
+	return nil
+}
+
+// recordLoginFailure — başarısız denemeyi sayar ve gerekirse gecikme veya kilit uygular. Hesap
+// ilk kez kilitlendiğinde user nil değilse sahibine e-posta gönderilir.
+func (s *Service) recordLoginFailure(ctx context.Context, email string, user *User) {
+	if s.guard == nil {
+		return
+	}
+	key := lockoutKey(email)
+	n, err := s.guard.store.RecordFailure(ctx, key, failureWindow)
+	if err != nil {
+		log.Printf("[auth] başarısız giriş sayılamadı: %v", err)
+		return
+	}
+	delay, locked := failureDelay(n)
+	if delay == 0 {
+		return
+	}
+	if err := s.guard.store.Lock(ctx, key, delay); err != nil {
+		log.Printf("[auth] hesap kilitlenemedi: %v", err)
+		return
+	}
+	if !locked || n != lockAfter || user == nil {
+		return
+	}
+
+	until := time.Now().Add(delay)
+	s.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &user.ID,
+		Action:       audit.ActionAccountLocked,
+		ResourceType: "user",
+		ResourceID:   &user.ID,
+		Status:       audit.StatusBlocked,
+		Details:      map[string]any{"failures": n, "locked_until": until.UTC()},
+	})
+	if m := s.guard.mailer; m != nil && m.Enabled() {
+		// Yanıt süresi kilitlenme anını ele vermesin diye e-posta arka planda gönderilir.
+		resetURL := s.guard.frontendURL + "/forgot-password"
+		go func(to string) {
+			if err := m.SendAccountLocked(to, n, until, resetURL); err != nil {
+				log.Printf("[MAILER ERROR] email=%s err=%v", to, err)
+			}
+		}(user.Email)
+	}
+}
+
+// clearLockout — başarılı girişte veya şifre sıfırlamada sayacı ve kilidi temizler.
+func (s *Service) clearLockout(ctx context.Context, email string) {
+	if s.guard == nil {
+		return
+	}
+	if err := s.guard.store.Reset(ctx, lockoutKey(email)); err != nil {
+		log.Printf("[auth] kilit temizlenemedi: %v", err)
+	}
+}
+
+// UnlockAccount — organizasyon yöneticisinin üyenin hesap kilidini ve başarısız deneme sayacını
+// kaldırması. Yetki kuralı AdminResetTwoFactor ile aynıdır.
+func (s *Service) UnlockAccount(ctx context.Context, actorID, targetID uuid.UUID) error {
+	if actorID == targetID {
+		return ErrUnlockDenied
+	}
+	ok, err := orgs.CanManageUser(ctx, s.db, actorID, targetID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUnlockDenied
+	}
+	user, err := s.GetUserByID(targetID)
+	if err != nil {
+		return err
+	}
+	if s.guard != nil {
+		if err := s.guard.store.Reset(ctx, lockoutKey(user.Email)); err != nil {
+			return err
+		}
+	}
+
+	s.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &actorID,
+		Action:       audit.ActionAccountUnlocked,
+		ResourceType: "user",
+		ResourceID:   &targetID,
+		Status:       audit.StatusSuccess,
+	})
+	return nil
+}
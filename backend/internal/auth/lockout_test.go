@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nanonet-backend/pkg/lockout"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailureDelay(t *testing.T) {
+	for n, want := range map[int]time.Duration{
+		1: 0, 5: 0, 6: time.Second, 7: 2 * time.Second, 9: 8 * time.Second, 10: lockDuration, 14: lockDuration,
+	} {
+		got, _ := failureDelay(n)
+		assert.Equal(t, want, got, "n=%d", n)
+	}
+	_, locked := failureDelay(lockAfter - 1)
+	assert.False(t, locked)
+}
+
+func TestLoginGuard(t *testing.T) {
+	ctx := context.Background()
+	s := &Service{}
+	s.SetLockout(lockout.NewInMemory(), nil, "")
+
+	for i := 0; i < delayAfter; i++ {
+		s.recordLoginFailure(ctx, "Ada@Example.com", nil)
+	}
+	require.NoError(t, s.checkLockout(ctx, "ada@example.com"), "ilk denemeler bekletilmez")
+
+	s.recordLoginFailure(ctx, "ada@example.com", nil)
+	var locked *LockedError
+	require.True(t, errors.As(s.checkLockout(ctx, "ADA@example.com"), &locked), "anahtar e-postanın küçük harf halidir")
+	assert.False(t, locked.Locked)
+	assert.LessOrEqual(t, locked.RetryAfter, time.Second)
+
+	for i := delayAfter + 1; i < lockAfter; i++ {
+		s.recordLoginFailure(ctx, "ada@example.com", nil)
+	}
+	require.True(t, errors.As(s.checkLockout(ctx, "ada@example.com"), &locked))
+	assert.True(t, locked.Locked)
+	assert.InDelta(t, lockDuration.Seconds(), locked.RetryAfter.Seconds(), 1)
+	assert.NoError(t, s.checkLockout(ctx, "other@example.com"), "kilit hesaba özeldir")
+
+	s.clearLockout(ctx, "ada@example.com")
+	assert.NoError(t, s.checkLockout(ctx, "ada@example.com"))
+}
+
+func TestLockedResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	assert.True(t, lockedResponse(c, &LockedError{RetryAfter: 90 * time.Second, Locked: true}))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "90", w.Header().Get("Retry-After"))
+	assert.False(t, lockedResponse(c, errors.New("geçersiz email veya şifre")))
+}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"context"
+	"fmt"
 	"strings"
 
 	"nanonet-backend/pkg/response"
@@ -8,11 +10,14 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type Middleware struct {
 	service   *Service
 	blacklist tokenblacklist.Blacklist
+	apiKeys   APIKeyAuthenticator
+	verifier  EmailVerifier
 }
 
 func NewMiddleware(jwtSecret string, bl tokenblacklist.Blacklist) *Middleware {
@@ -22,6 +27,17 @@ func NewMiddleware(jwtSecret string, bl tokenblacklist.Blacklist) *Middleware {
 	}
 }
 
+// SetAPIKeys — nn_ ile başlayan kişisel API anahtarlarını kabul etmeyi etkinleştirir.
+func (m *Middleware) SetAPIKeys(a APIKeyAuthenticator) {
+	m.apiKeys = a
+}
+
+// SetEmailVerifier — e-posta adresi doğrulanmamış kullanıcıları unverifiedRoutes dışındaki
+// uçlardan 403 ile geri çevirir.
+func (m *Middleware) SetEmailVerifier(v EmailVerifier) {
+	m.verifier = v
+}
+
 // tokenTypeFromString — token string'inden tip alanını okur (imza doğrulanmadan).
 // Güvenli kullanım için yalnızca reddetme kararlarında kullanılmalı;
 // kabul kararları her zaman ValidateToken ile yapılır.
@@ -50,6 +66,11 @@ func (m *Middleware) Required() gin.HandlerFunc {
 			}
 		}
 
+		if strings.HasPrefix(tokenString, APIKeyPrefix) {
+			m.authenticateAPIKey(c, tokenString)
+			return
+		}
+
 		if tokenString == "" {
 			tokenString = c.Query("token")
 		}
@@ -74,15 +95,80 @@ func (m *Middleware) Required() gin.HandlerFunc {
 			return
 		}
 
-		userID, err := m.service.ValidateToken(tokenString)
+		userID, sessionID, err := m.service.validateUserToken(tokenString)
 		if err != nil {
 			response.Unauthorized(c, "geçersiz token")
 			c.Abort()
 			return
 		}
+		if sessionID != uuid.Nil && m.blacklist.IsBlacklisted(c.Request.Context(), sessionRevokedKey(sessionID.String())) {
+			response.Unauthorized(c, "oturum sonlandırılmış, lütfen tekrar giriş yapın")
+			c.Abort()
+			return
+		}
+
+		if m.verifier != nil && !unverifiedRoutes[c.FullPath()] {
+			verified, err := m.verifier.EmailVerified(c.Request.Context(), userID)
+			if err != nil {
+				response.InternalError(c, "hesap durumu alınamadı")
+				c.Abort()
+				return
+			}
+			if !verified {
+				response.Forbidden(c, ErrEmailNotVerified.Error())
+				c.Abort()
+				return
+			}
+		}
 
 		c.Set("user_id", userID.String())
 		c.Set("token", tokenString)
+		if sessionID != uuid.Nil {
+			c.Set("session_id", sessionID.String())
+		}
 		c.Next()
 	}
 }
+
+// TokenRevoked — token'ın veya bağlı olduğu oturumun iptal edildiğini bildirir. Middleware dışında
+// kullanıcı token'ı kabul eden yerler (WebSocket) için; imza doğrulaması çağıranın işidir.
+func (m *Middleware) TokenRevoked(ctx context.Context, tokenString string) bool {
+	if m.blacklist.IsBlacklisted(ctx, tokenString) {
+		return true
+	}
+	_, sessionID, err := m.service.validateUserToken(tokenString)
+	return err == nil && sessionID != uuid.Nil &&
+		m.blacklist.IsBlacklisted(ctx, sessionRevokedKey(sessionID.String()))
+}
+
+// authenticateAPIKey — Authorization başlığındaki API anahtarını doğrular ve route'un gerektirdiği
+// kapsamı denetler. Anahtarlar URL'de (token query) kabul edilmez.
+func (m *Middleware) authenticateAPIKey(c *gin.Context, key string) {
+	if m.apiKeys == nil {
+		response.Unauthorized(c, "API anahtarları etkin değil")
+		c.Abort()
+		return
+	}
+	apiKey, err := m.apiKeys.AuthenticateAPIKey(c.Request.Context(), key)
+	if err != nil {
+		response.Unauthorized(c, ErrAPIKeyInvalid.Error())
+		c.Abort()
+		return
+	}
+
+	scope := ScopeForRoute(c.Request.Method, c.FullPath())
+	if scope == "" {
+		response.Forbidden(c, "bu uç API anahtarıyla kullanılamaz")
+		c.Abort()
+		return
+	}
+	if !apiKey.HasScope(scope) {
+		response.Forbidden(c, fmt.Sprintf("API anahtarının %s kapsamı yok", scope))
+		c.Abort()
+		return
+	}
+
+	c.Set("user_id", apiKey.UserID.String())
+	c.Set("api_key_id", apiKey.ID.String())
+	c.Next()
+}
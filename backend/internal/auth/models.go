@@ -10,14 +10,22 @@ type User struct {
 	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	Email        string    `gorm:"type:varchar(255);unique;not null" json:"email"`
 	PasswordHash string    `gorm:"type:varchar(60);not null" json:"-"`
-	APIKeyHash   *string   `gorm:"type:varchar(60)" json:"-"`
-	CreatedAt    time.Time `gorm:"not null;default:now()" json:"created_at"`
-	UpdatedAt    time.Time `gorm:"not null;default:now()" json:"updated_at"`
+	// TOTPSecret — şifrelenmiş TOTP anahtarı; TOTPEnabledAt dolana kadar kurulum tamamlanmamıştır.
+	TOTPSecret    *string    `gorm:"column:totp_secret" json:"-"`
+	TOTPEnabledAt *time.Time `gorm:"column:totp_enabled_at" json:"two_factor_enabled_at,omitempty"`
+	TOTPLastStep  int64      `gorm:"column:totp_last_step;not null;default:0" json:"-"`
+	CreatedAt     time.Time  `gorm:"not null;default:now()" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"not null;default:now()" json:"updated_at"`
+
+	// EmailVerifiedAt — nil ise hesap e-posta doğrulanana kadar yalnızca hesap uçlarına erişebilir.
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
 }
 
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=12"`
+	// InvitationToken — davet bağlantısıyla kayıtta davet kabul edilir; e-posta davetle aynı olmalıdır.
+	InvitationToken string `json:"invitation_token" binding:"omitempty,max=128"`
 }
 
 type LoginRequest struct {
@@ -25,6 +33,21 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// TwoFactorLoginRequest — parolayla alınan challenge token'ı ve TOTP ya da kurtarma kodu.
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required,max=32"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required,max=32"`
+}
+
 type TokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
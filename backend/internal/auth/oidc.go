@@ -0,0 +1,627 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"nanonet-backend/internal/orgs"
+	"nanonet-backend/pkg/audit"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	// oidcFlowTTL — giriş başlatıldıktan sonra callback'in gelmesi gereken süre.
+	oidcFlowTTL = 10 * time.Minute
+	// jwksMinRefresh — bilinmeyen kid için JWKS en fazla bu aralıkla yeniden çekilir.
+	jwksMinRefresh = time.Minute
+	// oidcClockSkew — id_token zaman alanlarında tolere edilen saat farkı.
+	oidcClockSkew = time.Minute
+)
+
+var (
+	ErrOIDCDisabled     = errors.New("tek oturum açma yapılandırılmamış")
+	ErrOIDCInvalidFlow  = errors.New("oturum açma isteği geçersiz veya süresi dolmuş")
+	ErrOIDCInvalidToken = errors.New("kimlik sağlayıcısının token'ı doğrulanamadı")
+	ErrOIDCEmail        = errors.New("kimlik sağlayıcısı doğrulanmış e-posta adresi döndürmedi")
+	ErrOIDCDomain       = errors.New("bu e-posta alan adıyla giriş yapılamaz")
+	// ErrOIDCAccountExists — adres mevcut bir hesaba ait ve sağlayıcı adresi doğrulamadığı için
+	// kimlik o hesaba bağlanamaz.
+	ErrOIDCAccountExists = errors.New("bu e-posta adresiyle kayıtlı bir hesap var; kimlik sağlayıcısı adresi doğrulamadığı için bağlanamaz")
+)
+
+// OIDCConfig — authorization code + PKCE akışının ayarları.
+type OIDCConfig struct {
+	Issuer         string
+	ClientID       string
+	ClientSecret   string
+	RedirectURL    string
+	Scopes         []string
+	AllowedDomains []string
+	GroupsClaim    string
+	// GroupRoles — grup adı → rol; eşleşen en yüksek rol OrgID organizasyonunda verilir.
+	GroupRoles map[string]string
+	OrgID      uuid.UUID
+}
+
+// ParseGroupRoles — "grup=rol" çiftlerini eşlemeye çevirir. Sahiplik gruptan verilemez.
+func ParseGroupRoles(pairs []string) (map[string]string, error) {
+	roles := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		group, role, ok := strings.Cut(pair, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" || !slices.Contains(orgs.TeamRoles, role) {
+			return nil, fmt.Errorf("geçersiz grup eşlemesi %q (grup=viewer|operator|admin)", pair)
+		}
+		roles[group] = role
+	}
+	return roles, nil
+}
+
+// OIDCIdentity — doğrulanmış id_token'dan okunan kimlik.
+type OIDCIdentity struct {
+	Issuer  string
+	Subject string
+	Email   string
+	Groups  []string
+	// EmailVerified — sağlayıcı email_verified=true döndürdü; claim yoksa false.
+	EmailVerified bool
+}
+
+// OIDCLink — sağlayıcıdaki (issuer, subject) kimliğinin yerel hesaba bağı.
+type OIDCLink struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null"`
+	Issuer      string    `gorm:"type:varchar(255);not null"`
+	Subject     string    `gorm:"type:varchar(255);not null"`
+	Email       string    `gorm:"type:varchar(255);not null"`
+	CreatedAt   time.Time `gorm:"not null;default:now()"`
+	LastLoginAt time.Time `gorm:"not null;default:now()"`
+}
+
+func (OIDCLink) TableName() string { return "oidc_identities" }
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCProvider — yapılandırılmış kimlik sağlayıcısıyla konuşur: keşif belgesi, JWKS, kod
+// değişimi ve id_token doğrulaması. Keşif belgesi ve anahtarlar önbelleğe alınır.
+type OIDCProvider struct {
+	cfg    OIDCConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]*rsa.PublicKey
+	keysAt    time.Time
+}
+
+func NewOIDCProvider(cfg OIDCConfig) *OIDCProvider {
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	if !slices.Contains(cfg.Scopes, "openid") {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+	return &OIDCProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var d oidcDiscovery
+	wellKnown := strings.TrimRight(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, &d); err != nil {
+		return nil, fmt.Errorf("OIDC keşif belgesi alınamadı: %w", err)
+	}
+	if d.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("OIDC issuer uyuşmuyor: %q != %q", d.Issuer, p.cfg.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("OIDC keşif belgesi eksik")
+	}
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, target string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: HTTP %d", target, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
+
+// AuthCodeURL — kullanıcının yönlendirileceği yetkilendirme adresi (PKCE S256 ile).
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {pkceChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Exchange — yetkilendirme kodunu code_verifier ile token uç noktasında id_token'a çevirir.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, verifier string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("OIDC token isteği başarısız: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("OIDC token yanıtı okunamadı: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return "", fmt.Errorf("OIDC token isteği reddedildi: %s %s", body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", errors.New("OIDC token yanıtında id_token yok")
+	}
+	return body.IDToken, nil
+}
+
+// VerifyIDToken — id_token'ın imzasını (JWKS, RS256), issuer, audience, süre ve nonce alanlarını
+// doğrular; doğrulanmış e-posta adresini ve grupları döndürür.
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, raw, nonce string) (*OIDCIdentity, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.NewParser(
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(d.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(oidcClockSkew),
+	).ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.keyFor(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCInvalidToken, err)
+	}
+	if got, _ := claims["nonce"].(string); subtle.ConstantTimeCompare([]byte(got), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce uyuşmuyor", ErrOIDCInvalidToken)
+	}
+	// Birden çok audience varsa azp istemcinin kendisi olmalıdır.
+	if aud, _ := claims.GetAudience(); len(aud) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.cfg.ClientID {
+			return nil, fmt.Errorf("%w: azp uyuşmuyor", ErrOIDCInvalidToken)
+		}
+	}
+
+	ident := &OIDCIdentity{Issuer: d.Issuer}
+	ident.Subject, _ = claims["sub"].(string)
+	ident.Email, _ = claims["email"].(string)
+	ident.Email = strings.ToLower(strings.TrimSpace(ident.Email))
+	if ident.Subject == "" || ident.Email == "" {
+		return nil, ErrOIDCEmail
+	}
+	// email_verified false ise reddedilir; claim yoksa adres doğrulanmamış sayılır ve yalnızca
+	// yeni ya da zaten bağlı hesaplarda kullanılır (bkz. resolveOIDCAccount).
+	verified, ok := claims["email_verified"]
+	ident.EmailVerified = verified == true || verified == "true"
+	if ok && !ident.EmailVerified {
+		return nil, ErrOIDCEmail
+	}
+	ident.Groups = stringClaim(claims[p.cfg.GroupsClaim])
+	return ident, nil
+}
+
+// stringClaim — string veya string dizisi olan bir claim'i listeye çevirir.
+func stringClaim(v any) []string {
+	switch val := v.(type) {
+	case string:
+		if val == "" {
+			return nil
+		}
+		return []string{val}
+	case []any:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func (p *OIDCProvider) keyFor(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	stale := time.Since(p.keysAt) >= jwksMinRefresh
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if !stale {
+		return nil, fmt.Errorf("bilinmeyen imza anahtarı %q", kid)
+	}
+	if err := p.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	// Tek anahtar yayınlayan sağlayıcılar kid göndermeyebilir.
+	if kid == "" && len(p.keys) == 1 {
+		for _, k := range p.keys {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("bilinmeyen imza anahtarı %q", kid)
+}
+
+func (p *OIDCProvider) refreshKeys(ctx context.Context) error {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return err
+	}
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, d.JWKSURI, &set); err != nil {
+		return fmt.Errorf("OIDC JWKS alınamadı: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.keysAt = time.Now()
+	p.mu.Unlock()
+	return nil
+}
+
+// CheckDomain — izinli alan adı listesi tanımlıysa e-posta adresinin bunlardan birinde olmasını
+// şart koşar.
+func (p *OIDCProvider) CheckDomain(email string) error {
+	if len(p.cfg.AllowedDomains) == 0 {
+		return nil
+	}
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return ErrOIDCDomain
+	}
+	domain := email[at+1:]
+	for _, allowed := range p.cfg.AllowedDomains {
+		if strings.EqualFold(domain, allowed) {
+			return nil
+		}
+	}
+	return ErrOIDCDomain
+}
+
+// RoleForGroups — kullanıcının gruplarından eşleşen en yüksek rol; eşleşme yoksa "".
+func (p *OIDCProvider) RoleForGroups(groups []string) string {
+	best := ""
+	for _, g := range groups {
+		if role, ok := p.cfg.GroupRoles[g]; ok && orgs.RoleRank(role) > orgs.RoleRank(best) {
+			best = role
+		}
+	}
+	return best
+}
+
+// syncsRoles — grup eşlemesi ve hedef organizasyon tanımlıysa true.
+func (p *OIDCProvider) syncsRoles() bool {
+	return p.cfg.OrgID != uuid.Nil && len(p.cfg.GroupRoles) > 0
+}
+
+// oidcAccountAction — OIDC kimliğinin hangi yerel hesapla eşleşeceği.
+type oidcAccountAction int
+
+const (
+	oidcUseLinked oidcAccountAction = iota
+	oidcLinkExisting
+	oidcCreate
+)
+
+// resolveOIDCAccount — hesap önce (issuer, subject) bağıyla bulunur. Bağ yoksa aynı e-postalı
+// mevcut hesaba yalnızca sağlayıcı adresi doğruladıysa bağlanılır; aksi halde e-postası eşleşen
+// herhangi bir kimlik parola hesabını ele geçirebilirdi. Hesap yoksa yenisi oluşturulur.
+func resolveOIDCAccount(linked, byEmail *User, ident *OIDCIdentity) (oidcAccountAction, error) {
+	switch {
+	case linked != nil:
+		return oidcUseLinked, nil
+	case byEmail == nil:
+		return oidcCreate, nil
+	case !ident.EmailVerified:
+		return 0, ErrOIDCAccountExists
+	}
+	return oidcLinkExisting, nil
+}
+
+// oidcFlowClaims — giriş başlatılırken HttpOnly çerezde taşınan, imzalı akış durumu.
+type oidcFlowClaims struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Redirect string `json:"redirect,omitempty"`
+	jwt.RegisteredClaims
+}
+
+func randomURLToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// newOIDCFlow — state, nonce ve PKCE verifier üretir; çerez değeri olarak imzalı akışı döndürür.
+func (s *Service) newOIDCFlow(redirect string) (*oidcFlowClaims, string, error) {
+	flow := &oidcFlowClaims{Redirect: redirect}
+	for _, dst := range []*string{&flow.State, &flow.Nonce, &flow.Verifier} {
+		v, err := randomURLToken()
+		if err != nil {
+			return nil, "", err
+		}
+		*dst = v
+	}
+	now := time.Now()
+	flow.Subject = "oidc_flow"
+	flow.IssuedAt = jwt.NewNumericDate(now)
+	flow.ExpiresAt = jwt.NewNumericDate(now.Add(oidcFlowTTL))
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, flow).SignedString([]byte(s.jwtSecret))
+	return flow, signed, err
+}
+
+// parseOIDCFlow — çerezdeki akışı doğrular ve callback'teki state ile karşılaştırır.
+func (s *Service) parseOIDCFlow(cookie, state string) (*oidcFlowClaims, error) {
+	flow := &oidcFlowClaims{}
+	_, err := jwt.ParseWithClaims(cookie, flow, func(t *jwt.Token) (interface{}, error) {
+		return []byte(s.jwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithSubject("oidc_flow"))
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(flow.State), []byte(state)) != 1 {
+		return nil, ErrOIDCInvalidFlow
+	}
+	return flow, nil
+}
+
+// LoginOIDC — doğrulanmış kimliği yerel kullanıcıya bağlar. Kullanıcı yoksa kişisel
+// organizasyonuyla birlikte oluşturulur (JIT); grup eşlemesi tanımlıysa OIDC organizasyonundaki
+// rolü kimlik sağlayıcısına göre güncellenir.
+func (s *Service) LoginOIDC(ctx context.Context, p *OIDCProvider, ident *OIDCIdentity) (*User, error) {
+	if err := p.CheckDomain(ident.Email); err != nil {
+		s.auditLogger.Record(ctx, audit.Entry{
+			Action:       audit.ActionLoginFailed,
+			ResourceType: "user",
+			Status:       audit.StatusFailure,
+			Details:      map[string]any{"reason": "domain_not_allowed", "method": "oidc", "email": ident.Email},
+		})
+		return nil, err
+	}
+
+	var user User
+	created := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var link OIDCLink
+		var linked, byEmail *User
+		err := tx.Where("issuer = ? AND subject = ?", ident.Issuer, ident.Subject).First(&link).Error
+		switch {
+		case err == nil:
+			var u User
+			if err := tx.First(&u, "id = ?", link.UserID).Error; err != nil {
+				return err
+			}
+			linked = &u
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			var u User
+			err := tx.Where("LOWER(email) = ?", ident.Email).First(&u).Error
+			if err == nil {
+				byEmail = &u
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		default:
+			return err
+		}
+
+		action, err := resolveOIDCAccount(linked, byEmail, ident)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		switch action {
+		case oidcUseLinked:
+			user = *linked
+			if err := tx.Model(&OIDCLink{}).Where("id = ?", link.ID).
+				Updates(map[string]interface{}{"email": ident.Email, "last_login_at": now}).Error; err != nil {
+				return err
+			}
+		case oidcLinkExisting:
+			user = *byEmail
+		case oidcCreate:
+			// SSO kullanıcısının parolası yoktur; rastgele bir özet parola girişini imkânsız kılar.
+			secret, err := randomURLToken()
+			if err != nil {
+				return err
+			}
+			hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+			if err != nil {
+				return err
+			}
+			user = User{Email: ident.Email, PasswordHash: string(hash)}
+			if ident.EmailVerified {
+				user.EmailVerifiedAt = &now
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			if _, err := orgs.CreatePersonal(tx, user.ID, user.Email); err != nil {
+				return err
+			}
+			created = true
+		}
+		if action != oidcUseLinked {
+			if err := tx.Create(&OIDCLink{
+				UserID:  user.ID,
+				Issuer:  ident.Issuer,
+				Subject: ident.Subject,
+				Email:   ident.Email,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		// Sağlayıcının doğruladığı adres hesabın adresiyse hesap da doğrulanmış sayılır.
+		if ident.EmailVerified && user.EmailVerifiedAt == nil && strings.EqualFold(user.Email, ident.Email) {
+			if err := markEmailVerified(tx, user.ID); err != nil {
+				return err
+			}
+			user.EmailVerifiedAt = &now
+		}
+
+		if p.syncsRoles() {
+			return orgs.SyncMembership(tx, p.cfg.OrgID, user.ID, p.RoleForGroups(ident.Groups))
+		}
+		return nil
+	})
+	if errors.Is(err, ErrOIDCAccountExists) {
+		s.auditLogger.Record(ctx, audit.Entry{
+			Action:       audit.ActionLoginFailed,
+			ResourceType: "user",
+			Status:       audit.StatusFailure,
+			Details: map[string]any{
+				"reason": "unverified_email_link", "method": "oidc",
+				"email": ident.Email, "issuer": ident.Issuer, "subject": ident.Subject,
+			},
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	details := map[string]any{"method": "oidc", "issuer": ident.Issuer, "subject": ident.Subject}
+	if created {
+		s.auditLogger.Record(ctx, audit.Entry{
+			UserID:       &user.ID,
+			Action:       audit.ActionRegister,
+			ResourceType: "user",
+			ResourceID:   &user.ID,
+			Status:       audit.StatusSuccess,
+			Details:      details,
+		})
+	}
+	s.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &user.ID,
+		Action:       audit.ActionLogin,
+		ResourceType: "user",
+		ResourceID:   &user.ID,
+		Status:       audit.StatusSuccess,
+		Details:      details,
+	})
+	return &user, nil
+}
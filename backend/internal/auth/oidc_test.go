@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockOIDC — discovery, JWKS, yetkilendirme ve token uç noktalarını sunan yerel OIDC sağlayıcısı.
+type mockOIDC struct {
+	t      *testing.T
+	srv    *httptest.Server
+	key    *rsa.PrivateKey
+	claims jwt.MapClaims
+
+	mu    sync.Mutex
+	codes map[string]url.Values // kod → yetkilendirme isteğinin parametreleri
+}
+
+func newMockOIDC(t *testing.T) *mockOIDC {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	m := &mockOIDC{t: t, key: key, codes: map[string]url.Values{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.srv.URL,
+			"authorization_endpoint": m.srv.URL + "/authorize",
+			"token_endpoint":         m.srv.URL + "/token",
+			"jwks_uri":               m.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "use": "sig", "kid": "k1", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		m.codes["code-1"] = r.URL.Query()
+		m.mu.Unlock()
+		redirect := r.URL.Query().Get("redirect_uri") + "?code=code-1&state=" + r.URL.Query().Get("state")
+		http.Redirect(w, r, redirect, http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		m.mu.Lock()
+		auth, ok := m.codes[r.PostForm.Get("code")]
+		delete(m.codes, r.PostForm.Get("code"))
+		m.mu.Unlock()
+		if !ok || pkceChallenge(r.PostForm.Get("code_verifier")) != auth.Get("code_challenge") {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		claims := jwt.MapClaims{
+			"iss":   m.srv.URL,
+			"aud":   "nanonet",
+			"sub":   "user-1",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"iat":   time.Now().Unix(),
+			"nonce": auth.Get("nonce"),
+		}
+		for k, v := range m.claims {
+			claims[k] = v
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": m.sign(claims), "token_type": "Bearer"})
+	})
+	m.srv = httptest.NewServer(mux)
+	t.Cleanup(m.srv.Close)
+	return m
+}
+
+func (m *mockOIDC) sign(claims jwt.MapClaims) string {
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = "k1"
+	signed, err := tok.SignedString(m.key)
+	require.NoError(m.t, err)
+	return signed
+}
+
+// login — yetkilendirme adresini izleyip callback'e dönen kodu alır.
+func (m *mockOIDC) login(t *testing.T, p *OIDCProvider, nonce, verifier string) string {
+	authURL, err := p.AuthCodeURL(context.Background(), "st", nonce, verifier)
+	require.NoError(t, err)
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "st", loc.Query().Get("state"))
+	return loc.Query().Get("code")
+}
+
+func newTestProvider(m *mockOIDC) *OIDCProvider {
+	return NewOIDCProvider(OIDCConfig{
+		Issuer:         m.srv.URL,
+		ClientID:       "nanonet",
+		RedirectURL:    "http://localhost:8080/api/v1/auth/oidc/callback",
+		AllowedDomains: []string{"example.com"},
+		GroupRoles:     map[string]string{"sre": "operator", "platform": "admin"},
+	})
+}
+
+func TestOIDC_CodeFlowWithPKCE(t *testing.T) {
+	m := newMockOIDC(t)
+	m.claims = jwt.MapClaims{"email": "Ada@Example.com", "email_verified": true, "groups": []string{"sre", "platform", "other"}}
+	p := newTestProvider(m)
+	ctx := context.Background()
+
+	code := m.login(t, p, "n1", "verifier-1")
+	_, err := p.Exchange(ctx, code, "wrong-verifier")
+	assert.Error(t, err, "PKCE verifier uyuşmazsa kod değiştirilemez")
+
+	code = m.login(t, p, "n1", "verifier-1")
+	idToken, err := p.Exchange(ctx, code, "verifier-1")
+	require.NoError(t, err)
+
+	ident, err := p.VerifyIDToken(ctx, idToken, "n1")
+	require.NoError(t, err)
+	assert.Equal(t, "ada@example.com", ident.Email)
+	assert.NoError(t, p.CheckDomain(ident.Email))
+	assert.Equal(t, "admin", p.RoleForGroups(ident.Groups))
+
+	_, err = p.VerifyIDToken(ctx, idToken, "other-nonce")
+	assert.ErrorIs(t, err, ErrOIDCInvalidToken)
+}
+
+func TestOIDC_VerifyRejects(t *testing.T) {
+	m := newMockOIDC(t)
+	p := newTestProvider(m)
+	ctx := context.Background()
+	base := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": m.srv.URL, "aud": "nanonet", "sub": "u", "nonce": "n",
+			"email": "a@example.com", "exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	ident, err := p.VerifyIDToken(ctx, m.sign(base()), "n")
+	require.NoError(t, err)
+	assert.False(t, ident.EmailVerified, "email_verified yoksa adres doğrulanmamış sayılır")
+	assert.Equal(t, m.srv.URL, ident.Issuer)
+
+	for name, mutate := range map[string]func(jwt.MapClaims){
+		"yanlış audience": func(c jwt.MapClaims) { c["aud"] = "başka" },
+		"yanlış issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example" },
+		"süresi dolmuş":   func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"e-posta yok":     func(c jwt.MapClaims) { delete(c, "email") },
+		"doğrulanmamış":   func(c jwt.MapClaims) { c["email_verified"] = false },
+	} {
+		c := base()
+		mutate(c)
+		_, err := p.VerifyIDToken(ctx, m.sign(c), "n")
+		assert.Error(t, err, name)
+	}
+
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, base())
+	forged, err := hs.SignedString([]byte("secret"))
+	require.NoError(t, err)
+	_, err = p.VerifyIDToken(ctx, forged, "n")
+	assert.ErrorIs(t, err, ErrOIDCInvalidToken, "HS256 ile imzalanmış token kabul edilmez")
+
+	assert.ErrorIs(t, p.CheckDomain("a@evil.com"), ErrOIDCDomain)
+}
+
+func TestResolveOIDCAccount(t *testing.T) {
+	existing := &User{Email: "ada@example.com"}
+	unverified := &OIDCIdentity{Issuer: "https://idp", Subject: "s1", Email: "ada@example.com"}
+	verified := &OIDCIdentity{Issuer: "https://idp", Subject: "s1", Email: "ada@example.com", EmailVerified: true}
+
+	_, err := resolveOIDCAccount(nil, existing, unverified)
+	assert.ErrorIs(t, err, ErrOIDCAccountExists, "doğrulanmamış adresle mevcut parola hesabına bağlanılmaz")
+
+	action, err := resolveOIDCAccount(nil, existing, verified)
+	require.NoError(t, err)
+	assert.Equal(t, oidcLinkExisting, action)
+
+	action, err = resolveOIDCAccount(existing, nil, unverified)
+	require.NoError(t, err)
+	assert.Equal(t, oidcUseLinked, action, "bağlı kimlik e-posta doğrulamasından bağımsız giriş yapar")
+
+	action, err = resolveOIDCAccount(nil, nil, unverified)
+	require.NoError(t, err)
+	assert.Equal(t, oidcCreate, action)
+}
+
+func TestOIDCFlowCookie(t *testing.T) {
+	s := &Service{jwtSecret: "test-secret-key-minimum-32-chars-x!"}
+	flow, cookie, err := s.newOIDCFlow("/services")
+	require.NoError(t, err)
+
+	got, err := s.parseOIDCFlow(cookie, flow.State)
+	require.NoError(t, err)
+	assert.Equal(t, flow.Verifier, got.Verifier)
+	assert.Equal(t, "/services", got.Redirect)
+
+	_, err = s.parseOIDCFlow(cookie, "başka-state")
+	assert.ErrorIs(t, err, ErrOIDCInvalidFlow)
+	_, err = (&Service{jwtSecret: "another-secret-key-minimum-32-chars"}).parseOIDCFlow(cookie, flow.State)
+	assert.ErrorIs(t, err, ErrOIDCInvalidFlow)
+}
+
+func TestParseGroupRoles(t *testing.T) {
+	roles, err := ParseGroupRoles([]string{"sre=operator", " platform = admin "})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"sre": "operator", "platform": "admin"}, roles)
+
+	_, err = ParseGroupRoles([]string{"founders=owner"})
+	assert.Error(t, err, "sahiplik gruptan verilemez")
+	_, err = ParseGroupRoles([]string{"sre"})
+	assert.Error(t, err)
+}
+
+func TestSafeRedirect(t *testing.T) {
+	assert.Equal(t, "/services/1", safeRedirect("/services/1"))
+	assert.Equal(t, "/", safeRedirect("https://evil.example"))
+	assert.Equal(t, "/", safeRedirect("//evil.example"))
+	assert.Equal(t, "/", safeRedirect("/\\evil.example"))
+}
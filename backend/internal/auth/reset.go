@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
@@ -9,6 +10,7 @@ import (
 	"log"
 	"time"
 
+	"nanonet-backend/pkg/audit"
 	"nanonet-backend/pkg/mailer"
 
 	"github.com/google/uuid"
@@ -102,7 +104,7 @@ func (s *Service) ResetPassword(plainToken, newPassword string) error {
 		return err
 	}
 
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Model(&User{}).
 			Where("id = ?", prt.UserID).
 			Update("password_hash", string(hash)).Error; err != nil {
@@ -110,5 +112,25 @@ func (s *Service) ResetPassword(plainToken, newPassword string) error {
 		}
 		now := time.Now()
 		return tx.Model(&prt).Update("used_at", now).Error
+	}); err != nil {
+		return err
+	}
+
+	// Şifre sıfırlanınca mevcut oturumlar kapanır ve hesap kilidi kalkar
+	ctx := context.Background()
+	if _, err := s.RevokeAllSessions(ctx, prt.UserID, uuid.Nil, RevokePasswordChanged); err != nil {
+		log.Printf("[auth] oturumlar kapatılamadı (%s): %v", prt.UserID, err)
+	}
+	if user, err := s.GetUserByID(prt.UserID); err == nil {
+		s.clearLockout(ctx, user.Email)
+	}
+	s.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &prt.UserID,
+		Action:       audit.ActionPasswordReset,
+		ResourceType: "user",
+		ResourceID:   &prt.UserID,
+		Status:       audit.StatusSuccess,
+		Details:      map[string]any{"method": "email"},
 	})
+	return nil
 }
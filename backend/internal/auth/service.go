@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	"nanonet-backend/internal/orgs"
 	"nanonet-backend/pkg/audit"
+	"nanonet-backend/pkg/tokenblacklist"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -18,6 +21,13 @@ type Service struct {
 	db          *gorm.DB
 	jwtSecret   string
 	auditLogger *audit.Logger
+	// blacklist — tüketilen eski refresh token'ları ve iptal edilen oturumları tutar.
+	blacklist tokenblacklist.Blacklist
+	// guard — hesap başına başarısız giriş kilidi; nil ise yalnızca IP rate limit uygulanır.
+	guard *loginGuard
+	// requireVerification — yeni hesaplar e-posta doğrulanana kadar kısıtlı kalır.
+	requireVerification bool
+	verifiedUsers       sync.Map
 }
 
 func NewService(db *gorm.DB, jwtSecret string) *Service {
@@ -25,32 +35,68 @@ func NewService(db *gorm.DB, jwtSecret string) *Service {
 		db:          db,
 		jwtSecret:   jwtSecret,
 		auditLogger: audit.New(db),
+		blacklist:   tokenblacklist.Default,
 	}
 }
 
-func (s *Service) Register(email, password string) (*User, error) {
+// SetBlacklist — oturum iptallerinin yazılacağı kara listeyi ayarlar; middleware ile aynı
+// kara liste kullanılmalıdır.
+func (s *Service) SetBlacklist(bl tokenblacklist.Blacklist) {
+	s.blacklist = bl
+}
+
+// Register — hesabı kişisel organizasyonuyla oluşturur. invitationToken doluysa davet aynı
+// transaction'da kabul edilir ve davet bu adrese gönderildiği için hesap doğrulanmış açılır.
+func (s *Service) Register(ctx context.Context, email, password, invitationToken string) (*User, *orgs.Invitation, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), 12)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	user := &User{
 		Email:        email,
 		PasswordHash: string(hash),
 	}
+	if !s.requireVerification || invitationToken != "" {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
 
-	if err := s.db.Create(user).Error; err != nil {
-		return nil, err
+	// Her kullanıcının servisleri için kişisel bir organizasyonu olur.
+	var inv *orgs.Invitation
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		if _, err := orgs.CreatePersonal(tx, user.ID, email); err != nil {
+			return err
+		}
+		if invitationToken != "" {
+			inv, err = orgs.AcceptInvitation(tx, invitationToken, user.ID, email)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return user, nil
+	if inv != nil {
+		s.recordInvitationAccepted(ctx, user, inv)
+	}
+	return user, inv, nil
 }
 
 func (s *Service) Login(email, password string) (*User, error) {
+	ctx := context.Background()
+	if err := s.checkLockout(ctx, email); err != nil {
+		return nil, err
+	}
+
 	var user User
 	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			s.auditLogger.Record(context.Background(), audit.Entry{
+			s.recordLoginFailure(ctx, email, nil)
+			s.auditLogger.Record(ctx, audit.Entry{
 				Action:       audit.ActionLoginFailed,
 				ResourceType: "user",
 				Status:       audit.StatusFailure,
@@ -62,7 +108,8 @@ func (s *Service) Login(email, password string) (*User, error) {
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		s.auditLogger.Record(context.Background(), audit.Entry{
+		s.recordLoginFailure(ctx, email, &user)
+		s.auditLogger.Record(ctx, audit.Entry{
 			UserID:       &user.ID,
 			Action:       audit.ActionLoginFailed,
 			ResourceType: "user",
@@ -73,25 +120,11 @@ func (s *Service) Login(email, password string) (*User, error) {
 		return nil, errors.New("geçersiz email veya şifre")
 	}
 
-	return &user, nil
-}
-
-func (s *Service) GenerateTokens(userID uuid.UUID) (*TokenResponse, error) {
-	accessToken, err := s.generateToken(userID, 24*time.Hour, "access")
-	if err != nil {
-		return nil, err
+	// İki adımlı doğrulamada sayaç ikinci adım tamamlanınca sıfırlanır.
+	if user.TOTPEnabledAt == nil {
+		s.clearLockout(ctx, email)
 	}
-
-	refreshToken, err := s.generateToken(userID, 30*24*time.Hour, "refresh")
-	if err != nil {
-		return nil, err
-	}
-
-	return &TokenResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    int64((24 * time.Hour).Seconds()),
-	}, nil
+	return &user, nil
 }
 
 func (s *Service) generateToken(userID uuid.UUID, duration time.Duration, tokenType string) (string, error) {
@@ -106,44 +139,11 @@ func (s *Service) generateToken(userID uuid.UUID, duration time.Duration, tokenT
 	return token.SignedString([]byte(s.jwtSecret))
 }
 
-// ValidateRefreshToken refresh token'ını doğrular; access token ile kullanılamaz.
 // GenerateAgentToken uzun ömürlü (1 yıl) agent token'ı üretir.
 func (s *Service) GenerateAgentToken(userID uuid.UUID) (string, error) {
 	return s.generateToken(userID, 3650*24*time.Hour, "agent")
 }
 
-// ValidateRefreshToken validates a refresh token and returns the user ID and token expiry time.
-func (s *Service) ValidateRefreshToken(tokenString string) (uuid.UUID, time.Time, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
-			return nil, fmt.Errorf("beklenmeyen imza algoritması: %v", token.Header["alg"])
-		}
-		return []byte(s.jwtSecret), nil
-	})
-
-	if err != nil {
-		return uuid.Nil, time.Time{}, err
-	}
-
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		if typ, _ := claims["typ"].(string); typ != "refresh" {
-			return uuid.Nil, time.Time{}, errors.New("geçersiz token tipi: refresh token gerekli")
-		}
-		userIDStr, ok := claims["sub"].(string)
-		if !ok {
-			return uuid.Nil, time.Time{}, errors.New("geçersiz token payload")
-		}
-		var expiry time.Time
-		if exp, ok := claims["exp"].(float64); ok {
-			expiry = time.Unix(int64(exp), 0)
-		}
-		userID, err := uuid.Parse(userIDStr)
-		return userID, expiry, err
-	}
-
-	return uuid.Nil, time.Time{}, errors.New("geçersiz token")
-}
-
 func (s *Service) GetUserByID(userID uuid.UUID) (*User, error) {
 	var user User
 	if err := s.db.Where("id = ?", userID).First(&user).Error; err != nil {
@@ -157,6 +157,13 @@ func (s *Service) UpdatePasswordHash(userID uuid.UUID, hash string) error {
 }
 
 func (s *Service) ValidateToken(tokenString string) (uuid.UUID, error) {
+	userID, _, err := s.validateUserToken(tokenString)
+	return userID, err
+}
+
+// validateUserToken — access token'ı doğrular; kullanıcıyı ve (varsa) oturum ID'sini döndürür. Oturumlardan
+// önce üretilmiş token'larda oturum ID'si uuid.Nil'dir.
+func (s *Service) validateUserToken(tokenString string) (uuid.UUID, uuid.UUID, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
 			return nil, fmt.Errorf("beklenmeyen imza algoritması: %v", token.Header["alg"])
@@ -165,16 +172,35 @@ func (s *Service) ValidateToken(tokenString string) (uuid.UUID, error) {
 	})
 
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, uuid.Nil, err
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		// Yalnızca access token API erişimi verir. Challenge token'ı yalnızca parolanın doğrulandığını
+		// gösterir; refresh token'lar 30 gün yaşar ve yalnızca /auth/refresh'te kullanılabilir.
+		switch typ, _ := claims["typ"].(string); typ {
+		case "access":
+		case TwoFactorChallengeType:
+			return uuid.Nil, uuid.Nil, errors.New("geçersiz token tipi: iki adımlı doğrulama tamamlanmadı")
+		default:
+			return uuid.Nil, uuid.Nil, errors.New("geçersiz token tipi: access token gerekli")
+		}
 		userIDStr, ok := claims["sub"].(string)
 		if !ok {
-			return uuid.Nil, errors.New("geçersiz token payload")
+			return uuid.Nil, uuid.Nil, errors.New("geçersiz token payload")
+		}
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			return uuid.Nil, uuid.Nil, err
+		}
+		var sessionID uuid.UUID
+		if sid, ok := claims["sid"].(string); ok {
+			if sessionID, err = uuid.Parse(sid); err != nil {
+				return uuid.Nil, uuid.Nil, errors.New("geçersiz token payload")
+			}
 		}
-		return uuid.Parse(userIDStr)
+		return userID, sessionID, nil
 	}
 
-	return uuid.Nil, errors.New("geçersiz token")
+	return uuid.Nil, uuid.Nil, errors.New("geçersiz token")
 }
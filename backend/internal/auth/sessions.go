@@ -0,0 +1,381 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"nanonet-backend/pkg/audit"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	accessTokenTTL  = 24 * time.Hour
+	refreshTokenTTL = 30 * 24 * time.Hour
+	// refreshReuseGrace — aynı refresh token'ın eşzamanlı iki istekte (ör. iki sekme) kullanılması
+	// bu süre içinde saldırı sayılmaz; ikinci istek oturumu iptal etmeden reddedilir.
+	refreshReuseGrace = 30 * time.Second
+	// sessionRetention — iptal edilmiş veya süresi dolmuş oturumların saklanma süresi.
+	sessionRetention = 30 * 24 * time.Hour
+)
+
+// Oturum iptal nedenleri.
+const (
+	RevokeLogout          = "logout"
+	RevokeUser            = "revoked"
+	RevokeLogoutAll       = "logout_all"
+	RevokePasswordChanged = "password_changed"
+	RevokeRefreshReuse    = "refresh_reuse"
+)
+
+var (
+	ErrSessionNotFound     = errors.New("oturum bulunamadı")
+	ErrInvalidRefreshToken = errors.New("geçersiz refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token tekrar kullanıldı, oturum güvenlik nedeniyle sonlandırıldı")
+	ErrRefreshRaced        = errors.New("refresh token zaten yenilendi")
+)
+
+// Session — bir girişle açılan ve refresh token'larıyla sürdürülen oturum. Refresh token her
+// kullanımda yenilenir; oturum, token ailesinin tamamını temsil eder.
+type Session struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null" json:"-"`
+	RefreshJTI    string     `gorm:"column:refresh_jti;type:varchar(64);not null" json:"-"`
+	PreviousJTI   *string    `gorm:"column:previous_jti;type:varchar(64)" json:"-"`
+	RotatedAt     *time.Time `json:"-"`
+	Device        string     `gorm:"type:varchar(100);not null" json:"device"`
+	IP            string     `gorm:"type:varchar(45);not null" json:"ip"`
+	UserAgent     string     `gorm:"type:varchar(512);not null" json:"user_agent"`
+	CreatedAt     time.Time  `gorm:"not null;default:now()" json:"created_at"`
+	LastUsedAt    time.Time  `gorm:"not null;default:now()" json:"last_used_at"`
+	ExpiresAt     time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason *string    `gorm:"type:varchar(50)" json:"revoked_reason,omitempty"`
+
+	// Current — istek bu oturumun access token'ıyla yapıldıysa true.
+	Current bool `gorm:"-" json:"current"`
+}
+
+func (Session) TableName() string { return "sessions" }
+
+// SessionMeta — oturumu açan veya yenileyen isteğin istemci bilgileri.
+type SessionMeta struct {
+	IP        string
+	UserAgent string
+}
+
+// sessionRevokedKey — iptal edilen oturumun token'larını geçersiz kılmak için kara listeye yazılan
+// anahtar. Oturumun en uzun yaşayan token'ı refresh token olduğundan anahtar refreshTokenTTL tutulur.
+func sessionRevokedKey(sessionID string) string {
+	return "session:" + sessionID
+}
+
+// deviceLabel — User-Agent'tan "Chrome · macOS" biçiminde kısa bir cihaz etiketi üretir.
+func deviceLabel(ua string) string {
+	if ua == "" {
+		return "Bilinmeyen cihaz"
+	}
+	lower := strings.ToLower(ua)
+	first := func(pairs [][2]string) string {
+		for _, p := range pairs {
+			if strings.Contains(lower, p[0]) {
+				return p[1]
+			}
+		}
+		return ""
+	}
+	// Sıra önemli: Edge ve Opera "chrome", Chrome da "safari" içerir; Android "linux" içerir.
+	browser := first([][2]string{
+		{"edg/", "Edge"}, {"opr/", "Opera"}, {"firefox/", "Firefox"}, {"chrome/", "Chrome"},
+		{"safari/", "Safari"}, {"curl/", "curl"}, {"python-requests", "Python"}, {"go-http-client", "Go"},
+		{"postman", "Postman"},
+	})
+	platform := first([][2]string{
+		{"android", "Android"}, {"iphone", "iOS"}, {"ipad", "iPadOS"}, {"windows", "Windows"},
+		{"mac os", "macOS"}, {"cros", "ChromeOS"}, {"linux", "Linux"},
+	})
+	switch {
+	case browser != "" && platform != "":
+		return browser + " · " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	}
+	return "Bilinmeyen cihaz"
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
+
+// generateSessionToken — oturuma bağlı access veya refresh token'ı üretir. Refresh token'lar
+// rotasyonu izlemek için jti taşır.
+func (s *Service) generateSessionToken(userID, sessionID uuid.UUID, jti string, duration time.Duration, tokenType string) (string, error) {
+	claims := jwt.MapClaims{
+		"sub": userID.String(),
+		"sid": sessionID.String(),
+		"exp": time.Now().Add(duration).Unix(),
+		"iat": time.Now().Unix(),
+		"typ": tokenType,
+	}
+	if jti != "" {
+		claims["jti"] = jti
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.jwtSecret))
+}
+
+func (s *Service) issueTokens(userID, sessionID uuid.UUID, jti string) (*TokenResponse, error) {
+	accessToken, err := s.generateSessionToken(userID, sessionID, "", accessTokenTTL, "access")
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := s.generateSessionToken(userID, sessionID, jti, refreshTokenTTL, "refresh")
+	if err != nil {
+		return nil, err
+	}
+	return &TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(accessTokenTTL.Seconds()),
+	}, nil
+}
+
+// StartSession — başarılı girişten sonra yeni bir oturum açar ve token'larını üretir.
+func (s *Service) StartSession(ctx context.Context, userID uuid.UUID, meta SessionMeta) (*TokenResponse, error) {
+	// Kullanıcının uzun süre önce biten oturumlarını temizle; listede yalnızca yakın geçmiş kalır.
+	cutoff := time.Now().Add(-sessionRetention)
+	if err := s.db.WithContext(ctx).
+		Where("user_id = ? AND (expires_at < ? OR revoked_at < ?)", userID, cutoff, cutoff).
+		Delete(&Session{}).Error; err != nil {
+		log.Printf("[auth] eski oturumlar silinemedi (%s): %v", userID, err)
+	}
+
+	now := time.Now()
+	sess := &Session{
+		UserID:     userID,
+		RefreshJTI: uuid.NewString(),
+		Device:     truncate(deviceLabel(meta.UserAgent), 100),
+		IP:         truncate(meta.IP, 45),
+		UserAgent:  truncate(meta.UserAgent, 512),
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(refreshTokenTTL),
+	}
+	if err := s.db.WithContext(ctx).Create(sess).Error; err != nil {
+		return nil, err
+	}
+	return s.issueTokens(userID, sess.ID, sess.RefreshJTI)
+}
+
+// refreshClaims — doğrulanmış refresh token'ın içeriği. SessionID, oturumlardan önce üretilmiş
+// token'larda uuid.Nil'dir.
+type refreshClaims struct {
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+	JTI       string
+	ExpiresAt time.Time
+}
+
+func (s *Service) parseRefreshToken(tokenString string) (*refreshClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(s.jwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if typ, _ := claims["typ"].(string); typ != "refresh" {
+		return nil, ErrInvalidRefreshToken
+	}
+	sub, _ := claims["sub"].(string)
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	rc := &refreshClaims{UserID: userID}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		rc.ExpiresAt = exp.Time
+	}
+	if sid, ok := claims["sid"].(string); ok {
+		if rc.SessionID, err = uuid.Parse(sid); err != nil {
+			return nil, ErrInvalidRefreshToken
+		}
+		rc.JTI, _ = claims["jti"].(string)
+		if rc.JTI == "" {
+			return nil, ErrInvalidRefreshToken
+		}
+	}
+	return rc, nil
+}
+
+// RefreshSession — refresh token'ı yeniler. Oturumun geçerli token'ı değilse token ailesi
+// çalınmış kabul edilir ve oturum iptal edilir (reuse detection). Oturumlardan önce üretilmiş
+// token'lar kara listeye alınarak tüketilir ve yerine yeni bir oturum açılır.
+func (s *Service) RefreshSession(ctx context.Context, tokenString string, meta SessionMeta) (*TokenResponse, error) {
+	rc, err := s.parseRefreshToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if rc.SessionID == uuid.Nil {
+		if s.blacklist.IsBlacklisted(ctx, tokenString) {
+			return nil, ErrInvalidRefreshToken
+		}
+		if ttl := time.Until(rc.ExpiresAt); ttl > 0 {
+			_ = s.blacklist.Add(ctx, tokenString, ttl)
+		}
+		return s.StartSession(ctx, rc.UserID, meta)
+	}
+
+	var sess Session
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", rc.SessionID, rc.UserID).
+		First(&sess).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if sess.RevokedAt != nil || now.After(sess.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	newJTI := uuid.NewString()
+	res := s.db.WithContext(ctx).Model(&Session{}).
+		Where("id = ? AND refresh_jti = ? AND revoked_at IS NULL", sess.ID, rc.JTI).
+		Updates(map[string]interface{}{
+			"refresh_jti":  newJTI,
+			"previous_jti": rc.JTI,
+			"rotated_at":   now,
+			"last_used_at": now,
+			"expires_at":   now.Add(refreshTokenTTL),
+			"ip":           truncate(meta.IP, 45),
+			"user_agent":   truncate(meta.UserAgent, 512),
+		})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return s.issueTokens(rc.UserID, sess.ID, newJTI)
+	}
+
+	// Token geçerli değil: ya eşzamanlı bir istek az önce yeniledi ya da eski bir token tekrar
+	// kullanılıyor. Güncel satırı okuyup hangisi olduğuna karar ver.
+	if err := s.db.WithContext(ctx).First(&sess, "id = ?", sess.ID).Error; err != nil {
+		return nil, err
+	}
+	if sess.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if sess.PreviousJTI != nil && *sess.PreviousJTI == rc.JTI &&
+		sess.RotatedAt != nil && now.Sub(*sess.RotatedAt) < refreshReuseGrace {
+		return nil, ErrRefreshRaced
+	}
+
+	if err := s.revokeSessions(ctx, rc.UserID, []uuid.UUID{sess.ID}, RevokeRefreshReuse); err != nil {
+		return nil, err
+	}
+	s.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &rc.UserID,
+		Action:       audit.ActionRefreshTokenReuse,
+		ResourceType: "session",
+		ResourceID:   &sess.ID,
+		Status:       audit.StatusBlocked,
+		IPAddress:    meta.IP,
+		Details:      map[string]any{"device": sess.Device, "ip": meta.IP, "user_agent": truncate(meta.UserAgent, 200)},
+	})
+	return nil, ErrRefreshTokenReused
+}
+
+// ListSessions — kullanıcının etkin oturumları, en son kullanılandan eskiye.
+func (s *Service) ListSessions(ctx context.Context, userID uuid.UUID) ([]Session, error) {
+	var sessions []Session
+	err := s.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > NOW()", userID).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// RevokeSession — kullanıcının tek bir oturumunu sonlandırır.
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID, reason string) error {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrSessionNotFound
+	}
+	if err := s.revokeSessions(ctx, userID, []uuid.UUID{sessionID}, reason); err != nil {
+		return err
+	}
+	if reason != RevokeLogout {
+		s.auditLogger.Record(ctx, audit.Entry{
+			UserID:       &userID,
+			Action:       audit.ActionSessionRevoke,
+			ResourceType: "session",
+			ResourceID:   &sessionID,
+			Status:       audit.StatusSuccess,
+			Details:      map[string]any{"reason": reason},
+		})
+	}
+	return nil
+}
+
+// RevokeAllSessions — kullanıcının tüm oturumlarını sonlandırır ("her yerden çıkış"). except
+// uuid.Nil değilse o oturum açık kalır. Sonlandırılan oturum sayısını döndürür.
+func (s *Service) RevokeAllSessions(ctx context.Context, userID, except uuid.UUID, reason string) (int, error) {
+	var ids []uuid.UUID
+	q := s.db.WithContext(ctx).Model(&Session{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > NOW()", userID)
+	if except != uuid.Nil {
+		q = q.Where("id <> ?", except)
+	}
+	if err := q.Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if err := s.revokeSessions(ctx, userID, ids, reason); err != nil {
+		return 0, err
+	}
+	s.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &userID,
+		Action:       audit.ActionSessionRevokeAll,
+		ResourceType: "user",
+		ResourceID:   &userID,
+		Status:       audit.StatusSuccess,
+		Details:      map[string]any{"reason": reason, "count": len(ids)},
+	})
+	return len(ids), nil
+}
+
+// revokeSessions — oturumları iptal eder ve access token'larını kara listeye alır.
+func (s *Service) revokeSessions(ctx context.Context, userID uuid.UUID, ids []uuid.UUID, reason string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.db.WithContext(ctx).Model(&Session{}).
+		Where("user_id = ? AND id IN ? AND revoked_at IS NULL", userID, ids).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason}).Error; err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := s.blacklist.Add(ctx, sessionRevokedKey(id.String()), refreshTokenTTL); err != nil {
+			return fmt.Errorf("oturum kara listeye alınamadı: %w", err)
+		}
+	}
+	return nil
+}
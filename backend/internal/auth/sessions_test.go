@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nanonet-backend/pkg/tokenblacklist"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceLabel(t *testing.T) {
+	for ua, want := range map[string]string{
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36":         "Chrome · macOS",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36 Edg/120.0":     "Edge · Windows",
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Mobile Safari/537.36":         "Chrome · Android",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/604.1": "Safari · iOS",
+		"curl/8.4.0": "curl",
+		"":           "Bilinmeyen cihaz",
+	} {
+		assert.Equal(t, want, deviceLabel(ua), ua)
+	}
+}
+
+func TestParseRefreshToken(t *testing.T) {
+	s := &Service{jwtSecret: "test-secret-key-minimum-32-chars-x!"}
+	userID, sessionID := uuid.New(), uuid.New()
+
+	tokens, err := s.issueTokens(userID, sessionID, "jti-1")
+	require.NoError(t, err)
+
+	rc, err := s.parseRefreshToken(tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, userID, rc.UserID)
+	assert.Equal(t, sessionID, rc.SessionID)
+	assert.Equal(t, "jti-1", rc.JTI)
+
+	_, err = s.parseRefreshToken(tokens.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken, "access token refresh yerine kullanılamaz")
+
+	legacy, err := s.generateToken(userID, time.Hour, "refresh")
+	require.NoError(t, err)
+	rc, err = s.parseRefreshToken(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Nil, rc.SessionID, "oturumdan önceki token'lar oturumsuz ayrıştırılır")
+}
+
+func TestMiddleware_RejectsRevokedSession(t *testing.T) {
+	const secret = "test-secret-key-minimum-32-chars-x!"
+	bl := tokenblacklist.NewInMemory()
+	m := NewMiddleware(secret, bl)
+	s := &Service{jwtSecret: secret}
+
+	sessionID := uuid.New()
+	tokens, err := s.issueTokens(uuid.New(), sessionID, "jti-1")
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.GET("/me", m.Required(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("session_id"))
+	})
+	call := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := call()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, sessionID.String(), w.Body.String())
+	assert.False(t, m.TokenRevoked(context.Background(), tokens.AccessToken))
+
+	require.NoError(t, bl.Add(context.Background(), sessionRevokedKey(sessionID.String()), time.Minute))
+	assert.Equal(t, http.StatusUnauthorized, call().Code)
+	assert.True(t, m.TokenRevoked(context.Background(), tokens.AccessToken))
+}
@@ -0,0 +1,650 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"nanonet-backend/internal/orgs"
+	"nanonet-backend/pkg/audit"
+	"nanonet-backend/pkg/mailer"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// TwoFactorChallengeType — parola doğrulandıktan sonra verilen, yalnızca POST /auth/login/2fa'da
+// geçerli token'ın tipi.
+const TwoFactorChallengeType = "2fa_challenge"
+
+const (
+	// RFC 6238 varsayılanları; yaygın doğrulayıcı uygulamaları yalnızca bunları destekler.
+	totpDigits = 6
+	totpPeriod = 30
+	// totpSkew — saat kaymasına karşı önceki/sonraki kaç adımın kabul edileceği.
+	totpSkew   = 1
+	totpIssuer = "NanoNet"
+
+	recoveryCodeCount = 10
+	// recoveryCodeAlphabet — karıştırılabilecek karakterler (0/o, 1/l/i) çıkarılmıştır.
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+	twoFactorChallengeTTL = 5 * time.Minute
+	twoFactorResetTTL     = time.Hour
+)
+
+var (
+	ErrTwoFactorNotEnabled     = errors.New("iki adımlı doğrulama etkin değil")
+	ErrTwoFactorAlreadyEnabled = errors.New("iki adımlı doğrulama zaten etkin")
+	ErrTwoFactorSetupMissing   = errors.New("önce iki adımlı doğrulama kurulumunu başlatın")
+	ErrInvalidTwoFactorCode    = errors.New("geçersiz doğrulama kodu")
+	ErrInvalidChallenge        = errors.New("geçersiz veya süresi dolmuş doğrulama oturumu, lütfen tekrar giriş yapın")
+	ErrInvalidPassword         = errors.New("şifre hatalı")
+	ErrTwoFactorResetDenied    = errors.New("bu kullanıcının iki adımlı doğrulamasını sıfırlama yetkiniz yok")
+)
+
+// RecoveryCode — tek kullanımlık kurtarma kodu; kodun kendisi yalnızca SHA-256 özeti olarak saklanır.
+type RecoveryCode struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null"`
+	CodeHash  string     `gorm:"type:varchar(64);not null"`
+	UsedAt    *time.Time `gorm:"default:null"`
+	CreatedAt time.Time  `gorm:"not null;default:now()"`
+}
+
+func (RecoveryCode) TableName() string { return "recovery_codes" }
+
+// TwoFactorResetToken — e-postayla gönderilen iki adımlı doğrulama sıfırlama bağlantısı.
+type TwoFactorResetToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null"`
+	TokenHash string     `gorm:"type:varchar(64);unique;not null"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time `gorm:"default:null"`
+	CreatedAt time.Time  `gorm:"not null;default:now()"`
+}
+
+func (TwoFactorResetToken) TableName() string { return "two_factor_reset_tokens" }
+
+// TOTPSetup — kurulum yanıtı; otpauth_uri QR kod olarak gösterilir, secret elle girilebilir.
+type TOTPSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TwoFactorStatus — GET /auth/2fa yanıtı.
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+}
+
+// ── TOTP (RFC 6238) ───────────────────────────────────────────────
+
+func generateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b), nil
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+}
+
+// totpCode — verilen zaman adımı için HOTP-SHA1 kodu (RFC 4226 dinamik kesme).
+func totpCode(key []byte, step uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], step)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, bin%1_000_000)
+}
+
+// verifyTOTP — kodu ±totpSkew adım içinde arar. lastStep ve öncesi reddedilir, böylece aynı kod
+// ikinci kez kullanılamaz. Eşleşen adımı döndürür.
+func verifyTOTP(key []byte, code string, now time.Time, lastStep int64) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		if hmac.Equal([]byte(totpCode(key, uint64(step))), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func totpURI(email, secret string) string {
+	q := url.Values{
+		"secret":    {secret},
+		"issuer":    {totpIssuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(totpDigits)},
+		"period":    {fmt.Sprint(totpPeriod)},
+	}
+	label := url.PathEscape(totpIssuer + ":" + email)
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// ── Anahtar saklama ───────────────────────────────────────────────
+
+// totpKey — TOTP anahtarlarını şifreleyen AES-256 anahtarı; JWT secret'tan türetilir.
+func (s *Service) totpKey() []byte {
+	sum := sha256.Sum256([]byte("nanonet/totp/" + s.jwtSecret))
+	return sum[:]
+}
+
+func (s *Service) sealTOTPSecret(secret string) (string, error) {
+	block, err := aes.NewCipher(s.totpKey())
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+func (s *Service) openTOTPSecret(sealed string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(s.totpKey())
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return nil, errors.New("bozuk TOTP anahtarı")
+	}
+	secret, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeTOTPSecret(string(secret))
+}
+
+// ── Kurtarma kodları ──────────────────────────────────────────────
+
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := make([]byte, 0, 11)
+	for i, v := range b {
+		if i == 5 {
+			code = append(code, '-')
+		}
+		code = append(code, recoveryCodeAlphabet[int(v)%len(recoveryCodeAlphabet)])
+	}
+	return string(code), nil
+}
+
+// normalizeRecoveryCode — kullanıcının girdiği kodu büyük/küçük harf, boşluk ve tireden bağımsız
+// hale getirir.
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// replaceRecoveryCodes — kullanıcının eski kodlarını siler ve yenilerini üretir.
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, recoveryCodeCount)
+	rows := make([]RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		rows[i] = RecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(code)}
+	}
+	return codes, tx.Create(&rows).Error
+}
+
+// disableTwoFactor — anahtarı ve kurtarma kodlarını siler.
+func disableTwoFactor(tx *gorm.DB, userID uuid.UUID) error {
+	if err := tx.Model(&User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"totp_secret":     nil,
+		"totp_enabled_at": nil,
+		"totp_last_step":  0,
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error
+}
+
+// ── Kayıt ve yönetim ──────────────────────────────────────────────
+
+// SetupTOTP — yeni bir anahtar üretir ve doğrulanmamış olarak saklar. EnableTOTP geçerli bir kodla
+// çağrılana kadar giriş akışı değişmez.
+func (s *Service) SetupTOTP(ctx context.Context, userID uuid.UUID) (*TOTPSetup, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.sealTOTPSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Model(&User{}).
+		Where("id = ? AND totp_enabled_at IS NULL", userID).
+		Updates(map[string]interface{}{"totp_secret": sealed, "totp_last_step": 0}).Error; err != nil {
+		return nil, err
+	}
+	return &TOTPSetup{Secret: secret, OTPAuthURI: totpURI(user.Email, secret)}, nil
+}
+
+// EnableTOTP — kurulumdaki anahtarla üretilen kodu doğrular, iki adımlı doğrulamayı etkinleştirir
+// ve kurtarma kodlarını döndürür. Kodlar yalnızca bu yanıtta gösterilir.
+func (s *Service) EnableTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == nil {
+		return nil, ErrTwoFactorSetupMissing
+	}
+	key, err := s.openTOTPSecret(*user.TOTPSecret)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := verifyTOTP(key, strings.TrimSpace(code), time.Now(), user.TOTPLastStep)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	var codes []string
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&User{}).
+			Where("id = ? AND totp_enabled_at IS NULL", userID).
+			Updates(map[string]interface{}{"totp_enabled_at": time.Now(), "totp_last_step": step})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrTwoFactorAlreadyEnabled
+		}
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordTwoFactor(ctx, audit.ActionTwoFactorEnabled, userID, userID, nil)
+	return codes, nil
+}
+
+// DisableTOTP — parola ve geçerli bir kod (TOTP veya kurtarma kodu) ile iki adımlı doğrulamayı kapatır.
+func (s *Service) DisableTOTP(ctx context.Context, userID uuid.UUID, password, code string) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.TOTPEnabledAt == nil {
+		return ErrTwoFactorNotEnabled
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return ErrInvalidPassword
+	}
+	if _, err := s.verifySecondFactor(ctx, user, code); err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return disableTwoFactor(tx, userID)
+	}); err != nil {
+		return err
+	}
+
+	s.recordTwoFactor(ctx, audit.ActionTwoFactorDisabled, userID, userID, nil)
+	return nil
+}
+
+// RegenerateRecoveryCodes — geçerli bir kodla eski kurtarma kodlarını geçersiz kılar ve yenilerini üretir.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt == nil {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if _, err := s.verifySecondFactor(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordTwoFactor(ctx, audit.ActionTwoFactorRecoveryCodes, userID, userID, nil)
+	return codes, nil
+}
+
+// TwoFactorStatus — kullanıcının iki adımlı doğrulama durumu ve kalan kurtarma kodu sayısı.
+func (s *Service) TwoFactorStatus(ctx context.Context, userID uuid.UUID) (*TwoFactorStatus, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &TwoFactorStatus{Enabled: user.TOTPEnabledAt != nil, EnabledAt: user.TOTPEnabledAt}
+	if status.Enabled {
+		if err := s.db.WithContext(ctx).Model(&RecoveryCode{}).
+			Where("user_id = ? AND used_at IS NULL", userID).
+			Count(&status.RecoveryCodesRemaining).Error; err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// verifySecondFactor — 6 haneli kodları TOTP, diğerlerini kurtarma kodu olarak doğrular ve
+// kullanılan yöntemi döndürür. Kullanılan TOTP adımı ve kurtarma kodu atomik olarak tüketilir;
+// eşzamanlı iki istek aynı kodu kullanamaz.
+func (s *Service) verifySecondFactor(ctx context.Context, user *User, code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == totpDigits && strings.Trim(code, "0123456789") == "" {
+		if user.TOTPSecret == nil {
+			return "", ErrInvalidTwoFactorCode
+		}
+		key, err := s.openTOTPSecret(*user.TOTPSecret)
+		if err != nil {
+			return "", err
+		}
+		step, ok := verifyTOTP(key, code, time.Now(), user.TOTPLastStep)
+		if !ok {
+			return "", ErrInvalidTwoFactorCode
+		}
+		res := s.db.WithContext(ctx).Model(&User{}).
+			Where("id = ? AND totp_last_step < ?", user.ID, step).
+			Update("totp_last_step", step)
+		if res.Error != nil {
+			return "", res.Error
+		}
+		if res.RowsAffected == 0 {
+			return "", ErrInvalidTwoFactorCode
+		}
+		return "totp", nil
+	}
+
+	res := s.db.WithContext(ctx).Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return "", res.Error
+	}
+	if res.RowsAffected == 0 {
+		return "", ErrInvalidTwoFactorCode
+	}
+	return "recovery_code", nil
+}
+
+// ── Giriş ─────────────────────────────────────────────────────────
+
+// NewTwoFactorChallenge — parolası doğrulanmış kullanıcı için kısa ömürlü challenge token'ı üretir.
+// Bu token ValidateToken tarafından reddedilir; yalnızca CompleteTwoFactorLogin'de geçerlidir.
+func (s *Service) NewTwoFactorChallenge(userID uuid.UUID) (string, error) {
+	return s.generateToken(userID, twoFactorChallengeTTL, TwoFactorChallengeType)
+}
+
+// parseTwoFactorChallenge — challenge token'ını doğrular; kullanıcıyı ve bitiş zamanını döndürür.
+func (s *Service) parseTwoFactorChallenge(challenge string) (uuid.UUID, time.Time, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(challenge, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(s.jwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return uuid.Nil, time.Time{}, ErrInvalidChallenge
+	}
+	if typ, _ := claims["typ"].(string); typ != TwoFactorChallengeType {
+		return uuid.Nil, time.Time{}, ErrInvalidChallenge
+	}
+	sub, _ := claims["sub"].(string)
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		return uuid.Nil, time.Time{}, ErrInvalidChallenge
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return uuid.Nil, time.Time{}, ErrInvalidChallenge
+	}
+	return userID, exp.Time, nil
+}
+
+// CompleteTwoFactorLogin — challenge token'ı ve ikinci adım koduyla girişi tamamlar. Dönen bitiş
+// zamanı, kullanılmış challenge'ın kara listeye alınması içindir.
+func (s *Service) CompleteTwoFactorLogin(ctx context.Context, challenge, code string) (*User, time.Time, error) {
+	userID, expiry, err := s.parseTwoFactorChallenge(challenge)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, time.Time{}, ErrInvalidChallenge
+		}
+		return nil, time.Time{}, err
+	}
+	// Challenge alındıktan sonra 2FA kapatılmış veya sıfırlanmışsa akış baştan başlamalıdır.
+	if user.TOTPEnabledAt == nil {
+		return nil, time.Time{}, ErrInvalidChallenge
+	}
+	if err := s.checkLockout(ctx, user.Email); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	method, err := s.verifySecondFactor(ctx, user, code)
+	if err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			// Yanlış kodlar parola hatalarıyla aynı sayaca yazılır; challenge ile kod denemesi
+			// kilitten kaçamaz.
+			s.recordLoginFailure(ctx, user.Email, user)
+			s.auditLogger.Record(ctx, audit.Entry{
+				UserID:       &user.ID,
+				Action:       audit.ActionLoginFailed,
+				ResourceType: "user",
+				ResourceID:   &user.ID,
+				Status:       audit.StatusFailure,
+				Details:      map[string]any{"reason": "invalid_2fa_code"},
+			})
+		}
+		return nil, time.Time{}, err
+	}
+	s.clearLockout(ctx, user.Email)
+	if method == "recovery_code" {
+		log.Printf("[auth] %s kurtarma koduyla giriş yaptı", user.ID)
+	}
+	return user, expiry, nil
+}
+
+// ── Sıfırlama ─────────────────────────────────────────────────────
+
+// RequestTwoFactorReset — doğrulayıcısını kaybeden kullanıcıya sıfırlama bağlantısı gönderir.
+// Email enumeration'ı önlemek için kullanıcı yoksa veya 2FA kapalıysa da nil döner.
+func (s *Service) RequestTwoFactorReset(email string, m *mailer.Mailer, frontendURL string) error {
+	var user User
+	if err := s.db.Where("email = ? AND totp_enabled_at IS NOT NULL", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// Önceki kullanılmamış bağlantıları geçersiz kıl
+	s.db.Model(&TwoFactorResetToken{}).
+		Where("user_id = ? AND used_at IS NULL AND expires_at > NOW()", user.ID).
+		Update("expires_at", time.Now())
+
+	plain, hashed, err := generateResetToken()
+	if err != nil {
+		return err
+	}
+	if err := s.db.Create(&TwoFactorResetToken{
+		UserID:    user.ID,
+		TokenHash: hashed,
+		ExpiresAt: time.Now().Add(twoFactorResetTTL),
+	}).Error; err != nil {
+		return err
+	}
+
+	resetURL := fmt.Sprintf("%s/reset-two-factor?token=%s", frontendURL, plain)
+	if m != nil && m.Enabled() {
+		if sendErr := m.SendTwoFactorReset(user.Email, resetURL); sendErr != nil {
+			log.Printf("[MAILER ERROR] email=%s err=%v", user.Email, sendErr)
+		}
+	} else {
+		log.Printf("[MAILER SKIP] not configured — 2FA reset URL: %s", resetURL)
+	}
+	return nil
+}
+
+// ConfirmTwoFactorReset — e-postadaki bağlantıyı ve hesabın parolasını doğrulayarak iki adımlı
+// doğrulamayı kapatır. Kullanıcı girişten sonra yeniden kurulum yapabilir.
+func (s *Service) ConfirmTwoFactorReset(ctx context.Context, plainToken, password string) error {
+	sum := sha256.Sum256([]byte(plainToken))
+	hashed := hex.EncodeToString(sum[:])
+
+	var t TwoFactorResetToken
+	if err := s.db.WithContext(ctx).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > NOW()", hashed).
+		First(&t).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("geçersiz veya süresi dolmuş sıfırlama bağlantısı")
+		}
+		return err
+	}
+	user, err := s.GetUserByID(t.UserID)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return ErrInvalidPassword
+	}
+
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&t).Where("used_at IS NULL").Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("geçersiz veya süresi dolmuş sıfırlama bağlantısı")
+		}
+		return disableTwoFactor(tx, t.UserID)
+	}); err != nil {
+		return err
+	}
+
+	s.recordTwoFactor(ctx, audit.ActionTwoFactorReset, t.UserID, t.UserID, map[string]any{"method": "email"})
+	return nil
+}
+
+// AdminResetTwoFactor — organizasyon yöneticisinin, doğrulayıcısını kaybeden üyenin iki adımlı
+// doğrulamasını kapatması. Kullanıcıya bilgilendirme e-postası gönderilir.
+func (s *Service) AdminResetTwoFactor(ctx context.Context, actorID, targetID uuid.UUID, m *mailer.Mailer) error {
+	if actorID == targetID {
+		return ErrTwoFactorResetDenied
+	}
+	ok, err := orgs.CanManageUser(ctx, s.db, actorID, targetID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTwoFactorResetDenied
+	}
+	user, err := s.GetUserByID(targetID)
+	if err != nil {
+		return err
+	}
+	if user.TOTPEnabledAt == nil {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return disableTwoFactor(tx, targetID)
+	}); err != nil {
+		return err
+	}
+
+	s.recordTwoFactor(ctx, audit.ActionTwoFactorReset, actorID, targetID, map[string]any{"method": "admin"})
+	if m != nil && m.Enabled() {
+		if sendErr := m.SendTwoFactorDisabled(user.Email); sendErr != nil {
+			log.Printf("[MAILER ERROR] email=%s err=%v", user.Email, sendErr)
+		}
+	}
+	return nil
+}
+
+func (s *Service) recordTwoFactor(ctx context.Context, action audit.Action, actorID, targetID uuid.UUID, details map[string]any) {
+	s.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &actorID,
+		Action:       action,
+		ResourceType: "user",
+		ResourceID:   &targetID,
+		Status:       audit.StatusSuccess,
+		Details:      details,
+	})
+}
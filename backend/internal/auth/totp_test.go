@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTPCode_RFC6238(t *testing.T) {
+	// RFC 6238 Ek B, SHA-1 test vektörleri (8 hanenin son 6'sı).
+	key := []byte("12345678901234567890")
+	for ts, want := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		assert.Equal(t, want, totpCode(key, uint64(ts/totpPeriod)), "t=%d", ts)
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	secret, err := generateTOTPSecret()
+	require.NoError(t, err)
+	key, err := decodeTOTPSecret(secret)
+	require.NoError(t, err)
+
+	now := time.Unix(1_700_000_000, 0)
+	current := now.Unix() / totpPeriod
+
+	step, ok := verifyTOTP(key, totpCode(key, uint64(current)), now, 0)
+	assert.True(t, ok)
+	assert.Equal(t, current, step)
+
+	_, ok = verifyTOTP(key, totpCode(key, uint64(current-1)), now, 0)
+	assert.True(t, ok, "bir önceki adım saat kayması için kabul edilir")
+	_, ok = verifyTOTP(key, totpCode(key, uint64(current-2)), now, 0)
+	assert.False(t, ok)
+
+	_, ok = verifyTOTP(key, totpCode(key, uint64(current)), now, current)
+	assert.False(t, ok, "kullanılmış adım tekrar kabul edilmez")
+}
+
+func TestTOTPSecretSealing(t *testing.T) {
+	s := &Service{jwtSecret: "test-secret-key-minimum-32-chars-x!"}
+	secret, err := generateTOTPSecret()
+	require.NoError(t, err)
+
+	sealed, err := s.sealTOTPSecret(secret)
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, secret)
+
+	key, err := s.openTOTPSecret(sealed)
+	require.NoError(t, err)
+	want, _ := decodeTOTPSecret(secret)
+	assert.Equal(t, want, key)
+
+	_, err = (&Service{jwtSecret: "another-secret-key-minimum-32-chars"}).openTOTPSecret(sealed)
+	assert.Error(t, err)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := totpURI("ada@example.com", "JBSWY3DPEHPK3PXP")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/NanoNet:ada@example.com?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=NanoNet")
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	code, err := generateRecoveryCode()
+	require.NoError(t, err)
+	assert.Len(t, code, 11)
+	assert.Equal(t, byte('-'), code[5])
+
+	assert.Equal(t, hashRecoveryCode(code), hashRecoveryCode(" "+strings.ToUpper(strings.Replace(code, "-", "", 1))+" "))
+}
+
+func TestTwoFactorChallenge(t *testing.T) {
+	s := &Service{jwtSecret: "test-secret-key-minimum-32-chars-x!"}
+	userID := uuid.New()
+
+	challenge, err := s.NewTwoFactorChallenge(userID)
+	require.NoError(t, err)
+
+	_, err = s.ValidateToken(challenge)
+	assert.Error(t, err, "challenge token API erişimi vermez")
+
+	got, expiry, err := s.parseTwoFactorChallenge(challenge)
+	require.NoError(t, err)
+	assert.Equal(t, userID, got)
+	assert.WithinDuration(t, time.Now().Add(twoFactorChallengeTTL), expiry, 2*time.Second)
+
+	access, err := s.generateToken(userID, time.Hour, "access")
+	require.NoError(t, err)
+	_, _, err = s.parseTwoFactorChallenge(access)
+	assert.ErrorIs(t, err, ErrInvalidChallenge, "access token challenge yerine kullanılamaz")
+}
@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"nanonet-backend/internal/orgs"
+	"nanonet-backend/pkg/audit"
+	"nanonet-backend/pkg/mailer"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	verificationTTL = 24 * time.Hour
+	// resendCooldown — yeni doğrulama e-postası istemeden önce beklenmesi gereken süre.
+	resendCooldown = time.Minute
+)
+
+var (
+	ErrEmailNotVerified     = errors.New("bu işlem için e-posta adresinizi doğrulamanız gerekiyor")
+	ErrEmailAlreadyVerified = errors.New("e-posta adresi zaten doğrulanmış")
+	ErrInvalidVerification  = errors.New("geçersiz veya süresi dolmuş doğrulama bağlantısı")
+	ErrVerificationCooldown = errors.New("yeni doğrulama e-postası için bir dakika bekleyin")
+)
+
+// EmailVerificationToken — kayıt sonrası gönderilen doğrulama bağlantısı (password_reset_tokens
+// ile aynı yapı).
+type EmailVerificationToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null"`
+	TokenHash string     `gorm:"type:varchar(64);unique;not null"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time `gorm:"default:null"`
+	CreatedAt time.Time  `gorm:"not null;default:now()"`
+}
+
+// EmailVerifier — kullanıcının e-posta adresini doğrulayıp doğrulamadığını bildirir.
+type EmailVerifier interface {
+	EmailVerified(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+// unverifiedRoutes — e-posta adresi doğrulanmamış kullanıcının erişebildiği uçlar: hesap bilgisi,
+// oturumlar, şifre, iki adımlı doğrulama, doğrulama e-postası ve davet kabulü.
+var unverifiedRoutes = map[string]bool{
+	"/api/v1/auth/me":                  true,
+	"/api/v1/auth/logout":              true,
+	"/api/v1/auth/password":            true,
+	"/api/v1/auth/sessions":            true,
+	"/api/v1/auth/sessions/:sessionId": true,
+	"/api/v1/auth/2fa":                 true,
+	"/api/v1/auth/2fa/setup":           true,
+	"/api/v1/auth/2fa/enable":          true,
+	"/api/v1/auth/2fa/disable":         true,
+	"/api/v1/auth/2fa/recovery-codes":  true,
+	"/api/v1/auth/verify-email/resend": true,
+	"/api/v1/auth/invitations/accept":  true,
+}
+
+// SetEmailVerification — required false ise yeni hesaplar kayıt anında doğrulanmış sayılır.
+func (s *Service) SetEmailVerification(required bool) {
+	s.requireVerification = required
+}
+
+// EmailVerified — doğrulama kalıcı olduğundan olumlu sonuçlar bellekte tutulur; middleware her
+// istekte yalnızca doğrulanmamış hesaplar için veritabanına gider.
+func (s *Service) EmailVerified(ctx context.Context, userID uuid.UUID) (bool, error) {
+	if _, ok := s.verifiedUsers.Load(userID); ok {
+		return true, nil
+	}
+	var user User
+	if err := s.db.WithContext(ctx).Select("email_verified_at").Where("id = ?", userID).Take(&user).Error; err != nil {
+		return false, err
+	}
+	if user.EmailVerifiedAt == nil {
+		return false, nil
+	}
+	s.verifiedUsers.Store(userID, struct{}{})
+	return true, nil
+}
+
+// markEmailVerified — adresi tx içinde doğrulanmış işaretler; daha önce doğrulanmışsa değiştirmez.
+func markEmailVerified(tx *gorm.DB, userID uuid.UUID) error {
+	return tx.Model(&User{}).
+		Where("id = ? AND email_verified_at IS NULL", userID).
+		Update("email_verified_at", time.Now()).Error
+}
+
+// SendVerificationEmail — kullanıcının bekleyen doğrulama bağlantılarını geçersiz kılar ve yenisini
+// m üzerinden gönderir. Gönderim hatası loglanır; kullanıcı yeniden isteyebilir.
+func (s *Service) SendVerificationEmail(ctx context.Context, user *User, m *mailer.Mailer, frontendURL string) error {
+	s.db.WithContext(ctx).Model(&EmailVerificationToken{}).
+		Where("user_id = ? AND used_at IS NULL AND expires_at > NOW()", user.ID).
+		Update("expires_at", time.Now())
+
+	plain, hashed, err := generateResetToken()
+	if err != nil {
+		return err
+	}
+	evt := &EmailVerificationToken{
+		UserID:    user.ID,
+		TokenHash: hashed,
+		ExpiresAt: time.Now().Add(verificationTTL),
+	}
+	if err := s.db.WithContext(ctx).Create(evt).Error; err != nil {
+		return err
+	}
+
+	verifyURL := fmt.Sprintf("%s/verify-email?token=%s", frontendURL, plain)
+	if m != nil && m.Enabled() {
+		if sendErr := m.SendEmailVerification(user.Email, verifyURL); sendErr != nil {
+			log.Printf("[MAILER ERROR] email=%s err=%v", user.Email, sendErr)
+		} else {
+			log.Printf("[MAILER OK] verification email sent to %s", user.Email)
+		}
+	} else {
+		log.Printf("[MAILER SKIP] not configured — verification URL: %s", verifyURL)
+	}
+	return nil
+}
+
+// ResendVerification — doğrulanmamış hesap için yeni doğrulama e-postası gönderir. Son
+// gönderimden resendCooldown geçmeden ErrVerificationCooldown döner.
+func (s *Service) ResendVerification(ctx context.Context, userID uuid.UUID, m *mailer.Mailer, frontendURL string) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+	var recent int64
+	if err := s.db.WithContext(ctx).Model(&EmailVerificationToken{}).
+		Where("user_id = ? AND created_at > ?", userID, time.Now().Add(-resendCooldown)).
+		Count(&recent).Error; err != nil {
+		return err
+	}
+	if recent > 0 {
+		return ErrVerificationCooldown
+	}
+	return s.SendVerificationEmail(ctx, user, m, frontendURL)
+}
+
+// VerifyEmail — doğrulama bağlantısındaki token'ı tüketir ve hesabı doğrulanmış işaretler.
+func (s *Service) VerifyEmail(ctx context.Context, plainToken string) (*User, error) {
+	sum := sha256.Sum256([]byte(plainToken))
+	hashed := hex.EncodeToString(sum[:])
+
+	var evt EmailVerificationToken
+	if err := s.db.WithContext(ctx).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > NOW()", hashed).
+		First(&evt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidVerification
+		}
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := markEmailVerified(tx, evt.UserID); err != nil {
+			return err
+		}
+		return tx.Model(&evt).Update("used_at", time.Now()).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	user, err := s.GetUserByID(evt.UserID)
+	if err != nil {
+		return nil, err
+	}
+	s.verifiedUsers.Store(user.ID, struct{}{})
+	s.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &user.ID,
+		Action:       audit.ActionEmailVerified,
+		ResourceType: "user",
+		ResourceID:   &user.ID,
+		Status:       audit.StatusSuccess,
+		Details:      map[string]any{"method": "link"},
+	})
+	return user, nil
+}
+
+// AcceptInvitation — oturum açmış kullanıcının daveti kabul etmesi. Davet kullanıcının adresine
+// gönderildiğinden adres de doğrulanmış sayılır.
+func (s *Service) AcceptInvitation(ctx context.Context, userID uuid.UUID, plainToken string) (*orgs.Invitation, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	var inv *orgs.Invitation
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if inv, err = orgs.AcceptInvitation(tx, plainToken, user.ID, user.Email); err != nil {
+			return err
+		}
+		return markEmailVerified(tx, user.ID)
+	}); err != nil {
+		return nil, err
+	}
+	s.recordInvitationAccepted(ctx, user, inv)
+	return inv, nil
+}
+
+func (s *Service) recordInvitationAccepted(ctx context.Context, user *User, inv *orgs.Invitation) {
+	s.verifiedUsers.Store(user.ID, struct{}{})
+	s.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &user.ID,
+		Action:       audit.ActionOrgInviteAccepted,
+		ResourceType: "organization",
+		ResourceID:   &inv.OrgID,
+		Status:       audit.StatusSuccess,
+		Details:      map[string]any{"invitation_id": inv.ID, "role": inv.Role, "team_ids": []string(inv.TeamIDs)},
+	})
+}
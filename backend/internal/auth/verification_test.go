@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nanonet-backend/pkg/tokenblacklist"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeVerifier map[uuid.UUID]bool
+
+func (f fakeVerifier) EmailVerified(_ context.Context, userID uuid.UUID) (bool, error) {
+	return f[userID], nil
+}
+
+func TestMiddleware_RestrictsUnverifiedAccounts(t *testing.T) {
+	const secret = "test-secret-key-minimum-32-chars-x!"
+	m := NewMiddleware(secret, tokenblacklist.NewInMemory())
+	s := &Service{jwtSecret: secret}
+
+	verified, unverified := uuid.New(), uuid.New()
+	m.SetEmailVerifier(fakeVerifier{verified: true})
+
+	r := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/api/v1/auth/me", m.Required(), ok)
+	r.POST("/api/v1/auth/verify-email/resend", m.Required(), ok)
+	r.GET("/api/v1/services", m.Required(), ok)
+	r.POST("/api/v1/auth/api-keys", m.Required(), ok)
+
+	call := func(userID uuid.UUID, method, path string) int {
+		tokens, err := s.issueTokens(userID, uuid.New(), "jti")
+		require.NoError(t, err)
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, call(unverified, http.MethodGet, "/api/v1/auth/me"))
+	assert.Equal(t, http.StatusOK, call(unverified, http.MethodPost, "/api/v1/auth/verify-email/resend"))
+	assert.Equal(t, http.StatusForbidden, call(unverified, http.MethodGet, "/api/v1/services"))
+	assert.Equal(t, http.StatusForbidden, call(unverified, http.MethodPost, "/api/v1/auth/api-keys"),
+		"doğrulanmamış hesap API anahtarı oluşturamaz")
+	assert.Equal(t, http.StatusOK, call(verified, http.MethodGet, "/api/v1/services"))
+}
@@ -14,13 +14,38 @@ type CommandLog struct {
 	CommandID   string          `gorm:"type:varchar(100);not null" json:"command_id"`
 	Action      string          `gorm:"type:varchar(50);not null" json:"action"`
 	Status      string          `gorm:"type:varchar(20);not null;default:'queued'" json:"status"`
+	Source      string          `gorm:"type:varchar(30);not null;default:'user'" json:"source"`
+	ParentID    *uuid.UUID      `gorm:"type:uuid" json:"parent_id,omitempty"`
 	Payload     json.RawMessage `gorm:"type:jsonb" json:"payload,omitempty"`
 	Output      *string         `gorm:"type:text" json:"output,omitempty"`
 	QueuedAt    time.Time       `gorm:"not null;default:now()" json:"queued_at"`
 	CompletedAt *time.Time      `json:"completed_at,omitempty"`
 	DurationMS  *int            `json:"duration_ms,omitempty"`
+	CancelledBy *uuid.UUID      `gorm:"type:uuid" json:"cancelled_by,omitempty"`
+	CancelledAt *time.Time      `json:"cancelled_at,omitempty"`
 }
 
 func (CommandLog) TableName() string {
 	return "command_logs"
 }
+
+// Komutu kimin başlattığını belirten kaynak değerleri.
+const (
+	SourceUser         = "user"
+	SourceAutoRecovery = "auto_recovery"
+	SourceRunbook      = "runbook"
+	SourceSchedule     = "schedule"
+	SourceFleet        = "fleet"
+)
+
+// StatusCancelled — kullanıcı tarafından iptal edilen komut.
+const StatusCancelled = "cancelled"
+
+// IsTerminalStatus — komutun sonuçlanıp sonuçlanmadığını döndürür.
+func IsTerminalStatus(status string) bool {
+	switch status {
+	case "success", "failed", "timeout", StatusCancelled:
+		return true
+	}
+	return false
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"time"
 
+	"nanonet-backend/internal/orgs"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -42,10 +44,97 @@ func (r *Repository) UpdateStatus(ctx context.Context, commandID, status string,
 
 	return r.db.WithContext(ctx).
 		Model(&CommandLog{}).
-		Where("command_id = ?", commandID).
+		Where("command_id = ? AND status <> ?", commandID, StatusCancelled).
+		Updates(updates).Error
+}
+
+// UpdateResult — agent sonucunu (durum + çıktı) yazar; süre queued_at'ten hesaplanır.
+// İptal edilmiş komutların durumu değiştirilmez.
+func (r *Repository) UpdateResult(ctx context.Context, commandID, status string, output *string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	updates := map[string]interface{}{
+		"status": status,
+	}
+	if IsTerminalStatus(status) {
+		updates["completed_at"] = time.Now()
+		updates["duration_ms"] = gorm.Expr("(EXTRACT(EPOCH FROM (now() - queued_at)) * 1000)::int")
+	}
+	if output != nil {
+		updates["output"] = *output
+	}
+
+	return r.db.WithContext(ctx).
+		Model(&CommandLog{}).
+		Where("command_id = ? AND status <> ?", commandID, StatusCancelled).
 		Updates(updates).Error
 }
 
+// MarkCancelled — sonuçlanmamış komutu iptal edildi olarak işaretler.
+// Komut zaten sonuçlandıysa false döner.
+func (r *Repository) MarkCancelled(ctx context.Context, commandID string, userID uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	result := r.db.WithContext(ctx).
+		Model(&CommandLog{}).
+		Where("command_id = ? AND status IN ('queued', 'received')", commandID).
+		Updates(map[string]interface{}{
+			"status":       StatusCancelled,
+			"cancelled_by": userID,
+			"cancelled_at": now,
+			"completed_at": now,
+			"duration_ms":  gorm.Expr("(EXTRACT(EPOCH FROM (now() - queued_at)) * 1000)::int"),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *Repository) GetByCommandID(ctx context.Context, commandID string) (*CommandLog, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var log CommandLog
+	err := r.db.WithContext(ctx).
+		Where("command_id = ?", commandID).
+		First(&log).Error
+	if err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+// GetByParentID — bir üst işleme (runbook çalıştırması vb.) bağlı alt komutları döndürür.
+func (r *Repository) GetByParentID(ctx context.Context, parentID uuid.UUID) ([]CommandLog, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var logs []CommandLog
+	err := r.db.WithContext(ctx).
+		Where("parent_id = ?", parentID).
+		Order("queued_at ASC").
+		Find(&logs).Error
+	return logs, err
+}
+
+// GetPageByParentID — üst işleme bağlı komutları en yeniden eskiye sayfalı döndürür.
+func (r *Repository) GetPageByParentID(ctx context.Context, parentID uuid.UUID, limit, offset int) ([]CommandLog, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := r.db.WithContext(ctx).Model(&CommandLog{}).Where("parent_id = ?", parentID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []CommandLog
+	err := query.Order("queued_at DESC").Limit(limit).Offset(offset).Find(&logs).Error
+	return logs, total, err
+}
+
 func (r *Repository) HasInFlightCommand(ctx context.Context, serviceID uuid.UUID, action string) (bool, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
@@ -69,16 +158,11 @@ func (r *Repository) MarkStalledCommandsTimeout(ctx context.Context, threshold t
 		}).Error
 }
 
+// IsServiceOwner — kullanıcının servise en az viewer rolüyle erişebildiğini bildirir
+// (organizasyon üyeliği veya takım ataması).
 func (r *Repository) IsServiceOwner(ctx context.Context, serviceID, userID uuid.UUID) bool {
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-
-	var count int64
-	r.db.WithContext(ctx).
-		Table("services").
-		Where("id = ? AND user_id = ?", serviceID, userID).
-		Count(&count)
-	return count > 0
+	ok, err := orgs.HasServiceRole(ctx, r.db, serviceID, userID, orgs.RoleViewer)
+	return err == nil && ok
 }
 
 func (r *Repository) GetByServiceID(ctx context.Context, serviceID uuid.UUID, limit, offset int) ([]CommandLog, int64, error) {
@@ -3,12 +3,18 @@ package commands
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrCommandNotFound = errors.New("komut bulunamadı")
+	ErrCommandFinished = errors.New("komut zaten sonuçlanmış")
+)
+
 type Service struct {
 	repo *Repository
 }
@@ -20,6 +26,17 @@ func NewService(db *gorm.DB) *Service {
 }
 
 func (s *Service) LogCommand(ctx context.Context, serviceID, userID uuid.UUID, commandID, action string, payload interface{}) error {
+	return s.LogCommandWithSource(ctx, serviceID, userID, commandID, action, SourceUser, payload)
+}
+
+// LogCommandWithSource — sistem tarafından başlatılan komutlar için kaynak bilgisiyle kayıt oluşturur.
+// userID komutun adına çalıştırıldığı kullanıcıdır (sistem komutlarında servis sahibi).
+func (s *Service) LogCommandWithSource(ctx context.Context, serviceID, userID uuid.UUID, commandID, action, source string, payload interface{}) error {
+	return s.LogChildCommand(ctx, serviceID, userID, commandID, action, source, nil, payload)
+}
+
+// LogChildCommand — bir üst işleme (parentID) bağlı komut kaydı oluşturur.
+func (s *Service) LogChildCommand(ctx context.Context, serviceID, userID uuid.UUID, commandID, action, source string, parentID *uuid.UUID, payload interface{}) error {
 	payloadJSON, _ := json.Marshal(payload)
 
 	log := &CommandLog{
@@ -28,6 +45,8 @@ func (s *Service) LogCommand(ctx context.Context, serviceID, userID uuid.UUID, c
 		CommandID: commandID,
 		Action:    action,
 		Status:    "queued",
+		Source:    source,
+		ParentID:  parentID,
 		Payload:   payloadJSON,
 	}
 
@@ -38,6 +57,79 @@ func (s *Service) UpdateStatus(ctx context.Context, commandID, status string, du
 	return s.repo.UpdateStatus(ctx, commandID, status, durationMS)
 }
 
+// RecordResult — agent'tan gelen sonucu çıktısıyla birlikte kaydeder.
+func (s *Service) RecordResult(ctx context.Context, commandID, status string, output *string) error {
+	return s.repo.UpdateResult(ctx, commandID, status, output)
+}
+
+func (s *Service) GetByCommandID(ctx context.Context, commandID string) (*CommandLog, error) {
+	return s.repo.GetByCommandID(ctx, commandID)
+}
+
+func (s *Service) GetByParentID(ctx context.Context, parentID uuid.UUID) ([]CommandLog, error) {
+	return s.repo.GetByParentID(ctx, parentID)
+}
+
+func (s *Service) GetPageByParentID(ctx context.Context, parentID uuid.UUID, limit, offset int) ([]CommandLog, int64, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	return s.repo.GetPageByParentID(ctx, parentID, limit, offset)
+}
+
+// WaitForResult — komut sonuçlanana kadar command_logs tablosunu yoklar.
+// Sonuç başka bir backend örneğine gelmiş olsa bile DB üzerinden görülür.
+// Süre dolarsa komutun son bilinen kaydı ve context hatası döner.
+func (s *Service) WaitForResult(ctx context.Context, commandID string, timeout time.Duration) (*CommandLog, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var last *CommandLog
+	for {
+		if cl, err := s.repo.GetByCommandID(ctx, commandID); err == nil {
+			last = cl
+			if IsTerminalStatus(cl.Status) {
+				return cl, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return last, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Cancel — servise ait sonuçlanmamış komutu, iptal eden kullanıcıyla birlikte "cancelled" işaretler.
+// Kuyruktan çıkarma ve agent'a iptal mesajı gönderme çağırana aittir.
+func (s *Service) Cancel(ctx context.Context, serviceID uuid.UUID, commandID string, userID uuid.UUID) (*CommandLog, error) {
+	cl, err := s.repo.GetByCommandID(ctx, commandID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommandNotFound
+		}
+		return nil, err
+	}
+	if cl.ServiceID != serviceID {
+		return nil, ErrCommandNotFound
+	}
+	if IsTerminalStatus(cl.Status) {
+		return nil, ErrCommandFinished
+	}
+
+	ok, err := s.repo.MarkCancelled(ctx, commandID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrCommandFinished
+	}
+	return s.repo.GetByCommandID(ctx, commandID)
+}
+
 func (s *Service) GetHistory(ctx context.Context, serviceID uuid.UUID, limit, offset int) ([]CommandLog, int64, error) {
 	if limit <= 0 {
 		limit = 20
@@ -0,0 +1,114 @@
+package fleet
+
+import (
+	"errors"
+	"strconv"
+
+	"nanonet-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Create — POST /fleet/operations
+// Komutu etiket veya ID listesiyle seçilen servislerde batch'ler halinde çalıştırır.
+func (h *Handler) Create(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	op, err := h.service.Create(c.Request.Context(), userID, req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidTarget) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.InternalError(c, "operasyon başlatılamadı")
+		return
+	}
+	response.Created(c, op)
+}
+
+// List — GET /fleet/operations
+func (h *Handler) List(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	ops, total, err := h.service.List(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		response.InternalError(c, "operasyonlar alınamadı")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"operations": ops,
+		"total":      total,
+		"page":       page,
+	})
+}
+
+// Get — GET /fleet/operations/:opId
+// Operasyonu servis bazlı sonuçlar ve alt komut kayıtlarıyla döndürür.
+func (h *Handler) Get(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+
+	opID, err := uuid.Parse(c.Param("opId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz operasyon ID")
+		return
+	}
+
+	op, err := h.service.Get(c.Request.Context(), opID, userID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, "operasyon alınamadı")
+		return
+	}
+
+	cmds, err := h.service.Commands(c.Request.Context(), op.ID)
+	if err != nil {
+		response.InternalError(c, "operasyon komutları alınamadı")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"operation": op,
+		"commands":  cmds,
+	})
+}
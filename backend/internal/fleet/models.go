@@ -0,0 +1,93 @@
+package fleet
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Operasyon durumları.
+const (
+	StatusRunning = "running"
+	StatusSuccess = "success"
+	// StatusPartial — tüm batch'ler çalıştı, başarısızlıklar eşiğin altında kaldı.
+	StatusPartial = "partial"
+	// StatusFailed — başarısızlık eşiği aşıldı, kalan servisler atlandı.
+	StatusFailed = "failed"
+)
+
+// Hedef servis durumları.
+const (
+	TargetPending = "pending"
+	TargetRunning = "running"
+	TargetSuccess = "success"
+	TargetFailed  = "failed"
+	TargetSkipped = "skipped"
+)
+
+type Operation struct {
+	ID                uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID            uuid.UUID       `gorm:"type:uuid;not null" json:"user_id"`
+	Action            string          `gorm:"type:varchar(20);not null" json:"action"`
+	Params            json.RawMessage `gorm:"type:jsonb" json:"params,omitempty"`
+	TargetTag         *string         `gorm:"type:varchar(50)" json:"target_tag,omitempty"`
+	BatchSize         int             `gorm:"not null" json:"batch_size"`
+	WaitHealthy       bool            `gorm:"not null;default:true" json:"wait_healthy"`
+	HealthTimeoutSec  int             `gorm:"not null" json:"health_timeout_sec"`
+	CommandTimeoutSec int             `gorm:"not null" json:"command_timeout_sec"`
+	FailureThreshold  int             `gorm:"not null;default:0" json:"failure_threshold"`
+	Status            string          `gorm:"type:varchar(20);not null;default:'running'" json:"status"`
+	TotalServices     int             `gorm:"not null" json:"total_services"`
+	Succeeded         int             `gorm:"not null;default:0" json:"succeeded"`
+	Failed            int             `gorm:"not null;default:0" json:"failed"`
+	CurrentBatch      int             `gorm:"not null;default:0" json:"current_batch"`
+	TotalBatches      int             `gorm:"not null" json:"total_batches"`
+	Results           json.RawMessage `gorm:"type:jsonb" json:"results,omitempty"`
+	Error             *string         `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt         time.Time       `gorm:"not null;default:now()" json:"created_at"`
+	CompletedAt       *time.Time      `json:"completed_at,omitempty"`
+}
+
+func (Operation) TableName() string { return "fleet_operations" }
+
+// TargetResult — operasyondaki tek bir servisin durumu.
+type TargetResult struct {
+	ServiceID   uuid.UUID  `json:"service_id"`
+	ServiceName string     `json:"service_name"`
+	Batch       int        `json:"batch"`
+	Status      string     `json:"status"`
+	CommandID   string     `json:"command_id,omitempty"`
+	Healthy     *bool      `json:"healthy,omitempty"`
+	Message     string     `json:"message,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// Progress — fleet_progress WebSocket olayı ve özet yanıtı.
+type Progress struct {
+	OperationID   uuid.UUID     `json:"operation_id"`
+	Action        string        `json:"action"`
+	Status        string        `json:"status"`
+	TotalServices int           `json:"total_services"`
+	Succeeded     int           `json:"succeeded"`
+	Failed        int           `json:"failed"`
+	CurrentBatch  int           `json:"current_batch"`
+	TotalBatches  int           `json:"total_batches"`
+	Target        *TargetResult `json:"target,omitempty"`
+}
+
+type CreateRequest struct {
+	Action     string                 `json:"action" binding:"required,oneof=restart stop start ping exec scale"`
+	Params     map[string]interface{} `json:"params"`
+	ServiceIDs []string               `json:"service_ids" binding:"omitempty,max=500,dive,uuid"`
+	Tag        string                 `json:"tag" binding:"omitempty,max=50"`
+	BatchSize  int                    `json:"batch_size" binding:"omitempty,min=1,max=50"`
+	// WaitHealthy — batch'ler arasında servislerin "up" raporlamasını bekle.
+	// Varsayılan: restart/start/scale için true, diğerleri için false.
+	WaitHealthy       *bool `json:"wait_healthy"`
+	HealthTimeoutSec  int   `json:"health_timeout_sec" binding:"omitempty,min=10,max=1800"`
+	CommandTimeoutSec int   `json:"command_timeout_sec" binding:"omitempty,min=10,max=900"`
+	// FailureThreshold — izin verilen en fazla başarısız servis; aşılınca kalan batch'ler çalışmaz.
+	FailureThreshold *int `json:"failure_threshold" binding:"omitempty,min=0"`
+}
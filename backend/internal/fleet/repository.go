@@ -0,0 +1,85 @@
+package fleet
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) Create(ctx context.Context, op *Operation) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Create(op).Error
+}
+
+func (r *Repository) Update(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).
+		Model(&Operation{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+func (r *Repository) Get(ctx context.Context, id, userID uuid.UUID) (*Operation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var op Operation
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&op).Error
+	if err != nil {
+		return nil, err
+	}
+	return &op, nil
+}
+
+// List — kullanıcının operasyonlarını sonuç detayları olmadan döndürür.
+func (r *Repository) List(ctx context.Context, userID uuid.UUID, limit, offset int) ([]Operation, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := r.db.WithContext(ctx).Model(&Operation{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var ops []Operation
+	err := query.Omit("results").
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&ops).Error
+	return ops, total, err
+}
+
+// MarkStale — threshold'dan önce başlamış ve hâlâ "running" görünen operasyonları
+// başarısız işaretler (örn: backend operasyon sırasında yeniden başladı).
+func (r *Repository) MarkStale(ctx context.Context, threshold time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).
+		Model(&Operation{}).
+		Where("status = ? AND created_at < ?", StatusRunning, threshold).
+		Updates(map[string]interface{}{
+			"status":       StatusFailed,
+			"error":        "operasyon yarıda kaldı (süre aşıldı)",
+			"completed_at": time.Now(),
+		}).Error
+}
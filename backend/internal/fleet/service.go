@@ -0,0 +1,410 @@
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"nanonet-backend/internal/commands"
+	"nanonet-backend/internal/metrics"
+	"nanonet-backend/internal/orgs"
+	"nanonet-backend/internal/services"
+	"nanonet-backend/internal/ws"
+	"nanonet-backend/pkg/audit"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound      = errors.New("operasyon bulunamadı")
+	ErrInvalidTarget = errors.New("geçersiz hedef")
+)
+
+const (
+	defaultBatchSize      = 5
+	defaultHealthTimeout  = 120
+	defaultCommandTimeout = 120
+	healthPollInterval    = 5 * time.Second
+
+	// maxOperationDuration — bu süreyi aşan operasyonda kalan servisler atlanır.
+	maxOperationDuration = 6 * time.Hour
+	staleSweepInterval   = 5 * time.Minute
+)
+
+type Service struct {
+	repo        *Repository
+	svcRepo     *services.Repository
+	dispatcher  *services.Dispatcher
+	cmdService  *commands.Service
+	metricsRepo *metrics.Repository
+	hub         *ws.Hub
+	auditLogger *audit.Logger
+}
+
+func NewService(db *gorm.DB, hub *ws.Hub) *Service {
+	return &Service{
+		repo:        NewRepository(db),
+		svcRepo:     services.NewRepository(db),
+		dispatcher:  services.NewDispatcher(db, hub),
+		cmdService:  commands.NewService(db),
+		metricsRepo: metrics.NewRepository(db),
+		hub:         hub,
+		auditLogger: audit.New(db),
+	}
+}
+
+// checkTargets — çözülen hedef sayısını istekle karşılaştırır. Etiket tek başına verildiğinde
+// hiçbir servisle eşleşmemesi (örn. yazım hatası) operasyonu boş yere oluşturmak yerine reddedilir.
+func checkTargets(requestedIDs int, tag string, found int) error {
+	switch {
+	case requestedIDs > 0 && tag == "" && found != requestedIDs:
+		return fmt.Errorf("%w: %d servisten %d tanesi bulunamadı", ErrInvalidTarget, requestedIDs, requestedIDs-found)
+	case found == 0 && tag != "":
+		return fmt.Errorf("%w: %q etiketiyle eşleşen servis yok", ErrInvalidTarget, tag)
+	case found == 0:
+		return fmt.Errorf("%w: hedeflerle eşleşen servis yok", ErrInvalidTarget)
+	}
+	return nil
+}
+
+// Start — süresi aşılmış "running" operasyonları periyodik olarak başarısız işaretler.
+func (s *Service) Start(ctx context.Context) {
+	ticker := time.NewTicker(staleSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			threshold := time.Now().Add(-(maxOperationDuration + time.Minute))
+			if err := s.repo.MarkStale(ctx, threshold); err != nil {
+				log.Printf("[fleet] yarım kalan operasyonlar işaretlenemedi: %v", err)
+			}
+		}
+	}
+}
+
+// Create — hedef servisleri çözer, operasyonu kaydeder ve arka planda batch'ler halinde başlatır.
+func (s *Service) Create(ctx context.Context, userID uuid.UUID, req CreateRequest) (*Operation, error) {
+	// Etiket servislerde saklandığı biçimde aranır ("Prod " → "prod").
+	req.Tag = services.NormalizeTag(req.Tag)
+	if len(req.ServiceIDs) == 0 && req.Tag == "" {
+		return nil, fmt.Errorf("%w: service_ids veya tag belirtilmeli", ErrInvalidTarget)
+	}
+	if err := services.ValidateCommand(req.Action, req.Params); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTarget, err)
+	}
+
+	ids := make([]uuid.UUID, 0, len(req.ServiceIDs))
+	seen := make(map[uuid.UUID]bool, len(req.ServiceIDs))
+	for _, raw := range req.ServiceIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: geçersiz servis ID %q", ErrInvalidTarget, raw)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	targets, err := s.svcRepo.ListTargets(ctx, userID, orgs.RoleOperator, ids, req.Tag)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTargets(len(ids), req.Tag, len(targets)); err != nil {
+		return nil, err
+	}
+
+	op := &Operation{
+		UserID:            userID,
+		Action:            req.Action,
+		BatchSize:         req.BatchSize,
+		WaitHealthy:       req.Action == "restart" || req.Action == "start" || req.Action == "scale",
+		HealthTimeoutSec:  req.HealthTimeoutSec,
+		CommandTimeoutSec: req.CommandTimeoutSec,
+		Status:            StatusRunning,
+		TotalServices:     len(targets),
+	}
+	if req.Params != nil {
+		op.Params, _ = json.Marshal(req.Params)
+	}
+	if req.Tag != "" {
+		op.TargetTag = &req.Tag
+	}
+	if op.BatchSize == 0 {
+		op.BatchSize = defaultBatchSize
+	}
+	if req.WaitHealthy != nil {
+		op.WaitHealthy = *req.WaitHealthy
+	}
+	if op.HealthTimeoutSec == 0 {
+		op.HealthTimeoutSec = defaultHealthTimeout
+	}
+	if op.CommandTimeoutSec == 0 {
+		op.CommandTimeoutSec = defaultCommandTimeout
+	}
+	if req.FailureThreshold != nil {
+		op.FailureThreshold = *req.FailureThreshold
+	}
+	op.TotalBatches = (len(targets) + op.BatchSize - 1) / op.BatchSize
+
+	results := make([]TargetResult, len(targets))
+	for i, svc := range targets {
+		results[i] = TargetResult{
+			ServiceID:   svc.ID,
+			ServiceName: svc.Name,
+			Batch:       i/op.BatchSize + 1,
+			Status:      TargetPending,
+		}
+	}
+	op.Results, _ = json.Marshal(results)
+
+	if err := s.repo.Create(ctx, op); err != nil {
+		return nil, err
+	}
+
+	s.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &userID,
+		Action:       audit.ActionFleetCommand,
+		ResourceType: "fleet_operation",
+		ResourceID:   &op.ID,
+		Status:       audit.StatusSuccess,
+		Details: map[string]any{
+			"action":         op.Action,
+			"tag":            req.Tag,
+			"total_services": op.TotalServices,
+			"batch_size":     op.BatchSize,
+		},
+	})
+
+	log.Printf("[fleet] operasyon başladı id=%s action=%s services=%d batches=%d", op.ID, op.Action, op.TotalServices, op.TotalBatches)
+
+	go s.execute(*op, req.Params, results)
+	return op, nil
+}
+
+func (s *Service) Get(ctx context.Context, id, userID uuid.UUID) (*Operation, error) {
+	op, err := s.repo.Get(ctx, id, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	return op, err
+}
+
+func (s *Service) List(ctx context.Context, userID uuid.UUID, limit, offset int) ([]Operation, int64, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.repo.List(ctx, userID, limit, offset)
+}
+
+// Commands — operasyonun servislere gönderdiği komut kayıtlarını döndürür.
+func (s *Service) Commands(ctx context.Context, operationID uuid.UUID) ([]commands.CommandLog, error) {
+	return s.cmdService.GetByParentID(ctx, operationID)
+}
+
+// run — tek bir operasyonun yürütme durumu; results goroutine'ler arasında paylaşılır.
+type run struct {
+	op      Operation
+	params  map[string]interface{}
+	mu      sync.Mutex
+	results []TargetResult
+}
+
+func (s *Service) execute(op Operation, params map[string]interface{}, results []TargetResult) {
+	ctx, cancel := context.WithTimeout(context.Background(), maxOperationDuration)
+	defer cancel()
+
+	r := &run{op: op, params: params, results: results}
+	var failure string
+
+	for batch := 1; batch <= op.TotalBatches; batch++ {
+		r.mu.Lock()
+		r.op.CurrentBatch = batch
+		r.mu.Unlock()
+		s.save(r, nil)
+
+		var wg sync.WaitGroup
+		for i := range results {
+			if results[i].Batch != batch {
+				continue
+			}
+			wg.Add(1)
+			go func(idx int) {
+				defer wg.Done()
+				s.runTarget(ctx, r, idx)
+			}(i)
+		}
+		wg.Wait()
+
+		r.mu.Lock()
+		failed := r.op.Failed
+		r.mu.Unlock()
+		if failed > op.FailureThreshold {
+			failure = fmt.Sprintf("başarısızlık eşiği aşıldı (%d > %d), kalan servisler atlandı", failed, op.FailureThreshold)
+			break
+		}
+		if ctx.Err() != nil {
+			failure = "operasyon süresi doldu, kalan servisler atlandı"
+			break
+		}
+	}
+
+	r.mu.Lock()
+	for i := range r.results {
+		if r.results[i].Status == TargetPending {
+			r.results[i].Status = TargetSkipped
+		}
+	}
+	switch {
+	case failure != "":
+		r.op.Status = StatusFailed
+		r.op.Error = &failure
+	case r.op.Failed > 0:
+		r.op.Status = StatusPartial
+	default:
+		r.op.Status = StatusSuccess
+	}
+	now := time.Now()
+	r.op.CompletedAt = &now
+	r.mu.Unlock()
+
+	s.save(r, nil)
+	log.Printf("[fleet] operasyon bitti id=%s status=%s succeeded=%d failed=%d", op.ID, r.op.Status, r.op.Succeeded, r.op.Failed)
+}
+
+func (s *Service) runTarget(ctx context.Context, r *run, idx int) {
+	started := time.Now()
+	r.mu.Lock()
+	target := &r.results[idx]
+	target.Status = TargetRunning
+	target.StartedAt = &started
+	serviceID := target.ServiceID
+	r.mu.Unlock()
+	s.save(r, &idx)
+
+	commandID, ok, healthy, msg := s.executeOn(ctx, r, serviceID, started)
+
+	finished := time.Now()
+	r.mu.Lock()
+	target.CommandID = commandID
+	target.Healthy = healthy
+	target.Message = msg
+	target.FinishedAt = &finished
+	if ok {
+		target.Status = TargetSuccess
+		r.op.Succeeded++
+	} else {
+		target.Status = TargetFailed
+		r.op.Failed++
+	}
+	r.mu.Unlock()
+	s.save(r, &idx)
+}
+
+// executeOn — komutu gönderir, sonucunu ve gerekiyorsa servisin sağlıklı raporlamasını bekler.
+func (s *Service) executeOn(ctx context.Context, r *run, serviceID uuid.UUID, started time.Time) (string, bool, *bool, string) {
+	parentID := r.op.ID
+	dispatched, err := s.dispatcher.Dispatch(ctx, services.DispatchRequest{
+		ServiceID: serviceID,
+		UserID:    r.op.UserID,
+		Action:    r.op.Action,
+		Params:    r.params,
+		Source:    commands.SourceFleet,
+		ParentID:  &parentID,
+	})
+	if err != nil {
+		return "", false, nil, err.Error()
+	}
+
+	cl, err := s.cmdService.WaitForResult(ctx, dispatched.CommandID, time.Duration(r.op.CommandTimeoutSec)*time.Second)
+	if err != nil {
+		msg := "komut zaman aşımına uğradı"
+		_ = s.cmdService.RecordResult(context.Background(), dispatched.CommandID, "timeout", &msg)
+		return dispatched.CommandID, false, nil, msg
+	}
+	if cl.Status != "success" {
+		return dispatched.CommandID, false, nil, "komut başarısız: " + cl.Status
+	}
+	if !r.op.WaitHealthy {
+		return dispatched.CommandID, true, nil, ""
+	}
+
+	healthy := s.waitHealthy(ctx, serviceID, started, time.Duration(r.op.HealthTimeoutSec)*time.Second)
+	if !healthy {
+		return dispatched.CommandID, false, &healthy, "servis süre içinde sağlıklı raporlamadı"
+	}
+	return dispatched.CommandID, true, &healthy, ""
+}
+
+// waitHealthy — since'den sonra raporlanan bir metriğin "up" olmasını bekler.
+func (s *Service) waitHealthy(ctx context.Context, serviceID uuid.UUID, since time.Time, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(healthPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if m, err := s.metricsRepo.GetLatest(ctx, serviceID); err == nil {
+			if m.Time.After(since) && m.Status == "up" {
+				return true
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// save — operasyonun güncel durumunu DB'ye yazar ve fleet_progress olayı yayınlar.
+// idx verilirse olaya o servisin sonucu eklenir.
+func (s *Service) save(r *run, idx *int) {
+	r.mu.Lock()
+	resultsJSON, _ := json.Marshal(r.results)
+	progress := Progress{
+		OperationID:   r.op.ID,
+		Action:        r.op.Action,
+		Status:        r.op.Status,
+		TotalServices: r.op.TotalServices,
+		Succeeded:     r.op.Succeeded,
+		Failed:        r.op.Failed,
+		CurrentBatch:  r.op.CurrentBatch,
+		TotalBatches:  r.op.TotalBatches,
+	}
+	if idx != nil {
+		target := r.results[*idx]
+		progress.Target = &target
+	}
+	updates := map[string]interface{}{
+		"status":        r.op.Status,
+		"succeeded":     r.op.Succeeded,
+		"failed":        r.op.Failed,
+		"current_batch": r.op.CurrentBatch,
+		"results":       json.RawMessage(resultsJSON),
+	}
+	if r.op.Error != nil {
+		updates["error"] = *r.op.Error
+	}
+	if r.op.CompletedAt != nil {
+		updates["completed_at"] = *r.op.CompletedAt
+	}
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.repo.Update(ctx, r.op.ID, updates); err != nil {
+		log.Printf("[fleet] ilerleme kaydedilemedi id=%s: %v", r.op.ID, err)
+	}
+
+	s.hub.BroadcastUserEvent(r.op.UserID.String(), "fleet_progress", progress)
+}
@@ -0,0 +1,20 @@
+package fleet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckTargets(t *testing.T) {
+	assert.NoError(t, checkTargets(0, "prod", 3))
+	assert.NoError(t, checkTargets(2, "", 2))
+	assert.NoError(t, checkTargets(3, "prod", 1), "id listesi etiketle süzülebilir")
+
+	err := checkTargets(0, "prdo", 0)
+	assert.ErrorIs(t, err, ErrInvalidTarget)
+	assert.ErrorContains(t, err, `"prdo"`)
+
+	assert.ErrorIs(t, checkTargets(2, "", 1), ErrInvalidTarget)
+	assert.ErrorIs(t, checkTargets(2, "prod", 0), ErrInvalidTarget)
+}
@@ -1,18 +1,27 @@
 package k8s
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strconv"
 
+	"nanonet-backend/pkg/audit"
 	"nanonet-backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // Handler — Kubernetes API endpoint'leri.
 type Handler struct {
-	client *Client
+	client    *Client
+	approvals approvalGate
+}
+
+// approvalGate — approvals.Service tarafından karşılanır; import döngüsünü önler.
+type approvalGate interface {
+	Submit(ctx context.Context, serviceID *uuid.UUID, requestedBy uuid.UUID, action, target string, params map[string]interface{}) (*uuid.UUID, error)
 }
 
 // NewHandler — K8s handler oluşturur. client nil olabilir (K8s devre dışı).
@@ -20,6 +29,38 @@ func NewHandler(client *Client) *Handler {
 	return &Handler{client: client}
 }
 
+// SetApprovalGate wires in the two-person approval check for DeletePod and UndeployService.
+func (h *Handler) SetApprovalGate(g approvalGate) {
+	h.approvals = g
+}
+
+// awaitApproval — aksiyon için cluster onay politikası varsa bekleyen istek oluşturur ve yanıtı yazar.
+// true dönerse işlem çalıştırılmamalıdır; onaylandığında approvals servisi çalıştırır.
+func (h *Handler) awaitApproval(c *gin.Context, action, target string) bool {
+	if h.approvals == nil {
+		return false
+	}
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return true
+	}
+	approvalID, err := h.approvals.Submit(c.Request.Context(), nil, userID, action, target, nil)
+	if err != nil {
+		response.InternalError(c, "onay isteği oluşturulamadı")
+		return true
+	}
+	if approvalID == nil {
+		return false
+	}
+	audit.Annotate(c, "approval_id", approvalID)
+	response.Success(c, gin.H{
+		"approval_id": approvalID,
+		"status":      "pending_approval",
+	})
+	return true
+}
+
 // k8sCheck — K8s client'in mevcut olup olmadığını kontrol eder.
 func (h *Handler) k8sCheck(c *gin.Context) bool {
 	if h.client == nil {
@@ -135,11 +176,15 @@ func (h *Handler) ScaleDeployment(c *gin.Context) {
 		return
 	}
 
+	if before, err := h.client.GetDeployment(c.Request.Context(), name); err == nil {
+		audit.Before(c, before)
+	}
 	result, err := h.client.ScaleDeployment(c.Request.Context(), name, req.Replicas)
 	if err != nil {
 		response.InternalError(c, err.Error())
 		return
 	}
+	audit.After(c, result)
 
 	response.Success(c, result)
 }
@@ -192,11 +237,15 @@ func (h *Handler) CreateOrUpdateHPA(c *gin.Context) {
 		req.CPUTargetPercent = 70
 	}
 
+	if before, err := h.client.GetHPA(c.Request.Context(), req.DeploymentName); err == nil {
+		audit.Before(c, before)
+	}
 	hpa, err := h.client.CreateOrUpdateHPA(c.Request.Context(), req.DeploymentName, req.MinReplicas, req.MaxReplicas, req.CPUTargetPercent)
 	if err != nil {
 		response.InternalError(c, err.Error())
 		return
 	}
+	audit.After(c, hpa)
 
 	response.Success(c, hpa)
 }
@@ -214,6 +263,9 @@ func (h *Handler) DeleteHPA(c *gin.Context) {
 		return
 	}
 
+	if before, err := h.client.GetHPA(c.Request.Context(), name); err == nil {
+		audit.Before(c, before)
+	}
 	if err := h.client.DeleteHPA(c.Request.Context(), name); err != nil {
 		response.InternalError(c, err.Error())
 		return
@@ -368,6 +420,10 @@ func (h *Handler) DeletePod(c *gin.Context) {
 		return
 	}
 
+	if h.awaitApproval(c, "k8s_delete_pod", name) {
+		return
+	}
+
 	if err := h.client.DeletePod(c.Request.Context(), name); err != nil {
 		if errors.Is(err, ErrNotFound) {
 			response.Error(c, http.StatusNotFound, "pod bulunamadı: "+name)
@@ -460,11 +516,13 @@ func (h *Handler) DeployService(c *gin.Context) {
 	if req.Replicas <= 0 {
 		req.Replicas = 1
 	}
+	audit.Annotate(c, "request", req)
 	slug, err := h.client.DeployService(c.Request.Context(), req.Name, req.Image, req.Port, req.Replicas)
 	if err != nil {
 		response.InternalError(c, err.Error())
 		return
 	}
+	audit.Annotate(c, "k8s_name", slug)
 	response.Success(c, gin.H{
 		"message":  req.Name + " başarıyla K8s'e dağıtıldı",
 		"k8s_name": slug,
@@ -482,6 +540,9 @@ func (h *Handler) UndeployService(c *gin.Context) {
 		response.BadRequest(c, "servis adı gerekli")
 		return
 	}
+	if h.awaitApproval(c, "k8s_undeploy", name) {
+		return
+	}
 	if err := h.client.UndeployService(c.Request.Context(), name); err != nil {
 		response.InternalError(c, err.Error())
 		return
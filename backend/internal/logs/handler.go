@@ -0,0 +1,148 @@
+package logs
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"nanonet-backend/internal/ws"
+	"nanonet-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Search — GET /services/:id/logs
+// Saklanan agent log satırlarını yeniden eskiye döndürür. Filtreler: from/to (RFC3339, varsayılan
+// son 1 saat), level ve stream (virgülle ayrılmış), agent_id, q (büyük/küçük harf duyarsız alt
+// dizge), regex (POSIX regex) ve limit. Sonraki sayfa için to=next_before ve
+// before_seq=next_before_seq verilir.
+func (h *Handler) Search(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz servis ID")
+		return
+	}
+
+	owner, err := h.service.IsServiceOwner(c.Request.Context(), serviceID, userID)
+	if err != nil {
+		response.InternalError(c, "servis doğrulanamadı")
+		return
+	}
+	if !owner {
+		response.NotFound(c, "servis bulunamadı")
+		return
+	}
+
+	q, err := parseSearchQuery(c.Request.URL.Query(), time.Now())
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	q.ServiceID = serviceID
+
+	result, err := h.service.Search(c.Request.Context(), q)
+	if errors.Is(err, ErrInvalidQuery) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		response.InternalError(c, "loglar alınamadı")
+		return
+	}
+	response.Success(c, result)
+}
+
+func parseSearchQuery(v url.Values, now time.Time) (SearchQuery, error) {
+	q := SearchQuery{To: now, Limit: DefaultSearchLimit}
+
+	var err error
+	if s := v.Get("to"); s != "" {
+		if q.To, err = time.Parse(time.RFC3339Nano, s); err != nil {
+			return q, fmt.Errorf("%w: to RFC3339 biçiminde olmalı", ErrInvalidQuery)
+		}
+	}
+	q.From = q.To.Add(-DefaultSearchRange)
+	if s := v.Get("from"); s != "" {
+		if q.From, err = time.Parse(time.RFC3339Nano, s); err != nil {
+			return q, fmt.Errorf("%w: from RFC3339 biçiminde olmalı", ErrInvalidQuery)
+		}
+	}
+	if !q.From.Before(q.To) {
+		return q, fmt.Errorf("%w: from, to'dan önce olmalı", ErrInvalidQuery)
+	}
+	if q.To.Sub(q.From) > MaxSearchRange {
+		return q, fmt.Errorf("%w: zaman aralığı en fazla 14 gün olabilir", ErrInvalidQuery)
+	}
+
+	// Seviye doğrulaması canlı takiple aynı kurallara uyar. Regex aramayı çalıştıran Postgres
+	// tarafından doğrulanır (Service.Search); burada yalnızca uzunluk denetlenir.
+	filter, err := ws.ParseLogFilter(v.Get("level"), "", "")
+	if err != nil {
+		return q, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+	}
+	q.Levels = filter.Levels
+	q.Regex = v.Get("regex")
+	if len(q.Regex) > ws.MaxLogPatternLen {
+		return q, fmt.Errorf("%w: regex en fazla %d karakter olabilir", ErrInvalidQuery, ws.MaxLogPatternLen)
+	}
+	q.Contains = v.Get("q")
+	if len(q.Contains) > 200 {
+		return q, fmt.Errorf("%w: q en fazla 200 karakter olabilir", ErrInvalidQuery)
+	}
+
+	for _, s := range strings.Split(v.Get("stream"), ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !contains(ws.LogStreams, s) {
+			return q, fmt.Errorf("%w: bilinmeyen stream: %s", ErrInvalidQuery, s)
+		}
+		q.Streams = append(q.Streams, s)
+	}
+	q.AgentID = v.Get("agent_id")
+
+	if s := v.Get("before_seq"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n <= 0 {
+			return q, fmt.Errorf("%w: before_seq pozitif bir sayı olmalı", ErrInvalidQuery)
+		}
+		q.BeforeSeq = n
+	}
+
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			return q, fmt.Errorf("%w: limit pozitif bir sayı olmalı", ErrInvalidQuery)
+		}
+		q.Limit = min(n, MaxSearchLimit)
+	}
+	return q, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	q, err := parseSearchQuery(url.Values{}, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-time.Hour), q.From)
+	assert.Equal(t, now, q.To)
+	assert.Equal(t, DefaultSearchLimit, q.Limit)
+
+	q, err = parseSearchQuery(url.Values{
+		"from":   {"2026-03-01T00:00:00Z"},
+		"to":     {"2026-03-01T06:00:00Z"},
+		"level":  {"warning,error"},
+		"stream": {"stderr"},
+		"q":      {"50%_off"},
+		"regex":  {`user=\d+`},
+		"limit":  {"5000"},
+	}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"warn", "error"}, q.Levels)
+	assert.Equal(t, []string{"stderr"}, q.Streams)
+	assert.Equal(t, MaxSearchLimit, q.Limit)
+	assert.Equal(t, `50\%\_off`, escapeLike(q.Contains))
+
+	q, err = parseSearchQuery(url.Values{"regex": {`[[:alpha:]]+\y`}, "before_seq": {"42"}}, now)
+	require.NoError(t, err, "Postgres'e özgü regex sözdizimi Go ile reddedilmemeli")
+	assert.Equal(t, int64(42), q.BeforeSeq)
+
+	for _, bad := range []url.Values{
+		{"from": {"dün"}},
+		{"from": {"2026-03-01T13:00:00Z"}},
+		{"from": {"2026-01-01T00:00:00Z"}},
+		{"level": {"loud"}},
+		{"stream": {"syslog"}},
+		{"regex": {strings.Repeat("a", 257)}},
+		{"before_seq": {"0"}},
+		{"limit": {"-1"}},
+	} {
+		_, err := parseSearchQuery(bad, now)
+		assert.ErrorIs(t, err, ErrInvalidQuery, "%v", bad)
+	}
+}
+
+func TestPaginate_CursorIncludesSeq(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Time: t0, Seq: 9},
+		{Time: t0, Seq: 8},
+		{Time: t0, Seq: 7},
+		{Time: t0, Seq: 6},
+	}
+
+	res := paginate(entries, 3)
+	require.Len(t, res.Logs, 3, "tamamı aynı zaman damgalı sayfa da ilerleyebilmeli")
+	require.NotNil(t, res.NextBefore)
+	require.NotNil(t, res.NextBeforeSeq)
+	assert.Equal(t, t0, *res.NextBefore)
+	assert.Equal(t, int64(7), *res.NextBeforeSeq)
+
+	res = paginate(entries[:2], 3)
+	assert.Len(t, res.Logs, 2)
+	assert.Nil(t, res.NextBefore)
+	assert.Nil(t, res.NextBeforeSeq)
+}
+
+func TestInsertSplitting_IsolatesBadRows(t *testing.T) {
+	errBad := errors.New("invalid byte sequence")
+	entries := make([]Entry, 7)
+	entries[4].Message = "bad"
+
+	var stored []Entry
+	insert := func(_ context.Context, batch []Entry) error {
+		for _, e := range batch {
+			if e.Message == "bad" {
+				return errBad
+			}
+		}
+		stored = append(stored, batch...)
+		return nil
+	}
+	dropped, err := insertSplitting(context.Background(), entries, insert, func(error) bool { return true })
+	assert.Equal(t, 1, dropped)
+	assert.ErrorIs(t, err, errBad)
+	assert.Len(t, stored, 6, "bozuk satır dışındaki tüm satırlar yazılmalı")
+
+	calls := 0
+	dropped, _ = insertSplitting(context.Background(), entries, func(context.Context, []Entry) error {
+		calls++
+		return errors.New("connection refused")
+	}, func(error) bool { return false })
+	assert.Equal(t, 7, dropped)
+	assert.Equal(t, 1, calls, "bağlantı hatasında grup bölünmemeli")
+}
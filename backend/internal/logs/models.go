@@ -0,0 +1,47 @@
+package logs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entry — service_logs hypertable'ındaki tek log satırı.
+type Entry struct {
+	Time      time.Time `gorm:"not null" json:"time"`
+	ServiceID uuid.UUID `gorm:"type:uuid;not null" json:"service_id"`
+	AgentID   string    `gorm:"type:varchar(100);not null" json:"agent_id"`
+	Stream    string    `gorm:"type:varchar(10);not null" json:"stream"`
+	Level     string    `gorm:"type:varchar(10);not null" json:"level"`
+	Message   string    `gorm:"not null" json:"message"`
+	Path      *string   `json:"path,omitempty"`
+	// Seq — aynı zaman damgasını paylaşan satırları sıralayan artan sayı (veritabanı atar).
+	Seq int64 `gorm:"->" json:"seq"`
+}
+
+func (Entry) TableName() string { return "service_logs" }
+
+// SearchQuery — log araması filtreleri. Sonuçlar yeniden eskiye sıralanır; sonraki sayfa için
+// To ve BeforeSeq, önceki sayfanın NextBefore ve NextBeforeSeq değerleriyle verilir.
+type SearchQuery struct {
+	ServiceID uuid.UUID
+	From      time.Time
+	To        time.Time
+	Levels    []string
+	Streams   []string
+	AgentID   string
+	Contains  string
+	Regex     string
+	Limit     int
+
+	// BeforeSeq — 0 değilse To anındaki satırlardan yalnızca seq değeri bundan küçük olanlar döner.
+	BeforeSeq int64
+}
+
+type SearchResult struct {
+	Logs []Entry `json:"logs"`
+	// NextBefore/NextBeforeSeq — daha eski satırlar varsa bir sonraki sayfanın "to" ve
+	// "before_seq" değerleri.
+	NextBefore    *time.Time `json:"next_before,omitempty"`
+	NextBeforeSeq *int64     `json:"next_before_seq,omitempty"`
+}
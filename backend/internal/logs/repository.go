@@ -0,0 +1,110 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"nanonet-backend/internal/orgs"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) InsertBatch(ctx context.Context, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).CreateInBatches(entries, 500).Error
+}
+
+// Search — filtrelere uyan en fazla q.Limit+1 satırı yeniden eskiye döndürür; fazladan satır
+// bir sonraki sayfanın varlığını anlamak için kullanılır.
+func (r *Repository) Search(ctx context.Context, q SearchQuery) ([]Entry, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx := r.db.WithContext(ctx).Where("service_id = ? AND time >= ?", q.ServiceID, q.From)
+	if q.BeforeSeq > 0 {
+		tx = tx.Where("(time < ? OR (time = ? AND seq < ?))", q.To, q.To, q.BeforeSeq)
+	} else {
+		tx = tx.Where("time < ?", q.To)
+	}
+	if len(q.Levels) > 0 {
+		tx = tx.Where("level IN ?", q.Levels)
+	}
+	if len(q.Streams) > 0 {
+		tx = tx.Where("stream IN ?", q.Streams)
+	}
+	if q.AgentID != "" {
+		tx = tx.Where("agent_id = ?", q.AgentID)
+	}
+	if q.Contains != "" {
+		tx = tx.Where("message ILIKE ?", "%"+escapeLike(q.Contains)+"%")
+	}
+	if q.Regex != "" {
+		tx = tx.Where("message ~ ?", q.Regex)
+	}
+
+	var entries []Entry
+	err := tx.Order("time DESC, seq DESC").Limit(q.Limit + 1).Find(&entries).Error
+	return entries, err
+}
+
+// ValidateRegex — deseni aramayı çalıştıracak olan Postgres regex motoruyla derler. Go regexp ile
+// Postgres ARE sözdizimi farklı olduğu için doğrulama sorgunun çalıştığı yerde yapılır.
+func (r *Repository) ValidateRegex(ctx context.Context, pattern string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := r.db.WithContext(ctx).Exec("SELECT '' ~ ?", pattern).Error
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgInvalidRegex {
+		return fmt.Errorf("%w: geçersiz regex: %s", ErrInvalidQuery, pgErr.Message)
+	}
+	return err
+}
+
+// IsServiceOwner — kullanıcının servise en az viewer rolüyle erişebildiğini bildirir
+// (organizasyon üyeliği veya takım ataması).
+func (r *Repository) IsServiceOwner(ctx context.Context, serviceID, userID uuid.UUID) (bool, error) {
+	return orgs.HasServiceRole(ctx, r.db, serviceID, userID, orgs.RoleViewer)
+}
+
+const (
+	// pgInvalidRegex — Postgres invalid_regular_expression hata kodu.
+	pgInvalidRegex = "2201B"
+)
+
+func pgErrorCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// rowError — hatanın tek tek satırlardaki veriden kaynaklandığını bildirir (data exception ve
+// integrity constraint sınıfları); bağlantı veya zaman aşımı hatalarında false döner.
+func rowError(err error) bool {
+	code := pgErrorCode(err)
+	return strings.HasPrefix(code, "22") || strings.HasPrefix(code, "23")
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,173 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"nanonet-backend/internal/ws"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// ingestQueueSize — yazılmayı bekleyen en fazla satır grubu; dolunca yeni gruplar atılır.
+	ingestQueueSize = 1024
+	flushInterval   = time.Second
+	flushRows       = 2000
+
+	DefaultSearchLimit = 200
+	MaxSearchLimit     = 1000
+	DefaultSearchRange = time.Hour
+	// MaxSearchRange — saklama süresiyle (14 gün) aynıdır.
+	MaxSearchRange = 14 * 24 * time.Hour
+)
+
+var ErrInvalidQuery = errors.New("geçersiz log sorgusu")
+
+// Service — agent log satırlarını toplu olarak saklar ve aramayı yönetir. Satırlar agent'ın
+// okuma döngüsünü veritabanına bağlamamak için kuyruktan arka planda yazılır.
+type Service struct {
+	repo  *Repository
+	hub   *ws.Hub
+	queue chan []Entry
+}
+
+func NewService(db *gorm.DB, hub *ws.Hub) *Service {
+	return &Service{
+		repo:  NewRepository(db),
+		hub:   hub,
+		queue: make(chan []Entry, ingestQueueSize),
+	}
+}
+
+// Attach — hub'dan gelen log satırlarını saklama kuyruğuna bağlar.
+func (s *Service) Attach() {
+	s.hub.SetOnLogs(s.enqueue)
+}
+
+func (s *Service) enqueue(sess ws.AgentSession, lines []ws.LogLine) {
+	serviceID, err := uuid.Parse(sess.ServiceID)
+	if err != nil {
+		return
+	}
+	entries := make([]Entry, 0, len(lines))
+	for _, l := range lines {
+		ts, err := time.Parse(time.RFC3339Nano, l.TS)
+		if err != nil {
+			continue
+		}
+		e := Entry{
+			Time:      ts,
+			ServiceID: serviceID,
+			AgentID:   sess.AgentID,
+			Stream:    l.Stream,
+			Level:     l.Level,
+			Message:   l.Message,
+		}
+		if l.Path != "" {
+			path := l.Path
+			e.Path = &path
+		}
+		entries = append(entries, e)
+	}
+
+	select {
+	case s.queue <- entries:
+	default:
+		log.Printf("[WARN] Log kuyruğu dolu, %d satır atlandı (service: %s, agent: %s)", len(entries), sess.ServiceID, sess.AgentID)
+	}
+}
+
+// Run — kuyruktaki satırları flushInterval aralıklarla veya flushRows satıra ulaşınca yazar.
+// ctx iptal edildiğinde kalan satırları yazıp döner.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var pending []Entry
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if dropped, err := insertSplitting(context.Background(), pending, s.repo.InsertBatch, rowError); dropped > 0 {
+			log.Printf("Log satırları kaydedilemedi (%d/%d satır): %v", dropped, len(pending), err)
+		}
+		pending = nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case entries := <-s.queue:
+					pending = append(pending, entries...)
+				default:
+					flush()
+					return
+				}
+			}
+		case entries := <-s.queue:
+			pending = append(pending, entries...)
+			if len(pending) >= flushRows {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// insertSplitting — satırları yazar; satır verisinden kaynaklanan bir hatada (splittable) grubu
+// ikiye bölerek yeniden dener, böylece tek bir bozuk satır tüm grubun kaybolmasına yol açmaz.
+// Yazılamayan satır sayısını ve son hatayı döndürür. Bağlantı hatalarında bölmeden vazgeçilir.
+func insertSplitting(ctx context.Context, entries []Entry, insert func(context.Context, []Entry) error, splittable func(error) bool) (int, error) {
+	err := insert(ctx, entries)
+	if err == nil {
+		return 0, nil
+	}
+	if len(entries) == 1 || !splittable(err) {
+		return len(entries), err
+	}
+	mid := len(entries) / 2
+	droppedA, errA := insertSplitting(ctx, entries[:mid], insert, splittable)
+	droppedB, errB := insertSplitting(ctx, entries[mid:], insert, splittable)
+	if errB == nil {
+		errB = errA
+	}
+	return droppedA + droppedB, errB
+}
+
+func (s *Service) IsServiceOwner(ctx context.Context, serviceID, userID uuid.UUID) (bool, error) {
+	return s.repo.IsServiceOwner(ctx, serviceID, userID)
+}
+
+// Search — regex verilmişse önce Postgres ile doğrular; geçersiz desen ErrInvalidQuery döner.
+func (s *Service) Search(ctx context.Context, q SearchQuery) (*SearchResult, error) {
+	if q.Regex != "" {
+		if err := s.repo.ValidateRegex(ctx, q.Regex); err != nil {
+			return nil, err
+		}
+	}
+	entries, err := s.repo.Search(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return paginate(entries, q.Limit), nil
+}
+
+// paginate — Limit+1 satırlık sonuçtan sayfayı ve sonraki sayfanın (time, seq) imlecini üretir.
+// Sonraki sayfa "(time, seq) < imleç" ile çekildiği için aynı zaman damgasını paylaşan satırlar
+// sayfa sınırında bölünse de atlanmaz.
+func paginate(entries []Entry, limit int) *SearchResult {
+	if len(entries) <= limit {
+		return &SearchResult{Logs: entries}
+	}
+	page := entries[:limit]
+	last := page[len(page)-1]
+	next, seq := last.Time, last.Seq
+	return &SearchResult{Logs: page, NextBefore: &next, NextBeforeSeq: &seq}
+}
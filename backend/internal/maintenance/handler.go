@@ -3,6 +3,7 @@ package maintenance
 import (
 	"time"
 
+	"nanonet-backend/pkg/audit"
 	"nanonet-backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -97,6 +98,7 @@ func (h *Handler) Create(c *gin.Context) {
 		response.InternalError(c, "bakım penceresi oluşturulamadı")
 		return
 	}
+	audit.After(c, w)
 
 	response.Created(c, w)
 }
@@ -126,6 +128,9 @@ func (h *Handler) Delete(c *gin.Context) {
 		return
 	}
 
+	if before, err := h.repo.Get(c.Request.Context(), windowID, serviceID); err == nil {
+		audit.Before(c, before)
+	}
 	if err := h.repo.Delete(c.Request.Context(), windowID, serviceID); err != nil {
 		response.NotFound(c, "bakım penceresi bulunamadı")
 		return
@@ -4,6 +4,8 @@ import (
 	"context"
 	"time"
 
+	"nanonet-backend/internal/orgs"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -43,6 +45,22 @@ func (r *Repository) List(ctx context.Context, serviceID uuid.UUID) ([]Maintenan
 	return windows, err
 }
 
+// Get returns a maintenance window by ID, scoped to the given service.
+func (r *Repository) Get(ctx context.Context, id, serviceID uuid.UUID) (*MaintenanceWindow, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var w MaintenanceWindow
+	err := r.db.WithContext(ctx).
+		Table("maintenance_windows").
+		Where("id = ? AND service_id = ?", id, serviceID).
+		Take(&w).Error
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
 // Create inserts a new maintenance window.
 func (r *Repository) Create(ctx context.Context, w *MaintenanceWindow) error {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
@@ -69,15 +87,9 @@ func (r *Repository) Delete(ctx context.Context, id, serviceID uuid.UUID) error
 	return nil
 }
 
-// IsServiceOwner checks ownership via the services table.
+// IsServiceOwner — kullanıcının servise en az viewer rolüyle erişebildiğini bildirir
+// (organizasyon üyeliği veya takım ataması).
 func (r *Repository) IsServiceOwner(ctx context.Context, serviceID, userID uuid.UUID) bool {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	var count int64
-	r.db.WithContext(ctx).
-		Table("services").
-		Where("id = ? AND user_id = ?", serviceID, userID).
-		Count(&count)
-	return count > 0
+	ok, err := orgs.HasServiceRole(ctx, r.db, serviceID, userID, orgs.RoleViewer)
+	return err == nil && ok
 }
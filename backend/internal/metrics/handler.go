@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"nanonet-backend/internal/orgs"
 	"nanonet-backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -24,14 +25,11 @@ func NewHandler(db *gorm.DB) *Handler {
 	}
 }
 
-// checkServiceOwnership servisin kimlik doğrulama yapan kullanıcıya ait olup olmadığını kontrol eder.
+// checkServiceOwnership kimlik doğrulama yapan kullanıcının servise en az viewer rolüyle
+// erişebildiğini kontrol eder.
 func (h *Handler) checkServiceOwnership(ctx context.Context, serviceID, userID uuid.UUID) bool {
-	var count int64
-	h.db.WithContext(ctx).
-		Table("services").
-		Where("id = ? AND user_id = ?", serviceID, userID).
-		Count(&count)
-	return count > 0
+	ok, err := orgs.HasServiceRole(ctx, h.db, serviceID, userID, orgs.RoleViewer)
+	return err == nil && ok
 }
 
 func (h *Handler) GetHistory(c *gin.Context) {
@@ -161,8 +159,8 @@ func (h *Handler) InsertMetric(c *gin.Context) {
 		return
 	}
 
-	// Servisin var olduğunu VE mevcut kullanıcıya ait olduğunu doğrula
-	if !h.checkServiceOwnership(c.Request.Context(), metric.ServiceID, userID) {
+	// Servisin var olduğunu VE kullanıcının servise yazabildiğini (operator) doğrula
+	if ok, err := orgs.HasServiceRole(c.Request.Context(), h.db, metric.ServiceID, userID, orgs.RoleOperator); err != nil || !ok {
 		response.NotFound(c, "servis bulunamadı")
 		return
 	}
@@ -314,13 +312,15 @@ func (h *Handler) GetBulkUptime(c *gin.Context) {
 		return
 	}
 
-	// Kullanıcının sahip olduğu tüm servis ID'lerini çek
+	// Kullanıcının erişebildiği tüm servis ID'lerini çek
 	type serviceRow struct {
 		ID uuid.UUID `gorm:"column:id"`
 	}
 	var rows []serviceRow
 	if err := h.db.WithContext(c.Request.Context()).
-		Raw("SELECT id FROM services WHERE user_id = ?", userID).
+		Table("services").
+		Select("id").
+		Where("id IN (?)", orgs.AccessibleServices(h.db, userID, orgs.RoleViewer)).
 		Scan(&rows).Error; err != nil {
 		response.InternalError(c, "servis listesi alınamadı")
 		return
@@ -0,0 +1,288 @@
+package orgs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"nanonet-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ServiceRoleKey — RequireServiceRole'ün kullanıcının servis rolünü yazdığı gin context anahtarı.
+const ServiceRoleKey = "service_role"
+
+// ErrTwoFactorRequired — organizasyon iki adımlı doğrulama istiyor ama kullanıcıda etkin değil.
+var ErrTwoFactorRequired = errors.New("bu organizasyonda bu işlem için iki adımlı doğrulama gerekli")
+
+// AccessibleServices — kullanıcının en az need rolüne sahip olduğu servislerin ID'lerini veren
+// alt sorgu. Servis sorgularında Where("id IN (?)", ...) ile kullanılır. Operator ve üstü
+// işlemlerde iki adımlı doğrulama isteyen organizasyonların servisleri, kullanıcıda iki adımlı
+// doğrulama yoksa dışarıda kalır.
+func AccessibleServices(db *gorm.DB, userID uuid.UUID, need string) *gorm.DB {
+	q := db.Table("service_access").
+		Select("service_id").
+		Where("user_id = ? AND role_rank >= ?", userID, RoleRank(need))
+	if needsTwoFactor(need) {
+		q = q.Where("service_id NOT IN (?)", twoFactorBlocked(db, userID))
+	}
+	return q
+}
+
+// needsTwoFactor — rolün iki adımlı doğrulama zorunluluğuna tabi olup olmadığı.
+func needsTwoFactor(need string) bool {
+	return RoleRank(need) >= RoleRank(RoleOperator)
+}
+
+// twoFactorBlocked — kullanıcının iki adımlı doğrulaması olmadığı için operator işlemi
+// yapamayacağı servisler.
+func twoFactorBlocked(db *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return db.Table("services s").
+		Select("s.id").
+		Joins("JOIN organizations o ON o.id = s.org_id").
+		Where("o.require_two_factor AND EXISTS (SELECT 1 FROM users u WHERE u.id = ? AND u.totp_enabled_at IS NULL)", userID)
+}
+
+// TwoFactorMissing — serviceID veya orgID'den verilenin organizasyonu iki adımlı doğrulama
+// istiyor ve kullanıcıda etkin değilse true.
+func TwoFactorMissing(ctx context.Context, db *gorm.DB, serviceID, orgID, userID uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	q := db.WithContext(ctx).Table("organizations o").
+		Joins("JOIN users u ON u.id = ?", userID).
+		Where("o.require_two_factor AND u.totp_enabled_at IS NULL")
+	if serviceID != uuid.Nil {
+		q = q.Where("o.id = (SELECT org_id FROM services WHERE id = ?)", serviceID)
+	} else {
+		q = q.Where("o.id = ?", orgID)
+	}
+	var count int64
+	err := q.Count(&count).Error
+	return count > 0, err
+}
+
+// ServiceRole — kullanıcının servis üzerindeki en yüksek rolü; erişimi yoksa (veya servis
+// yoksa) "" döner.
+func ServiceRole(ctx context.Context, db *gorm.DB, serviceID, userID uuid.UUID) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var roles []string
+	err := db.WithContext(ctx).
+		Table("service_access").
+		Where("service_id = ? AND user_id = ?", serviceID, userID).
+		Order("role_rank DESC").
+		Limit(1).
+		Pluck("role", &roles).Error
+	if err != nil || len(roles) == 0 {
+		return "", err
+	}
+	return roles[0], nil
+}
+
+// HasServiceRole — kullanıcının servis üzerinde en az need rolüne sahip olduğunu bildirir.
+// Operator ve üstü için organizasyonun iki adımlı doğrulama zorunluluğu da uygulanır.
+func HasServiceRole(ctx context.Context, db *gorm.DB, serviceID, userID uuid.UUID, need string) (bool, error) {
+	role, err := ServiceRole(ctx, db, serviceID, userID)
+	if err != nil || !RoleAllows(role, need) {
+		return false, err
+	}
+	if needsTwoFactor(need) {
+		missing, err := TwoFactorMissing(ctx, db, serviceID, uuid.Nil, userID)
+		if err != nil || missing {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// OrgRole — kullanıcının organizasyondaki rolü; üye değilse "".
+func OrgRole(ctx context.Context, db *gorm.DB, orgID, userID uuid.UUID) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var m Member
+	err := db.WithContext(ctx).
+		Where("org_id = ? AND user_id = ?", orgID, userID).
+		First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	return m.Role, err
+}
+
+// HasOrgRole — kullanıcının organizasyonda en az need rolüne sahip olduğunu bildirir; orgID
+// uuid.Nil ise false döner. Operator ve üstü için iki adımlı doğrulama zorunluluğu da uygulanır.
+func HasOrgRole(ctx context.Context, db *gorm.DB, orgID, userID uuid.UUID, need string) (bool, error) {
+	if orgID == uuid.Nil {
+		return false, nil
+	}
+	role, err := OrgRole(ctx, db, orgID, userID)
+	if err != nil || !RoleAllows(role, need) {
+		return false, err
+	}
+	if needsTwoFactor(need) {
+		missing, err := TwoFactorMissing(ctx, db, uuid.Nil, orgID, userID)
+		if err != nil || missing {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// CanManageUser — actor'ün, hedef kullanıcının da üyesi olduğu kişisel olmayan bir organizasyonda
+// admin veya owner olduğunu ve hedefin rolünün kendisininkinden düşük olduğunu bildirir (owner
+// herkesi yönetebilir). Hesap düzeyindeki yönetici işlemleri (ör. 2FA sıfırlama) için kullanılır.
+func CanManageUser(ctx context.Context, db *gorm.DB, actorID, targetID uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var count int64
+	err := db.WithContext(ctx).Table("org_members a").
+		Joins("JOIN org_members t ON t.org_id = a.org_id AND t.user_id = ?", targetID).
+		Joins("JOIN organizations o ON o.id = a.org_id AND NOT o.personal").
+		Where("a.user_id = ? AND a.role IN ?", actorID, []string{RoleAdmin, RoleOwner}).
+		Where("a.role = ? OR t.role NOT IN ?", RoleOwner, []string{RoleAdmin, RoleOwner}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Authorizer —servis ve organizasyon rollerini denetleyen tek yetkilendirme katmanı.
+// Route'lara middleware olarak bağlanır; paketler aynı kuralları AccessibleServices ve
+// HasServiceRole ile sorgularında uygular.
+type Authorizer struct {
+	db *gorm.DB
+}
+
+func NewAuthorizer(db *gorm.DB) *Authorizer {
+	return &Authorizer{db: db}
+}
+
+// ServiceRole — services.ServiceRoleResolver; kullanıcının servis üzerindeki rolü.
+func (a *Authorizer) ServiceRole(ctx context.Context, serviceID, userID uuid.UUID) (string, error) {
+	return ServiceRole(ctx, a.db, serviceID, userID)
+}
+
+// RequireServiceRole — :id parametresindeki servis için en az need rolünü şart koşar. Erişimi
+// olmayan kullanıcıya servis yokmuş gibi 404, rolü yetmeyene 403 döner.
+func (a *Authorizer) RequireServiceRole(need string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.GetString("user_id"))
+		if err != nil {
+			response.Unauthorized(c, "geçersiz kullanıcı")
+			c.Abort()
+			return
+		}
+		serviceID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			response.BadRequest(c, "geçersiz servis ID")
+			c.Abort()
+			return
+		}
+
+		role, err := ServiceRole(c.Request.Context(), a.db, serviceID, userID)
+		if err != nil {
+			response.InternalError(c, "yetki doğrulanamadı")
+			c.Abort()
+			return
+		}
+		if role == "" {
+			response.NotFound(c, "servis bulunamadı")
+			c.Abort()
+			return
+		}
+		if !RoleAllows(role, need) {
+			response.Forbidden(c, fmt.Sprintf("bu işlem için en az %s rolü gerekli", need))
+			c.Abort()
+			return
+		}
+		if !a.checkTwoFactor(c, serviceID, uuid.Nil, userID, need) {
+			return
+		}
+		c.Set(ServiceRoleKey, role)
+		c.Next()
+	}
+}
+
+// RequireOrgRole — sabit bir organizasyonda (örn. Kubernetes cluster'ının sahibi) en az need
+// rolünü şart koşar. orgID uuid.Nil ise (organizasyon yapılandırılmamış) istek reddedilir.
+func (a *Authorizer) RequireOrgRole(orgID uuid.UUID, need string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if orgID == uuid.Nil {
+			response.Forbidden(c, "bu uçlar için yetkili organizasyon yapılandırılmamış")
+			c.Abort()
+			return
+		}
+		userID, err := uuid.Parse(c.GetString("user_id"))
+		if err != nil {
+			response.Unauthorized(c, "geçersiz kullanıcı")
+			c.Abort()
+			return
+		}
+		role, err := OrgRole(c.Request.Context(), a.db, orgID, userID)
+		if err != nil {
+			response.InternalError(c, "yetki doğrulanamadı")
+			c.Abort()
+			return
+		}
+		if !RoleAllows(role, need) {
+			response.Forbidden(c, fmt.Sprintf("bu işlem için organizasyonda en az %s rolü gerekli", need))
+			c.Abort()
+			return
+		}
+		if !a.checkTwoFactor(c, uuid.Nil, orgID, userID, need) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// checkTwoFactor — operator ve üstü işlemlerde organizasyonun iki adımlı doğrulama zorunluluğunu
+// uygular; engellenen istek için yanıtı yazar ve false döner.
+func (a *Authorizer) checkTwoFactor(c *gin.Context, serviceID, orgID, userID uuid.UUID, need string) bool {
+	if !needsTwoFactor(need) {
+		return true
+	}
+	missing, err := TwoFactorMissing(c.Request.Context(), a.db, serviceID, orgID, userID)
+	if err != nil {
+		response.InternalError(c, "yetki doğrulanamadı")
+		c.Abort()
+		return false
+	}
+	if missing {
+		response.Forbidden(c, ErrTwoFactorRequired.Error())
+		c.Abort()
+		return false
+	}
+	return true
+}
+
+// OrgAuditor — organizasyonun admin ve üstü üyelerine tüm kullanıcıların audit kayıtlarını açar
+// (audit.Auditor).
+type OrgAuditor struct {
+	db    *gorm.DB
+	orgID uuid.UUID
+}
+
+func NewOrgAuditor(db *gorm.DB, orgID uuid.UUID) *OrgAuditor {
+	return &OrgAuditor{db: db, orgID: orgID}
+}
+
+// CanAuditAll — kullanıcının organizasyondaki rolü en az admin ise ve organizasyon istiyorsa
+// iki adımlı doğrulaması etkinse true.
+func (a *OrgAuditor) CanAuditAll(ctx context.Context, userID uuid.UUID) (bool, error) {
+	role, err := OrgRole(ctx, a.db, a.orgID, userID)
+	if err != nil || !RoleAllows(role, RoleAdmin) {
+		return false, err
+	}
+	missing, err := TwoFactorMissing(ctx, a.db, uuid.Nil, a.orgID, userID)
+	if err != nil {
+		return false, err
+	}
+	return !missing, nil
+}
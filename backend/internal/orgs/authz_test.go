@@ -0,0 +1,41 @@
+package orgs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleAllows(t *testing.T) {
+	assert.True(t, RoleAllows(RoleOwner, RoleAdmin))
+	assert.True(t, RoleAllows(RoleAdmin, RoleOperator))
+	assert.True(t, RoleAllows(RoleOperator, RoleOperator))
+	assert.False(t, RoleAllows(RoleViewer, RoleOperator))
+	assert.False(t, RoleAllows("", RoleViewer))
+	assert.False(t, RoleAllows("root", RoleViewer), "bilinmeyen rol hiçbir yetki vermez")
+}
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "platform-ekibi", slugify("Platform Ekibi"))
+	assert.Equal(t, "guvenlik-ops", slugify("  Güvenlik / Ops!  "))
+	assert.Equal(t, "", slugify("---"))
+	assert.Len(t, slugify(strings.Repeat("b", 80)), 60)
+	assert.Equal(t, strings.Repeat("a", 59), slugify(strings.Repeat("a", 59)+" x"), "kesilen slug tire ile bitmez")
+}
+
+func TestRequireOrgRole_FailsClosedWithoutOrg(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user_id", uuid.New().String())
+
+	NewAuthorizer(nil).RequireOrgRole(uuid.Nil, RoleViewer)(c)
+
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,408 @@
+package orgs
+
+import (
+	"errors"
+
+	"nanonet-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// List — GET /orgs
+// Kullanıcının üyesi olduğu organizasyonlar ve her birindeki rolü.
+func (h *Handler) List(c *gin.Context) {
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	orgs, err := h.service.List(c.Request.Context(), userID)
+	if err != nil {
+		response.InternalError(c, "organizasyonlar alınamadı")
+		return
+	}
+	response.Success(c, gin.H{"organizations": orgs, "roles": Roles})
+}
+
+// Create — POST /orgs
+func (h *Handler) Create(c *gin.Context) {
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	var req CreateOrgRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+	org, err := h.service.Create(c.Request.Context(), userID, req)
+	if err != nil {
+		writeError(c, err, "organizasyon oluşturulamadı")
+		return
+	}
+	response.Created(c, org)
+}
+
+// Get — GET /orgs/:orgId
+func (h *Handler) Get(c *gin.Context) {
+	userID, orgID, ok := orgParams(c)
+	if !ok {
+		return
+	}
+	org, err := h.service.Get(c.Request.Context(), orgID, userID)
+	if err != nil {
+		writeError(c, err, "organizasyon alınamadı")
+		return
+	}
+	response.Success(c, org)
+}
+
+// Update — PUT /orgs/:orgId (admin)
+func (h *Handler) Update(c *gin.Context) {
+	userID, orgID, ok := orgParams(c)
+	if !ok {
+		return
+	}
+	var req UpdateOrgRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+	org, err := h.service.Update(c.Request.Context(), orgID, userID, req)
+	if err != nil {
+		writeError(c, err, "organizasyon güncellenemedi")
+		return
+	}
+	response.Success(c, org)
+}
+
+// Delete — DELETE /orgs/:orgId (owner)
+func (h *Handler) Delete(c *gin.Context) {
+	userID, orgID, ok := orgParams(c)
+	if !ok {
+		return
+	}
+	if err := h.service.Delete(c.Request.Context(), orgID, userID); err != nil {
+		writeError(c, err, "organizasyon silinemedi")
+		return
+	}
+	response.Success(c, gin.H{"deleted": true})
+}
+
+// ListMembers — GET /orgs/:orgId/members
+func (h *Handler) ListMembers(c *gin.Context) {
+	userID, orgID, ok := orgParams(c)
+	if !ok {
+		return
+	}
+	members, err := h.service.ListMembers(c.Request.Context(), orgID, userID)
+	if err != nil {
+		writeError(c, err, "üyeler alınamadı")
+		return
+	}
+	response.Success(c, gin.H{"members": members, "total": len(members)})
+}
+
+// AddMember — POST /orgs/:orgId/members (admin)
+func (h *Handler) AddMember(c *gin.Context) {
+	userID, orgID, ok := orgParams(c)
+	if !ok {
+		return
+	}
+	var req AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+	member, err := h.service.AddMember(c.Request.Context(), orgID, userID, req)
+	if err != nil {
+		writeError(c, err, "üye eklenemedi")
+		return
+	}
+	response.Created(c, member)
+}
+
+// UpdateMember — PUT /orgs/:orgId/members/:userId (admin)
+func (h *Handler) UpdateMember(c *gin.Context) {
+	userID, orgID, ok := orgParams(c)
+	if !ok {
+		return
+	}
+	memberID, ok := uuidParam(c, "userId", "geçersiz kullanıcı ID")
+	if !ok {
+		return
+	}
+	var req UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+	if err := h.service.UpdateMember(c.Request.Context(), orgID, userID, memberID, req.Role); err != nil {
+		writeError(c, err, "üye güncellenemedi")
+		return
+	}
+	response.Success(c, gin.H{"user_id": memberID, "role": req.Role})
+}
+
+// RemoveMember — DELETE /orgs/:orgId/members/:userId (admin; kullanıcı kendini çıkarabilir)
+func (h *Handler) RemoveMember(c *gin.Context) {
+	userID, orgID, ok := orgParams(c)
+	if !ok {
+		return
+	}
+	memberID, ok := uuidParam(c, "userId", "geçersiz kullanıcı ID")
+	if !ok {
+		return
+	}
+	if err := h.service.RemoveMember(c.Request.Context(), orgID, userID, memberID); err != nil {
+		writeError(c, err, "üye çıkarılamadı")
+		return
+	}
+	response.Success(c, gin.H{"removed": true})
+}
+
+// ListTeams — GET /orgs/:orgId/teams
+func (h *Handler) ListTeams(c *gin.Context) {
+	userID, orgID, ok := orgParams(c)
+	if !ok {
+		return
+	}
+	teams, err := h.service.ListTeams(c.Request.Context(), orgID, userID)
+	if err != nil {
+		writeError(c, err, "takımlar alınamadı")
+		return
+	}
+	response.Success(c, gin.H{"teams": teams, "roles": TeamRoles})
+}
+
+// CreateTeam — POST /orgs/:orgId/teams (admin)
+func (h *Handler) CreateTeam(c *gin.Context) {
+	userID, orgID, ok := orgParams(c)
+	if !ok {
+		return
+	}
+	var req TeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+	team, err := h.service.CreateTeam(c.Request.Context(), orgID, userID, req)
+	if err != nil {
+		writeError(c, err, "takım oluşturulamadı")
+		return
+	}
+	response.Created(c, team)
+}
+
+// UpdateTeam — PUT /orgs/:orgId/teams/:teamId (admin)
+func (h *Handler) UpdateTeam(c *gin.Context) {
+	userID, orgID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+	var req TeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+	team, err := h.service.UpdateTeam(c.Request.Context(), orgID, teamID, userID, req)
+	if err != nil {
+		writeError(c, err, "takım güncellenemedi")
+		return
+	}
+	response.Success(c, team)
+}
+
+// DeleteTeam — DELETE /orgs/:orgId/teams/:teamId (admin)
+func (h *Handler) DeleteTeam(c *gin.Context) {
+	userID, orgID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteTeam(c.Request.Context(), orgID, teamID, userID); err != nil {
+		writeError(c, err, "takım silinemedi")
+		return
+	}
+	response.Success(c, gin.H{"deleted": true})
+}
+
+// AddTeamMember — POST /orgs/:orgId/teams/:teamId/members (admin)
+func (h *Handler) AddTeamMember(c *gin.Context) {
+	userID, orgID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+	var req TeamMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+	if err := h.service.AddTeamMember(c.Request.Context(), orgID, teamID, userID, req.UserID); err != nil {
+		writeError(c, err, "takım üyesi eklenemedi")
+		return
+	}
+	response.Success(c, gin.H{"team_id": teamID, "user_id": req.UserID})
+}
+
+// RemoveTeamMember — DELETE /orgs/:orgId/teams/:teamId/members/:userId (admin)
+func (h *Handler) RemoveTeamMember(c *gin.Context) {
+	userID, orgID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+	memberID, ok := uuidParam(c, "userId", "geçersiz kullanıcı ID")
+	if !ok {
+		return
+	}
+	if err := h.service.RemoveTeamMember(c.Request.Context(), orgID, teamID, userID, memberID); err != nil {
+		writeError(c, err, "takım üyesi çıkarılamadı")
+		return
+	}
+	response.Success(c, gin.H{"removed": true})
+}
+
+// AddTeamService — POST /orgs/:orgId/teams/:teamId/services (admin)
+func (h *Handler) AddTeamService(c *gin.Context) {
+	userID, orgID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+	var req TeamServiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+	if err := h.service.AddTeamService(c.Request.Context(), orgID, teamID, userID, req.ServiceID); err != nil {
+		writeError(c, err, "servis takıma atanamadı")
+		return
+	}
+	response.Success(c, gin.H{"team_id": teamID, "service_id": req.ServiceID})
+}
+
+// RemoveTeamService — DELETE /orgs/:orgId/teams/:teamId/services/:serviceId (admin)
+func (h *Handler) RemoveTeamService(c *gin.Context) {
+	userID, orgID, teamID, ok := teamParams(c)
+	if !ok {
+		return
+	}
+	serviceID, ok := uuidParam(c, "serviceId", "geçersiz servis ID")
+	if !ok {
+		return
+	}
+	if err := h.service.RemoveTeamService(c.Request.Context(), orgID, teamID, userID, serviceID); err != nil {
+		writeError(c, err, "servis takımdan çıkarılamadı")
+		return
+	}
+	response.Success(c, gin.H{"removed": true})
+}
+
+// ListInvitations — GET /orgs/:orgId/invitations (admin)
+func (h *Handler) ListInvitations(c *gin.Context) {
+	userID, orgID, ok := orgParams(c)
+	if !ok {
+		return
+	}
+	invs, err := h.service.ListInvitations(c.Request.Context(), orgID, userID)
+	if err != nil {
+		writeError(c, err, "davetler alınamadı")
+		return
+	}
+	response.Success(c, gin.H{"invitations": invs, "total": len(invs)})
+}
+
+// CreateInvitation — POST /orgs/:orgId/invitations (admin)
+// E-posta gönderilemezse yanıttaki invite_url bağlantısı davet edilene iletilmelidir.
+func (h *Handler) CreateInvitation(c *gin.Context) {
+	userID, orgID, ok := orgParams(c)
+	if !ok {
+		return
+	}
+	var req InviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+	inv, err := h.service.CreateInvitation(c.Request.Context(), orgID, userID, req)
+	if err != nil {
+		writeError(c, err, "davet gönderilemedi")
+		return
+	}
+	response.Created(c, inv)
+}
+
+// RevokeInvitation — DELETE /orgs/:orgId/invitations/:invitationId (admin)
+func (h *Handler) RevokeInvitation(c *gin.Context) {
+	userID, orgID, ok := orgParams(c)
+	if !ok {
+		return
+	}
+	invitationID, ok := uuidParam(c, "invitationId", "geçersiz davet ID")
+	if !ok {
+		return
+	}
+	if err := h.service.RevokeInvitation(c.Request.Context(), orgID, invitationID, userID); err != nil {
+		writeError(c, err, "davet geri çekilemedi")
+		return
+	}
+	response.Success(c, gin.H{"revoked": true})
+}
+
+func writeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrOrgNotFound), errors.Is(err, ErrTeamNotFound),
+		errors.Is(err, ErrMemberNotFound), errors.Is(err, ErrUserNotFound), errors.Is(err, ErrInvitationNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrForbidden):
+		response.Forbidden(c, err.Error())
+	case errors.Is(err, ErrInvalidOrg), errors.Is(err, ErrSlugTaken), errors.Is(err, ErrAlreadyMember),
+		errors.Is(err, ErrLastOwner), errors.Is(err, ErrTeamExists), errors.Is(err, ErrOrgNotEmpty),
+		errors.Is(err, ErrInvitationInvalid), errors.Is(err, ErrInvitationEmail):
+		response.BadRequest(c, err.Error())
+	default:
+		response.InternalError(c, fallback)
+	}
+}
+
+func currentUser(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func uuidParam(c *gin.Context, name, message string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		response.BadRequest(c, message)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func orgParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := currentUser(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	orgID, ok := uuidParam(c, "orgId", "geçersiz organizasyon ID")
+	return userID, orgID, ok
+}
+
+func teamParams(c *gin.Context) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	userID, orgID, ok := orgParams(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	teamID, ok := uuidParam(c, "teamId", "geçersiz takım ID")
+	return userID, orgID, teamID, ok
+}
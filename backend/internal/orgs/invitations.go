@@ -0,0 +1,228 @@
+package orgs
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"nanonet-backend/pkg/audit"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InvitationTTL — davet bağlantısının geçerlilik süresi.
+const InvitationTTL = 7 * 24 * time.Hour
+
+func newInvitationToken() (plain, hashed string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+	plain = hex.EncodeToString(b)
+	hashed = hashInvitationToken(plain)
+	return
+}
+
+func hashInvitationToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateInvitation — adrese organizasyon daveti gönderir. Adres henüz kayıtlı olmayabilir; davet
+// kayıt sırasında veya giriş yaptıktan sonra kabul edilir. Sahip rolüyle yalnızca sahipler davet
+// edebilir; aynı adrese bekleyen önceki davet geçersiz olur.
+func (s *Service) CreateInvitation(ctx context.Context, orgID, userID uuid.UUID, req InviteRequest) (*Invitation, error) {
+	role, err := s.Require(ctx, orgID, userID, RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if req.Role == RoleOwner && role != RoleOwner {
+		return nil, ErrForbidden
+	}
+	org, err := s.repo.Get(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org.Personal {
+		return nil, fmt.Errorf("%w: kişisel organizasyona davet gönderilemez", ErrInvalidOrg)
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	memberID, err := s.repo.FindUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		existing, err := OrgRole(ctx, s.repo.db, orgID, memberID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != "" {
+			return nil, ErrAlreadyMember
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	teamIDs := make(pq.StringArray, 0, len(req.TeamIDs))
+	for _, id := range req.TeamIDs {
+		if !slices.Contains(teamIDs, id.String()) {
+			teamIDs = append(teamIDs, id.String())
+		}
+	}
+	if len(teamIDs) > 0 {
+		n, err := s.repo.CountTeams(ctx, orgID, teamIDs)
+		if err != nil {
+			return nil, err
+		}
+		if n != int64(len(teamIDs)) {
+			return nil, fmt.Errorf("%w: takım bu organizasyona ait değil", ErrInvalidOrg)
+		}
+	}
+
+	plain, hashed, err := newInvitationToken()
+	if err != nil {
+		return nil, err
+	}
+	inv := &Invitation{
+		OrgID:     orgID,
+		Email:     email,
+		Role:      req.Role,
+		TeamIDs:   teamIDs,
+		TokenHash: hashed,
+		InvitedBy: &userID,
+		ExpiresAt: time.Now().Add(InvitationTTL),
+	}
+	if err := s.repo.CreateInvitation(ctx, inv); err != nil {
+		return nil, err
+	}
+	inv.OrgName = org.Name
+
+	inviteURL := fmt.Sprintf("%s/invite?token=%s", s.frontendURL, plain)
+	if m := s.mailer; m != nil && m.Enabled() {
+		inviter, _ := s.repo.UserEmail(ctx, userID)
+		if err := m.SendInvitation(email, org.Name, inviter, req.Role, inviteURL, inv.ExpiresAt); err != nil {
+			log.Printf("[MAILER ERROR] email=%s err=%v", email, err)
+			inv.InviteURL = inviteURL
+		}
+	} else {
+		log.Printf("[MAILER SKIP] not configured — invite URL: %s", inviteURL)
+		inv.InviteURL = inviteURL
+	}
+
+	s.record(ctx, audit.ActionOrgInvite, userID, orgID, map[string]any{
+		"invitation_id": inv.ID, "email": email, "role": req.Role, "team_ids": []string(teamIDs),
+	})
+	return inv, nil
+}
+
+// ListInvitations — bekleyen davetler (admin).
+func (s *Service) ListInvitations(ctx context.Context, orgID, userID uuid.UUID) ([]Invitation, error) {
+	if _, err := s.Require(ctx, orgID, userID, RoleAdmin); err != nil {
+		return nil, err
+	}
+	return s.repo.ListInvitations(ctx, orgID)
+}
+
+// RevokeInvitation — bekleyen daveti geri çeker; bağlantı artık kabul edilemez.
+func (s *Service) RevokeInvitation(ctx context.Context, orgID, invitationID, userID uuid.UUID) error {
+	if _, err := s.Require(ctx, orgID, userID, RoleAdmin); err != nil {
+		return err
+	}
+	revoked, err := s.repo.RevokeInvitation(ctx, orgID, invitationID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrInvitationNotFound
+	}
+	s.record(ctx, audit.ActionOrgInviteRevoke, userID, orgID, map[string]any{"invitation_id": invitationID})
+	return nil
+}
+
+// pendingInvitation — token'a ait kabul edilebilir davet sorgusu.
+func pendingInvitation(db *gorm.DB, plainToken string) *gorm.DB {
+	return db.Model(&Invitation{}).
+		Where("token_hash = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()",
+			hashInvitationToken(plainToken))
+}
+
+// LookupInvitation — kabul edilebilir davet, organizasyon adıyla birlikte. Kayıt ve giriş
+// ekranlarının daveti önizlemesi için; davet yoksa ErrInvitationInvalid döner.
+func LookupInvitation(ctx context.Context, db *gorm.DB, plainToken string) (*Invitation, error) {
+	var inv Invitation
+	err := pendingInvitation(db.WithContext(ctx), plainToken).
+		Select("org_invitations.*, o.name AS org_name").
+		Joins("JOIN organizations o ON o.id = org_invitations.org_id").
+		Take(&inv).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvitationInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// AcceptInvitation — daveti tx içinde kabul eder: kullanıcı organizasyona eklenir, zaten üyeyse
+// rolü davetteki role yükseltilir (düşürülmez), ardından davetteki ve hâlâ var olan takımlara
+// eklenir. Davet yalnızca gönderildiği adrese sahip kullanıcı tarafından kabul edilebilir.
+func AcceptInvitation(tx *gorm.DB, plainToken string, userID uuid.UUID, email string) (*Invitation, error) {
+	var inv Invitation
+	err := pendingInvitation(tx, plainToken).Clauses(clause.Locking{Strength: "UPDATE"}).Take(&inv).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvitationInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(inv.Email, strings.TrimSpace(email)) {
+		return nil, ErrInvitationEmail
+	}
+
+	var current Member
+	err = tx.Where("org_id = ? AND user_id = ?", inv.OrgID, userID).First(&current).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if err := tx.Create(&Member{OrgID: inv.OrgID, UserID: userID, Role: inv.Role}).Error; err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case RoleRank(inv.Role) > RoleRank(current.Role):
+		if err := tx.Model(&Member{}).Where("org_id = ? AND user_id = ?", inv.OrgID, userID).
+			Update("role", inv.Role).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if len(inv.TeamIDs) > 0 {
+		var teams []uuid.UUID
+		if err := tx.Model(&Team{}).Where("org_id = ? AND id IN ?", inv.OrgID, []string(inv.TeamIDs)).
+			Pluck("id", &teams).Error; err != nil {
+			return nil, err
+		}
+		for _, teamID := range teams {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&TeamMember{TeamID: teamID, UserID: userID}).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	now := time.Now()
+	if err := tx.Model(&inv).Updates(map[string]any{"accepted_at": now, "accepted_by": userID}).Error; err != nil {
+		return nil, err
+	}
+	inv.AcceptedAt = &now
+	inv.AcceptedBy = &userID
+	return &inv, nil
+}
@@ -0,0 +1,160 @@
+package orgs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Roller düşükten yükseğe sıralıdır; her rol altındakilerin yetkilerini kapsar.
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+	RoleOwner    = "owner"
+)
+
+// Roles — organizasyon üyelerine verilebilecek roller.
+var Roles = []string{RoleViewer, RoleOperator, RoleAdmin, RoleOwner}
+
+// TeamRoles — takımlara verilebilecek roller (sahiplik takım üzerinden verilemez).
+var TeamRoles = []string{RoleViewer, RoleOperator, RoleAdmin}
+
+var roleRanks = map[string]int{RoleViewer: 1, RoleOperator: 2, RoleAdmin: 3, RoleOwner: 4}
+
+// RoleRank — rolün sırası; bilinmeyen veya boş rol için 0. service_access görünümündeki
+// role_rank ile aynıdır.
+func RoleRank(role string) int {
+	return roleRanks[role]
+}
+
+// RoleAllows — have rolünün need rolünün yetkilerini kapsadığını bildirir.
+func RoleAllows(have, need string) bool {
+	return RoleRank(have) > 0 && RoleRank(have) >= RoleRank(need)
+}
+
+// Organization — servislerin ve üyelerin bağlı olduğu organizasyon. Her kullanıcının silinemeyen
+// bir kişisel organizasyonu vardır.
+type Organization struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name      string     `gorm:"type:varchar(100);not null" json:"name"`
+	Slug      string     `gorm:"type:varchar(60);not null;unique" json:"slug"`
+	Personal  bool       `gorm:"not null;default:false" json:"personal"`
+	CreatedBy *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt time.Time  `gorm:"not null;default:now()" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"not null;default:now()" json:"updated_at"`
+
+	// RequireTwoFactor — operator ve üstü işlemler için iki adımlı doğrulamayı zorunlu kılar.
+	RequireTwoFactor bool `gorm:"not null;default:false" json:"require_two_factor"`
+
+	// Role — isteği yapan kullanıcının organizasyondaki rolü (listelemede doldurulur).
+	Role string `gorm:"->;-:migration" json:"role,omitempty"`
+}
+
+func (Organization) TableName() string { return "organizations" }
+
+// Member — organizasyon üyeliği.
+type Member struct {
+	OrgID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"org_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Role      string    `gorm:"type:varchar(20);not null" json:"role"`
+	CreatedAt time.Time `gorm:"not null;default:now()" json:"created_at"`
+
+	// Email — users tablosundan okunur.
+	Email string `gorm:"->;-:migration" json:"email,omitempty"`
+}
+
+func (Member) TableName() string { return "org_members" }
+
+// Team — organizasyon içindeki kullanıcı grubu. Takıma atanmış servislerde üyeler en az takım
+// rolüne sahip olur.
+type Team struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrgID     uuid.UUID `gorm:"type:uuid;not null" json:"org_id"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	Role      string    `gorm:"type:varchar(20);not null" json:"role"`
+	CreatedAt time.Time `gorm:"not null;default:now()" json:"created_at"`
+
+	Members    []uuid.UUID `gorm:"-" json:"members"`
+	ServiceIDs []uuid.UUID `gorm:"-" json:"service_ids"`
+}
+
+func (Team) TableName() string { return "teams" }
+
+type TeamMember struct {
+	TeamID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	CreatedAt time.Time `gorm:"not null;default:now()"`
+}
+
+func (TeamMember) TableName() string { return "team_members" }
+
+type ServiceTeam struct {
+	ServiceID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	TeamID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+}
+
+func (ServiceTeam) TableName() string { return "service_teams" }
+
+// Invitation — e-postayla gönderilen organizasyon daveti. Kabul eden kullanıcı Role ile
+// organizasyona, TeamIDs ile servislere atanmış takımlara eklenir. Token yalnızca özetiyle saklanır.
+type Invitation struct {
+	ID         uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrgID      uuid.UUID      `gorm:"type:uuid;not null" json:"org_id"`
+	Email      string         `gorm:"type:varchar(255);not null" json:"email"`
+	Role       string         `gorm:"type:varchar(20);not null" json:"role"`
+	TeamIDs    pq.StringArray `gorm:"type:uuid[];not null" json:"team_ids"`
+	TokenHash  string         `gorm:"type:varchar(64);unique;not null" json:"-"`
+	InvitedBy  *uuid.UUID     `gorm:"type:uuid" json:"invited_by,omitempty"`
+	ExpiresAt  time.Time      `gorm:"not null" json:"expires_at"`
+	AcceptedAt *time.Time     `json:"accepted_at,omitempty"`
+	AcceptedBy *uuid.UUID     `gorm:"type:uuid" json:"accepted_by,omitempty"`
+	RevokedAt  *time.Time     `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time      `gorm:"not null;default:now()" json:"created_at"`
+
+	// OrgName — organizations tablosundan okunur.
+	OrgName string `gorm:"->;-:migration" json:"org_name,omitempty"`
+	// InviteURL — e-posta gönderilemediğinde davet bağlantısı yöneticiye döndürülür.
+	InviteURL string `gorm:"-" json:"invite_url,omitempty"`
+}
+
+func (Invitation) TableName() string { return "org_invitations" }
+
+type CreateOrgRequest struct {
+	Name string `json:"name" binding:"required,min=2,max=100"`
+	Slug string `json:"slug" binding:"omitempty,min=2,max=60"`
+}
+
+type UpdateOrgRequest struct {
+	Name             string `json:"name" binding:"omitempty,min=2,max=100"`
+	RequireTwoFactor *bool  `json:"require_two_factor"`
+}
+
+type AddMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=viewer operator admin owner"`
+}
+
+type UpdateMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=viewer operator admin owner"`
+}
+
+type TeamRequest struct {
+	Name string `json:"name" binding:"required,min=2,max=100"`
+	Role string `json:"role" binding:"required,oneof=viewer operator admin"`
+}
+
+type TeamMemberRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+}
+
+type TeamServiceRequest struct {
+	ServiceID uuid.UUID `json:"service_id" binding:"required"`
+}
+
+type InviteRequest struct {
+	Email   string      `json:"email" binding:"required,email"`
+	Role    string      `json:"role" binding:"required,oneof=viewer operator admin owner"`
+	TeamIDs []uuid.UUID `json:"team_ids" binding:"max=20"`
+}
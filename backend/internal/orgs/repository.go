@@ -0,0 +1,347 @@
+package orgs
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// CreateWithOwner — organizasyonu oluşturur ve kullanıcıyı sahibi olarak ekler.
+func (r *Repository) CreateWithOwner(ctx context.Context, org *Organization, ownerID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createWithOwner(tx, org, ownerID)
+	})
+}
+
+func createWithOwner(tx *gorm.DB, org *Organization, ownerID uuid.UUID) error {
+	if err := tx.Create(org).Error; err != nil {
+		return err
+	}
+	return tx.Create(&Member{OrgID: org.ID, UserID: ownerID, Role: RoleOwner}).Error
+}
+
+// ListForUser — kullanıcının üyesi olduğu organizasyonlar, kullanıcının rolüyle birlikte.
+func (r *Repository) ListForUser(ctx context.Context, userID uuid.UUID) ([]Organization, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var orgs []Organization
+	err := r.db.WithContext(ctx).
+		Table("organizations o").
+		Select("o.*, m.role").
+		Joins("JOIN org_members m ON m.org_id = o.id").
+		Where("m.user_id = ?", userID).
+		Order("o.personal DESC, o.name ASC").
+		Scan(&orgs).Error
+	return orgs, err
+}
+
+func (r *Repository) Get(ctx context.Context, orgID uuid.UUID) (*Organization, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var org Organization
+	if err := r.db.WithContext(ctx).Where("id = ?", orgID).First(&org).Error; err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// PersonalOrgID — kullanıcının kişisel organizasyonu.
+func (r *Repository) PersonalOrgID(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var org Organization
+	err := r.db.WithContext(ctx).
+		Where("personal = TRUE AND created_by = ?", userID).
+		First(&org).Error
+	return org.ID, err
+}
+
+func (r *Repository) SlugExists(ctx context.Context, slug string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Organization{}).Where("slug = ?", slug).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *Repository) Update(ctx context.Context, orgID uuid.UUID, updates map[string]interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	updates["updated_at"] = time.Now()
+	return r.db.WithContext(ctx).Model(&Organization{}).
+		Where("id = ?", orgID).
+		Updates(updates).Error
+}
+
+// TwoFactorEnabled — kullanıcının hesabında iki adımlı doğrulamanın etkin olup olmadığı.
+func (r *Repository) TwoFactorEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var count int64
+	err := r.db.WithContext(ctx).Table("users").
+		Where("id = ? AND totp_enabled_at IS NOT NULL", userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *Repository) Delete(ctx context.Context, orgID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return r.db.WithContext(ctx).Where("id = ?", orgID).Delete(&Organization{}).Error
+}
+
+func (r *Repository) CountServices(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var count int64
+	err := r.db.WithContext(ctx).Table("services").Where("org_id = ?", orgID).Count(&count).Error
+	return count, err
+}
+
+// ServiceOrg — servisin bağlı olduğu organizasyon; servis yoksa gorm.ErrRecordNotFound.
+func (r *Repository) ServiceOrg(ctx context.Context, serviceID uuid.UUID) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var row struct{ OrgID uuid.UUID }
+	err := r.db.WithContext(ctx).Table("services").Select("org_id").Where("id = ?", serviceID).Take(&row).Error
+	return row.OrgID, err
+}
+
+func (r *Repository) ListMembers(ctx context.Context, orgID uuid.UUID) ([]Member, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var members []Member
+	err := r.db.WithContext(ctx).
+		Table("org_members m").
+		Select("m.*, u.email").
+		Joins("JOIN users u ON u.id = m.user_id").
+		Where("m.org_id = ?", orgID).
+		Order("m.created_at ASC").
+		Scan(&members).Error
+	return members, err
+}
+
+// FindUserByEmail — e-posta adresine ait kullanıcı; yoksa gorm.ErrRecordNotFound.
+func (r *Repository) FindUserByEmail(ctx context.Context, email string) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var row struct{ ID uuid.UUID }
+	err := r.db.WithContext(ctx).Table("users").Select("id").Where("LOWER(email) = LOWER(?)", email).Take(&row).Error
+	return row.ID, err
+}
+
+func (r *Repository) AddMember(ctx context.Context, m *Member) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return r.db.WithContext(ctx).Create(m).Error
+}
+
+func (r *Repository) UpdateMemberRole(ctx context.Context, orgID, userID uuid.UUID, role string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return r.db.WithContext(ctx).Model(&Member{}).
+		Where("org_id = ? AND user_id = ?", orgID, userID).
+		Update("role", role).Error
+}
+
+// RemoveMember — üyeliği ve kullanıcının organizasyondaki takım üyeliklerini siler.
+func (r *Repository) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND team_id IN (?)", userID,
+			tx.Model(&Team{}).Select("id").Where("org_id = ?", orgID)).
+			Delete(&TeamMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("org_id = ? AND user_id = ?", orgID, userID).Delete(&Member{}).Error
+	})
+}
+
+func (r *Repository) CountOwners(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Member{}).
+		Where("org_id = ? AND role = ?", orgID, RoleOwner).
+		Count(&count).Error
+	return count, err
+}
+
+// ListTeams — organizasyonun takımları, üyeleri ve atanmış servisleriyle.
+func (r *Repository) ListTeams(ctx context.Context, orgID uuid.UUID) ([]Team, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var teams []Team
+	if err := r.db.WithContext(ctx).Where("org_id = ?", orgID).Order("name ASC").Find(&teams).Error; err != nil {
+		return nil, err
+	}
+	if len(teams) == 0 {
+		return teams, nil
+	}
+
+	ids := make([]uuid.UUID, len(teams))
+	for i, t := range teams {
+		ids[i] = t.ID
+	}
+	var members []TeamMember
+	if err := r.db.WithContext(ctx).Where("team_id IN ?", ids).Order("created_at ASC").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	var services []ServiceTeam
+	if err := r.db.WithContext(ctx).Where("team_id IN ?", ids).Find(&services).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]*Team, len(teams))
+	for i := range teams {
+		teams[i].Members = []uuid.UUID{}
+		teams[i].ServiceIDs = []uuid.UUID{}
+		byID[teams[i].ID] = &teams[i]
+	}
+	for _, m := range members {
+		byID[m.TeamID].Members = append(byID[m.TeamID].Members, m.UserID)
+	}
+	for _, s := range services {
+		byID[s.TeamID].ServiceIDs = append(byID[s.TeamID].ServiceIDs, s.ServiceID)
+	}
+	return teams, nil
+}
+
+func (r *Repository) GetTeam(ctx context.Context, orgID, teamID uuid.UUID) (*Team, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var team Team
+	if err := r.db.WithContext(ctx).Where("id = ? AND org_id = ?", teamID, orgID).First(&team).Error; err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+func (r *Repository) CreateTeam(ctx context.Context, team *Team) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return r.db.WithContext(ctx).Create(team).Error
+}
+
+func (r *Repository) UpdateTeam(ctx context.Context, team *Team) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return r.db.WithContext(ctx).Model(team).Select("name", "role").Updates(team).Error
+}
+
+func (r *Repository) DeleteTeam(ctx context.Context, teamID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return r.db.WithContext(ctx).Where("id = ?", teamID).Delete(&Team{}).Error
+}
+
+func (r *Repository) AddTeamMember(ctx context.Context, teamID, userID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return r.db.WithContext(ctx).
+		Where(TeamMember{TeamID: teamID, UserID: userID}).
+		FirstOrCreate(&TeamMember{TeamID: teamID, UserID: userID}).Error
+}
+
+func (r *Repository) RemoveTeamMember(ctx context.Context, teamID, userID uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	res := r.db.WithContext(ctx).Where("team_id = ? AND user_id = ?", teamID, userID).Delete(&TeamMember{})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *Repository) AddTeamService(ctx context.Context, teamID, serviceID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return r.db.WithContext(ctx).
+		Where(ServiceTeam{TeamID: teamID, ServiceID: serviceID}).
+		FirstOrCreate(&ServiceTeam{TeamID: teamID, ServiceID: serviceID}).Error
+}
+
+func (r *Repository) RemoveTeamService(ctx context.Context, teamID, serviceID uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	res := r.db.WithContext(ctx).Where("team_id = ? AND service_id = ?", teamID, serviceID).Delete(&ServiceTeam{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// UserEmail — kullanıcının e-posta adresi.
+func (r *Repository) UserEmail(ctx context.Context, userID uuid.UUID) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var row struct{ Email string }
+	err := r.db.WithContext(ctx).Table("users").Select("email").Where("id = ?", userID).Take(&row).Error
+	return row.Email, err
+}
+
+// CountTeams — ids içinden organizasyona ait takımların sayısı.
+func (r *Repository) CountTeams(ctx context.Context, orgID uuid.UUID, ids []string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var n int64
+	err := r.db.WithContext(ctx).Model(&Team{}).Where("org_id = ? AND id IN ?", orgID, ids).Count(&n).Error
+	return n, err
+}
+
+// CreateInvitation — aynı adrese bekleyen daveti geri çekip yenisini oluşturur.
+func (r *Repository) CreateInvitation(ctx context.Context, inv *Invitation) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Invitation{}).
+			Where("org_id = ? AND email = ? AND accepted_at IS NULL AND revoked_at IS NULL", inv.OrgID, inv.Email).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(inv).Error
+	})
+}
+
+// ListInvitations — organizasyonun kabul edilmemiş ve geri çekilmemiş davetleri, yeniden eskiye.
+func (r *Repository) ListInvitations(ctx context.Context, orgID uuid.UUID) ([]Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var invs []Invitation
+	err := r.db.WithContext(ctx).
+		Where("org_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", orgID).
+		Order("created_at DESC").
+		Find(&invs).Error
+	return invs, err
+}
+
+func (r *Repository) RevokeInvitation(ctx context.Context, orgID, invitationID uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	res := r.db.WithContext(ctx).Model(&Invitation{}).
+		Where("id = ? AND org_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitationID, orgID).
+		Update("revoked_at", time.Now())
+	return res.RowsAffected > 0, res.Error
+}
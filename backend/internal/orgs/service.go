@@ -0,0 +1,532 @@
+package orgs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+
+	"nanonet-backend/pkg/audit"
+	"nanonet-backend/pkg/mailer"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOrgNotFound    = errors.New("organizasyon bulunamadı")
+	ErrForbidden      = errors.New("bu işlem için yetkiniz yok")
+	ErrInvalidOrg     = errors.New("geçersiz organizasyon isteği")
+	ErrSlugTaken      = errors.New("bu kısa ad kullanılıyor")
+	ErrUserNotFound   = errors.New("kullanıcı bulunamadı")
+	ErrAlreadyMember  = errors.New("kullanıcı zaten üye")
+	ErrMemberNotFound = errors.New("üye bulunamadı")
+	ErrLastOwner      = errors.New("organizasyonun en az bir sahibi kalmalı")
+	ErrTeamNotFound   = errors.New("takım bulunamadı")
+	ErrTeamExists     = errors.New("bu adda bir takım zaten var")
+	ErrOrgNotEmpty    = errors.New("organizasyonda servis bulunduğu için silinemez")
+
+	ErrInvitationNotFound = errors.New("davet bulunamadı")
+	ErrInvitationInvalid  = errors.New("geçersiz veya süresi dolmuş davet bağlantısı")
+	ErrInvitationEmail    = errors.New("davet başka bir e-posta adresine gönderilmiş")
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,59}$`)
+
+// Service — organizasyon, üyelik ve takım yönetimi. Her işlem isteği yapan kullanıcının
+// organizasyondaki rolüyle denetlenir.
+type Service struct {
+	repo        *Repository
+	auditLogger *audit.Logger
+	mailer      *mailer.Mailer
+	frontendURL string
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{
+		repo:        NewRepository(db),
+		auditLogger: audit.New(db),
+	}
+}
+
+// SetMailer — davet e-postalarının gönderileceği mailer'ı ve bağlantıların açılacağı frontend
+// adresini ayarlar.
+func (s *Service) SetMailer(m *mailer.Mailer, frontendURL string) {
+	s.mailer = m
+	s.frontendURL = frontendURL
+}
+
+// CreatePersonal — yeni kullanıcının kişisel organizasyonunu tx içinde oluşturur. Kayıt işlemiyle
+// aynı transaction'da çağrılır.
+func CreatePersonal(tx *gorm.DB, userID uuid.UUID, email string) (*Organization, error) {
+	name := email
+	if at := strings.IndexByte(email, '@'); at > 0 {
+		name = email[:at]
+	}
+	org := &Organization{
+		Name:      truncate(name, 100),
+		Slug:      "u-" + strings.ReplaceAll(userID.String(), "-", ""),
+		Personal:  true,
+		CreatedBy: &userID,
+	}
+	if err := createWithOwner(tx, org, userID); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// SyncMembership — dış kimlik sağlayıcısının belirlediği rolü organizasyona yansıtır. role boşsa
+// üyelik kaldırılır. Sahiplik dışarıdan verilmez ve mevcut sahipler değiştirilmez.
+func SyncMembership(tx *gorm.DB, orgID, userID uuid.UUID, role string) error {
+	var current Member
+	err := tx.Where("org_id = ? AND user_id = ?", orgID, userID).First(&current).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	exists := err == nil
+	switch {
+	case exists && current.Role == RoleOwner:
+		return nil
+	case role == "":
+		if !exists {
+			return nil
+		}
+		if err := tx.Where("user_id = ? AND team_id IN (?)", userID,
+			tx.Model(&Team{}).Select("id").Where("org_id = ?", orgID)).
+			Delete(&TeamMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("org_id = ? AND user_id = ?", orgID, userID).Delete(&Member{}).Error
+	case role == RoleOwner || RoleRank(role) == 0:
+		return ErrInvalidOrg
+	case exists:
+		if current.Role == role {
+			return nil
+		}
+		return tx.Model(&Member{}).Where("org_id = ? AND user_id = ?", orgID, userID).Update("role", role).Error
+	default:
+		return tx.Create(&Member{OrgID: orgID, UserID: userID, Role: role}).Error
+	}
+}
+
+// PersonalOrgID — kullanıcının kişisel organizasyonu.
+func (s *Service) PersonalOrgID(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	return s.repo.PersonalOrgID(ctx, userID)
+}
+
+// Require — kullanıcının organizasyonda en az need rolüne sahip olduğunu doğrular ve rolü
+// döndürür. Üye olmayan kullanıcı için ErrOrgNotFound döner.
+func (s *Service) Require(ctx context.Context, orgID, userID uuid.UUID, need string) (string, error) {
+	role, err := OrgRole(ctx, s.repo.db, orgID, userID)
+	if err != nil {
+		return "", err
+	}
+	if role == "" {
+		return "", ErrOrgNotFound
+	}
+	if !RoleAllows(role, need) {
+		return role, ErrForbidden
+	}
+	return role, nil
+}
+
+func (s *Service) List(ctx context.Context, userID uuid.UUID) ([]Organization, error) {
+	return s.repo.ListForUser(ctx, userID)
+}
+
+func (s *Service) Get(ctx context.Context, orgID, userID uuid.UUID) (*Organization, error) {
+	role, err := s.Require(ctx, orgID, userID, RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	org, err := s.repo.Get(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	org.Role = role
+	return org, nil
+}
+
+// Create — yeni organizasyon oluşturur; oluşturan kullanıcı sahibi olur. Kısa ad verilmezse
+// addan türetilir.
+func (s *Service) Create(ctx context.Context, userID uuid.UUID, req CreateOrgRequest) (*Organization, error) {
+	name := strings.TrimSpace(req.Name)
+	slug := strings.TrimSpace(req.Slug)
+	if slug == "" {
+		slug = slugify(name)
+	}
+	if !slugPattern.MatchString(slug) || strings.HasPrefix(slug, "u-") {
+		return nil, fmt.Errorf("%w: kısa ad küçük harf, rakam ve '-' içermeli ve 'u-' ile başlamamalı", ErrInvalidOrg)
+	}
+	exists, err := s.repo.SlugExists(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrSlugTaken
+	}
+
+	org := &Organization{Name: name, Slug: slug, CreatedBy: &userID}
+	if err := s.repo.CreateWithOwner(ctx, org, userID); err != nil {
+		return nil, err
+	}
+	org.Role = RoleOwner
+	s.record(ctx, audit.ActionOrgCreate, userID, org.ID, map[string]any{"name": org.Name, "slug": org.Slug})
+	return org, nil
+}
+
+// Update — organizasyonun adını ve iki adımlı doğrulama zorunluluğunu günceller (admin).
+// Zorunluluğu açan kullanıcının kendi hesabında iki adımlı doğrulama etkin olmalıdır.
+func (s *Service) Update(ctx context.Context, orgID, userID uuid.UUID, req UpdateOrgRequest) (*Organization, error) {
+	if _, err := s.Require(ctx, orgID, userID, RoleAdmin); err != nil {
+		return nil, err
+	}
+	updates := map[string]interface{}{}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		updates["name"] = name
+	}
+	if req.RequireTwoFactor != nil {
+		if *req.RequireTwoFactor {
+			enabled, err := s.repo.TwoFactorEnabled(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			if !enabled {
+				return nil, fmt.Errorf("%w: zorunlu kılmadan önce kendi hesabınızda iki adımlı doğrulamayı etkinleştirin", ErrInvalidOrg)
+			}
+		}
+		updates["require_two_factor"] = *req.RequireTwoFactor
+	}
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("%w: güncellenecek alan yok", ErrInvalidOrg)
+	}
+	details := maps.Clone(updates)
+	if err := s.repo.Update(ctx, orgID, updates); err != nil {
+		return nil, err
+	}
+	s.record(ctx, audit.ActionOrgUpdate, userID, orgID, details)
+	return s.Get(ctx, orgID, userID)
+}
+
+// Delete — organizasyonu siler. Kişisel organizasyonlar ve servisi olan organizasyonlar silinemez.
+func (s *Service) Delete(ctx context.Context, orgID, userID uuid.UUID) error {
+	if _, err := s.Require(ctx, orgID, userID, RoleOwner); err != nil {
+		return err
+	}
+	org, err := s.repo.Get(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if org.Personal {
+		return fmt.Errorf("%w: kişisel organizasyon silinemez", ErrInvalidOrg)
+	}
+	count, err := s.repo.CountServices(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrOrgNotEmpty
+	}
+	if err := s.repo.Delete(ctx, orgID); err != nil {
+		return err
+	}
+	s.record(ctx, audit.ActionOrgDelete, userID, orgID, map[string]any{"slug": org.Slug})
+	return nil
+}
+
+func (s *Service) ListMembers(ctx context.Context, orgID, userID uuid.UUID) ([]Member, error) {
+	if _, err := s.Require(ctx, orgID, userID, RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.repo.ListMembers(ctx, orgID)
+}
+
+// AddMember — kayıtlı kullanıcıyı organizasyona ekler. Sahip rolünü yalnızca sahipler verebilir;
+// kişisel organizasyonlara üye eklenemez.
+func (s *Service) AddMember(ctx context.Context, orgID, userID uuid.UUID, req AddMemberRequest) (*Member, error) {
+	role, err := s.Require(ctx, orgID, userID, RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if req.Role == RoleOwner && role != RoleOwner {
+		return nil, ErrForbidden
+	}
+	org, err := s.repo.Get(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org.Personal {
+		return nil, fmt.Errorf("%w: kişisel organizasyona üye eklenemez", ErrInvalidOrg)
+	}
+
+	memberID, err := s.repo.FindUserByEmail(ctx, req.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	existing, err := OrgRole(ctx, s.repo.db, orgID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != "" {
+		return nil, ErrAlreadyMember
+	}
+
+	m := &Member{OrgID: orgID, UserID: memberID, Role: req.Role}
+	if err := s.repo.AddMember(ctx, m); err != nil {
+		return nil, err
+	}
+	m.Email = strings.ToLower(req.Email)
+	s.record(ctx, audit.ActionOrgMemberAdd, userID, orgID, map[string]any{"member_id": memberID, "role": req.Role})
+	return m, nil
+}
+
+// UpdateMember — üyenin rolünü değiştirir. Sahip rolü veren veya sahibin rolünü değiştiren
+// yalnızca sahip olabilir; son sahip düşürülemez.
+func (s *Service) UpdateMember(ctx context.Context, orgID, userID, memberID uuid.UUID, newRole string) error {
+	role, err := s.Require(ctx, orgID, userID, RoleAdmin)
+	if err != nil {
+		return err
+	}
+	current, err := OrgRole(ctx, s.repo.db, orgID, memberID)
+	if err != nil {
+		return err
+	}
+	if current == "" {
+		return ErrMemberNotFound
+	}
+	if (newRole == RoleOwner || current == RoleOwner) && role != RoleOwner {
+		return ErrForbidden
+	}
+	if current == RoleOwner && newRole != RoleOwner {
+		if err := s.ensureAnotherOwner(ctx, orgID); err != nil {
+			return err
+		}
+	}
+	if err := s.repo.UpdateMemberRole(ctx, orgID, memberID, newRole); err != nil {
+		return err
+	}
+	s.record(ctx, audit.ActionOrgMemberUpdate, userID, orgID, map[string]any{"member_id": memberID, "from": current, "role": newRole})
+	return nil
+}
+
+// RemoveMember — üyeyi organizasyondan çıkarır. Kullanıcı kendi üyeliğini her zaman sonlandırabilir;
+// son sahip çıkarılamaz.
+func (s *Service) RemoveMember(ctx context.Context, orgID, userID, memberID uuid.UUID) error {
+	need := RoleAdmin
+	if memberID == userID {
+		need = RoleViewer
+	}
+	role, err := s.Require(ctx, orgID, userID, need)
+	if err != nil {
+		return err
+	}
+	current, err := OrgRole(ctx, s.repo.db, orgID, memberID)
+	if err != nil {
+		return err
+	}
+	if current == "" {
+		return ErrMemberNotFound
+	}
+	if current == RoleOwner {
+		if role != RoleOwner {
+			return ErrForbidden
+		}
+		if err := s.ensureAnotherOwner(ctx, orgID); err != nil {
+			return err
+		}
+	}
+	if err := s.repo.RemoveMember(ctx, orgID, memberID); err != nil {
+		return err
+	}
+	s.record(ctx, audit.ActionOrgMemberRemove, userID, orgID, map[string]any{"member_id": memberID, "role": current})
+	return nil
+}
+
+func (s *Service) ensureAnotherOwner(ctx context.Context, orgID uuid.UUID) error {
+	owners, err := s.repo.CountOwners(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+func (s *Service) ListTeams(ctx context.Context, orgID, userID uuid.UUID) ([]Team, error) {
+	if _, err := s.Require(ctx, orgID, userID, RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.repo.ListTeams(ctx, orgID)
+}
+
+func (s *Service) CreateTeam(ctx context.Context, orgID, userID uuid.UUID, req TeamRequest) (*Team, error) {
+	if _, err := s.Require(ctx, orgID, userID, RoleAdmin); err != nil {
+		return nil, err
+	}
+	team := &Team{OrgID: orgID, Name: strings.TrimSpace(req.Name), Role: req.Role}
+	if err := s.repo.CreateTeam(ctx, team); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
+			return nil, ErrTeamExists
+		}
+		return nil, err
+	}
+	team.Members = []uuid.UUID{}
+	team.ServiceIDs = []uuid.UUID{}
+	s.record(ctx, audit.ActionTeamCreate, userID, orgID, map[string]any{"team_id": team.ID, "name": team.Name, "role": team.Role})
+	return team, nil
+}
+
+func (s *Service) UpdateTeam(ctx context.Context, orgID, teamID, userID uuid.UUID, req TeamRequest) (*Team, error) {
+	team, err := s.adminTeam(ctx, orgID, teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	team.Name = strings.TrimSpace(req.Name)
+	team.Role = req.Role
+	if err := s.repo.UpdateTeam(ctx, team); err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, ErrTeamExists
+		}
+		return nil, err
+	}
+	s.record(ctx, audit.ActionTeamUpdate, userID, orgID, map[string]any{"team_id": team.ID, "name": team.Name, "role": team.Role})
+	return team, nil
+}
+
+func (s *Service) DeleteTeam(ctx context.Context, orgID, teamID, userID uuid.UUID) error {
+	team, err := s.adminTeam(ctx, orgID, teamID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteTeam(ctx, team.ID); err != nil {
+		return err
+	}
+	s.record(ctx, audit.ActionTeamDelete, userID, orgID, map[string]any{"team_id": team.ID, "name": team.Name})
+	return nil
+}
+
+// AddTeamMember — organizasyon üyesini takıma ekler.
+func (s *Service) AddTeamMember(ctx context.Context, orgID, teamID, userID, memberID uuid.UUID) error {
+	team, err := s.adminTeam(ctx, orgID, teamID, userID)
+	if err != nil {
+		return err
+	}
+	role, err := OrgRole(ctx, s.repo.db, orgID, memberID)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return ErrMemberNotFound
+	}
+	if err := s.repo.AddTeamMember(ctx, team.ID, memberID); err != nil {
+		return err
+	}
+	s.record(ctx, audit.ActionTeamUpdate, userID, orgID, map[string]any{"team_id": team.ID, "member_added": memberID})
+	return nil
+}
+
+func (s *Service) RemoveTeamMember(ctx context.Context, orgID, teamID, userID, memberID uuid.UUID) error {
+	team, err := s.adminTeam(ctx, orgID, teamID, userID)
+	if err != nil {
+		return err
+	}
+	removed, err := s.repo.RemoveTeamMember(ctx, team.ID, memberID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrMemberNotFound
+	}
+	s.record(ctx, audit.ActionTeamUpdate, userID, orgID, map[string]any{"team_id": team.ID, "member_removed": memberID})
+	return nil
+}
+
+// AddTeamService — organizasyonun servisini takıma atar; takım üyeleri serviste en az takım
+// rolüne sahip olur.
+func (s *Service) AddTeamService(ctx context.Context, orgID, teamID, userID, serviceID uuid.UUID) error {
+	team, err := s.adminTeam(ctx, orgID, teamID, userID)
+	if err != nil {
+		return err
+	}
+	svcOrg, err := s.repo.ServiceOrg(ctx, serviceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && svcOrg != orgID) {
+		return fmt.Errorf("%w: servis bu organizasyona ait değil", ErrInvalidOrg)
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.repo.AddTeamService(ctx, team.ID, serviceID); err != nil {
+		return err
+	}
+	s.record(ctx, audit.ActionTeamUpdate, userID, orgID, map[string]any{"team_id": team.ID, "service_added": serviceID})
+	return nil
+}
+
+func (s *Service) RemoveTeamService(ctx context.Context, orgID, teamID, userID, serviceID uuid.UUID) error {
+	team, err := s.adminTeam(ctx, orgID, teamID, userID)
+	if err != nil {
+		return err
+	}
+	removed, err := s.repo.RemoveTeamService(ctx, team.ID, serviceID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("%w: servis takıma atanmamış", ErrInvalidOrg)
+	}
+	s.record(ctx, audit.ActionTeamUpdate, userID, orgID, map[string]any{"team_id": team.ID, "service_removed": serviceID})
+	return nil
+}
+
+func (s *Service) adminTeam(ctx context.Context, orgID, teamID, userID uuid.UUID) (*Team, error) {
+	if _, err := s.Require(ctx, orgID, userID, RoleAdmin); err != nil {
+		return nil, err
+	}
+	team, err := s.repo.GetTeam(ctx, orgID, teamID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTeamNotFound
+	}
+	return team, err
+}
+
+func (s *Service) record(ctx context.Context, action audit.Action, userID, orgID uuid.UUID, details map[string]any) {
+	s.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &userID,
+		Action:       action,
+		ResourceType: "organization",
+		ResourceID:   &orgID,
+		Status:       audit.StatusSuccess,
+		Details:      details,
+	})
+}
+
+// slugify — addan kısa ad türetir: küçük harf, Türkçe karakterler ASCII'ye, diğerleri '-'.
+func slugify(name string) string {
+	replacer := strings.NewReplacer("ç", "c", "ğ", "g", "ı", "i", "ö", "o", "ş", "s", "ü", "u")
+	name = replacer.Replace(strings.ToLower(name))
+	var b strings.Builder
+	dash := false
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimRight(truncate(b.String(), 60), "-")
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
@@ -0,0 +1,472 @@
+package recovery
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"nanonet-backend/internal/commands"
+	"nanonet-backend/internal/orgs"
+	"nanonet-backend/pkg/audit"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// checkInterval — aktif kurtarmaların yeniden değerlendirilme sıklığı.
+const checkInterval = 15 * time.Second
+
+// Controller — UserSettings.AutoRecovery açık olan servislerde alertlere
+// restart komutu ile tepki verir. Üstel bekleme, saatlik deneme bütçesi ve
+// devre kesici ile restart döngülerinin önüne geçer.
+type Controller struct {
+	db          *gorm.DB
+	sender      commandSender
+	cmdService  *commands.Service
+	auditLogger *audit.Logger
+	maint       maintenanceChecker
+	runbooks    runbookRunner
+	cfg         Config
+
+	alertRunbooks alertRunbookRunner
+
+	mu     sync.Mutex
+	states map[uuid.UUID]*serviceState
+}
+
+func NewController(db *gorm.DB, sender commandSender, cfg Config) *Controller {
+	if len(cfg.AlertTypes) == 0 {
+		cfg.AlertTypes = DefaultConfig.AlertTypes
+	}
+	if cfg.MaxAttemptsPerHour <= 0 {
+		cfg.MaxAttemptsPerHour = DefaultConfig.MaxAttemptsPerHour
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = DefaultConfig.BaseBackoff
+	}
+	if cfg.MaxBackoff < cfg.BaseBackoff {
+		cfg.MaxBackoff = DefaultConfig.MaxBackoff
+	}
+	if cfg.BreakerThreshold <= 0 {
+		cfg.BreakerThreshold = DefaultConfig.BreakerThreshold
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = DefaultConfig.BreakerCooldown
+	}
+
+	return &Controller{
+		db:          db,
+		sender:      sender,
+		cmdService:  commands.NewService(db),
+		auditLogger: audit.New(db),
+		cfg:         cfg,
+		states:      make(map[uuid.UUID]*serviceState),
+	}
+}
+
+// SetMaintenanceChecker wires in a maintenance window checker after construction.
+func (c *Controller) SetMaintenanceChecker(m maintenanceChecker) {
+	c.maint = m
+}
+
+// SetRunbookRunner wires in the runbook service; when the service has an
+// auto_recovery runbook it is run instead of a plain restart.
+func (c *Controller) SetRunbookRunner(r runbookRunner) {
+	c.runbooks = r
+}
+
+// SetAlertRunbookRunner wires in the runbook service for alert-triggered runbooks.
+// HandleAlert starts them first; when one starts, it takes precedence over the
+// auto-recovery restart so a single alert never triggers both.
+func (c *Controller) SetAlertRunbookRunner(r alertRunbookRunner) {
+	c.alertRunbooks = r
+}
+
+// HandleAlert — alerts.Service yeni bir alert oluşturduğunda çağrılır. Alert tetiklemeli bir
+// runbook başlatılırsa kurtarma denemesi yapılmaz.
+func (c *Controller) HandleAlert(serviceID uuid.UUID, alertType string) {
+	if c.alertRunbooks != nil && c.alertRunbooks.HandleAlert(serviceID, alertType) {
+		log.Printf("[recovery] alert runbook tarafından ele alındı, restart atlandı service=%s alert=%s", serviceID, alertType)
+		return
+	}
+	if !c.isTriggerType(alertType) {
+		return
+	}
+
+	c.mu.Lock()
+	st := c.state(serviceID)
+	if st.triggerType == "" {
+		st.triggerType = alertType
+		st.episodeAttempts = 0
+	}
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	c.attempt(ctx, serviceID)
+}
+
+// HandleCommandResult — agent'tan gelen komut sonuçlarını takip eder; başarısız
+// restart'lar devre kesici sayacını artırır.
+func (c *Controller) HandleCommandResult(commandID, status string) {
+	if commandID == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for serviceID, st := range c.states {
+		if st.lastCommandID != commandID {
+			continue
+		}
+		if st.runbookRunning {
+			// Runbook uzun sürebilir; bekleme süresi sonuçtan itibaren başlar.
+			st.runbookRunning = false
+			st.nextAttemptAt = time.Now().Add(c.backoff(st.episodeAttempts))
+		}
+		if status == "failed" || status == "timeout" {
+			// Komut sonuçlandı; bir sonraki denemede tekrar sayılmasın.
+			st.lastCommandID = ""
+			c.recordFailure(serviceID, st)
+		}
+		return
+	}
+}
+
+// Start — aktif kurtarmaları periyodik olarak değerlendirir. Alert çözülmüşse
+// durumu sıfırlar, hâlâ aktifse bekleme süresi dolduğunda yeniden dener.
+func (c *Controller) Start(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	log.Printf("Auto-recovery controller başlatıldı (alert tipleri: %v)", c.cfg.AlertTypes)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.evaluate(ctx)
+		}
+	}
+}
+
+// Status — servis için mevcut kurtarma durumunu döndürür.
+func (c *Controller) Status(ctx context.Context, serviceID uuid.UUID) Status {
+	_, enabled, _ := c.ownerSettings(ctx, serviceID)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	result := Status{
+		ServiceID:          serviceID,
+		Enabled:            enabled,
+		MaxAttemptsPerHour: c.cfg.MaxAttemptsPerHour,
+	}
+
+	st, ok := c.states[serviceID]
+	if !ok {
+		return result
+	}
+
+	st.attempts = pruneAttempts(st.attempts, now)
+	result.Active = st.triggerType != ""
+	result.TriggerType = st.triggerType
+	result.AttemptsLastHour = len(st.attempts)
+	result.ConsecutiveFailures = st.consecutiveFailures
+	result.LastCommandID = st.lastCommandID
+	if now.Before(st.breakerOpenUntil) {
+		until := st.breakerOpenUntil
+		result.BreakerOpen = true
+		result.BreakerOpenUntil = &until
+	}
+	if !st.nextAttemptAt.IsZero() && result.Active {
+		next := st.nextAttemptAt
+		result.NextAttemptAt = &next
+	}
+	if !st.lastAttemptAt.IsZero() {
+		last := st.lastAttemptAt
+		result.LastAttemptAt = &last
+	}
+	return result
+}
+
+// IsServiceOwner — kullanıcının servise en az viewer rolüyle erişebildiğini bildirir
+// (organizasyon üyeliği veya takım ataması).
+func (c *Controller) IsServiceOwner(ctx context.Context, serviceID, userID uuid.UUID) bool {
+	ok, err := orgs.HasServiceRole(ctx, c.db, serviceID, userID, orgs.RoleViewer)
+	return err == nil && ok
+}
+
+func (c *Controller) evaluate(ctx context.Context) {
+	c.mu.Lock()
+	pending := make(map[uuid.UUID]string)
+	for serviceID, st := range c.states {
+		if st.triggerType != "" {
+			pending[serviceID] = st.triggerType
+		}
+	}
+	c.mu.Unlock()
+
+	for serviceID, alertType := range pending {
+		active, err := c.isAlertActive(ctx, serviceID, alertType)
+		if err != nil {
+			log.Printf("[recovery] alert durumu okunamadı service=%s: %v", serviceID, err)
+			continue
+		}
+		if !active {
+			c.markRecovered(serviceID)
+			continue
+		}
+		c.attempt(ctx, serviceID)
+	}
+}
+
+// attempt — tüm korumalar izin veriyorsa servise restart komutu gönderir.
+func (c *Controller) attempt(ctx context.Context, serviceID uuid.UUID) {
+	now := time.Now()
+
+	st, alertType, attemptNo, ok := c.reserve(serviceID, now)
+	if !ok {
+		return
+	}
+	defer c.release(st)
+
+	ownerID, enabled, err := c.ownerSettings(ctx, serviceID)
+	if err != nil {
+		log.Printf("[recovery] servis sahibi ayarları okunamadı service=%s: %v", serviceID, err)
+		return
+	}
+	if !enabled {
+		c.markRecovered(serviceID)
+		return
+	}
+
+	if c.maint != nil {
+		if active, err := c.maint.IsActiveNow(ctx, serviceID); err == nil && active {
+			return
+		}
+	}
+
+	if c.runbooks != nil {
+		executionID, started, err := c.runbooks.RunForRecovery(ctx, serviceID, ownerID, alertType)
+		if err != nil {
+			log.Printf("[recovery] runbook başlatılamadı service=%s: %v", serviceID, err)
+			return
+		}
+		if started {
+			c.recordAttempt(st, now, attemptNo, executionID, true)
+			c.auditLogger.Record(ctx, audit.Entry{
+				Action:       audit.ActionAutoRecovery,
+				ResourceType: "service",
+				ResourceID:   &serviceID,
+				Status:       audit.StatusSuccess,
+				Details: map[string]any{
+					"initiated_by":         "system",
+					"owner_id":             ownerID.String(),
+					"runbook_execution_id": executionID,
+					"alert_type":           alertType,
+					"attempt":              attemptNo,
+				},
+			})
+			log.Printf("[recovery] runbook başlatıldı service=%s attempt=%d execution=%s", serviceID, attemptNo, executionID)
+			return
+		}
+	}
+
+	// Restart komutlarını üst üste yığma
+	if inFlight, err := c.cmdService.HasInFlightCommand(ctx, serviceID, "restart"); err != nil || inFlight {
+		return
+	}
+
+	commandID := uuid.New().String()
+	command := map[string]interface{}{
+		"type":        "command",
+		"command_id":  commandID,
+		"action":      "restart",
+		"timeout_sec": 30,
+	}
+
+	if err := c.cmdService.LogCommandWithSource(ctx, serviceID, ownerID, commandID, "restart", commands.SourceAutoRecovery, command); err != nil {
+		log.Printf("[recovery] komut kaydedilemedi service=%s: %v", serviceID, err)
+		return
+	}
+
+	sent := c.sender.SendCommandToAgent(serviceID.String(), command)
+
+	c.recordAttempt(st, now, attemptNo, commandID, false)
+
+	c.auditLogger.Record(ctx, audit.Entry{
+		Action:       audit.ActionAutoRecovery,
+		ResourceType: "service",
+		ResourceID:   &serviceID,
+		Status:       audit.StatusSuccess,
+		Details: map[string]any{
+			"initiated_by": "system",
+			"owner_id":     ownerID.String(),
+			"command_id":   commandID,
+			"alert_type":   alertType,
+			"attempt":      attemptNo,
+			"delivered":    sent,
+		},
+	})
+
+	log.Printf("[recovery] restart gönderildi service=%s attempt=%d command_id=%s delivered=%t", serviceID, attemptNo, commandID, sent)
+}
+
+// reserve — korumalar (devre kesici, bekleme süresi, saatlik bütçe) izin veriyorsa servis için
+// denemeyi ayırır. Ayrım release çağrılana kadar sürer; bu sırada gelen diğer denemeler döner.
+// HasInFlightCommand ile LogCommandWithSource arasındaki yarış bu sayede kapanır.
+func (c *Controller) reserve(serviceID uuid.UUID, now time.Time) (*serviceState, string, int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	st := c.state(serviceID)
+	if st.triggerType == "" || st.runbookRunning || st.attempting {
+		return nil, "", 0, false
+	}
+	// Önceki deneme sonuç bildirmeden alert hâlâ aktifse o deneme başarısız sayılır.
+	if st.lastCommandID != "" && now.After(st.nextAttemptAt) {
+		st.lastCommandID = ""
+		c.recordFailure(serviceID, st)
+	}
+	if now.Before(st.breakerOpenUntil) || now.Before(st.nextAttemptAt) {
+		return nil, "", 0, false
+	}
+	st.attempts = pruneAttempts(st.attempts, now)
+	if len(st.attempts) >= c.cfg.MaxAttemptsPerHour {
+		log.Printf("[recovery] saatlik deneme bütçesi tükendi service=%s (%d/%d)", serviceID, len(st.attempts), c.cfg.MaxAttemptsPerHour)
+		return nil, "", 0, false
+	}
+	st.attempting = true
+	return st, st.triggerType, st.episodeAttempts + 1, true
+}
+
+func (c *Controller) release(st *serviceState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st.attempting = false
+}
+
+func (c *Controller) recordAttempt(st *serviceState, now time.Time, attemptNo int, commandID string, runbook bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	st.attempts = append(st.attempts, now)
+	st.episodeAttempts = attemptNo
+	st.lastAttemptAt = now
+	st.lastCommandID = commandID
+	st.runbookRunning = runbook
+	st.nextAttemptAt = now.Add(c.backoff(attemptNo))
+}
+
+// recordFailure — çağıran c.mu'yu tutmalıdır.
+func (c *Controller) recordFailure(serviceID uuid.UUID, st *serviceState) {
+	st.consecutiveFailures++
+	if st.consecutiveFailures >= c.cfg.BreakerThreshold {
+		st.breakerOpenUntil = time.Now().Add(c.cfg.BreakerCooldown)
+		// Yarı açık durum: soğuma sonrası tek bir başarısızlık devreyi yeniden açar.
+		st.consecutiveFailures = c.cfg.BreakerThreshold - 1
+		log.Printf("[recovery] devre kesici açıldı service=%s (soğuma: %s)", serviceID, c.cfg.BreakerCooldown)
+	}
+}
+
+func (c *Controller) markRecovered(serviceID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	st, ok := c.states[serviceID]
+	if !ok {
+		return
+	}
+	if st.triggerType != "" && st.episodeAttempts > 0 {
+		log.Printf("[recovery] servis toparlandı service=%s (deneme: %d)", serviceID, st.episodeAttempts)
+	}
+	st.triggerType = ""
+	st.episodeAttempts = 0
+	st.consecutiveFailures = 0
+	st.nextAttemptAt = time.Time{}
+	st.lastCommandID = ""
+	st.runbookRunning = false
+}
+
+// backoff — n. denemeden sonra beklenecek süre: base * 2^(n-1), MaxBackoff ile sınırlı.
+func (c *Controller) backoff(attempt int) time.Duration {
+	d := c.cfg.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= c.cfg.MaxBackoff {
+			return c.cfg.MaxBackoff
+		}
+	}
+	return d
+}
+
+// state — çağıran c.mu'yu tutmalıdır.
+func (c *Controller) state(serviceID uuid.UUID) *serviceState {
+	st, ok := c.states[serviceID]
+	if !ok {
+		st = &serviceState{}
+		c.states[serviceID] = st
+	}
+	return st
+}
+
+func (c *Controller) isTriggerType(alertType string) bool {
+	for _, t := range c.cfg.AlertTypes {
+		if t == alertType {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Controller) isAlertActive(ctx context.Context, serviceID uuid.UUID, alertType string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var count int64
+	err := c.db.WithContext(ctx).
+		Table("alerts").
+		Where("service_id = ? AND type = ? AND resolved_at IS NULL", serviceID, alertType).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ownerSettings — servis sahibinin ID'sini ve auto_recovery tercihini döndürür.
+func (c *Controller) ownerSettings(ctx context.Context, serviceID uuid.UUID) (uuid.UUID, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var row struct {
+		UserID       uuid.UUID `gorm:"column:user_id"`
+		AutoRecovery bool      `gorm:"column:auto_recovery"`
+	}
+	err := c.db.WithContext(ctx).Raw(`
+		SELECT sv.user_id, COALESCE(us.auto_recovery, FALSE) AS auto_recovery
+		FROM services sv
+		LEFT JOIN user_settings us ON us.user_id = sv.user_id
+		WHERE sv.id = ?
+	`, serviceID).Scan(&row).Error
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	if row.UserID == uuid.Nil {
+		return uuid.Nil, false, gorm.ErrRecordNotFound
+	}
+	return row.UserID, row.AutoRecovery, nil
+}
+
+func pruneAttempts(attempts []time.Time, now time.Time) []time.Time {
+	cutoff := now.Add(-time.Hour)
+	valid := attempts[:0]
+	for _, t := range attempts {
+		if t.After(cutoff) {
+			valid = append(valid, t)
+		}
+	}
+	return valid
+}
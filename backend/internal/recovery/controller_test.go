@@ -0,0 +1,72 @@
+package recovery
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReserve_OneConcurrentAttemptPerService(t *testing.T) {
+	c := NewController(nil, nil, Config{})
+	serviceID := uuid.New()
+	c.state(serviceID).triggerType = "service_down"
+	now := time.Now()
+
+	var granted atomic.Int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if _, _, _, ok := c.reserve(serviceID, now); ok {
+				granted.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	assert.Equal(t, int32(1), granted.Load(), "aynı anda yalnızca bir deneme ayrılmalı")
+
+	st := c.state(serviceID)
+	c.recordAttempt(st, now, 1, "cmd-1", false)
+	c.release(st)
+	_, _, _, ok := c.reserve(serviceID, now)
+	assert.False(t, ok, "kaydedilen denemeden sonra bekleme süresi uygulanmalı")
+
+	_, _, attemptNo, ok := c.reserve(serviceID, now.Add(c.backoff(1)+time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, 2, attemptNo)
+}
+
+type fakeAlertRunbooks struct {
+	started bool
+	calls   int
+}
+
+func (f *fakeAlertRunbooks) HandleAlert(uuid.UUID, string) bool {
+	f.calls++
+	return f.started
+}
+
+func TestHandleAlert_RunbookTakesPrecedence(t *testing.T) {
+	c := NewController(nil, nil, Config{})
+	rb := &fakeAlertRunbooks{started: true}
+	c.SetAlertRunbookRunner(rb)
+	serviceID := uuid.New()
+
+	c.HandleAlert(serviceID, "service_down")
+	assert.Equal(t, 1, rb.calls)
+	assert.Empty(t, c.states, "runbook başladıysa kurtarma denemesi başlamamalı")
+
+	// Kurtarma tetiklemeyen alert tipleri için de runbook denenir.
+	rb.started = false
+	c.HandleAlert(serviceID, "high_latency_custom")
+	assert.Equal(t, 2, rb.calls)
+	assert.Empty(t, c.states)
+}
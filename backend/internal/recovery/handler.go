@@ -0,0 +1,39 @@
+package recovery
+
+import (
+	"nanonet-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	controller *Controller
+}
+
+func NewHandler(controller *Controller) *Handler {
+	return &Handler{controller: controller}
+}
+
+// GetStatus — GET /services/:id/recovery
+// Servisin otomatik kurtarma durumunu (deneme bütçesi, devre kesici) döndürür.
+func (h *Handler) GetStatus(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz servis ID")
+		return
+	}
+
+	if !h.controller.IsServiceOwner(c.Request.Context(), serviceID, userID) {
+		response.NotFound(c, "servis bulunamadı")
+		return
+	}
+
+	response.Success(c, h.controller.Status(c.Request.Context(), serviceID))
+}
@@ -0,0 +1,91 @@
+package recovery
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Config — otomatik kurtarma denetleyicisinin davranış parametreleri.
+type Config struct {
+	// AlertTypes — restart tetikleyen alert tipleri (örn: service_down).
+	AlertTypes []string
+	// MaxAttemptsPerHour — bir servis için saatlik maksimum restart denemesi.
+	MaxAttemptsPerHour int
+	// BaseBackoff — ardışık denemeler arasındaki ilk bekleme; her denemede ikiye katlanır.
+	BaseBackoff time.Duration
+	// MaxBackoff — üstel bekleme süresinin üst sınırı.
+	MaxBackoff time.Duration
+	// BreakerThreshold — devre kesicinin açılması için gereken ardışık başarısız deneme sayısı.
+	BreakerThreshold int
+	// BreakerCooldown — devre kesici açıkken yeni deneme yapılmayan süre.
+	BreakerCooldown time.Duration
+}
+
+// DefaultConfig is applied for any zero-valued field in the supplied Config.
+var DefaultConfig = Config{
+	AlertTypes:         []string{"service_down"},
+	MaxAttemptsPerHour: 3,
+	BaseBackoff:        30 * time.Second,
+	MaxBackoff:         10 * time.Minute,
+	BreakerThreshold:   3,
+	BreakerCooldown:    30 * time.Minute,
+}
+
+// serviceState — tek bir servis için bellek içi kurtarma durumu.
+type serviceState struct {
+	// triggerType — kurtarmayı başlatan ve hâlâ aktif olan alert tipi ("" ise bekleyen kurtarma yok).
+	triggerType         string
+	attempts            []time.Time
+	episodeAttempts     int
+	consecutiveFailures int
+	nextAttemptAt       time.Time
+	breakerOpenUntil    time.Time
+	lastCommandID       string
+	lastAttemptAt       time.Time
+	// runbookRunning — lastCommandID bir runbook çalıştırmasıdır ve henüz sonuçlanmadı.
+	runbookRunning bool
+
+	// attempting — bir deneme korumalardan geçti ve komutu henüz kaydedilmedi. Eşzamanlı
+	// HandleAlert/evaluate çağrılarının aynı servise ikinci restart göndermesini engeller.
+	attempting bool
+}
+
+// Status — GET /services/:id/recovery yanıtı.
+type Status struct {
+	ServiceID           uuid.UUID  `json:"service_id"`
+	Enabled             bool       `json:"enabled"`
+	Active              bool       `json:"active"`
+	TriggerType         string     `json:"trigger_type,omitempty"`
+	AttemptsLastHour    int        `json:"attempts_last_hour"`
+	MaxAttemptsPerHour  int        `json:"max_attempts_per_hour"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	BreakerOpen         bool       `json:"breaker_open"`
+	BreakerOpenUntil    *time.Time `json:"breaker_open_until,omitempty"`
+	NextAttemptAt       *time.Time `json:"next_attempt_at,omitempty"`
+	LastAttemptAt       *time.Time `json:"last_attempt_at,omitempty"`
+	LastCommandID       string     `json:"last_command_id,omitempty"`
+}
+
+// commandSender is satisfied by ws.Hub without a direct import.
+type commandSender interface {
+	SendCommandToAgent(serviceID string, command map[string]interface{}) bool
+}
+
+// runbookRunner is satisfied by runbooks.Service without a direct import cycle.
+// started=false means the service has no auto_recovery runbook and a plain restart is used.
+type runbookRunner interface {
+	RunForRecovery(ctx context.Context, serviceID, ownerID uuid.UUID, alertType string) (executionID string, started bool, err error)
+}
+
+// alertRunbookRunner is satisfied by runbooks.Service; started=true means an
+// alert-triggered runbook now owns the alert and auto-recovery stays out of it.
+type alertRunbookRunner interface {
+	HandleAlert(serviceID uuid.UUID, alertType string) (started bool)
+}
+
+// maintenanceChecker is satisfied by maintenance.Repository without a direct import cycle.
+type maintenanceChecker interface {
+	IsActiveNow(ctx context.Context, serviceID uuid.UUID) (bool, error)
+}
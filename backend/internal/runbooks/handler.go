@@ -0,0 +1,256 @@
+package runbooks
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"nanonet-backend/pkg/audit"
+	"nanonet-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// authorize — kullanıcıyı ve servis sahipliğini doğrular; başarısızsa yanıtı yazar.
+func (h *Handler) authorize(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz servis ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if !h.service.IsServiceOwner(c.Request.Context(), serviceID, userID) {
+		response.NotFound(c, "servis bulunamadı")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, serviceID, true
+}
+
+// List — GET /services/:id/runbooks
+func (h *Handler) List(c *gin.Context) {
+	_, serviceID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+
+	runbooks, err := h.service.List(c.Request.Context(), serviceID)
+	if err != nil {
+		response.InternalError(c, "runbook'lar alınamadı")
+		return
+	}
+	response.Success(c, runbooks)
+}
+
+// Create — POST /services/:id/runbooks
+func (h *Handler) Create(c *gin.Context) {
+	userID, serviceID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	rb, err := h.service.Create(c.Request.Context(), serviceID, userID, req)
+	if err != nil {
+		h.writeError(c, err, "runbook oluşturulamadı")
+		return
+	}
+	audit.After(c, rb)
+	response.Created(c, rb)
+}
+
+// Get — GET /services/:id/runbooks/:runbookId
+func (h *Handler) Get(c *gin.Context) {
+	_, serviceID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+	runbookID, err := uuid.Parse(c.Param("runbookId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz runbook ID")
+		return
+	}
+
+	rb, err := h.service.Get(c.Request.Context(), runbookID, serviceID)
+	if err != nil {
+		h.writeError(c, err, "runbook alınamadı")
+		return
+	}
+	response.Success(c, rb)
+}
+
+// Update — PUT /services/:id/runbooks/:runbookId
+func (h *Handler) Update(c *gin.Context) {
+	_, serviceID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+	runbookID, err := uuid.Parse(c.Param("runbookId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz runbook ID")
+		return
+	}
+
+	var req UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	if before, err := h.service.Get(c.Request.Context(), runbookID, serviceID); err == nil {
+		audit.Before(c, before)
+	}
+	rb, err := h.service.Update(c.Request.Context(), runbookID, serviceID, req)
+	if err != nil {
+		h.writeError(c, err, "runbook güncellenemedi")
+		return
+	}
+	audit.After(c, rb)
+	response.Success(c, rb)
+}
+
+// Delete — DELETE /services/:id/runbooks/:runbookId
+func (h *Handler) Delete(c *gin.Context) {
+	_, serviceID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+	runbookID, err := uuid.Parse(c.Param("runbookId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz runbook ID")
+		return
+	}
+
+	if before, err := h.service.Get(c.Request.Context(), runbookID, serviceID); err == nil {
+		audit.Before(c, before)
+	}
+	if err := h.service.Delete(c.Request.Context(), runbookID, serviceID); err != nil {
+		h.writeError(c, err, "runbook silinemedi")
+		return
+	}
+	response.Success(c, gin.H{"message": "runbook silindi"})
+}
+
+// Run — POST /services/:id/runbooks/:runbookId/run
+// Çalıştırmayı arka planda başlatır; ilerleme runbook_progress olaylarıyla izlenir.
+func (h *Handler) Run(c *gin.Context) {
+	userID, serviceID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+	runbookID, err := uuid.Parse(c.Param("runbookId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz runbook ID")
+		return
+	}
+
+	exec, err := h.service.Run(c.Request.Context(), runbookID, serviceID, userID)
+	if err != nil {
+		h.writeError(c, err, "runbook başlatılamadı")
+		return
+	}
+	response.Success(c, exec)
+}
+
+// ListExecutions — GET /services/:id/runbooks/:runbookId/executions
+func (h *Handler) ListExecutions(c *gin.Context) {
+	_, serviceID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+	runbookID, err := uuid.Parse(c.Param("runbookId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz runbook ID")
+		return
+	}
+
+	if _, err := h.service.Get(c.Request.Context(), runbookID, serviceID); err != nil {
+		h.writeError(c, err, "runbook alınamadı")
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	execs, total, err := h.service.ListExecutions(c.Request.Context(), runbookID, limit, offset)
+	if err != nil {
+		response.InternalError(c, "çalıştırma geçmişi alınamadı")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"executions": execs,
+		"total":      total,
+		"page":       page,
+	})
+}
+
+// GetExecution — GET /services/:id/runbook-executions/:executionId
+// Çalıştırmayı alt komut kayıtlarıyla birlikte döndürür.
+func (h *Handler) GetExecution(c *gin.Context) {
+	_, serviceID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+	executionID, err := uuid.Parse(c.Param("executionId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz çalıştırma ID")
+		return
+	}
+
+	exec, err := h.service.GetExecution(c.Request.Context(), executionID, serviceID)
+	if err != nil {
+		response.NotFound(c, "çalıştırma bulunamadı")
+		return
+	}
+
+	cmds, err := h.service.ExecutionCommands(c.Request.Context(), exec.ID)
+	if err != nil {
+		response.InternalError(c, "çalıştırma komutları alınamadı")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"execution": exec,
+		"commands":  cmds,
+	})
+}
+
+func (h *Handler) writeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrInvalidStep):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, ErrExecutionRunning):
+		response.Error(c, http.StatusConflict, err.Error())
+	default:
+		response.InternalError(c, fallback)
+	}
+}
@@ -0,0 +1,143 @@
+package runbooks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tetikleyici tipleri.
+const (
+	TriggerManual       = "manual"
+	TriggerAlert        = "alert"
+	TriggerAutoRecovery = "auto_recovery"
+)
+
+// Çalıştırma durumları.
+const (
+	StatusRunning        = "running"
+	StatusSuccess        = "success"
+	StatusFailed         = "failed"
+	StatusRolledBack     = "rolled_back"
+	StatusRollbackFailed = "rollback_failed"
+)
+
+// Adım tipleri.
+const (
+	StepCommand   = "command"
+	StepWait      = "wait"
+	StepCondition = "condition"
+)
+
+// Adım başarısız olduğunda uygulanacak davranış.
+const (
+	OnFailureRollback = "rollback"
+	OnFailureAbort    = "abort"
+	OnFailureContinue = "continue"
+)
+
+type Runbook struct {
+	ID               uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ServiceID        uuid.UUID       `gorm:"type:uuid;not null" json:"service_id"`
+	UserID           uuid.UUID       `gorm:"type:uuid;not null" json:"user_id"`
+	Name             string          `gorm:"type:varchar(100);not null" json:"name"`
+	Description      *string         `gorm:"type:text" json:"description,omitempty"`
+	TriggerType      string          `gorm:"type:varchar(20);not null;default:'manual'" json:"trigger_type"`
+	TriggerAlertType *string         `gorm:"type:varchar(50)" json:"trigger_alert_type,omitempty"`
+	Steps            json.RawMessage `gorm:"type:jsonb;not null" json:"steps"`
+	RollbackSteps    json.RawMessage `gorm:"type:jsonb" json:"rollback_steps,omitempty"`
+	Enabled          bool            `gorm:"not null;default:true" json:"enabled"`
+	CreatedAt        time.Time       `gorm:"not null;default:now()" json:"created_at"`
+	UpdatedAt        time.Time       `gorm:"not null;default:now()" json:"updated_at"`
+}
+
+func (Runbook) TableName() string { return "runbooks" }
+
+// Step — runbook'un tek bir adımı.
+type Step struct {
+	Name string `json:"name,omitempty"`
+	Type string `json:"type" binding:"required,oneof=command wait condition"`
+
+	// command adımı: beyaz listedeki aksiyon ve parametreleri
+	Action     string                 `json:"action,omitempty"`
+	Params     map[string]interface{} `json:"params,omitempty"`
+	TimeoutSec int                    `json:"timeout_sec,omitempty"`
+
+	// wait adımı
+	WaitSec int `json:"wait_sec,omitempty"`
+
+	// condition adımı
+	Condition *Condition `json:"condition,omitempty"`
+
+	// OnFailure — rollback (varsayılan) | abort | continue
+	OnFailure string `json:"on_failure,omitempty"`
+}
+
+// Condition — önceki komut sonucu veya servisin son metriği üzerinde kontrol.
+type Condition struct {
+	// Source — command_result | metric
+	Source string `json:"source"`
+
+	// command_result: önceki command adımının durumu ve/veya çıktısı
+	Status         string `json:"status,omitempty"`
+	OutputContains string `json:"output_contains,omitempty"`
+
+	// metric: status | cpu_percent | memory_used_mb | latency_ms | error_rate | disk_used_gb
+	Metric   string      `json:"metric,omitempty"`
+	Operator string      `json:"operator,omitempty"` // eq | ne | gt | gte | lt | lte
+	Value    interface{} `json:"value,omitempty"`
+}
+
+type Execution struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RunbookID   uuid.UUID       `gorm:"type:uuid;not null" json:"runbook_id"`
+	ServiceID   uuid.UUID       `gorm:"type:uuid;not null" json:"service_id"`
+	UserID      uuid.UUID       `gorm:"type:uuid;not null" json:"user_id"`
+	TriggeredBy string          `gorm:"type:varchar(20);not null" json:"triggered_by"`
+	TriggerRef  *string         `gorm:"type:varchar(100)" json:"trigger_ref,omitempty"`
+	Status      string          `gorm:"type:varchar(20);not null;default:'running'" json:"status"`
+	CurrentStep int             `gorm:"not null;default:0" json:"current_step"`
+	TotalSteps  int             `gorm:"not null" json:"total_steps"`
+	StepResults json.RawMessage `gorm:"type:jsonb" json:"step_results,omitempty"`
+	Error       *string         `gorm:"type:text" json:"error,omitempty"`
+	StartedAt   time.Time       `gorm:"not null;default:now()" json:"started_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+}
+
+func (Execution) TableName() string { return "runbook_executions" }
+
+// StepResult — çalıştırma sırasında bir adımın sonucu.
+type StepResult struct {
+	Index      int       `json:"index"`
+	Phase      string    `json:"phase"` // main | rollback
+	Name       string    `json:"name,omitempty"`
+	Type       string    `json:"type"`
+	Action     string    `json:"action,omitempty"`
+	Status     string    `json:"status"` // success | failed
+	CommandID  string    `json:"command_id,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+type CreateRequest struct {
+	Name             string  `json:"name" binding:"required,min=2,max=100"`
+	Description      *string `json:"description"`
+	TriggerType      string  `json:"trigger_type" binding:"omitempty,oneof=manual alert auto_recovery"`
+	TriggerAlertType *string `json:"trigger_alert_type" binding:"omitempty,max=50"`
+	Steps            []Step  `json:"steps" binding:"required,min=1,max=20,dive"`
+	RollbackSteps    []Step  `json:"rollback_steps" binding:"omitempty,max=20,dive"`
+	Enabled          *bool   `json:"enabled"`
+}
+
+type UpdateRequest struct {
+	Name             *string `json:"name" binding:"omitempty,min=2,max=100"`
+	Description      *string `json:"description"`
+	TriggerType      *string `json:"trigger_type" binding:"omitempty,oneof=manual alert auto_recovery"`
+	TriggerAlertType *string `json:"trigger_alert_type" binding:"omitempty,max=50"`
+	Steps            []Step  `json:"steps" binding:"omitempty,min=1,max=20,dive"`
+	// RollbackSteps — nil ise değişmez, boş dizi rollback adımlarını temizler.
+	RollbackSteps *[]Step `json:"rollback_steps"`
+	Enabled       *bool   `json:"enabled"`
+}
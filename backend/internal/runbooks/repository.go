@@ -0,0 +1,182 @@
+package runbooks
+
+import (
+	"context"
+	"time"
+
+	"nanonet-backend/internal/orgs"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) Create(ctx context.Context, rb *Runbook) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Create(rb).Error
+}
+
+func (r *Repository) Update(ctx context.Context, rb *Runbook) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	rb.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).Save(rb).Error
+}
+
+// Get returns a runbook scoped to the given service.
+func (r *Repository) Get(ctx context.Context, id, serviceID uuid.UUID) (*Runbook, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var rb Runbook
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND service_id = ?", id, serviceID).
+		First(&rb).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rb, nil
+}
+
+func (r *Repository) List(ctx context.Context, serviceID uuid.UUID) ([]Runbook, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var runbooks []Runbook
+	err := r.db.WithContext(ctx).
+		Where("service_id = ?", serviceID).
+		Order("created_at DESC").
+		Find(&runbooks).Error
+	return runbooks, err
+}
+
+func (r *Repository) Delete(ctx context.Context, id, serviceID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND service_id = ?", id, serviceID).
+		Delete(&Runbook{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// FindTriggered returns enabled runbooks of the given trigger type that match the alert type.
+// A runbook without trigger_alert_type matches every alert type.
+func (r *Repository) FindTriggered(ctx context.Context, serviceID uuid.UUID, triggerType, alertType string) ([]Runbook, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var runbooks []Runbook
+	err := r.db.WithContext(ctx).
+		Where("service_id = ? AND enabled AND trigger_type = ?", serviceID, triggerType).
+		Where("trigger_alert_type IS NULL OR trigger_alert_type = ?", alertType).
+		Order("trigger_alert_type IS NULL, created_at ASC").
+		Find(&runbooks).Error
+	return runbooks, err
+}
+
+// ──────────────────────── Executions ────────────────────────
+
+func (r *Repository) CreateExecution(ctx context.Context, exec *Execution) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Create(exec).Error
+}
+
+func (r *Repository) UpdateExecution(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).
+		Model(&Execution{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+func (r *Repository) GetExecution(ctx context.Context, id, serviceID uuid.UUID) (*Execution, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var exec Execution
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND service_id = ?", id, serviceID).
+		First(&exec).Error
+	if err != nil {
+		return nil, err
+	}
+	return &exec, nil
+}
+
+func (r *Repository) ListExecutions(ctx context.Context, runbookID uuid.UUID, limit, offset int) ([]Execution, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := r.db.WithContext(ctx).Model(&Execution{}).Where("runbook_id = ?", runbookID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var execs []Execution
+	err := query.Order("started_at DESC").Limit(limit).Offset(offset).Find(&execs).Error
+	return execs, total, err
+}
+
+// HasRunningExecution — servis için devam eden bir runbook çalıştırması olup olmadığını döndürür.
+func (r *Repository) HasRunningExecution(ctx context.Context, serviceID uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&Execution{}).
+		Where("service_id = ? AND status = ?", serviceID, StatusRunning).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// MarkStale — threshold'dan önce başlamış ve hâlâ "running" görünen çalıştırmaları
+// başarısız işaretler (örn: backend çalıştırma sırasında yeniden başladı).
+func (r *Repository) MarkStale(ctx context.Context, threshold time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).
+		Model(&Execution{}).
+		Where("status = ? AND started_at < ?", StatusRunning, threshold).
+		Updates(map[string]interface{}{
+			"status":       StatusFailed,
+			"error":        "çalıştırma yarıda kaldı (süre aşıldı)",
+			"completed_at": time.Now(),
+		}).Error
+}
+
+// CanOperate — kullanıcının servis üzerinde komut çalıştırabildiğini (operator) bildirir.
+func (r *Repository) CanOperate(ctx context.Context, serviceID, userID uuid.UUID) (bool, error) {
+	return orgs.HasServiceRole(ctx, r.db, serviceID, userID, orgs.RoleOperator)
+}
+
+// IsServiceOwner — kullanıcının servise en az viewer rolüyle erişebildiğini bildirir
+// (organizasyon üyeliği veya takım ataması).
+func (r *Repository) IsServiceOwner(ctx context.Context, serviceID, userID uuid.UUID) bool {
+	ok, err := orgs.HasServiceRole(ctx, r.db, serviceID, userID, orgs.RoleViewer)
+	return err == nil && ok
+}
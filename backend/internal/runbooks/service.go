@@ -0,0 +1,771 @@
+package runbooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"nanonet-backend/internal/commands"
+	"nanonet-backend/internal/metrics"
+	"nanonet-backend/internal/services"
+	"nanonet-backend/internal/ws"
+	"nanonet-backend/pkg/audit"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound         = errors.New("runbook bulunamadı")
+	ErrInvalidStep      = errors.New("geçersiz runbook adımı")
+	ErrExecutionRunning = errors.New("bu servis için devam eden bir runbook çalıştırması var")
+)
+
+const (
+	defaultStepTimeout = 120 * time.Second
+	maxStepTimeoutSec  = 900
+	maxWaitSec         = 3600
+
+	// Ana adımlar ve rollback için toplam süre sınırları. Bu süreyi aşan
+	// "running" kayıtlar yarıda kalmış sayılır (örn: backend yeniden başladı).
+	maxExecutionDuration = 2 * time.Hour
+	maxRollbackDuration  = 30 * time.Minute
+	staleSweepInterval   = 5 * time.Minute
+)
+
+// latestMetrics — metrics.Repository tarafından karşılanır; metrik koşulları için son ölçüm.
+type latestMetrics interface {
+	GetLatest(ctx context.Context, serviceID uuid.UUID) (*metrics.Metric, error)
+}
+
+// OnExecutionFinishedFunc — bir runbook çalıştırması sonuçlandığında çağrılır.
+type OnExecutionFinishedFunc func(exec Execution)
+
+type Service struct {
+	repo        *Repository
+	dispatcher  *services.Dispatcher
+	cmdService  *commands.Service
+	metricsRepo latestMetrics
+	hub         *ws.Hub
+	auditLogger *audit.Logger
+	onFinished  OnExecutionFinishedFunc
+
+	// startMu — aynı backend örneğinde bir servis için eşzamanlı başlatmaları sıralar.
+	startMu sync.Mutex
+}
+
+func NewService(db *gorm.DB, hub *ws.Hub) *Service {
+	return &Service{
+		repo:        NewRepository(db),
+		dispatcher:  services.NewDispatcher(db, hub),
+		cmdService:  commands.NewService(db),
+		metricsRepo: metrics.NewRepository(db),
+		hub:         hub,
+		auditLogger: audit.New(db),
+	}
+}
+
+// SetOnExecutionFinished wires in a callback that runs when an execution reaches a final status.
+func (s *Service) SetOnExecutionFinished(fn OnExecutionFinishedFunc) {
+	s.onFinished = fn
+}
+
+// Start — süresi aşılmış "running" çalıştırmaları periyodik olarak başarısız işaretler.
+func (s *Service) Start(ctx context.Context) {
+	ticker := time.NewTicker(staleSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			threshold := time.Now().Add(-(maxExecutionDuration + maxRollbackDuration + time.Minute))
+			if err := s.repo.MarkStale(ctx, threshold); err != nil {
+				log.Printf("[runbooks] yarım kalan çalıştırmalar işaretlenemedi: %v", err)
+			}
+		}
+	}
+}
+
+func (s *Service) Create(ctx context.Context, serviceID, userID uuid.UUID, req CreateRequest) (*Runbook, error) {
+	if err := validateSteps(req.Steps); err != nil {
+		return nil, err
+	}
+	if err := validateSteps(req.RollbackSteps); err != nil {
+		return nil, err
+	}
+
+	triggerType := req.TriggerType
+	if triggerType == "" {
+		triggerType = TriggerManual
+	}
+
+	steps, _ := json.Marshal(req.Steps)
+	rb := &Runbook{
+		ServiceID:        serviceID,
+		UserID:           userID,
+		Name:             req.Name,
+		Description:      req.Description,
+		TriggerType:      triggerType,
+		TriggerAlertType: normalizeAlertType(triggerType, req.TriggerAlertType),
+		Steps:            steps,
+		Enabled:          true,
+	}
+	if len(req.RollbackSteps) > 0 {
+		rb.RollbackSteps, _ = json.Marshal(req.RollbackSteps)
+	}
+	if req.Enabled != nil {
+		rb.Enabled = *req.Enabled
+	}
+
+	if err := s.repo.Create(ctx, rb); err != nil {
+		return nil, err
+	}
+	return rb, nil
+}
+
+func (s *Service) Update(ctx context.Context, id, serviceID uuid.UUID, req UpdateRequest) (*Runbook, error) {
+	rb, err := s.Get(ctx, id, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		rb.Name = *req.Name
+	}
+	if req.Description != nil {
+		rb.Description = req.Description
+	}
+	if req.TriggerType != nil {
+		rb.TriggerType = *req.TriggerType
+	}
+	if req.TriggerAlertType != nil {
+		rb.TriggerAlertType = req.TriggerAlertType
+	}
+	rb.TriggerAlertType = normalizeAlertType(rb.TriggerType, rb.TriggerAlertType)
+	if req.Steps != nil {
+		if err := validateSteps(req.Steps); err != nil {
+			return nil, err
+		}
+		rb.Steps, _ = json.Marshal(req.Steps)
+	}
+	if req.RollbackSteps != nil {
+		if err := validateSteps(*req.RollbackSteps); err != nil {
+			return nil, err
+		}
+		rb.RollbackSteps = nil
+		if len(*req.RollbackSteps) > 0 {
+			rb.RollbackSteps, _ = json.Marshal(*req.RollbackSteps)
+		}
+	}
+	if req.Enabled != nil {
+		rb.Enabled = *req.Enabled
+	}
+
+	if err := s.repo.Update(ctx, rb); err != nil {
+		return nil, err
+	}
+	return rb, nil
+}
+
+func (s *Service) Get(ctx context.Context, id, serviceID uuid.UUID) (*Runbook, error) {
+	rb, err := s.repo.Get(ctx, id, serviceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	return rb, err
+}
+
+func (s *Service) List(ctx context.Context, serviceID uuid.UUID) ([]Runbook, error) {
+	return s.repo.List(ctx, serviceID)
+}
+
+func (s *Service) Delete(ctx context.Context, id, serviceID uuid.UUID) error {
+	err := s.repo.Delete(ctx, id, serviceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *Service) GetExecution(ctx context.Context, id, serviceID uuid.UUID) (*Execution, error) {
+	return s.repo.GetExecution(ctx, id, serviceID)
+}
+
+func (s *Service) ListExecutions(ctx context.Context, runbookID uuid.UUID, limit, offset int) ([]Execution, int64, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.repo.ListExecutions(ctx, runbookID, limit, offset)
+}
+
+// ExecutionCommands — çalıştırmanın alt komut kayıtlarını döndürür.
+func (s *Service) ExecutionCommands(ctx context.Context, executionID uuid.UUID) ([]commands.CommandLog, error) {
+	return s.cmdService.GetByParentID(ctx, executionID)
+}
+
+func (s *Service) IsServiceOwner(ctx context.Context, serviceID, userID uuid.UUID) bool {
+	return s.repo.IsServiceOwner(ctx, serviceID, userID)
+}
+
+// Run — runbook'u elle başlatır. Devre dışı runbook'lar da elle çalıştırılabilir;
+// enabled yalnızca alert ve auto-recovery tetiklemelerini etkiler.
+func (s *Service) Run(ctx context.Context, runbookID, serviceID, userID uuid.UUID) (*Execution, error) {
+	rb, err := s.Get(ctx, runbookID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.start(ctx, rb, userID, TriggerManual, nil)
+}
+
+// HandleAlert — yeni oluşturulan alert için alert tetiklemeli runbook'u başlatır; başlatıldıysa
+// true döner (auto-recovery bu durumda aynı alert için restart göndermez).
+func (s *Service) HandleAlert(serviceID uuid.UUID, alertType string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	runbooks, err := s.repo.FindTriggered(ctx, serviceID, TriggerAlert, alertType)
+	if err != nil {
+		log.Printf("[runbooks] tetiklenen runbook'lar okunamadı service=%s: %v", serviceID, err)
+		return false
+	}
+	if len(runbooks) == 0 {
+		return false
+	}
+
+	// Bir servis için aynı anda tek çalıştırma olduğundan en spesifik eşleşme seçilir.
+	rb := runbooks[0]
+	// Adımlar runbook'u oluşturan kullanıcı adına gönderilir; operator rolü kalmadıysa çalıştırılmaz.
+	allowed, err := s.repo.CanOperate(ctx, serviceID, rb.UserID)
+	if err != nil {
+		log.Printf("[runbooks] yetki doğrulanamadı runbook=%s: %v", rb.ID, err)
+		return false
+	}
+	if !allowed {
+		log.Printf("[runbooks] oluşturan kullanıcının operator yetkisi yok, alert runbook'u atlandı runbook=%s user=%s", rb.ID, rb.UserID)
+		return false
+	}
+	ref := alertType
+	if _, err := s.start(ctx, &rb, rb.UserID, TriggerAlert, &ref); err != nil {
+		log.Printf("[runbooks] alert runbook'u başlatılamadı runbook=%s: %v", rb.ID, err)
+		return false
+	}
+	return true
+}
+
+// RunForRecovery — auto-recovery controller için servisin auto_recovery runbook'unu
+// başlatır. Eşleşen runbook yoksa started=false döner ve controller restart'a düşer.
+func (s *Service) RunForRecovery(ctx context.Context, serviceID, ownerID uuid.UUID, alertType string) (string, bool, error) {
+	runbooks, err := s.repo.FindTriggered(ctx, serviceID, TriggerAutoRecovery, alertType)
+	if err != nil {
+		return "", false, err
+	}
+	if len(runbooks) == 0 {
+		return "", false, nil
+	}
+
+	rb := runbooks[0]
+	ref := alertType
+	exec, err := s.start(ctx, &rb, ownerID, TriggerAutoRecovery, &ref)
+	if err != nil {
+		return "", false, err
+	}
+	return exec.ID.String(), true, nil
+}
+
+func (s *Service) start(ctx context.Context, rb *Runbook, userID uuid.UUID, triggeredBy string, triggerRef *string) (*Execution, error) {
+	var steps, rollback []Step
+	if err := json.Unmarshal(rb.Steps, &steps); err != nil {
+		return nil, fmt.Errorf("runbook adımları okunamadı: %w", err)
+	}
+	if len(rb.RollbackSteps) > 0 {
+		if err := json.Unmarshal(rb.RollbackSteps, &rollback); err != nil {
+			return nil, fmt.Errorf("rollback adımları okunamadı: %w", err)
+		}
+	}
+
+	s.startMu.Lock()
+	defer s.startMu.Unlock()
+
+	running, err := s.repo.HasRunningExecution(ctx, rb.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	if running {
+		return nil, ErrExecutionRunning
+	}
+
+	exec := &Execution{
+		RunbookID:   rb.ID,
+		ServiceID:   rb.ServiceID,
+		UserID:      userID,
+		TriggeredBy: triggeredBy,
+		TriggerRef:  triggerRef,
+		Status:      StatusRunning,
+		TotalSteps:  len(steps),
+		StepResults: json.RawMessage("[]"),
+		StartedAt:   time.Now(),
+	}
+	if err := s.repo.CreateExecution(ctx, exec); err != nil {
+		return nil, err
+	}
+
+	entry := audit.Entry{
+		Action:       audit.ActionRunbookRun,
+		ResourceType: "runbook",
+		ResourceID:   &rb.ID,
+		Status:       audit.StatusSuccess,
+		Details: map[string]any{
+			"service_id":   rb.ServiceID.String(),
+			"execution_id": exec.ID.String(),
+			"triggered_by": triggeredBy,
+		},
+	}
+	if triggeredBy == TriggerManual {
+		entry.UserID = &userID
+	} else {
+		entry.Details["initiated_by"] = "system"
+		entry.Details["owner_id"] = userID.String()
+	}
+	s.auditLogger.Record(ctx, entry)
+
+	log.Printf("[runbooks] çalıştırma başladı runbook=%s execution=%s trigger=%s", rb.ID, exec.ID, triggeredBy)
+
+	go s.execute(*exec, rb.Name, steps, rollback)
+	return exec, nil
+}
+
+// execute — adımları çalıştırır, sonucu DB'ye yazar ve hub'a yayınlar.
+func (s *Service) execute(exec Execution, name string, steps, rollback []Step) {
+	var lastCmd *commands.CommandLog
+	run := func(ctx context.Context, index int, phase string, step Step) StepResult {
+		res, cmd := s.runStep(ctx, exec, index, phase, step, lastCmd)
+		if cmd != nil {
+			lastCmd = cmd
+		}
+		return res
+	}
+	results, failure := runSteps(&exec, steps, rollback, run, s.saveProgress)
+
+	now := time.Now()
+	exec.CompletedAt = &now
+	updates := map[string]interface{}{
+		"status":       exec.Status,
+		"completed_at": now,
+		"step_results": mustJSON(results),
+	}
+	if failure != "" {
+		exec.Error = &failure
+		updates["error"] = failure
+	}
+
+	if err := s.repo.UpdateExecution(context.Background(), exec.ID, updates); err != nil {
+		log.Printf("[runbooks] çalıştırma sonucu kaydedilemedi execution=%s: %v", exec.ID, err)
+	}
+
+	s.hub.BroadcastEvent(exec.ServiceID.String(), "runbook_progress", map[string]interface{}{
+		"execution_id": exec.ID,
+		"runbook_id":   exec.RunbookID,
+		"runbook_name": name,
+		"status":       exec.Status,
+		"current_step": exec.CurrentStep,
+		"total_steps":  exec.TotalSteps,
+		"error":        exec.Error,
+	})
+
+	log.Printf("[runbooks] çalıştırma bitti execution=%s status=%s", exec.ID, exec.Status)
+
+	if s.onFinished != nil {
+		s.onFinished(exec)
+	}
+}
+
+// stepRunner — tek bir adımı çalıştırır; testlerde sahtesiyle değiştirilir.
+type stepRunner func(ctx context.Context, index int, phase string, step Step) StepResult
+
+// progressFunc — her adımdan sonra ilerlemeyi kaydeder.
+type progressFunc func(exec Execution, results []StepResult, last StepResult)
+
+// runSteps — adımları sırayla çalıştırır, başarısızlıkta adımın on_failure davranışını
+// uygular ve exec.Status'u belirler. Adım sonuçlarını ve hata mesajını döndürür.
+func runSteps(exec *Execution, steps, rollback []Step, run stepRunner, progress progressFunc) ([]StepResult, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), maxExecutionDuration)
+	defer cancel()
+
+	var (
+		results   []StepResult
+		failure   string
+		onFailure string
+	)
+
+	for i, step := range steps {
+		exec.CurrentStep = i + 1
+		res := run(ctx, i, "main", step)
+		results = append(results, res)
+		progress(*exec, results, res)
+
+		if res.Status == StatusSuccess {
+			continue
+		}
+		policy := step.OnFailure
+		if policy == "" {
+			policy = OnFailureRollback
+		}
+		if policy == OnFailureContinue {
+			continue
+		}
+		failure = fmt.Sprintf("adım %d başarısız: %s", i+1, res.Message)
+		onFailure = policy
+		break
+	}
+
+	exec.Status = StatusSuccess
+	if failure != "" {
+		exec.Status = StatusFailed
+		if onFailure == OnFailureRollback && len(rollback) > 0 {
+			exec.Status = runRollback(*exec, rollback, run, progress, &results)
+		}
+	}
+	return results, failure
+}
+
+// runRollback — rollback adımlarını en iyi çaba ile sonuna kadar çalıştırır.
+func runRollback(exec Execution, steps []Step, run stepRunner, progress progressFunc, results *[]StepResult) string {
+	ctx, cancel := context.WithTimeout(context.Background(), maxRollbackDuration)
+	defer cancel()
+
+	status := StatusRolledBack
+	for i, step := range steps {
+		res := run(ctx, i, "rollback", step)
+		*results = append(*results, res)
+		progress(exec, *results, res)
+		if res.Status != StatusSuccess {
+			status = StatusRollbackFailed
+		}
+	}
+	return status
+}
+
+func (s *Service) runStep(ctx context.Context, exec Execution, index int, phase string, step Step, lastCmd *commands.CommandLog) (StepResult, *commands.CommandLog) {
+	res := StepResult{
+		Index:     index,
+		Phase:     phase,
+		Name:      step.Name,
+		Type:      step.Type,
+		Action:    step.Action,
+		StartedAt: time.Now(),
+	}
+
+	var (
+		ok  bool
+		msg string
+		cmd *commands.CommandLog
+	)
+	switch step.Type {
+	case StepCommand:
+		cmd, ok, msg = s.runCommand(ctx, exec, step)
+		if cmd != nil {
+			res.CommandID = cmd.CommandID
+		}
+	case StepWait:
+		select {
+		case <-time.After(time.Duration(step.WaitSec) * time.Second):
+			ok = true
+		case <-ctx.Done():
+			msg = "çalıştırma süresi doldu"
+		}
+	case StepCondition:
+		ok, msg = s.evalCondition(ctx, exec.ServiceID, step.Condition, lastCmd)
+	default:
+		msg = "bilinmeyen adım tipi"
+	}
+
+	res.Status = StatusFailed
+	if ok {
+		res.Status = StatusSuccess
+	}
+	res.Message = msg
+	res.FinishedAt = time.Now()
+	return res, cmd
+}
+
+func (s *Service) runCommand(ctx context.Context, exec Execution, step Step) (*commands.CommandLog, bool, string) {
+	parentID := exec.ID
+	dispatched, err := s.dispatcher.Dispatch(ctx, services.DispatchRequest{
+		ServiceID: exec.ServiceID,
+		UserID:    exec.UserID,
+		Action:    step.Action,
+		Params:    step.Params,
+		Source:    commands.SourceRunbook,
+		ParentID:  &parentID,
+	})
+	if err != nil {
+		return nil, false, err.Error()
+	}
+
+	timeout := defaultStepTimeout
+	if step.TimeoutSec > 0 {
+		timeout = time.Duration(step.TimeoutSec) * time.Second
+	}
+
+	cl, err := s.cmdService.WaitForResult(ctx, dispatched.CommandID, timeout)
+	if err != nil {
+		msg := "komut zaman aşımına uğradı"
+		_ = s.cmdService.RecordResult(context.Background(), dispatched.CommandID, "timeout", &msg)
+		if cl == nil {
+			cl = &commands.CommandLog{CommandID: dispatched.CommandID, Action: step.Action}
+		}
+		cl.Status = "timeout"
+		return cl, false, msg
+	}
+
+	if cl.Status != "success" {
+		msg := "komut başarısız: " + cl.Status
+		if cl.Output != nil && *cl.Output != "" {
+			msg += " (" + truncate(*cl.Output, 200) + ")"
+		}
+		return cl, false, msg
+	}
+	return cl, true, ""
+}
+
+// evalCondition — koşulu önceki komut sonucu veya servisin son metriği üzerinde değerlendirir.
+func (s *Service) evalCondition(ctx context.Context, serviceID uuid.UUID, cond *Condition, lastCmd *commands.CommandLog) (bool, string) {
+	if cond == nil {
+		return false, "koşul tanımlı değil"
+	}
+
+	switch cond.Source {
+	case "command_result":
+		if lastCmd == nil {
+			return false, "önceki komut sonucu yok"
+		}
+		if cond.Status != "" && lastCmd.Status != cond.Status {
+			return false, fmt.Sprintf("komut durumu %s, beklenen %s", lastCmd.Status, cond.Status)
+		}
+		if cond.OutputContains != "" {
+			if lastCmd.Output == nil || !strings.Contains(*lastCmd.Output, cond.OutputContains) {
+				return false, fmt.Sprintf("komut çıktısı %q içermiyor", cond.OutputContains)
+			}
+		}
+		return true, ""
+
+	case "metric":
+		m, err := s.metricsRepo.GetLatest(ctx, serviceID)
+		if err != nil {
+			return false, "servis için metrik bulunamadı"
+		}
+		if cond.Metric == "status" {
+			want, _ := cond.Value.(string)
+			match := m.Status == want
+			if cond.Operator == "ne" {
+				match = !match
+			}
+			if !match {
+				return false, fmt.Sprintf("status=%s, koşul %s %s", m.Status, cond.Operator, want)
+			}
+			return true, ""
+		}
+
+		actual, ok := metricValue(m, cond.Metric)
+		if !ok {
+			return false, fmt.Sprintf("%s metriği raporlanmamış", cond.Metric)
+		}
+		want, _ := toFloat(cond.Value)
+		if !compare(actual, cond.Operator, want) {
+			return false, fmt.Sprintf("%s=%.2f, koşul %s %.2f", cond.Metric, actual, cond.Operator, want)
+		}
+		return true, ""
+	}
+
+	return false, "bilinmeyen koşul kaynağı"
+}
+
+func (s *Service) saveProgress(exec Execution, results []StepResult, last StepResult) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := s.repo.UpdateExecution(ctx, exec.ID, map[string]interface{}{
+		"current_step": exec.CurrentStep,
+		"step_results": mustJSON(results),
+	})
+	if err != nil {
+		log.Printf("[runbooks] ilerleme kaydedilemedi execution=%s: %v", exec.ID, err)
+	}
+
+	s.hub.BroadcastEvent(exec.ServiceID.String(), "runbook_progress", map[string]interface{}{
+		"execution_id": exec.ID,
+		"runbook_id":   exec.RunbookID,
+		"status":       StatusRunning,
+		"current_step": exec.CurrentStep,
+		"total_steps":  exec.TotalSteps,
+		"step":         last,
+	})
+}
+
+// ──────────────────────── Doğrulama ────────────────────────
+
+var conditionMetrics = map[string]bool{
+	"status":         true,
+	"cpu_percent":    true,
+	"memory_used_mb": true,
+	"latency_ms":     true,
+	"error_rate":     true,
+	"disk_used_gb":   true,
+}
+
+var conditionOperators = map[string]bool{
+	"eq": true, "ne": true, "gt": true, "gte": true, "lt": true, "lte": true,
+}
+
+func validateSteps(steps []Step) error {
+	for i, step := range steps {
+		if err := validateStep(step); err != nil {
+			return fmt.Errorf("%w: adım %d: %v", ErrInvalidStep, i+1, err)
+		}
+	}
+	return nil
+}
+
+func validateStep(step Step) error {
+	switch step.OnFailure {
+	case "", OnFailureRollback, OnFailureAbort, OnFailureContinue:
+	default:
+		return fmt.Errorf("geçersiz on_failure: %s", step.OnFailure)
+	}
+
+	switch step.Type {
+	case StepCommand:
+		if err := services.ValidateCommand(step.Action, step.Params); err != nil {
+			return err
+		}
+		if step.TimeoutSec < 0 || step.TimeoutSec > maxStepTimeoutSec {
+			return fmt.Errorf("timeout_sec 0-%d arasında olmalı", maxStepTimeoutSec)
+		}
+	case StepWait:
+		if step.WaitSec < 1 || step.WaitSec > maxWaitSec {
+			return fmt.Errorf("wait_sec 1-%d arasında olmalı", maxWaitSec)
+		}
+	case StepCondition:
+		return validateCondition(step.Condition)
+	default:
+		return fmt.Errorf("bilinmeyen adım tipi: %s", step.Type)
+	}
+	return nil
+}
+
+func validateCondition(cond *Condition) error {
+	if cond == nil {
+		return errors.New("condition alanı zorunlu")
+	}
+
+	switch cond.Source {
+	case "command_result":
+		if cond.Status == "" && cond.OutputContains == "" {
+			return errors.New("status veya output_contains belirtilmeli")
+		}
+		if cond.Status != "" && !commands.IsTerminalStatus(cond.Status) {
+			return fmt.Errorf("geçersiz komut durumu: %s", cond.Status)
+		}
+	case "metric":
+		if !conditionMetrics[cond.Metric] {
+			return fmt.Errorf("geçersiz metrik: %s", cond.Metric)
+		}
+		if !conditionOperators[cond.Operator] {
+			return fmt.Errorf("geçersiz operatör: %s", cond.Operator)
+		}
+		if cond.Metric == "status" {
+			if cond.Operator != "eq" && cond.Operator != "ne" {
+				return errors.New("status metriği için yalnızca eq/ne kullanılabilir")
+			}
+			if v, ok := cond.Value.(string); !ok || v == "" {
+				return errors.New("status koşulu için value metin olmalı")
+			}
+		} else if _, ok := toFloat(cond.Value); !ok {
+			return errors.New("metrik koşulu için value sayısal olmalı")
+		}
+	default:
+		return fmt.Errorf("geçersiz koşul kaynağı: %s", cond.Source)
+	}
+	return nil
+}
+
+// normalizeAlertType — manual runbook'larda alert tipi anlamsızdır; boş değer "tümü" demektir.
+func normalizeAlertType(triggerType string, alertType *string) *string {
+	if triggerType == TriggerManual || alertType == nil || *alertType == "" {
+		return nil
+	}
+	return alertType
+}
+
+func metricValue(m *metrics.Metric, name string) (float64, bool) {
+	var v *float32
+	switch name {
+	case "cpu_percent":
+		v = m.CPUPercent
+	case "memory_used_mb":
+		v = m.MemoryUsedMB
+	case "latency_ms":
+		v = m.LatencyMS
+	case "error_rate":
+		v = m.ErrorRate
+	case "disk_used_gb":
+		v = m.DiskUsedGB
+	}
+	if v == nil {
+		return 0, false
+	}
+	return float64(*v), true
+}
+
+func compare(actual float64, op string, want float64) bool {
+	switch op {
+	case "eq":
+		return actual == want
+	case "ne":
+		return actual != want
+	case "gt":
+		return actual > want
+	case "gte":
+		return actual >= want
+	case "lt":
+		return actual < want
+	case "lte":
+		return actual <= want
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+func mustJSON(v interface{}) json.RawMessage {
+	if v == nil {
+		return json.RawMessage("[]")
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return json.RawMessage("[]")
+	}
+	return b
+}
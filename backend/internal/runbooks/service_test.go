@@ -0,0 +1,230 @@
+package runbooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"nanonet-backend/internal/commands"
+	"nanonet-backend/internal/metrics"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeMetrics struct {
+	m   *metrics.Metric
+	err error
+}
+
+func (f fakeMetrics) GetLatest(context.Context, uuid.UUID) (*metrics.Metric, error) {
+	return f.m, f.err
+}
+
+func f32(v float32) *float32 { return &v }
+
+func strPtr(s string) *string { return &s }
+
+func TestValidateSteps(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []Step
+		ok    bool
+	}{
+		{"command", []Step{{Type: StepCommand, Action: "restart"}}, true},
+		{"izin verilmeyen aksiyon", []Step{{Type: StepCommand, Action: "rm"}}, false},
+		{"timeout sınır dışı", []Step{{Type: StepCommand, Action: "restart", TimeoutSec: maxStepTimeoutSec + 1}}, false},
+		{"wait", []Step{{Type: StepWait, WaitSec: 30}}, true},
+		{"wait sıfır", []Step{{Type: StepWait}}, false},
+		{"wait sınır dışı", []Step{{Type: StepWait, WaitSec: maxWaitSec + 1}}, false},
+		{"condition eksik", []Step{{Type: StepCondition}}, false},
+		{"bilinmeyen tip", []Step{{Type: "shell"}}, false},
+		{"on_failure continue", []Step{{Type: StepWait, WaitSec: 1, OnFailure: OnFailureContinue}}, true},
+		{"geçersiz on_failure", []Step{{Type: StepWait, WaitSec: 1, OnFailure: "retry"}}, false},
+		{"ikinci adım geçersiz", []Step{{Type: StepWait, WaitSec: 1}, {Type: StepWait}}, false},
+	}
+	for _, tt := range tests {
+		err := validateSteps(tt.steps)
+		if tt.ok {
+			assert.NoError(t, err, tt.name)
+		} else {
+			assert.ErrorIs(t, err, ErrInvalidStep, tt.name)
+		}
+	}
+}
+
+func TestValidateCondition(t *testing.T) {
+	tests := []struct {
+		name string
+		cond *Condition
+		ok   bool
+	}{
+		{"nil", nil, false},
+		{"komut durumu", &Condition{Source: "command_result", Status: "success"}, true},
+		{"komut çıktısı", &Condition{Source: "command_result", OutputContains: "ok"}, true},
+		{"boş komut koşulu", &Condition{Source: "command_result"}, false},
+		{"terminal olmayan durum", &Condition{Source: "command_result", Status: "pending"}, false},
+		{"sayısal metrik", &Condition{Source: "metric", Metric: "cpu_percent", Operator: "lt", Value: 80.0}, true},
+		{"int değer", &Condition{Source: "metric", Metric: "latency_ms", Operator: "lte", Value: 500}, true},
+		{"metin değer", &Condition{Source: "metric", Metric: "cpu_percent", Operator: "lt", Value: "80"}, false},
+		{"bilinmeyen metrik", &Condition{Source: "metric", Metric: "load", Operator: "lt", Value: 1.0}, false},
+		{"bilinmeyen operatör", &Condition{Source: "metric", Metric: "cpu_percent", Operator: "between", Value: 1.0}, false},
+		{"status eq", &Condition{Source: "metric", Metric: "status", Operator: "eq", Value: "UP"}, true},
+		{"status gt", &Condition{Source: "metric", Metric: "status", Operator: "gt", Value: "UP"}, false},
+		{"status boş", &Condition{Source: "metric", Metric: "status", Operator: "ne", Value: ""}, false},
+		{"bilinmeyen kaynak", &Condition{Source: "log"}, false},
+	}
+	for _, tt := range tests {
+		err := validateCondition(tt.cond)
+		if tt.ok {
+			assert.NoError(t, err, tt.name)
+		} else {
+			assert.Error(t, err, tt.name)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		actual float64
+		op     string
+		want   float64
+		match  bool
+	}{
+		{5, "eq", 5, true},
+		{5, "ne", 5, false},
+		{6, "gt", 5, true},
+		{5, "gt", 5, false},
+		{5, "gte", 5, true},
+		{4, "lt", 5, true},
+		{5, "lt", 5, false},
+		{5, "lte", 5, true},
+		{5, "between", 5, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, compare(tt.actual, tt.op, tt.want), "%v %s %v", tt.actual, tt.op, tt.want)
+	}
+}
+
+func TestToFloat(t *testing.T) {
+	v, ok := toFloat(80)
+	assert.True(t, ok)
+	assert.Equal(t, 80.0, v)
+
+	// JSON'dan gelen tam sayı float64 olarak çözülür.
+	var cond Condition
+	require.NoError(t, json.Unmarshal([]byte(`{"source":"metric","metric":"cpu_percent","operator":"gt","value":90}`), &cond))
+	v, ok = toFloat(cond.Value)
+	assert.True(t, ok)
+	assert.Equal(t, 90.0, v)
+	assert.NoError(t, validateCondition(&cond))
+
+	for _, bad := range []interface{}{"90", nil, true} {
+		_, ok := toFloat(bad)
+		assert.False(t, ok, "%v", bad)
+	}
+}
+
+func TestEvalCondition_CommandResult(t *testing.T) {
+	s := &Service{}
+	ctx := context.Background()
+	last := &commands.CommandLog{Status: "success", Output: strPtr("healthy: ok")}
+
+	tests := []struct {
+		name string
+		cond *Condition
+		last *commands.CommandLog
+		ok   bool
+	}{
+		{"koşul yok", nil, last, false},
+		{"önceki komut yok", &Condition{Source: "command_result", Status: "success"}, nil, false},
+		{"durum eşleşir", &Condition{Source: "command_result", Status: "success"}, last, true},
+		{"durum farklı", &Condition{Source: "command_result", Status: "failed"}, last, false},
+		{"çıktı içerir", &Condition{Source: "command_result", Status: "success", OutputContains: "ok"}, last, true},
+		{"çıktı içermez", &Condition{Source: "command_result", OutputContains: "error"}, last, false},
+		{"çıktı yok", &Condition{Source: "command_result", OutputContains: "ok"}, &commands.CommandLog{Status: "success"}, false},
+		{"bilinmeyen kaynak", &Condition{Source: "log"}, last, false},
+	}
+	for _, tt := range tests {
+		ok, msg := s.evalCondition(ctx, uuid.New(), tt.cond, tt.last)
+		assert.Equal(t, tt.ok, ok, tt.name)
+		if !tt.ok {
+			assert.NotEmpty(t, msg, tt.name)
+		}
+	}
+}
+
+func TestEvalCondition_Metric(t *testing.T) {
+	ctx := context.Background()
+	s := &Service{metricsRepo: fakeMetrics{m: &metrics.Metric{Status: "UP", CPUPercent: f32(72.5)}}}
+
+	tests := []struct {
+		name string
+		cond *Condition
+		ok   bool
+	}{
+		{"cpu lt", &Condition{Source: "metric", Metric: "cpu_percent", Operator: "lt", Value: 80.0}, true},
+		{"cpu gt", &Condition{Source: "metric", Metric: "cpu_percent", Operator: "gt", Value: 80}, false},
+		{"raporlanmamış metrik", &Condition{Source: "metric", Metric: "latency_ms", Operator: "lt", Value: 100.0}, false},
+		{"status eq", &Condition{Source: "metric", Metric: "status", Operator: "eq", Value: "UP"}, true},
+		{"status ne", &Condition{Source: "metric", Metric: "status", Operator: "ne", Value: "UP"}, false},
+		{"status ne farklı", &Condition{Source: "metric", Metric: "status", Operator: "ne", Value: "DOWN"}, true},
+	}
+	for _, tt := range tests {
+		ok, _ := s.evalCondition(ctx, uuid.New(), tt.cond, nil)
+		assert.Equal(t, tt.ok, ok, tt.name)
+	}
+
+	s.metricsRepo = fakeMetrics{err: errors.New("kayıt yok")}
+	ok, msg := s.evalCondition(ctx, uuid.New(), &Condition{Source: "metric", Metric: "cpu_percent", Operator: "lt", Value: 80.0}, nil)
+	assert.False(t, ok)
+	assert.Equal(t, "servis için metrik bulunamadı", msg)
+}
+
+func TestRunSteps_StatusTransitions(t *testing.T) {
+	ok := Step{Name: "ok", Type: StepWait}
+	fail := func(onFailure string) Step { return Step{Name: "fail", Type: StepWait, OnFailure: onFailure} }
+
+	tests := []struct {
+		name        string
+		steps       []Step
+		rollback    []Step
+		status      string
+		failed      bool
+		currentStep int
+		phases      []string
+	}{
+		{"tümü başarılı", []Step{ok, ok}, []Step{ok}, StatusSuccess, false, 2, []string{"main", "main"}},
+		{"varsayılan rollback", []Step{ok, fail(""), ok}, []Step{ok, ok}, StatusRolledBack, true, 2, []string{"main", "main", "rollback", "rollback"}},
+		{"rollback adımı başarısız", []Step{fail(OnFailureRollback)}, []Step{fail(""), ok}, StatusRollbackFailed, true, 1, []string{"main", "rollback", "rollback"}},
+		{"rollback adımı yok", []Step{fail("")}, nil, StatusFailed, true, 1, []string{"main"}},
+		{"abort rollback çalıştırmaz", []Step{fail(OnFailureAbort), ok}, []Step{ok}, StatusFailed, true, 1, []string{"main"}},
+		{"continue sonraki adıma geçer", []Step{fail(OnFailureContinue), ok}, []Step{ok}, StatusSuccess, false, 2, []string{"main", "main"}},
+	}
+	for _, tt := range tests {
+		run := func(_ context.Context, index int, phase string, step Step) StepResult {
+			res := StepResult{Index: index, Phase: phase, Name: step.Name, Status: StatusSuccess}
+			if step.Name == "fail" {
+				res.Status, res.Message = StatusFailed, "hata"
+			}
+			return res
+		}
+		progressCalls := 0
+		progress := func(Execution, []StepResult, StepResult) { progressCalls++ }
+
+		exec := &Execution{Status: StatusRunning}
+		results, failure := runSteps(exec, tt.steps, tt.rollback, run, progress)
+
+		assert.Equal(t, tt.status, exec.Status, tt.name)
+		assert.Equal(t, tt.failed, failure != "", tt.name)
+		assert.Equal(t, tt.currentStep, exec.CurrentStep, tt.name)
+		phases := make([]string, 0, len(results))
+		for _, r := range results {
+			phases = append(phases, r.Phase)
+		}
+		assert.Equal(t, tt.phases, phases, tt.name)
+		assert.Equal(t, len(results), progressCalls, tt.name)
+	}
+}
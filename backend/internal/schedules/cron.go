@@ -0,0 +1,212 @@
+package schedules
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronExpr — standart 5 alanlı cron ifadesi: dakika saat ay-günü ay hafta-günü.
+// Listeler (1,15), aralıklar (1-5), adımlar (*/10, 0-30/5), ay ve gün adları
+// (jan, mon) ile @hourly, @daily, @weekly, @monthly, @yearly kısaltmaları desteklenir.
+type CronExpr struct {
+	minute, hour, dom, month, dow uint64
+	// domAny/dowAny — alan "*" ile başlıyordu. İkisi de kısıtlıysa cron
+	// geleneğine uygun olarak gün eşleşmesi VEYA ile yapılır.
+	domAny, dowAny bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = cronField{name: "dakika", min: 0, max: 59}
+	hourField   = cronField{name: "saat", min: 0, max: 23}
+	domField    = cronField{name: "ay günü", min: 1, max: 31}
+	monthField  = cronField{name: "ay", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 de pazar kabul edilir ve 0'a katlanır.
+	dowField = cronField{name: "hafta günü", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron — cron ifadesini ayrıştırır.
+func ParseCron(expr string) (*CronExpr, error) {
+	expr = strings.TrimSpace(strings.ToLower(expr))
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron ifadesi 5 alan içermeli, %d alan bulundu", len(fields))
+	}
+
+	c := &CronExpr{
+		domAny: strings.HasPrefix(fields[2], "*"),
+		dowAny: strings.HasPrefix(fields[4], "*"),
+	}
+	var err error
+	if c.minute, err = parseCronField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseCronField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseCronField(fields[2], domField); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseCronField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if c.dow, err = parseCronField(fields[4], dowField); err != nil {
+		return nil, err
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow = c.dow&^(1<<7) | 1
+	}
+	return c, nil
+}
+
+func parseCronField(field string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		if part == "" {
+			return 0, fmt.Errorf("%s alanı geçersiz: %q", f.name, field)
+		}
+
+		rangePart, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s alanında geçersiz adım: %q", f.name, part)
+			}
+			rangePart, step = part[:i], n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s alanında geçersiz aralık: %q", f.name, rangePart)
+			}
+		default:
+			v, err := f.value(rangePart)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			// "5/15" biçimi 5'ten başlayıp alan sonuna kadar adımlar.
+			if step > 1 {
+				hi = f.max
+			} else {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[s]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s alanı %d-%d arasında olmalı: %q", f.name, f.min, f.max, s)
+	}
+	return v, nil
+}
+
+// errNoNextRun — ifade hiçbir zaman eşleşmiyor (örn: 30 şubat).
+var errNoNextRun = errors.New("cron ifadesi hiçbir tarihle eşleşmiyor")
+
+// Next — after'dan sonraki ilk çalışma zamanını after'ın saat diliminde hesaplar.
+// Yaz saati geçişinde atlanan yerel saatler o gün çalışmaz, tekrarlanan saat bir kez çalışır.
+func (c *CronExpr) Next(after time.Time) (time.Time, error) {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			// Mutlak zaman üzerinden ilerle; belirsiz yerel saatlerde geri gidilmez.
+			t = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, nil
+	}
+	return time.Time{}, errNoNextRun
+}
+
+func (c *CronExpr) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if !c.domAny && !c.dowAny {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// MinInterval — art arda gelen ilk birkaç çalışma arasındaki en kısa süre.
+func (c *CronExpr) MinInterval(from time.Time, samples int) (time.Duration, error) {
+	prev, err := c.Next(from)
+	if err != nil {
+		return 0, err
+	}
+	var min time.Duration
+	for i := 0; i < samples; i++ {
+		next, err := c.Next(prev)
+		if err != nil {
+			return 0, err
+		}
+		if d := next.Sub(prev); min == 0 || d < min {
+			min = d
+		}
+		prev = next
+	}
+	return min, nil
+}
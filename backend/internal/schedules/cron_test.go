@@ -0,0 +1,99 @@
+package schedules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustNext(t *testing.T, expr string, after time.Time) time.Time {
+	t.Helper()
+	c, err := ParseCron(expr)
+	require.NoError(t, err)
+	next, err := c.Next(after)
+	require.NoError(t, err)
+	return next
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"1,,2 * * * *",
+		"@every 5m",
+	} {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestNext_Basic(t *testing.T) {
+	base := time.Date(2026, 3, 10, 10, 17, 42, 0, time.UTC) // salı
+
+	assert.Equal(t, time.Date(2026, 3, 10, 10, 18, 0, 0, time.UTC), mustNext(t, "* * * * *", base))
+	assert.Equal(t, time.Date(2026, 3, 10, 10, 30, 0, 0, time.UTC), mustNext(t, "*/15 * * * *", base))
+	assert.Equal(t, time.Date(2026, 3, 10, 11, 0, 0, 0, time.UTC), mustNext(t, "@hourly", base))
+	assert.Equal(t, time.Date(2026, 3, 11, 3, 0, 0, 0, time.UTC), mustNext(t, "0 3 * * *", base))
+	assert.Equal(t, time.Date(2026, 3, 13, 2, 30, 0, 0, time.UTC), mustNext(t, "30 2 * * fri", base))
+	assert.Equal(t, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC), mustNext(t, "0 0 * * 7", base))
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), mustNext(t, "@monthly", base))
+	assert.Equal(t, time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC), mustNext(t, "0 9 * * mon-fri", base))
+}
+
+func TestNext_DomOrDow(t *testing.T) {
+	// Ay günü ve hafta günü birlikte kısıtlıysa herhangi biri yeterli.
+	base := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC) // salı
+	assert.Equal(t, time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC), mustNext(t, "0 0 15 * thu", base))
+	assert.Equal(t, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC), mustNext(t, "0 0 15 * mon", base))
+}
+
+func TestNext_Timezone(t *testing.T) {
+	ist, err := time.LoadLocation("Europe/Istanbul")
+	require.NoError(t, err)
+
+	base := time.Date(2026, 3, 10, 22, 0, 0, 0, time.UTC).In(ist) // 01:00 yerel
+	next := mustNext(t, "0 3 * * *", base)
+	assert.Equal(t, time.Date(2026, 3, 11, 3, 0, 0, 0, ist), next)
+	assert.Equal(t, time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestNext_DSTGap(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// 8 Mart 2026: 02:00-03:00 yerel saat yok; 02:30 işi o gün atlanır.
+	base := time.Date(2026, 3, 7, 12, 0, 0, 0, ny)
+	assert.Equal(t, time.Date(2026, 3, 9, 2, 30, 0, 0, ny), mustNext(t, "30 2 * * *", base))
+}
+
+func TestNext_NeverMatches(t *testing.T) {
+	c, err := ParseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	_, err = c.Next(time.Now())
+	assert.Error(t, err)
+}
+
+func TestMinInterval(t *testing.T) {
+	base := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	c, err := ParseCron("0,2 * * * *")
+	require.NoError(t, err)
+	d, err := c.MinInterval(base, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, d)
+
+	c, err = ParseCron("@daily")
+	require.NoError(t, err)
+	d, err = c.MinInterval(base, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, d)
+}
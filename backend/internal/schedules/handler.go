@@ -0,0 +1,182 @@
+package schedules
+
+import (
+	"errors"
+	"strconv"
+
+	"nanonet-backend/pkg/audit"
+	"nanonet-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// authorize — kullanıcıyı ve servis sahipliğini doğrular; başarısızsa yanıtı yazar.
+func (h *Handler) authorize(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz servis ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if !h.service.IsServiceOwner(c.Request.Context(), serviceID, userID) {
+		response.NotFound(c, "servis bulunamadı")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, serviceID, true
+}
+
+// List — GET /services/:id/schedules
+func (h *Handler) List(c *gin.Context) {
+	_, serviceID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+
+	schedules, err := h.service.List(c.Request.Context(), serviceID)
+	if err != nil {
+		response.InternalError(c, "zamanlamalar alınamadı")
+		return
+	}
+	response.Success(c, schedules)
+}
+
+// Create — POST /services/:id/schedules
+func (h *Handler) Create(c *gin.Context) {
+	userID, serviceID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	sch, err := h.service.Create(c.Request.Context(), serviceID, userID, req)
+	if err != nil {
+		h.writeError(c, err, "zamanlama oluşturulamadı")
+		return
+	}
+	audit.After(c, sch)
+	response.Created(c, sch)
+}
+
+// Update — PUT /services/:id/schedules/:scheduleId
+func (h *Handler) Update(c *gin.Context) {
+	_, serviceID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+	scheduleID, err := uuid.Parse(c.Param("scheduleId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz zamanlama ID")
+		return
+	}
+
+	var req UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	if before, err := h.service.Get(c.Request.Context(), scheduleID, serviceID); err == nil {
+		audit.Before(c, before)
+	}
+	sch, err := h.service.Update(c.Request.Context(), scheduleID, serviceID, req)
+	if err != nil {
+		h.writeError(c, err, "zamanlama güncellenemedi")
+		return
+	}
+	audit.After(c, sch)
+	response.Success(c, sch)
+}
+
+// Delete — DELETE /services/:id/schedules/:scheduleId
+func (h *Handler) Delete(c *gin.Context) {
+	_, serviceID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+	scheduleID, err := uuid.Parse(c.Param("scheduleId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz zamanlama ID")
+		return
+	}
+
+	if before, err := h.service.Get(c.Request.Context(), scheduleID, serviceID); err == nil {
+		audit.Before(c, before)
+	}
+	if err := h.service.Delete(c.Request.Context(), scheduleID, serviceID); err != nil {
+		h.writeError(c, err, "zamanlama silinemedi")
+		return
+	}
+	response.Success(c, gin.H{"message": "zamanlama silindi"})
+}
+
+// Runs — GET /services/:id/schedules/:scheduleId/runs
+// Zamanlamanın gönderdiği komutları command_logs üzerinden listeler.
+func (h *Handler) Runs(c *gin.Context) {
+	_, serviceID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+	scheduleID, err := uuid.Parse(c.Param("scheduleId"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz zamanlama ID")
+		return
+	}
+
+	if _, err := h.service.Get(c.Request.Context(), scheduleID, serviceID); err != nil {
+		h.writeError(c, err, "zamanlama alınamadı")
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	logs, total, err := h.service.Runs(c.Request.Context(), scheduleID, limit, offset)
+	if err != nil {
+		response.InternalError(c, "çalıştırma geçmişi alınamadı")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"commands": logs,
+		"total":    total,
+		"page":     page,
+	})
+}
+
+func (h *Handler) writeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrInvalidSchedule):
+		response.BadRequest(c, err.Error())
+	default:
+		response.InternalError(c, fallback)
+	}
+}
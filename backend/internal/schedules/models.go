@@ -0,0 +1,64 @@
+package schedules
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Zamanlanmış çalıştırmanın son durumu (last_status).
+const (
+	RunSent               = "sent"
+	RunQueued             = "queued"
+	RunFailed             = "failed"
+	RunSkippedMaintenance = "skipped_maintenance"
+
+	// RunSkippedForbidden — oluşturan kullanıcının servisteki operator rolü kalmadı;
+	// zamanlama devre dışı bırakılır.
+	RunSkippedForbidden = "skipped_forbidden"
+)
+
+type Schedule struct {
+	ID            uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ServiceID     uuid.UUID       `gorm:"type:uuid;not null" json:"service_id"`
+	UserID        uuid.UUID       `gorm:"type:uuid;not null" json:"user_id"`
+	Name          string          `gorm:"type:varchar(100);not null" json:"name"`
+	CronExpr      string          `gorm:"type:varchar(100);not null" json:"cron_expr"`
+	Timezone      string          `gorm:"type:varchar(64);not null;default:'UTC'" json:"timezone"`
+	Action        string          `gorm:"type:varchar(20);not null" json:"action"`
+	Params        json.RawMessage `gorm:"type:jsonb" json:"params,omitempty"`
+	Enabled       bool            `gorm:"not null;default:true" json:"enabled"`
+	NextRunAt     *time.Time      `json:"next_run_at,omitempty"`
+	LastRunAt     *time.Time      `json:"last_run_at,omitempty"`
+	LastStatus    *string         `gorm:"type:varchar(30)" json:"last_status,omitempty"`
+	LastCommandID *string         `gorm:"type:varchar(100)" json:"last_command_id,omitempty"`
+	CreatedAt     time.Time       `gorm:"not null;default:now()" json:"created_at"`
+	UpdatedAt     time.Time       `gorm:"not null;default:now()" json:"updated_at"`
+}
+
+func (Schedule) TableName() string { return "command_schedules" }
+
+type CreateRequest struct {
+	Name     string                 `json:"name" binding:"required,min=2,max=100"`
+	CronExpr string                 `json:"cron_expr" binding:"required,max=100"`
+	Timezone string                 `json:"timezone" binding:"omitempty,max=64"`
+	Action   string                 `json:"action" binding:"required,oneof=restart stop start ping exec scale"`
+	Params   map[string]interface{} `json:"params"`
+	Enabled  *bool                  `json:"enabled"`
+}
+
+type UpdateRequest struct {
+	Name     *string                `json:"name" binding:"omitempty,min=2,max=100"`
+	CronExpr *string                `json:"cron_expr" binding:"omitempty,max=100"`
+	Timezone *string                `json:"timezone" binding:"omitempty,max=64"`
+	Action   *string                `json:"action" binding:"omitempty,oneof=restart stop start ping exec scale"`
+	Params   map[string]interface{} `json:"params"`
+	Enabled  *bool                  `json:"enabled"`
+}
+
+// maintenanceChecker is satisfied by maintenance.Repository without a direct import cycle.
+type maintenanceChecker interface {
+	IsActiveNow(ctx context.Context, serviceID uuid.UUID) (bool, error)
+}
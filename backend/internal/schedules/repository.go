@@ -0,0 +1,148 @@
+package schedules
+
+import (
+	"context"
+	"time"
+
+	"nanonet-backend/internal/orgs"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) Create(ctx context.Context, s *Schedule) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Create(s).Error
+}
+
+func (r *Repository) Update(ctx context.Context, s *Schedule) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	s.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).Save(s).Error
+}
+
+func (r *Repository) Get(ctx context.Context, id, serviceID uuid.UUID) (*Schedule, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var s Schedule
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND service_id = ?", id, serviceID).
+		First(&s).Error
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *Repository) List(ctx context.Context, serviceID uuid.UUID) ([]Schedule, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var schedules []Schedule
+	err := r.db.WithContext(ctx).
+		Where("service_id = ?", serviceID).
+		Order("created_at DESC").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *Repository) Delete(ctx context.Context, id, serviceID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND service_id = ?", id, serviceID).
+		Delete(&Schedule{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListDue — çalışma zamanı gelmiş etkin zamanlamaları döndürür.
+func (r *Repository) ListDue(ctx context.Context, now time.Time, limit int) ([]Schedule, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var schedules []Schedule
+	err := r.db.WithContext(ctx).
+		Where("enabled AND next_run_at <= ?", now).
+		Order("next_run_at ASC").
+		Limit(limit).
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// Claim — zamanlamayı bu backend örneği için sahiplenir ve bir sonraki çalışma
+// zamanını ilerletir. next_run_at başka bir örnek tarafından değiştirildiyse false döner.
+func (r *Repository) Claim(ctx context.Context, id uuid.UUID, dueAt time.Time, nextRunAt *time.Time, now time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).
+		Model(&Schedule{}).
+		Where("id = ? AND enabled AND next_run_at = ?", id, dueAt).
+		Updates(map[string]interface{}{
+			"next_run_at": nextRunAt,
+			"last_run_at": now,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// RecordRun — çalıştırmanın sonucunu kaydeder.
+func (r *Repository) RecordRun(ctx context.Context, id uuid.UUID, status string, commandID *string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).
+		Model(&Schedule{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_status":     status,
+			"last_command_id": commandID,
+		}).Error
+}
+
+// Disable — zamanlamayı devre dışı bırakır ve son durumunu status olarak kaydeder.
+func (r *Repository) Disable(ctx context.Context, id uuid.UUID, status string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).
+		Model(&Schedule{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"enabled":         false,
+			"next_run_at":     nil,
+			"last_status":     status,
+			"last_command_id": nil,
+		}).Error
+}
+
+// CanOperate — kullanıcının servis üzerinde komut çalıştırabildiğini (operator) bildirir.
+func (r *Repository) CanOperate(ctx context.Context, serviceID, userID uuid.UUID) (bool, error) {
+	return orgs.HasServiceRole(ctx, r.db, serviceID, userID, orgs.RoleOperator)
+}
+
+// IsServiceOwner — kullanıcının servise en az viewer rolüyle erişebildiğini bildirir
+// (organizasyon üyeliği veya takım ataması).
+func (r *Repository) IsServiceOwner(ctx context.Context, serviceID, userID uuid.UUID) bool {
+	ok, err := orgs.HasServiceRole(ctx, r.db, serviceID, userID, orgs.RoleViewer)
+	return err == nil && ok
+}
@@ -0,0 +1,287 @@
+package schedules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	// Alpine imajında zoneinfo bulunmadığından saat dilimi verisi binary'ye gömülür.
+	_ "time/tzdata"
+
+	"nanonet-backend/internal/commands"
+	"nanonet-backend/internal/services"
+	"nanonet-backend/internal/ws"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound        = errors.New("zamanlama bulunamadı")
+	ErrInvalidSchedule = errors.New("geçersiz zamanlama")
+)
+
+const (
+	// tickInterval — zamanı gelmiş komutların kontrol sıklığı.
+	tickInterval = 30 * time.Second
+	// minInterval — art arda iki çalıştırma arasındaki en kısa süre.
+	minInterval  = 5 * time.Minute
+	dueBatchSize = 100
+)
+
+type Service struct {
+	repo       *Repository
+	dispatcher *services.Dispatcher
+	cmdService *commands.Service
+	maint      maintenanceChecker
+}
+
+func NewService(db *gorm.DB, hub *ws.Hub) *Service {
+	return &Service{
+		repo:       NewRepository(db),
+		dispatcher: services.NewDispatcher(db, hub),
+		cmdService: commands.NewService(db),
+	}
+}
+
+// SetMaintenanceChecker wires in a maintenance window checker after construction.
+func (s *Service) SetMaintenanceChecker(m maintenanceChecker) {
+	s.maint = m
+}
+
+func (s *Service) Create(ctx context.Context, serviceID, userID uuid.UUID, req CreateRequest) (*Schedule, error) {
+	sch := &Schedule{
+		ServiceID: serviceID,
+		UserID:    userID,
+		Name:      req.Name,
+		CronExpr:  req.CronExpr,
+		Timezone:  req.Timezone,
+		Action:    req.Action,
+		Enabled:   true,
+	}
+	if sch.Timezone == "" {
+		sch.Timezone = "UTC"
+	}
+	if req.Params != nil {
+		sch.Params, _ = json.Marshal(req.Params)
+	}
+	if req.Enabled != nil {
+		sch.Enabled = *req.Enabled
+	}
+
+	if err := s.prepare(sch, req.Params); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, sch); err != nil {
+		return nil, err
+	}
+	return sch, nil
+}
+
+func (s *Service) Update(ctx context.Context, id, serviceID uuid.UUID, req UpdateRequest) (*Schedule, error) {
+	sch, err := s.Get(ctx, id, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		sch.Name = *req.Name
+	}
+	if req.CronExpr != nil {
+		sch.CronExpr = *req.CronExpr
+	}
+	if req.Timezone != nil {
+		sch.Timezone = *req.Timezone
+	}
+	if req.Action != nil {
+		sch.Action = *req.Action
+	}
+	if req.Params != nil {
+		sch.Params, _ = json.Marshal(req.Params)
+	}
+	if req.Enabled != nil {
+		sch.Enabled = *req.Enabled
+	}
+
+	if err := s.prepare(sch, decodeParams(sch.Params)); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, sch); err != nil {
+		return nil, err
+	}
+	return sch, nil
+}
+
+func (s *Service) Get(ctx context.Context, id, serviceID uuid.UUID) (*Schedule, error) {
+	sch, err := s.repo.Get(ctx, id, serviceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	return sch, err
+}
+
+func (s *Service) List(ctx context.Context, serviceID uuid.UUID) ([]Schedule, error) {
+	return s.repo.List(ctx, serviceID)
+}
+
+func (s *Service) Delete(ctx context.Context, id, serviceID uuid.UUID) error {
+	err := s.repo.Delete(ctx, id, serviceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Runs — zamanlamanın oluşturduğu komut kayıtlarını döndürür.
+func (s *Service) Runs(ctx context.Context, scheduleID uuid.UUID, limit, offset int) ([]commands.CommandLog, int64, error) {
+	return s.cmdService.GetPageByParentID(ctx, scheduleID, limit, offset)
+}
+
+func (s *Service) IsServiceOwner(ctx context.Context, serviceID, userID uuid.UUID) bool {
+	return s.repo.IsServiceOwner(ctx, serviceID, userID)
+}
+
+// prepare — cron ifadesini, saat dilimini ve komutu doğrular; next_run_at'i hesaplar.
+func (s *Service) prepare(sch *Schedule, params map[string]interface{}) error {
+	loc, err := time.LoadLocation(sch.Timezone)
+	if err != nil {
+		return fmt.Errorf("%w: bilinmeyen saat dilimi %q", ErrInvalidSchedule, sch.Timezone)
+	}
+	expr, err := ParseCron(sch.CronExpr)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	if err := services.ValidateCommand(sch.Action, params); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+
+	now := time.Now().In(loc)
+	interval, err := expr.MinInterval(now, 24)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	if interval < minInterval {
+		return fmt.Errorf("%w: çalıştırmalar arasında en az %s olmalı", ErrInvalidSchedule, minInterval)
+	}
+
+	sch.NextRunAt = nil
+	if sch.Enabled {
+		next, err := expr.Next(now)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+		}
+		next = next.UTC()
+		sch.NextRunAt = &next
+	}
+	return nil
+}
+
+// Start — zamanı gelen komutları periyodik olarak çalıştırır. Birden fazla
+// backend örneğinde her çalıştırmayı yalnızca Claim'i kazanan örnek yapar.
+func (s *Service) Start(ctx context.Context) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	log.Println("Komut zamanlayıcı başlatıldı")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runDue(ctx)
+		}
+	}
+}
+
+func (s *Service) runDue(ctx context.Context) {
+	now := time.Now()
+	due, err := s.repo.ListDue(ctx, now, dueBatchSize)
+	if err != nil {
+		log.Printf("[schedules] zamanlamalar okunamadı: %v", err)
+		return
+	}
+	for i := range due {
+		s.run(ctx, &due[i], now)
+	}
+}
+
+func (s *Service) run(ctx context.Context, sch *Schedule, now time.Time) {
+	// Kaçırılan çalıştırmalar (örn: backend kapalıyken) biriktirilmez;
+	// bir kez çalışılır ve sonraki zaman şimdiden itibaren hesaplanır.
+	var next *time.Time
+	loc, err := time.LoadLocation(sch.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	if expr, err := ParseCron(sch.CronExpr); err == nil {
+		if n, err := expr.Next(now.In(loc)); err == nil {
+			n = n.UTC()
+			next = &n
+		}
+	}
+
+	claimed, err := s.repo.Claim(ctx, sch.ID, *sch.NextRunAt, next, now)
+	if err != nil {
+		log.Printf("[schedules] zamanlama sahiplenilemedi id=%s: %v", sch.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	// Komut oluşturan kullanıcı adına gönderilir; rolü düşürülen veya organizasyondan
+	// çıkarılan kullanıcının zamanlaması çalışmaya devam etmemeli.
+	allowed, err := s.repo.CanOperate(ctx, sch.ServiceID, sch.UserID)
+	if err != nil {
+		_ = s.repo.RecordRun(ctx, sch.ID, RunFailed, nil)
+		log.Printf("[schedules] yetki doğrulanamadı id=%s: %v", sch.ID, err)
+		return
+	}
+	if !allowed {
+		_ = s.repo.Disable(ctx, sch.ID, RunSkippedForbidden)
+		log.Printf("[schedules] oluşturan kullanıcının operator yetkisi yok, zamanlama devre dışı id=%s user=%s", sch.ID, sch.UserID)
+		return
+	}
+
+	if s.maint != nil {
+		if active, err := s.maint.IsActiveNow(ctx, sch.ServiceID); err == nil && active {
+			_ = s.repo.RecordRun(ctx, sch.ID, RunSkippedMaintenance, nil)
+			log.Printf("[schedules] bakım penceresi nedeniyle atlandı id=%s service=%s", sch.ID, sch.ServiceID)
+			return
+		}
+	}
+
+	parentID := sch.ID
+	result, err := s.dispatcher.Dispatch(ctx, services.DispatchRequest{
+		ServiceID: sch.ServiceID,
+		UserID:    sch.UserID,
+		Action:    sch.Action,
+		Params:    decodeParams(sch.Params),
+		Source:    commands.SourceSchedule,
+		ParentID:  &parentID,
+	})
+	if err != nil {
+		_ = s.repo.RecordRun(ctx, sch.ID, RunFailed, nil)
+		log.Printf("[schedules] komut gönderilemedi id=%s: %v", sch.ID, err)
+		return
+	}
+
+	status := RunSent
+	if !result.Sent {
+		status = RunQueued
+	}
+	_ = s.repo.RecordRun(ctx, sch.ID, status, &result.CommandID)
+	log.Printf("[schedules] %s gönderildi id=%s service=%s command_id=%s", sch.Action, sch.ID, sch.ServiceID, result.CommandID)
+}
+
+func decodeParams(raw json.RawMessage) map[string]interface{} {
+	params := map[string]interface{}{}
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &params)
+	}
+	return params
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"nanonet-backend/internal/commands"
+	"nanonet-backend/internal/ws"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// allowedScaleStrategies — scale komutu için izin verilen load balancing stratejileri.
+var allowedScaleStrategies = map[string]bool{
+	"round_robin": true,
+	"least_conn":  true,
+	"ip_hash":     true,
+	"random":      true,
+	"weighted":    true,
+}
+
+var (
+	// ErrActionNotAllowed — istenen aksiyon veya exec komutu beyaz listede değil.
+	ErrActionNotAllowed = errors.New("bu komut izin verilmiyor")
+	// ErrAgentNotConnected — hedeflenen agent instance'ı servise bağlı değil.
+	ErrAgentNotConnected = errors.New("agent instance bağlı değil")
+	// ErrApprovalRequired — aksiyon için onay politikası tanımlı; komut yalnızca onaylanan
+	// bir istek üzerinden gönderilebilir.
+	ErrApprovalRequired = errors.New("bu aksiyon onay gerektiriyor; komut onay isteği üzerinden gönderilmeli")
+)
+
+// DispatchRequest — handler dışından (runbook, zamanlayıcı vb.) gönderilen komut isteği.
+type DispatchRequest struct {
+	ServiceID uuid.UUID
+	// UserID — komutun adına çalıştırıldığı kullanıcı (sistem komutlarında servis sahibi).
+	UserID   uuid.UUID
+	Action   string
+	Params   map[string]interface{}
+	Source   string
+	ParentID *uuid.UUID
+	// AgentID — boş değilse komut yalnızca bu agent instance'ına gönderilir.
+	AgentID string
+	// Approved — komut onaylanmış bir istekten geliyor; onay politikası denetimi atlanır.
+	// Yalnızca approvals servisi tarafından işaretlenir.
+	Approved bool
+}
+
+// DispatchResult — gönderilen komutun kimliği ve iletim durumu.
+type DispatchResult struct {
+	CommandID string                 `json:"command_id"`
+	Status    string                 `json:"status"`
+	Sent      bool                   `json:"agent_connected"`
+	Command   map[string]interface{} `json:"-"`
+}
+
+// Dispatcher — komut doğrulama, command_logs kaydı ve agent'a iletimi
+// Handler ile aynı beyaz liste kurallarıyla tek noktada toplar.
+type Dispatcher struct {
+	hub        *ws.Hub
+	cmdService *commands.Service
+	catalog    *ExecCatalog
+	policies   approvalPolicies
+}
+
+// approvalPolicies — servis aksiyonunun onay politikasına bağlı olup olmadığını söyler.
+type approvalPolicies interface {
+	RequiresApproval(ctx context.Context, serviceID uuid.UUID, action string) (bool, error)
+}
+
+func NewDispatcher(db *gorm.DB, hub *ws.Hub) *Dispatcher {
+	return &Dispatcher{
+		hub:        hub,
+		cmdService: commands.NewService(db),
+		catalog:    NewExecCatalog(db, hub),
+		policies:   policyTable{db: db},
+	}
+}
+
+// policyTable — approval_policies tablosunu doğrudan okur; approvals paketi bu paketi
+// içe aktardığından import döngüsü oluşmaz.
+type policyTable struct {
+	db *gorm.DB
+}
+
+func (p policyTable) RequiresApproval(ctx context.Context, serviceID uuid.UUID, action string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var count int64
+	err := p.db.WithContext(ctx).
+		Table("approval_policies").
+		Where("service_id = ? AND action = ?", serviceID, action).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Dispatch — komutu doğrular, kaydeder ve servise bağlı agent'lara iletir. Onay politikası
+// tanımlı aksiyonlar (runbook, zamanlayıcı, fleet vb. hangi yoldan gelirse gelsin) yalnızca
+// onaylanmış bir istekten gönderilebilir.
+func (d *Dispatcher) Dispatch(ctx context.Context, req DispatchRequest) (*DispatchResult, error) {
+	if !req.Approved {
+		gated, err := d.policies.RequiresApproval(ctx, req.ServiceID, req.Action)
+		if err != nil {
+			return nil, fmt.Errorf("onay politikası okunamadı: %w", err)
+		}
+		if gated {
+			return nil, fmt.Errorf("%w: %s", ErrApprovalRequired, req.Action)
+		}
+	}
+
+	commandID := uuid.New().String()
+	var (
+		command map[string]interface{}
+		entry   *ExecCommand
+		err     error
+	)
+	if req.Action == "exec" {
+		// exec girdileri servisin kataloğundan çözülür ve komutun sahibinin rolüyle yetkilendirilir.
+		entry, command, err = d.catalog.Build(ctx, req.ServiceID, req.UserID, commandID, req.Params)
+	} else {
+		command, err = BuildCommand(commandID, req.Action, req.Params)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if req.AgentID != "" {
+		if _, ok := d.hub.GetAgentInstance(req.ServiceID.String(), req.AgentID); !ok {
+			return nil, ErrAgentNotConnected
+		}
+	}
+	if err := d.hub.CheckCapability(req.ServiceID.String(), req.AgentID, req.Action); err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		if err := d.hub.CheckExecCommand(req.ServiceID.String(), req.AgentID, entry.Name, entry.Builtin); err != nil {
+			return nil, err
+		}
+	}
+
+	source := req.Source
+	if source == "" {
+		source = commands.SourceUser
+	}
+
+	if err := d.cmdService.LogChildCommand(ctx, req.ServiceID, req.UserID, commandID, req.Action, source, req.ParentID, command); err != nil {
+		return nil, fmt.Errorf("komut kaydedilemedi: %w", err)
+	}
+
+	var sent bool
+	if req.AgentID != "" {
+		sent = d.hub.SendCommandToAgentInstance(req.ServiceID.String(), req.AgentID, command)
+		if !sent {
+			msg := ErrAgentNotConnected.Error()
+			_ = d.cmdService.RecordResult(ctx, commandID, "failed", &msg)
+			return nil, ErrAgentNotConnected
+		}
+	} else {
+		sent = d.hub.SendCommandToAgent(req.ServiceID.String(), command)
+	}
+	status := "sent"
+	if !sent {
+		status = "queued"
+	}
+
+	return &DispatchResult{
+		CommandID: commandID,
+		Status:    status,
+		Sent:      sent,
+		Command:   command,
+	}, nil
+}
+
+// ValidateCommand — aksiyonu ve parametrelerini komut oluşturmadan doğrular. Servise özgü
+// exec girdileri burada yalnızca ad biçimiyle denetlenir; tam doğrulama gönderim anında
+// servisin kataloğuyla yapılır.
+func ValidateCommand(action string, params map[string]interface{}) error {
+	if action == "exec" {
+		name, _ := params["command"].(string)
+		if _, ok := builtinExecCommand(name); !ok {
+			if !execNamePattern.MatchString(name) {
+				return fmt.Errorf("%w: exec %q", ErrActionNotAllowed, name)
+			}
+			return nil
+		}
+	}
+	_, err := BuildCommand("", action, params)
+	return err
+}
+
+// BuildCommand — Handler endpoint'leriyle aynı varsayılan ve sınırları uygulayarak
+// agent'a gönderilecek komut mesajını oluşturur. exec için yalnızca yerleşik katalog
+// girdileri kabul edilir; servise özgü girdiler ExecCatalog.Build ile oluşturulur.
+func BuildCommand(commandID, action string, params map[string]interface{}) (map[string]interface{}, error) {
+	command := map[string]interface{}{
+		"type":       "command",
+		"command_id": commandID,
+		"action":     action,
+	}
+
+	switch action {
+	case "restart":
+		command["timeout_sec"] = intParam(params, "timeout_sec", 30, 1, 300)
+
+	case "stop":
+		graceful := true
+		if v, ok := params["graceful"].(bool); ok {
+			graceful = v
+		}
+		command["graceful"] = graceful
+
+	case "start", "ping":
+
+	case "exec":
+		name, _ := params["command"].(string)
+		entry, ok := builtinExecCommand(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: exec %q", ErrActionNotAllowed, name)
+		}
+		return entry.Command(commandID, params)
+
+	case "scale":
+		raw, ok := params["instances"].(float64)
+		if !ok {
+			if i, isInt := params["instances"].(int); isInt {
+				raw, ok = float64(i), true
+			}
+		}
+		if !ok || raw < 0 || raw > 32 {
+			return nil, errors.New("scale için instances 0-32 arasında olmalı")
+		}
+		strategy, _ := params["strategy"].(string)
+		if strategy == "" {
+			strategy = "round_robin"
+		}
+		if !allowedScaleStrategies[strategy] {
+			return nil, fmt.Errorf("geçersiz strateji: %s", strategy)
+		}
+		weight, _ := params["weight_config"].(string)
+		command["instances"] = int(raw)
+		command["strategy"] = strategy
+		command["weight_config"] = weight
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrActionNotAllowed, action)
+	}
+
+	return command, nil
+}
+
+// intParam — JSON'dan gelen sayısal parametreyi [min, max] aralığına sıkıştırır.
+func intParam(params map[string]interface{}, key string, def, min, max int) int {
+	v := def
+	switch n := params[key].(type) {
+	case float64:
+		v = int(n)
+	case int:
+		v = n
+	}
+	if v < min {
+		v = def
+	}
+	if v > max {
+		v = max
+	}
+	return v
+}
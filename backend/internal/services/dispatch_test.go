@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"nanonet-backend/internal/commands"
+	"nanonet-backend/internal/ws"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCommand_MatchesCommandSchema(t *testing.T) {
+	cases := map[string]map[string]interface{}{
+		"restart": {"timeout_sec": 60.0},
+		"stop":    {"graceful": false},
+		"start":   nil,
+		"ping":    nil,
+		"exec":    {"command": "uptime"},
+		"scale":   {"instances": 3.0, "strategy": "least_conn"},
+	}
+	for _, action := range ws.CommandActions {
+		params, ok := cases[action]
+		require.True(t, ok, "%s için örnek yok", action)
+
+		command, err := BuildCommand("c1", action, params)
+		require.NoError(t, err, action)
+		raw, err := json.Marshal(command)
+		require.NoError(t, err)
+
+		msgType, err := ws.ValidateMessage(ws.FromBackend, raw)
+		assert.NoError(t, err, string(raw))
+		assert.Equal(t, ws.MsgCommand, msgType)
+	}
+}
+
+type fakePolicies map[string]bool
+
+func (f fakePolicies) RequiresApproval(_ context.Context, _ uuid.UUID, action string) (bool, error) {
+	return f[action], nil
+}
+
+func TestDispatch_GatedActionRequiresApproval(t *testing.T) {
+	d := &Dispatcher{policies: fakePolicies{"stop": true}}
+
+	for _, source := range []string{commands.SourceRunbook, commands.SourceSchedule, commands.SourceFleet} {
+		_, err := d.Dispatch(context.Background(), DispatchRequest{
+			ServiceID: uuid.New(),
+			UserID:    uuid.New(),
+			Action:    "stop",
+			Source:    source,
+		})
+		assert.ErrorIs(t, err, ErrApprovalRequired, source)
+	}
+}
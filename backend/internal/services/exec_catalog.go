@@ -0,0 +1,537 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"nanonet-backend/internal/orgs"
+	"nanonet-backend/internal/ws"
+	"nanonet-backend/pkg/audit"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ExecRoles — katalog girdilerinin gerektirebileceği roller, yetkisi artan sırada.
+// Organizasyon sahipleri tüm girdileri çalıştırabilir.
+var ExecRoles = []string{orgs.RoleViewer, orgs.RoleOperator, orgs.RoleAdmin}
+
+const (
+	defaultExecTimeout  = 30
+	maxExecTimeout      = 300
+	maxExecParams       = 10
+	defaultParamMaxLen  = 256
+	maxParamMaxLen      = 1024
+	maxParamPatternLen  = 200
+	maxParamEnumValues  = 50
+	execCatalogEditRole = orgs.RoleAdmin
+)
+
+var (
+	execNamePattern      = regexp.MustCompile(`^[a-z][a-z0-9-]{1,49}$`)
+	execParamNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+	// ErrInvalidExecCommand — katalog girdisi tanımı geçersiz.
+	ErrInvalidExecCommand = errors.New("geçersiz exec komutu tanımı")
+	// ErrInvalidExecArgs — exec isteğindeki parametreler girdinin şemasına uymuyor.
+	ErrInvalidExecArgs = errors.New("geçersiz exec parametresi")
+	// ErrExecForbidden — kullanıcının rolü girdinin gerektirdiği rolden düşük.
+	ErrExecForbidden = errors.New("bu komut için yetkiniz yok")
+	// ErrExecCommandExists — aynı adda girdi zaten var (yerleşik adlar dahil).
+	ErrExecCommandExists = errors.New("bu adda bir exec komutu zaten var")
+	// ErrExecCommandNotFound — servis kataloğunda böyle bir girdi yok.
+	ErrExecCommandNotFound = errors.New("exec komutu bulunamadı")
+)
+
+// builtinExecCommands — her serviste bulunan, agent'ın yerleşik olarak uyguladığı diagnostikler.
+var builtinExecCommands = []ExecCommand{
+	builtinExec("df-h", "Disk kullanımı (df -h)"),
+	builtinExec("free-m", "Bellek kullanımı (free -m)"),
+	builtinExec("health-check", "Yerel health endpoint'ini çağırır"),
+	builtinExec("logs", "Son 100 sistem log satırı"),
+	builtinExec("status", "Servis yöneticisi durumu"),
+	builtinExec("top-snapshot", "En çok CPU kullanan 15 süreç"),
+	builtinExec("uptime", "Sistem çalışma süresi ve yük"),
+	builtinExec("version", "Çekirdek ve işletim sistemi sürümü"),
+}
+
+func builtinExec(name, description string) ExecCommand {
+	return ExecCommand{
+		Name:              name,
+		Description:       description,
+		Params:            json.RawMessage(`[]`),
+		DefaultTimeoutSec: defaultExecTimeout,
+		MaxTimeoutSec:     maxExecTimeout,
+		RequiredRole:      orgs.RoleOperator,
+		Enabled:           true,
+		Builtin:           true,
+		Agents:            []string{},
+	}
+}
+
+func builtinExecCommand(name string) (*ExecCommand, bool) {
+	for _, cmd := range builtinExecCommands {
+		if cmd.Name == name {
+			cmd := cmd
+			return &cmd, true
+		}
+	}
+	return nil, false
+}
+
+// ParamSpecs — girdinin parametre şemasını çözer.
+func (e *ExecCommand) ParamSpecs() ([]ExecParam, error) {
+	if len(e.Params) == 0 {
+		return nil, nil
+	}
+	var specs []ExecParam
+	if err := json.Unmarshal(e.Params, &specs); err != nil {
+		return nil, fmt.Errorf("%w: params çözülemedi", ErrInvalidExecCommand)
+	}
+	return specs, nil
+}
+
+// Command — girdinin şemasına göre parametreleri doğrulayıp agent'a gidecek exec komutunu
+// oluşturur. params içinde timeout_sec ve args (parametre adı → değer) okunur.
+func (e *ExecCommand) Command(commandID string, params map[string]interface{}) (map[string]interface{}, error) {
+	specs, err := e.ParamSpecs()
+	if err != nil {
+		return nil, err
+	}
+
+	var given map[string]interface{}
+	switch a := params["args"].(type) {
+	case nil:
+	case map[string]interface{}:
+		given = a
+	default:
+		return nil, fmt.Errorf("%w: args bir nesne olmalı", ErrInvalidExecArgs)
+	}
+
+	args := make(map[string]interface{}, len(specs))
+	for _, spec := range specs {
+		raw, ok := given[spec.Name]
+		if !ok || raw == nil {
+			if spec.Default != nil {
+				raw = spec.Default
+			} else if spec.Required {
+				return nil, fmt.Errorf("%w: %s zorunlu", ErrInvalidExecArgs, spec.Name)
+			} else {
+				continue
+			}
+		}
+		v, err := coerceExecArg(spec, raw)
+		if err != nil {
+			return nil, err
+		}
+		args[spec.Name] = v
+	}
+	for name := range given {
+		if !hasParam(specs, name) {
+			return nil, fmt.Errorf("%w: bilinmeyen parametre %s", ErrInvalidExecArgs, name)
+		}
+	}
+
+	command := map[string]interface{}{
+		"type":        "command",
+		"command_id":  commandID,
+		"action":      "exec",
+		"command":     e.Name,
+		"timeout_sec": intParam(params, "timeout_sec", e.DefaultTimeoutSec, 1, e.MaxTimeoutSec),
+	}
+	if len(args) > 0 {
+		command["args"] = args
+	}
+	return command, nil
+}
+
+func hasParam(specs []ExecParam, name string) bool {
+	for _, s := range specs {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// coerceExecArg — değeri parametre tipine çevirir ve sınırlarını denetler. Değerler agent'ta
+// ortam değişkeni olarak verildiği için metinlerde kontrol karakterine izin verilmez.
+func coerceExecArg(spec ExecParam, raw interface{}) (interface{}, error) {
+	bad := func(reason string) error {
+		return fmt.Errorf("%w: %s %s", ErrInvalidExecArgs, spec.Name, reason)
+	}
+
+	switch spec.Type {
+	case "integer":
+		var n float64
+		switch v := raw.(type) {
+		case float64:
+			n = v
+		case int:
+			n = float64(v)
+		default:
+			return nil, bad("tam sayı olmalı")
+		}
+		if n != math.Trunc(n) || math.Abs(n) > 1<<53 {
+			return nil, bad("tam sayı olmalı")
+		}
+		if spec.Min != nil && n < float64(*spec.Min) {
+			return nil, bad(fmt.Sprintf("en az %d olmalı", *spec.Min))
+		}
+		if spec.Max != nil && n > float64(*spec.Max) {
+			return nil, bad(fmt.Sprintf("en fazla %d olmalı", *spec.Max))
+		}
+		return int64(n), nil
+
+	case "boolean":
+		b, ok := raw.(bool)
+		if !ok {
+			return nil, bad("true/false olmalı")
+		}
+		return b, nil
+
+	case "enum":
+		s, ok := raw.(string)
+		if !ok || !containsStr(spec.Enum, s) {
+			return nil, bad("şu değerlerden biri olmalı: " + strings.Join(spec.Enum, ", "))
+		}
+		return s, nil
+
+	case "string":
+		s, ok := raw.(string)
+		if !ok {
+			return nil, bad("metin olmalı")
+		}
+		maxLen := spec.MaxLength
+		if maxLen <= 0 {
+			maxLen = defaultParamMaxLen
+		}
+		if len(s) > maxLen {
+			return nil, bad(fmt.Sprintf("en fazla %d karakter olabilir", maxLen))
+		}
+		if strings.IndexFunc(s, unicode.IsControl) >= 0 {
+			return nil, bad("kontrol karakteri içeremez")
+		}
+		if spec.Pattern != "" {
+			re, err := regexp.Compile(`^(?:` + spec.Pattern + `)$`)
+			if err != nil || !re.MatchString(s) {
+				return nil, bad("biçime uymuyor")
+			}
+		}
+		return s, nil
+	}
+	return nil, bad("bilinmeyen tip")
+}
+
+func containsStr(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeExecCommand — oluşturma/güncelleme isteğini doğrular ve varsayılanları uygular.
+func normalizeExecCommand(req ExecCommandRequest) (ExecCommandRequest, error) {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidExecCommand, fmt.Sprintf(format, args...))
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if !execNamePattern.MatchString(req.Name) {
+		return req, invalid("ad küçük harf, rakam ve '-' içermeli (2-50 karakter)")
+	}
+	req.Description = strings.TrimSpace(req.Description)
+
+	if req.DefaultTimeoutSec == 0 {
+		req.DefaultTimeoutSec = defaultExecTimeout
+	}
+	if req.MaxTimeoutSec == 0 {
+		req.MaxTimeoutSec = maxExecTimeout
+	}
+	if req.DefaultTimeoutSec < 1 || req.MaxTimeoutSec > maxExecTimeout || req.DefaultTimeoutSec > req.MaxTimeoutSec {
+		return req, invalid("zaman aşımı 1 ≤ default_timeout_sec ≤ max_timeout_sec ≤ %d olmalı", maxExecTimeout)
+	}
+
+	if req.RequiredRole == "" {
+		req.RequiredRole = "operator"
+	}
+	if !slices.Contains(ExecRoles, req.RequiredRole) {
+		return req, invalid("required_role şunlardan biri olmalı: %s", strings.Join(ExecRoles, ", "))
+	}
+
+	if len(req.Params) > maxExecParams {
+		return req, invalid("en fazla %d parametre tanımlanabilir", maxExecParams)
+	}
+	if req.Params == nil {
+		req.Params = []ExecParam{}
+	}
+	seen := make(map[string]bool, len(req.Params))
+	for i := range req.Params {
+		p := &req.Params[i]
+		if !execParamNamePattern.MatchString(p.Name) {
+			return req, invalid("parametre adı %q geçersiz (küçük harf, rakam, '_')", p.Name)
+		}
+		if seen[p.Name] {
+			return req, invalid("parametre %s birden fazla tanımlanmış", p.Name)
+		}
+		seen[p.Name] = true
+
+		switch p.Type {
+		case "string":
+			if p.MaxLength < 0 || p.MaxLength > maxParamMaxLen {
+				return req, invalid("%s.max_length 0-%d aralığında olmalı", p.Name, maxParamMaxLen)
+			}
+			if len(p.Pattern) > maxParamPatternLen {
+				return req, invalid("%s.pattern en fazla %d karakter olabilir", p.Name, maxParamPatternLen)
+			}
+			if p.Pattern != "" {
+				if _, err := regexp.Compile(p.Pattern); err != nil {
+					return req, invalid("%s.pattern geçersiz: %v", p.Name, err)
+				}
+			}
+		case "integer":
+			if p.Min != nil && p.Max != nil && *p.Min > *p.Max {
+				return req, invalid("%s.min, max'tan büyük olamaz", p.Name)
+			}
+		case "enum":
+			if len(p.Enum) == 0 || len(p.Enum) > maxParamEnumValues {
+				return req, invalid("%s.enum 1-%d değer içermeli", p.Name, maxParamEnumValues)
+			}
+			for _, v := range p.Enum {
+				if v == "" || len(v) > 100 || strings.IndexFunc(v, unicode.IsControl) >= 0 {
+					return req, invalid("%s.enum değeri %q geçersiz", p.Name, v)
+				}
+			}
+		case "boolean":
+		default:
+			return req, invalid("%s.type string, integer, boolean veya enum olmalı", p.Name)
+		}
+		if p.Default != nil {
+			v, err := coerceExecArg(*p, p.Default)
+			if err != nil {
+				return req, invalid("%s.default şemaya uymuyor", p.Name)
+			}
+			p.Default = v
+		}
+	}
+	return req, nil
+}
+
+// ServiceRoleResolver — kullanıcının servis üzerindeki rolünü döndürür; erişimi yoksa "".
+type ServiceRoleResolver interface {
+	ServiceRole(ctx context.Context, serviceID, userID uuid.UUID) (string, error)
+}
+
+// ExecCatalog — servis bazında exec komut kataloğu: yerleşik diagnostikler ile servise özgü
+// girdilerin çözümlenmesi, yetki denetimi ve yönetimi.
+type ExecCatalog struct {
+	repo        *Repository
+	hub         *ws.Hub
+	roles       ServiceRoleResolver
+	auditLogger *audit.Logger
+}
+
+func NewExecCatalog(db *gorm.DB, hub *ws.Hub) *ExecCatalog {
+	repo := NewRepository(db)
+	return &ExecCatalog{
+		repo:        repo,
+		hub:         hub,
+		roles:       orgs.NewAuthorizer(db),
+		auditLogger: audit.New(db),
+	}
+}
+
+// List — servisin kataloğu (yerleşikler dahil, ada göre sıralı). Her girdi için komutu
+// uyguladığını bildiren bağlı agent'lar doldurulur.
+func (c *ExecCatalog) List(ctx context.Context, serviceID uuid.UUID) ([]ExecCommand, error) {
+	custom, err := c.repo.ListExecCommands(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	all := make([]ExecCommand, 0, len(builtinExecCommands)+len(custom))
+	all = append(all, builtinExecCommands...)
+	all = append(all, custom...)
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+
+	instances := c.hub.ListAgentInstances(serviceID.String())
+	for i := range all {
+		all[i].Agents = []string{}
+		for _, inst := range instances {
+			if inst.SupportsExec(all[i].Name, all[i].Builtin) {
+				all[i].Agents = append(all[i].Agents, inst.AgentID)
+			}
+		}
+	}
+	return all, nil
+}
+
+// Names — servisin etkin katalog girdilerinin adları (hata mesajları için).
+func (c *ExecCatalog) Names(ctx context.Context, serviceID uuid.UUID) []string {
+	cmds, err := c.List(ctx, serviceID)
+	if err != nil {
+		cmds = builtinExecCommands
+	}
+	names := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		if cmd.Enabled {
+			names = append(names, cmd.Name)
+		}
+	}
+	return names
+}
+
+// Resolve — adı servisin etkin katalog girdisine çözer.
+func (c *ExecCatalog) Resolve(ctx context.Context, serviceID uuid.UUID, name string) (*ExecCommand, error) {
+	if cmd, ok := builtinExecCommand(name); ok {
+		return cmd, nil
+	}
+	if !execNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: exec %q", ErrActionNotAllowed, name)
+	}
+	cmd, err := c.repo.GetExecCommand(ctx, serviceID, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !cmd.Enabled) {
+		return nil, fmt.Errorf("%w: exec %q", ErrActionNotAllowed, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// Authorize — kullanıcının servis üzerindeki rolünün girdinin gerektirdiği rolü karşıladığını doğrular.
+func (c *ExecCatalog) Authorize(ctx context.Context, serviceID, userID uuid.UUID, required string) error {
+	role, err := c.roles.ServiceRole(ctx, serviceID, userID)
+	if err != nil {
+		return err
+	}
+	if !orgs.RoleAllows(role, required) {
+		return ErrExecForbidden
+	}
+	return nil
+}
+
+// Build — adı çözer, yetkiyi denetler ve exec komutunu oluşturur.
+func (c *ExecCatalog) Build(ctx context.Context, serviceID, userID uuid.UUID, commandID string, params map[string]interface{}) (*ExecCommand, map[string]interface{}, error) {
+	name, _ := params["command"].(string)
+	entry, err := c.Resolve(ctx, serviceID, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := c.Authorize(ctx, serviceID, userID, entry.RequiredRole); err != nil {
+		return nil, nil, err
+	}
+	command, err := entry.Command(commandID, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry, command, nil
+}
+
+func (c *ExecCatalog) Create(ctx context.Context, serviceID, userID uuid.UUID, req ExecCommandRequest) (*ExecCommand, error) {
+	req, err := normalizeExecCommand(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := builtinExecCommand(req.Name); ok {
+		return nil, ErrExecCommandExists
+	}
+	if _, err := c.repo.GetExecCommand(ctx, serviceID, req.Name); err == nil {
+		return nil, ErrExecCommandExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	params, _ := json.Marshal(req.Params)
+	cmd := &ExecCommand{
+		ServiceID:         &serviceID,
+		Name:              req.Name,
+		Description:       req.Description,
+		Params:            params,
+		DefaultTimeoutSec: req.DefaultTimeoutSec,
+		MaxTimeoutSec:     req.MaxTimeoutSec,
+		RequiredRole:      req.RequiredRole,
+		Enabled:           req.Enabled == nil || *req.Enabled,
+		CreatedBy:         &userID,
+		Agents:            []string{},
+	}
+	if err := c.repo.CreateExecCommand(ctx, cmd); err != nil {
+		return nil, err
+	}
+	c.record(ctx, audit.ActionExecCommandCreate, serviceID, userID, cmd)
+	return cmd, nil
+}
+
+// Update — girdinin tanımını değiştirir; ad değiştirilemez.
+func (c *ExecCatalog) Update(ctx context.Context, serviceID, userID uuid.UUID, name string, req ExecCommandRequest) (*ExecCommand, error) {
+	if _, ok := builtinExecCommand(name); ok {
+		return nil, fmt.Errorf("%w: yerleşik komutlar değiştirilemez", ErrInvalidExecCommand)
+	}
+	cmd, err := c.repo.GetExecCommand(ctx, serviceID, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrExecCommandNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	req.Name = name
+	req, err = normalizeExecCommand(req)
+	if err != nil {
+		return nil, err
+	}
+	params, _ := json.Marshal(req.Params)
+	cmd.Description = req.Description
+	cmd.Params = params
+	cmd.DefaultTimeoutSec = req.DefaultTimeoutSec
+	cmd.MaxTimeoutSec = req.MaxTimeoutSec
+	cmd.RequiredRole = req.RequiredRole
+	if req.Enabled != nil {
+		cmd.Enabled = *req.Enabled
+	}
+	if err := c.repo.UpdateExecCommand(ctx, cmd); err != nil {
+		return nil, err
+	}
+	cmd.Agents = []string{}
+	c.record(ctx, audit.ActionExecCommandUpdate, serviceID, userID, cmd)
+	return cmd, nil
+}
+
+func (c *ExecCatalog) Delete(ctx context.Context, serviceID, userID uuid.UUID, name string) error {
+	if _, ok := builtinExecCommand(name); ok {
+		return fmt.Errorf("%w: yerleşik komutlar silinemez", ErrInvalidExecCommand)
+	}
+	deleted, err := c.repo.DeleteExecCommand(ctx, serviceID, name)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrExecCommandNotFound
+	}
+	c.record(ctx, audit.ActionExecCommandDelete, serviceID, userID, &ExecCommand{Name: name})
+	return nil
+}
+
+func (c *ExecCatalog) record(ctx context.Context, action audit.Action, serviceID, userID uuid.UUID, cmd *ExecCommand) {
+	c.auditLogger.Record(ctx, audit.Entry{
+		UserID:       &userID,
+		Action:       action,
+		ResourceType: "service",
+		ResourceID:   &serviceID,
+		Status:       audit.StatusSuccess,
+		Details: map[string]interface{}{
+			"name":          cmd.Name,
+			"required_role": cmd.RequiredRole,
+			"enabled":       cmd.Enabled,
+		},
+	})
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"nanonet-backend/internal/ws"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestNormalizeExecCommand(t *testing.T) {
+	req, err := normalizeExecCommand(ExecCommandRequest{
+		Name: "flush-cache",
+		Params: []ExecParam{
+			{Name: "db", Type: "integer", Min: intPtr(0), Max: intPtr(15), Default: 0.0},
+			{Name: "mode", Type: "enum", Enum: []string{"sync", "async"}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, defaultExecTimeout, req.DefaultTimeoutSec)
+	assert.Equal(t, maxExecTimeout, req.MaxTimeoutSec)
+	assert.Equal(t, "operator", req.RequiredRole)
+	assert.Equal(t, int64(0), req.Params[0].Default)
+
+	for _, bad := range []ExecCommandRequest{
+		{Name: "Flush Cache"},
+		{Name: "x"},
+		{Name: "ok-name", RequiredRole: "root"},
+		{Name: "ok-name", DefaultTimeoutSec: 120, MaxTimeoutSec: 60},
+		{Name: "ok-name", Params: []ExecParam{{Name: "a", Type: "file"}}},
+		{Name: "ok-name", Params: []ExecParam{{Name: "a", Type: "string"}, {Name: "a", Type: "string"}}},
+		{Name: "ok-name", Params: []ExecParam{{Name: "a", Type: "enum"}}},
+		{Name: "ok-name", Params: []ExecParam{{Name: "a", Type: "string", Pattern: "("}}},
+		{Name: "ok-name", Params: []ExecParam{{Name: "a", Type: "integer", Min: intPtr(5), Max: intPtr(1)}}},
+		{Name: "ok-name", Params: []ExecParam{{Name: "a", Type: "integer", Max: intPtr(3), Default: 9.0}}},
+	} {
+		_, err := normalizeExecCommand(bad)
+		assert.ErrorIs(t, err, ErrInvalidExecCommand, "%+v", bad)
+	}
+}
+
+func TestExecCommand_Command(t *testing.T) {
+	specs, _ := json.Marshal([]ExecParam{
+		{Name: "db", Type: "integer", Min: intPtr(0), Max: intPtr(15), Default: 0},
+		{Name: "pattern", Type: "string", Required: true, Pattern: `[a-z:*]+`},
+		{Name: "dry_run", Type: "boolean"},
+	})
+	entry := ExecCommand{Name: "flush-cache", Params: specs, DefaultTimeoutSec: 20, MaxTimeoutSec: 60}
+
+	command, err := entry.Command("c1", map[string]interface{}{
+		"timeout_sec": 600.0,
+		"args":        map[string]interface{}{"pattern": "session:*", "dry_run": true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 60, command["timeout_sec"], "girdinin üst sınırı uygulanmalı")
+	assert.Equal(t, map[string]interface{}{"db": int64(0), "pattern": "session:*", "dry_run": true}, command["args"])
+
+	raw, _ := json.Marshal(command)
+	msgType, err := ws.ValidateMessage(ws.FromBackend, raw)
+	require.NoError(t, err, string(raw))
+	assert.Equal(t, ws.MsgCommand, msgType)
+
+	for name, args := range map[string]map[string]interface{}{
+		"zorunlu eksik":     {},
+		"bilinmeyen":        {"pattern": "a", "extra": 1.0},
+		"biçim dışı":        {"pattern": "a; rm -rf /"},
+		"kontrol karakteri": {"pattern": "a\nb"},
+		"aralık dışı":       {"pattern": "a", "db": 16.0},
+		"ondalık":           {"pattern": "a", "db": 1.5},
+		"yanlış tip":        {"pattern": "a", "dry_run": "yes"},
+	} {
+		_, err := entry.Command("c1", map[string]interface{}{"args": args})
+		assert.ErrorIs(t, err, ErrInvalidExecArgs, name)
+	}
+}
+
+func TestValidateCommand_CustomExecResolvedAtDispatch(t *testing.T) {
+	assert.NoError(t, ValidateCommand("exec", map[string]interface{}{"command": "uptime"}))
+	assert.NoError(t, ValidateCommand("exec", map[string]interface{}{"command": "flush-cache"}))
+	assert.ErrorIs(t, ValidateCommand("exec", map[string]interface{}{"command": "rm -rf /"}), ErrActionNotAllowed)
+
+	_, err := BuildCommand("c1", "exec", map[string]interface{}{"command": "flush-cache"})
+	assert.ErrorIs(t, err, ErrActionNotAllowed, "servis kataloğu olmadan yalnızca yerleşikler oluşturulabilir")
+}
+
+func TestAgentInstance_SupportsExec(t *testing.T) {
+	legacy := ws.AgentInstance{ProtocolVersion: 2, Capabilities: []string{"exec"}}
+	assert.True(t, legacy.SupportsExec("uptime", true))
+	assert.False(t, legacy.SupportsExec("flush-cache", false), "katalog bildirmeyen agent özel komut çalıştıramaz")
+
+	current := ws.AgentInstance{ProtocolVersion: 2, Capabilities: []string{"exec"}, ExecCommands: []string{"flush-cache"}}
+	assert.True(t, current.SupportsExec("flush-cache", false))
+	assert.False(t, current.SupportsExec("uptime", true))
+
+	noExec := ws.AgentInstance{ProtocolVersion: 2, Capabilities: []string{"restart"}, ExecCommands: []string{"flush-cache"}}
+	assert.False(t, noExec.SupportsExec("flush-cache", false))
+}
@@ -1,10 +1,16 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"log"
+	"strings"
 	"time"
 
 	"nanonet-backend/internal/commands"
+	"nanonet-backend/internal/orgs"
 	"nanonet-backend/internal/ws"
+	"nanonet-backend/pkg/audit"
 	"nanonet-backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -16,6 +22,21 @@ type Handler struct {
 	service    *ServiceLayer
 	hub        *ws.Hub
 	cmdService *commands.Service
+	rolling    *RollingRestarter
+	approvals  approvalGate
+	configs    configNotifier
+	catalog    *ExecCatalog
+}
+
+// approvalGate — approvals.Service tarafından karşılanır; import döngüsünü önler.
+type approvalGate interface {
+	Submit(ctx context.Context, serviceID *uuid.UUID, requestedBy uuid.UUID, action, target string, params map[string]interface{}) (*uuid.UUID, error)
+}
+
+// configNotifier — agents.ConfigStore tarafından karşılanır; poll_interval_sec değişince
+// agent'lara yeni yapılandırma sürümü gönderilir.
+type configNotifier interface {
+	ConfigChanged(ctx context.Context, serviceID uuid.UUID) error
 }
 
 func NewHandler(db *gorm.DB, hub *ws.Hub) *Handler {
@@ -23,9 +44,21 @@ func NewHandler(db *gorm.DB, hub *ws.Hub) *Handler {
 		service:    NewServiceLayer(db),
 		hub:        hub,
 		cmdService: commands.NewService(db),
+		rolling:    NewRollingRestarter(db, hub),
+		catalog:    NewExecCatalog(db, hub),
 	}
 }
 
+// SetApprovalGate wires in the two-person approval check for destructive commands.
+func (h *Handler) SetApprovalGate(g approvalGate) {
+	h.approvals = g
+}
+
+// SetConfigNotifier wires in agent config pushes for service fields agents consume.
+func (h *Handler) SetConfigNotifier(n configNotifier) {
+	h.configs = n
+}
+
 func (h *Handler) Create(c *gin.Context) {
 	userID, err := uuid.Parse(c.GetString("user_id"))
 	if err != nil {
@@ -40,10 +73,16 @@ func (h *Handler) Create(c *gin.Context) {
 	}
 
 	service, err := h.service.Create(c.Request.Context(), userID, req)
+	if errors.Is(err, ErrOrgForbidden) {
+		response.Forbidden(c, err.Error())
+		return
+	}
 	if err != nil {
 		response.InternalError(c, "servis oluşturulamadı")
 		return
 	}
+	audit.SetResource(c, service.ID)
+	audit.After(c, service)
 
 	response.Created(c, service)
 }
@@ -69,12 +108,14 @@ func (h *Handler) Get(c *gin.Context) {
 
 	type serviceWithAgent struct {
 		Service
-		AgentConnected bool `json:"agent_connected"`
+		AgentConnected bool       `json:"agent_connected"`
+		AgentLink      *AgentLink `json:"agent_link,omitempty"`
 	}
 
 	response.Success(c, serviceWithAgent{
 		Service:        *service,
 		AgentConnected: h.hub.IsAgentConnected(id.String()),
+		AgentLink:      agentLink(h.hub.ListAgentInstances(id.String())),
 	})
 }
 
@@ -85,7 +126,12 @@ func (h *Handler) List(c *gin.Context) {
 		return
 	}
 
-	services, err := h.service.List(c.Request.Context(), userID)
+	var services []Service
+	if tag := strings.ToLower(strings.TrimSpace(c.Query("tag"))); tag != "" {
+		services, err = h.service.ListByTag(c.Request.Context(), userID, tag)
+	} else {
+		services, err = h.service.List(c.Request.Context(), userID)
+	}
 	if err != nil {
 		response.InternalError(c, "servisler listelenemedi")
 		return
@@ -126,11 +172,21 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
+	if before, err := h.service.Get(c.Request.Context(), id, userID); err == nil {
+		audit.Before(c, before)
+	}
 	service, err := h.service.Update(c.Request.Context(), id, userID, req)
 	if err != nil {
 		response.InternalError(c, "servis güncellenemedi")
 		return
 	}
+	audit.After(c, service)
+
+	if req.PollIntervalSec != nil && h.configs != nil {
+		if err := h.configs.ConfigChanged(c.Request.Context(), id); err != nil {
+			log.Printf("[services] agent yapılandırması gönderilemedi service=%s: %v", id, err)
+		}
+	}
 
 	response.Success(c, service)
 }
@@ -148,6 +204,9 @@ func (h *Handler) Delete(c *gin.Context) {
 		return
 	}
 
+	if before, err := h.service.Get(c.Request.Context(), id, userID); err == nil {
+		audit.Before(c, before)
+	}
 	if err := h.service.Delete(c.Request.Context(), id, userID); err != nil {
 		response.InternalError(c, "servis silinemedi")
 		return
@@ -181,7 +240,10 @@ func (h *Handler) Restart(c *gin.Context) {
 	}
 
 	var req struct {
-		TimeoutSec int `json:"timeout_sec"`
+		TimeoutSec       int    `json:"timeout_sec"`
+		AgentID          string `json:"agent_id"`
+		Strategy         string `json:"strategy"`
+		HealthTimeoutSec int    `json:"health_timeout_sec"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		req.TimeoutSec = 30
@@ -189,6 +251,39 @@ func (h *Handler) Restart(c *gin.Context) {
 	if req.TimeoutSec <= 0 {
 		req.TimeoutSec = 30
 	}
+	audit.Annotate(c, "request", req)
+
+	switch req.Strategy {
+	case "", "all":
+	case "rolling":
+		if req.AgentID != "" {
+			response.BadRequest(c, "rolling stratejisi agent_id ile birlikte kullanılamaz")
+			return
+		}
+		if req.HealthTimeoutSec < 0 || req.HealthTimeoutSec > 1800 {
+			response.BadRequest(c, "health_timeout_sec 0-1800 aralığında olmalı")
+			return
+		}
+		rollout, err := h.rolling.Start(id, userID, req.TimeoutSec, req.HealthTimeoutSec)
+		if err != nil {
+			if errors.Is(err, ErrNoInstances) || errors.Is(err, ErrRolloutRunning) {
+				response.Error(c, 409, err.Error())
+				return
+			}
+			response.InternalError(c, "rolling restart başlatılamadı")
+			return
+		}
+		audit.Annotate(c, "rollout_id", rollout.ID)
+		response.Success(c, rollout)
+		return
+	default:
+		response.BadRequest(c, "geçersiz strateji — izin verilenler: all, rolling")
+		return
+	}
+
+	if !h.checkAgent(c, id, req.AgentID, "restart") {
+		return
+	}
 
 	commandID := uuid.New().String()
 	command := map[string]interface{}{
@@ -202,8 +297,12 @@ func (h *Handler) Restart(c *gin.Context) {
 		response.InternalError(c, "komut kaydedilemedi")
 		return
 	}
+	audit.Annotate(c, "command_id", commandID)
 
-	sent := h.hub.SendCommandToAgent(id.String(), command)
+	sent, ok := h.send(c, id, req.AgentID, commandID, command)
+	if !ok {
+		return
+	}
 	status := "sent"
 	if !sent {
 		status = "queued"
@@ -242,7 +341,8 @@ func (h *Handler) Stop(c *gin.Context) {
 	}
 
 	var req struct {
-		Graceful *bool `json:"graceful"`
+		Graceful *bool  `json:"graceful"`
+		AgentID  string `json:"agent_id"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		defaultGraceful := true
@@ -252,6 +352,18 @@ func (h *Handler) Stop(c *gin.Context) {
 		defaultGraceful := true
 		req.Graceful = &defaultGraceful
 	}
+	audit.Annotate(c, "request", req)
+
+	if !h.checkAgent(c, id, req.AgentID, "stop") {
+		return
+	}
+
+	if h.awaitApproval(c, id, userID, "stop", map[string]interface{}{
+		"graceful": *req.Graceful,
+		"agent_id": req.AgentID,
+	}) {
+		return
+	}
 
 	commandID := uuid.New().String()
 	command := map[string]interface{}{
@@ -265,8 +377,12 @@ func (h *Handler) Stop(c *gin.Context) {
 		response.InternalError(c, "komut kaydedilemedi")
 		return
 	}
+	audit.Annotate(c, "command_id", commandID)
 
-	sent := h.hub.SendCommandToAgent(id.String(), command)
+	sent, ok := h.send(c, id, req.AgentID, commandID, command)
+	if !ok {
+		return
+	}
 	status := "sent"
 	if !sent {
 		status = "queued"
@@ -280,19 +396,10 @@ func (h *Handler) Stop(c *gin.Context) {
 	})
 }
 
-// allowedExecCommands — agent üzerinde çalıştırılmasına izin verilen komutların beyaz listesi.
-// Arbitrary shell execution güvenlik riski oluşturur; yalnızca onaylanmış diagnostik komutlar kabul edilir.
-var allowedExecCommands = map[string]bool{
-	"status":       true,
-	"logs":         true,
-	"health-check": true,
-	"version":      true,
-	"uptime":       true,
-	"df-h":         true,
-	"free-m":       true,
-	"top-snapshot": true,
-}
-
+// Exec — POST /services/:id/exec
+// Servisin exec kataloğundaki adlandırılmış bir diagnostiği çalıştırır. Keyfi shell komutu
+// kabul edilmez; komutun kendisi agent'ta tanımlıdır, backend yalnızca adı ve şemaya göre
+// doğrulanmış parametreleri (args) gönderir.
 func (h *Handler) Exec(c *gin.Context) {
 	userID, err := uuid.Parse(c.GetString("user_id"))
 	if err != nil {
@@ -312,42 +419,60 @@ func (h *Handler) Exec(c *gin.Context) {
 	}
 
 	var req struct {
-		Command    string `json:"command" binding:"required"`
-		TimeoutSec int    `json:"timeout_sec"`
+		Command    string                 `json:"command" binding:"required"`
+		TimeoutSec int                    `json:"timeout_sec"`
+		AgentID    string                 `json:"agent_id"`
+		Args       map[string]interface{} `json:"args"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.ValidationError(c, err)
 		return
 	}
+	audit.Annotate(c, "request", req)
 
-	// Güvenlik: Yalnızca beyaz listedeki komutlara izin ver
-	if !allowedExecCommands[req.Command] {
-		response.BadRequest(c, "bu komut izin verilmiyor — yalnızca şu komutlar kullanılabilir: status, logs, health-check, version, uptime, df-h, free-m, top-snapshot")
-		return
+	commandID := uuid.New().String()
+	params := map[string]interface{}{
+		"command":     req.Command,
+		"timeout_sec": req.TimeoutSec,
 	}
-
-	if req.TimeoutSec <= 0 {
-		req.TimeoutSec = 30
+	if req.Args != nil {
+		params["args"] = req.Args
 	}
-	if req.TimeoutSec > 300 {
-		req.TimeoutSec = 300
+	entry, command, err := h.catalog.Build(c.Request.Context(), id, userID, commandID, params)
+	switch {
+	case errors.Is(err, ErrActionNotAllowed):
+		response.BadRequest(c, "bu komut izin verilmiyor — yalnızca şu komutlar kullanılabilir: "+
+			strings.Join(h.catalog.Names(c.Request.Context(), id), ", "))
+		return
+	case errors.Is(err, ErrExecForbidden):
+		response.Forbidden(c, err.Error())
+		return
+	case errors.Is(err, ErrInvalidExecArgs), errors.Is(err, ErrInvalidExecCommand):
+		response.BadRequest(c, err.Error())
+		return
+	case err != nil:
+		response.InternalError(c, "komut oluşturulamadı")
+		return
 	}
 
-	commandID := uuid.New().String()
-	command := map[string]interface{}{
-		"type":        "command",
-		"command_id":  commandID,
-		"action":      "exec",
-		"command":     req.Command,
-		"timeout_sec": req.TimeoutSec,
+	if !h.checkAgent(c, id, req.AgentID, "exec") {
+		return
+	}
+	if err := h.hub.CheckExecCommand(id.String(), req.AgentID, entry.Name, entry.Builtin); err != nil {
+		response.Error(c, 422, err.Error())
+		return
 	}
 
 	if err := h.cmdService.LogCommand(c.Request.Context(), id, userID, commandID, "exec", command); err != nil {
 		response.InternalError(c, "komut kaydedilemedi")
 		return
 	}
+	audit.Annotate(c, "command_id", commandID)
 
-	sent := h.hub.SendCommandToAgent(id.String(), command)
+	sent, ok := h.send(c, id, req.AgentID, commandID, command)
+	if !ok {
+		return
+	}
 	status := "sent"
 	if !sent {
 		status = "queued"
@@ -379,6 +504,17 @@ func (h *Handler) Start(c *gin.Context) {
 		return
 	}
 
+	// Gövde opsiyonel; yalnızca agent_id hedeflemesi için okunur.
+	var req struct {
+		AgentID string `json:"agent_id"`
+	}
+	_ = c.ShouldBindJSON(&req)
+	audit.Annotate(c, "request", req)
+
+	if !h.checkAgent(c, id, req.AgentID, "start") {
+		return
+	}
+
 	commandID := uuid.New().String()
 	command := map[string]interface{}{
 		"type":       "command",
@@ -390,8 +526,12 @@ func (h *Handler) Start(c *gin.Context) {
 		response.InternalError(c, "komut kaydedilemedi")
 		return
 	}
+	audit.Annotate(c, "command_id", commandID)
 
-	sent := h.hub.SendCommandToAgent(id.String(), command)
+	sent, ok := h.send(c, id, req.AgentID, commandID, command)
+	if !ok {
+		return
+	}
 	status := "sent"
 	if !sent {
 		status = "queued"
@@ -405,6 +545,108 @@ func (h *Handler) Start(c *gin.Context) {
 	})
 }
 
+// CancelCommand — POST /services/:id/commands/:commandId/cancel
+// Henüz iletilmemiş komut kuyruktan çıkarılır; iletilmişse agent'a iptal mesajı gönderilir.
+func (h *Handler) CancelCommand(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz servis ID")
+		return
+	}
+
+	if _, err := h.service.Get(c.Request.Context(), id, userID); err != nil {
+		response.NotFound(c, "servis bulunamadı")
+		return
+	}
+
+	commandID := c.Param("commandId")
+	cl, err := h.cmdService.Cancel(c.Request.Context(), id, commandID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, commands.ErrCommandNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, commands.ErrCommandFinished):
+			response.Error(c, 409, err.Error())
+		default:
+			response.InternalError(c, "komut iptal edilemedi")
+		}
+		return
+	}
+
+	delivery := "dequeued"
+	if !h.hub.RemovePendingCommand(id.String(), commandID) {
+		delivery = "agent_offline"
+		if h.hub.SendCancelToAgents(id.String(), commandID) {
+			delivery = "cancel_sent"
+		}
+	}
+	h.hub.BroadcastCommandStatus(id.String(), commandID, commands.StatusCancelled)
+
+	response.Success(c, gin.H{
+		"command":         cl,
+		"cancel_delivery": delivery,
+	})
+}
+
+// checkAgent — agent_id verilmişse instance'ın servise bağlı olduğunu, ardından
+// hedefin aksiyonu yetenek olarak bildirdiğini doğrular.
+func (h *Handler) checkAgent(c *gin.Context, serviceID uuid.UUID, agentID, action string) bool {
+	if agentID != "" {
+		if _, ok := h.hub.GetAgentInstance(serviceID.String(), agentID); !ok {
+			response.NotFound(c, ErrAgentNotConnected.Error())
+			return false
+		}
+	}
+	if err := h.hub.CheckCapability(serviceID.String(), agentID, action); err != nil {
+		response.Error(c, 422, err.Error())
+		return false
+	}
+	return true
+}
+
+// awaitApproval — aksiyon için onay politikası varsa bekleyen bir istek oluşturur ve yanıtı yazar.
+// true dönerse komut gönderilmemelidir; onaylandığında approvals servisi komutu gönderir.
+func (h *Handler) awaitApproval(c *gin.Context, serviceID, userID uuid.UUID, action string, params map[string]interface{}) bool {
+	if h.approvals == nil {
+		return false
+	}
+	approvalID, err := h.approvals.Submit(c.Request.Context(), &serviceID, userID, action, "", params)
+	if err != nil {
+		response.InternalError(c, "onay isteği oluşturulamadı")
+		return true
+	}
+	if approvalID == nil {
+		return false
+	}
+	audit.Annotate(c, "approval_id", approvalID)
+	response.Success(c, gin.H{
+		"approval_id": approvalID,
+		"status":      "pending_approval",
+	})
+	return true
+}
+
+// send — komutu agent_id verilmişse yalnızca o instance'a, değilse servisin tüm agent'larına iletir.
+// Hedefli gönderim başarısız olursa komut "failed" işaretlenir, yanıt yazılır ve ok=false döner.
+func (h *Handler) send(c *gin.Context, serviceID uuid.UUID, agentID, commandID string, command map[string]interface{}) (sent, ok bool) {
+	if agentID == "" {
+		return h.hub.SendCommandToAgent(serviceID.String(), command), true
+	}
+	if h.hub.SendCommandToAgentInstance(serviceID.String(), agentID, command) {
+		return true, true
+	}
+	msg := ErrAgentNotConnected.Error()
+	_ = h.cmdService.RecordResult(c.Request.Context(), commandID, "failed", &msg)
+	response.NotFound(c, msg)
+	return false, false
+}
+
 func (h *Handler) Scale(c *gin.Context) {
 	userID, err := uuid.Parse(c.GetString("user_id"))
 	if err != nil {
@@ -435,18 +677,24 @@ func (h *Handler) Scale(c *gin.Context) {
 	if req.Strategy == "" {
 		req.Strategy = "round_robin"
 	}
-	allowedStrategies := map[string]bool{
-		"round_robin": true,
-		"least_conn":  true,
-		"ip_hash":     true,
-		"random":      true,
-		"weighted":    true,
-	}
-	if !allowedStrategies[req.Strategy] {
+	audit.Annotate(c, "request", req)
+	if !allowedScaleStrategies[req.Strategy] {
 		response.BadRequest(c, "geçersiz strateji — izin verilenler: round_robin, least_conn, ip_hash, random, weighted")
 		return
 	}
 
+	if !h.checkAgent(c, id, "", "scale") {
+		return
+	}
+
+	if h.awaitApproval(c, id, userID, "scale", map[string]interface{}{
+		"instances":     req.Instances,
+		"strategy":      req.Strategy,
+		"weight_config": req.WeightJSON,
+	}) {
+		return
+	}
+
 	commandID := uuid.New().String()
 	command := map[string]interface{}{
 		"type":          "command",
@@ -461,6 +709,7 @@ func (h *Handler) Scale(c *gin.Context) {
 		response.InternalError(c, "komut kaydedilemedi")
 		return
 	}
+	audit.Annotate(c, "command_id", commandID)
 
 	sent := h.hub.SendCommandToAgent(id.String(), command)
 	status := "sent"
@@ -499,26 +748,177 @@ func (h *Handler) Ping(c *gin.Context) {
 
 	agentReachable := h.hub.IsAgentConnected(id.String())
 
-	var latencyMs *float64
-	commandID := uuid.New().String()
+	// latency_ms hub'ın WebSocket ping/pong ile ölçtüğü son RTT'dir; yeni bir ölçüm başlatılır,
+	// sonucu sonraki istekte ve servis detayında görünür.
 	if agentReachable {
-		start := time.Now()
-		command := map[string]interface{}{
+		h.hub.ProbeAgentLinks(id.String())
+		h.hub.SendCommandToAgent(id.String(), map[string]interface{}{
 			"type":       "command",
-			"command_id": commandID,
+			"command_id": uuid.New().String(),
 			"action":     "ping",
-		}
-		h.hub.SendCommandToAgent(id.String(), command)
-		elapsed := float64(time.Since(start).Microseconds()) / 1000.0
-		latencyMs = &elapsed
+		})
 	}
+	link := agentLink(h.hub.ListAgentInstances(id.String()))
 
 	serviceStatus := svc.Status
 	serviceReachable := serviceStatus == "up" || serviceStatus == "degraded"
 
+	var latencyMs *float64
+	if link != nil {
+		latencyMs = link.RTTMs
+	}
 	response.Success(c, gin.H{
 		"agent_reachable":   agentReachable,
 		"service_reachable": serviceReachable,
 		"latency_ms":        latencyMs,
+		"agent_link":        link,
 	})
 }
+
+var linkQualityRank = map[string]int{ws.LinkUnknown: 0, ws.LinkGood: 1, ws.LinkDegraded: 2, ws.LinkPoor: 3}
+
+// agentLink — bağlı instance'ların bağlantı kalitesini özetler. Genel kalite en kötü instance'ın
+// kalitesidir; RTT en düşük son ölçümdür. Bağlı instance yoksa nil döner.
+func agentLink(instances []ws.AgentInstance) *AgentLink {
+	if len(instances) == 0 {
+		return nil
+	}
+	link := &AgentLink{Quality: ws.LinkUnknown, Agents: make([]AgentLinkDetail, 0, len(instances))}
+	for _, inst := range instances {
+		quality := inst.LinkQuality()
+		if linkQualityRank[quality] > linkQualityRank[link.Quality] {
+			link.Quality = quality
+		}
+		if inst.RTTMs != nil && (link.RTTMs == nil || *inst.RTTMs < *link.RTTMs) {
+			link.RTTMs = inst.RTTMs
+		}
+		link.Agents = append(link.Agents, AgentLinkDetail{
+			AgentID:     inst.AgentID,
+			Quality:     quality,
+			ConnectedAt: inst.ConnectedAt,
+			RTTMs:       inst.RTTMs,
+			RTTAvgMs:    inst.RTTAvgMs,
+			LastPongAt:  inst.LastPongAt,
+			Reconnects:  inst.Reconnects,
+			SendDrops:   inst.SendDrops,
+		})
+	}
+	return link
+}
+
+// ListExecCommands — GET /services/:id/exec-commands
+// Servisin exec kataloğunu (yerleşikler dahil) ve her girdiyi uygulayan bağlı agent'ları döndürür.
+func (h *Handler) ListExecCommands(c *gin.Context) {
+	serviceID, _, ok := h.authorizeCatalog(c, "viewer")
+	if !ok {
+		return
+	}
+
+	cmds, err := h.catalog.List(c.Request.Context(), serviceID)
+	if err != nil {
+		response.InternalError(c, "exec kataloğu alınamadı")
+		return
+	}
+	response.Success(c, gin.H{"commands": cmds, "roles": ExecRoles})
+}
+
+// CreateExecCommand — POST /services/:id/exec-commands
+func (h *Handler) CreateExecCommand(c *gin.Context) {
+	serviceID, userID, ok := h.authorizeCatalog(c, execCatalogEditRole)
+	if !ok {
+		return
+	}
+
+	var req ExecCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	cmd, err := h.catalog.Create(c.Request.Context(), serviceID, userID, req)
+	if !h.catalogError(c, err) {
+		return
+	}
+	response.Created(c, cmd)
+}
+
+// UpdateExecCommand — PUT /services/:id/exec-commands/:name
+func (h *Handler) UpdateExecCommand(c *gin.Context) {
+	serviceID, userID, ok := h.authorizeCatalog(c, execCatalogEditRole)
+	if !ok {
+		return
+	}
+
+	var req ExecCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	cmd, err := h.catalog.Update(c.Request.Context(), serviceID, userID, c.Param("name"), req)
+	if !h.catalogError(c, err) {
+		return
+	}
+	response.Success(c, cmd)
+}
+
+// DeleteExecCommand — DELETE /services/:id/exec-commands/:name
+func (h *Handler) DeleteExecCommand(c *gin.Context) {
+	serviceID, userID, ok := h.authorizeCatalog(c, execCatalogEditRole)
+	if !ok {
+		return
+	}
+
+	err := h.catalog.Delete(c.Request.Context(), serviceID, userID, c.Param("name"))
+	if !h.catalogError(c, err) {
+		return
+	}
+	response.Success(c, gin.H{"message": "exec komutu silindi"})
+}
+
+// authorizeCatalog — kullanıcının servis üzerindeki rolünün required'ı karşıladığını doğrular.
+func (h *Handler) authorizeCatalog(c *gin.Context, required string) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "geçersiz servis ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	role, err := h.catalog.roles.ServiceRole(c.Request.Context(), serviceID, userID)
+	if err != nil {
+		response.InternalError(c, "servis doğrulanamadı")
+		return uuid.Nil, uuid.Nil, false
+	}
+	if role == "" {
+		response.NotFound(c, "servis bulunamadı")
+		return uuid.Nil, uuid.Nil, false
+	}
+	if !orgs.RoleAllows(role, required) {
+		response.Forbidden(c, ErrExecForbidden.Error())
+		return uuid.Nil, uuid.Nil, false
+	}
+	return serviceID, userID, true
+}
+
+// catalogError — katalog hatasını yanıta çevirir; hata yoksa true döner.
+func (h *Handler) catalogError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, ErrInvalidExecCommand):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, ErrExecCommandExists):
+		response.Error(c, 409, err.Error())
+	case errors.Is(err, ErrExecCommandNotFound):
+		response.NotFound(c, err.Error())
+	default:
+		response.InternalError(c, "exec kataloğu güncellenemedi")
+	}
+	return false
+}
@@ -1,37 +1,113 @@
 package services
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type Service struct {
-	ID              uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID          uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
-	Name            string     `gorm:"type:varchar(100);not null" json:"name"`
-	Host            string     `gorm:"type:varchar(255);not null" json:"host"`
-	Port            int        `gorm:"not null" json:"port"`
-	HealthEndpoint  string     `gorm:"type:varchar(255);not null;default:'/health'" json:"health_endpoint"`
-	PollIntervalSec int        `gorm:"not null;default:10" json:"poll_interval_sec"`
-	Status          string     `gorm:"type:varchar(20);not null;default:'unknown'" json:"status"`
-	AgentID         *uuid.UUID `gorm:"type:uuid" json:"agent_id,omitempty"`
-	CreatedAt       time.Time  `gorm:"not null;default:now()" json:"created_at"`
-	UpdatedAt       time.Time  `gorm:"not null;default:now()" json:"updated_at"`
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	// UserID — servisi oluşturan kullanıcı; erişim OrgID üzerinden belirlenir.
+	UserID          uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
+	OrgID           uuid.UUID      `gorm:"type:uuid;not null" json:"org_id"`
+	Name            string         `gorm:"type:varchar(100);not null" json:"name"`
+	Host            string         `gorm:"type:varchar(255);not null" json:"host"`
+	Port            int            `gorm:"not null" json:"port"`
+	HealthEndpoint  string         `gorm:"type:varchar(255);not null;default:'/health'" json:"health_endpoint"`
+	PollIntervalSec int            `gorm:"not null;default:10" json:"poll_interval_sec"`
+	Status          string         `gorm:"type:varchar(20);not null;default:'unknown'" json:"status"`
+	AgentID         *uuid.UUID     `gorm:"type:uuid" json:"agent_id,omitempty"`
+	Tags            pq.StringArray `gorm:"type:text[];not null;default:'{}'" json:"tags"`
+	CreatedAt       time.Time      `gorm:"not null;default:now()" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"not null;default:now()" json:"updated_at"`
+}
+
+// AgentLink — servis detayında gösterilen agent bağlantı kalitesi. Backend'in ölçtüğü RTT,
+// yeniden bağlanmalar ve atlanan mesajlar; servisin kendi sağlığından bağımsızdır.
+type AgentLink struct {
+	Quality string            `json:"quality"`
+	RTTMs   *float64          `json:"rtt_ms,omitempty"`
+	Agents  []AgentLinkDetail `json:"agents"`
+}
+
+// AgentLinkDetail — tek agent instance'ının bağlantı kalitesi.
+type AgentLinkDetail struct {
+	AgentID     string     `json:"agent_id"`
+	Quality     string     `json:"quality"`
+	ConnectedAt time.Time  `json:"connected_at"`
+	RTTMs       *float64   `json:"rtt_ms,omitempty"`
+	RTTAvgMs    *float64   `json:"rtt_avg_ms,omitempty"`
+	LastPongAt  *time.Time `json:"last_pong_at,omitempty"`
+	Reconnects  int        `json:"reconnects"`
+	SendDrops   int64      `json:"send_drops"`
 }
 
 type CreateServiceRequest struct {
-	Name            string `json:"name" binding:"required,min=2,max=100"`
-	Host            string `json:"host" binding:"required"`
-	Port            int    `json:"port" binding:"required,min=1,max=65535"`
-	HealthEndpoint  string `json:"health_endpoint" binding:"required"`
-	PollIntervalSec int    `json:"poll_interval_sec" binding:"required,min=5,max=300"`
+	Name            string   `json:"name" binding:"required,min=2,max=100"`
+	Host            string   `json:"host" binding:"required"`
+	Port            int      `json:"port" binding:"required,min=1,max=65535"`
+	HealthEndpoint  string   `json:"health_endpoint" binding:"required"`
+	PollIntervalSec int      `json:"poll_interval_sec" binding:"omitempty,min=5,max=300"` // 0 → kullanıcı ayarı
+	Tags            []string `json:"tags" binding:"omitempty,max=20,dive,min=1,max=50"`
+	// OrgID — servisin oluşturulacağı organizasyon (admin rolü gerekir); boşsa kişisel organizasyon.
+	OrgID *uuid.UUID `json:"org_id,omitempty"`
 }
 
 type UpdateServiceRequest struct {
-	Name            *string `json:"name,omitempty" binding:"omitempty,min=2,max=100"`
-	Host            *string `json:"host,omitempty"`
-	Port            *int    `json:"port,omitempty" binding:"omitempty,min=1,max=65535"`
-	HealthEndpoint  *string `json:"health_endpoint,omitempty"`
-	PollIntervalSec *int    `json:"poll_interval_sec,omitempty" binding:"omitempty,min=5,max=300"`
+	Name            *string  `json:"name,omitempty" binding:"omitempty,min=2,max=100"`
+	Host            *string  `json:"host,omitempty"`
+	Port            *int     `json:"port,omitempty" binding:"omitempty,min=1,max=65535"`
+	HealthEndpoint  *string  `json:"health_endpoint,omitempty"`
+	PollIntervalSec *int     `json:"poll_interval_sec,omitempty" binding:"omitempty,min=5,max=300"`
+	Tags            []string `json:"tags,omitempty" binding:"omitempty,max=20,dive,min=1,max=50"`
+}
+
+// ExecCommand — exec aksiyonuyla çalıştırılabilen adlandırılmış diagnostik. Yerleşik girdiler
+// (Builtin) kodda tanımlıdır ve kaydedilmez; servise özgü girdiler service_exec_commands'ta tutulur.
+type ExecCommand struct {
+	ID                *uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id,omitempty"`
+	ServiceID         *uuid.UUID      `gorm:"type:uuid" json:"service_id,omitempty"`
+	Name              string          `gorm:"type:varchar(50);not null" json:"name"`
+	Description       string          `gorm:"type:varchar(500);not null;default:''" json:"description"`
+	Params            json.RawMessage `gorm:"type:jsonb;not null;default:'[]'" json:"params"`
+	DefaultTimeoutSec int             `gorm:"not null;default:30" json:"default_timeout_sec"`
+	MaxTimeoutSec     int             `gorm:"not null;default:300" json:"max_timeout_sec"`
+	RequiredRole      string          `gorm:"type:varchar(20);not null;default:'operator'" json:"required_role"`
+	Enabled           bool            `gorm:"not null;default:true" json:"enabled"`
+	CreatedBy         *uuid.UUID      `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt         time.Time       `gorm:"not null;default:now()" json:"created_at"`
+	UpdatedAt         time.Time       `gorm:"not null;default:now()" json:"updated_at"`
+
+	Builtin bool `gorm:"-" json:"builtin"`
+	// Agents — komutu uyguladığını bildiren bağlı agent'lar; listelemede doldurulur.
+	Agents []string `gorm:"-" json:"agents"`
+}
+
+func (ExecCommand) TableName() string { return "service_exec_commands" }
+
+// ExecParam — katalog girdisinin kabul ettiği tek parametrenin şeması.
+type ExecParam struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"` // string | integer | boolean | enum
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Enum        []string    `json:"enum,omitempty"`
+	Min         *int        `json:"min,omitempty"`
+	Max         *int        `json:"max,omitempty"`
+	MaxLength   int         `json:"max_length,omitempty"`
+	Pattern     string      `json:"pattern,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+}
+
+type ExecCommandRequest struct {
+	Name              string      `json:"name"`
+	Description       string      `json:"description" binding:"max=500"`
+	Params            []ExecParam `json:"params" binding:"max=10"`
+	DefaultTimeoutSec int         `json:"default_timeout_sec" binding:"omitempty,min=1,max=300"`
+	MaxTimeoutSec     int         `json:"max_timeout_sec" binding:"omitempty,min=1,max=300"`
+	RequiredRole      string      `json:"required_role"`
+	Enabled           *bool       `json:"enabled"`
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"time"
 
+	"nanonet-backend/internal/orgs"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -23,29 +25,69 @@ func (r *Repository) Create(ctx context.Context, service *Service) error {
 	return r.db.WithContext(ctx).Create(service).Error
 }
 
+// DefaultPollInterval — kullanıcının ayarlarındaki toplama aralığı; ayar kaydı yoksa 10 sn.
+func (r *Repository) DefaultPollInterval(ctx context.Context, userID uuid.UUID) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var intervals []int
+	err := r.db.WithContext(ctx).
+		Table("user_settings").
+		Where("user_id = ?", userID).
+		Pluck("poll_interval_sec", &intervals).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(intervals) == 0 {
+		return 10, nil
+	}
+	return intervals[0], nil
+}
+
+// GetByID — kullanıcının en az viewer rolüyle erişebildiği servis.
 func (r *Repository) GetByID(ctx context.Context, id, userID uuid.UUID) (*Service, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	var service Service
 	err := r.db.WithContext(ctx).
-		Where("id = ? AND user_id = ?", id, userID).
+		Where("id = ? AND id IN (?)", id, orgs.AccessibleServices(r.db, userID, orgs.RoleViewer)).
 		First(&service).Error
 	return &service, err
 }
 
+// List — kullanıcının erişebildiği tüm servisler (üyesi olduğu organizasyonlar ve takımlar).
 func (r *Repository) List(ctx context.Context, userID uuid.UUID) ([]Service, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	var services []Service
 	err := r.db.WithContext(ctx).
-		Where("user_id = ?", userID).
+		Where("id IN (?)", orgs.AccessibleServices(r.db, userID, orgs.RoleViewer)).
 		Order("created_at DESC").
 		Find(&services).Error
 	return services, err
 }
 
+// ListTargets — kullanıcının en az need rolüne sahip olduğu servislerden ID listesiyle veya
+// etiketle eşleşenleri döndürür. İkisi birlikte verilirse ikisine de uyan servisler seçilir.
+func (r *Repository) ListTargets(ctx context.Context, userID uuid.UUID, need string, ids []uuid.UUID, tag string) ([]Service, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := r.db.WithContext(ctx).Where("id IN (?)", orgs.AccessibleServices(r.db, userID, need))
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	if tag != "" {
+		query = query.Where("? = ANY(tags)", tag)
+	}
+
+	var services []Service
+	err := query.Order("name ASC").Find(&services).Error
+	return services, err
+}
+
 func (r *Repository) Update(ctx context.Context, service *Service) error {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
@@ -59,10 +101,20 @@ func (r *Repository) Delete(ctx context.Context, id, userID uuid.UUID) error {
 	defer cancel()
 
 	return r.db.WithContext(ctx).
-		Where("id = ? AND user_id = ?", id, userID).
+		Where("id = ? AND id IN (?)", id, orgs.AccessibleServices(r.db, userID, orgs.RoleAdmin)).
 		Delete(&Service{}).Error
 }
 
+// OrgRole — kullanıcının organizasyondaki rolü; üye değilse "".
+func (r *Repository) OrgRole(ctx context.Context, orgID, userID uuid.UUID) (string, error) {
+	return orgs.OrgRole(ctx, r.db, orgID, userID)
+}
+
+// PersonalOrgID — kullanıcının kişisel organizasyonu.
+func (r *Repository) PersonalOrgID(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	return orgs.NewRepository(r.db).PersonalOrgID(ctx, userID)
+}
+
 func (r *Repository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
@@ -72,3 +124,57 @@ func (r *Repository) UpdateStatus(ctx context.Context, id uuid.UUID, status stri
 		Where("id = ?", id).
 		Update("status", status).Error
 }
+
+func (r *Repository) ListExecCommands(ctx context.Context, serviceID uuid.UUID) ([]ExecCommand, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var cmds []ExecCommand
+	err := r.db.WithContext(ctx).
+		Where("service_id = ?", serviceID).
+		Order("name ASC").
+		Find(&cmds).Error
+	return cmds, err
+}
+
+func (r *Repository) GetExecCommand(ctx context.Context, serviceID uuid.UUID, name string) (*ExecCommand, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var cmd ExecCommand
+	err := r.db.WithContext(ctx).
+		Where("service_id = ? AND name = ?", serviceID, name).
+		First(&cmd).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cmd, nil
+}
+
+func (r *Repository) CreateExecCommand(ctx context.Context, cmd *ExecCommand) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Create(cmd).Error
+}
+
+func (r *Repository) UpdateExecCommand(ctx context.Context, cmd *ExecCommand) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cmd.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).
+		Model(cmd).
+		Select("description", "params", "default_timeout_sec", "max_timeout_sec", "required_role", "enabled", "updated_at").
+		Updates(cmd).Error
+}
+
+func (r *Repository) DeleteExecCommand(ctx context.Context, serviceID uuid.UUID, name string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	res := r.db.WithContext(ctx).
+		Where("service_id = ? AND name = ?", serviceID, name).
+		Delete(&ExecCommand{})
+	return res.RowsAffected > 0, res.Error
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"nanonet-backend/internal/commands"
+	"nanonet-backend/internal/ws"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRolloutRunning = errors.New("bu servis için devam eden bir rolling restart var")
+	ErrNoInstances    = errors.New("servise bağlı agent instance'ı yok")
+)
+
+const (
+	defaultRollingHealthTimeout = 120
+	rollingPollInterval         = 2 * time.Second
+)
+
+// Rollout — başlatılan rolling restart. Alt komutlar command_logs'ta
+// parent_id = Rollout.ID ile tutulur.
+type Rollout struct {
+	ID        uuid.UUID          `json:"rollout_id"`
+	ServiceID uuid.UUID          `json:"service_id"`
+	Strategy  string             `json:"strategy"`
+	Instances []ws.AgentInstance `json:"instances"`
+}
+
+// RollingRestarter — çok instance'lı servislerde restart'ı instance instance uygular;
+// bir instance yeniden "up" raporlamadan sıradakine geçmez.
+type RollingRestarter struct {
+	hub        *ws.Hub
+	dispatcher *Dispatcher
+	cmdService *commands.Service
+
+	mu      sync.Mutex
+	running map[uuid.UUID]bool
+}
+
+func NewRollingRestarter(db *gorm.DB, hub *ws.Hub) *RollingRestarter {
+	return &RollingRestarter{
+		hub:        hub,
+		dispatcher: NewDispatcher(db, hub),
+		cmdService: commands.NewService(db),
+		running:    make(map[uuid.UUID]bool),
+	}
+}
+
+// Start — bağlı instance'ları sabitler ve rolling restart'ı arka planda başlatır.
+func (r *RollingRestarter) Start(serviceID, userID uuid.UUID, timeoutSec, healthTimeoutSec int) (*Rollout, error) {
+	instances := r.hub.ListAgentInstances(serviceID.String())
+	if len(instances) == 0 {
+		return nil, ErrNoInstances
+	}
+	if healthTimeoutSec <= 0 {
+		healthTimeoutSec = defaultRollingHealthTimeout
+	}
+
+	r.mu.Lock()
+	if r.running[serviceID] {
+		r.mu.Unlock()
+		return nil, ErrRolloutRunning
+	}
+	r.running[serviceID] = true
+	r.mu.Unlock()
+
+	rollout := &Rollout{
+		ID:        uuid.New(),
+		ServiceID: serviceID,
+		Strategy:  "rolling",
+		Instances: instances,
+	}
+
+	go func() {
+		defer func() {
+			r.mu.Lock()
+			delete(r.running, serviceID)
+			r.mu.Unlock()
+		}()
+		r.run(rollout, userID, timeoutSec, time.Duration(healthTimeoutSec)*time.Second)
+	}()
+
+	return rollout, nil
+}
+
+func (r *RollingRestarter) run(rollout *Rollout, userID uuid.UUID, timeoutSec int, healthTimeout time.Duration) {
+	serviceID := rollout.ServiceID.String()
+	total := len(rollout.Instances)
+
+	perInstance := time.Duration(timeoutSec+30)*time.Second + healthTimeout
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(total)*perInstance+time.Minute)
+	defer cancel()
+
+	for i, inst := range rollout.Instances {
+		r.progress(rollout, "restarting", i+1, total, inst.AgentID, "")
+
+		dispatched, err := r.dispatcher.Dispatch(ctx, DispatchRequest{
+			ServiceID: rollout.ServiceID,
+			UserID:    userID,
+			Action:    "restart",
+			Params:    map[string]interface{}{"timeout_sec": timeoutSec},
+			ParentID:  &rollout.ID,
+			AgentID:   inst.AgentID,
+		})
+		if err != nil {
+			r.progress(rollout, "failed", i+1, total, inst.AgentID, err.Error())
+			return
+		}
+
+		// Agent'ın kendi restart timeout'una ek pay bırak.
+		cl, err := r.cmdService.WaitForResult(ctx, dispatched.CommandID, time.Duration(timeoutSec+30)*time.Second)
+		if err != nil {
+			msg := "komut zaman aşımına uğradı"
+			_ = r.cmdService.RecordResult(ctx, dispatched.CommandID, "timeout", &msg)
+			r.progress(rollout, "failed", i+1, total, inst.AgentID, msg)
+			return
+		}
+		if cl.Status != "success" {
+			r.progress(rollout, "failed", i+1, total, inst.AgentID, "restart başarısız: "+cl.Status)
+			return
+		}
+
+		r.progress(rollout, "waiting_healthy", i+1, total, inst.AgentID, "")
+		if !r.waitInstanceUp(ctx, serviceID, inst.AgentID, time.Now(), healthTimeout) {
+			r.progress(rollout, "failed", i+1, total, inst.AgentID, "instance süre içinde up raporlamadı")
+			return
+		}
+	}
+
+	r.progress(rollout, "completed", total, total, "", "")
+	log.Printf("[rolling] restart tamamlandı service=%s instances=%d", serviceID, total)
+}
+
+// waitInstanceUp — instance'ın since'den sonra "up" durumlu metrik göndermesini bekler.
+func (r *RollingRestarter) waitInstanceUp(ctx context.Context, serviceID, agentID string, since time.Time, timeout time.Duration) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(rollingPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if inst, ok := r.hub.GetAgentInstance(serviceID, agentID); ok {
+			if inst.LastStatus == "up" && inst.LastMetricAt != nil && inst.LastMetricAt.After(since) {
+				return true
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-deadline.C:
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *RollingRestarter) progress(rollout *Rollout, status string, step, total int, agentID, message string) {
+	data := map[string]interface{}{
+		"rollout_id": rollout.ID,
+		"status":     status,
+		"step":       step,
+		"total":      total,
+	}
+	if agentID != "" {
+		data["agent_id"] = agentID
+	}
+	if message != "" {
+		data["message"] = message
+		log.Printf("[rolling] service=%s agent=%s: %s", rollout.ServiceID, agentID, message)
+	}
+	r.hub.BroadcastEvent(rollout.ServiceID.String(), "rolling_restart_progress", data)
+}
@@ -2,6 +2,10 @@ package services
 
 import (
 	"context"
+	"errors"
+	"strings"
+
+	"nanonet-backend/internal/orgs"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -17,15 +21,46 @@ func NewServiceLayer(db *gorm.DB) *ServiceLayer {
 	}
 }
 
+// ErrOrgForbidden — kullanıcı servisi oluşturmak istediği organizasyonda admin değil.
+var ErrOrgForbidden = errors.New("bu organizasyonda servis oluşturma yetkiniz yok")
+
 func (s *ServiceLayer) Create(ctx context.Context, userID uuid.UUID, req CreateServiceRequest) (*Service, error) {
+	var orgID uuid.UUID
+	if req.OrgID != nil {
+		orgID = *req.OrgID
+		role, err := s.repo.OrgRole(ctx, orgID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !orgs.RoleAllows(role, orgs.RoleAdmin) {
+			return nil, ErrOrgForbidden
+		}
+	} else {
+		personal, err := s.repo.PersonalOrgID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		orgID = personal
+	}
+
+	if req.PollIntervalSec == 0 {
+		interval, err := s.repo.DefaultPollInterval(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		req.PollIntervalSec = interval
+	}
+
 	service := &Service{
 		UserID:          userID,
+		OrgID:           orgID,
 		Name:            req.Name,
 		Host:            req.Host,
 		Port:            req.Port,
 		HealthEndpoint:  req.HealthEndpoint,
 		PollIntervalSec: req.PollIntervalSec,
 		Status:          "unknown",
+		Tags:            normalizeTags(req.Tags),
 	}
 
 	if err := s.repo.Create(ctx, service); err != nil {
@@ -43,6 +78,10 @@ func (s *ServiceLayer) List(ctx context.Context, userID uuid.UUID) ([]Service, e
 	return s.repo.List(ctx, userID)
 }
 
+func (s *ServiceLayer) ListByTag(ctx context.Context, userID uuid.UUID, tag string) ([]Service, error) {
+	return s.repo.ListTargets(ctx, userID, orgs.RoleViewer, nil, tag)
+}
+
 func (s *ServiceLayer) Update(ctx context.Context, id, userID uuid.UUID, req UpdateServiceRequest) (*Service, error) {
 	service, err := s.repo.GetByID(ctx, id, userID)
 	if err != nil {
@@ -64,6 +103,9 @@ func (s *ServiceLayer) Update(ctx context.Context, id, userID uuid.UUID, req Upd
 	if req.PollIntervalSec != nil {
 		service.PollIntervalSec = *req.PollIntervalSec
 	}
+	if req.Tags != nil {
+		service.Tags = normalizeTags(req.Tags)
+	}
 
 	if err := s.repo.Update(ctx, service); err != nil {
 		return nil, err
@@ -75,3 +117,23 @@ func (s *ServiceLayer) Update(ctx context.Context, id, userID uuid.UUID, req Upd
 func (s *ServiceLayer) Delete(ctx context.Context, id, userID uuid.UUID) error {
 	return s.repo.Delete(ctx, id, userID)
 }
+
+// NormalizeTag — etiketi servislerde saklandığı biçime getirir (kırpılmış, küçük harf).
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// normalizeTags — etiketleri küçük harfe çevirir, boşlukları kırpar ve tekrarları atar.
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = NormalizeTag(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
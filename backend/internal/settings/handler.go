@@ -1,6 +1,7 @@
 package settings
 
 import (
+	"nanonet-backend/pkg/audit"
 	"nanonet-backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -32,6 +33,16 @@ func (h *Handler) Get(c *gin.Context) {
 	response.Success(c, s)
 }
 
+// redacted — audit kaydı için webhook sırrı maskelenmiş kopya.
+func redacted(s *UserSettings) UserSettings {
+	out := *s
+	if out.WebhookSecret != nil && *out.WebhookSecret != "" {
+		masked := "***"
+		out.WebhookSecret = &masked
+	}
+	return out
+}
+
 func (h *Handler) Update(c *gin.Context) {
 	userID, err := uuid.Parse(c.GetString("user_id"))
 	if err != nil {
@@ -45,11 +56,15 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
+	if before, err := h.service.Get(c.Request.Context(), userID); err == nil {
+		audit.Before(c, redacted(before))
+	}
 	s, err := h.service.Update(c.Request.Context(), userID, req)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
 	}
+	audit.After(c, redacted(s))
 
 	response.Success(c, s)
 }
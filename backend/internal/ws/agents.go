@@ -0,0 +1,415 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// agentPresenceTTL — Redis kaydında bu süreden uzun süredir görülmeyen instance'lar
+// (örn: çöken backend örneğine bağlı olanlar) listelenmez.
+const agentPresenceTTL = 2 * time.Minute
+
+// AgentInstance — bir servise bağlı tek bir agent bağlantısı.
+type AgentInstance struct {
+	AgentID      string     `json:"agent_id"`
+	ServiceID    string     `json:"service_id"`
+	ConnectedAt  time.Time  `json:"connected_at"`
+	LastStatus   string     `json:"last_status,omitempty"`
+	LastMetricAt *time.Time `json:"last_metric_at,omitempty"`
+	LastSeenAt   time.Time  `json:"last_seen_at"`
+
+	ProtocolVersion int      `json:"protocol_version,omitempty"`
+	Capabilities    []string `json:"capabilities,omitempty"`
+	ExecCommands    []string `json:"exec_commands,omitempty"`
+	ConfigVersion   int      `json:"config_version,omitempty"`
+
+	// Bağlantı kalitesi: backend'in WebSocket ping/pong ile ölçtüğü gidiş-dönüş süresi,
+	// son 24 saatteki yeniden bağlanmalar ve gönderim tamponu dolduğu için atlanan mesajlar.
+	RTTMs      *float64   `json:"rtt_ms,omitempty"`
+	RTTAvgMs   *float64   `json:"rtt_avg_ms,omitempty"`
+	LastPongAt *time.Time `json:"last_pong_at,omitempty"`
+	Reconnects int        `json:"reconnects"`
+	SendDrops  int64      `json:"send_drops"`
+}
+
+// Supports — instance'ın aksiyonu çalıştırabildiğini bildirir. hello göndermemiş
+// (eski protokol) agent'ların her aksiyonu desteklediği varsayılır.
+func (i AgentInstance) Supports(action string) bool {
+	return i.ProtocolVersion == 0 || containsString(i.Capabilities, action)
+}
+
+// AgentSession — tek bir agent WebSocket bağlantısı.
+type AgentSession struct {
+	SessionID    string
+	ServiceID    string
+	AgentID      string
+	RemoteIP     string
+	CredentialID string
+	ConnectedAt  time.Time
+}
+
+type OnAgentConnectFunc func(s AgentSession)
+type OnAgentHelloFunc func(s AgentSession, msg AgentMessage)
+type OnAgentDisconnectFunc func(s AgentSession, disconnectedAt time.Time)
+
+// SetOnAgentConnect — agent bağlandığında (async) çağrılır.
+func (h *Hub) SetOnAgentConnect(fn OnAgentConnectFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onAgentConnect = fn
+}
+
+// SetOnAgentHello — agent hello mesajı gönderdiğinde (async) çağrılır.
+func (h *Hub) SetOnAgentHello(fn OnAgentHelloFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onAgentHello = fn
+}
+
+// SetOnAgentDisconnect — agent bağlantısı kapandığında (async) çağrılır.
+func (h *Hub) SetOnAgentDisconnect(fn OnAgentDisconnectFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onAgentDisconnect = fn
+}
+
+// LocalAgentSessions — bu backend örneğine bağlı tüm agent bağlantılarını döndürür.
+func (h *Hub) LocalAgentSessions() []AgentSession {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	sessions := make([]AgentSession, 0, len(h.agentClients))
+	for client := range h.agentClients {
+		sessions = append(sessions, client.session())
+	}
+	return sessions
+}
+
+func (c *Client) session() AgentSession {
+	return AgentSession{
+		SessionID:    c.sessionID,
+		ServiceID:    c.serviceID,
+		AgentID:      c.id,
+		RemoteIP:     c.remoteIP,
+		CredentialID: c.credentialID,
+		ConnectedAt:  c.connectedAt,
+	}
+}
+
+// SupportsExec — instance'ın exec katalog girdisini çalıştırabildiğini bildirir. Katalog
+// bildirmeyen agent'ların yalnızca yerleşik komutları uyguladığı varsayılır.
+func (i AgentInstance) SupportsExec(name string, builtin bool) bool {
+	if !i.Supports("exec") {
+		return false
+	}
+	if i.ExecCommands == nil {
+		return builtin
+	}
+	return containsString(i.ExecCommands, name)
+}
+
+// instance — çağıran h.mu'yu tutmalıdır.
+func (c *Client) instance() AgentInstance {
+	inst := AgentInstance{
+		AgentID:     c.id,
+		ServiceID:   c.serviceID,
+		ConnectedAt: c.connectedAt,
+		LastStatus:  c.lastStatus,
+		LastSeenAt:  time.Now(),
+
+		ProtocolVersion: c.protocolVersion,
+		Capabilities:    c.capabilities,
+		ExecCommands:    c.execCommands,
+		ConfigVersion:   c.configVersion,
+
+		Reconnects: c.reconnects,
+		SendDrops:  c.sendDrops.Load(),
+	}
+	if !c.lastMetricAt.IsZero() {
+		t := c.lastMetricAt
+		inst.LastMetricAt = &t
+	}
+	if !c.lastPongAt.IsZero() {
+		t := c.lastPongAt
+		inst.LastPongAt = &t
+		inst.RTTMs = durationMs(c.rtt)
+		inst.RTTAvgMs = durationMs(c.rttAvg)
+	}
+	return inst
+}
+
+func agentRegistryKey(serviceID string) string {
+	return "nanonet:agents:" + serviceID
+}
+
+// publishInstance — instance durumunu diğer backend örneklerinin görebilmesi için Redis'e yazar.
+func (h *Hub) publishInstance(inst AgentInstance) {
+	if h.redisClient == nil {
+		return
+	}
+	data, err := json.Marshal(inst)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	key := agentRegistryKey(inst.ServiceID)
+	h.redisClient.HSet(ctx, key, inst.AgentID, string(data))
+	h.redisClient.Expire(ctx, key, 24*time.Hour)
+}
+
+// removeInstance — bağlantı kapanınca Redis kaydını siler. Aynı agent başka bir
+// örneğe yeniden bağlandıysa (daha yeni connected_at) kayda dokunulmaz.
+func (h *Hub) removeInstance(serviceID, agentID string, connectedAt time.Time) {
+	if h.redisClient == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	key := agentRegistryKey(serviceID)
+	raw, err := h.redisClient.HGet(ctx, key, agentID).Result()
+	if err != nil {
+		return
+	}
+	var inst AgentInstance
+	if json.Unmarshal([]byte(raw), &inst) == nil && inst.ConnectedAt.After(connectedAt) {
+		return
+	}
+	h.redisClient.HDel(ctx, key, agentID)
+}
+
+// ListAgentInstances — servise bağlı agent instance'larını döndürür.
+// Redis yapılandırılmışsa tüm backend örneklerindeki bağlantılar listelenir.
+func (h *Hub) ListAgentInstances(serviceID string) []AgentInstance {
+	var instances []AgentInstance
+
+	if h.redisClient != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		entries, err := h.redisClient.HGetAll(ctx, agentRegistryKey(serviceID)).Result()
+		if err == nil {
+			cutoff := time.Now().Add(-agentPresenceTTL)
+			for _, raw := range entries {
+				var inst AgentInstance
+				if json.Unmarshal([]byte(raw), &inst) != nil {
+					continue
+				}
+				if inst.LastSeenAt.Before(cutoff) && !h.isLocalInstance(serviceID, inst.AgentID) {
+					continue
+				}
+				instances = append(instances, inst)
+			}
+			sortInstances(instances)
+			return instances
+		}
+		log.Printf("Agent kayıtları Redis'ten okunamadı [service=%s]: %v", serviceID, err)
+	}
+
+	h.mu.RLock()
+	for client := range h.agentClients {
+		if client.serviceID == serviceID {
+			instances = append(instances, client.instance())
+		}
+	}
+	h.mu.RUnlock()
+
+	sortInstances(instances)
+	return instances
+}
+
+// GetAgentInstance — servise bağlı belirli bir agent instance'ını döndürür.
+func (h *Hub) GetAgentInstance(serviceID, agentID string) (AgentInstance, bool) {
+	for _, inst := range h.ListAgentInstances(serviceID) {
+		if inst.AgentID == agentID {
+			return inst, true
+		}
+	}
+	return AgentInstance{}, false
+}
+
+// ErrCapabilityUnsupported — hedef agent(lar) aksiyonu yetenek olarak bildirmemiş.
+var ErrCapabilityUnsupported = errors.New("agent bu komutu desteklemiyor")
+
+// CheckCapability — komutun gönderilmeden önce hedefte çalıştırılabileceğini doğrular.
+// agentID boşsa bağlı instance'lardan birinin aksiyonu desteklemesi yeterlidir.
+// Bağlı instance yoksa komut kuyruğa alınabileceği için hata dönmez.
+func (h *Hub) CheckCapability(serviceID, agentID, action string) error {
+	var instances []AgentInstance
+	if agentID != "" {
+		if inst, ok := h.GetAgentInstance(serviceID, agentID); ok {
+			instances = append(instances, inst)
+		}
+	} else {
+		instances = h.ListAgentInstances(serviceID)
+	}
+	if len(instances) == 0 {
+		return nil
+	}
+	for _, inst := range instances {
+		if inst.Supports(action) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrCapabilityUnsupported, action)
+}
+
+// CheckExecCommand — exec katalog girdisinin hedefte uygulandığını doğrular. CheckCapability
+// gibi bağlı instance yoksa komut kuyruğa alınabileceği için hata dönmez.
+func (h *Hub) CheckExecCommand(serviceID, agentID, name string, builtin bool) error {
+	var instances []AgentInstance
+	if agentID != "" {
+		if inst, ok := h.GetAgentInstance(serviceID, agentID); ok {
+			instances = append(instances, inst)
+		}
+	} else {
+		instances = h.ListAgentInstances(serviceID)
+	}
+	if len(instances) == 0 {
+		return nil
+	}
+	for _, inst := range instances {
+		if inst.SupportsExec(name, builtin) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: exec %s", ErrCapabilityUnsupported, name)
+}
+
+// SendCommandToAgentInstance — komutu yalnızca belirtilen agent instance'ına gönderir.
+// Hedefli komutlar kuyruğa alınmaz; instance bağlı değilse false döner.
+func (h *Hub) SendCommandToAgentInstance(serviceID, agentID string, command map[string]interface{}) bool {
+	jsonData, err := h.encodeForAgent(serviceID, command)
+	if err != nil {
+		log.Printf("Komut serialize hatası: %v", err)
+		return false
+	}
+
+	if action, _ := command["action"].(string); action != "" {
+		if h.CheckCapability(serviceID, agentID, action) != nil {
+			log.Printf("Agent komutu desteklemiyor: service=%s, agent=%s, action=%s", serviceID, agentID, action)
+			return false
+		}
+	}
+
+	if h.deliverToLocalInstance(serviceID, agentID, jsonData) {
+		h.trackDelivered(command)
+		log.Printf("Komut agent instance'ına gönderildi: service=%s, agent=%s", serviceID, agentID)
+		return true
+	}
+
+	if h.redisClient != nil {
+		if _, ok := h.GetAgentInstance(serviceID, agentID); ok {
+			ctx := context.Background()
+			h.redisClient.Publish(ctx, "nanonet:agent:"+serviceID+":"+agentID, string(jsonData))
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Hub) deliverToLocalInstance(serviceID, agentID string, data []byte) bool {
+	h.mu.RLock()
+	var target *Client
+	for client := range h.agentClients {
+		if client.serviceID == serviceID && client.id == agentID {
+			target = client
+			break
+		}
+	}
+	h.mu.RUnlock()
+
+	if target == nil {
+		return false
+	}
+	select {
+	case target.send <- data:
+		return true
+	default:
+		target.noteDrop()
+		log.Printf("Agent send buffer dolu: %s", target.id)
+		return false
+	}
+}
+
+func (h *Hub) isLocalInstance(serviceID, agentID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for client := range h.agentClients {
+		if client.serviceID == serviceID && client.id == agentID {
+			return true
+		}
+	}
+	return false
+}
+
+func sortInstances(instances []AgentInstance) {
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].ConnectedAt.Before(instances[j].ConnectedAt)
+	})
+}
+
+// DisconnectCredential — kimlik bilgisiyle açılmış tüm agent bağlantılarını kapatır.
+// Redis yapılandırılmışsa diğer backend örneklerindeki bağlantılar da kapatılır.
+func (h *Hub) DisconnectCredential(credentialID string) {
+	if h.redisClient != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := h.redisClient.Publish(ctx, "nanonet:revoke:"+credentialID, "revoked").Err(); err == nil {
+			return
+		}
+	}
+	h.closeCredentialLocal(credentialID, "credential revoked")
+}
+
+// CloseExpiredCredentialSessions — kimlik bilgisinin süresi dolmuş yerel agent bağlantılarını kapatır.
+func (h *Hub) CloseExpiredCredentialSessions(now time.Time) int {
+	h.mu.RLock()
+	var targets []*Client
+	for client := range h.agentClients {
+		if client.credentialExpiresAt != nil && !client.credentialExpiresAt.After(now) {
+			targets = append(targets, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range targets {
+		log.Printf("Agent kimlik bilgisinin süresi doldu, bağlantı kapatılıyor: %s (service: %s)", client.id, client.serviceID)
+		client.closeWith(closeCodeCredential, "credential expired")
+	}
+	return len(targets)
+}
+
+// SetCredentialExpiry — kimlik bilgisiyle açılmış yerel bağlantıların geçerlilik sonunu günceller
+// (rotasyonda grace süresi, iptal veya veritabanında değişen süre). Bağlantılar bir sonraki
+// CloseExpiredCredentialSessions çağrısında bu değere göre kapatılır.
+func (h *Hub) SetCredentialExpiry(credentialID string, expiresAt *time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for client := range h.agentClients {
+		if client.credentialID == credentialID {
+			client.credentialExpiresAt = expiresAt
+		}
+	}
+}
+
+func (h *Hub) closeCredentialLocal(credentialID, reason string) {
+	h.mu.RLock()
+	var targets []*Client
+	for client := range h.agentClients {
+		if client.credentialID == credentialID {
+			targets = append(targets, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range targets {
+		log.Printf("Agent bağlantısı kapatılıyor (%s): %s (service: %s)", reason, client.id, client.serviceID)
+		client.closeWith(closeCodeCredential, reason)
+	}
+}
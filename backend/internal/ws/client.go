@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
@@ -14,6 +16,8 @@ const (
 	pongWait       = 60 * time.Second
 	pingPeriod     = (pongWait * 9) / 10
 	maxMessageSize = 4096
+	// maxAgentMessageSize — agent mesajları log satırı grupları taşıyabildiği için daha büyüktür.
+	maxAgentMessageSize = 1 << 20
 )
 
 type ClientType string
@@ -21,6 +25,7 @@ type ClientType string
 const (
 	DashboardClient ClientType = "dashboard"
 	AgentClient     ClientType = "agent"
+	LogTailClient   ClientType = "log_tail"
 )
 
 type Client struct {
@@ -31,15 +36,54 @@ type Client struct {
 	hub        *Hub
 	conn       *websocket.Conn
 	send       chan []byte
+
+	// sessionID — bu bağlantıya özgü kimlik (agent bağlantı geçmişinde kullanılır).
+	sessionID string
+	remoteIP  string
+
+	// credentialID — bağlantının kimlik doğrulamasında kullanılan agent kimlik bilgisi
+	// (eski JWT ile bağlananlarda boş). İptal/süre dolumu bu alan üzerinden bağlantıyı kapatır.
+	credentialID        string
+	credentialExpiresAt *time.Time
+
+	// Agent instance durumu — Hub.mu ile korunur.
+	connectedAt  time.Time
+	lastStatus   string
+	lastMetricAt time.Time
+
+	// hello ile anlaşılan protokol sürümü ve kabul edilen yetenekler.
+	// protocolVersion 0 ise agent hello göndermemiştir (eski protokol).
+	protocolVersion int
+	capabilities    []string
+	// execCommands — agent'ın uyguladığını bildirdiği exec katalog girdileri; nil ise agent
+	// listeyi bildirmemiştir ve yalnızca yerleşik komutları çalıştırdığı varsayılır.
+	execCommands []string
+
+	// configVersion — agent'ın uyguladığını bildirdiği son yapılandırma sürümü.
+	configVersion int
+
+	// logFilter — canlı log takibinde yalnızca eşleşen satırlar gönderilir.
+	logFilter LogFilter
+
+	// Bağlantı kalitesi — rtt/rttAvg/lastPongAt/reconnects Hub.mu ile korunur. rtt son ping/pong
+	// ölçümü, rttAvg üstel ortalamadır; reconnects agent'ın son 24 saatteki yeniden bağlanmaları.
+	rtt        time.Duration
+	rttAvg     time.Duration
+	lastPongAt time.Time
+	reconnects int
+	// sendDrops — gönderim tamponu dolu olduğu için atlanan mesaj sayısı (bu bağlantı).
+	sendDrops atomic.Int64
 }
 
 func NewClient(id string, clientType ClientType, hub *Hub, conn *websocket.Conn) *Client {
 	return &Client{
-		id:         id,
-		clientType: clientType,
-		hub:        hub,
-		conn:       conn,
-		send:       make(chan []byte, 256),
+		id:          id,
+		clientType:  clientType,
+		hub:         hub,
+		conn:        conn,
+		send:        make(chan []byte, 256),
+		sessionID:   uuid.New().String(),
+		connectedAt: time.Now(),
 	}
 }
 
@@ -49,10 +93,20 @@ func (c *Client) ReadPump() {
 		_ = c.conn.Close()
 	}()
 
-	c.conn.SetReadLimit(maxMessageSize)
+	if c.clientType == AgentClient {
+		c.conn.SetReadLimit(maxAgentMessageSize)
+	} else {
+		c.conn.SetReadLimit(maxMessageSize)
+	}
 	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
-	c.conn.SetPongHandler(func(string) error {
-		_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(appData string) error {
+		now := time.Now()
+		_ = c.conn.SetReadDeadline(now.Add(pongWait))
+		if c.clientType == AgentClient {
+			if rtt, ok := parsePong(appData, now); ok {
+				c.hub.recordPong(c, rtt, now)
+			}
+		}
 		return nil
 	})
 
@@ -103,13 +157,20 @@ func (c *Client) WritePump() {
 
 		case <-ticker.C:
 			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+			if err := c.conn.WriteMessage(websocket.PingMessage, pingPayload(time.Now())); err != nil {
 				return
 			}
 		}
 	}
 }
 
+// closeWith — bağlantıyı close frame ile sonlandırır; ReadPump hatayla çıkıp client'ı kayıttan düşürür.
+func (c *Client) closeWith(code int, reason string) {
+	_ = c.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, reason), time.Now().Add(writeWait))
+	_ = c.conn.Close()
+}
+
 func (c *Client) SendJSON(v interface{}) error {
 	data, err := json.Marshal(v)
 	if err != nil {
@@ -119,6 +180,7 @@ func (c *Client) SendJSON(v interface{}) error {
 	case c.send <- data:
 		return nil
 	default:
+		c.noteDrop()
 		return fmt.Errorf("client send buffer dolu, mesaj atlandı: %s", c.id)
 	}
 }
@@ -0,0 +1,105 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+)
+
+// ConfigCollectors — merkezi yapılandırmayla açılıp kapatılabilen metrik toplayıcılar.
+// Servis sağlık kontrolü her zaman açıktır.
+var ConfigCollectors = []string{"system", "process", "app_metrics"}
+
+// AgentConfig — agent'a gönderilen config mesajı.
+type AgentConfig struct {
+	Type            string   `json:"type"`
+	ConfigVersion   int      `json:"config_version"`
+	PollIntervalSec int      `json:"poll_interval_sec"`
+	Collectors      []string `json:"collectors"`
+	LogPaths        []string `json:"log_paths"`
+}
+
+type OnConfigAckFunc func(s AgentSession, msg AgentMessage)
+
+// SetOnConfigAck — agent config_ack gönderdiğinde (async) çağrılır.
+func (h *Hub) SetOnConfigAck(fn OnConfigAckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onConfigAck = fn
+}
+
+// PushAgentConfig — yapılandırmayı servisin config mesajını destekleyen agent'larına gönderir.
+// agentID boş değilse yalnızca o instance'a gönderilir. Redis yapılandırılmışsa diğer backend
+// örneklerine bağlı agent'lara da ulaşır. Yerelde iletilen agent sayısını döndürür.
+func (h *Hub) PushAgentConfig(serviceID, agentID string, cfg AgentConfig) int {
+	cfg.Type = MsgConfig
+	if cfg.Collectors == nil {
+		cfg.Collectors = []string{}
+	}
+	if cfg.LogPaths == nil {
+		cfg.LogPaths = []string{}
+	}
+	data, err := h.encodeConfig(serviceID, cfg)
+	if err != nil {
+		log.Printf("Agent yapılandırması serialize edilemedi [service=%s]: %v", serviceID, err)
+		return 0
+	}
+
+	if agentID != "" {
+		if n := h.deliverConfigLocal(serviceID, agentID, data); n > 0 {
+			return n
+		}
+		if h.redisClient != nil {
+			h.redisClient.Publish(context.Background(), "nanonet:agent:"+serviceID+":"+agentID, string(data))
+		}
+		return 0
+	}
+
+	if h.redisClient != nil {
+		// Tüm backend örneklerindeki agent'lara (bu örnek dahil) pub/sub ile ulaşır.
+		if err := h.redisClient.Publish(context.Background(), "nanonet:config:"+serviceID, string(data)).Err(); err == nil {
+			return 0
+		}
+	}
+	return h.deliverConfigLocal(serviceID, "", data)
+}
+
+func (h *Hub) encodeConfig(serviceID string, cfg AgentConfig) ([]byte, error) {
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, err
+	}
+	return h.encodeForAgent(serviceID, msg)
+}
+
+// deliverConfigLocal — config mesajını bu örneğe bağlı, protokolü config'i destekleyen
+// agent'lara iletir. agentID boşsa servisin tüm agent'ları hedeflenir.
+func (h *Hub) deliverConfigLocal(serviceID, agentID string, data []byte) int {
+	h.mu.RLock()
+	var targets []*Client
+	for client := range h.agentClients {
+		if client.serviceID != serviceID || (agentID != "" && client.id != agentID) {
+			continue
+		}
+		if client.protocolVersion < ConfigProtocolVersion {
+			continue
+		}
+		targets = append(targets, client)
+	}
+	h.mu.RUnlock()
+
+	sent := 0
+	for _, client := range targets {
+		select {
+		case client.send <- data:
+			sent++
+		default:
+			log.Printf("Agent send buffer dolu, yapılandırma atlanıyor: %s", client.id)
+		}
+	}
+	return sent
+}
@@ -1,6 +1,7 @@
 package ws
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"log"
@@ -8,7 +9,9 @@ import (
 	"strings"
 	"time"
 
+	"nanonet-backend/internal/orgs"
 	"nanonet-backend/pkg/ratelimit"
+	"nanonet-backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -16,6 +19,33 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+// AgentCredentialPrefix — servise bağlı agent kimlik bilgilerinin ön eki (JWT'lerden ayırt etmek için).
+const AgentCredentialPrefix = "nna_"
+
+// closeCodeCredential — kimlik bilgisi iptal edildiğinde/süresi dolduğunda gönderilen close kodu.
+const closeCodeCredential = 4403
+
+// AgentCredential — doğrulanmış agent kimlik bilgisi.
+type AgentCredential struct {
+	ID        string
+	ServiceID string
+	ExpiresAt *time.Time
+}
+
+// AgentAuthenticator — agent bağlantılarının kimlik doğrulaması (agents paketi uygular).
+type AgentAuthenticator interface {
+	// AuthenticateAgent — AgentCredentialPrefix ile başlayan token'ı doğrular.
+	AuthenticateAgent(ctx context.Context, token string) (*AgentCredential, error)
+	// HasServiceRole — kullanıcının servis üzerinde en az need rolüne sahip olduğunu doğrular
+	// (eski kullanıcı JWT'si ile bağlanan agent'lar ve dashboard akışları için).
+	HasServiceRole(ctx context.Context, userID, serviceID, need string) (bool, error)
+}
+
+// TokenRevocation — çıkış yapılmış token'ları ve sonlandırılmış oturumları tanır (auth paketi uygular).
+type TokenRevocation interface {
+	TokenRevoked(ctx context.Context, token string) bool
+}
+
 type Handler struct {
 	hub              *Hub
 	jwtSecret        string
@@ -23,6 +53,12 @@ type Handler struct {
 	upgrader         websocket.Upgrader
 	dashboardLimiter *ratelimit.Limiter
 	agentLimiter     *ratelimit.Limiter
+	agentAuth        AgentAuthenticator
+	revocation       TokenRevocation
+
+	// allowUserAgentTokens — kullanıcıya bağlı agent/access JWT'si ile agent bağlantısına izin verir
+	// (kullanımdan kaldırılıyor; varsayılan kapalı).
+	allowUserAgentTokens bool
 }
 
 func NewHandler(hub *Hub, jwtSecret string, frontendURL string) *Handler {
@@ -61,8 +97,23 @@ func NewHandler(hub *Hub, jwtSecret string, frontendURL string) *Handler {
 	return h
 }
 
+// SetAgentAuthenticator — agent kimlik bilgisi doğrulayıcısını bağlar.
+func (h *Handler) SetAgentAuthenticator(a AgentAuthenticator) {
+	h.agentAuth = a
+}
+
+// SetAllowUserAgentTokens — eski kullanıcı JWT'si ile agent bağlanmasını açar veya kapatır.
+func (h *Handler) SetAllowUserAgentTokens(allow bool) {
+	h.allowUserAgentTokens = allow
+}
+
+// SetTokenRevocation — dashboard ve akış bağlantılarında iptal edilmiş token'ları reddetmeyi etkinleştirir.
+func (h *Handler) SetTokenRevocation(r TokenRevocation) {
+	h.revocation = r
+}
+
 // validateUserToken imzayı doğrular ve user_id'yi döndürür.
-// Sadece "access" ve "refresh" türündeki tokenları kabul eder (agent token reddedilir).
+// Sadece "access" türündeki tokenları kabul eder (agent ve refresh token reddedilir).
 func (h *Handler) validateUserToken(tokenString string) (string, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
@@ -77,17 +128,21 @@ func (h *Handler) validateUserToken(tokenString string) (string, error) {
 	if !ok {
 		return "", errors.New("geçersiz token claims")
 	}
-	if typ, _ := claims["typ"].(string); typ == "agent" {
-		return "", errors.New("agent token dashboard bağlantısı için kullanılamaz")
+	if typ, _ := claims["typ"].(string); typ != "access" {
+		return "", errors.New("dashboard bağlantısı için access token gerekli")
 	}
 	userID, ok := claims["sub"].(string)
 	if !ok || userID == "" {
 		return "", errors.New("token'da kullanıcı ID eksik")
 	}
+	if h.revocation != nil && h.revocation.TokenRevoked(context.Background(), tokenString) {
+		return "", errors.New("token geçersiz kılınmış")
+	}
 	return userID, nil
 }
 
-func (h *Handler) extractTokenType(tokenString string) string {
+// parseAgentJWT — eski (kullanıcıya bağlı) agent/access JWT'sinin tipini ve kullanıcısını döndürür.
+func (h *Handler) parseAgentJWT(tokenString string) (typ, userID string) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, jwt.ErrSignatureInvalid
@@ -95,14 +150,15 @@ func (h *Handler) extractTokenType(tokenString string) string {
 		return []byte(h.jwtSecret), nil
 	})
 	if err != nil || !token.Valid {
-		return ""
+		return "", ""
 	}
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return ""
+		return "", ""
 	}
-	typ, _ := claims["typ"].(string)
-	return typ
+	typ, _ = claims["typ"].(string)
+	userID, _ = claims["sub"].(string)
+	return typ, userID
 }
 
 func (h *Handler) Dashboard(c *gin.Context) {
@@ -159,10 +215,6 @@ func (h *Handler) Dashboard(c *gin.Context) {
 
 func (h *Handler) AgentConnect(c *gin.Context) {
 	serviceID := c.Query("service_id")
-	if serviceID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "service_id gerekli"})
-		return
-	}
 
 	// Token'ı header veya query'den al ve tip kontrolü yap
 	var tokenString string
@@ -182,18 +234,52 @@ func (h *Handler) AgentConnect(c *gin.Context) {
 		return
 	}
 
-	tokenType := h.extractTokenType(tokenString)
-	if tokenType != "agent" && tokenType != "access" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "geçersiz token tipi: agent veya access token gerekli"})
-		return
-	}
-
 	ip := c.ClientIP()
 	if !h.agentLimiter.Allow(ip) {
 		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many agent connections — please wait"})
 		return
 	}
 
+	var cred *AgentCredential
+	if strings.HasPrefix(tokenString, AgentCredentialPrefix) {
+		if h.agentAuth == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "agent kimlik bilgileri desteklenmiyor"})
+			return
+		}
+		var err error
+		cred, err = h.agentAuth.AuthenticateAgent(c.Request.Context(), tokenString)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "geçersiz, süresi dolmuş veya iptal edilmiş agent kimlik bilgisi"})
+			return
+		}
+		if serviceID != "" && serviceID != cred.ServiceID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "kimlik bilgisi bu servise ait değil"})
+			return
+		}
+		serviceID = cred.ServiceID
+	} else {
+		if !h.allowUserAgentTokens || h.agentAuth == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "agent bağlantısı için servis kimlik bilgisi (" + AgentCredentialPrefix + "...) gerekli"})
+			return
+		}
+		if serviceID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "service_id gerekli"})
+			return
+		}
+		tokenType, userID := h.parseAgentJWT(tokenString)
+		if tokenType != "agent" && tokenType != "access" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "geçersiz token tipi: agent veya access token gerekli"})
+			return
+		}
+		// Kullanıcıya bağlı eski token'lar yalnızca kullanıcının operator rolü olan servislerde geçerlidir.
+		owns, err := h.agentAuth.HasServiceRole(c.Request.Context(), userID, serviceID, orgs.RoleOperator)
+		if err != nil || !owns {
+			c.JSON(http.StatusForbidden, gin.H{"error": "token bu servise erişemez"})
+			return
+		}
+		log.Printf("[DEPRECATED] Agent kullanıcı JWT'si ile bağlanıyor (service: %s, user: %s); servis kimlik bilgisine geçin, AGENT_ALLOW_USER_TOKENS kaldırılacak", serviceID, userID)
+	}
+
 	agentID := c.Query("agent_id")
 	if agentID == "" {
 		agentID = uuid.New().String()
@@ -207,12 +293,24 @@ func (h *Handler) AgentConnect(c *gin.Context) {
 
 	client := NewClient(agentID, AgentClient, h.hub, conn)
 	client.serviceID = serviceID
+	client.remoteIP = ip
+	if cred != nil {
+		client.credentialID = cred.ID
+		client.credentialExpiresAt = cred.ExpiresAt
+	}
+	client.reconnects = h.hub.countReconnect(serviceID, agentID, client.connectedAt)
 	h.hub.register <- client
 
 	go client.WritePump()
 	go client.ReadPump()
 }
 
+// SigningKeys — GET /agent/signing-keys
+// Agent'ların komut zarflarını doğrulamak için kullanacağı açık anahtarlar (kimlik doğrulama gerektirmez).
+func (h *Handler) SigningKeys(c *gin.Context) {
+	response.Success(c, gin.H{"keys": h.hub.CommandSigningKeys()})
+}
+
 func (h *Handler) ServiceStream(c *gin.Context) {
 	ip := c.ClientIP()
 	if !h.dashboardLimiter.Allow(ip) {
@@ -262,6 +360,10 @@ func (h *Handler) ServiceStream(c *gin.Context) {
 		return
 	}
 
+	if !h.authorizeStream(c, conn, userID, serviceID) {
+		return
+	}
+
 	clientID := uuid.New().String()
 	client := NewClient(clientID, DashboardClient, h.hub, conn)
 	client.userID = userID
@@ -271,3 +373,92 @@ func (h *Handler) ServiceStream(c *gin.Context) {
 	go client.WritePump()
 	go client.ReadPump()
 }
+
+// authorizeStream — ilk mesajla doğrulanan kullanıcının servise viewer erişimini denetler;
+// erişim yoksa bağlantıyı 4403 ile kapatır.
+func (h *Handler) authorizeStream(c *gin.Context, conn *websocket.Conn, userID, serviceID string) bool {
+	// Yetki denetleyicisi bağlanmamışsa erişim reddedilir (fail closed).
+	if h.agentAuth != nil {
+		ok, err := h.agentAuth.HasServiceRole(c.Request.Context(), userID, serviceID, orgs.RoleViewer)
+		if err == nil && ok {
+			return true
+		}
+	}
+	_ = conn.WriteMessage(websocket.CloseMessage,
+		websocket.FormatCloseMessage(4403, "forbidden"))
+	_ = conn.Close()
+	return false
+}
+
+// ServiceLogs — servisin agent log satırlarını canlı takip eder. level (virgülle ayrılmış),
+// q (büyük/küçük harf duyarsız alt dizge) ve regex query parametreleriyle filtrelenebilir.
+// Kimlik doğrulama ServiceStream gibi ilk mesajla yapılır; yalnızca servis sahibi takip edebilir.
+func (h *Handler) ServiceLogs(c *gin.Context) {
+	ip := c.ClientIP()
+	if !h.dashboardLimiter.Allow(ip) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many WebSocket connections — please wait"})
+		return
+	}
+
+	serviceID := c.Param("id")
+	if serviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "service_id gerekli"})
+		return
+	}
+	filter, err := ParseLogFilter(c.Query("level"), c.Query("q"), c.Query("regex"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade hatası: %v", err)
+		return
+	}
+
+	// İlk mesaj ile kimlik doğrulama
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	_, rawMsg, err := conn.ReadMessage()
+	if err != nil {
+		_ = conn.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(4401, "authentication required"))
+		_ = conn.Close()
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	var authMsg struct {
+		Type  string `json:"type"`
+		Token string `json:"token"`
+	}
+	if jsonErr := json.Unmarshal(rawMsg, &authMsg); jsonErr != nil || authMsg.Type != "auth" || authMsg.Token == "" {
+		_ = conn.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(4401, "invalid auth message"))
+		_ = conn.Close()
+		return
+	}
+
+	userID, err := h.validateUserToken(authMsg.Token)
+	if err != nil {
+		_ = conn.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(4401, "unauthorized"))
+		_ = conn.Close()
+		return
+	}
+
+	if !h.authorizeStream(c, conn, userID, serviceID) {
+		return
+	}
+
+	client := NewClient(uuid.New().String(), LogTailClient, h.hub, conn)
+	client.userID = userID
+	client.serviceID = serviceID
+	client.logFilter = filter
+	h.hub.register <- client
+
+	_ = client.SendJSON(gin.H{"type": "log_tail_ready", "service_id": serviceID})
+
+	go client.WritePump()
+	go client.ReadPump()
+}
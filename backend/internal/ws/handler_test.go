@@ -0,0 +1,53 @@
+package ws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAgentAuth struct{}
+
+func (fakeAgentAuth) AuthenticateAgent(context.Context, string) (*AgentCredential, error) {
+	return nil, context.Canceled
+}
+
+func (fakeAgentAuth) HasServiceRole(context.Context, string, string, string) (bool, error) {
+	return false, nil
+}
+
+func TestAgentConnect_UserJWTRequiresOptIn(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const secret = "test-secret-test-secret-test-secret"
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"typ": "agent",
+		"sub": "user-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(secret))
+	require.NoError(t, err)
+
+	h := NewHandler(NewHub(10), secret, "")
+	h.SetAgentAuthenticator(fakeAgentAuth{})
+	r := gin.New()
+	r.GET("/ws/agent", h.AgentConnect)
+
+	connect := func() int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/ws/agent?service_id=svc-1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, connect(), "kullanıcı JWT'si varsayılan olarak reddedilir")
+
+	h.SetAllowUserAgentTokens(true)
+	assert.Equal(t, http.StatusForbidden, connect(), "açıkken de servis rolü denetlenir")
+}
@@ -3,6 +3,8 @@ package ws
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"strings"
 	"sync"
@@ -25,6 +27,21 @@ type AgentMessage struct {
 	Service   map[string]interface{} `json:"service,omitempty"`
 	Process   map[string]interface{} `json:"process,omitempty"`
 	Timestamp string                 `json:"timestamp,omitempty"`
+
+	// hello mesajı alanları
+	ProtocolVersion int      `json:"protocol_version,omitempty"`
+	Version         string   `json:"version,omitempty"`
+	Hostname        string   `json:"hostname,omitempty"`
+	OS              string   `json:"os,omitempty"`
+	IPs             []string `json:"ips,omitempty"`
+	Capabilities    []string `json:"capabilities,omitempty"`
+	ExecCommands    []string `json:"exec_commands,omitempty"`
+
+	// config_ack mesajı alanı
+	ConfigVersion int `json:"config_version,omitempty"`
+
+	// log mesajı alanı
+	Lines []LogLine `json:"lines,omitempty"`
 }
 
 type OnMetricFunc func(serviceID string, msg AgentMessage)
@@ -33,21 +50,35 @@ type OnCommandResultFunc func(commandID, status string, msg AgentMessage)
 type Hub struct {
 	dashboardClients map[*Client]bool
 	agentClients     map[*Client]bool
+	logTailClients   map[*Client]bool
 	broadcast        chan []byte
 	register         chan *Client
 	unregister       chan *Client
 	mu               sync.RWMutex
 	maxConnections   int
 
-	onMetric        OnMetricFunc
-	onCommandResult OnCommandResultFunc
+	onMetric          OnMetricFunc
+	onCommandResult   OnCommandResultFunc
+	onAgentConnect    OnAgentConnectFunc
+	onAgentHello      OnAgentHelloFunc
+	onAgentDisconnect OnAgentDisconnectFunc
+	onConfigAck       OnConfigAckFunc
+	onLogs            OnLogsFunc
 
 	// pendingCommands — agent çevrimdışıyken biriken komutlar (in-memory fallback).
 	pendingCommands map[string][]pendingCommand // serviceID -> []command
 	pendingMu       sync.Mutex
 
+	// agentConnects — Redis yokken yeniden bağlanma sayımı için agent başına bağlantı anları.
+	agentConnects map[string][]time.Time
+	connectMu     sync.Mutex
+
 	// redisClient is nil when Redis is not configured (in-memory mode).
 	redisClient *redis.Client
+
+	// signer — nil değilse agent'a giden komut ve iptal mesajları imzalanır.
+	signer *CommandSigner
+	replay *replayGuard
 }
 
 type pendingCommand struct {
@@ -63,11 +94,14 @@ func NewHub(maxConnections int) *Hub {
 	return &Hub{
 		dashboardClients: make(map[*Client]bool),
 		agentClients:     make(map[*Client]bool),
+		logTailClients:   make(map[*Client]bool),
 		broadcast:        make(chan []byte, 1024),
 		register:         make(chan *Client),
 		unregister:       make(chan *Client),
 		maxConnections:   maxConnections,
 		pendingCommands:  make(map[string][]pendingCommand),
+		agentConnects:    make(map[string][]time.Time),
+		replay:           newReplayGuard(),
 	}
 }
 
@@ -90,12 +124,25 @@ func (h *Hub) SetOnCommandResult(fn OnCommandResultFunc) {
 	h.onCommandResult = fn
 }
 
+// SetCommandSigner — agent'a giden mesajları imzalayacak anahtarı bağlar. Run/StartRedis'ten önce çağrılmalıdır.
+func (h *Hub) SetCommandSigner(s *CommandSigner) {
+	h.signer = s
+}
+
+// CommandSigningKeys — agent'ların komut imzasını doğrulamak için kullanacağı açık anahtarlar.
+func (h *Hub) CommandSigningKeys() []SigningKey {
+	if h.signer == nil {
+		return []SigningKey{}
+	}
+	return []SigningKey{h.signer.PublicKey()}
+}
+
 func (h *Hub) Run() {
 	for {
 		select {
 		case client := <-h.register:
 			h.mu.Lock()
-			totalConnections := len(h.dashboardClients) + len(h.agentClients)
+			totalConnections := len(h.dashboardClients) + len(h.agentClients) + len(h.logTailClients)
 			if totalConnections >= h.maxConnections {
 				log.Printf("[WARN] Max bağlantı limiti aşıldı (%d), bağlantı reddedildi: %s", h.maxConnections, client.id)
 				close(client.send)
@@ -105,9 +152,19 @@ func (h *Hub) Run() {
 			if client.clientType == AgentClient {
 				h.agentClients[client] = true
 				log.Printf("Agent bağlandı: %s (service: %s)", client.id, client.serviceID)
+				instance := client.instance()
+				onConnect := h.onAgentConnect
 				h.mu.Unlock()
+				go h.publishInstance(instance)
+				if onConnect != nil {
+					go onConnect(client.session())
+				}
 				// Bağlanan agent için bekleyen komutları ilet
 				h.deliverPendingCommands(client)
+			} else if client.clientType == LogTailClient {
+				h.logTailClients[client] = true
+				log.Printf("Log takibi başladı: %s (service: %s, user: %s)", client.id, client.serviceID, client.userID)
+				h.mu.Unlock()
 			} else {
 				h.dashboardClients[client] = true
 				log.Printf("Dashboard client bağlandı: %s (user: %s)", client.id, client.userID)
@@ -121,6 +178,16 @@ func (h *Hub) Run() {
 					delete(h.agentClients, client)
 					close(client.send)
 					log.Printf("Agent ayrıldı: %s (service: %s)", client.id, client.serviceID)
+					go h.removeInstance(client.serviceID, client.id, client.connectedAt)
+					if fn := h.onAgentDisconnect; fn != nil {
+						go fn(client.session(), time.Now())
+					}
+				}
+			} else if client.clientType == LogTailClient {
+				if _, ok := h.logTailClients[client]; ok {
+					delete(h.logTailClients, client)
+					close(client.send)
+					log.Printf("Log takibi bitti: %s", client.id)
 				}
 			} else {
 				if _, ok := h.dashboardClients[client]; ok {
@@ -156,6 +223,11 @@ func (h *Hub) StartRedis(ctx context.Context) {
 	pubsub := h.redisClient.PSubscribe(ctx,
 		"nanonet:broadcast:*", // metric/alert broadcasts
 		"nanonet:cmd:*",       // cross-node agent commands
+		"nanonet:user:*",      // user-scoped events (fleet progress vb.)
+		"nanonet:agent:*",     // cross-node commands targeting a single agent instance
+		"nanonet:revoke:*",    // revoked agent credentials — close matching sockets
+		"nanonet:config:*",    // central agent configuration pushes
+		"nanonet:logs:*",      // agent log lines for live tail clients
 	)
 	defer func() { _ = pubsub.Close() }()
 
@@ -176,12 +248,109 @@ func (h *Hub) StartRedis(ctx context.Context) {
 				h.broadcast <- []byte(msg.Payload)
 			case strings.HasPrefix(msg.Channel, "nanonet:cmd:"):
 				serviceID := strings.TrimPrefix(msg.Channel, "nanonet:cmd:")
-				h.tryDeliverToLocalAgent(serviceID, []byte(msg.Payload))
+				if h.verifyRemote(serviceID, []byte(msg.Payload)) {
+					h.tryDeliverToLocalAgent(serviceID, []byte(msg.Payload))
+				}
+			case strings.HasPrefix(msg.Channel, "nanonet:agent:"):
+				serviceID, agentID, ok := strings.Cut(strings.TrimPrefix(msg.Channel, "nanonet:agent:"), ":")
+				if ok && h.verifyRemote(serviceID, []byte(msg.Payload)) {
+					h.deliverToLocalInstance(serviceID, agentID, []byte(msg.Payload))
+				}
+			case strings.HasPrefix(msg.Channel, "nanonet:config:"):
+				serviceID := strings.TrimPrefix(msg.Channel, "nanonet:config:")
+				if h.verifyRemote(serviceID, []byte(msg.Payload)) {
+					h.deliverConfigLocal(serviceID, "", []byte(msg.Payload))
+				}
+			case strings.HasPrefix(msg.Channel, "nanonet:logs:"):
+				h.deliverLogsRemote([]byte(msg.Payload))
+			case strings.HasPrefix(msg.Channel, "nanonet:revoke:"):
+				h.closeCredentialLocal(strings.TrimPrefix(msg.Channel, "nanonet:revoke:"), "credential revoked")
+			case strings.HasPrefix(msg.Channel, "nanonet:user:"):
+				userID := strings.TrimPrefix(msg.Channel, "nanonet:user:")
+				h.deliverToUser(userID, []byte(msg.Payload))
 			}
 		}
 	}
 }
 
+// verifyRemote — Redis üzerinden gelen agent mesajının imzasını, hedef servisini ve daha önce
+// iletilip iletilmediğini denetler. İmzalama kapalıysa mesaj olduğu gibi kabul edilir.
+func (h *Hub) verifyRemote(serviceID string, raw []byte) bool {
+	if h.signer == nil {
+		return true
+	}
+	now := time.Now()
+	payload, err := h.signer.Open(raw, now, true)
+	if err != nil {
+		log.Printf("[WARN] Redis'ten gelen agent mesajı reddedildi [service=%s]: %v", serviceID, err)
+		return false
+	}
+	if sid, _ := payload["service_id"].(string); sid != serviceID {
+		log.Printf("[WARN] Redis'ten gelen agent mesajı başka bir servis için imzalanmış [kanal=%s, imza=%s]", serviceID, sid)
+		return false
+	}
+	exp, _ := payload["expires_at"].(float64)
+	if !h.replay.firstSeen(replayKey(payload), time.Unix(int64(exp), 0), now) {
+		log.Printf("[WARN] Redis'ten gelen agent mesajı reddedildi [service=%s]: %v", serviceID, ErrEnvelopeReplayed)
+		return false
+	}
+	return true
+}
+
+// encodeForAgent — agent'a gidecek mesajı (imzalama açıksa imzalayarak) serialize eder.
+func (h *Hub) encodeForAgent(serviceID string, msg map[string]interface{}) ([]byte, error) {
+	if h.signer == nil {
+		return json.Marshal(msg)
+	}
+	sealed, err := h.signer.Seal(serviceID, msg, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sealed)
+}
+
+// trackDelivered — doğrudan yerel agent'a iletilen mesajı kaydeder; aynı mesaj Redis üzerinden
+// tekrar yayınlanırsa bu örnek onu iletmez.
+func (h *Hub) trackDelivered(msg map[string]interface{}) {
+	if h.signer == nil {
+		return
+	}
+	now := time.Now()
+	h.replay.firstSeen(replayKey(msg), now.Add(h.signer.ttl), now)
+}
+
+// resealQueued — kuyruktan iletilecek komutun imzasını doğrular ve güncel süreyle yeniden imzalar.
+func (h *Hub) resealQueued(serviceID string, raw []byte) ([]byte, bool) {
+	if h.signer == nil {
+		return raw, true
+	}
+	payload, err := h.signer.Open(raw, time.Now(), false)
+	if err != nil {
+		log.Printf("[WARN] Kuyruktaki komut reddedildi [service=%s]: %v", serviceID, err)
+		return nil, false
+	}
+	if sid, _ := payload["service_id"].(string); sid != serviceID {
+		log.Printf("[WARN] Kuyruktaki komut başka bir servis için imzalanmış [service=%s]", serviceID)
+		return nil, false
+	}
+	data, err := h.encodeForAgent(serviceID, payload)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+func replayKey(msg map[string]interface{}) string {
+	typ, _ := msg["type"].(string)
+	if typ == MsgConfig {
+		// config mesajlarının command_id'si yoktur; sürüm servis bazında tekildir.
+		sid, _ := msg["service_id"].(string)
+		return fmt.Sprintf("%s:%s:%v", typ, sid, msg["config_version"])
+	}
+	id, _ := msg["command_id"].(string)
+	return typ + ":" + id
+}
+
 // tryDeliverToLocalAgent attempts to send a command to a locally connected agent.
 func (h *Hub) tryDeliverToLocalAgent(serviceID string, data []byte) {
 	h.mu.RLock()
@@ -197,11 +366,23 @@ func (h *Hub) tryDeliverToLocalAgent(serviceID string, data []byte) {
 		select {
 		case client.send <- data:
 		default:
+			client.noteDrop()
 		}
 	}
 }
 
 func (h *Hub) HandleAgentMessage(client *Client, rawMessage []byte) {
+	msgType, err := ValidateMessage(FromAgent, rawMessage)
+	if err != nil {
+		log.Printf("Agent %s: geçersiz mesaj (type: %s): %v", client.id, msgType, err)
+		code := ErrCodeInvalidMessage
+		if errors.Is(err, ErrUnknownMessageType) {
+			code = ErrCodeUnsupportedType
+		}
+		h.sendProtocolError(client, code, err.Error(), msgType)
+		return
+	}
+
 	var msg AgentMessage
 	if err := json.Unmarshal(rawMessage, &msg); err != nil {
 		log.Printf("Agent mesaj parse hatası [%s]: %v", client.id, err)
@@ -219,6 +400,15 @@ func (h *Hub) HandleAgentMessage(client *Client, rawMessage []byte) {
 			return
 		}
 
+		h.mu.Lock()
+		if status, ok := msg.Service["status"].(string); ok && status != "" {
+			client.lastStatus = status
+		}
+		client.lastMetricAt = time.Now()
+		instance := client.instance()
+		h.mu.Unlock()
+		h.publishInstance(instance)
+
 		h.mu.RLock()
 		fn := h.onMetric
 		h.mu.RUnlock()
@@ -227,6 +417,38 @@ func (h *Hub) HandleAgentMessage(client *Client, rawMessage []byte) {
 			fn(serviceID, msg)
 		}
 
+	case "hello":
+		log.Printf("Agent %s: hello alındı (protocol: %d, version: %s, host: %s)", client.id, msg.ProtocolVersion, msg.Version, msg.Hostname)
+
+		version, err := negotiateVersion(msg.ProtocolVersion)
+		if err != nil {
+			h.sendProtocolError(client, ErrCodeUnsupportedProtocol, err.Error(), msgType)
+			return
+		}
+		capabilities := acceptCapabilities(msg.Capabilities)
+		execCommands := acceptExecCommands(msg.ExecCommands)
+
+		h.mu.Lock()
+		client.protocolVersion = version
+		client.capabilities = capabilities
+		client.execCommands = execCommands
+		instance := client.instance()
+		fn := h.onAgentHello
+		h.mu.Unlock()
+		h.publishInstance(instance)
+
+		_ = client.SendJSON(WelcomeMessage{
+			Type:            MsgWelcome,
+			ProtocolVersion: version,
+			AgentMessages:   SupportedMessages(FromAgent),
+			BackendMessages: SupportedMessages(FromBackend),
+			Capabilities:    capabilities,
+		})
+
+		if fn != nil {
+			go fn(client.session(), msg)
+		}
+
 	case "ack":
 		log.Printf("Agent %s: komut ACK alındı (command_id: %s)", client.id, msg.CommandID)
 		h.BroadcastCommandStatus(client.serviceID, msg.CommandID, "received")
@@ -244,11 +466,42 @@ func (h *Hub) HandleAgentMessage(client *Client, rawMessage []byte) {
 
 		h.BroadcastCommandResult(client.serviceID, msg.CommandID, msg.Status, msg.Output, msg.Error)
 
+	case "config_ack":
+		log.Printf("Agent %s: yapılandırma %s (version: %d)", client.id, msg.Status, msg.ConfigVersion)
+
+		h.mu.Lock()
+		if msg.Status == "applied" {
+			client.configVersion = msg.ConfigVersion
+		}
+		instance := client.instance()
+		fn := h.onConfigAck
+		h.mu.Unlock()
+		h.publishInstance(instance)
+
+		if fn != nil {
+			go fn(client.session(), msg)
+		}
+
+	case "log":
+		h.handleLogs(client, msg)
+
 	default:
 		log.Printf("Agent %s: bilinmeyen mesaj tipi: %s", client.id, msg.Type)
 	}
 }
 
+// sendProtocolError — agent'a işlenemeyen mesaj için error mesajı gönderir.
+func (h *Hub) sendProtocolError(client *Client, code, message, refType string) {
+	if err := client.SendJSON(ErrorMessage{
+		Type:    MsgError,
+		Code:    code,
+		Message: message,
+		RefType: refType,
+	}); err != nil {
+		log.Printf("Agent %s: error mesajı gönderilemedi: %v", client.id, err)
+	}
+}
+
 func (h *Hub) HandleDashboardMessage(client *Client, rawMessage []byte) {
 	var msg struct {
 		Type string `json:"type"`
@@ -352,24 +605,103 @@ func (h *Hub) BroadcastCommandResult(serviceID, commandID, status string, output
 	h.broadcast <- jsonData
 }
 
+// BroadcastEvent — servis kapsamlı genel amaçlı bir olayı dashboard'lara iletir
+// (örn: runbook_progress). data alanı olay tipine özgüdür.
+func (h *Hub) BroadcastEvent(serviceID, eventType string, data interface{}) {
+	msg := map[string]interface{}{
+		"type":       eventType,
+		"service_id": serviceID,
+		"data":       data,
+	}
+
+	jsonData, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Olay serialize hatası (%s): %v", eventType, err)
+		return
+	}
+
+	if h.redisClient != nil {
+		ctx := context.Background()
+		h.redisClient.Publish(ctx, "nanonet:broadcast:"+serviceID, string(jsonData))
+		return
+	}
+
+	h.broadcast <- jsonData
+}
+
+// BroadcastUserEvent — tek bir servise bağlı olmayan olayı (örn: fleet_progress)
+// yalnızca ilgili kullanıcının dashboard bağlantılarına iletir.
+func (h *Hub) BroadcastUserEvent(userID, eventType string, data interface{}) {
+	msg := map[string]interface{}{
+		"type": eventType,
+		"data": data,
+	}
+
+	jsonData, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Olay serialize hatası (%s): %v", eventType, err)
+		return
+	}
+
+	if h.redisClient != nil {
+		ctx := context.Background()
+		h.redisClient.Publish(ctx, "nanonet:user:"+userID, string(jsonData))
+		return
+	}
+
+	h.deliverToUser(userID, jsonData)
+}
+
+// deliverToUser sends a message to the local dashboard clients of a user.
+func (h *Hub) deliverToUser(userID string, data []byte) {
+	h.mu.RLock()
+	var targets []*Client
+	for client := range h.dashboardClients {
+		if client.userID == userID {
+			targets = append(targets, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range targets {
+		select {
+		case client.send <- data:
+		default:
+		}
+	}
+}
+
 // SendCommandToAgent — komutu servise bağlı TÜM agent'lara gönderir (multi-instance).
 // Hiçbir agent bağlı değilse komut kuyruğa eklenir.
 func (h *Hub) SendCommandToAgent(serviceID string, command map[string]interface{}) bool {
-	jsonData, err := json.Marshal(command)
+	jsonData, err := h.encodeForAgent(serviceID, command)
 	if err != nil {
 		log.Printf("Komut serialize hatası: %v", err)
 		return false
 	}
 
+	action, _ := command["action"].(string)
+
 	h.mu.RLock()
 	var targets []*Client
+	skipped := 0
 	for client := range h.agentClients {
-		if client.serviceID == serviceID {
-			targets = append(targets, client)
+		if client.serviceID != serviceID {
+			continue
 		}
+		if client.protocolVersion > 0 && !containsString(client.capabilities, action) {
+			skipped++
+			continue
+		}
+		targets = append(targets, client)
 	}
 	h.mu.RUnlock()
 
+	if len(targets) == 0 && skipped > 0 {
+		log.Printf("Bağlı agent'lar komutu desteklemiyor: service=%s, action=%s", serviceID, action)
+		return false
+	}
+
 	if len(targets) == 0 {
 		cmdID, _ := command["command_id"].(string)
 		if h.redisClient != nil {
@@ -383,6 +715,7 @@ func (h *Hub) SendCommandToAgent(serviceID string, command map[string]interface{
 		return false
 	}
 
+	h.trackDelivered(command)
 	sentCount := 0
 	for _, client := range targets {
 		select {
@@ -436,9 +769,14 @@ func (h *Hub) deliverPendingCommands(client *Client) {
 		h.redisClient.Del(ctx, key)
 		log.Printf("Redis'ten agent %s için %d bekleyen komut iletiliyor", client.id, len(cmds))
 		for _, cmd := range cmds {
+			data, ok := h.resealQueued(client.serviceID, []byte(cmd))
+			if !ok {
+				continue
+			}
 			select {
-			case client.send <- []byte(cmd):
+			case client.send <- data:
 			default:
+				client.noteDrop()
 				log.Printf("Agent buffer dolu, Redis kuyruk komutu atlanıyor")
 			}
 		}
@@ -457,15 +795,85 @@ func (h *Hub) deliverPendingCommands(client *Client) {
 
 	log.Printf("Agent %s için %d bekleyen komut iletiliyor", client.id, len(queue))
 	for _, cmd := range queue {
+		data, ok := h.resealQueued(client.serviceID, cmd.Data)
+		if !ok {
+			continue
+		}
 		select {
-		case client.send <- cmd.Data:
+		case client.send <- data:
 			log.Printf("Kuyruktan komut iletildi: command_id=%s", cmd.CommandID)
 		default:
+			client.noteDrop()
 			log.Printf("Agent buffer dolu, kuyruk komutu atlanıyor: command_id=%s", cmd.CommandID)
 		}
 	}
 }
 
+// RemovePendingCommand — henüz iletilmemiş komutu kuyruktan çıkarır.
+// Komut kuyrukta bulunup çıkarıldıysa true döner.
+func (h *Hub) RemovePendingCommand(serviceID, commandID string) bool {
+	if h.redisClient != nil {
+		ctx := context.Background()
+		key := "nanonet:pc:" + serviceID
+		cmds, err := h.redisClient.LRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return false
+		}
+		removed := false
+		for _, raw := range cmds {
+			var cmd struct {
+				CommandID string `json:"command_id"`
+			}
+			if json.Unmarshal([]byte(raw), &cmd) != nil || cmd.CommandID != commandID {
+				continue
+			}
+			if n, err := h.redisClient.LRem(ctx, key, 1, raw).Result(); err == nil && n > 0 {
+				removed = true
+			}
+		}
+		return removed
+	}
+
+	h.pendingMu.Lock()
+	defer h.pendingMu.Unlock()
+
+	queue := h.pendingCommands[serviceID]
+	for i, cmd := range queue {
+		if cmd.CommandID == commandID {
+			h.pendingCommands[serviceID] = append(queue[:i:i], queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// SendCancelToAgents — çalışmakta olan komut için servisin agent'larına iptal mesajı gönderir.
+// İptal mesajları kuyruğa alınmaz; hiçbir agent bağlı değilse false döner.
+func (h *Hub) SendCancelToAgents(serviceID, commandID string) bool {
+	jsonData, err := h.encodeForAgent(serviceID, map[string]interface{}{
+		"type":       "cancel",
+		"command_id": commandID,
+	})
+	if err != nil {
+		return false
+	}
+
+	if h.redisClient != nil {
+		// Tüm backend örneklerindeki agent'lara (bu örnek dahil) pub/sub ile ulaşır.
+		if len(h.ListAgentInstances(serviceID)) == 0 {
+			return false
+		}
+		h.redisClient.Publish(context.Background(), "nanonet:cmd:"+serviceID, string(jsonData))
+		return true
+	}
+
+	if !h.IsAgentConnected(serviceID) {
+		return false
+	}
+	h.tryDeliverToLocalAgent(serviceID, jsonData)
+	return true
+}
+
 // GetConnectedAgentsForService — bir servis için bağlı agent sayısını döndürür.
 func (h *Hub) GetConnectedAgentsForService(serviceID string) int {
 	h.mu.RLock()
@@ -0,0 +1,184 @@
+package ws
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// rttSmoothing — RTT ortalamasında son ölçümün ağırlığı (EWMA).
+	rttSmoothing = 0.2
+	// reconnectWindow — yeniden bağlanma sayısının hesaplandığı pencere.
+	reconnectWindow = 24 * time.Hour
+
+	// Bağlantı kalitesi eşikleri. RTT ortalaması, pencere içindeki yeniden bağlanmalar ve
+	// atlanan mesajlar agent ağ sorunlarını servis sorunlarından ayırmak için kullanılır.
+	linkDegradedRTT        = 300 * time.Millisecond
+	linkPoorRTT            = time.Second
+	linkDegradedReconnects = 3
+	linkPoorReconnects     = 10
+)
+
+// Bağlantı kalitesi değerleri.
+const (
+	LinkUnknown  = "unknown"
+	LinkGood     = "good"
+	LinkDegraded = "degraded"
+	LinkPoor     = "poor"
+)
+
+// pingPayload — WebSocket ping çerçevesine gönderim anı yazılır; agent pong'da aynı veriyi
+// geri döndürdüğü için RTT ek bir mesaj türü gerekmeden ölçülür.
+func pingPayload(now time.Time) []byte {
+	return []byte(strconv.FormatInt(now.UnixNano(), 10))
+}
+
+// parsePong — pong verisinden RTT'yi hesaplar. Veri backend'in gönderdiği biçimde değilse
+// (örn. agent boş pong gönderdiyse) false döner.
+func parsePong(appData string, now time.Time) (time.Duration, bool) {
+	sent, err := strconv.ParseInt(appData, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	rtt := now.Sub(time.Unix(0, sent))
+	if rtt < 0 || rtt > pongWait {
+		return 0, false
+	}
+	return rtt, true
+}
+
+// recordPong — agent bağlantısının RTT değerlerini günceller ve instance kaydını yayınlar.
+func (h *Hub) recordPong(client *Client, rtt time.Duration, at time.Time) {
+	h.mu.Lock()
+	client.rtt = rtt
+	if client.rttAvg == 0 {
+		client.rttAvg = rtt
+	} else {
+		client.rttAvg = time.Duration(rttSmoothing*float64(rtt) + (1-rttSmoothing)*float64(client.rttAvg))
+	}
+	client.lastPongAt = at
+	_, registered := h.agentClients[client]
+	instance := client.instance()
+	h.mu.Unlock()
+
+	if registered {
+		h.publishInstance(instance)
+	}
+}
+
+// noteDrop — gönderim tamponu dolu olduğu için atlanan mesajı sayar.
+func (c *Client) noteDrop() {
+	c.sendDrops.Add(1)
+}
+
+// ProbeAgentLinks — servise bu örnekte bağlı agent'lara hemen ping gönderir; RTT pong
+// geldiğinde güncellenir. Ping gönderilen bağlantı sayısını döndürür.
+func (h *Hub) ProbeAgentLinks(serviceID string) int {
+	h.mu.RLock()
+	var targets []*Client
+	for client := range h.agentClients {
+		if client.serviceID == serviceID {
+			targets = append(targets, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	sent := 0
+	for _, client := range targets {
+		if client.conn == nil {
+			continue
+		}
+		// WriteControl, WritePump'taki yazımlarla eşzamanlı çağrılabilir.
+		if err := client.conn.WriteControl(websocket.PingMessage, pingPayload(time.Now()), time.Now().Add(writeWait)); err == nil {
+			sent++
+		}
+	}
+	return sent
+}
+
+func reconnectKey(serviceID, agentID string) string {
+	return "nanonet:reconnects:" + serviceID + ":" + agentID
+}
+
+// countReconnect — agent'ın yeni bağlantısını kaydeder ve pencere içindeki yeniden bağlanma
+// sayısını (ilk bağlantı hariç) döndürür. Redis yapılandırılmışsa tüm backend örneklerindeki
+// bağlantılar sayılır.
+func (h *Hub) countReconnect(serviceID, agentID string, at time.Time) int {
+	cutoff := at.Add(-reconnectWindow)
+
+	if h.redisClient != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		key := reconnectKey(serviceID, agentID)
+		pipe := h.redisClient.TxPipeline()
+		pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(cutoff.UnixNano(), 10))
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(at.UnixNano()), Member: at.UnixNano()})
+		card := pipe.ZCard(ctx, key)
+		pipe.Expire(ctx, key, reconnectWindow)
+		if _, err := pipe.Exec(ctx); err == nil {
+			return reconnectsFrom(int(card.Val()))
+		}
+		log.Printf("Yeniden bağlanma sayısı Redis'e yazılamadı [service=%s, agent=%s]", serviceID, agentID)
+	}
+
+	h.connectMu.Lock()
+	defer h.connectMu.Unlock()
+	key := serviceID + ":" + agentID
+	recent := h.agentConnects[key][:0]
+	for _, t := range h.agentConnects[key] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, at)
+	h.agentConnects[key] = recent
+	return reconnectsFrom(len(recent))
+}
+
+func reconnectsFrom(connects int) int {
+	if connects <= 1 {
+		return 0
+	}
+	return connects - 1
+}
+
+// LinkQuality — instance'ın backend ile bağlantı kalitesini RTT ortalaması, pencere içindeki
+// yeniden bağlanmalar ve atlanan mesajlardan sınıflandırır. Henüz pong alınmamışsa ve başka
+// bir belirti yoksa LinkUnknown döner.
+func (i AgentInstance) LinkQuality() string {
+	var rtt time.Duration
+	if i.RTTAvgMs != nil {
+		rtt = time.Duration(*i.RTTAvgMs * float64(time.Millisecond))
+	}
+	switch {
+	case rtt >= linkPoorRTT, i.Reconnects >= linkPoorReconnects:
+		return LinkPoor
+	case rtt >= linkDegradedRTT, i.Reconnects >= linkDegradedReconnects, i.SendDrops > 0:
+		return LinkDegraded
+	case i.RTTAvgMs == nil:
+		return LinkUnknown
+	}
+	return LinkGood
+}
+
+// LocalAgentInstances — bu backend örneğine bağlı agent instance'larını döndürür.
+func (h *Hub) LocalAgentInstances() []AgentInstance {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	instances := make([]AgentInstance, 0, len(h.agentClients))
+	for client := range h.agentClients {
+		instances = append(instances, client.instance())
+	}
+	return instances
+}
+
+func durationMs(d time.Duration) *float64 {
+	ms := float64(d.Microseconds()) / 1000.0
+	return &ms
+}
@@ -0,0 +1,63 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePong(t *testing.T) {
+	now := time.Now()
+	rtt, ok := parsePong(string(pingPayload(now.Add(-40*time.Millisecond))), now)
+	require.True(t, ok)
+	assert.Equal(t, 40*time.Millisecond, rtt)
+
+	_, ok = parsePong("NN", now)
+	assert.False(t, ok, "agent'ın kendi ping'ine ait pong yok sayılmalı")
+	_, ok = parsePong(string(pingPayload(now.Add(time.Second))), now)
+	assert.False(t, ok)
+}
+
+func TestHub_LinkStats(t *testing.T) {
+	h := NewHub(10)
+	agent := NewClient("web-1", AgentClient, h, nil)
+	agent.serviceID = "svc-1"
+	h.agentClients[agent] = true
+
+	now := time.Now()
+	h.recordPong(agent, 100*time.Millisecond, now)
+	h.recordPong(agent, 200*time.Millisecond, now)
+
+	for i := 0; i < cap(agent.send); i++ {
+		require.NoError(t, agent.SendJSON(map[string]string{"type": "welcome"}))
+	}
+	assert.Error(t, agent.SendJSON(map[string]string{"type": "welcome"}))
+
+	inst := h.LocalAgentInstances()[0]
+	require.NotNil(t, inst.RTTMs)
+	assert.Equal(t, 200.0, *inst.RTTMs)
+	assert.Equal(t, 120.0, *inst.RTTAvgMs)
+	assert.Equal(t, int64(1), inst.SendDrops)
+	assert.Equal(t, LinkDegraded, inst.LinkQuality(), "atlanan mesaj bağlantıyı zayıf göstermeli")
+}
+
+func TestHub_CountReconnect(t *testing.T) {
+	h := NewHub(10)
+	start := time.Now()
+	assert.Equal(t, 0, h.countReconnect("svc-1", "web-1", start.Add(-25*time.Hour)))
+	assert.Equal(t, 0, h.countReconnect("svc-1", "web-1", start), "pencere dışındaki bağlantı sayılmamalı")
+	assert.Equal(t, 1, h.countReconnect("svc-1", "web-1", start.Add(time.Minute)))
+	assert.Equal(t, 0, h.countReconnect("svc-1", "web-2", start.Add(time.Minute)))
+}
+
+func TestAgentInstance_LinkQuality(t *testing.T) {
+	ms := func(v float64) *float64 { return &v }
+	assert.Equal(t, LinkUnknown, AgentInstance{}.LinkQuality())
+	assert.Equal(t, LinkGood, AgentInstance{RTTAvgMs: ms(40)}.LinkQuality())
+	assert.Equal(t, LinkDegraded, AgentInstance{RTTAvgMs: ms(450)}.LinkQuality())
+	assert.Equal(t, LinkDegraded, AgentInstance{RTTAvgMs: ms(40), Reconnects: 3}.LinkQuality())
+	assert.Equal(t, LinkPoor, AgentInstance{RTTAvgMs: ms(1500)}.LinkQuality())
+	assert.Equal(t, LinkPoor, AgentInstance{Reconnects: 12}.LinkQuality())
+}
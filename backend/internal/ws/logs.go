@@ -0,0 +1,268 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxLogLineBytes — tek log satırının saklanan en uzun hali; fazlası kırpılır.
+	MaxLogLineBytes = 8192
+	// maxLogClockSkew — agent saatinin bu kadar ilerisindeki zaman damgaları alınış anına çekilir.
+	maxLogClockSkew = 5 * time.Minute
+	// maxLogAge — bundan eski zaman damgalı satırlar (örn. yanlış ayarlı saat) alınış anına çekilir.
+	maxLogAge = 24 * time.Hour
+	// MaxLogPatternLen — canlı takip ve aramada kabul edilen en uzun regex.
+	MaxLogPatternLen = 256
+)
+
+// LogLevels — normalize edilmiş log seviyeleri.
+var LogLevels = []string{"trace", "debug", "info", "warn", "error", "fatal", "unknown"}
+
+// LogStreams — log satırının kaynağı.
+var LogStreams = []string{"stdout", "stderr", "file"}
+
+var logLevelAliases = map[string]string{
+	"trc":      "trace",
+	"dbg":      "debug",
+	"inf":      "info",
+	"notice":   "info",
+	"warning":  "warn",
+	"wrn":      "warn",
+	"err":      "error",
+	"critical": "fatal",
+	"crit":     "fatal",
+	"panic":    "fatal",
+	"alert":    "fatal",
+	"emerg":    "fatal",
+}
+
+// LogLine — agent'ın log mesajındaki tek satır. Normalize edildikten sonra TS her zaman
+// RFC3339Nano (UTC) biçimindedir.
+type LogLine struct {
+	TS      string `json:"ts"`
+	Stream  string `json:"stream"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+}
+
+// LogBatch — canlı takip bağlantılarına gönderilen log_lines mesajı.
+type LogBatch struct {
+	Type      string    `json:"type"`
+	ServiceID string    `json:"service_id"`
+	AgentID   string    `json:"agent_id"`
+	Lines     []LogLine `json:"lines"`
+}
+
+type OnLogsFunc func(s AgentSession, lines []LogLine)
+
+// SetOnLogs — agent log satırları gönderdiğinde (normalize edilmiş olarak) çağrılır.
+func (h *Hub) SetOnLogs(fn OnLogsFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onLogs = fn
+}
+
+// NormalizeLogLines — seviye/akış adlarını standartlaştırır, mesajları kırpar ve geçersiz ya da
+// makul olmayan zaman damgalarını alınış anına çeker. NUL baytları (Postgres TEXT kabul etmez)
+// silinir; boş mesajlı satırlar atılır.
+func NormalizeLogLines(lines []LogLine, now time.Time) []LogLine {
+	out := make([]LogLine, 0, len(lines))
+	for _, l := range lines {
+		msg := strings.TrimRight(stripNUL(strings.ToValidUTF8(l.Message, "�")), "\r\n")
+		if strings.TrimSpace(msg) == "" {
+			continue
+		}
+		l.Message = truncateUTF8(msg, MaxLogLineBytes)
+		l.Level = normalizeLogLevel(l.Level)
+		l.Path = truncateUTF8(stripNUL(strings.ToValidUTF8(l.Path, "�")), 500)
+		if !containsString(LogStreams, l.Stream) {
+			if l.Path != "" {
+				l.Stream = "file"
+			} else {
+				l.Stream = "stdout"
+			}
+		}
+
+		ts, err := time.Parse(time.RFC3339Nano, l.TS)
+		if err != nil || ts.After(now.Add(maxLogClockSkew)) || ts.Before(now.Add(-maxLogAge)) {
+			ts = now
+		}
+		l.TS = ts.UTC().Format(time.RFC3339Nano)
+		out = append(out, l)
+	}
+	return out
+}
+
+func stripNUL(s string) string {
+	return strings.ReplaceAll(s, "\x00", "")
+}
+
+func normalizeLogLevel(level string) string {
+	level = strings.ToLower(strings.TrimSpace(level))
+	if alias, ok := logLevelAliases[level]; ok {
+		return alias
+	}
+	if containsString(LogLevels, level) {
+		return level
+	}
+	return "unknown"
+}
+
+func truncateUTF8(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	s = s[:max]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// LogFilter — canlı takip ve arama için satır filtresi. Boş alanlar filtre uygulamaz.
+type LogFilter struct {
+	Levels   []string
+	Contains string
+	Pattern  *regexp.Regexp
+}
+
+// ParseLogFilter — virgülle ayrılmış seviyeleri, alt dizgeyi ve regex'i doğrular.
+func ParseLogFilter(levels, contains, pattern string) (LogFilter, error) {
+	var f LogFilter
+	for _, lvl := range strings.Split(levels, ",") {
+		lvl = strings.TrimSpace(lvl)
+		if lvl == "" {
+			continue
+		}
+		norm := normalizeLogLevel(lvl)
+		if norm == "unknown" && strings.ToLower(lvl) != "unknown" {
+			return f, fmt.Errorf("bilinmeyen log seviyesi: %s", lvl)
+		}
+		if !containsString(f.Levels, norm) {
+			f.Levels = append(f.Levels, norm)
+		}
+	}
+	f.Contains = strings.ToLower(contains)
+	if pattern != "" {
+		if len(pattern) > MaxLogPatternLen {
+			return f, fmt.Errorf("regex en fazla %d karakter olabilir", MaxLogPatternLen)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return f, fmt.Errorf("geçersiz regex: %v", err)
+		}
+		f.Pattern = re
+	}
+	return f, nil
+}
+
+// Match — satırın filtreye uyduğunu bildirir.
+func (f LogFilter) Match(l LogLine) bool {
+	if len(f.Levels) > 0 && !containsString(f.Levels, l.Level) {
+		return false
+	}
+	if f.Contains != "" && !strings.Contains(strings.ToLower(l.Message), f.Contains) {
+		return false
+	}
+	if f.Pattern != nil && !f.Pattern.MatchString(l.Message) {
+		return false
+	}
+	return true
+}
+
+// handleLogs — agent'ın log mesajını normalize eder, saklama için iletir ve canlı takipçilere yayar.
+func (h *Hub) handleLogs(client *Client, msg AgentMessage) {
+	lines := NormalizeLogLines(msg.Lines, time.Now())
+	if len(lines) == 0 {
+		return
+	}
+
+	h.mu.RLock()
+	fn := h.onLogs
+	h.mu.RUnlock()
+	if fn != nil {
+		fn(client.session(), lines)
+	}
+
+	h.PublishLogs(LogBatch{
+		Type:      "log_lines",
+		ServiceID: client.serviceID,
+		AgentID:   client.id,
+		Lines:     lines,
+	})
+}
+
+// PublishLogs — log satırlarını servisin canlı takip bağlantılarına iletir. Redis yapılandırılmışsa
+// diğer backend örneklerindeki takipçilere de ulaşır.
+func (h *Hub) PublishLogs(batch LogBatch) {
+	if h.redisClient != nil {
+		data, err := json.Marshal(batch)
+		if err != nil {
+			return
+		}
+		if err := h.redisClient.Publish(context.Background(), "nanonet:logs:"+batch.ServiceID, string(data)).Err(); err == nil {
+			return
+		}
+	}
+	h.deliverLogsLocal(batch)
+}
+
+func (h *Hub) deliverLogsRemote(payload []byte) {
+	var batch LogBatch
+	if err := json.Unmarshal(payload, &batch); err != nil {
+		log.Printf("Redis log mesajı çözülemedi: %v", err)
+		return
+	}
+	h.deliverLogsLocal(batch)
+}
+
+// deliverLogsLocal — her takipçiye yalnızca kendi filtresine uyan satırları gönderir.
+// Yetişemeyen takipçilerin satırları atlanır; agent bağlantısı yavaşlatılmaz.
+func (h *Hub) deliverLogsLocal(batch LogBatch) {
+	h.mu.RLock()
+	var targets []*Client
+	for client := range h.logTailClients {
+		if client.serviceID == batch.ServiceID {
+			targets = append(targets, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range targets {
+		lines := make([]LogLine, 0, len(batch.Lines))
+		for _, l := range batch.Lines {
+			if client.logFilter.Match(l) {
+				lines = append(lines, l)
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		out := batch
+		out.Lines = lines
+		if err := client.SendJSON(out); err != nil {
+			log.Printf("Log takipçisi yetişemiyor, satırlar atlandı: %s", client.id)
+		}
+	}
+}
+
+// GetLogTailCount — servisin canlı log takip bağlantısı sayısı (bu örnek).
+func (h *Hub) GetLogTailCount(serviceID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	n := 0
+	for client := range h.logTailClients {
+		if client.serviceID == serviceID {
+			n++
+		}
+	}
+	return n
+}
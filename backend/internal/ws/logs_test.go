@@ -0,0 +1,99 @@
+package ws
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeLogLines(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	lines := NormalizeLogLines([]LogLine{
+		{TS: "2026-03-01T11:59:00+03:00", Level: "WARNING", Message: "yavaş sorgu\r\n", Path: "/var/log/app.log"},
+		{TS: "2027-01-01T00:00:00Z", Stream: "stderr", Level: "crit", Message: "panic"},
+		{TS: "dün", Level: "verbose", Message: strings.Repeat("ç", MaxLogLineBytes)},
+		{TS: "2026-03-01T11:59:00Z", Message: "  \n"},
+		{TS: "2026-03-01T11:59:00Z", Message: "\x00\x00"},
+		{TS: "2026-03-01T11:59:00Z", Message: "a\x00b", Path: "/var/log/\x00x.log"},
+	}, now)
+
+	require.Len(t, lines, 4, "boş satır atılmalı")
+	assert.Equal(t, "ab", lines[3].Message, "NUL baytı silinmeli")
+	assert.Equal(t, "/var/log/x.log", lines[3].Path)
+	assert.Equal(t, "warn", lines[0].Level)
+	assert.Equal(t, "file", lines[0].Stream)
+	assert.Equal(t, "yavaş sorgu", lines[0].Message)
+	assert.Equal(t, "2026-03-01T08:59:00Z", lines[0].TS)
+
+	assert.Equal(t, "fatal", lines[1].Level)
+	assert.Equal(t, "stderr", lines[1].Stream)
+	assert.Equal(t, now.Format(time.RFC3339Nano), lines[1].TS, "gelecekteki zaman damgası alınış anına çekilmeli")
+
+	assert.Equal(t, "unknown", lines[2].Level)
+	assert.Equal(t, "stdout", lines[2].Stream)
+	assert.LessOrEqual(t, len(lines[2].Message), MaxLogLineBytes)
+	assert.True(t, strings.HasPrefix(lines[2].Message, "çç"))
+	assert.Equal(t, now.Format(time.RFC3339Nano), lines[2].TS)
+}
+
+func TestParseLogFilter(t *testing.T) {
+	f, err := ParseLogFilter("warning, error,error", "TIMEOUT", `db-\d+`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"warn", "error"}, f.Levels)
+
+	assert.True(t, f.Match(LogLine{Level: "error", Message: "db-3 timeout"}))
+	assert.False(t, f.Match(LogLine{Level: "info", Message: "db-3 timeout"}))
+	assert.False(t, f.Match(LogLine{Level: "warn", Message: "db-x timeout"}))
+	assert.False(t, f.Match(LogLine{Level: "warn", Message: "db-3 ok"}))
+
+	_, err = ParseLogFilter("loud", "", "")
+	assert.Error(t, err)
+	_, err = ParseLogFilter("", "", "(")
+	assert.Error(t, err)
+	_, err = ParseLogFilter("", "", strings.Repeat("a", MaxLogPatternLen+1))
+	assert.Error(t, err)
+}
+
+func TestHub_LogTailDelivery(t *testing.T) {
+	h := NewHub(10)
+	agent := NewClient("web-1", AgentClient, h, nil)
+	agent.serviceID = "svc-1"
+	errorsOnly := NewClient("tail-1", LogTailClient, h, nil)
+	errorsOnly.serviceID = "svc-1"
+	errorsOnly.logFilter, _ = ParseLogFilter("error", "", "")
+	all := NewClient("tail-2", LogTailClient, h, nil)
+	all.serviceID = "svc-1"
+	other := NewClient("tail-3", LogTailClient, h, nil)
+	other.serviceID = "svc-2"
+	for _, c := range []*Client{errorsOnly, all, other} {
+		h.logTailClients[c] = true
+	}
+
+	var stored []LogLine
+	h.SetOnLogs(func(s AgentSession, lines []LogLine) {
+		assert.Equal(t, "svc-1", s.ServiceID)
+		stored = append(stored, lines...)
+	})
+
+	ts := time.Now().UTC().Format(time.RFC3339Nano)
+	h.HandleAgentMessage(agent, []byte(`{"type":"log","lines":[`+
+		`{"ts":"`+ts+`","level":"info","message":"başladı"},`+
+		`{"ts":"`+ts+`","level":"err","message":"çöktü"}]}`))
+
+	assert.Len(t, stored, 2)
+	assert.Empty(t, other.send)
+
+	var batch LogBatch
+	require.NoError(t, json.Unmarshal(<-errorsOnly.send, &batch))
+	assert.Equal(t, "log_lines", batch.Type)
+	assert.Equal(t, "web-1", batch.AgentID)
+	require.Len(t, batch.Lines, 1)
+	assert.Equal(t, "çöktü", batch.Lines[0].Message)
+
+	require.NoError(t, json.Unmarshal(<-all.send, &batch))
+	assert.Len(t, batch.Lines, 2)
+}
@@ -0,0 +1,322 @@
+package ws
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// ProtocolVersion — backend'in konuştuğu en yeni agent protokol sürümü.
+	ProtocolVersion = 2
+	// MinProtocolVersion — hâlâ kabul edilen en eski protokol sürümü.
+	MinProtocolVersion = 1
+	// ConfigProtocolVersion — config/config_ack mesajlarını destekleyen ilk protokol sürümü.
+	ConfigProtocolVersion = 2
+)
+
+// Agent → backend mesaj tipleri.
+const (
+	MsgHello     = "hello"
+	MsgMetrics   = "metrics"
+	MsgAck       = "ack"
+	MsgResult    = "result"
+	MsgConfigAck = "config_ack"
+	MsgLog       = "log"
+)
+
+// Backend → agent mesaj tipleri.
+const (
+	MsgWelcome = "welcome"
+	MsgCommand = "command"
+	MsgCancel  = "cancel"
+	MsgError   = "error"
+	MsgConfig  = "config"
+)
+
+// error mesajı kodları.
+const (
+	ErrCodeInvalidMessage      = "invalid_message"
+	ErrCodeUnsupportedType     = "unsupported_message_type"
+	ErrCodeUnsupportedProtocol = "unsupported_protocol_version"
+)
+
+// Direction — mesajın akış yönü; şemalar yöne göre ayrı dizinlerde tutulur.
+type Direction string
+
+const (
+	FromAgent   Direction = "agent"
+	FromBackend Direction = "backend"
+)
+
+// CommandActions — agent'ların yetenek olarak bildirebileceği komut aksiyonları.
+var CommandActions = []string{"restart", "stop", "start", "exec", "scale", "ping"}
+
+var (
+	ErrUnknownMessageType = errors.New("bilinmeyen mesaj tipi")
+	ErrInvalidMessage     = errors.New("mesaj şemaya uymuyor")
+)
+
+//go:embed schemas
+var schemaFS embed.FS
+
+var schemas = mustLoadSchemas()
+
+// WelcomeMessage — hello yanıtı.
+type WelcomeMessage struct {
+	Type            string   `json:"type"`
+	ProtocolVersion int      `json:"protocol_version"`
+	AgentMessages   []string `json:"agent_messages"`
+	BackendMessages []string `json:"backend_messages"`
+	Capabilities    []string `json:"capabilities"`
+}
+
+// ErrorMessage — agent'tan gelen mesaj işlenemediğinde gönderilir.
+type ErrorMessage struct {
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	RefType string `json:"ref_type,omitempty"`
+}
+
+// SupportedMessages — yön için şeması tanımlı mesaj tiplerini sıralı döndürür.
+func SupportedMessages(dir Direction) []string {
+	types := make([]string, 0, len(schemas[dir]))
+	for t := range schemas[dir] {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ValidateMessage — ham mesajı tipine ait JSON şemasına göre doğrular ve tipini döndürür.
+func ValidateMessage(dir Direction, raw []byte) (string, error) {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("%w: mesaj bir JSON nesnesi olmalı", ErrInvalidMessage)
+	}
+	msgType, _ := obj["type"].(string)
+	schema, ok := schemas[dir][msgType]
+	if !ok {
+		return msgType, fmt.Errorf("%w: %q", ErrUnknownMessageType, msgType)
+	}
+	if err := schema.validate("$", v); err != nil {
+		return msgType, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	return msgType, nil
+}
+
+// negotiateVersion — agent'ın bildirdiği sürümle backend'in desteklediği en yüksek ortak sürümü seçer.
+func negotiateVersion(agentVersion int) (int, error) {
+	if agentVersion < MinProtocolVersion {
+		return 0, fmt.Errorf("protokol sürümü %d desteklenmiyor (en az %d)", agentVersion, MinProtocolVersion)
+	}
+	if agentVersion > ProtocolVersion {
+		return ProtocolVersion, nil
+	}
+	return agentVersion, nil
+}
+
+// acceptCapabilities — bildirilen yeteneklerden backend'in tanıdıklarını (tekrarsız) döndürür.
+func acceptCapabilities(declared []string) []string {
+	accepted := make([]string, 0, len(declared))
+	seen := make(map[string]bool, len(declared))
+	for _, c := range declared {
+		if seen[c] || !containsString(CommandActions, c) {
+			continue
+		}
+		seen[c] = true
+		accepted = append(accepted, c)
+	}
+	return accepted
+}
+
+// maxExecCommands — hello'da kabul edilen en fazla exec katalog girdisi.
+const maxExecCommands = 100
+
+var execCommandPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{1,49}$`)
+
+// acceptExecCommands — hello'daki exec katalog adlarını süzer. Alan hiç gönderilmemişse nil
+// döner; bu, agent'ın yalnızca yerleşik komutları uyguladığı anlamına gelir.
+func acceptExecCommands(declared []string) []string {
+	if declared == nil {
+		return nil
+	}
+	accepted := make([]string, 0, len(declared))
+	for _, name := range declared {
+		if len(accepted) == maxExecCommands {
+			break
+		}
+		if execCommandPattern.MatchString(name) && !containsString(accepted, name) {
+			accepted = append(accepted, name)
+		}
+	}
+	return accepted
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func mustLoadSchemas() map[Direction]map[string]*jsonSchema {
+	out := make(map[Direction]map[string]*jsonSchema)
+	for _, dir := range []Direction{FromAgent, FromBackend} {
+		out[dir] = make(map[string]*jsonSchema)
+		entries, err := schemaFS.ReadDir(path.Join("schemas", string(dir)))
+		if err != nil {
+			panic(fmt.Sprintf("ws: şema dizini okunamadı: %v", err))
+		}
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+				continue
+			}
+			data, err := schemaFS.ReadFile(path.Join("schemas", string(dir), e.Name()))
+			if err != nil {
+				panic(fmt.Sprintf("ws: şema okunamadı (%s): %v", e.Name(), err))
+			}
+			var s jsonSchema
+			if err := json.Unmarshal(data, &s); err != nil {
+				panic(fmt.Sprintf("ws: şema parse edilemedi (%s): %v", e.Name(), err))
+			}
+			out[dir][strings.TrimSuffix(e.Name(), ".json")] = &s
+		}
+	}
+	return out
+}
+
+// jsonSchema — mesaj şemalarında kullanılan JSON Schema alt kümesi.
+type jsonSchema struct {
+	Type                 interface{}            `json:"type"` // string veya []string
+	Const                interface{}            `json:"const"`
+	Enum                 []interface{}          `json:"enum"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Minimum              *float64               `json:"minimum"`
+	MaxLength            *int                   `json:"maxLength"`
+	MaxItems             *int                   `json:"maxItems"`
+}
+
+func (s *jsonSchema) validate(at string, v interface{}) error {
+	if s.Type != nil && !s.matchesType(v) {
+		return fmt.Errorf("%s: beklenen tip %v", at, s.Type)
+	}
+	if s.Const != nil && !jsonEqual(s.Const, v) {
+		return fmt.Errorf("%s: değer %v olmalı", at, s.Const)
+	}
+	if s.Enum != nil {
+		found := false
+		for _, e := range s.Enum {
+			if jsonEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: değer izin verilenlerden biri olmalı %v", at, s.Enum)
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, key := range s.Required {
+			if _, ok := val[key]; !ok {
+				return fmt.Errorf("%s.%s: zorunlu alan eksik", at, key)
+			}
+		}
+		for key, child := range val {
+			prop, ok := s.Properties[key]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s.%s: tanımsız alan", at, key)
+				}
+				continue
+			}
+			if err := prop.validate(at+"."+key, child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			return fmt.Errorf("%s: en fazla %d eleman olabilir", at, *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range val {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", at, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		if s.MaxLength != nil && len([]rune(val)) > *s.MaxLength {
+			return fmt.Errorf("%s: en fazla %d karakter olabilir", at, *s.MaxLength)
+		}
+	case float64:
+		if s.Minimum != nil && val < *s.Minimum {
+			return fmt.Errorf("%s: en az %v olmalı", at, *s.Minimum)
+		}
+	}
+	return nil
+}
+
+func (s *jsonSchema) matchesType(v interface{}) bool {
+	switch t := s.Type.(type) {
+	case string:
+		return matchesJSONType(t, v)
+	case []interface{}:
+		for _, item := range t {
+			if name, ok := item.(string); ok && matchesJSONType(name, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesJSONType(name string, v interface{}) bool {
+	switch name {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	}
+	return false
+}
+
+func jsonEqual(a, b interface{}) bool {
+	ab, errA := json.Marshal(a)
+	bb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ab) == string(bb)
+}
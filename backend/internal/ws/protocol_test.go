@@ -0,0 +1,255 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixture struct {
+	name string
+	raw  string
+}
+
+var validFixtures = map[Direction]map[string][]fixture{
+	FromAgent: {
+		MsgHello: {
+			{"tam", `{"type":"hello","protocol_version":1,"agent_id":"web-1","version":"0.2.0","hostname":"web-1","os":"Ubuntu 24.04","ips":["10.0.0.4"],"capabilities":["restart","exec"]}`},
+			{"asgari", `{"type":"hello","protocol_version":1,"capabilities":[]}`},
+			{"yeni sürüm", `{"type":"hello","protocol_version":7,"capabilities":["restart","tail_logs"]}`},
+			{"exec kataloğu", `{"type":"hello","protocol_version":2,"capabilities":["exec"],"exec_commands":["uptime","flush-cache"]}`},
+		},
+		MsgMetrics: {
+			{"agent çıktısı", `{"type":"metrics","agent_id":"a","agent_version":"0.2.0","service_id":"s","timestamp":"2026-01-01T00:00:00Z","system":{"cpu_percent":12.5},"app":{"cpu_percent":null},"service":{"status":"up","latency_ms":12.3,"http_status":200,"error_rate":0},"process":{"pid":1},"target_process":{"pid":2}}`},
+			{"health hatası", `{"type":"metrics","system":{},"service":{"status":"down","latency_ms":0,"http_status":null}}`},
+		},
+		MsgAck: {
+			{"ack", `{"type":"ack","command_id":"c1"}`},
+		},
+		MsgResult: {
+			{"başarılı", `{"type":"result","command_id":"c1","status":"success","output":"ok","error":null}`},
+			{"başarısız", `{"type":"result","command_id":"c1","status":"failed","error":"boom"}`},
+		},
+		MsgConfigAck: {
+			{"uygulandı", `{"type":"config_ack","config_version":3,"status":"applied"}`},
+			{"reddedildi", `{"type":"config_ack","config_version":3,"status":"rejected","error":"log yolu okunamıyor"}`},
+		},
+		MsgLog: {
+			{"dosya", `{"type":"log","lines":[{"ts":"2026-01-01T00:00:00Z","stream":"file","level":"error","message":"db bağlantısı koptu","path":"/var/log/app.log"}]}`},
+			{"asgari", `{"type":"log","lines":[{"ts":"2026-01-01T00:00:00Z","message":"x"}]}`},
+			{"boş", `{"type":"log","agent_id":"web-1","lines":[]}`},
+		},
+	},
+	FromBackend: {
+		MsgWelcome: {
+			{"welcome", `{"type":"welcome","protocol_version":1,"agent_messages":["ack"],"backend_messages":["command"],"capabilities":["restart"]}`},
+		},
+		MsgCommand: {
+			{"restart", `{"type":"command","command_id":"c1","action":"restart","timeout_sec":30}`},
+			{"scale", `{"type":"command","command_id":"c1","action":"scale","instances":0,"strategy":"round_robin","weight_config":""}`},
+			{"exec args", `{"type":"command","command_id":"c1","action":"exec","command":"flush-cache","timeout_sec":30,"args":{"db":2,"dry_run":true}}`},
+		},
+		MsgCancel: {
+			{"cancel", `{"type":"cancel","command_id":"c1"}`},
+		},
+		MsgError: {
+			{"error", `{"type":"error","code":"invalid_message","message":"x","ref_type":"hello"}`},
+		},
+		MsgConfig: {
+			{"tam", `{"type":"config","config_version":2,"poll_interval_sec":15,"collectors":["system","process"],"log_paths":["/var/log/app.log"]}`},
+			{"boş listeler", `{"type":"config","config_version":1,"poll_interval_sec":10,"collectors":[],"log_paths":[]}`},
+		},
+	},
+}
+
+var invalidFixtures = map[Direction]map[string][]fixture{
+	FromAgent: {
+		MsgHello: {
+			{"protocol_version eksik", `{"type":"hello","capabilities":[]}`},
+			{"protocol_version sıfır", `{"type":"hello","protocol_version":0,"capabilities":[]}`},
+			{"protocol_version ondalık", `{"type":"hello","protocol_version":1.5,"capabilities":[]}`},
+			{"capabilities string", `{"type":"hello","protocol_version":1,"capabilities":"restart"}`},
+			{"capability sayı", `{"type":"hello","protocol_version":1,"capabilities":[1]}`},
+			{"exec_commands string", `{"type":"hello","protocol_version":2,"capabilities":[],"exec_commands":"uptime"}`},
+		},
+		MsgMetrics: {
+			{"service eksik", `{"type":"metrics","system":{}}`},
+			{"status eksik", `{"type":"metrics","system":{},"service":{}}`},
+			{"system dizi", `{"type":"metrics","system":[],"service":{"status":"up"}}`},
+		},
+		MsgAck: {
+			{"command_id eksik", `{"type":"ack"}`},
+			{"command_id sayı", `{"type":"ack","command_id":5}`},
+		},
+		MsgResult: {
+			{"status eksik", `{"type":"result","command_id":"c1"}`},
+			{"bilinmeyen status", `{"type":"result","command_id":"c1","status":"done"}`},
+		},
+		MsgConfigAck: {
+			{"config_version eksik", `{"type":"config_ack","status":"applied"}`},
+			{"bilinmeyen status", `{"type":"config_ack","config_version":1,"status":"ok"}`},
+		},
+		MsgLog: {
+			{"lines eksik", `{"type":"log"}`},
+			{"message eksik", `{"type":"log","lines":[{"ts":"2026-01-01T00:00:00Z"}]}`},
+			{"bilinmeyen stream", `{"type":"log","lines":[{"ts":"2026-01-01T00:00:00Z","stream":"syslog","message":"x"}]}`},
+		},
+	},
+	FromBackend: {
+		MsgWelcome: {
+			{"capabilities eksik", `{"type":"welcome","protocol_version":1,"agent_messages":[],"backend_messages":[]}`},
+		},
+		MsgCommand: {
+			{"bilinmeyen action", `{"type":"command","command_id":"c1","action":"rm"}`},
+			{"negatif timeout", `{"type":"command","command_id":"c1","action":"restart","timeout_sec":0}`},
+			{"args dizi", `{"type":"command","command_id":"c1","action":"exec","command":"uptime","args":["x"]}`},
+		},
+		MsgCancel: {
+			{"command_id eksik", `{"type":"cancel"}`},
+		},
+		MsgError: {
+			{"bilinmeyen kod", `{"type":"error","code":"oops","message":"x"}`},
+		},
+		MsgConfig: {
+			{"sürüm sıfır", `{"type":"config","config_version":0,"poll_interval_sec":10,"collectors":[],"log_paths":[]}`},
+			{"kısa aralık", `{"type":"config","config_version":1,"poll_interval_sec":1,"collectors":[],"log_paths":[]}`},
+			{"bilinmeyen toplayıcı", `{"type":"config","config_version":1,"poll_interval_sec":10,"collectors":["gpu"],"log_paths":[]}`},
+			{"log_paths null", `{"type":"config","config_version":1,"poll_interval_sec":10,"collectors":[],"log_paths":null}`},
+		},
+	},
+}
+
+func TestSchemas_CoverEveryMessageType(t *testing.T) {
+	assert.Equal(t, []string{MsgAck, MsgConfigAck, MsgHello, MsgLog, MsgMetrics, MsgResult}, SupportedMessages(FromAgent))
+	assert.Equal(t, []string{MsgCancel, MsgCommand, MsgConfig, MsgError, MsgWelcome}, SupportedMessages(FromBackend))
+
+	for dir, byType := range validFixtures {
+		for _, msgType := range SupportedMessages(dir) {
+			assert.NotEmpty(t, byType[msgType], "%s/%s için geçerli örnek yok", dir, msgType)
+			assert.NotEmpty(t, invalidFixtures[dir][msgType], "%s/%s için geçersiz örnek yok", dir, msgType)
+		}
+	}
+}
+
+func TestValidateMessage_Valid(t *testing.T) {
+	for dir, byType := range validFixtures {
+		for msgType, fixtures := range byType {
+			for _, f := range fixtures {
+				got, err := ValidateMessage(dir, []byte(f.raw))
+				assert.NoError(t, err, "%s/%s: %s", dir, msgType, f.name)
+				assert.Equal(t, msgType, got)
+			}
+		}
+	}
+}
+
+func TestValidateMessage_Invalid(t *testing.T) {
+	for dir, byType := range invalidFixtures {
+		for msgType, fixtures := range byType {
+			for _, f := range fixtures {
+				_, err := ValidateMessage(dir, []byte(f.raw))
+				assert.ErrorIs(t, err, ErrInvalidMessage, "%s/%s: %s", dir, msgType, f.name)
+			}
+		}
+	}
+}
+
+func TestValidateMessage_UnknownType(t *testing.T) {
+	_, err := ValidateMessage(FromAgent, []byte(`{"type":"command","command_id":"c1","action":"restart"}`))
+	assert.ErrorIs(t, err, ErrUnknownMessageType)
+
+	_, err = ValidateMessage(FromAgent, []byte(`{"command_id":"c1"}`))
+	assert.ErrorIs(t, err, ErrUnknownMessageType)
+
+	_, err = ValidateMessage(FromAgent, []byte(`[1,2]`))
+	assert.ErrorIs(t, err, ErrInvalidMessage)
+}
+
+func TestOutgoingMessages_MatchSchemas(t *testing.T) {
+	for _, v := range []interface{}{
+		WelcomeMessage{
+			Type:            MsgWelcome,
+			ProtocolVersion: ProtocolVersion,
+			AgentMessages:   SupportedMessages(FromAgent),
+			BackendMessages: SupportedMessages(FromBackend),
+			Capabilities:    acceptCapabilities([]string{"restart"}),
+		},
+		ErrorMessage{Type: MsgError, Code: ErrCodeUnsupportedProtocol, Message: "x"},
+		map[string]interface{}{"type": MsgCancel, "command_id": "c1"},
+		AgentConfig{Type: MsgConfig, ConfigVersion: 1, PollIntervalSec: 10, Collectors: ConfigCollectors, LogPaths: []string{}},
+	} {
+		raw, err := json.Marshal(v)
+		require.NoError(t, err)
+		_, err = ValidateMessage(FromBackend, raw)
+		assert.NoError(t, err, string(raw))
+	}
+}
+
+func TestNegotiateVersion(t *testing.T) {
+	v, err := negotiateVersion(ProtocolVersion)
+	require.NoError(t, err)
+	assert.Equal(t, ProtocolVersion, v)
+
+	v, err = negotiateVersion(ProtocolVersion + 5)
+	require.NoError(t, err)
+	assert.Equal(t, ProtocolVersion, v)
+
+	_, err = negotiateVersion(MinProtocolVersion - 1)
+	assert.Error(t, err)
+}
+
+func TestAcceptCapabilities(t *testing.T) {
+	assert.Equal(t, []string{"restart", "exec"}, acceptCapabilities([]string{"restart", "tail_logs", "exec", "restart"}))
+	assert.Empty(t, acceptCapabilities(nil))
+}
+
+func TestAgentInstance_Supports(t *testing.T) {
+	legacy := AgentInstance{}
+	assert.True(t, legacy.Supports("exec"))
+
+	inst := AgentInstance{ProtocolVersion: 1, Capabilities: []string{"restart"}}
+	assert.True(t, inst.Supports("restart"))
+	assert.False(t, inst.Supports("exec"))
+
+	none := AgentInstance{ProtocolVersion: 1}
+	assert.False(t, none.Supports("restart"))
+}
+
+func TestHub_PushAgentConfig(t *testing.T) {
+	h := NewHub(10)
+	legacy := NewClient("old", AgentClient, h, nil)
+	legacy.serviceID = "svc-1"
+	legacy.protocolVersion = 1
+	current := NewClient("new", AgentClient, h, nil)
+	current.serviceID = "svc-1"
+	current.protocolVersion = ConfigProtocolVersion
+	other := NewClient("other", AgentClient, h, nil)
+	other.serviceID = "svc-2"
+	other.protocolVersion = ConfigProtocolVersion
+	for _, c := range []*Client{legacy, current, other} {
+		h.agentClients[c] = true
+	}
+
+	cfg := AgentConfig{ConfigVersion: 4, PollIntervalSec: 30, Collectors: []string{"system"}}
+	assert.Equal(t, 1, h.PushAgentConfig("svc-1", "", cfg), "config yalnızca protokolü destekleyen agent'lara gitmeli")
+	assert.Empty(t, legacy.send)
+	assert.Empty(t, other.send)
+
+	raw := <-current.send
+	msgType, err := ValidateMessage(FromBackend, raw)
+	require.NoError(t, err)
+	assert.Equal(t, MsgConfig, msgType)
+
+	assert.Equal(t, 0, h.PushAgentConfig("svc-1", "old", cfg))
+	assert.Equal(t, 1, h.PushAgentConfig("svc-1", "new", cfg))
+}
+
+func TestReplayKey_ConfigUsesServiceAndVersion(t *testing.T) {
+	a := replayKey(map[string]interface{}{"type": MsgConfig, "service_id": "svc-1", "config_version": float64(3)})
+	b := replayKey(map[string]interface{}{"type": MsgConfig, "service_id": "svc-2", "config_version": float64(3)})
+	c := replayKey(map[string]interface{}{"type": MsgConfig, "service_id": "svc-1", "config_version": float64(4)})
+	assert.NotEqual(t, a, b)
+	assert.NotEqual(t, a, c)
+}
@@ -0,0 +1,195 @@
+package ws
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultCommandTTL — imzalı komutun agent tarafından kabul edileceği varsayılan süre.
+const DefaultCommandTTL = 5 * time.Minute
+
+// SignatureAlg — zarflarda kullanılan imza algoritması.
+const SignatureAlg = "ed25519"
+
+var (
+	ErrUnsigned         = errors.New("mesaj imzalı değil")
+	ErrBadSignature     = errors.New("imza doğrulanamadı")
+	ErrEnvelopeExpired  = errors.New("imzalı mesajın süresi dolmuş")
+	ErrEnvelopeReplayed = errors.New("komut daha önce iletildi")
+)
+
+// Envelope — imzalı komut zarfı. Payload, komutun service_id/issued_at/expires_at alanlarıyla
+// birlikte JSON kodlamasının base64 halidir; imza bu baytlar üzerinden atılır. Agent'lar üst
+// seviyedeki alanlara değil, doğrulanmış payload'a göre hareket etmelidir.
+type Envelope struct {
+	Alg       string `json:"alg"`
+	KeyID     string `json:"key_id"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// SigningKey — agent'lara dağıtılan açık anahtar.
+type SigningKey struct {
+	KeyID     string `json:"key_id"`
+	Alg       string `json:"alg"`
+	PublicKey string `json:"public_key"`
+}
+
+// CommandSigner — backend → agent komutlarını Ed25519 ile imzalar ve doğrular.
+type CommandSigner struct {
+	key   ed25519.PrivateKey
+	keyID string
+	ttl   time.Duration
+}
+
+// NewCommandSigner — base64 kodlu 32 baytlık seed veya 64 baytlık özel anahtardan imzalayıcı oluşturur.
+func NewCommandSigner(encodedKey string, ttl time.Duration) (*CommandSigner, error) {
+	raw, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("imza anahtarı base64 çözülemedi: %w", err)
+	}
+	var key ed25519.PrivateKey
+	switch len(raw) {
+	case ed25519.SeedSize:
+		key = ed25519.NewKeyFromSeed(raw)
+	case ed25519.PrivateKeySize:
+		key = ed25519.PrivateKey(raw)
+	default:
+		return nil, fmt.Errorf("imza anahtarı %d veya %d bayt olmalı", ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+	return newSigner(key, ttl), nil
+}
+
+// GenerateCommandSigner — geçici (süreç ömrü boyunca geçerli) bir anahtarla imzalayıcı oluşturur.
+func GenerateCommandSigner(ttl time.Duration) (*CommandSigner, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newSigner(key, ttl), nil
+}
+
+func newSigner(key ed25519.PrivateKey, ttl time.Duration) *CommandSigner {
+	if ttl <= 0 {
+		ttl = DefaultCommandTTL
+	}
+	pub := key.Public().(ed25519.PublicKey)
+	sum := sha256.Sum256(pub)
+	return &CommandSigner{
+		key:   key,
+		keyID: hex.EncodeToString(sum[:8]),
+		ttl:   ttl,
+	}
+}
+
+// PublicKey — agent'ların doğrulamada kullanacağı açık anahtar bilgisi.
+func (s *CommandSigner) PublicKey() SigningKey {
+	return SigningKey{
+		KeyID:     s.keyID,
+		Alg:       SignatureAlg,
+		PublicKey: base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey)),
+	}
+}
+
+// Seal — mesaja service_id, issued_at ve expires_at ekleyip imzalar; imzalı alanlar ve
+// envelope'u içeren yeni bir mesaj döndürür. Girdi mesajı değiştirilmez.
+func (s *CommandSigner) Seal(serviceID string, msg map[string]interface{}, now time.Time) (map[string]interface{}, error) {
+	payload := make(map[string]interface{}, len(msg)+3)
+	for k, v := range msg {
+		if k != "envelope" {
+			payload[k] = v
+		}
+	}
+	payload["service_id"] = serviceID
+	payload["issued_at"] = now.Unix()
+	payload["expires_at"] = now.Add(s.ttl).Unix()
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	sealed := make(map[string]interface{}, len(payload)+1)
+	for k, v := range payload {
+		sealed[k] = v
+	}
+	sealed["envelope"] = Envelope{
+		Alg:       SignatureAlg,
+		KeyID:     s.keyID,
+		Payload:   base64.StdEncoding.EncodeToString(data),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, data)),
+	}
+	return sealed, nil
+}
+
+// Open — zarfın imzasını doğrular ve imzalı payload'ı döndürür. checkExpiry false ise
+// süresi dolmuş zarflar da kabul edilir (kuyruktan yeniden imzalama için).
+func (s *CommandSigner) Open(raw []byte, now time.Time, checkExpiry bool) (map[string]interface{}, error) {
+	var msg struct {
+		Envelope *Envelope `json:"envelope"`
+	}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, err
+	}
+	env := msg.Envelope
+	if env == nil {
+		return nil, ErrUnsigned
+	}
+	if env.Alg != SignatureAlg || env.KeyID != s.keyID {
+		return nil, ErrBadSignature
+	}
+	data, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return nil, ErrBadSignature
+	}
+	sig, err := base64.StdEncoding.DecodeString(env.Signature)
+	if err != nil || !ed25519.Verify(s.key.Public().(ed25519.PublicKey), data, sig) {
+		return nil, ErrBadSignature
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, ErrBadSignature
+	}
+	if checkExpiry {
+		exp, _ := payload["expires_at"].(float64)
+		if int64(exp) <= now.Unix() {
+			return nil, ErrEnvelopeExpired
+		}
+	}
+	return payload, nil
+}
+
+// replayGuard — bu örnekte iletilmiş imzalı mesaj kimliklerini süreleri dolana kadar tutar.
+type replayGuard struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func newReplayGuard() *replayGuard {
+	return &replayGuard{seen: make(map[string]time.Time)}
+}
+
+// firstSeen — kimlik daha önce görülmediyse kaydeder ve true döner.
+func (g *replayGuard) firstSeen(id string, expiresAt, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for k, exp := range g.seen {
+		if !exp.After(now) {
+			delete(g.seen, k)
+		}
+	}
+	if _, ok := g.seen[id]; ok {
+		return false
+	}
+	g.seen[id] = expiresAt
+	return true
+}
@@ -0,0 +1,149 @@
+package ws
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sealJSON(t *testing.T, s *CommandSigner, serviceID string, msg map[string]interface{}, now time.Time) []byte {
+	t.Helper()
+	sealed, err := s.Seal(serviceID, msg, now)
+	require.NoError(t, err)
+	raw, err := json.Marshal(sealed)
+	require.NoError(t, err)
+	return raw
+}
+
+func TestCommandSigner_SealOpen(t *testing.T) {
+	s, err := GenerateCommandSigner(time.Minute)
+	require.NoError(t, err)
+	now := time.Now()
+
+	cmd := map[string]interface{}{"type": "command", "command_id": "c1", "action": "restart", "timeout_sec": 30}
+	raw := sealJSON(t, s, "svc-1", cmd, now)
+	assert.NotContains(t, cmd, "envelope", "girdi mesajı değiştirilmemeli")
+
+	_, err = ValidateMessage(FromBackend, raw)
+	assert.NoError(t, err)
+
+	payload, err := s.Open(raw, now, true)
+	require.NoError(t, err)
+	assert.Equal(t, "svc-1", payload["service_id"])
+	assert.Equal(t, "c1", payload["command_id"])
+	assert.Equal(t, float64(now.Unix()), payload["issued_at"])
+	assert.Equal(t, float64(now.Add(time.Minute).Unix()), payload["expires_at"])
+
+	// Açık anahtar, ayrı bir doğrulayıcının (agent) imzayı kontrol etmesine yeter.
+	var msg struct {
+		Envelope Envelope `json:"envelope"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &msg))
+	pub, err := base64.StdEncoding.DecodeString(s.PublicKey().PublicKey)
+	require.NoError(t, err)
+	data, _ := base64.StdEncoding.DecodeString(msg.Envelope.Payload)
+	sig, _ := base64.StdEncoding.DecodeString(msg.Envelope.Signature)
+	assert.True(t, ed25519.Verify(pub, data, sig))
+	assert.Equal(t, s.PublicKey().KeyID, msg.Envelope.KeyID)
+}
+
+func TestCommandSigner_RejectsTampering(t *testing.T) {
+	s, err := GenerateCommandSigner(time.Minute)
+	require.NoError(t, err)
+	now := time.Now()
+	raw := sealJSON(t, s, "svc-1", map[string]interface{}{"type": "command", "command_id": "c1", "action": "ping"}, now)
+
+	var msg map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &msg))
+	env := msg["envelope"].(map[string]interface{})
+	env["payload"] = base64.StdEncoding.EncodeToString([]byte(`{"type":"command","command_id":"c1","action":"exec","service_id":"svc-1","expires_at":9999999999}`))
+	tampered, _ := json.Marshal(msg)
+	_, err = s.Open(tampered, now, true)
+	assert.ErrorIs(t, err, ErrBadSignature)
+
+	other, err := GenerateCommandSigner(time.Minute)
+	require.NoError(t, err)
+	_, err = other.Open(raw, now, true)
+	assert.ErrorIs(t, err, ErrBadSignature)
+
+	_, err = s.Open([]byte(`{"type":"command","command_id":"c1","action":"ping"}`), now, true)
+	assert.ErrorIs(t, err, ErrUnsigned)
+}
+
+func TestCommandSigner_Expiry(t *testing.T) {
+	s, err := GenerateCommandSigner(time.Minute)
+	require.NoError(t, err)
+	issued := time.Now().Add(-2 * time.Minute)
+	raw := sealJSON(t, s, "svc-1", map[string]interface{}{"type": "cancel", "command_id": "c1"}, issued)
+
+	_, err = s.Open(raw, time.Now(), true)
+	assert.ErrorIs(t, err, ErrEnvelopeExpired)
+
+	_, err = s.Open(raw, time.Now(), false)
+	assert.NoError(t, err)
+}
+
+func TestNewCommandSigner_KeyFormats(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	fromSeed, err := NewCommandSigner(base64.StdEncoding.EncodeToString(seed), 0)
+	require.NoError(t, err)
+	fromKey, err := NewCommandSigner(base64.StdEncoding.EncodeToString(ed25519.NewKeyFromSeed(seed)), 0)
+	require.NoError(t, err)
+	assert.Equal(t, fromSeed.PublicKey(), fromKey.PublicKey())
+	assert.Equal(t, DefaultCommandTTL, fromSeed.ttl)
+
+	_, err = NewCommandSigner(base64.StdEncoding.EncodeToString([]byte("kısa")), 0)
+	assert.Error(t, err)
+	_, err = NewCommandSigner("%%%", 0)
+	assert.Error(t, err)
+}
+
+func TestHub_VerifyRemote(t *testing.T) {
+	s, err := GenerateCommandSigner(time.Minute)
+	require.NoError(t, err)
+	h := NewHub(10)
+	h.SetCommandSigner(s)
+
+	raw := sealJSON(t, s, "svc-1", map[string]interface{}{"type": "command", "command_id": "c1", "action": "ping"}, time.Now())
+	assert.False(t, h.verifyRemote("svc-2", raw), "başka servisin kanalına yayınlanan komut reddedilmeli")
+	assert.True(t, h.verifyRemote("svc-1", raw))
+	assert.False(t, h.verifyRemote("svc-1", raw), "aynı komut ikinci kez iletilmemeli")
+
+	// Aynı kimlikli iptal mesajı komutun tekrarı sayılmaz.
+	cancel := sealJSON(t, s, "svc-1", map[string]interface{}{"type": "cancel", "command_id": "c1"}, time.Now())
+	assert.True(t, h.verifyRemote("svc-1", cancel))
+
+	// Yerelde doğrudan iletilen komut Redis üzerinden tekrar iletilmez.
+	local := map[string]interface{}{"type": "command", "command_id": "c2", "action": "ping"}
+	h.trackDelivered(local)
+	assert.False(t, h.verifyRemote("svc-1", sealJSON(t, s, "svc-1", local, time.Now())))
+
+	assert.False(t, h.verifyRemote("svc-1", []byte(`{"type":"command","command_id":"c3","action":"ping"}`)))
+}
+
+func TestHub_ResealQueued(t *testing.T) {
+	s, err := GenerateCommandSigner(time.Minute)
+	require.NoError(t, err)
+	h := NewHub(10)
+	h.SetCommandSigner(s)
+
+	old := sealJSON(t, s, "svc-1", map[string]interface{}{"type": "command", "command_id": "c1", "action": "ping"}, time.Now().Add(-time.Hour))
+	fresh, ok := h.resealQueued("svc-1", old)
+	require.True(t, ok)
+	payload, err := s.Open(fresh, time.Now(), true)
+	require.NoError(t, err)
+	assert.Equal(t, "c1", payload["command_id"])
+
+	_, ok = h.resealQueued("svc-2", old)
+	assert.False(t, ok)
+	_, ok = h.resealQueued("svc-1", []byte(`{"type":"command","command_id":"c1","action":"exec"}`))
+	assert.False(t, ok)
+}
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_command_logs_source;
+
+ALTER TABLE command_logs
+    DROP COLUMN IF EXISTS source;
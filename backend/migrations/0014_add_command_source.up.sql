@@ -0,0 +1,4 @@
+ALTER TABLE command_logs
+    ADD COLUMN IF NOT EXISTS source VARCHAR(30) NOT NULL DEFAULT 'user';
+
+CREATE INDEX IF NOT EXISTS idx_command_logs_source ON command_logs(service_id, source, queued_at DESC);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_command_logs_parent_id;
+
+ALTER TABLE command_logs
+    DROP COLUMN IF EXISTS parent_id;
+
+DROP TABLE IF EXISTS runbook_executions;
+DROP TABLE IF EXISTS runbooks;
@@ -0,0 +1,48 @@
+CREATE TABLE IF NOT EXISTS runbooks (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id          UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    user_id             UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name                VARCHAR(100) NOT NULL,
+    description         TEXT,
+    trigger_type        VARCHAR(20) NOT NULL DEFAULT 'manual'
+                        CHECK (trigger_type IN ('manual','alert','auto_recovery')),
+    trigger_alert_type  VARCHAR(50),
+    steps               JSONB NOT NULL,
+    rollback_steps      JSONB,
+    enabled             BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_runbooks_service_id ON runbooks(service_id);
+CREATE INDEX IF NOT EXISTS idx_runbooks_trigger    ON runbooks(service_id, trigger_type, trigger_alert_type)
+    WHERE enabled;
+
+CREATE TABLE IF NOT EXISTS runbook_executions (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    runbook_id      UUID NOT NULL REFERENCES runbooks(id) ON DELETE CASCADE,
+    service_id      UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    user_id         UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    triggered_by    VARCHAR(20) NOT NULL
+                    CHECK (triggered_by IN ('manual','alert','auto_recovery')),
+    trigger_ref     VARCHAR(100),
+    status          VARCHAR(20) NOT NULL DEFAULT 'running'
+                    CHECK (status IN ('running','success','failed','rolled_back','rollback_failed')),
+    current_step    INTEGER NOT NULL DEFAULT 0,
+    total_steps     INTEGER NOT NULL,
+    step_results    JSONB,
+    error           TEXT,
+    started_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_runbook_executions_runbook_id ON runbook_executions(runbook_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_runbook_executions_running    ON runbook_executions(service_id)
+    WHERE status = 'running';
+
+-- Runbook, toplu komut gibi üst işlemlerin alt komutlarını ilişkilendirir.
+ALTER TABLE command_logs
+    ADD COLUMN IF NOT EXISTS parent_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_command_logs_parent_id ON command_logs(parent_id)
+    WHERE parent_id IS NOT NULL;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS command_schedules (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id       UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    user_id          UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name             VARCHAR(100) NOT NULL,
+    cron_expr        VARCHAR(100) NOT NULL,
+    timezone         VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    action           VARCHAR(20) NOT NULL
+                     CHECK (action IN ('restart','stop','ping','exec','start','scale')),
+    params           JSONB,
+    enabled          BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at      TIMESTAMPTZ,
+    last_run_at      TIMESTAMPTZ,
+    last_status      VARCHAR(30),
+    last_command_id  VARCHAR(100),
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_command_schedules_service_id ON command_schedules(service_id);
+CREATE INDEX IF NOT EXISTS idx_command_schedules_due        ON command_schedules(next_run_at)
+    WHERE enabled;
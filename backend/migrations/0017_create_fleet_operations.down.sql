@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS fleet_operations;
+
+DROP INDEX IF EXISTS idx_services_tags;
+
+ALTER TABLE services
+    DROP COLUMN IF EXISTS tags;
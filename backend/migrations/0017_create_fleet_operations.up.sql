@@ -0,0 +1,32 @@
+-- Servisleri toplu komutlarda hedeflemek için etiketler.
+ALTER TABLE services
+    ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_services_tags ON services USING GIN (tags);
+
+CREATE TABLE IF NOT EXISTS fleet_operations (
+    id                   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id              UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    action               VARCHAR(20) NOT NULL
+                         CHECK (action IN ('restart','stop','ping','exec','start','scale')),
+    params               JSONB,
+    target_tag           VARCHAR(50),
+    batch_size           INTEGER NOT NULL,
+    wait_healthy         BOOLEAN NOT NULL DEFAULT TRUE,
+    health_timeout_sec   INTEGER NOT NULL,
+    command_timeout_sec  INTEGER NOT NULL,
+    failure_threshold    INTEGER NOT NULL DEFAULT 0,
+    status               VARCHAR(20) NOT NULL DEFAULT 'running'
+                         CHECK (status IN ('running','success','partial','failed')),
+    total_services       INTEGER NOT NULL,
+    succeeded            INTEGER NOT NULL DEFAULT 0,
+    failed               INTEGER NOT NULL DEFAULT 0,
+    current_batch        INTEGER NOT NULL DEFAULT 0,
+    total_batches        INTEGER NOT NULL,
+    results              JSONB,
+    error                TEXT,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at         TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_fleet_operations_user_id ON fleet_operations(user_id, created_at DESC);
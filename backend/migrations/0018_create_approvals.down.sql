@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS approval_requests;
+DROP TABLE IF EXISTS approval_policies;
@@ -0,0 +1,42 @@
+-- İki kişili onay: politika tanımlı aksiyonlar onaylanana kadar bekletilir.
+-- service_id NULL olan politikalar Kubernetes aksiyonları içindir (cluster geneli).
+CREATE TABLE IF NOT EXISTS approval_policies (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    service_id    UUID REFERENCES services(id) ON DELETE CASCADE,
+    action        VARCHAR(30) NOT NULL
+                  CHECK (action IN ('stop','scale','k8s_delete_pod','k8s_undeploy')),
+    approver_ids  UUID[] NOT NULL,
+    ttl_sec       INTEGER NOT NULL DEFAULT 3600,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_approval_policies_service_action
+    ON approval_policies(service_id, action) WHERE service_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_approval_policies_cluster_action
+    ON approval_policies(action) WHERE service_id IS NULL;
+
+CREATE TABLE IF NOT EXISTS approval_requests (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    policy_id      UUID REFERENCES approval_policies(id) ON DELETE SET NULL,
+    service_id     UUID REFERENCES services(id) ON DELETE CASCADE,
+    action         VARCHAR(30) NOT NULL,
+    target         VARCHAR(255),
+    params         JSONB,
+    requested_by   UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    approver_ids   UUID[] NOT NULL,
+    status         VARCHAR(20) NOT NULL DEFAULT 'pending'
+                   CHECK (status IN ('pending','approved','rejected','expired','executed','failed')),
+    decided_by     UUID REFERENCES users(id) ON DELETE SET NULL,
+    decision_note  TEXT,
+    command_id     VARCHAR(36),
+    error          TEXT,
+    expires_at     TIMESTAMPTZ NOT NULL,
+    decided_at     TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_approval_requests_requested_by ON approval_requests(requested_by, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_approval_requests_approvers ON approval_requests USING GIN (approver_ids);
+CREATE INDEX IF NOT EXISTS idx_approval_requests_pending ON approval_requests(expires_at) WHERE status = 'pending';
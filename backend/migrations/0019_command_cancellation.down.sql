@@ -0,0 +1,12 @@
+ALTER TABLE command_logs
+    DROP COLUMN IF EXISTS cancelled_at,
+    DROP COLUMN IF EXISTS cancelled_by;
+
+UPDATE command_logs SET status = 'failed' WHERE status = 'cancelled';
+
+ALTER TABLE command_logs
+    DROP CONSTRAINT IF EXISTS command_logs_status_check;
+
+ALTER TABLE command_logs
+    ADD CONSTRAINT command_logs_status_check
+    CHECK (status IN ('queued','received','success','failed','timeout'));
@@ -0,0 +1,10 @@
+ALTER TABLE command_logs
+    DROP CONSTRAINT IF EXISTS command_logs_status_check;
+
+ALTER TABLE command_logs
+    ADD CONSTRAINT command_logs_status_check
+    CHECK (status IN ('queued','received','success','failed','timeout','cancelled'));
+
+ALTER TABLE command_logs
+    ADD COLUMN IF NOT EXISTS cancelled_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMPTZ;
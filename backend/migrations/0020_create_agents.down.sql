@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS agent_connections;
+DROP TABLE IF EXISTS agents;
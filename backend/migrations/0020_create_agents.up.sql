@@ -0,0 +1,31 @@
+-- Kalıcı agent kaydı: agent bağlantısı kapandıktan sonra da sürüm/host bilgisi tutulur.
+CREATE TABLE IF NOT EXISTS agents (
+    id                    UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id            UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    agent_id              VARCHAR(100) NOT NULL,
+    version               VARCHAR(50),
+    hostname              VARCHAR(255),
+    os                    VARCHAR(100),
+    ips                   TEXT[] NOT NULL DEFAULT '{}',
+    capabilities          TEXT[] NOT NULL DEFAULT '{}',
+    remote_ip             VARCHAR(45),
+    first_seen_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_connected_at     TIMESTAMPTZ,
+    last_disconnected_at  TIMESTAMPTZ,
+    UNIQUE (service_id, agent_id)
+);
+
+CREATE TABLE IF NOT EXISTS agent_connections (
+    id               UUID PRIMARY KEY,
+    service_id       UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    agent_id         VARCHAR(100) NOT NULL,
+    remote_ip        VARCHAR(45),
+    connected_at     TIMESTAMPTZ NOT NULL,
+    disconnected_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_connections_agent
+    ON agent_connections(service_id, agent_id, connected_at DESC);
+CREATE INDEX IF NOT EXISTS idx_agent_connections_open
+    ON agent_connections(connected_at) WHERE disconnected_at IS NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE agent_connections DROP COLUMN IF EXISTS credential_id;
+DROP TABLE IF EXISTS agent_credentials;
@@ -0,0 +1,21 @@
+-- Servise bağlı agent kimlik bilgileri: token yalnızca SHA-256 özetiyle saklanır.
+CREATE TABLE IF NOT EXISTS agent_credentials (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id    UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    name          VARCHAR(100) NOT NULL,
+    token_prefix  VARCHAR(16) NOT NULL,
+    token_hash    VARCHAR(64) NOT NULL UNIQUE,
+    created_by    UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at    TIMESTAMPTZ,
+    last_used_at  TIMESTAMPTZ,
+    revoked_at    TIMESTAMPTZ,
+    revoked_by    UUID REFERENCES users(id) ON DELETE SET NULL,
+    rotated_to    UUID REFERENCES agent_credentials(id) ON DELETE SET NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_credentials_service
+    ON agent_credentials(service_id, created_at DESC);
+
+ALTER TABLE agent_connections
+    ADD COLUMN IF NOT EXISTS credential_id UUID REFERENCES agent_credentials(id) ON DELETE SET NULL;
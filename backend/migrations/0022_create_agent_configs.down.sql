@@ -0,0 +1,6 @@
+ALTER TABLE agents
+    DROP COLUMN IF EXISTS config_acked_at,
+    DROP COLUMN IF EXISTS config_error,
+    DROP COLUMN IF EXISTS config_status,
+    DROP COLUMN IF EXISTS config_version;
+DROP TABLE IF EXISTS agent_configs;
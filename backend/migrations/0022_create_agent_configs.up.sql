@@ -0,0 +1,17 @@
+-- Servis bazında merkezi agent yapılandırması. Toplama aralığı services.poll_interval_sec'ten
+-- okunur; her değişiklikte version artırılır ve bağlı agent'lara config mesajı gönderilir.
+CREATE TABLE IF NOT EXISTS agent_configs (
+    service_id  UUID PRIMARY KEY REFERENCES services(id) ON DELETE CASCADE,
+    version     INTEGER NOT NULL DEFAULT 1 CHECK (version >= 1),
+    collectors  TEXT[] NOT NULL DEFAULT '{system,process,app_metrics}',
+    log_paths   TEXT[] NOT NULL DEFAULT '{}',
+    updated_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Agent'ın bildirdiği son yapılandırma durumu (config_ack).
+ALTER TABLE agents
+    ADD COLUMN IF NOT EXISTS config_version   INTEGER,
+    ADD COLUMN IF NOT EXISTS config_status    VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS config_error     TEXT,
+    ADD COLUMN IF NOT EXISTS config_acked_at  TIMESTAMPTZ;
@@ -0,0 +1,18 @@
+-- Agent'ların gönderdiği log satırları. Ham log hacmi metriklerden çok daha büyük olduğu için
+-- saklama süresi kısa tutulur.
+CREATE TABLE IF NOT EXISTS service_logs (
+    time        TIMESTAMPTZ NOT NULL,
+    service_id  UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    agent_id    VARCHAR(100) NOT NULL,
+    stream      VARCHAR(10) NOT NULL CHECK (stream IN ('stdout','stderr','file')),
+    level       VARCHAR(10) NOT NULL CHECK (level IN ('trace','debug','info','warn','error','fatal','unknown')),
+    message     TEXT NOT NULL,
+    path        TEXT
+);
+
+SELECT create_hypertable('service_logs', 'time', if_not_exists => TRUE);
+
+CREATE INDEX IF NOT EXISTS idx_service_logs_service_time ON service_logs (service_id, time DESC);
+CREATE INDEX IF NOT EXISTS idx_service_logs_service_level_time ON service_logs (service_id, level, time DESC);
+
+SELECT add_retention_policy('service_logs', INTERVAL '14 days', if_not_exists => TRUE);
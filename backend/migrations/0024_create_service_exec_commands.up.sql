@@ -0,0 +1,19 @@
+-- Servis bazında exec komut kataloğu. Yerleşik diagnostikler (status, logs, df-h …) kodda
+-- tanımlıdır; bu tablo servise özgü ek komutları tutar. Komutun kendisi agent tarafında
+-- tanımlanır — backend yalnızca adı ve doğrulanmış parametreleri gönderir.
+CREATE TABLE IF NOT EXISTS service_exec_commands (
+    id                   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id           UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    name                 VARCHAR(50) NOT NULL CHECK (name ~ '^[a-z][a-z0-9-]{1,49}$'),
+    description          VARCHAR(500) NOT NULL DEFAULT '',
+    params               JSONB NOT NULL DEFAULT '[]',
+    default_timeout_sec  INTEGER NOT NULL DEFAULT 30 CHECK (default_timeout_sec BETWEEN 1 AND 300),
+    max_timeout_sec      INTEGER NOT NULL DEFAULT 300 CHECK (max_timeout_sec BETWEEN 1 AND 300),
+    required_role        VARCHAR(20) NOT NULL DEFAULT 'operator' CHECK (required_role IN ('viewer','operator','admin')),
+    enabled              BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by           UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (service_id, name),
+    CHECK (default_timeout_sec <= max_timeout_sec)
+);
@@ -0,0 +1,17 @@
+-- Agent ile backend arasındaki WebSocket bağlantısının kalite örnekleri. Registry her dakika
+-- bağlı agent'lar için bir satır yazar; agent ağ sorunları servis metriklerinden ayrı izlenir.
+CREATE TABLE IF NOT EXISTS agent_link_metrics (
+    time        TIMESTAMPTZ NOT NULL,
+    service_id  UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    agent_id    VARCHAR(100) NOT NULL,
+    rtt_ms      REAL,
+    rtt_avg_ms  REAL,
+    reconnects  INTEGER NOT NULL DEFAULT 0,
+    send_drops  BIGINT NOT NULL DEFAULT 0
+);
+
+SELECT create_hypertable('agent_link_metrics', 'time', if_not_exists => TRUE);
+
+CREATE INDEX IF NOT EXISTS idx_agent_link_metrics_service_agent_time ON agent_link_metrics (service_id, agent_id, time DESC);
+
+SELECT add_retention_policy('agent_link_metrics', INTERVAL '30 days', if_not_exists => TRUE);
@@ -0,0 +1,7 @@
+DROP VIEW IF EXISTS service_access;
+DROP TABLE IF EXISTS service_teams;
+ALTER TABLE services DROP COLUMN IF EXISTS org_id;
+DROP TABLE IF EXISTS team_members;
+DROP TABLE IF EXISTS teams;
+DROP TABLE IF EXISTS org_members;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,82 @@
+-- Organizasyonlar, üyelikler ve takımlar. Servisler bir organizasyona aittir; kullanıcının
+-- servis üzerindeki rolü organizasyon üyeliğinden ve servise atanmış takımlardan gelir.
+CREATE TABLE IF NOT EXISTS organizations (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        VARCHAR(100) NOT NULL,
+    slug        VARCHAR(60) NOT NULL UNIQUE CHECK (slug ~ '^[a-z0-9][a-z0-9-]{1,59}$'),
+    personal    BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS org_members (
+    org_id      UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role        VARCHAR(20) NOT NULL CHECK (role IN ('viewer','operator','admin','owner')),
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (org_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_org_members_user ON org_members (user_id);
+
+-- Takım rolü, takıma atanmış servislerde üyelerin organizasyon rolünü yükseltir (düşürmez).
+CREATE TABLE IF NOT EXISTS teams (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id      UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name        VARCHAR(100) NOT NULL,
+    role        VARCHAR(20) NOT NULL DEFAULT 'operator' CHECK (role IN ('viewer','operator','admin')),
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (org_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS team_members (
+    team_id     UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (team_id, user_id)
+);
+
+ALTER TABLE services ADD COLUMN IF NOT EXISTS org_id UUID REFERENCES organizations(id) ON DELETE RESTRICT;
+
+CREATE TABLE IF NOT EXISTS service_teams (
+    service_id  UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    team_id     UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    PRIMARY KEY (service_id, team_id)
+);
+
+-- Mevcut kullanıcılar için kişisel organizasyon; servisleri bu organizasyona taşınır.
+INSERT INTO organizations (id, name, slug, personal, created_by, created_at)
+SELECT gen_random_uuid(), split_part(u.email, '@', 1), 'u-' || replace(u.id::text, '-', ''), TRUE, u.id, u.created_at
+FROM users u
+WHERE NOT EXISTS (SELECT 1 FROM organizations o WHERE o.personal AND o.created_by = u.id);
+
+INSERT INTO org_members (org_id, user_id, role)
+SELECT o.id, o.created_by, 'owner'
+FROM organizations o
+WHERE o.personal AND o.created_by IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+UPDATE services s
+SET org_id = o.id
+FROM organizations o
+WHERE s.org_id IS NULL AND o.personal AND o.created_by = s.user_id;
+
+ALTER TABLE services ALTER COLUMN org_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_services_org ON services (org_id);
+
+-- service_access — kullanıcının servis üzerindeki tüm rol kaynakları. Yetki denetimi en yüksek
+-- role_rank değerini kullanır (viewer=1, operator=2, admin=3, owner=4). Takım rolü yalnızca
+-- organizasyon üyeliği devam eden kullanıcılara uygulanır.
+CREATE OR REPLACE VIEW service_access AS
+SELECT s.id AS service_id, m.user_id, m.role,
+       CASE m.role WHEN 'viewer' THEN 1 WHEN 'operator' THEN 2 WHEN 'admin' THEN 3 WHEN 'owner' THEN 4 END AS role_rank
+FROM services s
+JOIN org_members m ON m.org_id = s.org_id
+UNION ALL
+SELECT st.service_id, tm.user_id, t.role,
+       CASE t.role WHEN 'viewer' THEN 1 WHEN 'operator' THEN 2 WHEN 'admin' THEN 3 END AS role_rank
+FROM service_teams st
+JOIN teams t ON t.id = st.team_id
+JOIN team_members tm ON tm.team_id = t.id
+JOIN org_members m ON m.org_id = t.org_id AND m.user_id = tm.user_id;
@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS api_key_hash VARCHAR(60);
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,18 @@
+-- Kullanıcıya ait, kapsamlı (scope) API anahtarları. Hiç kullanılmayan users.api_key_hash
+-- kolonunun yerini alır; kullanıcı birden fazla isimli anahtar oluşturabilir.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id      UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name         VARCHAR(100) NOT NULL,
+    prefix       VARCHAR(16) NOT NULL,
+    key_hash     VARCHAR(64) NOT NULL UNIQUE,
+    scopes       TEXT[] NOT NULL,
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys(user_id, created_at DESC);
+
+ALTER TABLE users DROP COLUMN IF EXISTS api_key_hash;
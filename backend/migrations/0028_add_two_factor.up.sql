@@ -0,0 +1,32 @@
+-- TOTP ile iki adımlı doğrulama. totp_secret şifrelenmiş saklanır; totp_enabled_at NULL iken
+-- kurulum tamamlanmamıştır. totp_last_step aynı kodun ikinci kez kullanılmasını engeller.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS totp_secret     TEXT,
+    ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS totp_last_step  BIGINT NOT NULL DEFAULT 0;
+
+-- Tek kullanımlık kurtarma kodları (SHA-256 özeti).
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash  VARCHAR(64) NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user ON recovery_codes(user_id);
+
+-- E-postayla iki adımlı doğrulama sıfırlama bağlantıları (password_reset_tokens ile aynı yapı).
+CREATE TABLE IF NOT EXISTS two_factor_reset_tokens (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tfrt_user_id ON two_factor_reset_tokens(user_id);
+
+-- Organizasyon, operator ve üstü işlemler için iki adımlı doğrulamayı zorunlu tutabilir.
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS require_two_factor BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,20 @@
+-- Oturumlar: her giriş bir oturum (refresh token ailesi) açar. Refresh token her kullanımda
+-- yenilenir; refresh_jti geçerli token'ı, previous_jti bir önceki token'ı tutar. Eski bir token'ın
+-- tekrar kullanılması oturumun tamamını iptal eder.
+CREATE TABLE IF NOT EXISTS sessions (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id        UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    refresh_jti    VARCHAR(64) NOT NULL,
+    previous_jti   VARCHAR(64),
+    rotated_at     TIMESTAMPTZ,
+    device         VARCHAR(100) NOT NULL DEFAULT '',
+    ip             VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent     VARCHAR(512) NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at     TIMESTAMPTZ NOT NULL,
+    revoked_at     TIMESTAMPTZ,
+    revoked_reason VARCHAR(50)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_active ON sessions(user_id) WHERE revoked_at IS NULL;
@@ -0,0 +1,36 @@
+-- E-posta doğrulama. email_verified_at NULL olan hesaplar yalnızca hesap uçlarına erişebilir;
+-- bu sürümden önce açılmış hesaplar doğrulanmış sayılır.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+-- E-posta doğrulama bağlantıları (password_reset_tokens ile aynı yapı).
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_evt_user_id ON email_verification_tokens(user_id);
+
+-- Organizasyon davetleri. Kabul edildiğinde kullanıcı role ile organizasyona, team_ids ile
+-- servislere atanmış takımlara eklenir. Aynı adrese bekleyen en fazla bir davet olur.
+CREATE TABLE IF NOT EXISTS org_invitations (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id      UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    email       VARCHAR(255) NOT NULL,
+    role        VARCHAR(20) NOT NULL CHECK (role IN ('viewer','operator','admin','owner')),
+    team_ids    UUID[] NOT NULL DEFAULT '{}',
+    token_hash  VARCHAR(64) UNIQUE NOT NULL,
+    invited_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    accepted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at  TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_org_invitations_pending
+    ON org_invitations(org_id, email) WHERE accepted_at IS NULL AND revoked_at IS NULL;
@@ -0,0 +1,11 @@
+DROP TRIGGER IF EXISTS trg_audit_logs_append_only ON audit_logs;
+DROP FUNCTION IF EXISTS audit_logs_append_only();
+
+UPDATE audit_logs SET user_id = NULL WHERE user_id IS NOT NULL AND user_id NOT IN (SELECT id FROM users);
+ALTER TABLE audit_logs
+    ADD CONSTRAINT audit_logs_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE audit_logs
+    DROP COLUMN IF EXISTS hash,
+    DROP COLUMN IF EXISTS prev_hash,
+    DROP COLUMN IF EXISTS seq;
@@ -0,0 +1,22 @@
+-- Audit kayıtları hash zinciriyle bağlanır: her kayıt bir öncekinin özetini içerir.
+-- Zincirden önce yazılmış kayıtların seq değeri NULL kalır.
+ALTER TABLE audit_logs
+    ADD COLUMN IF NOT EXISTS seq       BIGINT UNIQUE,
+    ADD COLUMN IF NOT EXISTS prev_hash VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS hash      VARCHAR(64);
+
+-- Kullanıcı silindiğinde ON DELETE SET NULL özeti alınmış kaydı değiştirirdi; kullanıcı ID'si
+-- kayıtta olduğu gibi kalır.
+ALTER TABLE audit_logs DROP CONSTRAINT IF EXISTS audit_logs_user_id_fkey;
+
+-- Audit log yalnızca eklenebilir; değişiklik ve silme veritabanı seviyesinde reddedilir.
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs yalnızca eklenebilir (% reddedildi)', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_audit_logs_append_only ON audit_logs;
+CREATE TRIGGER trg_audit_logs_append_only
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
@@ -0,0 +1,15 @@
+-- OIDC kimlik bağları: hesaplar kimlik sağlayıcısındaki değişmez (issuer, subject) çiftiyle
+-- eşleştirilir. E-posta yalnızca ilk bağlamada ve sağlayıcı adresi doğrulamışsa kullanılır.
+-- Bu sürümden önce SSO ile giriş yapmış hesaplar bir sonraki girişte bu kurala göre bağlanır.
+CREATE TABLE IF NOT EXISTS oidc_identities (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer        VARCHAR(255) NOT NULL,
+    subject       VARCHAR(255) NOT NULL,
+    email         VARCHAR(255) NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_oidc_identities_user_id ON oidc_identities(user_id);
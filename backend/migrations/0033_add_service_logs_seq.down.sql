@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_service_logs_service_time_seq;
+ALTER TABLE service_logs DROP COLUMN IF EXISTS seq;
+DROP SEQUENCE IF EXISTS service_logs_seq;
@@ -0,0 +1,7 @@
+-- Log aramasında sayfalama imleci (time, seq) çiftidir; aynı zaman damgasını paylaşan satırlar
+-- sayfa sınırında seq ile ayrılır ve hiçbiri atlanmaz.
+CREATE SEQUENCE IF NOT EXISTS service_logs_seq;
+
+ALTER TABLE service_logs ADD COLUMN IF NOT EXISTS seq BIGINT NOT NULL DEFAULT nextval('service_logs_seq');
+
+CREATE INDEX IF NOT EXISTS idx_service_logs_service_time_seq ON service_logs (service_id, time DESC, seq DESC);
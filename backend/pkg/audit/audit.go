@@ -3,6 +3,7 @@ package audit
 import (
 	"context"
 	"encoding/json"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,15 +13,92 @@ import (
 type Action string
 
 const (
-	ActionLogin           Action = "auth.login"
-	ActionLoginFailed     Action = "auth.login_failed"
-	ActionRegister        Action = "auth.register"
-	ActionLogout          Action = "auth.logout"
-	ActionPasswordChanged Action = "auth.password_changed"
-	ActionServiceCreate   Action = "service.create"
-	ActionServiceDelete   Action = "service.delete"
-	ActionCommandExec     Action = "command.exec"
-	ActionAIAnalyze       Action = "ai.analyze"
+	ActionLogin             Action = "auth.login"
+	ActionLoginFailed       Action = "auth.login_failed"
+	ActionRegister          Action = "auth.register"
+	ActionLogout            Action = "auth.logout"
+	ActionPasswordChanged   Action = "auth.password_changed"
+	ActionAPIKeyCreate      Action = "api_key.create"
+	ActionAPIKeyRevoke      Action = "api_key.revoke"
+	ActionServiceCreate     Action = "service.create"
+	ActionServiceDelete     Action = "service.delete"
+	ActionCommandExec       Action = "command.exec"
+	ActionAIAnalyze         Action = "ai.analyze"
+	ActionAutoRecovery      Action = "recovery.restart"
+	ActionRunbookRun        Action = "runbook.run"
+	ActionFleetCommand      Action = "fleet.command"
+	ActionApprovalRequested Action = "approval.requested"
+	ActionApprovalApproved  Action = "approval.approved"
+	ActionApprovalRejected  Action = "approval.rejected"
+	ActionApprovalExpired   Action = "approval.expired"
+
+	ActionAgentCredentialCreate Action = "agent_credential.create"
+	ActionAgentCredentialRotate Action = "agent_credential.rotate"
+	ActionAgentCredentialRevoke Action = "agent_credential.revoke"
+	ActionAgentConfigUpdate     Action = "agent_config.update"
+	ActionExecCommandCreate     Action = "exec_command.create"
+	ActionExecCommandUpdate     Action = "exec_command.update"
+	ActionExecCommandDelete     Action = "exec_command.delete"
+
+	ActionOrgCreate       Action = "org.create"
+	ActionOrgUpdate       Action = "org.update"
+	ActionOrgDelete       Action = "org.delete"
+	ActionOrgMemberAdd    Action = "org.member_add"
+	ActionOrgMemberUpdate Action = "org.member_update"
+	ActionOrgMemberRemove Action = "org.member_remove"
+	ActionTeamCreate      Action = "team.create"
+	ActionTeamUpdate      Action = "team.update"
+	ActionTeamDelete      Action = "team.delete"
+
+	ActionTwoFactorEnabled       Action = "auth.2fa_enabled"
+	ActionTwoFactorDisabled      Action = "auth.2fa_disabled"
+	ActionTwoFactorReset         Action = "auth.2fa_reset"
+	ActionTwoFactorRecoveryCodes Action = "auth.2fa_recovery_codes"
+
+	ActionSessionRevoke     Action = "session.revoke"
+	ActionSessionRevokeAll  Action = "session.revoke_all"
+	ActionRefreshTokenReuse Action = "session.refresh_reuse"
+
+	ActionAccountLocked   Action = "auth.account_locked"
+	ActionAccountUnlocked Action = "auth.account_unlocked"
+
+	ActionEmailVerified     Action = "auth.email_verified"
+	ActionOrgInvite         Action = "org.invite"
+	ActionOrgInviteRevoke   Action = "org.invite_revoke"
+	ActionOrgInviteAccepted Action = "org.invite_accepted"
+
+	ActionPasswordReset   Action = "auth.password_reset"
+	ActionAgentTokenIssue Action = "auth.agent_token"
+
+	ActionServiceUpdate        Action = "service.update"
+	ActionServiceRestart       Action = "service.restart"
+	ActionServiceStop          Action = "service.stop"
+	ActionServiceStart         Action = "service.start"
+	ActionServiceScale         Action = "service.scale"
+	ActionCommandCancel        Action = "command.cancel"
+	ActionAlertRulesUpdate     Action = "alert_rules.update"
+	ActionAlertResolve         Action = "alert.resolve"
+	ActionMaintenanceCreate    Action = "maintenance.create"
+	ActionMaintenanceDelete    Action = "maintenance.delete"
+	ActionSettingsUpdate       Action = "settings.update"
+	ActionRunbookCreate        Action = "runbook.create"
+	ActionRunbookUpdate        Action = "runbook.update"
+	ActionRunbookDelete        Action = "runbook.delete"
+	ActionScheduleCreate       Action = "schedule.create"
+	ActionScheduleUpdate       Action = "schedule.update"
+	ActionScheduleDelete       Action = "schedule.delete"
+	ActionApprovalPolicySet    Action = "approval_policy.set"
+	ActionApprovalPolicyDelete Action = "approval_policy.delete"
+
+	ActionK8sDeploy    Action = "k8s.deploy"
+	ActionK8sUndeploy  Action = "k8s.undeploy"
+	ActionK8sScale     Action = "k8s.scale"
+	ActionK8sRestart   Action = "k8s.restart"
+	ActionK8sPodDelete Action = "k8s.pod_delete"
+	ActionK8sHPAUpdate Action = "k8s.hpa_update"
+	ActionK8sHPADelete Action = "k8s.hpa_delete"
+
+	ActionAuditExport Action = "audit.export"
 )
 
 type Status string
@@ -42,6 +120,11 @@ type Log struct {
 	Status       Status     `gorm:"type:varchar(20);not null"`
 	Details      []byte     `gorm:"type:jsonb"`
 	CreatedAt    time.Time  `gorm:"not null;default:now()"`
+
+	// Seq, PrevHash, Hash — hash zinciri; her kayıt bir öncekinin özetini içerir (bkz. chain.go).
+	Seq      *int64 `gorm:"unique"`
+	PrevHash string `gorm:"type:varchar(64)"`
+	Hash     string `gorm:"type:varchar(64)"`
 }
 
 func (Log) TableName() string { return "audit_logs" }
@@ -65,13 +148,15 @@ type Entry struct {
 	Details      map[string]any
 }
 
-func (l *Logger) Record(ctx context.Context, e Entry) {
+// newRecord — girişten zincire eklenecek kaydı üretir.
+func newRecord(e Entry, now time.Time) *Log {
 	var detailsJSON []byte
 	if len(e.Details) > 0 {
 		detailsJSON, _ = json.Marshal(e.Details)
 	}
 
-	record := &Log{
+	return &Log{
+		ID:           uuid.New(),
 		UserID:       e.UserID,
 		Action:       e.Action,
 		ResourceType: e.ResourceType,
@@ -80,13 +165,23 @@ func (l *Logger) Record(ctx context.Context, e Entry) {
 		UserAgent:    e.UserAgent,
 		Status:       e.Status,
 		Details:      detailsJSON,
+		// PostgreSQL mikrosaniye saklar; özet okunan değerle aynı zamandan hesaplanmalı.
+		CreatedAt: now.UTC().Truncate(time.Microsecond),
 	}
+}
+
+func (l *Logger) Record(ctx context.Context, e Entry) {
+	record := newRecord(e, time.Now())
 
 	go func() {
 		dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
-		if err := l.db.WithContext(dbCtx).Create(record).Error; err != nil {
-			_ = err
+		if err := l.append(dbCtx, record); err != nil {
+			log.Printf("[audit] kayıt yazılamadı action=%s: %v", record.Action, err)
+			return
+		}
+		if s := sink.Load(); s != nil {
+			s.publish(record)
 		}
 	}()
 }
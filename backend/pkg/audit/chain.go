@@ -0,0 +1,231 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// chainLockKey — kayıtların zincire sırayla eklenmesi için kullanılan advisory lock anahtarı.
+// Tüm backend örnekleri aynı kilidi alır.
+const chainLockKey = 0x6e6e617564697400
+
+// maxProblems — doğrulama yanıtında listelenecek en fazla sorun.
+const maxProblems = 100
+
+// ChainHead — zincirin son kaydı. Dışarıda saklanan bir baş, sondan silinen kayıtları da
+// tespit etmeyi sağlar.
+type ChainHead struct {
+	Seq  int64  `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// ChainProblem — doğrulamada bulunan tutarsızlık.
+//   - missing: Seq–To aralığındaki kayıtlar silinmiş
+//   - edited: kaydın içeriği özetiyle uyuşmuyor
+//   - relinked: kaydın prev_hash'i bir önceki kaydın özeti değil
+//   - head_mismatch: beklenen baş kayıt yok veya özeti farklı
+type ChainProblem struct {
+	Kind string     `json:"kind"`
+	Seq  int64      `json:"seq"`
+	To   int64      `json:"to,omitempty"`
+	ID   *uuid.UUID `json:"id,omitempty"`
+}
+
+// VerifyResult — zincir doğrulamasının sonucu.
+type VerifyResult struct {
+	Valid    bool           `json:"valid"`
+	Checked  int64          `json:"checked"`
+	Head     *ChainHead     `json:"head,omitempty"`
+	Problems []ChainProblem `json:"problems,omitempty"`
+	// Truncated — sorunlar maxProblems ile sınırlandı.
+	Truncated bool `json:"truncated,omitempty"`
+	// Unchained — zincirden önce yazılmış (seq'siz) kayıt sayısı.
+	Unchained int64 `json:"unchained"`
+}
+
+// append — kaydı zincirin sonuna ekler: advisory lock altında son kaydın sırası ve özeti okunur,
+// yeni kaydın özeti hesaplanır ve aynı transaction'da yazılır.
+func (l *Logger) append(ctx context.Context, record *Log) error {
+	return l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", chainLockKey).Error; err != nil {
+			return err
+		}
+		var head ChainHead
+		if err := tx.Raw("SELECT seq, hash FROM audit_logs WHERE seq IS NOT NULL ORDER BY seq DESC LIMIT 1").
+			Scan(&head).Error; err != nil {
+			return err
+		}
+		seq := head.Seq + 1
+		record.Seq = &seq
+		record.PrevHash = head.Hash
+		record.Hash = record.computeHash()
+		return tx.Create(record).Error
+	})
+}
+
+// computeHash — kaydın alanları ve prev_hash üzerinden SHA-256. Alanlar uzunluk önekiyle
+// birleştirilir; details, veritabanının JSON biçimlendirmesinden etkilenmemek için kanonik
+// biçime çevrilir.
+func (r *Log) computeHash() string {
+	var seq int64
+	if r.Seq != nil {
+		seq = *r.Seq
+	}
+	fields := []string{
+		strconv.FormatInt(seq, 10),
+		r.PrevHash,
+		r.ID.String(),
+		optionalUUID(r.UserID),
+		string(r.Action),
+		r.ResourceType,
+		optionalUUID(r.ResourceID),
+		r.IPAddress,
+		r.UserAgent,
+		string(r.Status),
+		canonicalJSON(r.Details),
+		r.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	h := sha256.New()
+	for _, f := range fields {
+		fmt.Fprintf(h, "%d:%s\n", len(f), f)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func optionalUUID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+// canonicalJSON — JSON'u anahtarları sıralı, boşluksuz biçime çevirir. jsonb anahtar sırasını ve
+// boşlukları değiştirdiğinden özet her zaman bu biçimden hesaplanır.
+func canonicalJSON(raw []byte) string {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return ""
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return string(raw)
+	}
+	return string(out)
+}
+
+// Verify — zinciri fromSeq'ten (0 ise baştan) sona kadar parçalar halinde doğrular. Ortadan
+// silinen kayıtlar sıra boşluğu ve kopan bağ olarak, düzenlenen kayıtlar özet uyuşmazlığı olarak
+// raporlanır. expect verilirse o sıradaki kaydın hâlâ aynı özetle durduğu da denetlenir.
+func (l *Logger) Verify(ctx context.Context, fromSeq int64, expect *ChainHead) (*VerifyResult, error) {
+	db := l.db.WithContext(ctx)
+	res := &VerifyResult{}
+	if err := db.Model(&Log{}).Where("seq IS NULL").Count(&res.Unchained).Error; err != nil {
+		return nil, err
+	}
+
+	if fromSeq < 1 {
+		fromSeq = 1
+	}
+	v := newChainChecker(res, fromSeq)
+	if fromSeq > 1 {
+		var prev Log
+		err := db.Where("seq = ?", fromSeq-1).Take(&prev).Error
+		switch {
+		case err == nil:
+			v.prevHash, v.havePrev = prev.Hash, true
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, err
+		}
+	}
+
+	const batch = 1000
+	for {
+		var rows []Log
+		if err := db.Where("seq >= ?", v.nextSeq).Order("seq").Limit(batch).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for i := range rows {
+			v.check(&rows[i])
+		}
+		if len(rows) < batch {
+			break
+		}
+	}
+
+	if expect != nil {
+		var row Log
+		err := db.Where("seq = ?", expect.Seq).Take(&row).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			v.checkHead(*expect, nil)
+		case err != nil:
+			return nil, err
+		default:
+			v.checkHead(*expect, &row)
+		}
+	}
+
+	res.Valid = len(res.Problems) == 0
+	return res, nil
+}
+
+// chainChecker — sırayla verilen kayıtları bir önceki kaydın özetiyle karşılaştırır.
+type chainChecker struct {
+	res      *VerifyResult
+	nextSeq  int64
+	prevHash string
+	// havePrev — prevHash bilinir; fromSeq'ten önceki kayıt silinmişse ilk bağ denetlenemez.
+	havePrev bool
+}
+
+func newChainChecker(res *VerifyResult, fromSeq int64) *chainChecker {
+	return &chainChecker{res: res, nextSeq: fromSeq, havePrev: fromSeq == 1}
+}
+
+func (v *chainChecker) addProblem(p ChainProblem) {
+	if len(v.res.Problems) >= maxProblems {
+		v.res.Truncated = true
+		return
+	}
+	v.res.Problems = append(v.res.Problems, p)
+}
+
+func (v *chainChecker) check(row *Log) {
+	seq := *row.Seq
+	if seq > v.nextSeq {
+		v.addProblem(ChainProblem{Kind: "missing", Seq: v.nextSeq, To: seq - 1})
+	}
+	if (seq == 1 && row.PrevHash != "") || (v.havePrev && seq > 1 && row.PrevHash != v.prevHash) {
+		v.addProblem(ChainProblem{Kind: "relinked", Seq: seq, ID: &row.ID})
+	}
+	if row.computeHash() != row.Hash {
+		v.addProblem(ChainProblem{Kind: "edited", Seq: seq, ID: &row.ID})
+	}
+	v.res.Checked++
+	v.prevHash, v.havePrev = row.Hash, true
+	v.nextSeq = seq + 1
+	v.res.Head = &ChainHead{Seq: seq, Hash: row.Hash}
+}
+
+// checkHead — dışarıda saklanan başı veritabanındaki kayıtla (yoksa nil) karşılaştırır.
+func (v *chainChecker) checkHead(expect ChainHead, row *Log) {
+	switch {
+	case row == nil:
+		v.addProblem(ChainProblem{Kind: "head_mismatch", Seq: expect.Seq})
+	case row.Hash != expect.Hash:
+		v.addProblem(ChainProblem{Kind: "head_mismatch", Seq: expect.Seq, ID: &row.ID})
+	}
+}
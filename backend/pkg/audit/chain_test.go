@@ -0,0 +1,125 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testChain — birbirine bağlı n kayıtlık zincir üretir.
+func testChain(n int) []Log {
+	rows := make([]Log, n)
+	prev := ""
+	for i := range rows {
+		rec := newRecord(Entry{
+			Action:       ActionServiceRestart,
+			ResourceType: "service",
+			Status:       StatusSuccess,
+			Details:      map[string]any{"attempt": i},
+		}, time.Now())
+		seq := int64(i + 1)
+		rec.Seq = &seq
+		rec.PrevHash = prev
+		rec.Hash = rec.computeHash()
+		prev = rec.Hash
+		rows[i] = *rec
+	}
+	return rows
+}
+
+func verifyRows(rows []Log) *VerifyResult {
+	res := &VerifyResult{}
+	v := newChainChecker(res, 1)
+	for i := range rows {
+		v.check(&rows[i])
+	}
+	res.Valid = len(res.Problems) == 0
+	return res
+}
+
+func TestComputeHash_CanonicalDetails(t *testing.T) {
+	rec := testRecord()
+	rec.Details = []byte(`{"route":"POST /x","nested":{"b":[1,2],"a":"ç"},"status_code":403}`)
+	want := rec.computeHash()
+
+	// jsonb anahtarları yeniden sıralar ve boşluk ekler; özet değişmemeli.
+	rec.Details = []byte(`{"nested": {"a": "ç", "b": [1, 2]}, "route": "POST /x", "status_code": 403}`)
+	assert.Equal(t, want, rec.computeHash())
+
+	rec.Details = []byte(`{"nested": {"a": "ç", "b": [2, 1]}, "route": "POST /x", "status_code": 403}`)
+	assert.NotEqual(t, want, rec.computeHash(), "değer değişikliği özeti değiştirmeli")
+}
+
+func TestNewRecord_TruncatesToMicroseconds(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 123456789, time.FixedZone("TRT", 3*3600))
+	rec := newRecord(Entry{Action: ActionLogin, Status: StatusSuccess}, now)
+	assert.Equal(t, 123456000, rec.CreatedAt.Nanosecond())
+	assert.Equal(t, time.UTC, rec.CreatedAt.Location())
+
+	// Postgres'ten okunan değer mikrosaniyeye yuvarlanmış ve yerel saat diliminde gelir.
+	rec.Hash = rec.computeHash()
+	stored := *rec
+	stored.CreatedAt = rec.CreatedAt.Truncate(time.Microsecond).In(time.Local)
+	assert.Equal(t, rec.Hash, stored.computeHash())
+
+	raw := *rec
+	raw.CreatedAt = now
+	assert.NotEqual(t, rec.Hash, raw.computeHash(), "yuvarlanmamış zaman okunan kayıtla eşleşmez")
+}
+
+func TestChainChecker(t *testing.T) {
+	res := verifyRows(testChain(5))
+	assert.True(t, res.Valid)
+	assert.Equal(t, int64(5), res.Checked)
+	require.NotNil(t, res.Head)
+	assert.Equal(t, int64(5), res.Head.Seq)
+
+	t.Run("missing", func(t *testing.T) {
+		rows := testChain(5)
+		res := verifyRows(append(rows[:2:2], rows[3:]...))
+		require.Len(t, res.Problems, 2)
+		assert.Equal(t, ChainProblem{Kind: "missing", Seq: 3, To: 3}, res.Problems[0])
+		assert.Equal(t, "relinked", res.Problems[1].Kind)
+		assert.Equal(t, int64(4), res.Problems[1].Seq)
+	})
+
+	t.Run("edited", func(t *testing.T) {
+		rows := testChain(5)
+		rows[1].UserAgent = "değiştirildi"
+		res := verifyRows(rows)
+		require.Len(t, res.Problems, 1)
+		assert.Equal(t, "edited", res.Problems[0].Kind)
+		assert.Equal(t, int64(2), res.Problems[0].Seq)
+		assert.Equal(t, rows[1].ID, *res.Problems[0].ID)
+	})
+
+	t.Run("relinked", func(t *testing.T) {
+		rows := testChain(5)
+		// Özeti yeniden hesaplanmış ama başka bir kayda bağlanmış kayıt.
+		rows[2].PrevHash = rows[0].Hash
+		rows[2].Hash = rows[2].computeHash()
+		res := verifyRows(rows)
+		kinds := make([]string, 0, len(res.Problems))
+		for _, p := range res.Problems {
+			kinds = append(kinds, p.Kind)
+		}
+		assert.Equal(t, []string{"relinked", "relinked"}, kinds, "kayıt 3 ve ona bağlı kayıt 4")
+		assert.Equal(t, int64(3), res.Problems[0].Seq)
+	})
+
+	t.Run("head_mismatch", func(t *testing.T) {
+		rows := testChain(3)
+		res := &VerifyResult{}
+		v := newChainChecker(res, 1)
+		v.checkHead(ChainHead{Seq: 3, Hash: rows[2].Hash}, &rows[2])
+		assert.Empty(t, res.Problems)
+
+		v.checkHead(ChainHead{Seq: 3, Hash: "eski"}, &rows[2])
+		v.checkHead(ChainHead{Seq: 4, Hash: "silinmiş"}, nil)
+		require.Len(t, res.Problems, 2)
+		assert.Equal(t, ChainProblem{Kind: "head_mismatch", Seq: 3, ID: &rows[2].ID}, res.Problems[0])
+		assert.Equal(t, ChainProblem{Kind: "head_mismatch", Seq: 4}, res.Problems[1])
+	})
+}
@@ -0,0 +1,290 @@
+package audit
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"nanonet-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Auditor — kullanıcının tüm kullanıcıların audit kayıtlarını dışa aktarıp aktaramayacağını bildirir.
+type Auditor interface {
+	CanAuditAll(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+// SetAuditor — verilmezse her kullanıcı yalnızca kendi kayıtlarını dışa aktarır ve zincir doğrulaması kapalıdır.
+func (h *Handler) SetAuditor(a Auditor) {
+	h.auditor = a
+}
+
+// ExportFilter — dışa aktarma süzgeci; boş alanlar süzmez.
+type ExportFilter struct {
+	UserID       *uuid.UUID
+	Actions      []string // tam eşleşme; "k8s.*" önek eşleşmesi
+	ResourceType string
+	ResourceID   *uuid.UUID
+	Status       Status
+	From, To     time.Time
+}
+
+// exportRecord — CSV ve JSON Lines satırı.
+type exportRecord struct {
+	ID           uuid.UUID       `json:"id"`
+	Seq          *int64          `json:"seq"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UserID       *uuid.UUID      `json:"user_id"`
+	Action       Action          `json:"action"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   *uuid.UUID      `json:"resource_id"`
+	Status       Status          `json:"status"`
+	IPAddress    string          `json:"ip_address"`
+	UserAgent    string          `json:"user_agent"`
+	Details      json.RawMessage `json:"details"`
+	PrevHash     string          `json:"prev_hash"`
+	Hash         string          `json:"hash"`
+}
+
+var csvHeader = []string{
+	"id", "seq", "created_at", "user_id", "action", "resource_type", "resource_id",
+	"status", "ip_address", "user_agent", "details", "prev_hash", "hash",
+}
+
+func newExportRecord(l *Log) exportRecord {
+	r := exportRecord{
+		ID: l.ID, Seq: l.Seq, CreatedAt: l.CreatedAt.UTC(), UserID: l.UserID, Action: l.Action,
+		ResourceType: l.ResourceType, ResourceID: l.ResourceID, Status: l.Status,
+		IPAddress: l.IPAddress, UserAgent: l.UserAgent, PrevHash: l.PrevHash, Hash: l.Hash,
+	}
+	if len(l.Details) > 0 {
+		r.Details = json.RawMessage(l.Details)
+	}
+	return r
+}
+
+// csvRow — CSV satırı. Hesap tablolarında formül olarak çalıştırılmaması için değerler csvSafe ile
+// yazılır (user agent, kaynak tipi ve details kullanıcıdan gelir).
+func (r exportRecord) csvRow() []string {
+	row := []string{
+		r.ID.String(), seqString(r.Seq), r.CreatedAt.Format(time.RFC3339Nano), optionalUUID(r.UserID),
+		string(r.Action), r.ResourceType, optionalUUID(r.ResourceID), string(r.Status),
+		r.IPAddress, r.UserAgent, string(r.Details), r.PrevHash, r.Hash,
+	}
+	for i, v := range row {
+		row[i] = csvSafe(v)
+	}
+	return row
+}
+
+// csvSafe — =, +, -, @, sekme veya satır başı ile başlayan değerin önüne ' ekler (CSV formül enjeksiyonu).
+func csvSafe(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// apply — süzgeci sorguya ekler.
+func (f ExportFilter) apply(q *gorm.DB) *gorm.DB {
+	if f.UserID != nil {
+		q = q.Where("user_id = ?", *f.UserID)
+	}
+	if len(f.Actions) > 0 {
+		var conds []string
+		var args []any
+		for _, a := range f.Actions {
+			if prefix, ok := strings.CutSuffix(a, "*"); ok {
+				conds = append(conds, "action LIKE ?")
+				args = append(args, strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix)+"%")
+			} else {
+				conds = append(conds, "action = ?")
+				args = append(args, a)
+			}
+		}
+		q = q.Where("("+strings.Join(conds, " OR ")+")", args...)
+	}
+	if f.ResourceType != "" {
+		q = q.Where("resource_type = ?", f.ResourceType)
+	}
+	if f.ResourceID != nil {
+		q = q.Where("resource_id = ?", *f.ResourceID)
+	}
+	if f.Status != "" {
+		q = q.Where("status = ?", f.Status)
+	}
+	if !f.From.IsZero() {
+		q = q.Where("created_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		q = q.Where("created_at < ?", f.To)
+	}
+	return q
+}
+
+// parseExportFilter — sorgu parametrelerini süzgece çevirir.
+func parseExportFilter(c *gin.Context) (ExportFilter, error) {
+	var f ExportFilter
+	parseUUID := func(key string) (*uuid.UUID, error) {
+		v := c.Query(key)
+		if v == "" {
+			return nil, nil
+		}
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("geçersiz %s", key)
+		}
+		return &id, nil
+	}
+	parseTime := func(key string) (time.Time, error) {
+		v := c.Query(key)
+		if v == "" {
+			return time.Time{}, nil
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("geçersiz %s (RFC3339 bekleniyor)", key)
+		}
+		return t, nil
+	}
+
+	var err error
+	if f.UserID, err = parseUUID("user_id"); err != nil {
+		return f, err
+	}
+	if f.ResourceID, err = parseUUID("resource_id"); err != nil {
+		return f, err
+	}
+	if f.From, err = parseTime("from"); err != nil {
+		return f, err
+	}
+	if f.To, err = parseTime("to"); err != nil {
+		return f, err
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.To.After(f.From) {
+		return f, fmt.Errorf("to from'dan sonra olmalı")
+	}
+	for _, a := range strings.Split(c.Query("action"), ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			f.Actions = append(f.Actions, a)
+		}
+	}
+	f.ResourceType = c.Query("resource_type")
+	switch s := Status(c.Query("status")); s {
+	case "", StatusSuccess, StatusFailure, StatusBlocked:
+		f.Status = s
+	default:
+		return f, fmt.Errorf("geçersiz status")
+	}
+	return f, nil
+}
+
+// Export — GET /api/v1/audit/export?format=csv|jsonl&user_id=&action=&resource_type=&resource_id=&status=&from=&to=
+// Süzülen kayıtları eskiden yeniye akış halinde indirir. Auditor yetkisi olmayan kullanıcı yalnızca
+// kendi kayıtlarını aktarabilir; başka bir user_id için 403 döner.
+func (h *Handler) Export(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+
+	format := c.DefaultQuery("format", "jsonl")
+	if format != "csv" && format != "jsonl" {
+		response.BadRequest(c, "geçersiz format (csv veya jsonl)")
+		return
+	}
+	filter, err := parseExportFilter(c)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	all := false
+	if h.auditor != nil {
+		if all, err = h.auditor.CanAuditAll(c.Request.Context(), userID); err != nil {
+			response.InternalError(c, "yetki doğrulanamadı")
+			return
+		}
+	}
+	if !all {
+		if filter.UserID != nil && *filter.UserID != userID {
+			response.Forbidden(c, "yalnızca kendi audit kayıtlarınızı dışa aktarabilirsiniz")
+			return
+		}
+		filter.UserID = &userID
+	}
+
+	rows, err := filter.apply(h.db.WithContext(c.Request.Context()).Model(&Log{})).
+		Order("created_at, seq").Rows()
+	if err != nil {
+		response.InternalError(c, "audit kayıtları alınamadı")
+		return
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("audit-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+	}
+	c.Status(http.StatusOK)
+
+	var (
+		cw  *csv.Writer
+		enc *json.Encoder
+	)
+	if format == "csv" {
+		cw = csv.NewWriter(c.Writer)
+		cw.Write(csvHeader)
+	} else {
+		enc = json.NewEncoder(c.Writer)
+	}
+
+	// Yanıt başladıktan sonra hata yalnızca loglanabilir; akış yarıda kesilir.
+	const flushEvery = 500
+	count := 0
+	for rows.Next() {
+		var l Log
+		if err := h.db.ScanRows(rows, &l); err != nil {
+			c.Error(err)
+			return
+		}
+		rec := newExportRecord(&l)
+		if cw != nil {
+			err = cw.Write(rec.csvRow())
+		} else {
+			err = enc.Encode(rec)
+		}
+		if err != nil {
+			return
+		}
+		if count++; count%flushEvery == 0 {
+			if cw != nil {
+				cw.Flush()
+			}
+			c.Writer.Flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		// Okuma yarıda kesildi; eksik dosya tamamlanmış gibi bitirilmez.
+		c.Error(err)
+		Annotate(c, "exported", count)
+		Annotate(c, "error", err.Error())
+		return
+	}
+	if cw != nil {
+		cw.Flush()
+	}
+	Annotate(c, "query", c.Request.URL.RawQuery)
+	Annotate(c, "exported", count)
+}
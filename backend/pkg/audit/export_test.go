@@ -0,0 +1,27 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVRow_NeutralizesFormulas(t *testing.T) {
+	rec := newExportRecord(testRecord())
+	rec.UserAgent = "=HYPERLINK(\"http://x\",\"tıkla\")"
+	rec.ResourceType = "@SUM(A1)"
+	rec.IPAddress = "-1+2"
+
+	row := rec.csvRow()
+	assert.Equal(t, "'=HYPERLINK(\"http://x\",\"tıkla\")", row[9])
+	assert.Equal(t, "'@SUM(A1)", row[5])
+	assert.Equal(t, "'-1+2", row[8])
+	assert.Equal(t, rec.ID.String(), row[0], "güvenli değerler değişmemeli")
+	assert.Equal(t, string(rec.Details), row[10])
+
+	for _, v := range []string{"+1", "\tx", "\rx"} {
+		assert.Equal(t, "'"+v, csvSafe(v))
+	}
+	assert.Equal(t, "", csvSafe(""))
+	assert.Equal(t, "a=b", csvSafe("a=b"))
+}
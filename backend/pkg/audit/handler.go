@@ -7,6 +7,7 @@ import (
 	"nanonet-backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -14,6 +15,8 @@ import (
 // main.go'daki standalone handleAuditLogs fonksiyonu buraya taşındı.
 type Handler struct {
 	db *gorm.DB
+
+	auditor Auditor
 }
 
 // NewHandler — yeni audit handler oluşturur.
@@ -67,3 +70,55 @@ func (h *Handler) GetLogs(c *gin.Context) {
 		},
 	})
 }
+
+// Verify — GET /api/v1/audit/verify?from_seq=&head_seq=&head_hash=
+// Hash zincirini doğrular. head_seq ve head_hash daha önce alınmış bir zincir başıdır; verilirse
+// o kaydın hâlâ aynı özetle durduğu da denetlenir ve sondan silinen kayıtlar da ortaya çıkar.
+// Zincir tüm kullanıcıların kayıtlarını kapsadığından yalnızca auditor yetkisiyle çağrılabilir.
+func (h *Handler) Verify(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		response.Unauthorized(c, "geçersiz kullanıcı")
+		return
+	}
+	if h.auditor == nil {
+		response.Forbidden(c, "audit zinciri doğrulaması için yetkiniz yok")
+		return
+	}
+	all, err := h.auditor.CanAuditAll(c.Request.Context(), userID)
+	if err != nil {
+		response.InternalError(c, "yetki doğrulanamadı")
+		return
+	}
+	if !all {
+		response.Forbidden(c, "audit zinciri doğrulaması için yetkiniz yok")
+		return
+	}
+
+	var fromSeq int64
+	if v := c.Query("from_seq"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			response.BadRequest(c, "geçersiz from_seq")
+			return
+		}
+		fromSeq = n
+	}
+
+	var expect *ChainHead
+	if v := c.Query("head_seq"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 || len(c.Query("head_hash")) != 64 {
+			response.BadRequest(c, "head_seq ve 64 karakterlik head_hash birlikte verilmeli")
+			return
+		}
+		expect = &ChainHead{Seq: n, Hash: c.Query("head_hash")}
+	}
+
+	result, err := New(h.db).Verify(c.Request.Context(), fromSeq, expect)
+	if err != nil {
+		response.InternalError(c, "audit zinciri doğrulanamadı")
+		return
+	}
+	response.Success(c, result)
+}
@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAuditor map[uuid.UUID]bool
+
+func (f fakeAuditor) CanAuditAll(_ context.Context, userID uuid.UUID) (bool, error) {
+	return f[userID], nil
+}
+
+func TestVerify_RequiresAuditor(t *testing.T) {
+	user := uuid.New()
+
+	call := func(h *Handler, userID, query string) int {
+		r := gin.New()
+		r.GET("/audit/verify", func(c *gin.Context) {
+			c.Set("user_id", userID)
+			h.Verify(c)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit/verify"+query, nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, call(NewHandler(nil), "", ""))
+	assert.Equal(t, http.StatusForbidden, call(NewHandler(nil), user.String(), ""), "auditor yoksa doğrulama kapalı")
+
+	h := NewHandler(nil)
+	h.SetAuditor(fakeAuditor{uuid.New(): true})
+	assert.Equal(t, http.StatusForbidden, call(h, user.String(), ""))
+
+	// Yetkili kullanıcı parametre denetimine kadar ilerler (geçersiz from_seq → 400, DB'ye gidilmez).
+	h.SetAuditor(fakeAuditor{user: true})
+	assert.Equal(t, http.StatusBadRequest, call(h, user.String(), "?from_seq=-1"))
+}
@@ -0,0 +1,265 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// SinkFormat — SIEM'e iletilen kayıtların biçimi.
+type SinkFormat string
+
+const (
+	// FormatSyslog — RFC 5424; kayıt alanları structured data, details JSON mesaj olarak taşınır.
+	FormatSyslog SinkFormat = "syslog"
+	// FormatCEF — RFC 5424 başlığı içinde ArcSight CEF mesajı.
+	FormatCEF SinkFormat = "cef"
+)
+
+const (
+	// syslogFacility — RFC 5424 "log audit" (13).
+	syslogFacility = 13
+	// sdID — structured data kimliği; 32473 RFC 5612'de örnekler için ayrılmış kurum numarasıdır.
+	sdID         = "nanonet@32473"
+	sinkAppName  = "nanonet"
+	sinkBuffer   = 1024
+	sinkTimeout  = 5 * time.Second
+	cefVendor    = "NanoNet"
+	cefProduct   = "NanoNet"
+	cefVersion   = "1.0"
+	droppedLogAt = 100
+)
+
+// SinkConfig — SIEM toplayıcısının adresi ve iletim biçimi.
+type SinkConfig struct {
+	Network string // tcp veya udp
+	Addr    string // host:port
+	Format  SinkFormat
+}
+
+// Sink — yazılan audit kayıtlarını TCP veya UDP üzerinden bir toplayıcıya iletir. Kayıtlar
+// tamponlanır; tampon doluysa veya toplayıcıya ulaşılamıyorsa kayıt iletilmeden düşülür
+// (veritabanındaki kayıt etkilenmez, dışa aktarma ile tamamlanabilir).
+type Sink struct {
+	cfg      SinkConfig
+	hostname string
+	events   chan *Log
+	conn     net.Conn
+	dropped  atomic.Int64
+}
+
+// sink — Logger örnekleri servis başına oluşturulduğundan iletim hedefi paket seviyesindedir.
+var sink atomic.Pointer[Sink]
+
+// SetSink — bundan sonra zincire eklenen kayıtlar s'ye iletilir; nil iletimi kapatır.
+func SetSink(s *Sink) {
+	sink.Store(s)
+}
+
+// NewSink — yapılandırmayı doğrular; bağlantı ilk kayıtta kurulur.
+func NewSink(cfg SinkConfig) (*Sink, error) {
+	if cfg.Network == "" {
+		cfg.Network = "udp"
+	}
+	if cfg.Network != "tcp" && cfg.Network != "udp" {
+		return nil, fmt.Errorf("geçersiz audit sink ağı %q (tcp veya udp)", cfg.Network)
+	}
+	if cfg.Format == "" {
+		cfg.Format = FormatSyslog
+	}
+	if cfg.Format != FormatSyslog && cfg.Format != FormatCEF {
+		return nil, fmt.Errorf("geçersiz audit sink biçimi %q (syslog veya cef)", cfg.Format)
+	}
+	if _, _, err := net.SplitHostPort(cfg.Addr); err != nil {
+		return nil, fmt.Errorf("geçersiz audit sink adresi %q: %w", cfg.Addr, err)
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &Sink{cfg: cfg, hostname: hostname, events: make(chan *Log, sinkBuffer)}, nil
+}
+
+// Start — kayıtları ctx bitene kadar iletir.
+func (s *Sink) Start(ctx context.Context) {
+	defer func() {
+		if s.conn != nil {
+			s.conn.Close()
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case r := <-s.events:
+			if err := s.send(s.format(r)); err != nil {
+				log.Printf("[audit] SIEM'e iletilemedi seq=%d: %v", derefSeq(r.Seq), err)
+			}
+		}
+	}
+}
+
+// publish — kaydı bloklamadan kuyruğa ekler.
+func (s *Sink) publish(r *Log) {
+	select {
+	case s.events <- r:
+	default:
+		if n := s.dropped.Add(1); n%droppedLogAt == 1 {
+			log.Printf("[audit] SIEM kuyruğu dolu, %d kayıt düşüldü", n)
+		}
+	}
+}
+
+// send — mesajı gönderir; bağlantı kopmuşsa bir kez yeniden bağlanıp tekrar dener.
+func (s *Sink) send(msg string) error {
+	frame := []byte(msg)
+	if s.cfg.Network == "tcp" {
+		// RFC 6587 octet counting: mesaj satır sonu içerse de çerçeve bozulmaz.
+		frame = []byte(strconv.Itoa(len(msg)) + " " + msg)
+	}
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if s.conn, err = net.DialTimeout(s.cfg.Network, s.cfg.Addr, sinkTimeout); err != nil {
+				s.conn = nil
+				continue
+			}
+		}
+		s.conn.SetWriteDeadline(time.Now().Add(sinkTimeout))
+		if _, err = s.conn.Write(frame); err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+// format — kaydı yapılandırılmış biçimde RFC 5424 mesajına çevirir.
+func (s *Sink) format(r *Log) string {
+	header := fmt.Sprintf("<%d>1 %s %s %s - %s",
+		syslogFacility*8+severity(r.Status),
+		r.CreatedAt.UTC().Format(time.RFC3339Nano),
+		s.hostname, sinkAppName, syslogMsgID(string(r.Action)))
+	if s.cfg.Format == FormatCEF {
+		return header + " - " + formatCEF(r)
+	}
+	msg := canonicalJSON(r.Details)
+	if msg == "" {
+		return header + " " + structuredData(r)
+	}
+	// RFC 5424 UTF-8 mesajları BOM ile başlar.
+	return header + " " + structuredData(r) + " \ufeff" + msg
+}
+
+// severity — RFC 5424 önem derecesi: success informational, failure notice, blocked warning.
+func severity(status Status) int {
+	switch status {
+	case StatusBlocked:
+		return 4
+	case StatusFailure:
+		return 5
+	}
+	return 6
+}
+
+// syslogMsgID — MSGID en fazla 32 yazdırılabilir ASCII karakterdir.
+func syslogMsgID(action string) string {
+	if action == "" {
+		return "-"
+	}
+	if len(action) > 32 {
+		action = action[:32]
+	}
+	return action
+}
+
+func structuredData(r *Log) string {
+	params := [][2]string{
+		{"id", r.ID.String()},
+		{"seq", seqString(r.Seq)},
+		{"user_id", optionalUUID(r.UserID)},
+		{"resource_type", r.ResourceType},
+		{"resource_id", optionalUUID(r.ResourceID)},
+		{"status", string(r.Status)},
+		{"ip", r.IPAddress},
+		{"user_agent", r.UserAgent},
+		{"hash", r.Hash},
+	}
+	var b strings.Builder
+	b.WriteString("[" + sdID)
+	for _, p := range params {
+		if p[1] == "" {
+			continue
+		}
+		b.WriteString(" " + p[0] + `="` + sdEscape(p[1]) + `"`)
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// sdEscape — structured data değerinde ", \ ve ] kaçırılır.
+func sdEscape(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(v)
+}
+
+// formatCEF — CEF:Version|Vendor|Product|Version|SignatureID|Name|Severity|Extension
+func formatCEF(r *Log) string {
+	cefSeverity := map[Status]int{StatusSuccess: 3, StatusFailure: 6, StatusBlocked: 8}[r.Status]
+	ext := [][2]string{
+		{"rt", strconv.FormatInt(r.CreatedAt.UnixMilli(), 10)},
+		{"externalId", r.ID.String()},
+		{"suid", optionalUUID(r.UserID)},
+		{"src", r.IPAddress},
+		{"requestClientApplication", r.UserAgent},
+		{"outcome", string(r.Status)},
+		{"cs1Label", "resourceType"},
+		{"cs1", r.ResourceType},
+		{"cs2Label", "resourceId"},
+		{"cs2", optionalUUID(r.ResourceID)},
+		{"cs3Label", "details"},
+		{"cs3", canonicalJSON(r.Details)},
+		{"cs4Label", "hash"},
+		{"cs4", r.Hash},
+		{"cn1Label", "seq"},
+		{"cn1", seqString(r.Seq)},
+	}
+	parts := make([]string, 0, len(ext))
+	for _, e := range ext {
+		if e[1] != "" {
+			parts = append(parts, e[0]+"="+cefExtEscape(e[1]))
+		}
+	}
+	return fmt.Sprintf("CEF:0|%s|%s|%s|%s|%s|%d|%s",
+		cefVendor, cefProduct, cefVersion,
+		cefHeaderEscape(string(r.Action)), cefHeaderEscape(string(r.Action)),
+		cefSeverity, strings.Join(parts, " "))
+}
+
+func cefHeaderEscape(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `|`, `\|`).Replace(v)
+}
+
+func cefExtEscape(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r\n", `\n`, "\n", `\n`, "\r", `\r`).Replace(v)
+}
+
+func seqString(seq *int64) string {
+	if seq == nil {
+		return ""
+	}
+	return strconv.FormatInt(*seq, 10)
+}
+
+func derefSeq(seq *int64) int64 {
+	if seq == nil {
+		return 0
+	}
+	return *seq
+}
@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRecord() *Log {
+	seq := int64(42)
+	userID := uuid.New()
+	return &Log{
+		ID:           uuid.New(),
+		UserID:       &userID,
+		Action:       ActionServiceRestart,
+		ResourceType: "service",
+		IPAddress:    "10.0.0.7",
+		UserAgent:    `curl/8 "quoted" [x]`,
+		Status:       StatusBlocked,
+		Details:      []byte(`{"route":"POST /x","status_code":403}`),
+		CreatedAt:    time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC),
+		Seq:          &seq,
+		Hash:         strings.Repeat("a", 64),
+	}
+}
+
+func startSink(t *testing.T, network, addr string, format SinkFormat) {
+	t.Helper()
+	s, err := NewSink(SinkConfig{Network: network, Addr: addr, Format: format})
+	require.NoError(t, err)
+	s.hostname = "host1"
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go s.Start(ctx)
+	s.publish(testRecord())
+}
+
+func TestSink_SyslogOverTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	startSink(t, "tcp", ln.Addr().String(), FormatSyslog)
+
+	conn, err := ln.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	// RFC 6587 octet counting: "<uzunluk> <mesaj>"
+	r := bufio.NewReader(conn)
+	prefix, err := r.ReadString(' ')
+	require.NoError(t, err)
+	n, err := strconv.Atoi(strings.TrimSpace(prefix))
+	require.NoError(t, err)
+	buf := make([]byte, n)
+	_, err = io.ReadFull(r, buf)
+	require.NoError(t, err)
+	msg := string(buf)
+
+	assert.True(t, strings.HasPrefix(msg, "<108>1 2026-01-02T03:04:05.000006Z host1 nanonet - service.restart [nanonet@32473 "), msg)
+	assert.Contains(t, msg, ` seq="42"`)
+	assert.Contains(t, msg, ` status="blocked"`)
+	assert.Contains(t, msg, `user_agent="curl/8 \"quoted\" [x\]"`)
+	assert.True(t, strings.HasSuffix(msg, "] \ufeff"+`{"route":"POST /x","status_code":403}`), msg)
+}
+
+func TestSink_CEFOverUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	startSink(t, "udp", pc.LocalAddr().String(), FormatCEF)
+
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 4096)
+	n, _, err := pc.ReadFrom(buf)
+	require.NoError(t, err)
+	msg := string(buf[:n])
+
+	assert.True(t, strings.HasPrefix(msg, "<108>1 "), msg)
+	assert.Contains(t, msg, " - CEF:0|NanoNet|NanoNet|1.0|service.restart|service.restart|8|rt=")
+	assert.Contains(t, msg, "outcome=blocked")
+	assert.Contains(t, msg, `cs3={"route":"POST /x","status_code":403}`)
+	assert.Contains(t, msg, "cn1Label=seq cn1=42")
+}
+
+func TestCEFEscaping(t *testing.T) {
+	assert.Equal(t, `a\|b\\c`, cefHeaderEscape(`a|b\c`))
+	assert.Equal(t, `k\=v\nx`, cefExtEscape("k=v\nx"))
+}
+
+func TestNewSink_Validation(t *testing.T) {
+	_, err := NewSink(SinkConfig{Network: "http", Addr: "127.0.0.1:514"})
+	assert.Error(t, err)
+	_, err = NewSink(SinkConfig{Addr: "127.0.0.1:514", Format: "json"})
+	assert.Error(t, err)
+	_, err = NewSink(SinkConfig{Addr: "collector"})
+	assert.Error(t, err)
+}
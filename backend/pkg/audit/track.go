@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"maps"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	ctxBefore   = "audit.before"
+	ctxAfter    = "audit.after"
+	ctxDetails  = "audit.details"
+	ctxResource = "audit.resource_id"
+)
+
+// Track — değişiklik yapan route'lar için audit middleware'i. Handler tamamlandıktan sonra kaydı
+// yazar: 2xx success, 403 ve 429 blocked, diğer kodlar failure. Handler'lar Before/After ile
+// kaynağın değişiklikten önceki ve sonraki halini, Annotate ile ek ayrıntıları ekler. Kaynak ID'si
+// SetResource ile verilmezse :id, o da yoksa UUID olan ilk path parametresinden okunur; diğer path
+// parametreleri ayrıntılara yazılır.
+func (l *Logger) Track(action Action, resourceType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		code := c.Writer.Status()
+		status := StatusFailure
+		switch {
+		case code >= 200 && code < 300:
+			status = StatusSuccess
+		case code == http.StatusForbidden || code == http.StatusTooManyRequests:
+			status = StatusBlocked
+		}
+
+		details := map[string]any{"route": c.Request.Method + " " + c.FullPath(), "status_code": code}
+		if extra, ok := c.Get(ctxDetails); ok {
+			maps.Copy(details, extra.(map[string]any))
+		}
+		if v, ok := c.Get(ctxBefore); ok {
+			details["before"] = v
+		}
+		if v, ok := c.Get(ctxAfter); ok {
+			details["after"] = v
+		}
+		if keyID := c.GetString("api_key_id"); keyID != "" {
+			details["api_key_id"] = keyID
+		}
+
+		var resourceID *uuid.UUID
+		if v, ok := c.Get(ctxResource); ok {
+			id := v.(uuid.UUID)
+			resourceID = &id
+		} else if id, err := uuid.Parse(c.Param("id")); err == nil {
+			resourceID = &id
+		} else {
+			for _, p := range c.Params {
+				if id, err := uuid.Parse(p.Value); err == nil {
+					resourceID = &id
+					break
+				}
+			}
+		}
+		params := map[string]string{}
+		for _, p := range c.Params {
+			if p.Key != "id" {
+				params[p.Key] = p.Value
+			}
+		}
+		if len(params) > 0 {
+			details["params"] = params
+		}
+
+		var userID *uuid.UUID
+		if id, err := uuid.Parse(c.GetString("user_id")); err == nil {
+			userID = &id
+		}
+
+		l.Record(c.Request.Context(), Entry{
+			UserID:       userID,
+			Action:       action,
+			ResourceType: resourceType,
+			ResourceID:   resourceID,
+			IPAddress:    c.ClientIP(),
+			UserAgent:    c.Request.UserAgent(),
+			Status:       status,
+			Details:      details,
+		})
+	}
+}
+
+// Before — değişiklikten önceki hali Track kaydına ekler.
+func Before(c *gin.Context, v any) {
+	c.Set(ctxBefore, v)
+}
+
+// After — değişiklikten sonraki hali Track kaydına ekler.
+func After(c *gin.Context, v any) {
+	c.Set(ctxAfter, v)
+}
+
+// Annotate — Track kaydının ayrıntılarına bir alan ekler.
+func Annotate(c *gin.Context, key string, v any) {
+	extra, _ := c.Get(ctxDetails)
+	m, ok := extra.(map[string]any)
+	if !ok {
+		m = map[string]any{}
+		c.Set(ctxDetails, m)
+	}
+	m[key] = v
+}
+
+// SetResource — Track kaydının kaynak ID'sini ayarlar (ör. yeni oluşturulan kaydın ID'si).
+func SetResource(c *gin.Context, id uuid.UUID) {
+	c.Set(ctxResource, id)
+}
@@ -26,6 +26,57 @@ type Config struct {
 	SMTPFrom     string
 
 	AllowedOrigins []string
+
+	// CommandSigningKey — agent komutlarını imzalayan base64 Ed25519 seed'i (boşsa geçici anahtar üretilir).
+	CommandSigningKey   string
+	CommandSignatureTTL int
+
+	AutoRecoveryAlertTypes       []string
+	AutoRecoveryMaxPerHour       int
+	AutoRecoveryBackoffSec       int
+	AutoRecoveryMaxBackoffSec    int
+	AutoRecoveryBreakerThreshold int
+	AutoRecoveryBreakerCooldown  int
+
+	// OIDC — tek oturum açma; OIDCIssuer boşsa devre dışıdır.
+	OIDCIssuer         string
+	OIDCClientID       string
+	OIDCClientSecret   string
+	OIDCRedirectURL    string
+	OIDCScopes         []string
+	OIDCAllowedDomains []string
+	OIDCGroupsClaim    string
+	// OIDCGroupRoles — "grup=rol" çiftleri; eşleşen en yüksek rol OIDCOrgID organizasyonunda verilir.
+	OIDCGroupRoles []string
+	OIDCOrgID      string
+
+	// EmailVerificationRequired — yeni hesaplar e-posta doğrulanana kadar yalnızca hesap uçlarına erişir.
+	EmailVerificationRequired bool
+
+	// AuditOrgID — bu organizasyonun adminleri tüm kullanıcıların audit kayıtlarını dışa aktarabilir.
+	AuditOrgID string
+	// AuditSinkAddr — audit kayıtlarının iletileceği SIEM toplayıcısı (host:port); boşsa devre dışı.
+	AuditSinkAddr    string
+	AuditSinkNetwork string
+	AuditSinkFormat  string
+
+	// LLMProvider — AI analiz sağlayıcısı: anthropic veya openai (OpenAI uyumlu uç: vLLM, Ollama,
+	// llama.cpp server). Boş model ve adres alanları sağlayıcının varsayılanlarını kullanır.
+	LLMProvider          string
+	LLMBaseURL           string
+	LLMAPIKey            string
+	LLMModel             string
+	LLMDeepModel         string
+	LLMMaxTokens         int
+	LLMTimeoutSec        int
+	LLMConnectTimeoutSec int
+
+	// K8sOrgID — Kubernetes cluster'ının bağlı olduğu organizasyon; boşsa k8s uçları kapalıdır.
+	K8sOrgID string
+
+	// AgentAllowUserTokens — agent'ların kullanıcı JWT'si ile bağlanmasına izin verir (kullanımdan
+	// kaldırılıyor); kapalıyken agent'lar yalnızca servis kimlik bilgisiyle bağlanabilir.
+	AgentAllowUserTokens bool
 }
 
 func Load() *Config {
@@ -49,6 +100,46 @@ func Load() *Config {
 		SMTPPassword:   getEnv("SMTP_PASSWORD", ""),
 		SMTPFrom:       getEnv("SMTP_FROM", ""),
 		AllowedOrigins: parseAllowedOrigins(),
+
+		CommandSigningKey:   getEnv("COMMAND_SIGNING_KEY", ""),
+		CommandSignatureTTL: getEnvInt("COMMAND_SIGNATURE_TTL_SEC", 300),
+
+		AutoRecoveryAlertTypes:       getEnvList("AUTO_RECOVERY_ALERT_TYPES", []string{"service_down"}),
+		AutoRecoveryMaxPerHour:       getEnvInt("AUTO_RECOVERY_MAX_PER_HOUR", 3),
+		AutoRecoveryBackoffSec:       getEnvInt("AUTO_RECOVERY_BACKOFF_SEC", 30),
+		AutoRecoveryMaxBackoffSec:    getEnvInt("AUTO_RECOVERY_MAX_BACKOFF_SEC", 600),
+		AutoRecoveryBreakerThreshold: getEnvInt("AUTO_RECOVERY_BREAKER_THRESHOLD", 3),
+		AutoRecoveryBreakerCooldown:  getEnvInt("AUTO_RECOVERY_BREAKER_COOLDOWN_SEC", 1800),
+
+		OIDCIssuer:         getEnv("OIDC_ISSUER", ""),
+		OIDCClientID:       getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:   getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:    getEnv("OIDC_REDIRECT_URL", ""),
+		OIDCScopes:         getEnvList("OIDC_SCOPES", []string{"openid", "email", "profile"}),
+		OIDCAllowedDomains: getEnvList("OIDC_ALLOWED_DOMAINS", nil),
+		OIDCGroupsClaim:    getEnv("OIDC_GROUPS_CLAIM", "groups"),
+		OIDCGroupRoles:     getEnvList("OIDC_GROUP_ROLES", nil),
+		OIDCOrgID:          getEnv("OIDC_ORG_ID", ""),
+
+		EmailVerificationRequired: getEnv("EMAIL_VERIFICATION_REQUIRED", "true") != "false",
+
+		AuditOrgID:       getEnv("AUDIT_ORG_ID", ""),
+		AuditSinkAddr:    getEnv("AUDIT_SINK_ADDR", ""),
+		AuditSinkNetwork: getEnv("AUDIT_SINK_NETWORK", "udp"),
+		AuditSinkFormat:  getEnv("AUDIT_SINK_FORMAT", "syslog"),
+
+		LLMProvider:          getEnv("LLM_PROVIDER", "anthropic"),
+		LLMBaseURL:           getEnv("LLM_BASE_URL", ""),
+		LLMAPIKey:            getEnv("LLM_API_KEY", ""),
+		LLMModel:             getEnv("LLM_MODEL", ""),
+		LLMDeepModel:         getEnv("LLM_DEEP_MODEL", ""),
+		LLMMaxTokens:         getEnvInt("LLM_MAX_TOKENS", 2048),
+		LLMTimeoutSec:        getEnvInt("LLM_TIMEOUT_SEC", 60),
+		LLMConnectTimeoutSec: getEnvInt("LLM_CONNECT_TIMEOUT_SEC", 10),
+
+		K8sOrgID: getEnv("K8S_ORG_ID", ""),
+
+		AgentAllowUserTokens: getEnv("AGENT_ALLOW_USER_TOKENS", "false") == "true",
 	}
 
 	if cfg.DatabaseURL == "" {
@@ -60,7 +151,11 @@ func Load() *Config {
 	if len(cfg.JWTSecret) < 32 {
 		log.Fatal("JWT_SECRET en az 32 karakter olmalı")
 	}
-	if cfg.ClaudeAPIKey == "" {
+	// Anthropic için CLAUDE_API_KEY geriye dönük uyumluluk amacıyla LLM_API_KEY yerine geçer.
+	if cfg.LLMAPIKey == "" && cfg.LLMProvider == "anthropic" {
+		cfg.LLMAPIKey = cfg.ClaudeAPIKey
+	}
+	if cfg.LLMAPIKey == "" && cfg.LLMProvider == "anthropic" {
 		log.Println("Warning: CLAUDE_API_KEY ayarlanmamış, AI analiz özelliği devre dışı")
 	}
 
@@ -84,9 +179,14 @@ func getEnvInt(key string, defaultValue int) int {
 }
 
 func parseAllowedOrigins() []string {
-	val := os.Getenv("ALLOWED_ORIGINS")
+	return getEnvList("ALLOWED_ORIGINS", nil)
+}
+
+// getEnvList virgülle ayrılmış bir ortam değişkenini boşlukları temizleyerek listeye çevirir.
+func getEnvList(key string, defaultValue []string) []string {
+	val := os.Getenv(key)
 	if val == "" {
-		return nil
+		return defaultValue
 	}
 	parts := strings.Split(val, ",")
 	var result []string
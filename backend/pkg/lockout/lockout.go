@@ -0,0 +1,166 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Store keeps per-key failure counters and temporary locks.
+// Implementations must be safe for concurrent use; the Redis store shares
+// state across backend replicas.
+type Store interface {
+	// RecordFailure increments the failure counter for key and returns the new
+	// count. The counter expires window after the first failure.
+	RecordFailure(ctx context.Context, key string, window time.Duration) (int, error)
+	// Lock blocks key for ttl. An existing longer lock is not shortened.
+	Lock(ctx context.Context, key string, ttl time.Duration) error
+	// LockedFor returns the remaining lock duration, or 0 if key is not locked.
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	// Reset clears both the failure counter and the lock.
+	Reset(ctx context.Context, key string) error
+}
+
+// ──────────────────────── InMemory ────────────────────────
+
+type counter struct {
+	count     int
+	expiresAt time.Time
+}
+
+// InMemoryStore is a single-process Store. Entries are evicted once expired.
+type InMemoryStore struct {
+	mu       sync.Mutex
+	failures map[string]counter
+	locks    map[string]time.Time
+}
+
+// NewInMemory creates an in-memory store with background cleanup.
+func NewInMemory() *InMemoryStore {
+	s := &InMemoryStore{
+		failures: make(map[string]counter),
+		locks:    make(map[string]time.Time),
+	}
+	go s.cleanup()
+	return s
+}
+
+func (s *InMemoryStore) RecordFailure(_ context.Context, key string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	c, ok := s.failures[key]
+	if !ok || now.After(c.expiresAt) {
+		c = counter{expiresAt: now.Add(window)}
+	}
+	c.count++
+	s.failures[key] = c
+	return c.count, nil
+}
+
+func (s *InMemoryStore) Lock(_ context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until := time.Now().Add(ttl)
+	if until.After(s.locks[key]) {
+		s.locks[key] = until
+	}
+	return nil
+}
+
+func (s *InMemoryStore) LockedFor(_ context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if left := time.Until(s.locks[key]); left > 0 {
+		return left, nil
+	}
+	return 0, nil
+}
+
+func (s *InMemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failures, key)
+	delete(s.locks, key)
+	return nil
+}
+
+func (s *InMemoryStore) cleanup() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		now := time.Now()
+		s.mu.Lock()
+		for key, c := range s.failures {
+			if now.After(c.expiresAt) {
+				delete(s.failures, key)
+			}
+		}
+		for key, until := range s.locks {
+			if now.After(until) {
+				delete(s.locks, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// ──────────────────────── Redis ────────────────────────
+
+// RedisStore keeps counters and locks in Redis with native key expiry.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedis creates a Redis-backed store.
+func NewRedis(client *redis.Client) *RedisStore {
+	return &RedisStore{
+		client: client,
+		prefix: "nanonet:lockout:",
+	}
+}
+
+func (r *RedisStore) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	k := r.prefix + "fail:" + key
+	n, err := r.client.Incr(ctx, k).Result()
+	if err != nil {
+		return 0, err
+	}
+	if n == 1 {
+		if err := r.client.PExpire(ctx, k, window).Err(); err != nil {
+			return int(n), err
+		}
+	}
+	return int(n), nil
+}
+
+func (r *RedisStore) Lock(ctx context.Context, key string, ttl time.Duration) error {
+	k := r.prefix + "lock:" + key
+	left, err := r.client.PTTL(ctx, k).Result()
+	if err != nil {
+		return err
+	}
+	if left >= ttl {
+		return nil
+	}
+	return r.client.Set(ctx, k, "1", ttl).Err()
+}
+
+func (r *RedisStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	left, err := r.client.PTTL(ctx, r.prefix+"lock:"+key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// PTTL returns -2 for missing keys and -1 for keys without expiry.
+	if left < 0 {
+		return 0, nil
+	}
+	return left, nil
+}
+
+func (r *RedisStore) Reset(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.prefix+"fail:"+key, r.prefix+"lock:"+key).Err()
+}
@@ -3,9 +3,11 @@ package mailer
 import (
 	"crypto/tls"
 	"fmt"
+	"html"
 	"net"
 	"net/smtp"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -42,6 +44,80 @@ func (m *Mailer) SendAlert(toEmail, serviceName, alertType, message, severity st
 	return m.send(toEmail, subject, body)
 }
 
+// SendApprovalRequest notifies an approver that a destructive action awaits their decision.
+func (m *Mailer) SendApprovalRequest(toEmail, summary, requestedBy string, expiresAt time.Time) error {
+	subject := "NanoNet — Onay Bekleyen İşlem: " + summary
+	body := buildApprovalEmail(summary, requestedBy, expiresAt)
+	return m.send(toEmail, subject, body)
+}
+
+// SendTwoFactorReset sends a link that disables two-factor authentication after password confirmation.
+func (m *Mailer) SendTwoFactorReset(toEmail, resetURL string) error {
+	subject := "NanoNet — İki Adımlı Doğrulama Sıfırlama"
+	body := buildNoticeEmail(
+		"İki Adımlı Doğrulama Sıfırlama",
+		fmt.Sprintf("<strong>%s</strong> hesabı için iki adımlı doğrulamayı sıfırlama talebinde bulundunuz. Bağlantıyı açıp şifrenizi girdiğinizde iki adımlı doğrulama kapatılır; giriş yaptıktan sonra yeniden kurabilirsiniz.", html.EscapeString(toEmail)),
+		"İki Adımlı Doğrulamayı Sıfırla", resetURL,
+		"Bu bağlantı <strong>1 saat</strong> geçerlidir. Talebi siz yapmadıysanız şifrenizi değiştirin.",
+	)
+	return m.send(toEmail, subject, body)
+}
+
+// SendTwoFactorDisabled notifies a user that an organization admin reset their two-factor authentication.
+func (m *Mailer) SendTwoFactorDisabled(toEmail string) error {
+	subject := "NanoNet — İki Adımlı Doğrulamanız Kapatıldı"
+	body := buildNoticeEmail(
+		"İki Adımlı Doğrulamanız Kapatıldı",
+		fmt.Sprintf("<strong>%s</strong> hesabının iki adımlı doğrulaması bir organizasyon yöneticisi tarafından sıfırlandı. Hesabınızı korumak için giriş yaptıktan sonra yeniden kurun.", html.EscapeString(toEmail)),
+		"", "",
+		"Bu işlemden haberiniz yoksa hemen şifrenizi değiştirin ve organizasyon yöneticinizle iletişime geçin.",
+	)
+	return m.send(toEmail, subject, body)
+}
+
+// SendAccountLocked notifies the account owner that repeated failed logins locked the account.
+func (m *Mailer) SendAccountLocked(toEmail string, failures int, until time.Time, resetURL string) error {
+	subject := "NanoNet — Hesabınız Geçici Olarak Kilitlendi"
+	body := buildNoticeEmail(
+		"Hesabınız Geçici Olarak Kilitlendi",
+		fmt.Sprintf("<strong>%s</strong> hesabında art arda <strong>%d</strong> başarısız giriş denemesi oldu. Hesap <strong>%s</strong> tarihine kadar girişe kapatıldı.",
+			html.EscapeString(toEmail), failures, until.UTC().Format("2006-01-02 15:04 UTC")),
+		"Şifremi Sıfırla", resetURL,
+		"Denemeleri siz yapmadıysanız şifrenizi sıfırlayın; sıfırlama kilidi de kaldırır. Organizasyon yöneticiniz de kilidi kaldırabilir.",
+	)
+	return m.send(toEmail, subject, body)
+}
+
+// SendEmailVerification sends the link that confirms ownership of a newly registered address.
+func (m *Mailer) SendEmailVerification(toEmail, verifyURL string) error {
+	subject := "NanoNet — E-posta Adresinizi Doğrulayın"
+	body := buildNoticeEmail(
+		"E-posta Adresinizi Doğrulayın",
+		fmt.Sprintf("<strong>%s</strong> adresiyle NanoNet hesabı oluşturuldu. Hesabınızı kullanmaya başlamak için adresinizi doğrulayın.", html.EscapeString(toEmail)),
+		"E-postamı Doğrula", verifyURL,
+		"Bu bağlantı <strong>24 saat</strong> geçerlidir. Hesabı siz oluşturmadıysanız bu emaili görmezden gelin.",
+	)
+	return m.send(toEmail, subject, body)
+}
+
+// SendInvitation invites an address to join an organization with the given role.
+func (m *Mailer) SendInvitation(toEmail, orgName, invitedBy, role, inviteURL string, expiresAt time.Time) error {
+	subject := "NanoNet — " + orgName + " Organizasyonuna Davet"
+	inviter := "Bir yönetici"
+	if invitedBy != "" {
+		inviter = fmt.Sprintf("<strong>%s</strong>", html.EscapeString(invitedBy))
+	}
+	body := buildNoticeEmail(
+		"Organizasyon Daveti",
+		fmt.Sprintf("%s sizi <strong>%s</strong> organizasyonuna <strong>%s</strong> rolüyle davet etti. Hesabınız yoksa bağlantıdan <strong>%s</strong> adresiyle kayıt olabilirsiniz.",
+			inviter, html.EscapeString(orgName), html.EscapeString(role), html.EscapeString(toEmail)),
+		"Daveti Kabul Et", inviteURL,
+		fmt.Sprintf("Davet <strong>%s</strong> tarihine kadar geçerlidir. Beklemediğiniz bir davetse bu emaili görmezden gelin.",
+			expiresAt.UTC().Format("2006-01-02 15:04 UTC")),
+	)
+	return m.send(toEmail, subject, body)
+}
+
 func severityLabel(s string) string {
 	switch s {
 	case "crit":
@@ -120,6 +196,94 @@ func buildAlertEmail(toEmail, serviceName, alertType, message, severity string)
 </html>`, color, icon, color, label, color, serviceName, alertType, color, label, message)
 }
 
+func buildApprovalEmail(summary, requestedBy string, expiresAt time.Time) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="tr">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width,initial-scale=1"></head>
+<body style="margin:0;padding:0;background:#f0fbff;font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',sans-serif;">
+  <table width="100%%" cellpadding="0" cellspacing="0" style="background:#f0fbff;padding:40px 0;">
+    <tr><td align="center">
+      <table width="480" cellpadding="0" cellspacing="0" style="background:#fff;border-radius:16px;box-shadow:0 4px 24px rgba(0,0,0,0.08);overflow:hidden;">
+        <tr>
+          <td style="background:linear-gradient(135deg,#0f172a,#1e293b);padding:28px 32px;">
+            <h1 style="margin:0;color:#f1f5f9;font-size:20px;font-weight:700;">Onay Bekleyen İşlem</h1>
+            <p style="margin:4px 0 0;color:#94a3b8;font-size:12px;">Microservice Monitoring Platform</p>
+          </td>
+        </tr>
+        <tr>
+          <td style="padding:32px;">
+            <div style="background:#f8fafc;border-left:4px solid #f59e0b;border-radius:0 8px 8px 0;padding:16px 20px;margin-bottom:24px;">
+              <p style="margin:0 0 4px;font-size:11px;color:#94a3b8;text-transform:uppercase;letter-spacing:0.5px;">İşlem</p>
+              <p style="margin:0;font-size:16px;font-weight:700;color:#1e293b;font-family:monospace;">%s</p>
+            </div>
+            <p style="margin:0 0 8px;font-size:14px;color:#374151;line-height:1.6;">
+              <strong>%s</strong> bu işlem için onayınızı istiyor.
+            </p>
+            <p style="margin:0;font-size:12px;color:#94a3b8;">
+              İstek <strong>%s</strong> tarihine kadar NanoNet Dashboard'dan onaylanmazsa geçersiz olur.
+            </p>
+          </td>
+        </tr>
+        <tr>
+          <td style="padding:16px 32px;background:#f8fafc;border-top:1px solid #f1f5f9;">
+            <p style="margin:0;font-size:11px;color:#cbd5e1;">Bu email NanoNet tarafından otomatik olarak gönderilmiştir.</p>
+          </td>
+        </tr>
+      </table>
+    </td></tr>
+  </table>
+</body>
+</html>`, html.EscapeString(summary), html.EscapeString(requestedBy), expiresAt.UTC().Format("2006-01-02 15:04 UTC"))
+}
+
+// buildNoticeEmail renders a short account notice. intro and footer are trusted HTML; callers
+// escape user-provided values. The button is omitted when actionURL is empty.
+func buildNoticeEmail(title, intro, actionLabel, actionURL, footer string) string {
+	action := ""
+	if actionURL != "" {
+		action = fmt.Sprintf(`
+            <div style="text-align:center;margin:32px 0;">
+              <a href="%s" style="display:inline-block;padding:14px 36px;background:linear-gradient(135deg,#00b4d8,#a78bfa);color:#fff;text-decoration:none;border-radius:10px;font-size:15px;font-weight:600;letter-spacing:0.2px;">
+                %s
+              </a>
+            </div>
+            <p style="margin:0 0 8px;font-size:12px;color:#94a3b8;line-height:1.6;">
+              Buton çalışmıyorsa aşağıdaki bağlantıyı tarayıcınıza kopyalayın:
+            </p>
+            <p style="margin:0;font-size:11px;color:#00b4d8;word-break:break-all;">%s</p>`,
+			html.EscapeString(actionURL), html.EscapeString(actionLabel), html.EscapeString(actionURL))
+	}
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="tr">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width,initial-scale=1"></head>
+<body style="margin:0;padding:0;background:#f0fbff;font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',sans-serif;">
+  <table width="100%%" cellpadding="0" cellspacing="0" style="background:#f0fbff;padding:40px 0;">
+    <tr><td align="center">
+      <table width="480" cellpadding="0" cellspacing="0" style="background:#fff;border-radius:16px;box-shadow:0 4px 24px rgba(0,180,216,0.10);overflow:hidden;">
+        <tr>
+          <td style="background:linear-gradient(135deg,#00b4d8,#a78bfa);padding:32px;text-align:center;">
+            <h1 style="margin:0;color:#fff;font-size:22px;font-weight:700;letter-spacing:-0.5px;">NanoNet</h1>
+            <p style="margin:4px 0 0;color:rgba(255,255,255,0.8);font-size:13px;">Microservice Monitoring Platform</p>
+          </td>
+        </tr>
+        <tr>
+          <td style="padding:36px 40px;">
+            <h2 style="margin:0 0 8px;font-size:18px;color:#1e293b;font-weight:600;">%s</h2>
+            <p style="margin:0 0 24px;font-size:14px;color:#64748b;line-height:1.6;">%s</p>%s
+          </td>
+        </tr>
+        <tr>
+          <td style="padding:20px 40px;border-top:1px solid #f1f5f9;background:#fafcff;">
+            <p style="margin:0;font-size:12px;color:#94a3b8;line-height:1.6;">%s</p>
+          </td>
+        </tr>
+      </table>
+    </td></tr>
+  </table>
+</body>
+</html>`, html.EscapeString(title), intro, action, footer)
+}
+
 func (m *Mailer) send(to, subject, htmlBody string) error {
 	from := m.cfg.From
 	if from == "" {